@@ -50,12 +50,28 @@ func (src *AzureCluster) ConvertTo(dstRaw conversion.Hub) error { // nolint
 	}
 
 	dst.Spec.NetworkSpec.PrivateDNSZoneName = restored.Spec.NetworkSpec.PrivateDNSZoneName
+	dst.Spec.NetworkSpec.PrivateDNSZoneResourceGroup = restored.Spec.NetworkSpec.PrivateDNSZoneResourceGroup
+	dst.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID = restored.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID
 
 	dst.Spec.NetworkSpec.APIServerLB.FrontendIPsCount = restored.Spec.NetworkSpec.APIServerLB.FrontendIPsCount
 	dst.Spec.NetworkSpec.APIServerLB.IdleTimeoutInMinutes = restored.Spec.NetworkSpec.APIServerLB.IdleTimeoutInMinutes
+	dst.Spec.NetworkSpec.APIServerLB.AllocatedOutboundPorts = restored.Spec.NetworkSpec.APIServerLB.AllocatedOutboundPorts
+	dst.Spec.NetworkSpec.APIServerLB.ExpectedBackendPoolSize = restored.Spec.NetworkSpec.APIServerLB.ExpectedBackendPoolSize
 	dst.Spec.NetworkSpec.NodeOutboundLB = restored.Spec.NetworkSpec.NodeOutboundLB
 	dst.Spec.CloudProviderConfigOverrides = restored.Spec.CloudProviderConfigOverrides
 	dst.Spec.BastionSpec = restored.Spec.BastionSpec
+	dst.Spec.IdentityPermissions = restored.Spec.IdentityPermissions
+	dst.Spec.CloudProviderIdentity = restored.Spec.CloudProviderIdentity
+	dst.Spec.DriftDetection = restored.Spec.DriftDetection
+	dst.Spec.EnforceTags = restored.Spec.EnforceTags
+	dst.Spec.ServiceIdentityRefs = restored.Spec.ServiceIdentityRefs
+	dst.Spec.DeletionPolicy = restored.Spec.DeletionPolicy
+	dst.Spec.AzureResourceNaming = restored.Spec.AzureResourceNaming
+	dst.Spec.FailureDomainOverrides = restored.Spec.FailureDomainOverrides
+	dst.Spec.DisabledServices = restored.Spec.DisabledServices
+	dst.Status.Resources = restored.Status.Resources
+	dst.Status.ServiceSpecHashes = restored.Status.ServiceSpecHashes
+	dst.Status.Services = restored.Status.Services
 
 	// Here we manually restore outbound security rules. Since v1alpha3 only supports ingress ("Inbound") rules, all v1alpha4 outbound rules are dropped when an AzureCluster
 	// is converted to v1alpha3. We loop through all security group rules. For all previously existing outbound rules we restore the full rule.
@@ -70,6 +86,7 @@ func (src *AzureCluster) ConvertTo(dstRaw conversion.Hub) error { // nolint
 					}
 				}
 				dst.Spec.NetworkSpec.Subnets[i].SecurityGroup.SecurityRules = append(dst.Spec.NetworkSpec.Subnets[i].SecurityGroup.SecurityRules, restoredOutboundRules...)
+				dst.Spec.NetworkSpec.Subnets[i].ServiceEndpoints = restoredSubnet.ServiceEndpoints
 				break
 			}
 		}
@@ -284,3 +301,8 @@ func Convert_v1alpha3_VnetSpec_To_v1alpha4_VnetSpec(in *VnetSpec, out *infrav1al
 func Convert_v1alpha4_LoadBalancerSpec_To_v1alpha3_LoadBalancerSpec(in *infrav1alpha4.LoadBalancerSpec, out *LoadBalancerSpec, s apiconversion.Scope) error {
 	return autoConvert_v1alpha4_LoadBalancerSpec_To_v1alpha3_LoadBalancerSpec(in, out, s)
 }
+
+// Convert_v1alpha4_Future_To_v1alpha3_Future converts from the Hub version (v1alpha4) of the Future to this version.
+func Convert_v1alpha4_Future_To_v1alpha3_Future(in *infrav1alpha4.Future, out *Future, s apiconversion.Scope) error {
+	return autoConvert_v1alpha4_Future_To_v1alpha3_Future(in, out, s)
+}
@@ -44,6 +44,11 @@ func (src *AzureClusterIdentity) ConvertTo(dstRaw conversion.Hub) error { // nol
 		dst.Spec.AllowedNamespaces.Selector = restored.Spec.AllowedNamespaces.Selector
 	}
 
+	dst.Spec.AuxiliaryTenantIDs = restored.Spec.AuxiliaryTenantIDs
+	dst.Spec.WorkloadIdentity = restored.Spec.WorkloadIdentity
+	dst.Spec.KeyVaultCertificate = restored.Spec.KeyVaultCertificate
+	dst.Spec.ProvisionRoleAssignments = restored.Spec.ProvisionRoleAssignments
+
 	return nil
 }
 
@@ -37,6 +37,12 @@ func (src *AzureMachine) ConvertTo(dstRaw conversion.Hub) error { // nolint
 		return err
 	}
 
+	dst.Spec.SSHPublicKeySecret = restored.Spec.SSHPublicKeySecret
+	dst.Spec.AdminPasswordSecret = restored.Spec.AdminPasswordSecret
+	dst.Spec.AdditionalCustomDataSecret = restored.Spec.AdditionalCustomDataSecret
+	dst.Spec.DeletionPolicy = restored.Spec.DeletionPolicy
+	dst.Status.Resources = restored.Status.Resources
+
 	// Handle special case for conversion of ManagedDisk to pointer.
 	if restored.Spec.OSDisk.ManagedDisk == nil && dst.Spec.OSDisk.ManagedDisk != nil {
 		if *dst.Spec.OSDisk.ManagedDisk == (v1alpha4.ManagedDiskParameters{}) {
@@ -36,6 +36,11 @@ func (src *AzureMachineTemplate) ConvertTo(dstRaw conversion.Hub) error { // nol
 		return err
 	}
 
+	dst.Spec.Template.Spec.SSHPublicKeySecret = restored.Spec.Template.Spec.SSHPublicKeySecret
+	dst.Spec.Template.Spec.AdminPasswordSecret = restored.Spec.Template.Spec.AdminPasswordSecret
+	dst.Spec.Template.Spec.AdditionalCustomDataSecret = restored.Spec.Template.Spec.AdditionalCustomDataSecret
+	dst.Spec.Template.Spec.DeletionPolicy = restored.Spec.Template.Spec.DeletionPolicy
+
 	// Handle special case for conversion of ManagedDisk to pointer.
 	if restored.Spec.Template.Spec.OSDisk.ManagedDisk == nil && dst.Spec.Template.Spec.OSDisk.ManagedDisk != nil {
 		if *dst.Spec.Template.Spec.OSDisk.ManagedDisk == (infrav1alpha4.ManagedDiskParameters{}) {
@@ -235,11 +235,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha4.Future)(nil), (*Future)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha4_Future_To_v1alpha3_Future(a.(*v1alpha4.Future), b.(*Future), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*Image)(nil), (*v1alpha4.Image)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_Image_To_v1alpha4_Image(a.(*Image), b.(*v1alpha4.Image), scope)
 	}); err != nil {
@@ -410,6 +405,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha4.Future)(nil), (*Future)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_Future_To_v1alpha3_Future(a.(*v1alpha4.Future), b.(*Future), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*v1alpha4.LoadBalancerSpec)(nil), (*LoadBalancerSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_LoadBalancerSpec_To_v1alpha3_LoadBalancerSpec(a.(*v1alpha4.LoadBalancerSpec), b.(*LoadBalancerSpec), scope)
 	}); err != nil {
@@ -597,7 +597,11 @@ func autoConvert_v1alpha4_AzureClusterIdentitySpec_To_v1alpha3_AzureClusterIdent
 	out.ClientID = in.ClientID
 	out.ClientSecret = in.ClientSecret
 	out.TenantID = in.TenantID
+	// WARNING: in.AuxiliaryTenantIDs requires manual conversion: does not exist in peer-type
 	// WARNING: in.AllowedNamespaces requires manual conversion: inconvertible types (*sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4.AllowedNamespaces vs []string)
+	// WARNING: in.WorkloadIdentity requires manual conversion: does not exist in peer-type
+	// WARNING: in.KeyVaultCertificate requires manual conversion: does not exist in peer-type
+	// WARNING: in.ProvisionRoleAssignments requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -690,9 +694,18 @@ func autoConvert_v1alpha4_AzureClusterSpec_To_v1alpha3_AzureClusterSpec(in *v1al
 	}
 	out.AdditionalTags = *(*Tags)(unsafe.Pointer(&in.AdditionalTags))
 	out.IdentityRef = (*v1.ObjectReference)(unsafe.Pointer(in.IdentityRef))
+	// WARNING: in.ServiceIdentityRefs requires manual conversion: does not exist in peer-type
+	// WARNING: in.IdentityPermissions requires manual conversion: does not exist in peer-type
+	// WARNING: in.CloudProviderIdentity requires manual conversion: does not exist in peer-type
+	// WARNING: in.DriftDetection requires manual conversion: does not exist in peer-type
+	// WARNING: in.EnforceTags requires manual conversion: does not exist in peer-type
 	// WARNING: in.AzureEnvironment requires manual conversion: does not exist in peer-type
 	// WARNING: in.BastionSpec requires manual conversion: does not exist in peer-type
 	// WARNING: in.CloudProviderConfigOverrides requires manual conversion: does not exist in peer-type
+	// WARNING: in.DeletionPolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.AzureResourceNaming requires manual conversion: does not exist in peer-type
+	// WARNING: in.FailureDomainOverrides requires manual conversion: does not exist in peer-type
+	// WARNING: in.DisabledServices requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -707,6 +720,9 @@ func autoConvert_v1alpha4_AzureClusterStatus_To_v1alpha3_AzureClusterStatus(in *
 	out.FailureDomains = *(*apiv1alpha3.FailureDomains)(unsafe.Pointer(&in.FailureDomains))
 	out.Ready = in.Ready
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
+	// WARNING: in.Resources requires manual conversion: does not exist in peer-type
+	// WARNING: in.ServiceSpecHashes requires manual conversion: does not exist in peer-type
+	// WARNING: in.Services requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -841,12 +857,16 @@ func autoConvert_v1alpha4_AzureMachineSpec_To_v1alpha3_AzureMachineSpec(in *v1al
 		out.DataDisks = nil
 	}
 	out.SSHPublicKey = in.SSHPublicKey
+	// WARNING: in.SSHPublicKeySecret requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdminPasswordSecret requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdditionalCustomDataSecret requires manual conversion: does not exist in peer-type
 	out.AdditionalTags = *(*Tags)(unsafe.Pointer(&in.AdditionalTags))
 	out.AllocatePublicIP = in.AllocatePublicIP
 	out.EnableIPForwarding = in.EnableIPForwarding
 	out.AcceleratedNetworking = (*bool)(unsafe.Pointer(in.AcceleratedNetworking))
 	out.SpotVMOptions = (*SpotVMOptions)(unsafe.Pointer(in.SpotVMOptions))
 	out.SecurityProfile = (*SecurityProfile)(unsafe.Pointer(in.SecurityProfile))
+	// WARNING: in.DeletionPolicy requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -867,6 +887,7 @@ func autoConvert_v1alpha4_AzureMachineStatus_To_v1alpha3_AzureMachineStatus(in *
 	out.FailureReason = (*errors.MachineStatusError)(unsafe.Pointer(in.FailureReason))
 	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
+	// WARNING: in.Resources requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1198,14 +1219,11 @@ func autoConvert_v1alpha4_Future_To_v1alpha3_Future(in *v1alpha4.Future, out *Fu
 	out.ResourceGroup = in.ResourceGroup
 	out.Name = in.Name
 	out.FutureData = in.FutureData
+	// WARNING: in.StartTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.ParametersHash requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1alpha4_Future_To_v1alpha3_Future is an autogenerated conversion function.
-func Convert_v1alpha4_Future_To_v1alpha3_Future(in *v1alpha4.Future, out *Future, s conversion.Scope) error {
-	return autoConvert_v1alpha4_Future_To_v1alpha3_Future(in, out, s)
-}
-
 func autoConvert_v1alpha3_Image_To_v1alpha4_Image(in *Image, out *v1alpha4.Image, s conversion.Scope) error {
 	out.ID = (*string)(unsafe.Pointer(in.ID))
 	out.SharedGallery = (*v1alpha4.AzureSharedGalleryImage)(unsafe.Pointer(in.SharedGallery))
@@ -1252,6 +1270,8 @@ func autoConvert_v1alpha4_LoadBalancerSpec_To_v1alpha3_LoadBalancerSpec(in *v1al
 	out.Type = LBType(in.Type)
 	// WARNING: in.FrontendIPsCount requires manual conversion: does not exist in peer-type
 	// WARNING: in.IdleTimeoutInMinutes requires manual conversion: does not exist in peer-type
+	// WARNING: in.AllocatedOutboundPorts requires manual conversion: does not exist in peer-type
+	// WARNING: in.ExpectedBackendPoolSize requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1296,6 +1316,8 @@ func autoConvert_v1alpha4_NetworkSpec_To_v1alpha3_NetworkSpec(in *v1alpha4.Netwo
 	}
 	// WARNING: in.NodeOutboundLB requires manual conversion: does not exist in peer-type
 	// WARNING: in.PrivateDNSZoneName requires manual conversion: does not exist in peer-type
+	// WARNING: in.PrivateDNSZoneResourceGroup requires manual conversion: does not exist in peer-type
+	// WARNING: in.PrivateDNSZoneSubscriptionID requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -1426,6 +1448,7 @@ func autoConvert_v1alpha4_SubnetSpec_To_v1alpha3_SubnetSpec(in *v1alpha4.SubnetS
 	if err := Convert_v1alpha4_RouteTable_To_v1alpha3_RouteTable(&in.RouteTable, &out.RouteTable, s); err != nil {
 		return err
 	}
+	// WARNING: in.ServiceEndpoints requires manual conversion: does not exist in peer-type
 	return nil
 }
 
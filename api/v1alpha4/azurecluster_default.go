@@ -45,6 +45,8 @@ func (c *AzureCluster) setDefaults() {
 	c.setResourceGroupDefault()
 	c.setAzureEnvironmentDefault()
 	c.setNetworkSpecDefaults()
+	c.setCloudProviderIdentityDefaults()
+	c.setDriftDetectionDefaults()
 }
 
 func (c *AzureCluster) setNetworkSpecDefaults() {
@@ -67,12 +69,24 @@ func (c *AzureCluster) setAzureEnvironmentDefault() {
 	}
 }
 
+func (c *AzureCluster) setCloudProviderIdentityDefaults() {
+	if c.Spec.CloudProviderIdentity != nil && c.Spec.CloudProviderIdentity.Name == "" {
+		c.Spec.CloudProviderIdentity.Name = c.resourceName(CloudProviderIdentityKind, generateCloudProviderIdentityName(c.ObjectMeta.Name))
+	}
+}
+
+func (c *AzureCluster) setDriftDetectionDefaults() {
+	if c.Spec.DriftDetection != nil && c.Spec.DriftDetection.Mode == "" {
+		c.Spec.DriftDetection.Mode = DriftDetectionModeRepair
+	}
+}
+
 func (c *AzureCluster) setVnetDefaults() {
 	if c.Spec.NetworkSpec.Vnet.ResourceGroup == "" {
 		c.Spec.NetworkSpec.Vnet.ResourceGroup = c.Spec.ResourceGroup
 	}
 	if c.Spec.NetworkSpec.Vnet.Name == "" {
-		c.Spec.NetworkSpec.Vnet.Name = generateVnetName(c.ObjectMeta.Name)
+		c.Spec.NetworkSpec.Vnet.Name = c.resourceName(VirtualNetworkKind, generateVnetName(c.ObjectMeta.Name))
 	}
 	if len(c.Spec.NetworkSpec.Vnet.CIDRBlocks) == 0 {
 		c.Spec.NetworkSpec.Vnet.CIDRBlocks = []string{DefaultVnetCIDR}
@@ -93,28 +107,28 @@ func (c *AzureCluster) setSubnetDefaults() {
 	}
 
 	if cpSubnet.Name == "" {
-		cpSubnet.Name = generateControlPlaneSubnetName(c.ObjectMeta.Name)
+		cpSubnet.Name = c.resourceName(ControlPlaneSubnetKind, generateControlPlaneSubnetName(c.ObjectMeta.Name))
 	}
 	if len(cpSubnet.CIDRBlocks) == 0 {
 		cpSubnet.CIDRBlocks = []string{DefaultControlPlaneSubnetCIDR}
 	}
 	if cpSubnet.SecurityGroup.Name == "" {
-		cpSubnet.SecurityGroup.Name = generateControlPlaneSecurityGroupName(c.ObjectMeta.Name)
+		cpSubnet.SecurityGroup.Name = c.resourceName(ControlPlaneSecurityGroupKind, generateControlPlaneSecurityGroupName(c.ObjectMeta.Name))
 	}
 	setSecurityRuleDefaults(&cpSubnet.SecurityGroup)
 
 	if nodeSubnet.Name == "" {
-		nodeSubnet.Name = generateNodeSubnetName(c.ObjectMeta.Name)
+		nodeSubnet.Name = c.resourceName(NodeSubnetKind, generateNodeSubnetName(c.ObjectMeta.Name))
 	}
 	if len(nodeSubnet.CIDRBlocks) == 0 {
 		nodeSubnet.CIDRBlocks = []string{DefaultNodeSubnetCIDR}
 	}
 	if nodeSubnet.SecurityGroup.Name == "" {
-		nodeSubnet.SecurityGroup.Name = generateNodeSecurityGroupName(c.ObjectMeta.Name)
+		nodeSubnet.SecurityGroup.Name = c.resourceName(NodeSecurityGroupKind, generateNodeSecurityGroupName(c.ObjectMeta.Name))
 	}
 	setSecurityRuleDefaults(&nodeSubnet.SecurityGroup)
 	if nodeSubnet.RouteTable.Name == "" {
-		nodeSubnet.RouteTable.Name = generateNodeRouteTableName(c.ObjectMeta.Name)
+		nodeSubnet.RouteTable.Name = c.resourceName(NodeRouteTableKind, generateNodeRouteTableName(c.ObjectMeta.Name))
 	}
 
 	c.Spec.NetworkSpec.UpdateControlPlaneSubnet(cpSubnet)
@@ -143,21 +157,21 @@ func (c *AzureCluster) setAPIServerLBDefaults() {
 
 	if lb.Type == Public {
 		if lb.Name == "" {
-			lb.Name = generatePublicLBName(c.ObjectMeta.Name)
+			lb.Name = c.resourceName(PublicLoadBalancerKind, generatePublicLBName(c.ObjectMeta.Name))
 		}
 		if len(lb.FrontendIPs) == 0 {
 			lb.FrontendIPs = []FrontendIP{
 				{
 					Name: generateFrontendIPConfigName(lb.Name),
 					PublicIP: &PublicIPSpec{
-						Name: generatePublicIPName(c.ObjectMeta.Name),
+						Name: c.resourceName(APIServerPublicIPKind, generatePublicIPName(c.ObjectMeta.Name)),
 					},
 				},
 			}
 		}
 	} else if lb.Type == Internal {
 		if lb.Name == "" {
-			lb.Name = generateInternalLBName(c.ObjectMeta.Name)
+			lb.Name = c.resourceName(InternalLoadBalancerKind, generateInternalLBName(c.ObjectMeta.Name))
 		}
 		if len(lb.FrontendIPs) == 0 {
 			lb.FrontendIPs = []FrontendIP{
@@ -172,7 +186,7 @@ func (c *AzureCluster) setAPIServerLBDefaults() {
 
 func (c *AzureCluster) setNodeOutboundLBDefaults() {
 	if c.Spec.NetworkSpec.NodeOutboundLB == nil {
-		if c.Spec.NetworkSpec.APIServerLB.Type == Internal {
+		if c.Spec.NetworkSpec.APIServerLB.Type == Internal || c.ServiceDisabled(NodeOutboundLBManagedService) {
 			return
 		}
 		c.Spec.NetworkSpec.NodeOutboundLB = &LoadBalancerSpec{}
@@ -199,7 +213,7 @@ func (c *AzureCluster) setNodeOutboundLBDefaults() {
 			{
 				Name: generateFrontendIPConfigName(lb.Name),
 				PublicIP: &PublicIPSpec{
-					Name: generateNodeOutboundIPName(c.ObjectMeta.Name),
+					Name: c.resourceName(NodeOutboundPublicIPKind, generateNodeOutboundIPName(c.ObjectMeta.Name)),
 				},
 			},
 		}
@@ -209,7 +223,7 @@ func (c *AzureCluster) setNodeOutboundLBDefaults() {
 			lb.FrontendIPs[i] = FrontendIP{
 				Name: withIndex(generateFrontendIPConfigName(lb.Name), i+1),
 				PublicIP: &PublicIPSpec{
-					Name: withIndex(generateNodeOutboundIPName(c.ObjectMeta.Name), i+1),
+					Name: withIndex(c.resourceName(NodeOutboundPublicIPKind, generateNodeOutboundIPName(c.ObjectMeta.Name)), i+1),
 				},
 			}
 		}
@@ -219,7 +233,7 @@ func (c *AzureCluster) setNodeOutboundLBDefaults() {
 func (c *AzureCluster) setBastionDefaults() {
 	if c.Spec.BastionSpec.AzureBastion != nil {
 		if c.Spec.BastionSpec.AzureBastion.Name == "" {
-			c.Spec.BastionSpec.AzureBastion.Name = generateAzureBastionName(c.ObjectMeta.Name)
+			c.Spec.BastionSpec.AzureBastion.Name = c.resourceName(BastionKind, generateAzureBastionName(c.ObjectMeta.Name))
 		}
 		// Ensure defaults for the Subnet settings.
 		{
@@ -233,17 +247,27 @@ func (c *AzureCluster) setBastionDefaults() {
 		// Ensure defaults for the PublicIP settings.
 		{
 			if c.Spec.BastionSpec.AzureBastion.PublicIP.Name == "" {
-				c.Spec.BastionSpec.AzureBastion.PublicIP.Name = generateAzureBastionPublicIPName(c.ObjectMeta.Name)
+				c.Spec.BastionSpec.AzureBastion.PublicIP.Name = c.resourceName(BastionPublicIPKind, generateAzureBastionPublicIPName(c.ObjectMeta.Name))
 			}
 		}
 	}
 }
 
+// resourceName returns the name of a resource of the cluster of a kind, following the naming of the cluster.
+func (c *AzureCluster) resourceName(kind AzureResourceKind, defaultName string) string {
+	return c.Spec.AzureResourceNaming.ResourceName(kind, defaultName, c.ObjectMeta.Name, "")
+}
+
 // generateVnetName generates a virtual network name, based on the cluster name.
 func generateVnetName(clusterName string) string {
 	return fmt.Sprintf("%s-%s", clusterName, "vnet")
 }
 
+// generateCloudProviderIdentityName generates a user-assigned identity name for the cloud provider, based on the cluster name.
+func generateCloudProviderIdentityName(clusterName string) string {
+	return fmt.Sprintf("%s-%s", clusterName, "cloud-provider")
+}
+
 // generateControlPlaneSubnetName generates a node subnet name, based on the cluster name.
 func generateControlPlaneSubnetName(clusterName string) string {
 	return fmt.Sprintf("%s-%s", clusterName, "controlplane-subnet")
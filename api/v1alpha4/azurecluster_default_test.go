@@ -20,8 +20,10 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -825,6 +827,31 @@ func TestNodeOutboundLBDefaults(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "no lb when the node outbound lb service is disabled",
+			cluster: &AzureCluster{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					DisabledServices: []ManagedService{NodeOutboundLBManagedService},
+					NetworkSpec: NetworkSpec{
+						APIServerLB: LoadBalancerSpec{Type: Public},
+					},
+				},
+			},
+			output: &AzureCluster{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "cluster-test",
+				},
+				Spec: AzureClusterSpec{
+					DisabledServices: []ManagedService{NodeOutboundLBManagedService},
+					NetworkSpec: NetworkSpec{
+						APIServerLB: LoadBalancerSpec{Type: Public},
+					},
+				},
+			},
+		},
 		{
 			name: "no lb for private clusters",
 			cluster: &AzureCluster{
@@ -1118,3 +1145,116 @@ func TestBastionDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudProviderIdentityDefaults(t *testing.T) {
+	cases := map[string]struct {
+		cluster *AzureCluster
+		output  *AzureCluster
+	}{
+		"no cloud provider identity": {
+			cluster: &AzureCluster{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+			output:  &AzureCluster{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+		},
+		"default cloud provider identity name": {
+			cluster: &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       AzureClusterSpec{CloudProviderIdentity: &CloudProviderIdentity{}},
+			},
+			output: &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       AzureClusterSpec{CloudProviderIdentity: &CloudProviderIdentity{Name: "foo-cloud-provider"}},
+			},
+		},
+		"custom cloud provider identity name": {
+			cluster: &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       AzureClusterSpec{CloudProviderIdentity: &CloudProviderIdentity{Name: "my-identity"}},
+			},
+			output: &AzureCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Spec:       AzureClusterSpec{CloudProviderIdentity: &CloudProviderIdentity{Name: "my-identity"}},
+			},
+		},
+	}
+
+	for name := range cases {
+		c := cases[name]
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c.cluster.setCloudProviderIdentityDefaults()
+			if !reflect.DeepEqual(c.cluster, c.output) {
+				expected, _ := json.MarshalIndent(c.output, "", "\t")
+				actual, _ := json.MarshalIndent(c.cluster, "", "\t")
+				t.Errorf("Expected %s, got %s", string(expected), string(actual))
+			}
+		})
+	}
+}
+
+func TestAzureResourceNamingDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &AzureCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec: AzureClusterSpec{
+			CloudProviderIdentity: &CloudProviderIdentity{},
+			BastionSpec:           BastionSpec{AzureBastion: &AzureBastion{}},
+			AzureResourceNaming: &AzureResourceNaming{
+				Prefix: "weu-",
+				Templates: []AzureResourceNamingTemplate{
+					{Kind: VirtualNetworkKind, Pattern: "vnet-{cluster}"},
+					{Kind: PublicLoadBalancerKind, Pattern: "lbe-{cluster}-apiserver"},
+					{Kind: NodeOutboundPublicIPKind, Pattern: "pip-{cluster}-egress"},
+				},
+			},
+		},
+	}
+	cluster.setDefaults()
+
+	g.Expect(cluster.Spec.CloudProviderIdentity.Name).To(Equal("weu-foo-cloud-provider"))
+	g.Expect(cluster.Spec.NetworkSpec.Vnet.Name).To(Equal("weu-vnet-foo"))
+	g.Expect(cluster.Spec.NetworkSpec.Subnets[0].Name).To(Equal("weu-foo-controlplane-subnet"))
+	g.Expect(cluster.Spec.NetworkSpec.Subnets[0].SecurityGroup.Name).To(Equal("weu-foo-controlplane-nsg"))
+	g.Expect(cluster.Spec.NetworkSpec.Subnets[1].Name).To(Equal("weu-foo-node-subnet"))
+	g.Expect(cluster.Spec.NetworkSpec.Subnets[1].SecurityGroup.Name).To(Equal("weu-foo-node-nsg"))
+	g.Expect(cluster.Spec.NetworkSpec.Subnets[1].RouteTable.Name).To(Equal("weu-foo-node-routetable"))
+	g.Expect(cluster.Spec.NetworkSpec.APIServerLB.Name).To(Equal("weu-lbe-foo-apiserver"))
+	g.Expect(cluster.Spec.NetworkSpec.APIServerLB.FrontendIPs[0].Name).To(Equal("weu-lbe-foo-apiserver-frontEnd"))
+	g.Expect(cluster.Spec.NetworkSpec.APIServerLB.FrontendIPs[0].PublicIP.Name).To(Equal("weu-pip-foo-apiserver"))
+	g.Expect(cluster.Spec.NetworkSpec.NodeOutboundLB.FrontendIPs[0].PublicIP.Name).To(Equal("weu-pip-foo-egress"))
+	g.Expect(cluster.Spec.BastionSpec.AzureBastion.Name).To(Equal("weu-foo-azure-bastion"))
+	g.Expect(cluster.Spec.BastionSpec.AzureBastion.PublicIP.Name).To(Equal("weu-foo-azure-bastion-pip"))
+}
+
+func TestDriftDetectionDefaults(t *testing.T) {
+	cases := map[string]struct {
+		cluster *AzureCluster
+		output  *AzureCluster
+	}{
+		"no drift detection": {
+			cluster: &AzureCluster{},
+			output:  &AzureCluster{},
+		},
+		"default mode": {
+			cluster: &AzureCluster{Spec: AzureClusterSpec{DriftDetection: &DriftDetection{Interval: metav1.Duration{Duration: time.Hour}}}},
+			output:  &AzureCluster{Spec: AzureClusterSpec{DriftDetection: &DriftDetection{Interval: metav1.Duration{Duration: time.Hour}, Mode: DriftDetectionModeRepair}}},
+		},
+		"custom mode": {
+			cluster: &AzureCluster{Spec: AzureClusterSpec{DriftDetection: &DriftDetection{Interval: metav1.Duration{Duration: time.Hour}, Mode: DriftDetectionModeReport}}},
+			output:  &AzureCluster{Spec: AzureClusterSpec{DriftDetection: &DriftDetection{Interval: metav1.Duration{Duration: time.Hour}, Mode: DriftDetectionModeReport}}},
+		},
+	}
+
+	for name := range cases {
+		c := cases[name]
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c.cluster.setDriftDetectionDefaults()
+			if !reflect.DeepEqual(c.cluster, c.output) {
+				expected, _ := json.MarshalIndent(c.output, "", "\t")
+				actual, _ := json.MarshalIndent(c.cluster, "", "\t")
+				t.Errorf("Expected %s, got %s", string(expected), string(actual))
+			}
+		})
+	}
+}
@@ -29,6 +29,34 @@ const (
 
 	// ClusterLabelNamespace indicates the namespace of the cluster.
 	ClusterLabelNamespace = "azurecluster.infrastructure.cluster.x-k8s.io/cluster-namespace"
+
+	// CheckIdentityPermissionsAnnotation makes the controller check the permissions of the cluster identity on the next
+	// reconciliation when set on an AzureCluster, instead of reusing the result of an earlier check. The annotation is
+	// removed once the permissions are checked.
+	CheckIdentityPermissionsAnnotation = "azurecluster.infrastructure.cluster.x-k8s.io/check-identity-permissions"
+
+	// ServiceReconcileTimeoutAnnotation overrides, for an AzureCluster and its machines, how long the reconciliation of
+	// a single Azure service may take, e.g. "45m".
+	ServiceReconcileTimeoutAnnotation = "azurecluster.infrastructure.cluster.x-k8s.io/service-reconcile-timeout"
+
+	// AzureCallTimeoutAnnotation overrides, for an AzureCluster and its machines, how long a single call to Azure,
+	// including waiting for a long running operation to complete, may take, e.g. "20m".
+	AzureCallTimeoutAnnotation = "azurecluster.infrastructure.cluster.x-k8s.io/azure-call-timeout"
+
+	// DryRunAnnotation makes the controller only log the changes it would make to the Azure resources of an
+	// AzureCluster and its machines, without making them, when set to "true" on the AzureCluster.
+	DryRunAnnotation = "azurecluster.infrastructure.cluster.x-k8s.io/dry-run"
+
+	// ReadOnlyAnnotation makes the controller refuse to change the Azure resources of an AzureCluster and its
+	// machines, while still reporting their observed state and drift, when set to "true" on the AzureCluster. It
+	// suits identities with only the Reader role.
+	ReadOnlyAnnotation = "azurecluster.infrastructure.cluster.x-k8s.io/read-only"
+
+	// SkipServiceAnnotationPrefix is the prefix of the annotations pausing the reconciliation of a single Azure service
+	// of an AzureCluster and its machines, while the other services are still reconciled. Set to "true" on the
+	// AzureCluster, e.g. "azure.cluster.x-k8s.io/skip-loadbalancers", the service neither creates, updates nor deletes
+	// its resources until the annotation is removed.
+	SkipServiceAnnotationPrefix = "azure.cluster.x-k8s.io/skip-"
 )
 
 // AzureClusterSpec defines the desired state of AzureCluster.
@@ -57,6 +85,34 @@ type AzureClusterSpec struct {
 	// +optional
 	IdentityRef *corev1.ObjectReference `json:"identityRef,omitempty"`
 
+	// ServiceIdentityRefs override the identity specific services of the cluster are reconciled with, e.g. to
+	// manage DNS records with a central identity while all other resources are managed with IdentityRef.
+	// +optional
+	ServiceIdentityRefs []ServiceIdentityRef `json:"serviceIdentityRefs,omitempty"`
+
+	// IdentityPermissions enables checking the permissions of the identity the cluster is reconciled with in
+	// the resource groups of the cluster. Missing permissions are reported in the IdentityPermissionsReady condition,
+	// and the controller does not create or update any other resources of the cluster while permissions are missing.
+	// +optional
+	IdentityPermissions *IdentityPermissions `json:"identityPermissions,omitempty"`
+
+	// CloudProviderIdentity makes the controller create a user-assigned identity for the cloud provider of the cluster,
+	// assign it the roles the cloud provider needs and use it on machines that have no identity of their own.
+	// +optional
+	CloudProviderIdentity *CloudProviderIdentity `json:"cloudProviderIdentity,omitempty"`
+
+	// DriftDetection makes the controller periodically compare the Azure resources of the cluster to their specs,
+	// even if the AzureCluster didn't change, to detect modifications made outside of the controller, e.g. security
+	// rules edited in the Azure portal. Drift is reported in the AzureResourcesInSync condition.
+	// +optional
+	DriftDetection *DriftDetection `json:"driftDetection,omitempty"`
+
+	// EnforceTags makes the controller restore the tags it sets on the Azure resources of the cluster, including
+	// AdditionalTags, when they are removed or changed outside of the controller. Restored tags are reported in the
+	// TagsEnforced condition.
+	// +optional
+	EnforceTags bool `json:"enforceTags,omitempty"`
+
 	// AzureEnvironment is the name of the AzureCloud to be used.
 	// The default value that would be used by most users is "AzurePublicCloud", other values are:
 	// - ChinaCloud: "AzureChinaCloud"
@@ -77,6 +133,191 @@ type AzureClusterSpec struct {
 	// Note: All cloud provider config values can be customized by creating the secret beforehand. CloudProviderConfigOverrides is only used when the secret is managed by the Azure Provider.
 	// +optional
 	CloudProviderConfigOverrides *CloudProviderConfigOverrides `json:"cloudProviderConfigOverrides,omitempty"`
+
+	// DeletionPolicy defines what happens to the Azure resources of the cluster when the AzureCluster is deleted:
+	// Delete deletes them, Retain leaves them in place, and RetainResourceGroup deletes them but leaves the resource
+	// group in place. It is also the deletion policy of the AzureMachines of the cluster which don't set their own.
+	// Defaults to Delete.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// AzureResourceNaming customizes the names generated for the Azure resources of the cluster and its machines, e.g.
+	// to follow the naming conventions of an organization. Names set in the spec are left as they are. Immutable, as
+	// changing the names of the resources of existing machines would replace them.
+	// +optional
+	AzureResourceNaming *AzureResourceNaming `json:"azureResourceNaming,omitempty"`
+
+	// FailureDomainOverrides customize the failure domains of the cluster, which are by default all the availability
+	// zones of its location, e.g. to exclude a degraded zone or to give zones custom names.
+	// +optional
+	FailureDomainOverrides []FailureDomainOverride `json:"failureDomainOverrides,omitempty"`
+
+	// DisabledServices are the services of the cluster whose Azure resources are provided outside of the controller,
+	// which then neither creates nor updates them. Disabling a service leaves its existing resources in place.
+	// +optional
+	DisabledServices []ManagedService `json:"disabledServices,omitempty"`
+}
+
+// ManagedService is a service of the cluster which the controller manages unless it is disabled.
+// +kubebuilder:validation:Enum=Bastion;PrivateDNS;NodeOutboundLoadBalancer
+type ManagedService string
+
+const (
+	// BastionManagedService manages the Azure Bastion host, its subnet and its public IP.
+	BastionManagedService ManagedService = "Bastion"
+	// PrivateDNSManagedService manages the private DNS zone of private clusters, its virtual network link and its records.
+	PrivateDNSManagedService ManagedService = "PrivateDNS"
+	// NodeOutboundLBManagedService manages the load balancer providing outbound connectivity to the nodes of public
+	// clusters, and its public IPs.
+	NodeOutboundLBManagedService ManagedService = "NodeOutboundLoadBalancer"
+)
+
+// FailureDomainOverride customizes the failure domain of an availability zone.
+type FailureDomainOverride struct {
+	// Zone is the availability zone, e.g. "1".
+	// +kubebuilder:validation:MinLength=1
+	Zone string `json:"zone"`
+
+	// Name is the name of the failure domain of the zone, which defaults to the zone. Immutable once set, as machines
+	// reference their failure domain by name.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Excluded removes the zone from the failure domains of the cluster, so that Cluster API doesn't place new machines
+	// in it. Machines already in the zone are left in place.
+	// +optional
+	Excluded bool `json:"excluded,omitempty"`
+}
+
+// AzureService is a service of the cluster whose identity can be overridden.
+// +kubebuilder:validation:Enum=PrivateDNS
+type AzureService string
+
+const (
+	// PrivateDNSService manages the private DNS zone, its virtual network link and its records.
+	PrivateDNSService AzureService = "PrivateDNS"
+)
+
+// ServiceIdentityRef references the identity a service of the cluster is reconciled with.
+type ServiceIdentityRef struct {
+	// Service is the service the identity is used for.
+	Service AzureService `json:"service"`
+
+	// IdentityRef is a reference to the AzureClusterIdentity the service is reconciled with.
+	IdentityRef *corev1.ObjectReference `json:"identityRef"`
+}
+
+// IdentityPermissions configures how the permissions of the cluster identity are managed. Whether the controller
+// assigns missing roles to the identity is decided by the AzureClusterIdentity, see its ProvisionRoleAssignments.
+type IdentityPermissions struct{}
+
+// DriftDetectionMode is what the controller does about Azure resources which differ from their specs.
+// +kubebuilder:validation:Enum=Report;Repair
+type DriftDetectionMode string
+
+const (
+	// DriftDetectionModeReport only reports drift, and leaves the resources as they are.
+	DriftDetectionModeReport DriftDetectionMode = "Report"
+	// DriftDetectionModeRepair reports drift, and updates the resources to match their specs.
+	DriftDetectionModeRepair DriftDetectionMode = "Repair"
+)
+
+// DriftDetection configures the periodic detection of drift of the Azure resources of the cluster.
+type DriftDetection struct {
+	// Interval is how often the Azure resources of the cluster are compared to their specs. Must be at least 1m.
+	Interval metav1.Duration `json:"interval"`
+
+	// Mode is what the controller does about resources which differ from their specs. In Report mode, changes to
+	// the specs of existing resources aren't applied either. Defaults to Repair.
+	// +optional
+	Mode DriftDetectionMode `json:"mode,omitempty"`
+}
+
+// CloudProviderIdentity configures the user-assigned identity the controller manages for the cloud provider.
+type CloudProviderIdentity struct {
+	// Name of the user-assigned identity, created in the resource group of the cluster.
+	// Defaults to <cluster name>-cloud-provider.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// AzureResourceKind is a kind of Azure resource whose generated names can be customized.
+// +kubebuilder:validation:Enum=VirtualNetwork;ControlPlaneSubnet;NodeSubnet;ControlPlaneSecurityGroup;NodeSecurityGroup;NodeRouteTable;PublicLoadBalancer;InternalLoadBalancer;APIServerPublicIP;NodeOutboundPublicIP;Bastion;BastionPublicIP;CloudProviderIdentity;NetworkInterface;PublicNetworkInterface;NodePublicIP;OSDisk
+type AzureResourceKind string
+
+const (
+	// VirtualNetworkKind is the virtual network of the cluster, named <cluster name>-vnet by default.
+	VirtualNetworkKind AzureResourceKind = "VirtualNetwork"
+	// ControlPlaneSubnetKind is the control plane subnet, named <cluster name>-controlplane-subnet by default.
+	ControlPlaneSubnetKind AzureResourceKind = "ControlPlaneSubnet"
+	// NodeSubnetKind is the node subnet, named <cluster name>-node-subnet by default.
+	NodeSubnetKind AzureResourceKind = "NodeSubnet"
+	// ControlPlaneSecurityGroupKind is the security group of the control plane subnet, named
+	// <cluster name>-controlplane-nsg by default.
+	ControlPlaneSecurityGroupKind AzureResourceKind = "ControlPlaneSecurityGroup"
+	// NodeSecurityGroupKind is the security group of the node subnet, named <cluster name>-node-nsg by default.
+	NodeSecurityGroupKind AzureResourceKind = "NodeSecurityGroup"
+	// NodeRouteTableKind is the route table of the node subnet, named <cluster name>-node-routetable by default.
+	NodeRouteTableKind AzureResourceKind = "NodeRouteTable"
+	// PublicLoadBalancerKind is the public API server load balancer, named <cluster name>-public-lb by default.
+	PublicLoadBalancerKind AzureResourceKind = "PublicLoadBalancer"
+	// InternalLoadBalancerKind is the internal API server load balancer, named <cluster name>-internal-lb by default.
+	InternalLoadBalancerKind AzureResourceKind = "InternalLoadBalancer"
+	// APIServerPublicIPKind is the public IP of the API server, named pip-<cluster name>-apiserver by default.
+	APIServerPublicIPKind AzureResourceKind = "APIServerPublicIP"
+	// NodeOutboundPublicIPKind is the public IP of the node outbound load balancer, named
+	// pip-<cluster name>-node-outbound by default. Clusters with several outbound IPs suffix them with their index.
+	NodeOutboundPublicIPKind AzureResourceKind = "NodeOutboundPublicIP"
+	// BastionKind is the Azure bastion host, named <cluster name>-azure-bastion by default.
+	BastionKind AzureResourceKind = "Bastion"
+	// BastionPublicIPKind is the public IP of the Azure bastion host, named <cluster name>-azure-bastion-pip by default.
+	BastionPublicIPKind AzureResourceKind = "BastionPublicIP"
+	// CloudProviderIdentityKind is the user-assigned identity of the cloud provider, named
+	// <cluster name>-cloud-provider by default.
+	CloudProviderIdentityKind AzureResourceKind = "CloudProviderIdentity"
+	// NetworkInterfaceKind is the network interface of a machine, named <machine name>-nic by default.
+	NetworkInterfaceKind AzureResourceKind = "NetworkInterface"
+	// PublicNetworkInterfaceKind is the network interface of the public IP of a machine, named
+	// <machine name>-public-nic by default.
+	PublicNetworkInterfaceKind AzureResourceKind = "PublicNetworkInterface"
+	// NodePublicIPKind is the public IP of a machine, named pip-<machine name> by default.
+	NodePublicIPKind AzureResourceKind = "NodePublicIP"
+	// OSDiskKind is the OS disk of a machine, named <machine name>_OSDisk by default.
+	OSDiskKind AzureResourceKind = "OSDisk"
+)
+
+// AzureResourceNaming customizes the names generated for the Azure resources of a cluster.
+type AzureResourceNaming struct {
+	// Prefix is prepended to all generated names.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Suffix is appended to all generated names.
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
+
+	// Templates customize the generated names of specific kinds of resources.
+	// +optional
+	Templates []AzureResourceNamingTemplate `json:"templates,omitempty"`
+}
+
+// AzureResourceNamingTemplate customizes the generated names of a kind of Azure resource.
+type AzureResourceNamingTemplate struct {
+	// Kind is the kind of resources the template applies to.
+	Kind AzureResourceKind `json:"kind"`
+
+	// Pattern replaces the default name of the resources of the kind. "{cluster}" is replaced by the name of the
+	// cluster and, for the resources of a machine, "{machine}" by the name of its VM, which the pattern must contain.
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+
+	// Prefix replaces the prefix of the naming for the resources of the kind.
+	// +optional
+	Prefix *string `json:"prefix,omitempty"`
+
+	// Suffix replaces the suffix of the naming for the resources of the kind.
+	// +optional
+	Suffix *string `json:"suffix,omitempty"`
 }
 
 // AzureClusterStatus defines the observed state of AzureCluster.
@@ -96,6 +337,16 @@ type AzureClusterStatus struct {
 	// Conditions defines current service state of the AzureCluster.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+	// Resources are the Azure resources managed for the AzureCluster, with their last known status.
+	// +optional
+	Resources []ResourceStatus `json:"resources,omitempty"`
+	// ServiceSpecHashes are the hashes of the specs of the services of the AzureCluster when they were last
+	// reconciled, so services whose spec didn't change are only reconciled again after a resync period.
+	// +optional
+	ServiceSpecHashes []ServiceSpecHash `json:"serviceSpecHashes,omitempty"`
+	// Services are the observed states of the Azure resources of the services of the AzureCluster, by service.
+	// +optional
+	Services []ServiceStatus `json:"services,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -137,6 +388,16 @@ func (c *AzureCluster) SetConditions(conditions clusterv1.Conditions) {
 	c.Status.Conditions = conditions
 }
 
+// ServiceDisabled returns true if the resources of a service of the cluster are provided outside of the controller.
+func (c *AzureCluster) ServiceDisabled(service ManagedService) bool {
+	for _, disabled := range c.Spec.DisabledServices {
+		if disabled == service {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	SchemeBuilder.Register(&AzureCluster{}, &AzureClusterList{})
 }
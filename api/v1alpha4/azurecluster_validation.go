@@ -17,10 +17,14 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"k8s.io/utils/pointer"
 
@@ -28,6 +32,8 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -48,17 +54,44 @@ const (
 	MinLBIdleTimeoutInMinutes = 4
 	// MaxLBIdleTimeoutInMinutes is the maximum number of minutes for the LB idle timeout.
 	MaxLBIdleTimeoutInMinutes = 30
+	// SNATPortsPerFrontendIP is the number of SNAT ports each frontend IP of a Standard LoadBalancer provides to its outbound rule.
+	// https://docs.microsoft.com/en-us/azure/load-balancer/load-balancer-outbound-connections#preallocatedports
+	SNATPortsPerFrontendIP = 64000
+	// Instances with fewer SNAT ports than this are likely to exhaust them under a moderate number of outbound connections.
+	minRecommendedOutboundPorts = 1024
 	// Network security rules should be a number between 100 and 4096.
 	// https://docs.microsoft.com/en-us/azure/virtual-network/network-security-groups-overview#security-rules
 	minRulePriority = 100
 	maxRulePriority = 4096
+	// Comparing all resources of a cluster takes many calls to Azure, which shouldn't be made more than once a minute.
+	minDriftDetectionInterval = time.Minute
 )
 
+// serviceEndpointServices are the services subnets can have service endpoints for.
+// https://docs.microsoft.com/en-us/azure/virtual-network/virtual-network-service-endpoints-overview
+var serviceEndpointServices = []string{
+	"Microsoft.AzureActiveDirectory",
+	"Microsoft.AzureCosmosDB",
+	"Microsoft.CognitiveServices",
+	"Microsoft.ContainerRegistry",
+	"Microsoft.EventHub",
+	"Microsoft.KeyVault",
+	"Microsoft.ServiceBus",
+	"Microsoft.Sql",
+	"Microsoft.Storage",
+	"Microsoft.Web",
+}
+
 // validateCluster validates a cluster.
 func (c *AzureCluster) validateCluster(old *AzureCluster) error {
 	var allErrs field.ErrorList
 	allErrs = append(allErrs, c.validateClusterName()...)
+	allErrs = append(allErrs, c.validateTimeoutAnnotations()...)
+	allErrs = append(allErrs, c.validateDryRunAnnotation()...)
+	allErrs = append(allErrs, c.validateReadOnlyAnnotation()...)
+	allErrs = append(allErrs, c.validateSkipServiceAnnotations()...)
 	allErrs = append(allErrs, c.validateClusterSpec(old)...)
+	allErrs = append(allErrs, c.validateClusterNetwork(context.TODO(), old)...)
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -76,6 +109,14 @@ func (c *AzureCluster) validateClusterSpec(old *AzureCluster) field.ErrorList {
 		oldNetworkSpec = old.Spec.NetworkSpec
 	}
 	allErrs = append(allErrs, validateNetworkSpec(c.Spec.NetworkSpec, oldNetworkSpec, field.NewPath("spec").Child("networkSpec"))...)
+	// The nodes of clusters whose node outbound load balancer is disabled get outbound connectivity otherwise.
+	if !c.ServiceDisabled(NodeOutboundLBManagedService) {
+		allErrs = append(allErrs, validateNodeOutboundLB(c.Spec.NetworkSpec.NodeOutboundLB, oldNetworkSpec.NodeOutboundLB, c.Spec.NetworkSpec.APIServerLB,
+			field.NewPath("spec").Child("networkSpec").Child("nodeOutboundLB"))...)
+	}
+	if old != nil {
+		allErrs = append(allErrs, validateNetworkSpecUpdate(c.Spec.NetworkSpec, old.Spec.NetworkSpec, c.Name, field.NewPath("spec").Child("networkSpec"))...)
+	}
 
 	var oldCloudProviderConfigOverrides *CloudProviderConfigOverrides
 	if old != nil {
@@ -84,9 +125,247 @@ func (c *AzureCluster) validateClusterSpec(old *AzureCluster) field.ErrorList {
 	allErrs = append(allErrs, validateCloudProviderConfigOverrides(c.Spec.CloudProviderConfigOverrides, oldCloudProviderConfigOverrides,
 		field.NewPath("spec").Child("cloudProviderConfigOverrides"))...)
 
+	allErrs = append(allErrs, validateServiceIdentityRefs(c.Spec.ServiceIdentityRefs, field.NewPath("spec").Child("serviceIdentityRefs"))...)
+
+	if err := validateDriftDetection(c.Spec.DriftDetection, field.NewPath("spec").Child("driftDetection")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	allErrs = append(allErrs, validateAzureResourceNaming(c.Spec.AzureResourceNaming, field.NewPath("spec").Child("azureResourceNaming"))...)
+
+	var oldFailureDomainOverrides []FailureDomainOverride
+	if old != nil {
+		oldFailureDomainOverrides = old.Spec.FailureDomainOverrides
+	}
+	allErrs = append(allErrs, validateFailureDomainOverrides(c.Spec.FailureDomainOverrides, oldFailureDomainOverrides,
+		field.NewPath("spec").Child("failureDomainOverrides"))...)
+
+	allErrs = append(allErrs, c.validateDisabledServices()...)
+
+	if old == nil || !reflect.DeepEqual(c.Spec.AdditionalTags, old.Spec.AdditionalTags) {
+		allErrs = append(allErrs, ValidateTagPolicy(c.Spec.AdditionalTags, true, field.NewPath("spec").Child("additionalTags"))...)
+	}
+
+	// The Azure Bastion subnet is immutable, so its service endpoints could not be changed once it exists.
+	if c.Spec.BastionSpec.AzureBastion != nil && len(c.Spec.BastionSpec.AzureBastion.Subnet.ServiceEndpoints) != 0 {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "bastionSpec", "azureBastion", "subnet", "serviceEndpoints"),
+			"service endpoints are only supported on the subnets of the network spec"))
+	}
+
 	return allErrs
 }
 
+// validateServiceIdentityRefs validates that every service has at most one identity.
+func validateServiceIdentityRefs(refs []ServiceIdentityRef, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	services := map[AzureService]bool{}
+	for i, ref := range refs {
+		if services[ref.Service] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("service"), ref.Service))
+		}
+		services[ref.Service] = true
+		if ref.IdentityRef == nil || ref.IdentityRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("identityRef"), "identity of the service must be set"))
+		}
+	}
+	return allErrs
+}
+
+// serviceIdentityRefsWarnings returns warnings for the service identities which are ignored, as the cluster doesn't
+// use their service.
+func serviceIdentityRefsWarnings(refs []ServiceIdentityRef, apiServerLB LoadBalancerSpec, fldPath *field.Path) []string {
+	var warnings []string
+	for i, ref := range refs {
+		if ref.Service == PrivateDNSService && apiServerLB.Type != Internal {
+			warnings = append(warnings, fmt.Sprintf("%s: ignored, as the %s service is only used with an internal API server load balancer",
+				fldPath.Index(i), ref.Service))
+		}
+	}
+	return warnings
+}
+
+// validateTimeoutAnnotations validates that the timeout annotations are positive durations.
+func (c *AzureCluster) validateTimeoutAnnotations() field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("metadata").Child("annotations")
+	for _, annotation := range []string{ServiceReconcileTimeoutAnnotation, AzureCallTimeoutAnnotation} {
+		value, ok := c.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(annotation), value, "must be a positive duration, e.g. 30m"))
+		}
+	}
+	return allErrs
+}
+
+// validateDryRunAnnotation validates that the dry run annotation is a boolean.
+func (c *AzureCluster) validateDryRunAnnotation() field.ErrorList {
+	return c.validateBoolAnnotation(DryRunAnnotation)
+}
+
+// validateReadOnlyAnnotation validates that the read-only annotation is a boolean.
+func (c *AzureCluster) validateReadOnlyAnnotation() field.ErrorList {
+	return c.validateBoolAnnotation(ReadOnlyAnnotation)
+}
+
+// validateBoolAnnotation validates that an annotation, if set, is a boolean.
+func (c *AzureCluster) validateBoolAnnotation(annotation string) field.ErrorList {
+	value, ok := c.Annotations[annotation]
+	if !ok {
+		return nil
+	}
+	if _, err := strconv.ParseBool(value); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("metadata").Child("annotations").Key(annotation), value, "must be true or false")}
+	}
+	return nil
+}
+
+// validateSkipServiceAnnotations validates that the annotations skipping services name a service and are booleans.
+func (c *AzureCluster) validateSkipServiceAnnotations() field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("metadata").Child("annotations")
+	for annotation, value := range c.Annotations {
+		if !strings.HasPrefix(annotation, SkipServiceAnnotationPrefix) {
+			continue
+		}
+		if strings.TrimPrefix(annotation, SkipServiceAnnotationPrefix) == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(annotation), value, "must name a service, e.g. "+SkipServiceAnnotationPrefix+"loadbalancers"))
+			continue
+		}
+		if _, err := strconv.ParseBool(value); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(annotation), value, "must be true or false"))
+		}
+	}
+	return allErrs
+}
+
+// validateAzureResourceNaming validates that every kind of resource has at most one naming template, and that the
+// patterns of the resources of machines name them after their machine.
+func validateAzureResourceNaming(naming *AzureResourceNaming, fldPath *field.Path) field.ErrorList {
+	if naming == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	kinds := map[AzureResourceKind]bool{}
+	for i, template := range naming.Templates {
+		templatePath := fldPath.Child("templates").Index(i)
+		if kinds[template.Kind] {
+			allErrs = append(allErrs, field.Duplicate(templatePath.Child("kind"), template.Kind))
+		}
+		kinds[template.Kind] = true
+		if template.Pattern == "" {
+			continue
+		}
+		usesMachineName := strings.Contains(template.Pattern, MachineNamePlaceholder)
+		if machineResourceKinds[template.Kind] && !usesMachineName {
+			allErrs = append(allErrs, field.Invalid(templatePath.Child("pattern"), template.Pattern,
+				fmt.Sprintf("pattern of the resources of machines must contain %s", MachineNamePlaceholder)))
+		}
+		if !machineResourceKinds[template.Kind] && usesMachineName {
+			allErrs = append(allErrs, field.Invalid(templatePath.Child("pattern"), template.Pattern,
+				fmt.Sprintf("only the patterns of the resources of machines can contain %s", MachineNamePlaceholder)))
+		}
+	}
+	return allErrs
+}
+
+// validateDisabledServices validates that every service is disabled at most once, and that the spec doesn't configure
+// the resources of disabled services.
+func (c *AzureCluster) validateDisabledServices() field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec").Child("disabledServices")
+	disabled := map[ManagedService]bool{}
+	for i, service := range c.Spec.DisabledServices {
+		if disabled[service] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), service))
+		}
+		disabled[service] = true
+	}
+
+	if disabled[BastionManagedService] && c.Spec.BastionSpec.AzureBastion != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "bastionSpec", "azureBastion"),
+			fmt.Sprintf("must not be set when the %s service is disabled", BastionManagedService)))
+	}
+
+	if disabled[PrivateDNSManagedService] {
+		networkSpecPath := field.NewPath("spec", "networkSpec")
+		if c.Spec.NetworkSpec.PrivateDNSZoneResourceGroup != "" {
+			allErrs = append(allErrs, field.Forbidden(networkSpecPath.Child("privateDNSZoneResourceGroup"),
+				fmt.Sprintf("must not be set when the %s service is disabled", PrivateDNSManagedService)))
+		}
+		if c.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID != "" {
+			allErrs = append(allErrs, field.Forbidden(networkSpecPath.Child("privateDNSZoneSubscriptionID"),
+				fmt.Sprintf("must not be set when the %s service is disabled", PrivateDNSManagedService)))
+		}
+		for i, ref := range c.Spec.ServiceIdentityRefs {
+			if ref.Service == PrivateDNSService {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "serviceIdentityRefs").Index(i),
+					fmt.Sprintf("must not reference the %s service, which is disabled", PrivateDNSManagedService)))
+			}
+		}
+	}
+
+	if disabled[NodeOutboundLBManagedService] && c.Spec.NetworkSpec.NodeOutboundLB != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "networkSpec", "nodeOutboundLB"),
+			fmt.Sprintf("must not be set when the %s service is disabled", NodeOutboundLBManagedService)))
+	}
+
+	return allErrs
+}
+
+// validateFailureDomainOverrides validates that every zone has at most one override, that the failure domain names are
+// unique and don't shadow other availability zones, and that names aren't changed once set.
+func validateFailureDomainOverrides(overrides, old []FailureDomainOverride, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	zones := map[string]bool{}
+	names := map[string]bool{}
+	for i, override := range overrides {
+		overridePath := fldPath.Index(i)
+		if zones[override.Zone] {
+			allErrs = append(allErrs, field.Duplicate(overridePath.Child("zone"), override.Zone))
+		}
+		zones[override.Zone] = true
+		if override.Name == "" {
+			continue
+		}
+		if names[override.Name] {
+			allErrs = append(allErrs, field.Duplicate(overridePath.Child("name"), override.Name))
+		}
+		names[override.Name] = true
+		// Zones without an override keep their zone as name, so a custom name must not look like another zone.
+		if _, err := strconv.Atoi(override.Name); err == nil && override.Name != override.Zone {
+			allErrs = append(allErrs, field.Invalid(overridePath.Child("name"), override.Name, "must not be the name of another availability zone"))
+		}
+	}
+
+	for _, oldOverride := range old {
+		if oldOverride.Name == "" {
+			continue
+		}
+		name := ""
+		for _, override := range overrides {
+			if override.Zone == oldOverride.Zone {
+				name = override.Name
+			}
+		}
+		if name != oldOverride.Name {
+			allErrs = append(allErrs, field.Invalid(fldPath, name,
+				fmt.Sprintf("name of the failure domain of zone %s is immutable once set", oldOverride.Zone)))
+		}
+	}
+	return allErrs
+}
+
+// validateDriftDetection validates that drift is detected at most once per minute.
+func validateDriftDetection(driftDetection *DriftDetection, fldPath *field.Path) *field.Error {
+	if driftDetection != nil && driftDetection.Interval.Duration < minDriftDetectionInterval {
+		return field.Invalid(fldPath.Child("interval"), driftDetection.Interval.Duration.String(), fmt.Sprintf("must be at least %s", minDriftDetectionInterval))
+	}
+	return nil
+}
+
 // validateClusterName validates ClusterName.
 func (c *AzureCluster) validateClusterName() field.ErrorList {
 	var allErrs field.ErrorList
@@ -131,16 +410,83 @@ func validateNetworkSpec(networkSpec NetworkSpec, old NetworkSpec, fldPath *fiel
 
 	allErrs = append(allErrs, validateAPIServerLB(networkSpec.APIServerLB, old.APIServerLB, cidrBlocks, fldPath.Child("apiServerLB"))...)
 
-	allErrs = append(allErrs, validateNodeOutboundLB(networkSpec.NodeOutboundLB, old.NodeOutboundLB, networkSpec.APIServerLB, fldPath.Child("nodeOutboundLB"))...)
-
 	allErrs = append(allErrs, validatePrivateDNSZoneName(networkSpec, fldPath)...)
 
+	allErrs = append(allErrs, validatePrivateDNSZoneLocation(networkSpec, fldPath)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return allErrs
 }
 
+// validateNetworkSpecUpdate validates that the networking fields the controller never updates in Azure aren't changed
+// once set, as such changes would be ignored or keep failing. The CIDR blocks of a vnet provided by the user are
+// discovered from Azure, so only those of a managed vnet are checked.
+func validateNetworkSpecUpdate(networkSpec NetworkSpec, old NetworkSpec, clusterName string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	vnetPath := fldPath.Child("vnet")
+	if old.Vnet.Name != "" && old.Vnet.Name != networkSpec.Vnet.Name {
+		allErrs = append(allErrs, immutableNetworkField(vnetPath.Child("name"), networkSpec.Vnet.Name, "virtual network"))
+	}
+	if old.Vnet.ResourceGroup != "" && old.Vnet.ResourceGroup != networkSpec.Vnet.ResourceGroup {
+		allErrs = append(allErrs, immutableNetworkField(vnetPath.Child("resourceGroup"), networkSpec.Vnet.ResourceGroup, "virtual network"))
+	}
+	managed := old.Vnet.ID != "" && old.Vnet.IsManaged(clusterName)
+	if managed && len(old.Vnet.CIDRBlocks) > 0 && !reflect.DeepEqual(old.Vnet.CIDRBlocks, networkSpec.Vnet.CIDRBlocks) {
+		allErrs = append(allErrs, immutableNetworkField(vnetPath.Child("cidrBlocks"), networkSpec.Vnet.CIDRBlocks, "virtual network"))
+	}
+
+	// Subnets are matched by role, as the control plane and node subnets are the only ones the controller manages.
+	for i, subnet := range networkSpec.Subnets {
+		oldSubnet, ok := subnetWithRole(old.Subnets, subnet.Role)
+		if !ok {
+			continue
+		}
+		subnetPath := fldPath.Child("subnets").Index(i)
+		if oldSubnet.Name != "" && oldSubnet.Name != subnet.Name {
+			allErrs = append(allErrs, immutableNetworkField(subnetPath.Child("name"), subnet.Name, "subnet"))
+		}
+		if managed && oldSubnet.ID != "" && len(oldSubnet.CIDRBlocks) > 0 && !reflect.DeepEqual(oldSubnet.CIDRBlocks, subnet.CIDRBlocks) {
+			allErrs = append(allErrs, immutableNetworkField(subnetPath.Child("cidrBlocks"), subnet.CIDRBlocks, "subnet"))
+		}
+	}
+
+	// The endpoint of the control plane is the FQDN of the public IP of the API server load balancer.
+	if len(old.APIServerLB.FrontendIPs) > 0 && old.APIServerLB.FrontendIPs[0].PublicIP != nil && len(networkSpec.APIServerLB.FrontendIPs) > 0 {
+		oldIP, ip := old.APIServerLB.FrontendIPs[0].PublicIP, networkSpec.APIServerLB.FrontendIPs[0].PublicIP
+		ipPath := fldPath.Child("apiServerLB").Child("frontendIPs").Index(0).Child("publicIP")
+		switch {
+		case ip == nil:
+			allErrs = append(allErrs, immutableNetworkField(ipPath, ip, "API server public IP"))
+		case oldIP.Name != "" && oldIP.Name != ip.Name:
+			allErrs = append(allErrs, immutableNetworkField(ipPath.Child("name"), ip.Name, "API server public IP"))
+		case oldIP.DNSName != "" && oldIP.DNSName != ip.DNSName:
+			allErrs = append(allErrs, immutableNetworkField(ipPath.Child("dnsName"), ip.DNSName, "API server public IP"))
+		}
+	}
+
+	return allErrs
+}
+
+// immutableNetworkField returns the error of a networking field changed after the creation of the cluster, with the
+// procedure to change it.
+func immutableNetworkField(fldPath *field.Path, value interface{}, resource string) *field.Error {
+	return field.Invalid(fldPath, value, fmt.Sprintf("field is immutable: the controller doesn't update the %s of an existing cluster, "+
+		"so the change would never be applied. To change it, revert this change, create a new cluster with the new value, "+
+		"move the workloads to it and delete this cluster", resource))
+}
+
+// subnetWithRole returns the first subnet with a role.
+func subnetWithRole(subnets Subnets, role SubnetRole) (SubnetSpec, bool) {
+	for _, subnet := range subnets {
+		if subnet.Role == role {
+			return subnet, true
+		}
+	}
+	return SubnetSpec{}, false
+}
+
 // validateResourceGroup validates a ResourceGroup.
 func validateResourceGroup(resourceGroup string, fldPath *field.Path) *field.Error {
 	if success, _ := regexp.MatchString(resourceGroupRegex, resourceGroup); !success {
@@ -181,6 +527,7 @@ func validateSubnets(subnets Subnets, vnet VnetSpec, fldPath *field.Path) field.
 			}
 		}
 		allErrs = append(allErrs, validateSubnetCIDR(subnet.CIDRBlocks, vnet.CIDRBlocks, fldPath.Index(i).Child("cidrBlocks"))...)
+		allErrs = append(allErrs, validateServiceEndpoints(subnet.ServiceEndpoints, fldPath.Index(i).Child("serviceEndpoints"))...)
 	}
 	for k, v := range requiredSubnetRoles {
 		if !v {
@@ -188,9 +535,76 @@ func validateSubnets(subnets Subnets, vnet VnetSpec, fldPath *field.Path) field.
 				fmt.Sprintf("required role %s not included in provided subnets", k)))
 		}
 	}
+	allErrs = append(allErrs, validateSubnetsOverlap(subnets, fldPath)...)
+	return allErrs
+}
+
+// validateSubnetsOverlap validates that the CIDR blocks of Subnets don't overlap each other.
+func validateSubnetsOverlap(subnets Subnets, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, subnet := range subnets {
+		for _, cidr := range subnet.CIDRBlocks {
+			_, nw, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			// Every pair of subnets is only reported once, on the later one.
+			for _, other := range subnets[:i] {
+				for _, otherCidr := range other.CIDRBlocks {
+					if _, otherNw, err := net.ParseCIDR(otherCidr); err == nil && cidrsOverlap(nw, otherNw) {
+						allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), cidr,
+							fmt.Sprintf("subnet CIDR overlaps with CIDR %s of subnet %s", otherCidr, other.Name)))
+					}
+				}
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateServiceEndpoints validates the service endpoints of a Subnet.
+func validateServiceEndpoints(serviceEndpoints []ServiceEndpointSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	services := make(map[string]bool, len(serviceEndpoints))
+	for i, serviceEndpoint := range serviceEndpoints {
+		if !serviceEndpointServiceSupported(serviceEndpoint.Service) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Index(i).Child("service"), serviceEndpoint.Service, serviceEndpointServices))
+		}
+		if services[strings.ToLower(serviceEndpoint.Service)] {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("service"), serviceEndpoint.Service))
+		}
+		services[strings.ToLower(serviceEndpoint.Service)] = true
+
+		if len(serviceEndpoint.Locations) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("locations"), "at least one location, or * for all of them, is required"))
+		}
+		locations := make(map[string]bool, len(serviceEndpoint.Locations))
+		for j, location := range serviceEndpoint.Locations {
+			switch {
+			case location == "":
+				allErrs = append(allErrs, field.Required(fldPath.Index(i).Child("locations").Index(j), "location must not be empty"))
+			case location == "*" && len(serviceEndpoint.Locations) > 1:
+				allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("locations").Index(j), location,
+					"* covers all locations and cannot be combined with other locations"))
+			case locations[strings.ToLower(location)]:
+				allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("locations").Index(j), location))
+			}
+			locations[strings.ToLower(location)] = true
+		}
+	}
 	return allErrs
 }
 
+// serviceEndpointServiceSupported returns true if subnets can have service endpoints for the service.
+func serviceEndpointServiceSupported(service string) bool {
+	for _, supported := range serviceEndpointServices {
+		if strings.EqualFold(service, supported) {
+			return true
+		}
+	}
+	return false
+}
+
 // validateSubnetName validates the Name of a Subnet.
 func validateSubnetName(name string, fldPath *field.Path) *field.Error {
 	if success, _ := regexp.Match(subnetRegex, []byte(name)); !success {
@@ -212,14 +626,18 @@ func validateSubnetCIDR(subnetCidrBlocks []string, vnetCidrBlocks []string, fldP
 	}
 
 	for _, subnetCidr := range subnetCidrBlocks {
-		subnetCidrIP, _, err := net.ParseCIDR(subnetCidr)
+		_, subnetNw, err := net.ParseCIDR(subnetCidr)
 		if err != nil {
 			allErrs = append(allErrs, field.Invalid(fldPath, subnetCidr, "invalid CIDR format"))
+			continue
 		}
 
+		// The whole subnet must be in the vnet range, not only its first address.
 		var found bool
+		subnetOnes, _ := subnetNw.Mask.Size()
 		for _, vnetNw := range vnetNws {
-			if vnetNw.Contains(subnetCidrIP) {
+			vnetOnes, _ := vnetNw.Mask.Size()
+			if vnetNw.Contains(subnetNw.IP) && vnetOnes <= subnetOnes {
 				found = true
 				break
 			}
@@ -244,6 +662,74 @@ func validateVnetCIDR(vnetCIDRBlocks []string, fldPath *field.Path) field.ErrorL
 	return allErrs
 }
 
+// validateClusterNetwork validates that the subnets of the cluster don't overlap the pod and service CIDRs of the
+// Cluster owning it. The check is skipped if the owning cluster can not be determined yet, and on updates which
+// change neither the subnets nor the owning cluster, so a cluster created before the check can still be deleted.
+func (c *AzureCluster) validateClusterNetwork(ctx context.Context, old *AzureCluster) field.ErrorList {
+	clusterName, ok := c.Labels[clusterv1.ClusterLabelName]
+	if !ok || azureClusterWebhookClient == nil {
+		return nil
+	}
+	if old != nil && old.Labels[clusterv1.ClusterLabelName] == clusterName &&
+		reflect.DeepEqual(old.Spec.NetworkSpec.Subnets, c.Spec.NetworkSpec.Subnets) {
+		return nil
+	}
+
+	fldPath := field.NewPath("spec").Child("networkSpec").Child("subnets")
+	cluster := &clusterv1.Cluster{}
+	key := client.ObjectKey{Namespace: c.Namespace, Name: clusterName}
+	if err := azureClusterWebhookClient.Get(ctx, key, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return field.ErrorList{field.InternalError(fldPath, err)}
+	}
+
+	return validateSubnetsClusterNetwork(c.Spec.NetworkSpec.Subnets, cluster.Spec.ClusterNetwork, fldPath)
+}
+
+// validateSubnetsClusterNetwork validates that the CIDR blocks of Subnets don't overlap the pod and service CIDRs of
+// a cluster network.
+func validateSubnetsClusterNetwork(subnets Subnets, clusterNetwork *clusterv1.ClusterNetwork, fldPath *field.Path) field.ErrorList {
+	if clusterNetwork == nil {
+		return nil
+	}
+	clusterRanges := []struct {
+		kind   string
+		ranges *clusterv1.NetworkRanges
+	}{
+		{kind: "pod", ranges: clusterNetwork.Pods},
+		{kind: "service", ranges: clusterNetwork.Services},
+	}
+
+	var allErrs field.ErrorList
+	for i, subnet := range subnets {
+		for _, cidr := range subnet.CIDRBlocks {
+			_, nw, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			for _, clusterRange := range clusterRanges {
+				if clusterRange.ranges == nil {
+					continue
+				}
+				for _, clusterCidr := range clusterRange.ranges.CIDRBlocks {
+					if _, clusterNw, err := net.ParseCIDR(clusterCidr); err == nil && cidrsOverlap(nw, clusterNw) {
+						allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("cidrBlocks"), cidr,
+							fmt.Sprintf("subnet CIDR overlaps with %s CIDR %s of the cluster", clusterRange.kind, clusterCidr)))
+					}
+				}
+			}
+		}
+	}
+	return allErrs
+}
+
+// cidrsOverlap returns true if two networks share any address.
+func cidrsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
 // validateLoadBalancerName validates the Name of a Load Balancer.
 func validateLoadBalancerName(name string, fldPath *field.Path) *field.Error {
 	if success, _ := regexp.Match(loadBalancerRegex, []byte(name)); !success {
@@ -310,6 +796,11 @@ func validateAPIServerLB(lb LoadBalancerSpec, old LoadBalancerSpec, cidrs []stri
 		allErrs = append(allErrs, field.Forbidden(fldPath.Child("idleTimeoutInMinutes"), "API Server load balancer idle timeout cannot be modified after AzureCluster creation."))
 	}
 
+	if old.SKU != "" && !pointer.Int32Equal(old.AllocatedOutboundPorts, lb.AllocatedOutboundPorts) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("allocatedOutboundPorts"), "API Server load balancer allocated outbound ports cannot be modified after AzureCluster creation."))
+	}
+	allErrs = append(allErrs, validateAllocatedOutboundPorts(lb, fldPath)...)
+
 	// There should only be one IP config.
 	if len(lb.FrontendIPs) != 1 || pointer.Int32Deref(lb.FrontendIPsCount, 1) != 1 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("frontendIPConfigs"), lb.FrontendIPs,
@@ -382,6 +873,10 @@ func validateNodeOutboundLB(lb *LoadBalancerSpec, old *LoadBalancerSpec, apiserv
 		allErrs = append(allErrs, field.Forbidden(fldPath.Child("idleTimeoutInMinutes"), "Node outbound load balancer idle timeout cannot be modified after AzureCluster creation."))
 	}
 
+	if old != nil && !pointer.Int32Equal(old.AllocatedOutboundPorts, lb.AllocatedOutboundPorts) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("allocatedOutboundPorts"), "Node outbound load balancer allocated outbound ports cannot be modified after AzureCluster creation."))
+	}
+
 	if old != nil && old.FrontendIPsCount == lb.FrontendIPsCount {
 		if len(old.FrontendIPs) != len(lb.FrontendIPs) {
 			allErrs = append(allErrs, field.Forbidden(fldPath.Child("frontendIPs"), "Node outbound load balancer FrontendIPs cannot be modified after AzureCluster creation."))
@@ -408,9 +903,110 @@ func validateNodeOutboundLB(lb *LoadBalancerSpec, old *LoadBalancerSpec, apiserv
 			fmt.Sprintf("Node outbound idle timeout should be between %d and %d minutes", MinLBIdleTimeoutInMinutes, MaxLoadBalancerOutboundIPs)))
 	}
 
+	allErrs = append(allErrs, validateAllocatedOutboundPorts(*lb, fldPath)...)
+
 	return allErrs
 }
 
+// validateAllocatedOutboundPorts validates the SNAT ports the outbound rule of a load balancer allocates to each backend
+// instance, making sure the frontend IPs of the load balancer provide enough ports for the expected backend pool size.
+func validateAllocatedOutboundPorts(lb LoadBalancerSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if pointer.Int32Deref(lb.AllocatedOutboundPorts, 0) == 0 {
+		return allErrs
+	}
+	ports := *lb.AllocatedOutboundPorts
+
+	if lb.Type == Internal {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("allocatedOutboundPorts"), "Internal load balancers have no outbound rule to allocate ports with"))
+		return allErrs
+	}
+
+	if ports < 0 || ports > SNATPortsPerFrontendIP || ports%8 != 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("allocatedOutboundPorts"), ports,
+			fmt.Sprintf("Allocated outbound ports should be a multiple of 8 between 0 and %d", SNATPortsPerFrontendIP)))
+		return allErrs
+	}
+
+	if lb.ExpectedBackendPoolSize != nil {
+		ips := frontendIPsCount(lb)
+		if available := int64(ips) * SNATPortsPerFrontendIP; int64(ports)*int64(*lb.ExpectedBackendPoolSize) > available {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("allocatedOutboundPorts"), ports,
+				fmt.Sprintf("%d ports for each of the %d expected backend instances exceed the %d SNAT ports provided by %d frontend IPs, "+
+					"allocate at most %d ports or add frontend IPs", ports, *lb.ExpectedBackendPoolSize, available, ips,
+					maxAllocatedOutboundPorts(ips, *lb.ExpectedBackendPoolSize))))
+		}
+	}
+
+	return allErrs
+}
+
+// allocatedOutboundPortsWarnings warns about an outbound rule that is likely to leave the instances behind a load
+// balancer short of SNAT ports.
+func allocatedOutboundPortsWarnings(lb *LoadBalancerSpec, fldPath *field.Path) []string {
+	if lb == nil || lb.Type == Internal {
+		return nil
+	}
+
+	ports := pointer.Int32Deref(lb.AllocatedOutboundPorts, 0)
+	if ports == 0 {
+		if lb.ExpectedBackendPoolSize == nil {
+			return nil
+		}
+		// Without an explicit allocation, Azure gives fewer ports to each instance the larger the backend pool is.
+		ports = defaultAllocatedOutboundPorts(*lb.ExpectedBackendPoolSize)
+		if ports >= minRecommendedOutboundPorts {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: not set, so Azure allocates only %d SNAT ports to each of the %d expected backend instances, "+
+			"which is likely to exhaust them; consider setting it explicitly", fldPath.Child("allocatedOutboundPorts"), ports, *lb.ExpectedBackendPoolSize)}
+	}
+
+	if ports < minRecommendedOutboundPorts {
+		return []string{fmt.Sprintf("%s: %d SNAT ports for each backend instance are likely to be exhausted, consider allocating at least %d",
+			fldPath.Child("allocatedOutboundPorts"), ports, minRecommendedOutboundPorts)}
+	}
+	return nil
+}
+
+// frontendIPsCount returns the number of frontend IPs of a load balancer.
+func frontendIPsCount(lb LoadBalancerSpec) int32 {
+	if len(lb.FrontendIPs) != 0 {
+		return int32(len(lb.FrontendIPs))
+	}
+	return pointer.Int32Deref(lb.FrontendIPsCount, 1)
+}
+
+// maxAllocatedOutboundPorts returns the largest number of SNAT ports, a multiple of 8, that frontend IPs can allocate to
+// each instance of a backend pool.
+func maxAllocatedOutboundPorts(frontendIPs int32, backendPoolSize int32) int64 {
+	ports := int64(frontendIPs) * SNATPortsPerFrontendIP / int64(backendPoolSize)
+	if ports > SNATPortsPerFrontendIP {
+		ports = SNATPortsPerFrontendIP
+	}
+	return ports - ports%8
+}
+
+// defaultAllocatedOutboundPorts returns the number of SNAT ports Azure allocates to each instance of a backend pool when
+// they are not set explicitly.
+// https://docs.microsoft.com/en-us/azure/load-balancer/load-balancer-outbound-connections#preallocatedports
+func defaultAllocatedOutboundPorts(backendPoolSize int32) int32 {
+	switch {
+	case backendPoolSize <= 50:
+		return 1024
+	case backendPoolSize <= 100:
+		return 512
+	case backendPoolSize <= 200:
+		return 256
+	case backendPoolSize <= 400:
+		return 128
+	case backendPoolSize <= 800:
+		return 64
+	default:
+		return 32
+	}
+}
+
 // validatePrivateDNSZoneName validate the PrivateDNSZoneName.
 func validatePrivateDNSZoneName(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
@@ -441,3 +1037,24 @@ func validateCloudProviderConfigOverrides(old, new *CloudProviderConfigOverrides
 	}
 	return allErrs
 }
+
+// validatePrivateDNSZoneLocation validates the resource group and subscription of an existing private DNS zone.
+func validatePrivateDNSZoneLocation(networkSpec NetworkSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(networkSpec.PrivateDNSZoneResourceGroup) > 0 {
+		if networkSpec.APIServerLB.Type != Internal {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("privateDNSZoneResourceGroup"), networkSpec.PrivateDNSZoneResourceGroup,
+				"PrivateDNSZoneResourceGroup is available only if APIServerLB.Type is Internal"))
+		}
+		if err := validateResourceGroup(networkSpec.PrivateDNSZoneResourceGroup, fldPath.Child("privateDNSZoneResourceGroup")); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if len(networkSpec.PrivateDNSZoneSubscriptionID) > 0 && len(networkSpec.PrivateDNSZoneResourceGroup) == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("privateDNSZoneSubscriptionID"), networkSpec.PrivateDNSZoneSubscriptionID,
+			"PrivateDNSZoneSubscriptionID is available only with PrivateDNSZoneResourceGroup"))
+	}
+
+	return allErrs
+}
@@ -18,12 +18,15 @@ package v1alpha4
 
 import (
 	"testing"
+	"time"
 
 	"k8s.io/utils/pointer"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
 func TestClusterNameValidation(t *testing.T) {
@@ -551,6 +554,70 @@ func TestSubnetNameInvalid(t *testing.T) {
 	})
 }
 
+func TestValidateServiceEndpoints(t *testing.T) {
+	fldPath := field.NewPath("serviceEndpoints")
+	tests := []struct {
+		name             string
+		serviceEndpoints []ServiceEndpointSpec
+		expectedErr      field.ErrorList
+	}{
+		{
+			name: "no service endpoints",
+		},
+		{
+			name: "valid service endpoints",
+			serviceEndpoints: []ServiceEndpointSpec{
+				{Service: "Microsoft.Storage", Locations: []string{"westeurope", "northeurope"}},
+				{Service: "Microsoft.KeyVault", Locations: []string{"*"}},
+			},
+		},
+		{
+			name: "unknown service",
+			serviceEndpoints: []ServiceEndpointSpec{
+				{Service: "Microsoft.Compute", Locations: []string{"*"}},
+			},
+			expectedErr: field.ErrorList{field.NotSupported(fldPath.Index(0).Child("service"), "Microsoft.Compute", serviceEndpointServices)},
+		},
+		{
+			name: "duplicate service",
+			serviceEndpoints: []ServiceEndpointSpec{
+				{Service: "Microsoft.Storage", Locations: []string{"westeurope"}},
+				{Service: "microsoft.storage", Locations: []string{"northeurope"}},
+			},
+			expectedErr: field.ErrorList{field.Duplicate(fldPath.Index(1).Child("service"), "microsoft.storage")},
+		},
+		{
+			name: "no locations",
+			serviceEndpoints: []ServiceEndpointSpec{
+				{Service: "Microsoft.Storage"},
+			},
+			expectedErr: field.ErrorList{field.Required(fldPath.Index(0).Child("locations"), "at least one location, or * for all of them, is required")},
+		},
+		{
+			name: "invalid locations",
+			serviceEndpoints: []ServiceEndpointSpec{
+				{Service: "Microsoft.Storage", Locations: []string{"westeurope", "", "WestEurope", "*"}},
+			},
+			expectedErr: field.ErrorList{
+				field.Required(fldPath.Index(0).Child("locations").Index(1), "location must not be empty"),
+				field.Duplicate(fldPath.Index(0).Child("locations").Index(2), "WestEurope"),
+				field.Invalid(fldPath.Index(0).Child("locations").Index(3), "*", "* covers all locations and cannot be combined with other locations"),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateServiceEndpoints(tc.serviceEndpoints, fldPath)
+			if tc.expectedErr == nil {
+				g.Expect(err).To(BeEmpty())
+			} else {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			}
+		})
+	}
+}
+
 func TestValidateSubnetCIDR(t *testing.T) {
 	g := NewWithT(t)
 
@@ -591,6 +658,18 @@ func TestValidateSubnetCIDR(t *testing.T) {
 				Detail:   "subnet CIDR not in vnet CIDR range",
 			},
 		},
+		{
+			name:             "subnet cidr partially in vnet range",
+			vnetCidrBlocks:   []string{"10.0.0.0/16"},
+			subnetCidrBlocks: []string{"10.0.0.0/8"},
+			wantErr:          true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "subnets.cidrBlocks",
+				BadValue: "10.0.0.0/8",
+				Detail:   "subnet CIDR not in vnet CIDR range",
+			},
+		},
 		{
 			name:             "subnet cidr in atleast one vnet's range in case of multiple vnet cidr blocks",
 			vnetCidrBlocks:   []string{"10.0.0.0/8", "11.0.0.0/8"},
@@ -617,6 +696,113 @@ func TestValidateSubnetCIDR(t *testing.T) {
 	}
 }
 
+func TestValidateSubnetsOverlap(t *testing.T) {
+	tests := []struct {
+		name        string
+		subnets     Subnets
+		expectedErr field.ErrorList
+	}{
+		{
+			name: "subnets don't overlap",
+			subnets: Subnets{
+				{Name: "control-plane-subnet", CIDRBlocks: []string{"10.0.0.0/16"}},
+				{Name: "node-subnet", CIDRBlocks: []string{"10.1.0.0/16", "10.2.0.0/16"}},
+			},
+		},
+		{
+			name: "subnet contained in another subnet",
+			subnets: Subnets{
+				{Name: "control-plane-subnet", CIDRBlocks: []string{"10.0.0.0/16"}},
+				{Name: "node-subnet", CIDRBlocks: []string{"10.1.0.0/16", "10.0.128.0/24"}},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(field.NewPath("subnets").Index(1).Child("cidrBlocks"), "10.0.128.0/24",
+					"subnet CIDR overlaps with CIDR 10.0.0.0/16 of subnet control-plane-subnet"),
+			},
+		},
+		{
+			name: "subnet containing another subnet",
+			subnets: Subnets{
+				{Name: "control-plane-subnet", CIDRBlocks: []string{"10.0.0.0/24"}},
+				{Name: "node-subnet", CIDRBlocks: []string{"10.0.0.0/8"}},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(field.NewPath("subnets").Index(1).Child("cidrBlocks"), "10.0.0.0/8",
+					"subnet CIDR overlaps with CIDR 10.0.0.0/24 of subnet control-plane-subnet"),
+			},
+		},
+		{
+			name: "invalid CIDRs are ignored",
+			subnets: Subnets{
+				{Name: "control-plane-subnet", CIDRBlocks: []string{"foo/bar"}},
+				{Name: "node-subnet", CIDRBlocks: []string{"foo/bar"}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateSubnetsOverlap(tc.subnets, field.NewPath("subnets"))
+			if tc.expectedErr != nil {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateSubnetsClusterNetwork(t *testing.T) {
+	subnets := Subnets{
+		{Name: "control-plane-subnet", CIDRBlocks: []string{"10.0.0.0/16"}},
+		{Name: "node-subnet", CIDRBlocks: []string{"10.1.0.0/16"}},
+	}
+	tests := []struct {
+		name           string
+		clusterNetwork *clusterv1.ClusterNetwork
+		expectedErr    field.ErrorList
+	}{
+		{
+			name: "no cluster network",
+		},
+		{
+			name: "pod and service CIDRs outside of the subnets",
+			clusterNetwork: &clusterv1.ClusterNetwork{
+				Pods:     &clusterv1.NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+				Services: &clusterv1.NetworkRanges{CIDRBlocks: []string{"10.96.0.0/12"}},
+			},
+		},
+		{
+			name: "pod and service CIDRs overlapping the subnets",
+			clusterNetwork: &clusterv1.ClusterNetwork{
+				Pods:     &clusterv1.NetworkRanges{CIDRBlocks: []string{"10.1.128.0/17"}},
+				Services: &clusterv1.NetworkRanges{CIDRBlocks: []string{"10.0.0.0/8"}},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(field.NewPath("subnets").Index(0).Child("cidrBlocks"), "10.0.0.0/16",
+					"subnet CIDR overlaps with service CIDR 10.0.0.0/8 of the cluster"),
+				field.Invalid(field.NewPath("subnets").Index(1).Child("cidrBlocks"), "10.1.0.0/16",
+					"subnet CIDR overlaps with pod CIDR 10.1.128.0/17 of the cluster"),
+				field.Invalid(field.NewPath("subnets").Index(1).Child("cidrBlocks"), "10.1.0.0/16",
+					"subnet CIDR overlaps with service CIDR 10.0.0.0/8 of the cluster"),
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateSubnetsClusterNetwork(subnets, tc.clusterNetwork, field.NewPath("subnets"))
+			if tc.expectedErr != nil {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestValidateSecurityRule(t *testing.T) {
 	g := NewWithT(t)
 
@@ -948,6 +1134,61 @@ func TestPrivateDNSZoneName(t *testing.T) {
 	}
 }
 
+func TestPrivateDNSZoneLocation(t *testing.T) {
+	g := NewWithT(t)
+
+	testcases := []struct {
+		name    string
+		network NetworkSpec
+		errs    []string
+	}{
+		{
+			name:    "zone of the cluster",
+			network: NetworkSpec{APIServerLB: createValidAPIServerInternalLB()},
+		},
+		{
+			name: "existing zone of another subscription",
+			network: NetworkSpec{
+				APIServerLB:                  createValidAPIServerInternalLB(),
+				PrivateDNSZoneResourceGroup:  "hub-rg",
+				PrivateDNSZoneSubscriptionID: "456",
+			},
+		},
+		{
+			name: "public API server",
+			network: NetworkSpec{
+				APIServerLB:                 LoadBalancerSpec{Name: "my-lb", Type: Public},
+				PrivateDNSZoneResourceGroup: "hub-rg",
+			},
+			errs: []string{"spec.networkSpec.privateDNSZoneResourceGroup"},
+		},
+		{
+			name: "invalid resource group",
+			network: NetworkSpec{
+				APIServerLB:                 createValidAPIServerInternalLB(),
+				PrivateDNSZoneResourceGroup: "hub rg!",
+			},
+			errs: []string{"spec.networkSpec.privateDNSZoneResourceGroup"},
+		},
+		{
+			name: "subscription without resource group",
+			network: NetworkSpec{
+				APIServerLB:                  createValidAPIServerInternalLB(),
+				PrivateDNSZoneSubscriptionID: "456",
+			},
+			errs: []string{"spec.networkSpec.privateDNSZoneSubscriptionID"},
+		},
+	}
+	for _, tc := range testcases {
+		errs := validatePrivateDNSZoneLocation(tc.network, field.NewPath("spec", "networkSpec"))
+		fields := make([]string, 0, len(errs))
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		g.Expect(fields).To(ConsistOf(tc.errs), tc.name)
+	}
+}
+
 func TestValidateNodeOutboundLB(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1077,6 +1318,38 @@ func TestValidateNodeOutboundLB(t *testing.T) {
 				Detail:   "Max front end ips allowed is 16",
 			},
 		},
+		{
+			name: "allocated outbound ports modified",
+			lb: &LoadBalancerSpec{
+				AllocatedOutboundPorts: pointer.Int32Ptr(2048),
+			},
+			old: &LoadBalancerSpec{
+				AllocatedOutboundPorts: pointer.Int32Ptr(1024),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueForbidden",
+				Field:    "nodeOutboundLB.allocatedOutboundPorts",
+				BadValue: nil,
+				Detail:   "Node outbound load balancer allocated outbound ports cannot be modified after AzureCluster creation.",
+			},
+		},
+		{
+			name: "allocated outbound ports exceed the ports of the frontend ips",
+			lb: &LoadBalancerSpec{
+				FrontendIPsCount:        pointer.Int32Ptr(1),
+				AllocatedOutboundPorts:  pointer.Int32Ptr(2048),
+				ExpectedBackendPoolSize: pointer.Int32Ptr(40),
+			},
+			wantErr: true,
+			expectedErr: field.Error{
+				Type:     "FieldValueInvalid",
+				Field:    "nodeOutboundLB.allocatedOutboundPorts",
+				BadValue: int32(2048),
+				Detail: "2048 ports for each of the 40 expected backend instances exceed the 64000 SNAT ports provided by 1 frontend IPs, " +
+					"allocate at most 1600 ports or add frontend IPs",
+			},
+		},
 	}
 
 	for _, test := range testcases {
@@ -1100,6 +1373,136 @@ func TestValidateNodeOutboundLB(t *testing.T) {
 	}
 }
 
+func TestValidateAllocatedOutboundPorts(t *testing.T) {
+	tests := []struct {
+		name        string
+		lb          LoadBalancerSpec
+		expectedErr field.ErrorList
+	}{
+		{
+			name: "no allocated outbound ports",
+			lb: LoadBalancerSpec{
+				Type:                    Public,
+				ExpectedBackendPoolSize: pointer.Int32Ptr(1000),
+			},
+		},
+		{
+			name: "allocated outbound ports fit the frontend ips",
+			lb: LoadBalancerSpec{
+				Type:                    Public,
+				FrontendIPs:             []FrontendIP{{Name: "ip-1"}, {Name: "ip-2"}},
+				AllocatedOutboundPorts:  pointer.Int32Ptr(3200),
+				ExpectedBackendPoolSize: pointer.Int32Ptr(40),
+			},
+		},
+		{
+			name: "allocated outbound ports without an expected backend pool size",
+			lb: LoadBalancerSpec{
+				Type:                   Public,
+				AllocatedOutboundPorts: pointer.Int32Ptr(64000),
+			},
+		},
+		{
+			name: "allocated outbound ports exceed the ports of the frontend ips",
+			lb: LoadBalancerSpec{
+				Type:                    Public,
+				FrontendIPs:             []FrontendIP{{Name: "ip-1"}, {Name: "ip-2"}},
+				AllocatedOutboundPorts:  pointer.Int32Ptr(3208),
+				ExpectedBackendPoolSize: pointer.Int32Ptr(40),
+			},
+			expectedErr: field.ErrorList{field.Invalid(field.NewPath("lb", "allocatedOutboundPorts"), int32(3208),
+				"3208 ports for each of the 40 expected backend instances exceed the 128000 SNAT ports provided by 2 frontend IPs, "+
+					"allocate at most 3200 ports or add frontend IPs")},
+		},
+		{
+			name: "allocated outbound ports not a multiple of 8",
+			lb: LoadBalancerSpec{
+				Type:                   Public,
+				AllocatedOutboundPorts: pointer.Int32Ptr(1004),
+			},
+			expectedErr: field.ErrorList{field.Invalid(field.NewPath("lb", "allocatedOutboundPorts"), int32(1004),
+				"Allocated outbound ports should be a multiple of 8 between 0 and 64000")},
+		},
+		{
+			name: "allocated outbound ports on an internal load balancer",
+			lb: LoadBalancerSpec{
+				Type:                   Internal,
+				AllocatedOutboundPorts: pointer.Int32Ptr(1024),
+			},
+			expectedErr: field.ErrorList{field.Forbidden(field.NewPath("lb", "allocatedOutboundPorts"),
+				"Internal load balancers have no outbound rule to allocate ports with")},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateAllocatedOutboundPorts(tc.lb, field.NewPath("lb"))
+			if tc.expectedErr == nil {
+				g.Expect(err).To(BeEmpty())
+			} else {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			}
+		})
+	}
+}
+
+func TestAllocatedOutboundPortsWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		lb       *LoadBalancerSpec
+		expected []string
+	}{
+		{
+			name: "no load balancer",
+		},
+		{
+			name: "default allocation for a small backend pool",
+			lb: &LoadBalancerSpec{
+				Type:                    Public,
+				ExpectedBackendPoolSize: pointer.Int32Ptr(50),
+			},
+		},
+		{
+			name: "default allocation for a large backend pool",
+			lb: &LoadBalancerSpec{
+				Type:                    Public,
+				ExpectedBackendPoolSize: pointer.Int32Ptr(300),
+			},
+			expected: []string{"lb.allocatedOutboundPorts: not set, so Azure allocates only 128 SNAT ports to each of the 300 expected backend instances, " +
+				"which is likely to exhaust them; consider setting it explicitly"},
+		},
+		{
+			name: "few allocated outbound ports",
+			lb: &LoadBalancerSpec{
+				Type:                   Public,
+				AllocatedOutboundPorts: pointer.Int32Ptr(256),
+			},
+			expected: []string{"lb.allocatedOutboundPorts: 256 SNAT ports for each backend instance are likely to be exhausted, consider allocating at least 1024"},
+		},
+		{
+			name: "enough allocated outbound ports",
+			lb: &LoadBalancerSpec{
+				Type:                    Public,
+				AllocatedOutboundPorts:  pointer.Int32Ptr(4000),
+				ExpectedBackendPoolSize: pointer.Int32Ptr(300),
+			},
+		},
+		{
+			name: "internal load balancer",
+			lb: &LoadBalancerSpec{
+				Type:                    Internal,
+				ExpectedBackendPoolSize: pointer.Int32Ptr(300),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(allocatedOutboundPortsWarnings(tc.lb, field.NewPath("lb"))).To(Equal(tc.expected))
+		})
+	}
+}
+
 func TestValidateCloudProviderConfigOverrides(t *testing.T) {
 	g := NewWithT(t)
 
@@ -1278,3 +1681,546 @@ func createValidAPIServerInternalLB() LoadBalancerSpec {
 		Type: Internal,
 	}
 }
+
+func TestValidateServiceIdentityRefs(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name           string
+		refs           []ServiceIdentityRef
+		expectedFields []string
+	}{
+		{
+			name: "no overrides",
+		},
+		{
+			name: "one identity per service",
+			refs: []ServiceIdentityRef{{Service: PrivateDNSService, IdentityRef: &corev1.ObjectReference{Name: "dns-identity"}}},
+		},
+		{
+			name: "service with two identities",
+			refs: []ServiceIdentityRef{
+				{Service: PrivateDNSService, IdentityRef: &corev1.ObjectReference{Name: "dns-identity"}},
+				{Service: PrivateDNSService, IdentityRef: &corev1.ObjectReference{Name: "other-identity"}},
+			},
+			expectedFields: []string{"spec.serviceIdentityRefs[1].service"},
+		},
+		{
+			name:           "service without identity",
+			refs:           []ServiceIdentityRef{{Service: PrivateDNSService}},
+			expectedFields: []string{"spec.serviceIdentityRefs[0].identityRef"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateServiceIdentityRefs(tc.refs, field.NewPath("spec", "serviceIdentityRefs"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestServiceIdentityRefsWarnings(t *testing.T) {
+	g := NewWithT(t)
+
+	refs := []ServiceIdentityRef{{Service: PrivateDNSService, IdentityRef: &corev1.ObjectReference{Name: "dns-identity"}}}
+	fldPath := field.NewPath("spec", "serviceIdentityRefs")
+
+	g.Expect(serviceIdentityRefsWarnings(nil, LoadBalancerSpec{Type: Public}, fldPath)).To(BeEmpty())
+	g.Expect(serviceIdentityRefsWarnings(refs, LoadBalancerSpec{Type: Internal}, fldPath)).To(BeEmpty())
+	g.Expect(serviceIdentityRefsWarnings(refs, LoadBalancerSpec{Type: Public}, fldPath)).To(Equal([]string{
+		"spec.serviceIdentityRefs[0]: ignored, as the PrivateDNS service is only used with an internal API server load balancer",
+	}))
+}
+
+func TestValidateTimeoutAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		expectedFields []string
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid timeouts",
+			annotations: map[string]string{
+				ServiceReconcileTimeoutAnnotation: "45m",
+				AzureCallTimeoutAnnotation:        "1h30m",
+			},
+		},
+		{
+			name:           "not a duration",
+			annotations:    map[string]string{ServiceReconcileTimeoutAnnotation: "forever"},
+			expectedFields: []string{"metadata.annotations[" + ServiceReconcileTimeoutAnnotation + "]"},
+		},
+		{
+			name:           "zero duration",
+			annotations:    map[string]string{AzureCallTimeoutAnnotation: "0s"},
+			expectedFields: []string{"metadata.annotations[" + AzureCallTimeoutAnnotation + "]"},
+		},
+		{
+			name:           "negative duration",
+			annotations:    map[string]string{AzureCallTimeoutAnnotation: "-5m"},
+			expectedFields: []string{"metadata.annotations[" + AzureCallTimeoutAnnotation + "]"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &AzureCluster{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			errs := cluster.validateTimeoutAnnotations()
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
+
+func TestValidateDryRunAnnotation(t *testing.T) {
+	g := NewWithT(t)
+
+	for value, valid := range map[string]bool{"true": true, "false": true, "yes": false, "": false} {
+		cluster := &AzureCluster{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{DryRunAnnotation: value}}}
+		g.Expect(cluster.validateDryRunAnnotation()).To(HaveLen(map[bool]int{true: 0, false: 1}[valid]), value)
+	}
+	g.Expect((&AzureCluster{}).validateDryRunAnnotation()).To(BeEmpty())
+}
+
+func TestValidateReadOnlyAnnotation(t *testing.T) {
+	g := NewWithT(t)
+
+	for value, valid := range map[string]bool{"true": true, "false": true, "yes": false, "": false} {
+		cluster := &AzureCluster{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ReadOnlyAnnotation: value}}}
+		g.Expect(cluster.validateReadOnlyAnnotation()).To(HaveLen(map[bool]int{true: 0, false: 1}[valid]), value)
+	}
+	g.Expect((&AzureCluster{}).validateReadOnlyAnnotation()).To(BeEmpty())
+}
+
+func TestValidateSkipServiceAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &AzureCluster{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		SkipServiceAnnotationPrefix + "loadbalancers": "true",
+		SkipServiceAnnotationPrefix + "subnets":       "false",
+		"example.com/skip-this":                       "yes",
+	}}}
+	g.Expect(cluster.validateSkipServiceAnnotations()).To(BeEmpty())
+
+	cluster.Annotations[SkipServiceAnnotationPrefix+"routetables"] = "yes"
+	cluster.Annotations[SkipServiceAnnotationPrefix] = "true"
+	errs := cluster.validateSkipServiceAnnotations()
+	g.Expect(errs).To(HaveLen(2))
+	g.Expect(errs.ToAggregate().Error()).To(And(ContainSubstring("must be true or false"), ContainSubstring("must name a service")))
+}
+
+func TestValidateDriftDetection(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name           string
+		driftDetection *DriftDetection
+		wantErr        bool
+	}{
+		{
+			name: "no drift detection",
+		},
+		{
+			name:           "valid interval",
+			driftDetection: &DriftDetection{Interval: metav1.Duration{Duration: 10 * time.Minute}},
+		},
+		{
+			name:           "interval too short",
+			driftDetection: &DriftDetection{Interval: metav1.Duration{Duration: 30 * time.Second}},
+			wantErr:        true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateDriftDetection(tc.driftDetection, field.NewPath("spec", "driftDetection"))
+			if tc.wantErr {
+				g.Expect(err).NotTo(BeNil())
+				g.Expect(err.Field).To(Equal("spec.driftDetection.interval"))
+			} else {
+				g.Expect(err).To(BeNil())
+			}
+		})
+	}
+}
+
+func TestValidateAzureResourceNaming(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		naming      *AzureResourceNaming
+		expectedErr field.ErrorList
+	}{
+		{
+			name: "no naming",
+		},
+		{
+			name: "valid templates",
+			naming: &AzureResourceNaming{
+				Prefix: "weu-",
+				Templates: []AzureResourceNamingTemplate{
+					{Kind: VirtualNetworkKind, Pattern: "vnet-{cluster}"},
+					{Kind: OSDiskKind, Pattern: "disk-{machine}-os"},
+					{Kind: NetworkInterfaceKind, Suffix: pointer.StringPtr("-nic")},
+				},
+			},
+		},
+		{
+			name: "duplicate kind",
+			naming: &AzureResourceNaming{
+				Templates: []AzureResourceNamingTemplate{
+					{Kind: VirtualNetworkKind, Pattern: "vnet-{cluster}"},
+					{Kind: VirtualNetworkKind, Pattern: "{cluster}-network"},
+				},
+			},
+			expectedErr: field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "azureResourceNaming", "templates").Index(1).Child("kind"), VirtualNetworkKind),
+			},
+		},
+		{
+			name: "machine pattern without machine name",
+			naming: &AzureResourceNaming{
+				Templates: []AzureResourceNamingTemplate{
+					{Kind: NetworkInterfaceKind, Pattern: "nic-{cluster}"},
+				},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "azureResourceNaming", "templates").Index(0).Child("pattern"), "nic-{cluster}",
+					"pattern of the resources of machines must contain {machine}"),
+			},
+		},
+		{
+			name: "cluster pattern with machine name",
+			naming: &AzureResourceNaming{
+				Templates: []AzureResourceNamingTemplate{
+					{Kind: NodeSubnetKind, Pattern: "{cluster}-{machine}"},
+				},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "azureResourceNaming", "templates").Index(0).Child("pattern"), "{cluster}-{machine}",
+					"only the patterns of the resources of machines can contain {machine}"),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAzureResourceNaming(tc.naming, field.NewPath("spec", "azureResourceNaming"))
+			if tc.expectedErr != nil {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateDisabledServices(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		name        string
+		spec        AzureClusterSpec
+		expectedErr field.ErrorList
+	}{
+		{
+			name: "no disabled services",
+			spec: AzureClusterSpec{
+				BastionSpec: BastionSpec{AzureBastion: &AzureBastion{}},
+			},
+		},
+		{
+			name: "disabled services without their resources",
+			spec: AzureClusterSpec{
+				DisabledServices: []ManagedService{BastionManagedService, PrivateDNSManagedService, NodeOutboundLBManagedService},
+			},
+		},
+		{
+			name: "duplicate service",
+			spec: AzureClusterSpec{
+				DisabledServices: []ManagedService{BastionManagedService, BastionManagedService},
+			},
+			expectedErr: field.ErrorList{
+				field.Duplicate(field.NewPath("spec", "disabledServices").Index(1), BastionManagedService),
+			},
+		},
+		{
+			name: "disabled bastion with azure bastion",
+			spec: AzureClusterSpec{
+				DisabledServices: []ManagedService{BastionManagedService},
+				BastionSpec:      BastionSpec{AzureBastion: &AzureBastion{}},
+			},
+			expectedErr: field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "bastionSpec", "azureBastion"), "must not be set when the Bastion service is disabled"),
+			},
+		},
+		{
+			name: "disabled private dns with zone placement and identity",
+			spec: AzureClusterSpec{
+				DisabledServices: []ManagedService{PrivateDNSManagedService},
+				NetworkSpec: NetworkSpec{
+					PrivateDNSZoneResourceGroup:  "dns-rg",
+					PrivateDNSZoneSubscriptionID: "dns-subscription",
+				},
+				ServiceIdentityRefs: []ServiceIdentityRef{{Service: PrivateDNSService}},
+			},
+			expectedErr: field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "networkSpec", "privateDNSZoneResourceGroup"), "must not be set when the PrivateDNS service is disabled"),
+				field.Forbidden(field.NewPath("spec", "networkSpec", "privateDNSZoneSubscriptionID"), "must not be set when the PrivateDNS service is disabled"),
+				field.Forbidden(field.NewPath("spec", "serviceIdentityRefs").Index(0), "must not reference the PrivateDNS service, which is disabled"),
+			},
+		},
+		{
+			name: "disabled node outbound lb with node outbound lb",
+			spec: AzureClusterSpec{
+				DisabledServices: []ManagedService{NodeOutboundLBManagedService},
+				NetworkSpec:      NetworkSpec{NodeOutboundLB: &LoadBalancerSpec{}},
+			},
+			expectedErr: field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "networkSpec", "nodeOutboundLB"), "must not be set when the NodeOutboundLoadBalancer service is disabled"),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &AzureCluster{Spec: tc.spec}
+			err := cluster.validateDisabledServices()
+			if tc.expectedErr != nil {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateFailureDomainOverrides(t *testing.T) {
+	g := NewWithT(t)
+	fldPath := field.NewPath("spec", "failureDomainOverrides")
+
+	tests := []struct {
+		name        string
+		overrides   []FailureDomainOverride
+		old         []FailureDomainOverride
+		expectedErr field.ErrorList
+	}{
+		{
+			name: "no overrides",
+		},
+		{
+			name: "valid overrides",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+				{Zone: "2", Name: "2"},
+				{Zone: "3", Excluded: true},
+			},
+		},
+		{
+			name: "duplicate zone",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Excluded: true},
+				{Zone: "1", Name: "zone-a"},
+			},
+			expectedErr: field.ErrorList{
+				field.Duplicate(fldPath.Index(1).Child("zone"), "1"),
+			},
+		},
+		{
+			name: "duplicate name",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+				{Zone: "2", Name: "zone-a"},
+			},
+			expectedErr: field.ErrorList{
+				field.Duplicate(fldPath.Index(1).Child("name"), "zone-a"),
+			},
+		},
+		{
+			name: "name of another zone",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Name: "3"},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(fldPath.Index(0).Child("name"), "3", "must not be the name of another availability zone"),
+			},
+		},
+		{
+			name: "zone excluded after being named",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a", Excluded: true},
+			},
+			old: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+			},
+		},
+		{
+			name: "name changed",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-b"},
+			},
+			old: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(fldPath, "zone-b", "name of the failure domain of zone 1 is immutable once set"),
+			},
+		},
+		{
+			name: "named override removed",
+			old: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+			},
+			expectedErr: field.ErrorList{
+				field.Invalid(fldPath, "", "name of the failure domain of zone 1 is immutable once set"),
+			},
+		},
+		{
+			name: "name set on existing override",
+			overrides: []FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+			},
+			old: []FailureDomainOverride{
+				{Zone: "1", Excluded: true},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFailureDomainOverrides(tc.overrides, tc.old, fldPath)
+			if tc.expectedErr != nil {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			} else {
+				g.Expect(err).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateNetworkSpecUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	managedNetworkSpec := func() NetworkSpec {
+		networkSpec := createValidNetworkSpec()
+		networkSpec.Vnet.ID = "/subscriptions/123/resourceGroups/custom-vnet/providers/Microsoft.Network/virtualNetworks/my-vnet"
+		networkSpec.Vnet.CIDRBlocks = []string{DefaultVnetCIDR}
+		networkSpec.Vnet.Tags = Tags{ClusterTagKey("test-cluster"): string(ResourceLifecycleOwned)}
+		networkSpec.Subnets[0].ID = "/subscriptions/123/resourceGroups/custom-vnet/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/control-plane-subnet"
+		networkSpec.Subnets[0].CIDRBlocks = []string{DefaultControlPlaneSubnetCIDR}
+		return networkSpec
+	}
+	fldPath := field.NewPath("spec", "networkSpec")
+
+	tests := []struct {
+		name        string
+		old         NetworkSpec
+		update      func(*NetworkSpec)
+		expectedErr string
+	}{
+		{
+			name:   "unchanged",
+			old:    managedNetworkSpec(),
+			update: func(*NetworkSpec) {},
+		},
+		{
+			name: "vnet and subnet CIDR blocks set by the controller",
+			old:  createValidNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				*networkSpec = managedNetworkSpec()
+			},
+		},
+		{
+			name: "vnet renamed",
+			old:  managedNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.Vnet.Name = "other-vnet"
+			},
+			expectedErr: "spec.networkSpec.vnet.name",
+		},
+		{
+			name: "vnet resource group changed",
+			old:  managedNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.Vnet.ResourceGroup = "other-rg"
+			},
+			expectedErr: "spec.networkSpec.vnet.resourceGroup",
+		},
+		{
+			name: "managed vnet CIDR blocks changed",
+			old:  managedNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.Vnet.CIDRBlocks = []string{"10.1.0.0/16"}
+			},
+			expectedErr: "spec.networkSpec.vnet.cidrBlocks",
+		},
+		{
+			name: "unmanaged vnet CIDR blocks changed",
+			old: func() NetworkSpec {
+				networkSpec := managedNetworkSpec()
+				networkSpec.Vnet.Tags = nil
+				return networkSpec
+			}(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.Vnet.Tags = nil
+				networkSpec.Vnet.CIDRBlocks = []string{"10.1.0.0/16"}
+				networkSpec.Subnets[0].CIDRBlocks = []string{"10.1.0.0/24"}
+			},
+		},
+		{
+			name: "subnet renamed",
+			old:  managedNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.Subnets[1].Name = "other-subnet"
+			},
+			expectedErr: "spec.networkSpec.subnets[1].name",
+		},
+		{
+			name: "subnet CIDR blocks changed",
+			old:  managedNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.Subnets[0].CIDRBlocks = []string{"10.0.1.0/24"}
+			},
+			expectedErr: "spec.networkSpec.subnets[0].cidrBlocks",
+		},
+		{
+			name: "API server public IP DNS name changed",
+			old:  managedNetworkSpec(),
+			update: func(networkSpec *NetworkSpec) {
+				networkSpec.APIServerLB.FrontendIPs[0].PublicIP.DNSName = "other.azure.com"
+			},
+			expectedErr: "spec.networkSpec.apiServerLB.frontendIPs[0].publicIP.dnsName",
+		},
+		{
+			name: "API server public IP DNS name set by the controller",
+			old: func() NetworkSpec {
+				networkSpec := managedNetworkSpec()
+				networkSpec.APIServerLB.FrontendIPs[0].PublicIP.DNSName = ""
+				return networkSpec
+			}(),
+			update: func(*NetworkSpec) {},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			networkSpec := *tc.old.DeepCopy()
+			tc.update(&networkSpec)
+			errs := validateNetworkSpecUpdate(networkSpec, tc.old, "test-cluster", fldPath)
+			if tc.expectedErr == "" {
+				g.Expect(errs).To(BeEmpty())
+				return
+			}
+			g.Expect(errs).To(HaveLen(1))
+			g.Expect(errs[0].Field).To(Equal(tc.expectedErr))
+			g.Expect(errs[0].Detail).To(ContainSubstring("create a new cluster with the new value"))
+		})
+	}
+}
@@ -23,15 +23,26 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	webhookutil "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 )
 
 // log is for logging in this package.
 var clusterlog = logf.Log.WithName("azurecluster-resource")
 
+// azureClusterWebhookClient is used to look up the Cluster an AzureCluster belongs to. It is set up together with the
+// webhook.
+var azureClusterWebhookClient client.Client
+
 // SetupWebhookWithManager sets up and registers the webhook with the manager.
 func (c *AzureCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	azureClusterWebhookClient = mgr.GetClient()
+	if err := webhookutil.RegisterValidatingWebhookWithWarnings(mgr, c); err != nil {
+		return err
+	}
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(c).
 		Complete()
@@ -41,6 +52,7 @@ func (c *AzureCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:webhook:verbs=create;update,path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha4-azurecluster,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=azureclusters,versions=v1alpha4,name=default.azurecluster.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
 
 var _ webhook.Validator = &AzureCluster{}
+var _ webhookutil.Warner = &AzureCluster{}
 var _ webhook.Defaulter = &AzureCluster{}
 
 // Default implements webhook.Defaulter so a webhook will be registered for the type.
@@ -98,8 +110,30 @@ func (c *AzureCluster) ValidateUpdate(oldRaw runtime.Object) error {
 		)
 	}
 
-	// Allow enabling azure bastion but avoid disabling it.
-	if old.Spec.BastionSpec.AzureBastion != nil && !reflect.DeepEqual(old.Spec.BastionSpec.AzureBastion, c.Spec.BastionSpec.AzureBastion) {
+	if !reflect.DeepEqual(c.Spec.NetworkSpec.PrivateDNSZoneResourceGroup, old.Spec.NetworkSpec.PrivateDNSZoneResourceGroup) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "NetworkSpec", "PrivateDNSZoneResourceGroup"),
+				c.Spec.NetworkSpec.PrivateDNSZoneResourceGroup, "field is immutable"),
+		)
+	}
+
+	if !reflect.DeepEqual(c.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID, old.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "NetworkSpec", "PrivateDNSZoneSubscriptionID"),
+				c.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID, "field is immutable"),
+		)
+	}
+
+	if !reflect.DeepEqual(c.Spec.AzureResourceNaming, old.Spec.AzureResourceNaming) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "AzureResourceNaming"),
+				c.Spec.AzureResourceNaming, "field is immutable"),
+		)
+	}
+
+	// Allow enabling azure bastion but avoid disabling it, unless its resources are now provided outside of the controller.
+	if old.Spec.BastionSpec.AzureBastion != nil && !reflect.DeepEqual(old.Spec.BastionSpec.AzureBastion, c.Spec.BastionSpec.AzureBastion) &&
+		!(c.Spec.BastionSpec.AzureBastion == nil && c.ServiceDisabled(BastionManagedService)) {
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("spec", "BastionSpec", "AzureBastion"),
 				c.Spec.BastionSpec.AzureBastion, "azure bastion cannot be removed from a cluster"),
@@ -119,3 +153,18 @@ func (c *AzureCluster) ValidateDelete() error {
 
 	return nil
 }
+
+// WarningsOnCreate implements webhookutil.Warner so warnings are returned when the AzureCluster is created.
+func (c *AzureCluster) WarningsOnCreate() []string {
+	warnings := serviceIdentityRefsWarnings(c.Spec.ServiceIdentityRefs, c.Spec.NetworkSpec.APIServerLB, field.NewPath("spec", "serviceIdentityRefs"))
+	warnings = append(warnings, allocatedOutboundPortsWarnings(&c.Spec.NetworkSpec.APIServerLB, field.NewPath("spec", "networkSpec", "apiServerLB"))...)
+	if !c.ServiceDisabled(NodeOutboundLBManagedService) {
+		warnings = append(warnings, allocatedOutboundPortsWarnings(c.Spec.NetworkSpec.NodeOutboundLB, field.NewPath("spec", "networkSpec", "nodeOutboundLB"))...)
+	}
+	return warnings
+}
+
+// WarningsOnUpdate implements webhookutil.Warner so warnings are returned when the AzureCluster is updated.
+func (c *AzureCluster) WarningsOnUpdate(_ runtime.Object) []string {
+	return c.WarningsOnCreate()
+}
@@ -20,6 +20,10 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestAzureCluster_ValidateCreate(t *testing.T) {
@@ -186,6 +190,44 @@ func TestAzureCluster_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "azurecluster resource naming is immutable",
+			oldCluster: &AzureCluster{
+				Spec: AzureClusterSpec{
+					AzureResourceNaming: &AzureResourceNaming{Prefix: "weu-"},
+				},
+			},
+			cluster: &AzureCluster{
+				Spec: AzureClusterSpec{
+					AzureResourceNaming: &AzureResourceNaming{Prefix: "neu-"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "azurecluster azure bastion cannot be removed",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.BastionSpec.AzureBastion = &AzureBastion{Name: "bastion"}
+				return cluster
+			}(),
+			cluster: createValidCluster(),
+			wantErr: true,
+		},
+		{
+			name: "azurecluster azure bastion can be removed when the bastion service is disabled",
+			oldCluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.BastionSpec.AzureBastion = &AzureBastion{Name: "bastion"}
+				return cluster
+			}(),
+			cluster: func() *AzureCluster {
+				cluster := createValidCluster()
+				cluster.Spec.DisabledServices = []ManagedService{BastionManagedService}
+				return cluster
+			}(),
+			wantErr: false,
+		},
 		{
 			name: "azurecluster location is immutable",
 			oldCluster: &AzureCluster{
@@ -227,3 +269,86 @@ func TestAzureCluster_ValidateUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureCluster_ValidateClusterNetwork(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+
+	azureClusterWebhookClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+			Spec: clusterv1.ClusterSpec{
+				ClusterNetwork: &clusterv1.ClusterNetwork{
+					Pods: &clusterv1.NetworkRanges{CIDRBlocks: []string{"10.1.0.0/16"}},
+				},
+			},
+		},
+	).Build()
+	defer func() { azureClusterWebhookClient = nil }()
+
+	// The node subnet of the cluster overlaps the pods of the cluster.
+	cluster := func(clusterName string) *AzureCluster {
+		cluster := createValidCluster()
+		cluster.Namespace = "default"
+		if clusterName != "" {
+			cluster.Labels = map[string]string{clusterv1.ClusterLabelName: clusterName}
+		}
+		cluster.Spec.NetworkSpec.Vnet.CIDRBlocks = []string{"10.0.0.0/8"}
+		cluster.Spec.NetworkSpec.Subnets[0].CIDRBlocks = []string{"10.0.0.0/16"}
+		cluster.Spec.NetworkSpec.Subnets[1].CIDRBlocks = []string{"10.1.0.0/16"}
+		return cluster
+	}
+
+	tests := []struct {
+		name       string
+		oldCluster *AzureCluster
+		cluster    *AzureCluster
+		wantErr    bool
+	}{
+		{
+			name:    "create without cluster label",
+			cluster: cluster(""),
+			wantErr: false,
+		},
+		{
+			name:    "create with unknown cluster",
+			cluster: cluster("unknown-cluster"),
+			wantErr: false,
+		},
+		{
+			name:    "create with overlapping pod CIDR",
+			cluster: cluster("test-cluster"),
+			wantErr: true,
+		},
+		{
+			name:       "update adding the cluster label",
+			oldCluster: cluster(""),
+			cluster:    cluster("test-cluster"),
+			wantErr:    true,
+		},
+		{
+			name:       "update changing neither the subnets nor the cluster",
+			oldCluster: cluster("test-cluster"),
+			cluster:    cluster("test-cluster"),
+			wantErr:    false,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var err error
+			if tc.oldCluster != nil {
+				err = tc.cluster.ValidateUpdate(tc.oldCluster)
+			} else {
+				err = tc.cluster.ValidateCreate()
+			}
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -55,6 +55,12 @@ type AzureClusterIdentitySpec struct {
 	ClientSecret corev1.SecretReference `json:"clientSecret,omitempty"`
 	// Service principal primary tenant id.
 	TenantID string `json:"tenantID"`
+	// AuxiliaryTenantIDs are additional tenants the identity acquires tokens in. The tokens are sent along
+	// with every request to Azure Resource Manager, which allows operations spanning tenants, like peering
+	// virtual networks of different tenants. Not supported for identities of type ServicePrincipal.
+	// +kubebuilder:validation:MaxItems=3
+	// +optional
+	AuxiliaryTenantIDs []string `json:"auxiliaryTenantIDs,omitempty"`
 	// AllowedNamespaces is used to identify the namespaces the clusters are allowed to use the identity from.
 	// Namespaces can be selected either using an array of namespaces or with label selector.
 	// An empty allowedNamespaces object indicates that AzureClusters can use this identity from any namespace.
@@ -64,6 +70,61 @@ type AzureClusterIdentitySpec struct {
 	// +optional
 	// +nullable
 	AllowedNamespaces *AllowedNamespaces `json:"allowedNamespaces"`
+	// WorkloadIdentity configures the service account tokens that are exchanged for Azure tokens when
+	// the identity is of type WorkloadIdentity.
+	// +optional
+	WorkloadIdentity *WorkloadIdentitySettings `json:"workloadIdentity,omitempty"`
+	// KeyVaultCertificate is the client certificate the identity authenticates with when it is of type
+	// ServicePrincipalCertificate. The controller reads it with its own credentials and picks up new
+	// versions of the certificate when it is rotated.
+	// +optional
+	KeyVaultCertificate *KeyVaultCertificateReference `json:"keyVaultCertificate,omitempty"`
+	// ProvisionRoleAssignments makes the controller assign the least privileged built-in roles the identity
+	// needs to the clusters checking its permissions, using the credentials of the controller rather than the
+	// ones of the identity. Roles are only assigned in resource groups the controller created for a cluster.
+	// The controller needs permission to assign roles, e.g. the User Access Administrator role.
+	// +optional
+	ProvisionRoleAssignments bool `json:"provisionRoleAssignments,omitempty"`
+}
+
+// KeyVaultCertificateReference references a certificate in Azure Key Vault.
+type KeyVaultCertificateReference struct {
+	// VaultURL is the URL of the Key Vault, e.g. https://myvault.vault.azure.net/.
+	// +kubebuilder:validation:Pattern=`^https://`
+	VaultURL string `json:"vaultURL"`
+	// CertificateName is the name of the certificate in the Key Vault. The latest version of the
+	// certificate is used, including its private key.
+	CertificateName string `json:"certificateName"`
+}
+
+// WorkloadIdentitySettings defines how the controller gets the service account tokens it exchanges for
+// Azure tokens of a workload identity. The identity needs a federated credential matching the issuer,
+// the audience and the subject system:serviceaccount:<namespace>:<service account name>.
+type WorkloadIdentitySettings struct {
+	// ServiceAccountName is the name of the service account in the namespace of the controller the tokens
+	// are minted for. Defaults to the service account of the controller. Other service accounts must be allowed
+	// by the --workload-identity-service-accounts flag of the controller. Must not be set with TokenFilePath.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Audience is the audience of the minted tokens, which must be allowed by the --workload-identity-audiences
+	// flag of the controller. Tokens read from TokenFilePath have the audience of the projected volume instead.
+	// +kubebuilder:default="api://AzureADTokenExchange"
+	// +optional
+	Audience string `json:"audience,omitempty"`
+	// TokenFilePath is the absolute path of a projected service account token mounted into the controller,
+	// e.g. /var/run/secrets/azure/tokens/azure-identity-token. If set, the controller reads its tokens from
+	// the file instead of minting them, so they can be issued for custom issuers and audiences. The path must be
+	// allowed by the --workload-identity-token-files flag of the controller.
+	// +optional
+	TokenFilePath string `json:"tokenFilePath,omitempty"`
+	// TokenRefreshInterval is how long a service account token is reused for acquiring Azure tokens before
+	// a new one is minted or the file is read again. By default, a new token is used for every acquisition.
+	// +optional
+	TokenRefreshInterval *metav1.Duration `json:"tokenRefreshInterval,omitempty"`
+	// Issuer is the expected issuer of the minted tokens, i.e. the service account issuer of the
+	// management cluster. Tokens from another issuer are rejected before they are exchanged.
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
 }
 
 // AzureClusterIdentityStatus defines the observed state of AzureClusterIdentity.
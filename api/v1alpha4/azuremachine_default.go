@@ -28,7 +28,7 @@ import (
 // SetDefaultSSHPublicKey sets the default SSHPublicKey for an AzureMachine.
 func (m *AzureMachine) SetDefaultSSHPublicKey() error {
 	sshKeyData := m.Spec.SSHPublicKey
-	if sshKeyData == "" {
+	if sshKeyData == "" && m.Spec.SSHPublicKeySecret == nil {
 		_, publicRsaKey, err := utilSSH.GenerateSSHKey()
 		if err != nil {
 			return err
@@ -45,6 +45,11 @@ func TestAzureMachine_SetDefaultSSHPublicKey(t *testing.T) {
 	err = publicKeyNotExistTest.machine.SetDefaultSSHPublicKey()
 	g.Expect(err).To(BeNil())
 	g.Expect(publicKeyNotExistTest.machine.Spec.SSHPublicKey).To(Not(BeEmpty()))
+
+	publicKeySecretTest := test{machine: createMachineWithSSHPublicKeySecret(t)}
+	err = publicKeySecretTest.machine.SetDefaultSSHPublicKey()
+	g.Expect(err).To(BeNil())
+	g.Expect(publicKeySecretTest.machine.Spec.SSHPublicKey).To(BeEmpty())
 }
 
 func TestAzureMachine_SetIdentityDefaults(t *testing.T) {
@@ -255,6 +260,12 @@ func createMachineWithSSHPublicKey(t *testing.T, sshPublicKey string) *AzureMach
 	return machine
 }
 
+func createMachineWithSSHPublicKeySecret(t *testing.T) *AzureMachine {
+	machine := hardcodedAzureMachineWithSSHKey("")
+	machine.Spec.SSHPublicKeySecret = &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/ssh-key"}
+	return machine
+}
+
 func createMachineWithUserAssignedIdentities(t *testing.T, identitiesList []UserAssignedIdentity) *AzureMachine {
 	machine := hardcodedAzureMachineWithSSHKey(generateSSHPublicKey(true))
 	machine.Spec.Identity = VMIdentityUserAssigned
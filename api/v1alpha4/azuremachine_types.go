@@ -28,6 +28,10 @@ const (
 	// MachineFinalizer allows ReconcileAzureMachine to clean up Azure resources associated with AzureMachine before
 	// removing it from the apiserver.
 	MachineFinalizer = "azuremachine.infrastructure.cluster.x-k8s.io"
+
+	// BootDiagnosticsAnnotation requests short-lived SAS URLs of the serial log and the screenshot of the VM of an
+	// AzureMachine when set. The URLs are recorded in an event on the AzureMachine and the annotation is removed.
+	BootDiagnosticsAnnotation = "azuremachine.infrastructure.cluster.x-k8s.io/boot-diagnostics"
 )
 
 // AzureMachineSpec defines the desired state of AzureMachine.
@@ -78,6 +82,22 @@ type AzureMachineSpec struct {
 
 	SSHPublicKey string `json:"sshPublicKey"`
 
+	// SSHPublicKeySecret references a secret in Azure Key Vault holding the SSH public key to add to the virtual machine,
+	// in the OpenSSH authorized_keys format. It is read when the virtual machine is created and is mutually exclusive with SSHPublicKey.
+	// +optional
+	SSHPublicKeySecret *KeyVaultSecretReference `json:"sshPublicKeySecret,omitempty"`
+
+	// AdminPasswordSecret references a secret in Azure Key Vault holding the password of the administrator of Windows
+	// virtual machines. It is read when the virtual machine is created. A random password is used if not set.
+	// +optional
+	AdminPasswordSecret *KeyVaultSecretReference `json:"adminPasswordSecret,omitempty"`
+
+	// AdditionalCustomDataSecret references a Secret in the namespace of the AzureMachine holding additional cloud-init
+	// user data, e.g. a cloud-config snippet or a shell script, which is run after the bootstrap data of the machine.
+	// It is read when the virtual machine is created, and is only supported for Linux machines.
+	// +optional
+	AdditionalCustomDataSecret *SecretKeyReference `json:"additionalCustomDataSecret,omitempty"`
+
 	// AdditionalTags is an optional set of tags to add to an instance, in addition to the ones added by default by the
 	// Azure provider. If both the AzureCluster and the AzureMachine specify the same tag name with different values, the
 	// AzureMachine's value takes precedence.
@@ -108,6 +128,12 @@ type AzureMachineSpec struct {
 	// SecurityProfile specifies the Security profile settings for a virtual machine.
 	// +optional
 	SecurityProfile *SecurityProfile `json:"securityProfile,omitempty"`
+
+	// DeletionPolicy defines what happens to the Azure resources of the machine when the AzureMachine is deleted:
+	// Delete and RetainResourceGroup delete them, and Retain leaves them in place. Defaults to the deletion policy of
+	// the AzureCluster.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 // SpotVMOptions defines the options relevant to running the Machine on Spot VMs.
@@ -171,6 +197,9 @@ type AzureMachineStatus struct {
 	// Conditions defines current service state of the AzureMachine.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+	// Resources are the Azure resources managed for the AzureMachine, with their last known status.
+	// +optional
+	Resources []ResourceStatus `json:"resources,omitempty"`
 }
 
 // +kubebuilder:object:root=true
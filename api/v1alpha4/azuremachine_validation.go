@@ -17,8 +17,10 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
@@ -45,6 +47,40 @@ func ValidateSSHKey(sshKey string, fldPath *field.Path) field.ErrorList {
 	return allErrs
 }
 
+// ValidateSSHPublicKeySecret validates that an SSH public key Key Vault secret is not combined with an inline key.
+func ValidateSSHPublicKeySecret(sshKey string, secret *KeyVaultSecretReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if secret != nil && sshKey != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "sshPublicKeySecret and sshPublicKey are mutually exclusive"))
+	}
+
+	return allErrs
+}
+
+// ValidateAdminPasswordSecret validates that an admin password Key Vault secret is only set for Windows machines.
+func ValidateAdminPasswordSecret(osType string, secret *KeyVaultSecretReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if secret != nil && osType != "Windows" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "adminPasswordSecret is only supported for Windows machines"))
+	}
+
+	return allErrs
+}
+
+// ValidateAdditionalCustomDataSecret validates that an additional custom data secret is only set for Linux machines,
+// whose custom data is run by cloud-init.
+func ValidateAdditionalCustomDataSecret(osType string, secret *SecretKeyReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if secret != nil && osType == "Windows" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "additionalCustomDataSecret is only supported for Linux machines"))
+	}
+
+	return allErrs
+}
+
 // ValidateSystemAssignedIdentity validates the system-assigned identities list.
 func ValidateSystemAssignedIdentity(identityType VMIdentity, old, new string, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -73,6 +109,15 @@ func ValidateUserAssignedIdentity(identityType VMIdentity, userAssignedIdentetie
 	return allErrs
 }
 
+// UserAssignedIdentitiesWarnings returns a warning if user-assigned identities are set with an identity type that
+// ignores them.
+func UserAssignedIdentitiesWarnings(identityType VMIdentity, userAssignedIdentities []UserAssignedIdentity, fldPath *field.Path) []string {
+	if identityType == VMIdentityUserAssigned || len(userAssignedIdentities) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: ignored, as the identity type is %q and not %q", fldPath, identityType, VMIdentityUserAssigned)}
+}
+
 // ValidateDataDisks validates a list of data disks.
 func ValidateDataDisks(dataDisks []DataDisk, fieldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
@@ -261,3 +306,100 @@ func validateCachingType(cachingType string, fieldPath *field.Path) field.ErrorL
 	allErrs = append(allErrs, field.Invalid(cachingTypeChildPath, cachingType, fmt.Sprintf("allowed values are %v", compute.PossibleCachingTypesValues())))
 	return allErrs
 }
+
+// VMSizeCapabilities are the capabilities of a VM size in the location of a cluster.
+// +kubebuilder:object:generate=false
+type VMSizeCapabilities struct {
+	// Location is the location of the cluster.
+	Location string
+	// Available is false if the VM size isn't offered, or is restricted, in the location for the subscription.
+	Available bool
+	// Zones are the zones of the location the VM size can be deployed to.
+	Zones                 []string
+	AcceleratedNetworking bool
+	EncryptionAtHost      bool
+	PremiumIO             bool
+	EphemeralOSDisk       bool
+	// HyperVGenerations are the VM generations supported by the VM size, e.g. V1 and V2.
+	HyperVGenerations []string
+}
+
+// VMSizeCapabilitiesGetter gets the capabilities of VM sizes in the location of a cluster.
+// +kubebuilder:object:generate=false
+type VMSizeCapabilitiesGetter interface {
+	// GetVMSizeCapabilities returns the capabilities of a VM size in the location of the AzureCluster of a cluster, or
+	// nil if they can't be known yet, e.g. because the cluster doesn't exist.
+	GetVMSizeCapabilities(ctx context.Context, namespace, clusterName, vmSize string) (*VMSizeCapabilities, error)
+}
+
+// ImageChecker checks that the images of machines exist.
+// +kubebuilder:object:generate=false
+type ImageChecker interface {
+	// ImageExists returns whether the image of a machine of a cluster exists, in the location of the AzureCluster of
+	// the cluster for marketplace images. It returns true if this can't be known yet, e.g. because the cluster doesn't
+	// exist.
+	ImageExists(ctx context.Context, namespace, clusterName string, image *Image) (bool, error)
+}
+
+// ValidateVMSizeCapabilities validates that the VM size of an AzureMachine supports the options it selects.
+func ValidateVMSizeCapabilities(spec AzureMachineSpec, capabilities *VMSizeCapabilities, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if capabilities == nil {
+		return allErrs
+	}
+	if !capabilities.Available {
+		return append(allErrs, field.Invalid(fldPath.Child("vmSize"), spec.VMSize,
+			fmt.Sprintf("VM size is not available in location %s", capabilities.Location)))
+	}
+
+	if spec.FailureDomain != nil && len(capabilities.Zones) > 0 && !stringInSlice(*spec.FailureDomain, capabilities.Zones) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("failureDomain"), *spec.FailureDomain,
+			fmt.Sprintf("VM size %s is only available in zones %s of location %s", spec.VMSize, strings.Join(capabilities.Zones, ", "), capabilities.Location)))
+	}
+	if spec.AcceleratedNetworking != nil && *spec.AcceleratedNetworking && !capabilities.AcceleratedNetworking {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("acceleratedNetworking"), true,
+			fmt.Sprintf("VM size %s doesn't support accelerated networking", spec.VMSize)))
+	}
+	if spec.SecurityProfile != nil && spec.SecurityProfile.EncryptionAtHost != nil && *spec.SecurityProfile.EncryptionAtHost && !capabilities.EncryptionAtHost {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("securityProfile", "encryptionAtHost"), true,
+			fmt.Sprintf("VM size %s doesn't support encryption at host", spec.VMSize)))
+	}
+	if spec.OSDisk.DiffDiskSettings != nil && !capabilities.EphemeralOSDisk {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("osDisk", "diffDiskSettings"), spec.OSDisk.DiffDiskSettings,
+			fmt.Sprintf("VM size %s doesn't support ephemeral OS disks", spec.VMSize)))
+	}
+	if !capabilities.PremiumIO {
+		if isPremiumStorage(spec.OSDisk.ManagedDisk) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("osDisk", "managedDisk", "storageAccountType"), spec.OSDisk.ManagedDisk.StorageAccountType,
+				fmt.Sprintf("VM size %s doesn't support premium storage", spec.VMSize)))
+		}
+		for i, disk := range spec.DataDisks {
+			if isPremiumStorage(disk.ManagedDisk) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("dataDisks").Index(i).Child("managedDisk", "storageAccountType"), disk.ManagedDisk.StorageAccountType,
+					fmt.Sprintf("VM size %s doesn't support premium storage", spec.VMSize)))
+			}
+		}
+	}
+	// The generation of an image is only known from its marketplace SKU, e.g. 18_04-lts-gen2.
+	if image := spec.Image; image != nil && image.Marketplace != nil && strings.Contains(strings.ToLower(image.Marketplace.SKU), "gen2") &&
+		len(capabilities.HyperVGenerations) > 0 && !stringInSlice("V2", capabilities.HyperVGenerations) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("image", "marketplace", "sku"), image.Marketplace.SKU,
+			fmt.Sprintf("VM size %s doesn't support generation 2 images", spec.VMSize)))
+	}
+	return allErrs
+}
+
+// isPremiumStorage returns whether managed disk parameters select premium storage, e.g. Premium_LRS.
+func isPremiumStorage(managedDisk *ManagedDiskParameters) bool {
+	return managedDisk != nil && strings.HasPrefix(managedDisk.StorageAccountType, "Premium")
+}
+
+// stringInSlice returns whether a string is in a slice, ignoring case.
+func stringInSlice(s string, slice []string) bool {
+	for _, item := range slice {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
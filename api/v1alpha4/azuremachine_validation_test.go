@@ -651,3 +651,121 @@ func TestAzureMachine_ValidateDataDisksUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureMachine_ValidateKeyVaultSecrets(t *testing.T) {
+	g := NewWithT(t)
+
+	secret := &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/my-secret"}
+
+	g.Expect(ValidateSSHPublicKeySecret("", secret, field.NewPath("sshPublicKeySecret"))).To(HaveLen(0))
+	g.Expect(ValidateSSHPublicKeySecret(generateSSHPublicKey(true), nil, field.NewPath("sshPublicKeySecret"))).To(HaveLen(0))
+	g.Expect(ValidateSSHPublicKeySecret(generateSSHPublicKey(true), secret, field.NewPath("sshPublicKeySecret"))).To(HaveLen(1))
+
+	g.Expect(ValidateAdminPasswordSecret("Windows", secret, field.NewPath("adminPasswordSecret"))).To(HaveLen(0))
+	g.Expect(ValidateAdminPasswordSecret("Linux", nil, field.NewPath("adminPasswordSecret"))).To(HaveLen(0))
+	g.Expect(ValidateAdminPasswordSecret("Linux", secret, field.NewPath("adminPasswordSecret"))).To(HaveLen(1))
+}
+
+func TestUserAssignedIdentitiesWarnings(t *testing.T) {
+	g := NewWithT(t)
+
+	identities := []UserAssignedIdentity{{ProviderID: "azure:///subscriptions/123/resourcegroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity"}}
+	fldPath := field.NewPath("spec", "userAssignedIdentities")
+
+	g.Expect(UserAssignedIdentitiesWarnings(VMIdentityNone, nil, fldPath)).To(BeEmpty())
+	g.Expect(UserAssignedIdentitiesWarnings(VMIdentityUserAssigned, identities, fldPath)).To(BeEmpty())
+	g.Expect(UserAssignedIdentitiesWarnings(VMIdentitySystemAssigned, identities, fldPath)).To(Equal([]string{
+		`spec.userAssignedIdentities: ignored, as the identity type is "SystemAssigned" and not "UserAssigned"`,
+	}))
+}
+
+func TestValidateVMSizeCapabilities(t *testing.T) {
+	g := NewWithT(t)
+
+	allCapabilities := &VMSizeCapabilities{
+		Location:              "westeurope",
+		Available:             true,
+		Zones:                 []string{"1", "2"},
+		AcceleratedNetworking: true,
+		EncryptionAtHost:      true,
+		PremiumIO:             true,
+		EphemeralOSDisk:       true,
+		HyperVGenerations:     []string{"V1", "V2"},
+	}
+	spec := AzureMachineSpec{
+		VMSize:                "Standard_D2s_v3",
+		FailureDomain:         to.StringPtr("3"),
+		AcceleratedNetworking: to.BoolPtr(true),
+		SecurityProfile:       &SecurityProfile{EncryptionAtHost: to.BoolPtr(true)},
+		OSDisk: OSDisk{
+			ManagedDisk:      &ManagedDiskParameters{StorageAccountType: "Premium_LRS"},
+			DiffDiskSettings: &DiffDiskSettings{Option: "Local"},
+		},
+		DataDisks: []DataDisk{
+			{NameSuffix: "etcd", ManagedDisk: &ManagedDiskParameters{StorageAccountType: "Standard_LRS"}},
+			{NameSuffix: "data", ManagedDisk: &ManagedDiskParameters{StorageAccountType: "Premium_LRS"}},
+		},
+		Image: &Image{Marketplace: &AzureMarketplaceImage{Publisher: "cncf-upstream", Offer: "capi", SKU: "ubuntu-1804-gen2", Version: "latest"}},
+	}
+
+	tests := []struct {
+		name           string
+		capabilities   *VMSizeCapabilities
+		expectedFields []string
+	}{
+		{
+			name: "unknown capabilities",
+		},
+		{
+			name: "VM size not available",
+			capabilities: &VMSizeCapabilities{
+				Location: "westeurope",
+			},
+			expectedFields: []string{"spec.vmSize"},
+		},
+		{
+			name: "all options supported",
+			capabilities: func() *VMSizeCapabilities {
+				capabilities := *allCapabilities
+				capabilities.Zones = []string{"1", "2", "3"}
+				return &capabilities
+			}(),
+		},
+		{
+			name: "no option supported",
+			capabilities: &VMSizeCapabilities{
+				Location:          "westeurope",
+				Available:         true,
+				Zones:             []string{"1", "2"},
+				HyperVGenerations: []string{"V1"},
+			},
+			expectedFields: []string{
+				"spec.failureDomain",
+				"spec.acceleratedNetworking",
+				"spec.securityProfile.encryptionAtHost",
+				"spec.osDisk.diffDiskSettings",
+				"spec.osDisk.managedDisk.storageAccountType",
+				"spec.dataDisks[1].managedDisk.storageAccountType",
+				"spec.image.marketplace.sku",
+			},
+		},
+		{
+			name: "location without zones",
+			capabilities: func() *VMSizeCapabilities {
+				capabilities := *allCapabilities
+				capabilities.Zones = nil
+				return &capabilities
+			}(),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := ValidateVMSizeCapabilities(spec, tc.capabilities, field.NewPath("spec"))
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
@@ -17,21 +17,50 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"context"
 	"reflect"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	webhookutil "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 )
 
 // log is for logging in this package.
 var machinelog = logf.Log.WithName("azuremachine-resource")
 
+// vmSizeCapabilitiesGetter gets the capabilities of the VM sizes of AzureMachines at admission, or is nil if they
+// aren't validated.
+var vmSizeCapabilitiesGetter VMSizeCapabilitiesGetter
+
+// imageChecker checks that the images of AzureMachines exist at admission, or is nil if they aren't checked.
+var imageChecker ImageChecker
+
+// onlineValidationTimeout is how long the validations getting Azure resources wait for them, shorter than the timeout
+// of the webhook.
+const onlineValidationTimeout = 5 * time.Second
+
+// SetVMSizeCapabilitiesGetter enables the validation of the VM sizes of AzureMachines at admission.
+func SetVMSizeCapabilitiesGetter(getter VMSizeCapabilitiesGetter) {
+	vmSizeCapabilitiesGetter = getter
+}
+
+// SetImageChecker enables checking that the images of AzureMachines exist at admission.
+func SetImageChecker(checker ImageChecker) {
+	imageChecker = checker
+}
+
 // SetupWebhookWithManager sets up and registers the webhook with the manager.
 func (m *AzureMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if err := webhookutil.RegisterValidatingWebhookWithWarnings(mgr, m); err != nil {
+		return err
+	}
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(m).
 		Complete()
@@ -41,6 +70,7 @@ func (m *AzureMachine) SetupWebhookWithManager(mgr ctrl.Manager) error {
 // +kubebuilder:webhook:verbs=create;update,path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha4-azuremachine,mutating=true,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=azuremachines,versions=v1alpha4,name=default.azuremachine.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
 
 var _ webhook.Validator = &AzureMachine{}
+var _ webhookutil.Warner = &AzureMachine{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (m *AzureMachine) ValidateCreate() error {
@@ -55,7 +85,19 @@ func (m *AzureMachine) ValidateCreate() error {
 		allErrs = append(allErrs, errs...)
 	}
 
-	if errs := ValidateSSHKey(m.Spec.SSHPublicKey, field.NewPath("sshPublicKey")); len(errs) > 0 {
+	if m.Spec.SSHPublicKeySecret != nil {
+		if errs := ValidateSSHPublicKeySecret(m.Spec.SSHPublicKey, m.Spec.SSHPublicKeySecret, field.NewPath("sshPublicKeySecret")); len(errs) > 0 {
+			allErrs = append(allErrs, errs...)
+		}
+	} else if errs := ValidateSSHKey(m.Spec.SSHPublicKey, field.NewPath("sshPublicKey")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateAdminPasswordSecret(m.Spec.OSDisk.OSType, m.Spec.AdminPasswordSecret, field.NewPath("adminPasswordSecret")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	if errs := ValidateAdditionalCustomDataSecret(m.Spec.OSDisk.OSType, m.Spec.AdditionalCustomDataSecret, field.NewPath("additionalCustomDataSecret")); len(errs) > 0 {
 		allErrs = append(allErrs, errs...)
 	}
 
@@ -71,12 +113,65 @@ func (m *AzureMachine) ValidateCreate() error {
 		allErrs = append(allErrs, errs...)
 	}
 
+	if errs := ValidateTagPolicy(m.Spec.AdditionalTags, false, field.NewPath("spec", "additionalTags")); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	allErrs = append(allErrs, m.validateVMSize()...)
+	allErrs = append(allErrs, m.validateImageExists()...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
 	return apierrors.NewInvalid(GroupVersion.WithKind("AzureMachine").GroupKind(), m.Name, allErrs)
 }
 
+// validateVMSize validates the VM size against its capabilities in the location of the cluster. The AzureMachine is
+// admitted if the capabilities can't be got, as the controller reports unsupported options when creating the VM.
+func (m *AzureMachine) validateVMSize() field.ErrorList {
+	clusterName := m.Labels[clusterv1.ClusterLabelName]
+	if vmSizeCapabilitiesGetter == nil || clusterName == "" || m.Spec.VMSize == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), onlineValidationTimeout)
+	defer cancel()
+	capabilities, err := vmSizeCapabilitiesGetter.GetVMSizeCapabilities(ctx, m.Namespace, clusterName, m.Spec.VMSize)
+	if err != nil {
+		machinelog.Error(err, "skipping validation of VM size", "name", m.Name, "vmSize", m.Spec.VMSize)
+		return nil
+	}
+	return ValidateVMSizeCapabilities(m.Spec, capabilities, field.NewPath("spec"))
+}
+
+// validateImageExists validates that the image exists. The AzureMachine is admitted if this can't be checked, as the
+// controller reports missing images when creating the VM.
+func (m *AzureMachine) validateImageExists() field.ErrorList {
+	clusterName := m.Labels[clusterv1.ClusterLabelName]
+	if imageChecker == nil || clusterName == "" || m.Spec.Image == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), onlineValidationTimeout)
+	defer cancel()
+	exists, err := imageChecker.ImageExists(ctx, m.Namespace, clusterName, m.Spec.Image)
+	if err != nil {
+		machinelog.Error(err, "skipping check of image", "name", m.Name)
+		return nil
+	}
+	if exists {
+		return nil
+	}
+	switch image := m.Spec.Image; {
+	case image.Marketplace != nil:
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "image", "marketplace"), image.Marketplace,
+			"marketplace image version not found in the location of the cluster")}
+	case image.SharedGallery != nil:
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "image", "sharedGallery"), image.SharedGallery,
+			"shared gallery image version not found")}
+	default:
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "image"), image, "image not found")}
+	}
+}
+
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (m *AzureMachine) ValidateUpdate(oldRaw runtime.Object) error {
 	machinelog.Info("validate update", "name", m.Name)
@@ -132,6 +227,27 @@ func (m *AzureMachine) ValidateUpdate(oldRaw runtime.Object) error {
 		)
 	}
 
+	if !reflect.DeepEqual(m.Spec.SSHPublicKeySecret, old.Spec.SSHPublicKeySecret) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "sshPublicKeySecret"),
+				m.Spec.SSHPublicKeySecret, "field is immutable"),
+		)
+	}
+
+	if !reflect.DeepEqual(m.Spec.AdminPasswordSecret, old.Spec.AdminPasswordSecret) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "adminPasswordSecret"),
+				m.Spec.AdminPasswordSecret, "field is immutable"),
+		)
+	}
+
+	if !reflect.DeepEqual(m.Spec.AdditionalCustomDataSecret, old.Spec.AdditionalCustomDataSecret) {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "additionalCustomDataSecret"),
+				m.Spec.AdditionalCustomDataSecret, "field is immutable"),
+		)
+	}
+
 	if !reflect.DeepEqual(m.Spec.AllocatePublicIP, old.Spec.AllocatePublicIP) {
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("spec", "allocatePublicIP"),
@@ -167,6 +283,10 @@ func (m *AzureMachine) ValidateUpdate(oldRaw runtime.Object) error {
 		)
 	}
 
+	if !reflect.DeepEqual(m.Spec.AdditionalTags, old.Spec.AdditionalTags) {
+		allErrs = append(allErrs, ValidateTagPolicy(m.Spec.AdditionalTags, false, field.NewPath("spec", "additionalTags"))...)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -180,6 +300,16 @@ func (m *AzureMachine) ValidateDelete() error {
 	return nil
 }
 
+// WarningsOnCreate implements webhookutil.Warner so warnings are returned when the AzureMachine is created.
+func (m *AzureMachine) WarningsOnCreate() []string {
+	return UserAssignedIdentitiesWarnings(m.Spec.Identity, m.Spec.UserAssignedIdentities, field.NewPath("spec", "userAssignedIdentities"))
+}
+
+// WarningsOnUpdate implements webhookutil.Warner so warnings are returned when the AzureMachine is updated.
+func (m *AzureMachine) WarningsOnUpdate(_ runtime.Object) []string {
+	return m.WarningsOnCreate()
+}
+
 // Default implements webhookutil.defaulter so a webhook will be registered for the type.
 func (m *AzureMachine) Default() {
 	machinelog.Info("default", "name", m.Name)
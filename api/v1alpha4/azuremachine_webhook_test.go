@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -24,6 +26,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
 	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
 var (
@@ -84,6 +87,58 @@ func TestAzureMachine_ValidateCreate(t *testing.T) {
 			machine: createMachineWithSSHPublicKey(t, "invalid ssh key"),
 			wantErr: true,
 		},
+		{
+			name:    "azuremachine with SSHPublicKeySecret",
+			machine: createMachineWithSSHPublicKeySecret(t),
+			wantErr: false,
+		},
+		{
+			name: "azuremachine with SSHPublicKeySecret and SSHPublicKey",
+			machine: func() *AzureMachine {
+				machine := createMachineWithSSHPublicKeySecret(t)
+				machine.Spec.SSHPublicKey = validSSHPublicKey
+				return machine
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "azuremachine with AdminPasswordSecret on Windows",
+			machine: func() *AzureMachine {
+				machine := createMachineWithSSHPublicKey(t, validSSHPublicKey)
+				machine.Spec.OSDisk.OSType = "Windows"
+				machine.Spec.AdminPasswordSecret = &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/admin-password"}
+				return machine
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "azuremachine with AdminPasswordSecret on Linux",
+			machine: func() *AzureMachine {
+				machine := createMachineWithSSHPublicKey(t, validSSHPublicKey)
+				machine.Spec.AdminPasswordSecret = &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/admin-password"}
+				return machine
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "azuremachine with AdditionalCustomDataSecret on Linux",
+			machine: func() *AzureMachine {
+				machine := createMachineWithSSHPublicKey(t, validSSHPublicKey)
+				machine.Spec.AdditionalCustomDataSecret = &SecretKeyReference{Name: "custom-data"}
+				return machine
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "azuremachine with AdditionalCustomDataSecret on Windows",
+			machine: func() *AzureMachine {
+				machine := createMachineWithSSHPublicKey(t, validSSHPublicKey)
+				machine.Spec.OSDisk.OSType = "Windows"
+				machine.Spec.AdditionalCustomDataSecret = &SecretKeyReference{Name: "custom-data"}
+				return machine
+			}(),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachine with list of user-assigned identities",
 			machine: createMachineWithUserAssignedIdentities(t, []UserAssignedIdentity{{ProviderID: "azure:///123"}, {ProviderID: "azure:///456"}}),
@@ -117,6 +172,144 @@ func TestAzureMachine_ValidateCreate(t *testing.T) {
 	}
 }
 
+type fakeVMSizeCapabilitiesGetter struct {
+	capabilities *VMSizeCapabilities
+	err          error
+}
+
+func (f fakeVMSizeCapabilitiesGetter) GetVMSizeCapabilities(_ context.Context, namespace, clusterName, vmSize string) (*VMSizeCapabilities, error) {
+	return f.capabilities, f.err
+}
+
+func TestAzureMachine_ValidateCreateVMSize(t *testing.T) {
+	g := NewWithT(t)
+
+	defer SetVMSizeCapabilitiesGetter(nil)
+	newMachine := func(labels map[string]string) *AzureMachine {
+		machine := createMachineWithtMarketPlaceImage(t, "PUB1234", "OFFER1234", "SKU1234", "1.0.0")
+		machine.Labels = labels
+		machine.Spec.VMSize = "Standard_B2s"
+		machine.Spec.AcceleratedNetworking = pointer.BoolPtr(true)
+		return machine
+	}
+	clusterLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster"}
+	unsupported := &VMSizeCapabilities{Location: "westeurope", Available: true}
+
+	tests := []struct {
+		name    string
+		getter  VMSizeCapabilitiesGetter
+		machine *AzureMachine
+		wantErr bool
+	}{
+		{
+			name:    "validation disabled",
+			machine: newMachine(clusterLabels),
+		},
+		{
+			name:    "accelerated networking not supported",
+			getter:  fakeVMSizeCapabilitiesGetter{capabilities: unsupported},
+			machine: newMachine(clusterLabels),
+			wantErr: true,
+		},
+		{
+			name:    "machine without cluster",
+			getter:  fakeVMSizeCapabilitiesGetter{capabilities: unsupported},
+			machine: newMachine(nil),
+		},
+		{
+			name:    "capabilities can't be got",
+			getter:  fakeVMSizeCapabilitiesGetter{err: errors.New("failed to list resource SKUs")},
+			machine: newMachine(clusterLabels),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetVMSizeCapabilitiesGetter(tc.getter)
+			err := tc.machine.ValidateCreate()
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("VM size Standard_B2s doesn't support accelerated networking"))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+type fakeImageChecker struct {
+	exists bool
+	err    error
+}
+
+func (f fakeImageChecker) ImageExists(_ context.Context, namespace, clusterName string, image *Image) (bool, error) {
+	return f.exists, f.err
+}
+
+func TestAzureMachine_ValidateCreateImage(t *testing.T) {
+	g := NewWithT(t)
+
+	defer SetImageChecker(nil)
+	clusterLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster"}
+	marketplaceMachine := func(labels map[string]string) *AzureMachine {
+		machine := createMachineWithtMarketPlaceImage(t, "PUB1234", "OFFER1234", "SKU1234", "1.0.0")
+		machine.Labels = labels
+		return machine
+	}
+	galleryMachine := createMachineWithSharedImage(t, "SUB123", "RG123", "NAME123", "GALLERY1", "1.0.0")
+	galleryMachine.Labels = clusterLabels
+
+	tests := []struct {
+		name    string
+		checker ImageChecker
+		machine *AzureMachine
+		wantErr string
+	}{
+		{
+			name:    "check disabled",
+			machine: marketplaceMachine(clusterLabels),
+		},
+		{
+			name:    "marketplace image exists",
+			checker: fakeImageChecker{exists: true},
+			machine: marketplaceMachine(clusterLabels),
+		},
+		{
+			name:    "marketplace image not found",
+			checker: fakeImageChecker{},
+			machine: marketplaceMachine(clusterLabels),
+			wantErr: "marketplace image version not found in the location of the cluster",
+		},
+		{
+			name:    "shared gallery image not found",
+			checker: fakeImageChecker{},
+			machine: galleryMachine,
+			wantErr: "shared gallery image version not found",
+		},
+		{
+			name:    "machine without cluster",
+			checker: fakeImageChecker{},
+			machine: marketplaceMachine(nil),
+		},
+		{
+			name:    "image can't be checked",
+			checker: fakeImageChecker{err: errors.New("authorization failed")},
+			machine: marketplaceMachine(clusterLabels),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetImageChecker(tc.checker)
+			err := tc.machine.ValidateCreate()
+			if tc.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tc.wantErr))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func TestAzureMachine_ValidateUpdate(t *testing.T) {
 	g := NewWithT(t)
 
@@ -362,6 +555,46 @@ func TestAzureMachine_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalidTest: azuremachine.spec.SSHPublicKeySecret is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					SSHPublicKeySecret: &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/ssh-key"},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					SSHPublicKeySecret: &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/other-ssh-key"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.AdminPasswordSecret is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AdminPasswordSecret: &KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/admin-password"},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalidTest: azuremachine.spec.AdditionalCustomDataSecret is immutable",
+			oldMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AdditionalCustomDataSecret: &SecretKeyReference{Name: "custom-data"},
+				},
+			},
+			newMachine: &AzureMachine{
+				Spec: AzureMachineSpec{
+					AdditionalCustomDataSecret: &SecretKeyReference{Name: "other-custom-data"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalidTest: azuremachine.spec.AllocatePublicIP is immutable",
 			oldMachine: &AzureMachine{
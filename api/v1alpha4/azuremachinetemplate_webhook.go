@@ -25,6 +25,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	webhookutil "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 )
 
 // AzureMachineTemplateImmutableMsg ...
@@ -35,17 +37,24 @@ var _ = logf.Log.WithName("azuremachinetemplate-resource")
 
 // SetupWebhookWithManager sets up and registers the webhook with the manager.
 func (r *AzureMachineTemplate) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if err := webhookutil.RegisterValidatingWebhookWithWarnings(mgr, r); err != nil {
+		return err
+	}
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
 }
 
-// +kubebuilder:webhook:verbs=update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha4-azuremachinetemplate,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=azuremachinetemplates,versions=v1alpha4,name=validation.azuremachinetemplate.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha4-azuremachinetemplate,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=infrastructure.cluster.x-k8s.io,resources=azuremachinetemplates,versions=v1alpha4,name=validation.azuremachinetemplate.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
 
 var _ webhook.Validator = &AzureMachineTemplate{}
+var _ webhookutil.Warner = &AzureMachineTemplate{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (r *AzureMachineTemplate) ValidateCreate() error {
+	if errs := ValidateTagPolicy(r.Spec.Template.Spec.AdditionalTags, false, field.NewPath("spec", "template", "spec", "additionalTags")); len(errs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("AzureMachineTemplate").GroupKind(), r.Name, errs)
+	}
 	return nil
 }
 
@@ -71,3 +80,14 @@ func (r *AzureMachineTemplate) ValidateUpdate(oldRaw runtime.Object) error {
 func (r *AzureMachineTemplate) ValidateDelete() error {
 	return nil
 }
+
+// WarningsOnCreate implements webhookutil.Warner so warnings are returned when the AzureMachineTemplate is created.
+func (r *AzureMachineTemplate) WarningsOnCreate() []string {
+	spec := r.Spec.Template.Spec
+	return UserAssignedIdentitiesWarnings(spec.Identity, spec.UserAssignedIdentities, field.NewPath("spec", "template", "spec", "userAssignedIdentities"))
+}
+
+// WarningsOnUpdate implements webhookutil.Warner so warnings are returned when the AzureMachineTemplate is updated.
+func (r *AzureMachineTemplate) WarningsOnUpdate(_ runtime.Object) []string {
+	return r.WarningsOnCreate()
+}
@@ -22,8 +22,71 @@ import clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 const (
 	// NetworkInfrastructureReadyCondition reports of current status of cluster infrastructure.
 	NetworkInfrastructureReadyCondition clusterv1.ConditionType = "NetworkInfrastructureReady"
+	// NetworkInfrastructureProvisionFailedReason used for failures during the provisioning of the cluster infrastructure.
+	NetworkInfrastructureProvisionFailedReason = "NetworkInfrastructureProvisionFailed"
 	// NamespaceNotAllowedByIdentity used to indicate cluster in a namespace not allowed by identity.
 	NamespaceNotAllowedByIdentity = "NamespaceNotAllowedByIdentity"
+	// IdentityPermissionsReadyCondition reports whether the cluster identity has all permissions it needs in the resource groups of the cluster.
+	IdentityPermissionsReadyCondition clusterv1.ConditionType = "IdentityPermissionsReady"
+	// IdentityPermissionsMissingReason used when the cluster identity lacks permissions.
+	IdentityPermissionsMissingReason = "IdentityPermissionsMissing"
+	// AzureResourcesInSyncCondition reports whether the Azure resources of the cluster match their specs, when drift detection is enabled.
+	AzureResourcesInSyncCondition clusterv1.ConditionType = "AzureResourcesInSync"
+	// AzureResourcesDriftedReason used when Azure resources of the cluster were modified outside of the controller.
+	AzureResourcesDriftedReason = "AzureResourcesDrifted"
+	// TagsEnforcedCondition reports whether the tags of the Azure resources of the cluster are enforced, and which tags were last restored.
+	TagsEnforcedCondition clusterv1.ConditionType = "TagsEnforced"
+	// TagsRestoredReason used when tags removed or changed outside of the controller were restored.
+	TagsRestoredReason = "TagsRestored"
+	// DryRunCondition reports, on an AzureCluster or AzureMachine in dry run mode, whether the dry run found changes to make to its Azure resources.
+	DryRunCondition clusterv1.ConditionType = "DryRun"
+	// DryRunChangesPendingReason used when a dry run found changes to make to Azure resources.
+	DryRunChangesPendingReason = "DryRunChangesPending"
+	// ReadOnlyCondition reports, on an AzureCluster or AzureMachine in read-only mode, whether changes to its Azure resources were refused.
+	ReadOnlyCondition clusterv1.ConditionType = "ReadOnly"
+	// MutationsRefusedReason used when changes to Azure resources were refused in read-only mode.
+	MutationsRefusedReason = "MutationsRefused"
+	// DegradedCondition reports, on an AzureCluster or AzureMachine, that its reconciliation failed several times in a row, so it is reconciled less often until it succeeds again.
+	DegradedCondition clusterv1.ConditionType = "Degraded"
+	// ReconcileFailingReason used when the reconciliation of an object keeps failing.
+	ReconcileFailingReason = "ReconcileFailing"
+	// AzureResourcesHealthyCondition reports, on an AzureCluster or AzureMachine, whether Azure Resource Health reports any of its Azure resources as unavailable.
+	AzureResourcesHealthyCondition clusterv1.ConditionType = "AzureResourcesHealthy"
+	// AzureResourcesUnavailableReason used when Azure Resource Health reports Azure resources as unavailable.
+	AzureResourcesUnavailableReason = "AzureResourcesUnavailable"
+	// QuotaAvailableCondition reports, on an AzureCluster, whether the quotas of its subscription leave room for its machines and machine pools to scale up.
+	QuotaAvailableCondition clusterv1.ConditionType = "QuotaAvailable"
+	// QuotaExceededReason used when scaling up the machines or machine pools of a cluster would exceed a quota of its subscription,
+	// or Azure Resource Manager refused to create a resource because it would.
+	QuotaExceededReason = "QuotaExceeded"
+)
+
+// Reasons of the conditions reporting failures of Azure Resource Manager, whatever the service which ran into them.
+const (
+	// SKUNotAvailableReason used when a VM size or other SKU isn't available in the location or zone of a resource.
+	SKUNotAvailableReason = "SKUNotAvailable"
+	// CapacityUnavailableReason used when Azure has no capacity left to allocate a VM of the requested size.
+	CapacityUnavailableReason = "CapacityUnavailable"
+	// AuthorizationFailedReason used when the identity of the cluster isn't allowed to perform an operation.
+	AuthorizationFailedReason = "AuthorizationFailed"
+	// AuthenticationFailedReason used when the credentials of the identity of the cluster are invalid or expired.
+	AuthenticationFailedReason = "AuthenticationFailed"
+	// RequestDisallowedByPolicyReason used when an Azure Policy denied a request.
+	RequestDisallowedByPolicyReason = "RequestDisallowedByPolicy"
+	// ResourceProviderNotRegisteredReason used when the subscription isn't registered with a resource provider.
+	ResourceProviderNotRegisteredReason = "ResourceProviderNotRegistered"
+	// SubscriptionUnavailableReason used when the subscription doesn't exist or is disabled.
+	SubscriptionUnavailableReason = "SubscriptionUnavailable"
+	// AzureResourceConflictReason used when the state of an Azure resource conflicts with an operation, e.g. another operation is in progress.
+	AzureResourceConflictReason = "AzureResourceConflict"
+	// AzureResourceNotFoundReason used when an Azure resource an operation refers to doesn't exist.
+	AzureResourceNotFoundReason = "AzureResourceNotFound"
+	// InvalidAzureRequestReason used when Azure Resource Manager rejected a request as invalid, e.g. because of an invalid spec.
+	InvalidAzureRequestReason = "InvalidAzureRequest"
+	// AzureRequestsThrottledReason used when Azure Resource Manager throttled the requests of the subscription.
+	AzureRequestsThrottledReason = "AzureRequestsThrottled"
+	// AzureServiceErrorReason used when Azure Resource Manager or a resource provider failed with an internal error.
+	AzureServiceErrorReason = "AzureServiceError"
 )
 
 // AzureMachine Conditions and Reasons.
@@ -74,4 +137,8 @@ const (
 	ScaleSetModelUpdatedCondition clusterv1.ConditionType = "ScaleSetModelUpdated"
 	// ScaleSetModelOutOfDateReason describes the machine pool model being out of date.
 	ScaleSetModelOutOfDateReason = "ScaleSetModelOutOfDate"
+
+	// LongRunningOperationStuckReason is the reason of the events of long running operations of Azure Resource Manager
+	// in flight for longer than the warning age set on the controller.
+	LongRunningOperationStuckReason = "LongRunningOperationStuck"
 )
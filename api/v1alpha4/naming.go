@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import "strings"
+
+const (
+	// ClusterNamePlaceholder is replaced by the name of the cluster in naming patterns.
+	ClusterNamePlaceholder = "{cluster}"
+	// MachineNamePlaceholder is replaced by the name of the VM of a machine in naming patterns.
+	MachineNamePlaceholder = "{machine}"
+)
+
+// machineResourceKinds are the kinds of resources which belong to a machine rather than to the cluster.
+var machineResourceKinds = map[AzureResourceKind]bool{
+	NetworkInterfaceKind:       true,
+	PublicNetworkInterfaceKind: true,
+	NodePublicIPKind:           true,
+	OSDiskKind:                 true,
+}
+
+// ResourceName returns the name of a resource of a kind: the pattern of the template of the kind with its
+// placeholders replaced, or the default name, between the prefix and the suffix of the naming. The machine name is
+// only used by the kinds of resources belonging to a machine. A nil naming returns the default name.
+func (n *AzureResourceNaming) ResourceName(kind AzureResourceKind, defaultName, clusterName, machineName string) string {
+	if n == nil {
+		return defaultName
+	}
+
+	name, prefix, suffix := defaultName, n.Prefix, n.Suffix
+	if template := n.template(kind); template != nil {
+		if template.Pattern != "" {
+			name = strings.ReplaceAll(template.Pattern, ClusterNamePlaceholder, clusterName)
+			if machineResourceKinds[kind] {
+				name = strings.ReplaceAll(name, MachineNamePlaceholder, machineName)
+			}
+		}
+		if template.Prefix != nil {
+			prefix = *template.Prefix
+		}
+		if template.Suffix != nil {
+			suffix = *template.Suffix
+		}
+	}
+	return prefix + name + suffix
+}
+
+// template returns the template of a kind of resources, if any.
+func (n *AzureResourceNaming) template(kind AzureResourceKind) *AzureResourceNamingTemplate {
+	for i := range n.Templates {
+		if n.Templates[i].Kind == kind {
+			return &n.Templates[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+)
+
+func TestAzureResourceNamingResourceName(t *testing.T) {
+	naming := &AzureResourceNaming{
+		Prefix: "weu-",
+		Suffix: "-prod",
+		Templates: []AzureResourceNamingTemplate{
+			{Kind: VirtualNetworkKind, Pattern: "vnet-{cluster}"},
+			{Kind: NetworkInterfaceKind, Pattern: "nic-{machine}-{cluster}", Suffix: pointer.StringPtr("")},
+			{Kind: BastionKind, Prefix: pointer.StringPtr("bas-")},
+			// Only the resources of machines are named after their machine.
+			{Kind: NodeSubnetKind, Pattern: "{cluster}-{machine}"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		naming      *AzureResourceNaming
+		kind        AzureResourceKind
+		defaultName string
+		expected    string
+	}{
+		{
+			name:        "no naming",
+			kind:        VirtualNetworkKind,
+			defaultName: "my-cluster-vnet",
+			expected:    "my-cluster-vnet",
+		},
+		{
+			name:        "kind without template",
+			naming:      naming,
+			kind:        PublicLoadBalancerKind,
+			defaultName: "my-cluster-public-lb",
+			expected:    "weu-my-cluster-public-lb-prod",
+		},
+		{
+			name:        "cluster pattern",
+			naming:      naming,
+			kind:        VirtualNetworkKind,
+			defaultName: "my-cluster-vnet",
+			expected:    "weu-vnet-my-cluster-prod",
+		},
+		{
+			name:        "machine pattern with suffix override",
+			naming:      naming,
+			kind:        NetworkInterfaceKind,
+			defaultName: "my-vm-nic",
+			expected:    "weu-nic-my-vm-my-cluster",
+		},
+		{
+			name:        "prefix override without pattern",
+			naming:      naming,
+			kind:        BastionKind,
+			defaultName: "my-cluster-azure-bastion",
+			expected:    "bas-my-cluster-azure-bastion-prod",
+		},
+		{
+			name:        "machine placeholder in cluster pattern",
+			naming:      naming,
+			kind:        NodeSubnetKind,
+			defaultName: "my-cluster-node-subnet",
+			expected:    "weu-my-cluster-{machine}-prod",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tc.naming.ResourceName(tc.kind, tc.defaultName, "my-cluster", "my-vm")).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// TagPolicy is a tag governance policy the webhooks enforce on the additional tags of all objects, so that tags
+// required by Azure Policy are rejected at admission rather than by Azure Resource Manager.
+type TagPolicy struct {
+	rules []tagRule
+}
+
+// tagRule requires a tag, whose value must fully match a pattern if it is set.
+type tagRule struct {
+	key     string
+	pattern string
+	value   *regexp.Regexp
+}
+
+// tagPolicy is the tag policy enforced at admission, or is nil if tags aren't governed.
+var tagPolicy *TagPolicy
+
+// SetTagPolicy enables the enforcement of a tag policy at admission.
+func SetTagPolicy(policy *TagPolicy) {
+	tagPolicy = policy
+}
+
+// ParseTagPolicy parses the tag policy of a ConfigMap. Every key of the ConfigMap is a required tag, and its value is a
+// regular expression the whole value of the tag must match. An empty value allows any value.
+func ParseTagPolicy(data map[string]string) (*TagPolicy, error) {
+	policy := &TagPolicy{}
+	for key, pattern := range data {
+		rule := tagRule{key: key, pattern: pattern}
+		if pattern != "" {
+			value, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern of tag %s", key)
+			}
+			rule.value = value
+		}
+		policy.rules = append(policy.rules, rule)
+	}
+	// Errors are reported in a stable order.
+	sort.Slice(policy.rules, func(i, j int) bool { return policy.rules[i].key < policy.rules[j].key })
+	return policy, nil
+}
+
+// ValidateTagPolicy validates additional tags against the tag policy. Only the objects whose tags are added to all
+// resources of a cluster must set every required tag, the tags of the other objects only have their values checked.
+// Webhooks only validate tags on updates changing them, so that objects created before the policy can still be updated.
+func ValidateTagPolicy(tags Tags, requireTags bool, fldPath *field.Path) field.ErrorList {
+	if tagPolicy == nil {
+		return nil
+	}
+	return tagPolicy.validate(tags, requireTags, fldPath)
+}
+
+// validate validates tags against the policy.
+func (p *TagPolicy) validate(tags Tags, requireTags bool, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, rule := range p.rules {
+		// Tag names are case-insensitive in Azure.
+		var found bool
+		for key, value := range tags {
+			if !strings.EqualFold(key, rule.key) {
+				continue
+			}
+			found = true
+			if rule.value != nil && !rule.value.MatchString(value) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Key(key), value,
+					fmt.Sprintf("value must match %q as required by the tag policy", rule.pattern)))
+			}
+		}
+		if !found && requireTags {
+			allErrs = append(allErrs, field.Required(fldPath.Key(rule.key), "tag is required by the tag policy"))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestParseTagPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := ParseTagPolicy(map[string]string{"costCenter": "[0-9"})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid pattern of tag costCenter")))
+
+	policy, err := ParseTagPolicy(map[string]string{"owner": "", "costCenter": "[0-9]{4}"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policy.rules).To(HaveLen(2))
+	g.Expect(policy.rules[0].key).To(Equal("costCenter"))
+	g.Expect(policy.rules[1].key).To(Equal("owner"))
+}
+
+func TestValidateTagPolicy(t *testing.T) {
+	fldPath := field.NewPath("additionalTags")
+	policy, err := ParseTagPolicy(map[string]string{"owner": "", "costCenter": "[0-9]{4}"})
+	NewWithT(t).Expect(err).NotTo(HaveOccurred())
+
+	tests := []struct {
+		name        string
+		tags        Tags
+		requireTags bool
+		expectedErr field.ErrorList
+	}{
+		{
+			name:        "compliant tags",
+			tags:        Tags{"costCenter": "1234", "owner": "team-a", "other": "value"},
+			requireTags: true,
+		},
+		{
+			name:        "tag names are case-insensitive",
+			tags:        Tags{"CostCenter": "1234", "OWNER": ""},
+			requireTags: true,
+		},
+		{
+			name:        "value must fully match the pattern",
+			tags:        Tags{"costCenter": "12345", "owner": "team-a"},
+			requireTags: true,
+			expectedErr: field.ErrorList{field.Invalid(fldPath.Key("costCenter"), "12345", `value must match "[0-9]{4}" as required by the tag policy`)},
+		},
+		{
+			name:        "missing required tags",
+			tags:        Tags{"costCenter": "1234"},
+			requireTags: true,
+			expectedErr: field.ErrorList{field.Required(fldPath.Key("owner"), "tag is required by the tag policy")},
+		},
+		{
+			name: "missing tags are allowed when not required",
+			tags: Tags{"other": "value"},
+		},
+		{
+			name:        "values are checked when tags aren't required",
+			tags:        Tags{"costCenter": "none"},
+			expectedErr: field.ErrorList{field.Invalid(fldPath.Key("costCenter"), "none", `value must match "[0-9]{4}" as required by the tag policy`)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := policy.validate(tc.tags, tc.requireTags, fldPath)
+			if tc.expectedErr == nil {
+				g.Expect(err).To(BeEmpty())
+			} else {
+				g.Expect(err).To(Equal(tc.expectedErr))
+			}
+		})
+	}
+
+	t.Run("no policy", func(t *testing.T) {
+		NewWithT(t).Expect(ValidateTagPolicy(nil, true, fldPath)).To(BeEmpty())
+	})
+
+	t.Run("policy set", func(t *testing.T) {
+		SetTagPolicy(policy)
+		defer SetTagPolicy(nil)
+		NewWithT(t).Expect(ValidateTagPolicy(nil, true, fldPath)).To(HaveLen(2))
+	})
+}
@@ -112,6 +112,10 @@ const (
 	// uses NameKubernetesClusterPrefix.
 	NameAzureProviderOwned = NameAzureProviderPrefix + "cluster_"
 
+	// NameAzureProviderRetained is the tag name we use to mark resources retained by the deletion policy of their
+	// AzureMachine, so that they are no longer purged as orphaned resources of the cluster.
+	NameAzureProviderRetained = NameAzureProviderPrefix + "retained"
+
 	// NameAzureClusterAPIRole is the tag name we use to mark roles for resources
 	// dedicated to this cluster api provider implementation.
 	NameAzureClusterAPIRole = NameAzureProviderPrefix + "role"
@@ -136,6 +140,14 @@ const (
 	// See https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations/
 	// for annotation formatting rules.
 	VMTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-vm"
+
+	// NICTagsLastAppliedAnnotation is the key for the machine object annotation
+	// which tracks the AdditionalTags applied to the network interfaces of the machine.
+	NICTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-nic"
+
+	// ClusterTagsLastAppliedAnnotation is the key for the AzureCluster annotation
+	// which tracks the AdditionalTags applied to the Azure resources of the cluster.
+	ClusterTagsLastAppliedAnnotation = "sigs.k8s.io/cluster-api-provider-azure-last-applied-tags-cluster"
 )
 
 // SpecVersionHashTagKey is the key for the spec version hash used to enable quick spec difference comparison.
@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -44,6 +45,16 @@ type Future struct {
 
 	// FutureData is the base64 url encoded json Azure AutoRest Future
 	FutureData string `json:"futureData,omitempty"`
+
+	// StartTime is when the controller started the long-running operation. Operations older than the maximum age set
+	// on the controller are dropped, and the resource is reconciled from its current state instead.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// ParametersHash is the hash of the parameters the long-running operation was started with. An operation creating
+	// or updating a resource whose parameters changed since is superseded, and its result is dropped once it completes.
+	// +optional
+	ParametersHash string `json:"parametersHash,omitempty"`
 }
 
 // NetworkSpec specifies what the Azure networking resources should look like.
@@ -67,6 +78,17 @@ type NetworkSpec struct {
 	// PrivateDNSZoneName defines the zone name for the Azure Private DNS.
 	// +optional
 	PrivateDNSZoneName string `json:"privateDNSZoneName,omitempty"`
+
+	// PrivateDNSZoneResourceGroup is the resource group of an existing private DNS zone, e.g. one shared by the
+	// clusters of a hub network. The zone is then neither created nor deleted with the cluster, only its link to the
+	// virtual network of the cluster and the records of the cluster are.
+	// +optional
+	PrivateDNSZoneResourceGroup string `json:"privateDNSZoneResourceGroup,omitempty"`
+
+	// PrivateDNSZoneSubscriptionID is the subscription of the existing private DNS zone in
+	// PrivateDNSZoneResourceGroup, if it is not the subscription of the cluster.
+	// +optional
+	PrivateDNSZoneSubscriptionID string `json:"privateDNSZoneSubscriptionID,omitempty"`
 }
 
 // VnetSpec configures an Azure virtual network.
@@ -150,6 +172,8 @@ type SecurityRule struct {
 	// +kubebuilder:validation:Enum=Inbound;Outbound
 	Direction SecurityRuleDirection `json:"direction"`
 	// Priority is a number between 100 and 4096. Each rule should have a unique value for priority. Rules are processed in priority order, with lower numbers processed before higher numbers. Once traffic matches a rule, processing stops.
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=4096
 	Priority int32 `json:"priority,omitempty"`
 	// SourcePorts specifies source port or range. Integer or range between 0 and 65535. Asterix '*' can also be used to match all ports.
 	SourcePorts *string `json:"sourcePorts,omitempty"`
@@ -172,9 +196,24 @@ type LoadBalancerSpec struct {
 	FrontendIPs []FrontendIP `json:"frontendIPs,omitempty"`
 	Type        LBType       `json:"type,omitempty"`
 	// FrontendIPsCount specifies the number of frontend IP addresses for the load balancer.
+	// +kubebuilder:validation:Maximum=16
 	FrontendIPsCount *int32 `json:"frontendIPsCount,omitempty"`
 	// IdleTimeoutInMinutes specifies the timeout for the TCP idle connection.
+	// +kubebuilder:validation:Minimum=4
+	// +kubebuilder:validation:Maximum=30
 	IdleTimeoutInMinutes *int32 `json:"idleTimeoutInMinutes,omitempty"`
+	// AllocatedOutboundPorts is the number of SNAT ports the outbound rule of the load balancer allocates to each
+	// backend instance. It must be a multiple of 8. When not set, Azure allocates ports based on the size of the
+	// backend pool. It cannot be modified after creation.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=64000
+	// +kubebuilder:validation:MultipleOf=8
+	AllocatedOutboundPorts *int32 `json:"allocatedOutboundPorts,omitempty"`
+	// ExpectedBackendPoolSize is the largest number of instances expected behind the load balancer, e.g. the maximum
+	// number of nodes of the cluster. It is only used at admission, to check that the frontend IPs provide enough SNAT
+	// ports for every instance.
+	// +kubebuilder:validation:Minimum=1
+	ExpectedBackendPoolSize *int32 `json:"expectedBackendPoolSize,omitempty"`
 }
 
 // SKU defines an Azure load balancer SKU.
@@ -195,6 +234,20 @@ const (
 	Public = LBType("Public")
 )
 
+// DeletionPolicy defines what happens to the Azure resources of an object when the object is deleted.
+// +kubebuilder:validation:Enum=Delete;Retain;RetainResourceGroup
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Azure resources along with the object. It is the default.
+	DeletionPolicyDelete = DeletionPolicy("Delete")
+	// DeletionPolicyRetain leaves the Azure resources in place when the object is deleted.
+	DeletionPolicyRetain = DeletionPolicy("Retain")
+	// DeletionPolicyRetainResourceGroup deletes the Azure resources one by one, but leaves the resource group of the
+	// cluster in place, even if it was created by the controller.
+	DeletionPolicyRetainResourceGroup = DeletionPolicy("RetainResourceGroup")
+)
+
 // FrontendIP defines a load balancer frontend IP configuration.
 type FrontendIP struct {
 	// +kubebuilder:validation:MinLength=1
@@ -237,6 +290,55 @@ const (
 	Deleted ProvisioningState = "Deleted"
 )
 
+// ResourceStatus is the last known status of an Azure resource managed for an object, as reported by Azure Resource
+// Manager.
+type ResourceStatus struct {
+	// ID is the ID of the resource.
+	ID string `json:"id"`
+	// Name is the name of the resource.
+	Name string `json:"name"`
+	// Type is the type of the resource, e.g. Microsoft.Network/virtualNetworks/subnets.
+	Type string `json:"type"`
+	// ProvisioningState is the last provisioning state of the resource.
+	// +optional
+	ProvisioningState ProvisioningState `json:"provisioningState,omitempty"`
+	// ErrorCode is the code of the error Azure returned for the last change to the resource, if it failed, e.g.
+	// QuotaExceeded.
+	// +optional
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// ServiceSpecHash is the hash of the spec of the Azure resources of a service when they were last reconciled.
+type ServiceSpecHash struct {
+	// Service is the name of the service, e.g. loadbalancers.
+	Service string `json:"service"`
+	// Hash is the hash of the spec of the Azure resources of the service.
+	Hash string `json:"hash"`
+	// ReconciledAt is when the Azure resources of the service were last reconciled.
+	ReconciledAt metav1.Time `json:"reconciledAt"`
+}
+
+// ServiceStatus is the observed state of the Azure resources of a service.
+type ServiceStatus struct {
+	// Service is the name of the service, e.g. loadbalancers.
+	Service string `json:"service"`
+	// SpecHash is the hash of the spec the Azure resources of the service were last reconciled with, for the services
+	// which are only reconciled when their spec changes.
+	// +optional
+	SpecHash string `json:"specHash,omitempty"`
+	// ReconciledAt is when the Azure resources of the service were last reconciled with SpecHash.
+	// +optional
+	ReconciledAt *metav1.Time `json:"reconciledAt,omitempty"`
+	// ResourceIDs are the IDs of the Azure resources the service created or updated, and didn't delete since.
+	// +optional
+	ResourceIDs []string `json:"resourceIDs,omitempty"`
+	// LastError is the last error Azure Resource Manager returned to a request of the service, e.g.
+	// "QuotaExceeded: Operation could not be completed as it results in exceeding approved quota.". It is cleared once
+	// the service is reconciled successfully.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
 // VM describes an Azure virtual machine.
 type VM struct {
 	ID               string `json:"id,omitempty"`
@@ -337,6 +439,26 @@ const (
 	VMIdentityUserAssigned VMIdentity = "UserAssigned"
 )
 
+// KeyVaultSecretReference references a secret in Azure Key Vault. The secret is read with the credentials of the cluster.
+type KeyVaultSecretReference struct {
+	// SecretURL is the URL of the secret, e.g. https://myvault.vault.azure.net/secrets/mysecret.
+	// The latest version of the secret is used unless the URL includes a version.
+	// +kubebuilder:validation:Pattern=`^https://[^/]+/secrets/[^/]+(/[^/]+)?/?$`
+	SecretURL string `json:"secretURL"`
+}
+
+// SecretKeyReference references a key of a Secret in the namespace of the referencing object.
+type SecretKeyReference struct {
+	// Name is the name of the Secret.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key is the key of the Secret holding the data. Defaults to value, the key of bootstrap data secrets.
+	// +kubebuilder:default=value
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
 // UserAssignedIdentity defines the user-assigned identities provided
 // by the user to be assigned to Azure resources.
 type UserAssignedIdentity struct {
@@ -353,7 +475,7 @@ const (
 )
 
 // IdentityType represents different types of identities.
-// +kubebuilder:validation:Enum=ServicePrincipal;UserAssignedMSI
+// +kubebuilder:validation:Enum=ServicePrincipal;ServicePrincipalCertificate;UserAssignedMSI;WorkloadIdentity
 type IdentityType string
 
 const (
@@ -362,6 +484,14 @@ const (
 
 	// ServicePrincipal represents a service principal.
 	ServicePrincipal IdentityType = "ServicePrincipal"
+
+	// ServicePrincipalCertificate represents a service principal authenticating with a client certificate
+	// stored in Azure Key Vault.
+	ServicePrincipalCertificate IdentityType = "ServicePrincipalCertificate"
+
+	// WorkloadIdentity represents an application or user-assigned identity with a federated credential
+	// trusting the service account tokens of the controller.
+	WorkloadIdentity IdentityType = "WorkloadIdentity"
 )
 
 // OSDisk defines the operating system disk for a VM.
@@ -391,12 +521,16 @@ type DataDisk struct {
 	// Each disk name will be in format <machineName>_<nameSuffix>.
 	NameSuffix string `json:"nameSuffix"`
 	// DiskSizeGB is the size in GB to assign to the data disk.
+	// +kubebuilder:validation:Minimum=4
+	// +kubebuilder:validation:Maximum=32767
 	DiskSizeGB int32 `json:"diskSizeGB"`
 	// ManagedDisk specifies the Managed Disk parameters for the data disk.
 	// +optional
 	ManagedDisk *ManagedDiskParameters `json:"managedDisk,omitempty"`
 	// Lun Specifies the logical unit number of the data disk. This value is used to identify data disks within the VM and therefore must be unique for each data disk attached to a VM.
 	// The value must be between 0 and 63.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=63
 	Lun *int32 `json:"lun,omitempty"`
 	// CachingType specifies the caching requirements.
 	// +optional
@@ -460,6 +594,21 @@ type SubnetSpec struct {
 	// RouteTable defines the route table that should be attached to this subnet.
 	// +optional
 	RouteTable RouteTable `json:"routeTable,omitempty"`
+
+	// ServiceEndpoints are the service endpoints of the subnet. They are only managed in virtual networks created by
+	// the controller, and existing service endpoints of a subnet are left untouched while none are listed.
+	// +optional
+	ServiceEndpoints []ServiceEndpointSpec `json:"serviceEndpoints,omitempty"`
+}
+
+// ServiceEndpointSpec configures an Azure service endpoint of a subnet.
+type ServiceEndpointSpec struct {
+	// Service is the name of the service, e.g. Microsoft.Storage.
+	Service string `json:"service"`
+
+	// Locations are the Azure locations the service endpoint covers, or * for all of them.
+	// +kubebuilder:validation:MinItems=1
+	Locations []string `json:"locations"`
 }
 
 // GetControlPlaneSubnet returns the cluster control plane subnet.
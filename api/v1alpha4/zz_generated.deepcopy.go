@@ -175,11 +175,26 @@ func (in *AzureClusterIdentityList) DeepCopyObject() runtime.Object {
 func (in *AzureClusterIdentitySpec) DeepCopyInto(out *AzureClusterIdentitySpec) {
 	*out = *in
 	out.ClientSecret = in.ClientSecret
+	if in.AuxiliaryTenantIDs != nil {
+		in, out := &in.AuxiliaryTenantIDs, &out.AuxiliaryTenantIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.AllowedNamespaces != nil {
 		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
 		*out = new(AllowedNamespaces)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentitySettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeyVaultCertificate != nil {
+		in, out := &in.KeyVaultCertificate, &out.KeyVaultCertificate
+		*out = new(KeyVaultCertificateReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureClusterIdentitySpec.
@@ -263,12 +278,49 @@ func (in *AzureClusterSpec) DeepCopyInto(out *AzureClusterSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.ServiceIdentityRefs != nil {
+		in, out := &in.ServiceIdentityRefs, &out.ServiceIdentityRefs
+		*out = make([]ServiceIdentityRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IdentityPermissions != nil {
+		in, out := &in.IdentityPermissions, &out.IdentityPermissions
+		*out = new(IdentityPermissions)
+		**out = **in
+	}
+	if in.CloudProviderIdentity != nil {
+		in, out := &in.CloudProviderIdentity, &out.CloudProviderIdentity
+		*out = new(CloudProviderIdentity)
+		**out = **in
+	}
+	if in.DriftDetection != nil {
+		in, out := &in.DriftDetection, &out.DriftDetection
+		*out = new(DriftDetection)
+		**out = **in
+	}
 	in.BastionSpec.DeepCopyInto(&out.BastionSpec)
 	if in.CloudProviderConfigOverrides != nil {
 		in, out := &in.CloudProviderConfigOverrides, &out.CloudProviderConfigOverrides
 		*out = new(CloudProviderConfigOverrides)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AzureResourceNaming != nil {
+		in, out := &in.AzureResourceNaming, &out.AzureResourceNaming
+		*out = new(AzureResourceNaming)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailureDomainOverrides != nil {
+		in, out := &in.FailureDomainOverrides, &out.FailureDomainOverrides
+		*out = make([]FailureDomainOverride, len(*in))
+		copy(*out, *in)
+	}
+	if in.DisabledServices != nil {
+		in, out := &in.DisabledServices, &out.DisabledServices
+		*out = make([]ManagedService, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureClusterSpec.
@@ -298,6 +350,25 @@ func (in *AzureClusterStatus) DeepCopyInto(out *AzureClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceSpecHashes != nil {
+		in, out := &in.ServiceSpecHashes, &out.ServiceSpecHashes
+		*out = make([]ServiceSpecHash, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]ServiceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureClusterStatus.
@@ -400,6 +471,21 @@ func (in *AzureMachineSpec) DeepCopyInto(out *AzureMachineSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SSHPublicKeySecret != nil {
+		in, out := &in.SSHPublicKeySecret, &out.SSHPublicKeySecret
+		*out = new(KeyVaultSecretReference)
+		**out = **in
+	}
+	if in.AdminPasswordSecret != nil {
+		in, out := &in.AdminPasswordSecret, &out.AdminPasswordSecret
+		*out = new(KeyVaultSecretReference)
+		**out = **in
+	}
+	if in.AdditionalCustomDataSecret != nil {
+		in, out := &in.AdditionalCustomDataSecret, &out.AdditionalCustomDataSecret
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
 	if in.AdditionalTags != nil {
 		in, out := &in.AdditionalTags, &out.AdditionalTags
 		*out = make(Tags, len(*in))
@@ -464,6 +550,11 @@ func (in *AzureMachineStatus) DeepCopyInto(out *AzureMachineStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureMachineStatus.
@@ -581,6 +672,53 @@ func (in *AzureMarketplaceImage) DeepCopy() *AzureMarketplaceImage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureResourceNaming) DeepCopyInto(out *AzureResourceNaming) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]AzureResourceNamingTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureResourceNaming.
+func (in *AzureResourceNaming) DeepCopy() *AzureResourceNaming {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureResourceNaming)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureResourceNamingTemplate) DeepCopyInto(out *AzureResourceNamingTemplate) {
+	*out = *in
+	if in.Prefix != nil {
+		in, out := &in.Prefix, &out.Prefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.Suffix != nil {
+		in, out := &in.Suffix, &out.Suffix
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureResourceNamingTemplate.
+func (in *AzureResourceNamingTemplate) DeepCopy() *AzureResourceNamingTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureResourceNamingTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureSharedGalleryImage) DeepCopyInto(out *AzureSharedGalleryImage) {
 	*out = *in
@@ -696,6 +834,21 @@ func (in *CloudProviderConfigOverrides) DeepCopy() *CloudProviderConfigOverrides
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudProviderIdentity) DeepCopyInto(out *CloudProviderIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudProviderIdentity.
+func (in *CloudProviderIdentity) DeepCopy() *CloudProviderIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudProviderIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataDisk) DeepCopyInto(out *DataDisk) {
 	*out = *in
@@ -751,6 +904,37 @@ func (in *DiskEncryptionSetParameters) DeepCopy() *DiskEncryptionSetParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftDetection) DeepCopyInto(out *DriftDetection) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftDetection.
+func (in *DriftDetection) DeepCopy() *DriftDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailureDomainOverride) DeepCopyInto(out *FailureDomainOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailureDomainOverride.
+func (in *FailureDomainOverride) DeepCopy() *FailureDomainOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(FailureDomainOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FrontendIP) DeepCopyInto(out *FrontendIP) {
 	*out = *in
@@ -774,6 +958,10 @@ func (in *FrontendIP) DeepCopy() *FrontendIP {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Future) DeepCopyInto(out *Future) {
 	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Future.
@@ -786,6 +974,21 @@ func (in *Future) DeepCopy() *Future {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityPermissions) DeepCopyInto(out *IdentityPermissions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityPermissions.
+func (in *IdentityPermissions) DeepCopy() *IdentityPermissions {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityPermissions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Image) DeepCopyInto(out *Image) {
 	*out = *in
@@ -816,6 +1019,36 @@ func (in *Image) DeepCopy() *Image {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyVaultCertificateReference) DeepCopyInto(out *KeyVaultCertificateReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyVaultCertificateReference.
+func (in *KeyVaultCertificateReference) DeepCopy() *KeyVaultCertificateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyVaultCertificateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyVaultSecretReference) DeepCopyInto(out *KeyVaultSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyVaultSecretReference.
+func (in *KeyVaultSecretReference) DeepCopy() *KeyVaultSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyVaultSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
 	*out = *in
@@ -836,6 +1069,16 @@ func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.AllocatedOutboundPorts != nil {
+		in, out := &in.AllocatedOutboundPorts, &out.AllocatedOutboundPorts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ExpectedBackendPoolSize != nil {
+		in, out := &in.ExpectedBackendPoolSize, &out.ExpectedBackendPoolSize
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSpec.
@@ -983,6 +1226,21 @@ func (in *RateLimitSpec) DeepCopy() *RateLimitSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceStatus) DeepCopyInto(out *ResourceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceStatus.
+func (in *ResourceStatus) DeepCopy() *ResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RouteTable) DeepCopyInto(out *RouteTable) {
 	*out = *in
@@ -998,6 +1256,21 @@ func (in *RouteTable) DeepCopy() *RouteTable {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecurityGroup) DeepCopyInto(out *SecurityGroup) {
 	*out = *in
@@ -1103,6 +1376,86 @@ func (in SecurityRules) DeepCopy() SecurityRules {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceEndpointSpec) DeepCopyInto(out *ServiceEndpointSpec) {
+	*out = *in
+	if in.Locations != nil {
+		in, out := &in.Locations, &out.Locations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceEndpointSpec.
+func (in *ServiceEndpointSpec) DeepCopy() *ServiceEndpointSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceEndpointSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceIdentityRef) DeepCopyInto(out *ServiceIdentityRef) {
+	*out = *in
+	if in.IdentityRef != nil {
+		in, out := &in.IdentityRef, &out.IdentityRef
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceIdentityRef.
+func (in *ServiceIdentityRef) DeepCopy() *ServiceIdentityRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceIdentityRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSpecHash) DeepCopyInto(out *ServiceSpecHash) {
+	*out = *in
+	in.ReconciledAt.DeepCopyInto(&out.ReconciledAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSpecHash.
+func (in *ServiceSpecHash) DeepCopy() *ServiceSpecHash {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSpecHash)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceStatus) DeepCopyInto(out *ServiceStatus) {
+	*out = *in
+	if in.ReconciledAt != nil {
+		in, out := &in.ReconciledAt, &out.ReconciledAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ResourceIDs != nil {
+		in, out := &in.ResourceIDs, &out.ResourceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceStatus.
+func (in *ServiceStatus) DeepCopy() *ServiceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpotVMOptions) DeepCopyInto(out *SpotVMOptions) {
 	*out = *in
@@ -1133,6 +1486,13 @@ func (in *SubnetSpec) DeepCopyInto(out *SubnetSpec) {
 	}
 	in.SecurityGroup.DeepCopyInto(&out.SecurityGroup)
 	out.RouteTable = in.RouteTable
+	if in.ServiceEndpoints != nil {
+		in, out := &in.ServiceEndpoints, &out.ServiceEndpoints
+		*out = make([]ServiceEndpointSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetSpec.
@@ -1257,3 +1617,23 @@ func (in *VnetSpec) DeepCopy() *VnetSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentitySettings) DeepCopyInto(out *WorkloadIdentitySettings) {
+	*out = *in
+	if in.TokenRefreshInterval != nil {
+		in, out := &in.TokenRefreshInterval, &out.TokenRefreshInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentitySettings.
+func (in *WorkloadIdentitySettings) DeepCopy() *WorkloadIdentitySettings {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentitySettings)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// activityLogPortalHosts are the hosts of the Azure portal of each cloud, by the host of its Azure Resource Manager.
+var activityLogPortalHosts = map[string]string{
+	"management.azure.com":         "portal.azure.com",
+	"management.chinacloudapi.cn":  "portal.azure.cn",
+	"management.usgovcloudapi.net": "portal.azure.us",
+	"management.microsoftazure.de": "portal.microsoftazure.de",
+}
+
+// activityLogWindow is how long before and after a failed request the Activity Log is searched for its entries, as
+// they may only show some time after the request.
+const activityLogWindow = 30 * time.Minute
+
+// activityLogQuery is the query of the Activity Log blade of the Azure portal.
+type activityLogQuery struct {
+	SearchString     string   `json:"searchString"`
+	TimeSpan         string   `json:"timeSpan"`
+	StartTime        string   `json:"startTime"`
+	EndTime          string   `json:"endTime"`
+	Subscriptions    []string `json:"subscriptions"`
+	ResourceGroupID  string   `json:"resourceGroupId"`
+	ResourceTypes    string   `json:"resourceTypes"`
+	OperationNames   string   `json:"operationNames"`
+	Category         string   `json:"category"`
+	Level            string   `json:"level"`
+	Status           string   `json:"status"`
+	EventInitiatedBy string   `json:"eventInitiatedBy"`
+	CorrelationID    string   `json:"correlationId"`
+}
+
+// activityLogURL returns the link to the entries of the Activity Log of a failed request which changes a resource, in
+// the Azure portal, if the request has a correlation ID and was sent to the Azure Resource Manager of a known cloud.
+// The entries are searched by the correlation ID of the request, around the time Azure answered it.
+func activityLogURL(req *http.Request, resp *http.Response) (string, bool) {
+	if req == nil || resp == nil || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return "", false
+	}
+	portalHost, ok := activityLogPortalHosts[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		return "", false
+	}
+	subscriptionID := requestSubscriptionID(req.URL.Path)
+	ids, _ := responseRequestIDs(req, resp)
+	if subscriptionID == "" || ids.CorrelationID == "" {
+		return "", false
+	}
+	answeredAt, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		answeredAt = time.Now()
+	}
+
+	query, err := json.Marshal(map[string]activityLogQuery{"query": {
+		// A time span of 7 is a custom time range.
+		TimeSpan:        "7",
+		StartTime:       answeredAt.Add(-activityLogWindow).UTC().Format(time.RFC3339),
+		EndTime:         answeredAt.Add(activityLogWindow).UTC().Format(time.RFC3339),
+		Subscriptions:   []string{subscriptionID},
+		ResourceGroupID: "all",
+		ResourceTypes:   "all",
+		OperationNames:  "all",
+		Category:        "all",
+		Level:           "all",
+		Status:          "all",
+		CorrelationID:   ids.CorrelationID,
+	}})
+	if err != nil {
+		return "", false
+	}
+	return "https://" + portalHost + "/#blade/Microsoft_Azure_ActivityLog/ActivityLogBlade/queryInputs/" + url.PathEscape(string(query)), true
+}
+
+// requestSubscriptionID returns the ID of the subscription of the path of a request, if any.
+func requestSubscriptionID(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 || !strings.EqualFold(segments[0], "subscriptions") {
+		return ""
+	}
+	return segments[1]
+}
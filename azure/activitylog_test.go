@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// testActivityLogURL is the link to the Activity Log of a failed request with correlation ID "abc" to subscription
+// "123" of the public cloud, which Azure answered at Mon, 02 Jan 2006 15:04:05 GMT.
+const testActivityLogURL = "https://portal.azure.com/#blade/Microsoft_Azure_ActivityLog/ActivityLogBlade/queryInputs/" +
+	"%7B%22query%22:%7B%22searchString%22:%22%22%2C%22timeSpan%22:%227%22%2C%22startTime%22:%222006-01-02T14:34:05Z%22" +
+	"%2C%22endTime%22:%222006-01-02T15:34:05Z%22%2C%22subscriptions%22:%5B%22123%22%5D%2C%22resourceGroupId%22:%22all%22" +
+	"%2C%22resourceTypes%22:%22all%22%2C%22operationNames%22:%22all%22%2C%22category%22:%22all%22%2C%22level%22:%22all%22" +
+	"%2C%22status%22:%22all%22%2C%22eventInitiatedBy%22:%22%22%2C%22correlationId%22:%22abc%22%7D%7D"
+
+func TestActivityLogURL(t *testing.T) {
+	const ip = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"
+
+	cases := map[string]struct {
+		method        string
+		url           string
+		correlationID string
+		portalHost    string
+	}{
+		"update": {
+			method:        http.MethodPut,
+			url:           "https://management.azure.com" + ip,
+			correlationID: "abc",
+			portalHost:    "portal.azure.com",
+		},
+		"delete in China": {
+			method:        http.MethodDelete,
+			url:           "https://management.chinacloudapi.cn" + ip,
+			correlationID: "abc",
+			portalHost:    "portal.azure.cn",
+		},
+		"read": {
+			method:        http.MethodGet,
+			url:           "https://management.azure.com" + ip,
+			correlationID: "abc",
+		},
+		"unknown cloud": {
+			method:        http.MethodPut,
+			url:           "https://management.example.com" + ip,
+			correlationID: "abc",
+		},
+		"no subscription": {
+			method:        http.MethodPost,
+			url:           "https://management.azure.com/providers/Microsoft.Resources/operations",
+			correlationID: "abc",
+		},
+		"no correlation ID": {
+			method: http.MethodPut,
+			url:    "https://management.azure.com" + ip,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			req, _ := http.NewRequest(tc.method, tc.url, nil)
+			resp := &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}, Request: req}
+			resp.Header.Set(correlationIDHeader, tc.correlationID)
+			resp.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+
+			link, ok := activityLogURL(req, resp)
+			if tc.portalHost == "" {
+				g.Expect(ok).To(BeFalse())
+				return
+			}
+			g.Expect(ok).To(BeTrue())
+
+			prefix := "https://" + tc.portalHost + "/#blade/Microsoft_Azure_ActivityLog/ActivityLogBlade/queryInputs/"
+			g.Expect(link).To(HavePrefix(prefix))
+			inputs, err := url.PathUnescape(strings.TrimPrefix(link, prefix))
+			g.Expect(err).NotTo(HaveOccurred())
+			var query map[string]activityLogQuery
+			g.Expect(json.Unmarshal([]byte(inputs), &query)).To(Succeed())
+			g.Expect(query["query"].Subscriptions).To(ConsistOf("123"))
+			g.Expect(query["query"].CorrelationID).To(Equal("abc"))
+			g.Expect(query["query"].StartTime).To(Equal("2006-01-02T14:34:05Z"))
+			g.Expect(query["query"].EndTime).To(Equal("2006-01-02T15:34:05Z"))
+		})
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+// WithAdditionalProperties returns a PrepareDecorator that merges properties which are not modelled by the
+// SDK API version in use into the "properties" object of a JSON request body. Nested objects are merged
+// recursively, so that settings already present in the body are kept. When apiVersion is not empty, the
+// request is sent with that api-version instead, so that ARM accepts the additional properties.
+// The request is left untouched if there are no additional properties.
+func WithAdditionalProperties(apiVersion string, properties map[string]interface{}) autorest.PrepareDecorator {
+	if len(properties) == 0 {
+		return WithAdditionalFields(apiVersion, nil)
+	}
+	return WithAdditionalFields(apiVersion, map[string]interface{}{"properties": properties})
+}
+
+// WithAdditionalFields works like WithAdditionalProperties, but merges fields into the top level of a JSON
+// request body, for fields such as the SKU of a resource which live outside of its properties.
+func WithAdditionalFields(apiVersion string, fields map[string]interface{}) autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil || len(fields) == 0 {
+				return r, err
+			}
+
+			body := map[string]interface{}{}
+			if r.Body != nil {
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					return r, errors.Wrap(err, "failed to read request body")
+				}
+				if err := r.Body.Close(); err != nil {
+					return r, errors.Wrap(err, "failed to close request body")
+				}
+				if len(b) > 0 {
+					if err := json.Unmarshal(b, &body); err != nil {
+						return r, errors.Wrap(err, "failed to unmarshal request body")
+					}
+				}
+			}
+
+			body = mergeProperties(body, fields)
+
+			b, err := json.Marshal(body)
+			if err != nil {
+				return r, errors.Wrap(err, "failed to marshal request body")
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(b))
+			r.ContentLength = int64(len(b))
+			r.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(b)), nil
+			}
+
+			return autorest.Prepare(r, WithAPIVersion(apiVersion))
+		})
+	}
+}
+
+// WithAPIVersion returns a PrepareDecorator that overrides the api-version query parameter of a request.
+// An empty apiVersion leaves the request untouched.
+func WithAPIVersion(apiVersion string) autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil || apiVersion == "" {
+				return r, err
+			}
+			q := r.URL.Query()
+			q.Set("api-version", apiVersion)
+			r.URL.RawQuery = q.Encode()
+			return r, nil
+		})
+	}
+}
+
+// ByCapturingAdditionalProperties returns a RespondDecorator that decodes the "properties" object of a
+// successful JSON response into properties. The response body is restored afterwards, so that it can
+// still be unmarshalled by the responder of the SDK.
+func ByCapturingAdditionalProperties(properties *map[string]interface{}) autorest.RespondDecorator {
+	return func(r autorest.Responder) autorest.Responder {
+		return autorest.ResponderFunc(func(resp *http.Response) error {
+			var fields map[string]interface{}
+			if err := ByCapturingAdditionalFields(&fields)(r).Respond(resp); err != nil {
+				return err
+			}
+			*properties, _ = fields["properties"].(map[string]interface{})
+			return nil
+		})
+	}
+}
+
+// ByCapturingAdditionalFields works like ByCapturingAdditionalProperties, but decodes all top level fields
+// of a successful JSON response into fields.
+func ByCapturingAdditionalFields(fields *map[string]interface{}) autorest.RespondDecorator {
+	return func(r autorest.Responder) autorest.Responder {
+		return autorest.ResponderFunc(func(resp *http.Response) error {
+			err := r.Respond(resp)
+			if err != nil || resp == nil || resp.Body == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return err
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return errors.Wrap(err, "failed to read response body")
+			}
+			if err := resp.Body.Close(); err != nil {
+				return errors.Wrap(err, "failed to close response body")
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+			body := map[string]interface{}{}
+			if len(b) > 0 {
+				if err := json.Unmarshal(b, &body); err != nil {
+					return errors.Wrap(err, "failed to unmarshal response body")
+				}
+			}
+			*fields = body
+			return nil
+		})
+	}
+}
+
+// DiffAdditionalProperties compares desired additional properties with the properties of an existing
+// resource, and returns the differences in a human readable form or an empty string if there are none.
+// Only the properties present in desired are compared, everything else AKS returns is ignored.
+func DiffAdditionalProperties(desired, existing map[string]interface{}) string {
+	if len(desired) == 0 {
+		return ""
+	}
+
+	// Round-trip the desired properties through JSON, so that their values have the same types as
+	// the ones decoded from the response.
+	normalized := map[string]interface{}{}
+	if b, err := json.Marshal(desired); err == nil {
+		_ = json.Unmarshal(b, &normalized)
+	}
+
+	return cmp.Diff(normalized, selectProperties(normalized, existing))
+}
+
+// selectProperties returns the properties of src that are present in keys, recursing into nested objects.
+func selectProperties(keys, src map[string]interface{}) map[string]interface{} {
+	selected := map[string]interface{}{}
+	for k, v := range keys {
+		existing, ok := src[k]
+		if !ok {
+			continue
+		}
+		keyObj, keyIsObj := v.(map[string]interface{})
+		existingObj, existingIsObj := existing.(map[string]interface{})
+		if keyIsObj && existingIsObj {
+			selected[k] = selectProperties(keyObj, existingObj)
+			continue
+		}
+		selected[k] = existing
+	}
+	return selected
+}
+
+// mergeProperties merges src into dst, recursing into nested objects present in both.
+func mergeProperties(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+	for k, v := range src {
+		srcObj, srcIsObj := v.(map[string]interface{})
+		dstObj, dstIsObj := dst[k].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			dst[k] = mergeProperties(dstObj, srcObj)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
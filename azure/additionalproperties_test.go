@@ -0,0 +1,244 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+)
+
+func TestWithAdditionalProperties(t *testing.T) {
+	type body struct {
+		Location   string                 `json:"location,omitempty"`
+		Properties map[string]interface{} `json:"properties,omitempty"`
+	}
+
+	tests := []struct {
+		name               string
+		apiVersion         string
+		additional         map[string]interface{}
+		expectedAPIVersion string
+		expectedProperties map[string]interface{}
+	}{
+		{
+			name:               "no additional properties leaves the request untouched",
+			apiVersion:         "2099-01-01",
+			expectedAPIVersion: "2020-02-01",
+			expectedProperties: map[string]interface{}{
+				"kubernetesVersion": "1.20.2",
+				"networkProfile":    map[string]interface{}{"networkPlugin": "azure"},
+			},
+		},
+		{
+			name:       "additional properties are merged and the api version is overridden",
+			apiVersion: "2099-01-01",
+			additional: map[string]interface{}{
+				"networkProfile": map[string]interface{}{"outboundType": "userDefinedRouting"},
+				"ingressProfile": map[string]interface{}{"webAppRouting": map[string]interface{}{"enabled": true}},
+			},
+			expectedAPIVersion: "2099-01-01",
+			expectedProperties: map[string]interface{}{
+				"kubernetesVersion": "1.20.2",
+				"networkProfile":    map[string]interface{}{"networkPlugin": "azure", "outboundType": "userDefinedRouting"},
+				"ingressProfile":    map[string]interface{}{"webAppRouting": map[string]interface{}{"enabled": true}},
+			},
+		},
+		{
+			name: "empty api version keeps the original one",
+			additional: map[string]interface{}{
+				"kubernetesVersion": "1.21.1",
+			},
+			expectedAPIVersion: "2020-02-01",
+			expectedProperties: map[string]interface{}{
+				"kubernetesVersion": "1.21.1",
+				"networkProfile":    map[string]interface{}{"networkPlugin": "azure"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			req, err := autorest.Prepare(&http.Request{},
+				autorest.AsPut(),
+				autorest.WithBaseURL("https://management.azure.com"),
+				autorest.WithPath("/foo"),
+				autorest.WithQueryParameters(map[string]interface{}{"api-version": "2020-02-01"}),
+				autorest.WithJSON(body{
+					Location: "westus2",
+					Properties: map[string]interface{}{
+						"kubernetesVersion": "1.20.2",
+						"networkProfile":    map[string]interface{}{"networkPlugin": "azure"},
+					},
+				}),
+				WithAdditionalProperties(tc.apiVersion, tc.additional),
+			)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(req.URL.Query().Get("api-version")).To(Equal(tc.expectedAPIVersion))
+
+			b, err := ioutil.ReadAll(req.Body)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(req.ContentLength).To(BeEquivalentTo(len(b)))
+			var got body
+			g.Expect(json.Unmarshal(b, &got)).To(Succeed())
+			g.Expect(got.Location).To(Equal("westus2"))
+			g.Expect(got.Properties).To(Equal(tc.expectedProperties))
+		})
+	}
+}
+
+func TestWithAdditionalFields(t *testing.T) {
+	g := NewWithT(t)
+
+	req, err := autorest.Prepare(&http.Request{},
+		autorest.AsPut(),
+		autorest.WithBaseURL("https://management.azure.com"),
+		autorest.WithPath("/foo"),
+		autorest.WithQueryParameters(map[string]interface{}{"api-version": "2020-02-01"}),
+		autorest.WithJSON(map[string]interface{}{
+			"location":   "westus2",
+			"properties": map[string]interface{}{"kubernetesVersion": "1.20.2"},
+		}),
+		WithAdditionalFields("2099-01-01", map[string]interface{}{
+			"sku":        map[string]interface{}{"name": "Base", "tier": "Standard"},
+			"properties": map[string]interface{}{"metricsProfile": map[string]interface{}{"costAnalysis": map[string]interface{}{"enabled": true}}},
+		}),
+	)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(req.URL.Query().Get("api-version")).To(Equal("2099-01-01"))
+
+	var got map[string]interface{}
+	g.Expect(json.NewDecoder(req.Body).Decode(&got)).To(Succeed())
+	g.Expect(got).To(Equal(map[string]interface{}{
+		"location": "westus2",
+		"sku":      map[string]interface{}{"name": "Base", "tier": "Standard"},
+		"properties": map[string]interface{}{
+			"kubernetesVersion": "1.20.2",
+			"metricsProfile":    map[string]interface{}{"costAnalysis": map[string]interface{}{"enabled": true}},
+		},
+	}))
+}
+
+func TestByCapturingAdditionalFields(t *testing.T) {
+	g := NewWithT(t)
+
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"name":"my-cluster","sku":{"name":"Base","tier":"Free"},"properties":{"kubernetesVersion":"1.20.2"}}`)),
+	}
+	var fields map[string]interface{}
+	g.Expect(autorest.Respond(resp, ByCapturingAdditionalFields(&fields))).To(Succeed())
+	g.Expect(fields).To(Equal(map[string]interface{}{
+		"name":       "my-cluster",
+		"sku":        map[string]interface{}{"name": "Base", "tier": "Free"},
+		"properties": map[string]interface{}{"kubernetesVersion": "1.20.2"},
+	}))
+}
+
+func TestByCapturingAdditionalProperties(t *testing.T) {
+	g := NewWithT(t)
+
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"name":"pool0","properties":{"count":3,"scaleDownMode":"Deallocate"}}`)),
+	}
+	var props map[string]interface{}
+	g.Expect(autorest.Respond(resp, ByCapturingAdditionalProperties(&props))).To(Succeed())
+	g.Expect(props).To(Equal(map[string]interface{}{"count": float64(3), "scaleDownMode": "Deallocate"}))
+
+	// The body can still be consumed by the SDK responder.
+	var pool struct {
+		Name string `json:"name"`
+	}
+	g.Expect(autorest.Respond(resp, autorest.ByUnmarshallingJSON(&pool), autorest.ByClosing())).To(Succeed())
+	g.Expect(pool.Name).To(Equal("pool0"))
+
+	props = nil
+	resp = &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":"NotFound"}}`)),
+	}
+	g.Expect(autorest.Respond(resp, ByCapturingAdditionalProperties(&props))).To(Succeed())
+	g.Expect(props).To(BeNil())
+}
+
+func TestDiffAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		name       string
+		desired    map[string]interface{}
+		existing   map[string]interface{}
+		expectDiff bool
+	}{
+		{
+			name:       "no desired properties",
+			existing:   map[string]interface{}{"scaleDownMode": "Delete"},
+			expectDiff: false,
+		},
+		{
+			name: "matching properties, ignoring the ones not desired",
+			desired: map[string]interface{}{
+				"ingressProfile": map[string]interface{}{
+					"webAppRouting": map[string]interface{}{
+						"enabled":            true,
+						"dnsZoneResourceIds": []string{"zone"},
+					},
+				},
+			},
+			existing: map[string]interface{}{
+				"kubernetesVersion": "1.20.2",
+				"ingressProfile": map[string]interface{}{
+					"webAppRouting": map[string]interface{}{
+						"enabled":            true,
+						"dnsZoneResourceIds": []interface{}{"zone"},
+						"identity":           map[string]interface{}{"clientId": "foo"},
+					},
+				},
+			},
+			expectDiff: false,
+		},
+		{
+			name:       "changed property",
+			desired:    map[string]interface{}{"scaleDownMode": "Deallocate"},
+			existing:   map[string]interface{}{"scaleDownMode": "Delete"},
+			expectDiff: true,
+		},
+		{
+			name:       "missing property",
+			desired:    map[string]interface{}{"scaleDownMode": "Deallocate"},
+			existing:   nil,
+			expectDiff: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			diff := DiffAdditionalProperties(tc.desired, tc.existing)
+			if tc.expectDiff {
+				g.Expect(diff).NotTo(BeEmpty())
+			} else {
+				g.Expect(diff).To(BeEmpty())
+			}
+		})
+	}
+}
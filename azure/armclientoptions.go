@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2/klogr"
+)
+
+// ARMClientOptions configures how all clients of the controller reach Azure Resource Manager and Azure AD, so the
+// management cluster can run without public egress.
+type ARMClientOptions struct {
+	// ProxyURL is the HTTP proxy requests to Azure are sent through. Unlike the HTTPS_PROXY environment variable,
+	// it does not apply to requests to the Kubernetes API server. If nil, the proxy of the environment is used.
+	ProxyURL *url.URL
+
+	// ResourceManagerEndpoint replaces the Azure Resource Manager endpoint of all environments, e.g. with a private
+	// endpoint of Azure Resource Manager. Tokens are still acquired for the audience of the environment.
+	ResourceManagerEndpoint string
+
+	// ReadRateLimit limits the reads of all clients from each subscription, on top of the limits of Azure Resource
+	// Manager, so a single cluster can't use them up.
+	ReadRateLimit RateLimit
+
+	// WriteRateLimit limits the writes and deletes of all clients to each subscription.
+	WriteRateLimit RateLimit
+
+	// DryRun makes all clients only log the changes they would make to Azure resources, without making them. See
+	// WithDryRun.
+	DryRun bool
+
+	// MaxRetries is how many times each request failing with a transient error is retried, with jittered backoff.
+	// See DefaultMaxRetries.
+	MaxRetries int
+
+	// RetryBudget is how many retries the requests made while reconciling an object may make in total. See
+	// WithRetryBudget and DefaultRetryBudget.
+	RetryBudget int
+
+	// ReadOnly makes all clients refuse to change Azure resources, for identities with only the Reader role. See
+	// NewReadOnlyDryRun.
+	ReadOnly bool
+
+	// PollingInterval is how often the clients poll long running operations for their completion, instead of the
+	// interval Azure asks for with the Retry-After of its responses. Zero keeps the interval of Azure.
+	PollingInterval time.Duration
+
+	// PollBudget is how long the clients wait for a single long running operation to complete, after which the
+	// service is requeued instead of waiting until the Azure call timeout. Zero waits until the Azure call timeout.
+	PollBudget time.Duration
+
+	// LogRequestBodies makes all clients log the bodies of the requests changing Azure resources and of their
+	// responses at verbosity 6, with secrets like custom data, passwords and SAS tokens redacted.
+	LogRequestBodies bool
+
+	// AuditLog is where all clients write an audit record of each change they request to an Azure resource, as JSON
+	// lines. See AuditRecord. If nil, changes aren't audited.
+	AuditLog io.Writer
+}
+
+var (
+	armClientOptions ARMClientOptions
+	armSender        *http.Client
+	armRateLimiters  = newRateLimiters(RateLimit{}, RateLimit{})
+	armAuditLog      *auditLog
+)
+
+// SetARMClientOptions configures the clients created afterwards. It is meant to be called once, at startup.
+func SetARMClientOptions(options ARMClientOptions) {
+	armClientOptions = options
+	armRateLimiters = newRateLimiters(options.ReadRateLimit, options.WriteRateLimit)
+	armAuditLog = newAuditLog(options.AuditLog)
+	armSender = nil
+	if options.ProxyURL != nil {
+		armSender = newProxySender(options.ProxyURL)
+	}
+}
+
+// GetARMClientOptions returns the options clients are configured with.
+func GetARMClientOptions() ARMClientOptions {
+	return armClientOptions
+}
+
+// senderOptions configures the decorators of the senders of the clients.
+type senderOptions struct {
+	// maxRetries is how many times a request failing with a transient error is retried.
+	maxRetries int
+	// retryBackoff is how long the first retry of a request waits.
+	retryBackoff time.Duration
+	// logger logs the bodies of requests and responses, if set.
+	logger logr.Logger
+	// audit records the changes requested to Azure resources, if set.
+	audit *auditLog
+}
+
+// armSendDecorators returns the decorators of the senders of the clients configured by the ARM client options.
+func armSendDecorators() []autorest.SendDecorator {
+	options := senderOptions{
+		maxRetries:   armClientOptions.MaxRetries,
+		retryBackoff: retryBackoff,
+		audit:        armAuditLog,
+	}
+	if armClientOptions.LogRequestBodies {
+		options.logger = klogr.New().WithName("azure")
+	}
+	return sendDecorators(options)
+}
+
+// sendDecorators returns the decorators of the senders of the clients, innermost first. Each request is traced by a
+// span propagated to Azure Resource Manager. In a dry run, requests changing Azure resources are recorded by the dry
+// run instead of being sent. Otherwise, the responses update the resource and service statuses, are recorded by the
+// resource events of the context, if any, and changes are audited. Requests failing with transient errors are
+// retried, and the bodies of requests and responses are logged each time they are sent.
+func sendDecorators(options senderOptions) []autorest.SendDecorator {
+	return []autorest.SendDecorator{
+		withRequestLogging(options.logger),
+		withRetries(options.maxRetries, options.retryBackoff),
+		withTagsUpdates(),
+		withAudit(options.audit),
+		withResourceStatuses(),
+		withServiceStatuses(),
+		withResourceEvents(),
+		withDryRun(),
+		withRequestSpans(),
+		withDryRunResults(),
+	}
+}
+
+// SetAuthorizerSender makes an authorizer acquire its tokens through the configured proxy, if any. Authorizers
+// of tokens other than service principal tokens are left as they are.
+func SetAuthorizerSender(authorizer autorest.Authorizer) {
+	if armSender == nil {
+		return
+	}
+	switch a := authorizer.(type) {
+	case *autorest.BearerAuthorizer:
+		if spt, ok := a.TokenProvider().(*adal.ServicePrincipalToken); ok {
+			spt.SetSender(armSender)
+		}
+	case *autorest.MultiTenantBearerAuthorizer:
+		if mt, ok := a.TokenProvider().(*adal.MultiTenantServicePrincipalToken); ok {
+			mt.PrimaryToken.SetSender(armSender)
+			for _, spt := range mt.AuxiliaryTokens {
+				spt.SetSender(armSender)
+			}
+		}
+	}
+}
+
+// newProxySender returns a client sending requests through a proxy. Requests to link-local and loopback
+// addresses, like the ones to the instance metadata service for managed identity tokens, bypass the proxy.
+func newProxySender(proxyURL *url.URL) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if ip := net.ParseIP(req.URL.Hostname()); ip != nil && (ip.IsLinkLocalUnicast() || ip.IsLoopback()) {
+			return nil, nil
+		}
+		if req.URL.Hostname() == "localhost" {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+)
+
+func TestARMClientOptions(t *testing.T) {
+	g := NewWithT(t)
+	defer SetARMClientOptions(ARMClientOptions{})
+
+	client := autorest.NewClientWithUserAgent("")
+	SetAutoRestClientDefaults(&client, autorest.NullAuthorizer{})
+	// The client doesn't retry requests itself, it only registers missing resource providers.
+	g.Expect(client.SendDecorators).To(HaveLen(1))
+	g.Expect(newThrottlingSender(armSender).sender).To(BeIdenticalTo(defaultSender))
+
+	proxyURL, _ := url.Parse("http://proxy.example.com:3128")
+	SetARMClientOptions(ARMClientOptions{ProxyURL: proxyURL})
+	g.Expect(GetARMClientOptions().ProxyURL).To(Equal(proxyURL))
+	g.Expect(newThrottlingSender(armSender).sender).To(BeIdenticalTo(armSender))
+
+	proxy := armSender.Transport.(*http.Transport).Proxy
+	for target, expected := range map[string]*url.URL{
+		"https://management.azure.com/subscriptions":            proxyURL,
+		"https://login.microsoftonline.com/tenant/oauth2/token": proxyURL,
+		"http://169.254.169.254/metadata/identity/oauth2/token": nil,
+		"http://127.0.0.1:2579/metadata/identity/oauth2/token":  nil,
+		"http://localhost:2579/metadata/identity/oauth2/token":  nil,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		actual, err := proxy(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(actual).To(Equal(expected), target)
+	}
+}
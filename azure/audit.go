@@ -0,0 +1,181 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// AuditOperationCreate is the operation of audit records of Azure resources created.
+	AuditOperationCreate = "Create"
+	// AuditOperationUpdate is the operation of audit records of Azure resources updated.
+	AuditOperationUpdate = "Update"
+	// AuditOperationDelete is the operation of audit records of Azure resources deleted.
+	AuditOperationDelete = "Delete"
+
+	// AuditResultSucceeded is the result of audit records of requests Azure completed.
+	AuditResultSucceeded = "Succeeded"
+	// AuditResultAccepted is the result of audit records of requests Azure accepted as long running operations.
+	AuditResultAccepted = "Accepted"
+	// AuditResultFailed is the result of audit records of requests Azure failed or didn't answer.
+	AuditResultFailed = "Failed"
+)
+
+// AuditRecord records a change the controller requested to an Azure resource, for change management.
+type AuditRecord struct {
+	// Timestamp is when the response to the request was received.
+	Timestamp time.Time `json:"timestamp"`
+	// ResourceID is the ID of the Azure resource changed.
+	ResourceID string `json:"resourceID"`
+	// Operation is the change requested: Create, Update or Delete.
+	Operation string `json:"operation"`
+	// Initiator is the object the change was made for, e.g. "AzureMachine default/my-machine", if known.
+	Initiator string `json:"initiator,omitempty"`
+	// Result is whether the change Succeeded, was Accepted as a long running operation, or Failed.
+	Result string `json:"result"`
+	// StatusCode is the HTTP status code of the response, if any.
+	StatusCode int `json:"statusCode,omitempty"`
+	// ErrorCode is the code of the error Azure returned, if the change failed.
+	ErrorCode string `json:"errorCode,omitempty"`
+	// CorrelationID is the ID Azure Resource Manager correlates the operations of the request with.
+	CorrelationID string `json:"correlationID,omitempty"`
+	// RequestID is the ID Azure Resource Manager assigned to the request.
+	RequestID string `json:"requestID,omitempty"`
+}
+
+// auditLog writes audit records as JSON lines.
+type auditLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	now     func() time.Time
+}
+
+// newAuditLog returns an audit log writing to w, or nil if w is nil.
+func newAuditLog(w io.Writer) *auditLog {
+	if w == nil {
+		return nil
+	}
+	return &auditLog{encoder: json.NewEncoder(w), now: time.Now}
+}
+
+// withAudit records the changes requested to Azure resources in audit, if set, unless they are requested in a dry
+// run.
+func withAudit(audit *auditLog) autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		if audit == nil {
+			return s
+		}
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := s.Do(req)
+			if DryRunFrom(req.Context()) == nil {
+				audit.record(req, resp)
+			}
+			return resp, err
+		})
+	}
+}
+
+// record writes an audit record for a request changing an Azure resource. Other requests aren't recorded.
+func (l *auditLog) record(req *http.Request, resp *http.Response) {
+	operation, ok := auditOperation(req, resp)
+	if !ok {
+		return
+	}
+	record := AuditRecord{
+		Timestamp:  l.now().UTC(),
+		ResourceID: resourceID(req.URL.Path),
+		Operation:  operation,
+		Result:     AuditResultFailed,
+	}
+	if events := resourceEventsFrom(req.Context()); events != nil {
+		record.Initiator = objectReference(events.object)
+	}
+	if ids, ok := responseRequestIDs(req, resp); ok {
+		record.CorrelationID, record.RequestID = ids.CorrelationID, ids.RequestID
+	} else {
+		record.CorrelationID = req.Header.Get(correlationIDHeader)
+	}
+	if resp != nil {
+		record.StatusCode = resp.StatusCode
+		switch {
+		case resp.StatusCode == http.StatusAccepted:
+			record.Result = AuditResultAccepted
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			record.Result = AuditResultSucceeded
+		default:
+			record.ErrorCode, _ = responseError(resp)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Failing to write the audit log doesn't fail the change, which was already made.
+	_ = l.encoder.Encode(record)
+}
+
+// auditOperation returns the operation of a request changing an Azure resource. PUT requests answered with 201
+// Created create resources, while the other PUT and PATCH requests update them.
+func auditOperation(req *http.Request, resp *http.Response) (string, bool) {
+	switch req.Method {
+	case http.MethodPut:
+		if resp != nil && resp.StatusCode == http.StatusCreated {
+			return AuditOperationCreate, true
+		}
+		return AuditOperationUpdate, true
+	case http.MethodPatch:
+		return AuditOperationUpdate, true
+	case http.MethodDelete:
+		return AuditOperationDelete, true
+	default:
+		return "", false
+	}
+}
+
+// resourceID returns the ID of the resource of a request path, without the trailing slash.
+func resourceID(path string) string {
+	return strings.TrimSuffix(path, "/")
+}
+
+// objectReference returns the kind, namespace and name of an object, e.g. "AzureMachine default/my-machine".
+func objectReference(obj runtime.Object) string {
+	if obj == nil {
+		return ""
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		kind = reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return kind
+	}
+	if accessor.GetNamespace() == "" {
+		return kind + " " + accessor.GetName()
+	}
+	return kind + " " + accessor.GetNamespace() + "/" + accessor.GetName()
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestAuditLog(t *testing.T) {
+	const ip = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		method     string
+		statusCode int
+		body       string
+		record     string
+	}{
+		"created": {
+			method:     http.MethodPut,
+			statusCode: http.StatusCreated,
+			record:     `{"timestamp":"2021-06-01T12:00:00Z","resourceID":"` + ip + `","operation":"Create","initiator":"AzureMachine default/my-machine","result":"Succeeded","statusCode":201,"correlationID":"abc","requestID":"def"}`,
+		},
+		"updated": {
+			method:     http.MethodPatch,
+			statusCode: http.StatusOK,
+			record:     `{"timestamp":"2021-06-01T12:00:00Z","resourceID":"` + ip + `","operation":"Update","initiator":"AzureMachine default/my-machine","result":"Succeeded","statusCode":200,"correlationID":"abc","requestID":"def"}`,
+		},
+		"deleting": {
+			method:     http.MethodDelete,
+			statusCode: http.StatusAccepted,
+			record:     `{"timestamp":"2021-06-01T12:00:00Z","resourceID":"` + ip + `","operation":"Delete","initiator":"AzureMachine default/my-machine","result":"Accepted","statusCode":202,"correlationID":"abc","requestID":"def"}`,
+		},
+		"failed": {
+			method:     http.MethodPut,
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"code":"PublicIPCountLimitReached","message":"Cannot create more than 10 public IP addresses"}}`,
+			record:     `{"timestamp":"2021-06-01T12:00:00Z","resourceID":"` + ip + `","operation":"Update","initiator":"AzureMachine default/my-machine","result":"Failed","statusCode":400,"errorCode":"PublicIPCountLimitReached","correlationID":"abc","requestID":"def"}`,
+		},
+		"read": {
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var log bytes.Buffer
+			audit := newAuditLog(&log)
+			audit.now = func() time.Time { return now }
+			sender := autorest.DecorateSender(&throttlingSender{
+				sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+					resp := &http.Response{
+						StatusCode: tc.statusCode,
+						Header:     http.Header{},
+						Body:       ioutil.NopCloser(strings.NewReader(tc.body)),
+						Request:    req,
+					}
+					resp.Header.Set(correlationIDHeader, "abc")
+					resp.Header.Set(requestIDHeader, "def")
+					return resp, nil
+				}),
+				buckets:  newThrottleBuckets(),
+				limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+			}, sendDecorators(senderOptions{
+				audit: audit,
+			})...)
+			machine := &infrav1.AzureMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-machine"}}
+			ctx := WithResourceEvents(context.Background(), NewResourceEvents(record.NewFakeRecorder(10), machine))
+
+			req, _ := http.NewRequestWithContext(ctx, tc.method, "https://management.azure.com"+ip, nil)
+			resp, err := sender.Do(req)
+			g.Expect(err).NotTo(HaveOccurred())
+			body, _ := ioutil.ReadAll(resp.Body)
+			g.Expect(string(body)).To(Equal(tc.body))
+
+			if tc.record == "" {
+				g.Expect(log.String()).To(BeEmpty())
+			} else {
+				g.Expect(log.String()).To(Equal(tc.record + "\n"))
+			}
+		})
+	}
+}
+
+func TestAuditLogDisabled(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(newAuditLog(nil)).To(BeNil())
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package azure
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest/azure"
 
@@ -26,6 +29,7 @@ import (
 	"github.com/pkg/errors"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/version"
 )
 
@@ -76,6 +80,22 @@ const (
 	bootstrapSentinelFile = "/run/cluster-api/bootstrap-success.complete"
 )
 
+// Azure built-in roles assigned to cluster identities, see https://docs.microsoft.com/en-us/azure/role-based-access-control/built-in-roles.
+const (
+	// VirtualMachineContributorRoleID is the ID of the Virtual Machine Contributor role.
+	VirtualMachineContributorRoleID = "9980e02c-c2be-4d73-94e8-173b1dc7cf3c"
+	// NetworkContributorRoleID is the ID of the Network Contributor role.
+	NetworkContributorRoleID = "4d97b98b-1d4f-4787-a291-c67834d212e7"
+	// PrivateDNSZoneContributorRoleID is the ID of the Private DNS Zone Contributor role.
+	PrivateDNSZoneContributorRoleID = "b12aa53e-6015-4669-85d0-8515ebb3ae7f"
+	// ManagedIdentityContributorRoleID is the ID of the Managed Identity Contributor role.
+	ManagedIdentityContributorRoleID = "e40ec5ca-96e0-45a2-b4ff-59039f2c2b59"
+	// UserAccessAdministratorRoleID is the ID of the User Access Administrator role.
+	UserAccessAdministratorRoleID = "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9"
+	// TagContributorRoleID is the ID of the Tag Contributor role.
+	TagContributorRoleID = "4a9ae827-6dc8-4573-8ac7-8239d42aa03f"
+)
+
 const (
 	// ProviderIDPrefix will be appended to the beginning of Azure resource IDs to form the Kubernetes Provider ID.
 	// NOTE: this format matches the 2 slashes format used in cloud-provider and cluster-autoscaler.
@@ -165,6 +185,11 @@ func WithIndex(name string, n int) string {
 	return fmt.Sprintf("%s-%d", name, n)
 }
 
+// ResourceGroupID returns the azure resource ID for a given resource group.
+func ResourceGroupID(subscriptionID, resourceGroup string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscriptionID, resourceGroup)
+}
+
 // VMID returns the azure resource ID for a given VM.
 func VMID(subscriptionID, resourceGroup, vmName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachines/%s", subscriptionID, resourceGroup, vmName)
@@ -200,6 +225,11 @@ func NetworkInterfaceID(subscriptionID, resourceGroup, nicName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkInterfaces/%s", subscriptionID, resourceGroup, nicName)
 }
 
+// LoadBalancerID returns the azure resource ID for a given load balancer.
+func LoadBalancerID(subscriptionID, resourceGroup, loadBalancerName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/loadBalancers/%s", subscriptionID, resourceGroup, loadBalancerName)
+}
+
 // FrontendIPConfigID returns the azure resource ID for a given frontend IP config.
 func FrontendIPConfigID(subscriptionID, resourceGroup, loadBalancerName, configName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/loadBalancers/%s/frontendIPConfigurations/%s", subscriptionID, resourceGroup, loadBalancerName, configName)
@@ -220,11 +250,31 @@ func NATRuleID(subscriptionID, resourceGroup, loadBalancerName, natRuleName stri
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/loadBalancers/%s/inboundNatRules/%s", subscriptionID, resourceGroup, loadBalancerName, natRuleName)
 }
 
+// BastionHostID returns the azure resource ID for a given bastion host.
+func BastionHostID(subscriptionID, resourceGroup, bastionName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/bastionHosts/%s", subscriptionID, resourceGroup, bastionName)
+}
+
 // AvailabilitySetID returns the azure resource ID for a given availability set.
 func AvailabilitySetID(subscriptionID, resourceGroup, availabilitySetName string) string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/availabilitySets/%s", subscriptionID, resourceGroup, availabilitySetName)
 }
 
+// ManagedClusterID returns the azure resource ID for a given managed cluster.
+func ManagedClusterID(subscriptionID, resourceGroup, managedClusterName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s", subscriptionID, resourceGroup, managedClusterName)
+}
+
+// BackupVaultID returns the azure resource ID for a given backup vault.
+func BackupVaultID(subscriptionID, resourceGroup, vaultName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.DataProtection/backupVaults/%s", subscriptionID, resourceGroup, vaultName)
+}
+
+// UserAssignedIdentityID returns the azure resource ID for a given user-assigned identity.
+func UserAssignedIdentityID(subscriptionID, resourceGroup, identityName string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ManagedIdentity/userAssignedIdentities/%s", subscriptionID, resourceGroup, identityName)
+}
+
 // GetDefaultImageSKUID gets the SKU ID of the image to use for the provided version of Kubernetes.
 func getDefaultImageSKUID(k8sVersion, os, osVersion string) (string, error) {
 	version, err := semver.ParseTolerant(k8sVersion)
@@ -295,9 +345,14 @@ func UserAgent() string {
 	return fmt.Sprintf("cluster-api-provider-azure/%s", version.Get().String())
 }
 
-// SetAutoRestClientDefaults set authorizer and user agent for autorest client.
+// SetAutoRestClientDefaults set authorizer, user agent and the sender of the ARM client options for autorest client.
 func SetAutoRestClientDefaults(c *autorest.Client, auth autorest.Authorizer) {
 	c.Authorizer = auth
+	c.Sender = autorest.DecorateSender(newThrottlingSender(armSender), armSendDecorators()...)
+	// Requests are retried by the sender rather than by the client, which would retry throttled requests until the
+	// throttling ends, blocking a worker of the controller; the controllers requeue them once it has ended instead.
+	// Only the registration of missing resource providers is kept from the default decorators of the client.
+	c.SendDecorators = []autorest.SendDecorator{doRegisterResourceProviders(auth)}
 	AutoRestClientAppendUserAgent(c, UserAgent())
 }
 
@@ -305,3 +360,62 @@ func SetAutoRestClientDefaults(c *autorest.Client, auth autorest.Authorizer) {
 func AutoRestClientAppendUserAgent(c *autorest.Client, extension string) {
 	_ = c.AddToUserAgent(extension) // intentionally ignore error as it doesn't matter
 }
+
+// pollBudgetRequeueAfter is how long a service whose long running operation didn't complete within the poll budget
+// waits before being reconciled again.
+const pollBudgetRequeueAfter = 15 * time.Second
+
+// WaitForCompletion waits for a long running operation to complete, for at most the Azure call timeout of ctx, or the
+// poll budget of the ARM client options if shorter, in which case a transient error requeues the service once the
+// budget is spent. A deadline on ctx is required for the timeout to apply, as the futures ignore the polling duration
+// of the client as soon as ctx has one. Waiting stops as soon as polling is throttled, rather than backing off, so the
+// controllers can requeue once the throttling ends.
+func WaitForCompletion(ctx context.Context, future azure.FutureAPI, client autorest.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.AzureCallTimeout(ctx))
+	defer cancel()
+	pollCtx := ctx
+	if budget := armClientOptions.PollBudget; budget > 0 {
+		var cancelPoll context.CancelFunc
+		pollCtx, cancelPoll = context.WithTimeout(ctx, budget)
+		defer cancelPoll()
+	}
+
+	// The futures only fall back to the polling delay of the client when responses have no Retry-After.
+	pollingInterval := armClientOptions.PollingInterval
+	if pollingInterval > 0 {
+		client.PollingDelay = pollingInterval
+		if resp := future.Response(); resp != nil {
+			resp.Header.Del("Retry-After")
+		}
+	}
+
+	var throttled *http.Response
+	inspectors := []autorest.RespondDecorator{
+		func(r autorest.Responder) autorest.Responder {
+			return autorest.ResponderFunc(func(resp *http.Response) error {
+				if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+					throttled = resp
+					cancel()
+				} else if resp != nil && pollingInterval > 0 {
+					resp.Header.Del("Retry-After")
+				}
+				return r.Respond(resp)
+			})
+		},
+	}
+	if client.ResponseInspector != nil {
+		inspectors = append(inspectors, client.ResponseInspector)
+	}
+	client.ResponseInspector = func(r autorest.Responder) autorest.Responder {
+		return autorest.DecorateResponder(r, inspectors...)
+	}
+
+	err := future.WaitForCompletionRef(pollCtx, client)
+	if throttled != nil {
+		return autorest.NewErrorWithError(err, "azure", "WaitForCompletion", throttled, "polling was throttled")
+	}
+	if err != nil && ctx.Err() == nil && errors.Is(pollCtx.Err(), context.DeadlineExceeded) {
+		return WithTransientError(errors.Wrapf(err, "long running operation didn't complete within the poll budget of %s", armClientOptions.PollBudget), pollBudgetRequeueAfter)
+	}
+	return err
+}
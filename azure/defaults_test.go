@@ -17,11 +17,20 @@ limitations under the License.
 package azure
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
 	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 )
 
 func TestGetDefaultImageSKUID(t *testing.T) {
@@ -149,3 +158,99 @@ func TestAutoRestClientAppendUserAgent(t *testing.T) {
 		})
 	}
 }
+
+type fakeFuture struct {
+	azure.FutureAPI
+	deadline time.Time
+	pending  bool
+}
+
+func (f *fakeFuture) WaitForCompletionRef(ctx context.Context, _ autorest.Client) error {
+	f.deadline, _ = ctx.Deadline()
+	if f.pending {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (f *fakeFuture) Response() *http.Response {
+	return nil
+}
+
+func TestWaitForCompletion(t *testing.T) {
+	g := NewWithT(t)
+
+	future := &fakeFuture{}
+	g.Expect(WaitForCompletion(context.Background(), future, autorest.Client{})).To(Succeed())
+	g.Expect(time.Until(future.deadline)).To(BeNumerically("~", reconciler.DefaultAzureCallTimeout, time.Minute))
+
+	ctx := reconciler.WithAzureCallTimeout(context.Background(), 40*time.Minute)
+	g.Expect(WaitForCompletion(ctx, future, autorest.Client{})).To(Succeed())
+	g.Expect(time.Until(future.deadline)).To(BeNumerically("~", 40*time.Minute, time.Minute))
+}
+
+func TestWaitForCompletionPollBudget(t *testing.T) {
+	g := NewWithT(t)
+	defer SetARMClientOptions(ARMClientOptions{})
+
+	// The call timeout ends waiting before the budget does.
+	SetARMClientOptions(ARMClientOptions{PollBudget: time.Minute})
+	ctx := reconciler.WithAzureCallTimeout(context.Background(), 10*time.Millisecond)
+	err := WaitForCompletion(ctx, &fakeFuture{pending: true}, autorest.Client{})
+	var reconcileError ReconcileError
+	g.Expect(errors.As(err, &reconcileError)).To(BeFalse())
+
+	SetARMClientOptions(ARMClientOptions{PollBudget: 10 * time.Millisecond})
+	err = WaitForCompletion(context.Background(), &fakeFuture{pending: true}, autorest.Client{})
+	g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+	g.Expect(reconcileError.IsTransient()).To(BeTrue())
+	g.Expect(reconcileError.RequeueAfter()).To(Equal(pollBudgetRequeueAfter))
+
+	future := &fakeFuture{}
+	g.Expect(WaitForCompletion(context.Background(), future, autorest.Client{})).To(Succeed())
+	g.Expect(time.Until(future.deadline)).To(BeNumerically("<=", 10*time.Millisecond))
+}
+
+func TestWaitForCompletionPollingInterval(t *testing.T) {
+	g := NewWithT(t)
+	defer SetARMClientOptions(ARMClientOptions{})
+	SetARMClientOptions(ARMClientOptions{PollingInterval: time.Millisecond})
+
+	retryAfter := http.Header{"Retry-After": []string{"3600"}}
+	req, _ := http.NewRequest(http.MethodDelete, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip", nil)
+	future, err := azure.NewFutureFromResponse(&http.Response{
+		StatusCode: http.StatusAccepted,
+		Header: http.Header{
+			"Azure-Asyncoperation": []string{"https://management.azure.com/subscriptions/123/providers/Microsoft.Network/locations/westus/operations/op"},
+			"Retry-After":          []string{"3600"},
+		},
+		Body:    ioutil.NopCloser(strings.NewReader("")),
+		Request: req,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Azure asks to poll again in an hour, but the operation is polled every millisecond.
+	polls := 0
+	client := autorest.Client{
+		RetryAttempts: 3,
+		Sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			polls++
+			status := "InProgress"
+			if polls > 2 {
+				status = "Succeeded"
+			}
+			body := fmt.Sprintf(`{"status": %q}`, status)
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        retryAfter.Clone(),
+				Body:          ioutil.NopCloser(strings.NewReader(body)),
+				ContentLength: int64(len(body)),
+				Request:       req,
+			}, nil
+		}),
+	}
+	ctx := reconciler.WithAzureCallTimeout(context.Background(), 10*time.Second)
+	g.Expect(WaitForCompletion(ctx, &future, client)).To(Succeed())
+	g.Expect(polls).To(Equal(3))
+}
@@ -0,0 +1,416 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2/klogr"
+)
+
+// DryRun records the changes requests would have made to Azure resources, instead of sending them. Later requests
+// of the same dry run see the resources as if the changes had been made, so a dry run goes as far as a reconciliation
+// would have gone.
+type DryRun struct {
+	logger logr.Logger
+	// readOnly is whether the changes are refused because the controller may only read Azure resources, rather than
+	// only logged.
+	readOnly bool
+
+	lock    sync.Mutex
+	changes []string
+	// resources are the resources changed by the dry run, by path. Deleted resources are nil.
+	resources map[string]map[string]interface{}
+}
+
+// NewDryRun returns a dry run logging the changes it records to logger.
+func NewDryRun(logger logr.Logger) *DryRun {
+	return &DryRun{
+		logger:    logger,
+		resources: make(map[string]map[string]interface{}),
+	}
+}
+
+// NewReadOnlyDryRun returns a dry run refusing the changes it records, for identities with only the Reader role,
+// and logging them to logger. Unlike in other dry runs, the resources read from Azure are reported as they are,
+// since the dry run changes none of them.
+func NewReadOnlyDryRun(logger logr.Logger) *DryRun {
+	dryRun := NewDryRun(logger)
+	dryRun.readOnly = true
+	return dryRun
+}
+
+// ReadOnly returns whether the dry run refuses the changes because the controller may only read Azure resources.
+func (d *DryRun) ReadOnly() bool {
+	return d.readOnly
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context in which the requests of all clients which would change Azure resources are recorded
+// by dryRun instead of being sent.
+func WithDryRun(ctx context.Context, dryRun *DryRun) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dryRun)
+}
+
+// DryRunFrom returns the dry run of a context, or nil if requests made with it change Azure resources. Without one,
+// requests are still not sent when the controller runs in dry run or read-only mode, but each of them is a dry run of
+// its own.
+func DryRunFrom(ctx context.Context) *DryRun {
+	if dryRun, ok := ctx.Value(dryRunKey{}).(*DryRun); ok {
+		return dryRun
+	}
+	if armClientOptions.ReadOnly {
+		return NewReadOnlyDryRun(klogr.New())
+	}
+	if armClientOptions.DryRun {
+		return NewDryRun(klogr.New())
+	}
+	return nil
+}
+
+// Changes returns the changes the dry run would have made, in the order they would have been made, e.g.
+// "create Microsoft.Network/publicIPAddresses/my-ip".
+func (d *DryRun) Changes() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]string(nil), d.changes...)
+}
+
+// withDryRun answers the requests of a dry run through the dry run, which records the requests changing Azure
+// resources rather than sending them.
+func withDryRun() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			if dryRun := DryRunFrom(req.Context()); dryRun != nil {
+				return dryRun.do(req, s.Do)
+			}
+			return s.Do(req)
+		})
+	}
+}
+
+// do answers a request as Azure Resource Manager would have if the changes recorded so far had been made. Requests
+// which don't change resources are sent through send, unless they read resources changed by the dry run.
+func (d *DryRun) do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := strings.ToLower(req.URL.Path)
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		d.lock.Lock()
+		resource, changed := d.resources[key]
+		d.lock.Unlock()
+		if !changed {
+			return send(req)
+		}
+		if resource == nil {
+			return jsonResponse(req, http.StatusNotFound, map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":    "ResourceNotFound",
+					"message": fmt.Sprintf("%s was deleted by the dry run", resourceName(req.URL.Path)),
+				},
+			})
+		}
+		return jsonResponse(req, http.StatusOK, resource)
+
+	case http.MethodPut, http.MethodPatch:
+		desired, err := readJSONBody(req)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := d.existing(req, key, send)
+		if err != nil {
+			return nil, err
+		}
+
+		change := "update " + resourceName(req.URL.Path)
+		resource := desired
+		if existing == nil {
+			change = "create " + resourceName(req.URL.Path)
+		} else if req.Method == http.MethodPatch {
+			resource = mergeJSON(existing, desired)
+		}
+		// Updates which wouldn't change anything aren't changes.
+		if diff := cmp.Diff(pruneJSON(existing, desired), desired); existing == nil || diff != "" {
+			d.record(change, diff)
+		}
+
+		resource["id"] = req.URL.Path
+		resource["name"] = path.Base(req.URL.Path)
+		properties, ok := resource["properties"].(map[string]interface{})
+		if !ok {
+			properties = map[string]interface{}{}
+			resource["properties"] = properties
+		}
+		properties["provisioningState"] = "Succeeded"
+		d.lock.Lock()
+		d.resources[key] = resource
+		d.lock.Unlock()
+		resp, err := jsonResponse(req, http.StatusOK, resource)
+		if err != nil {
+			return nil, err
+		}
+		// The result of the operation is read from the URL of the request, without the context of the dry run.
+		resultURL := *req.URL
+		query := resultURL.Query()
+		query.Set(dryRunResultParam, storeDryRunResult(resource))
+		resultURL.RawQuery = query.Encode()
+		resp.Request = req.Clone(req.Context())
+		resp.Request.URL = &resultURL
+		return resp, nil
+
+	case http.MethodDelete:
+		d.record("delete "+resourceName(req.URL.Path), "")
+		d.lock.Lock()
+		d.resources[key] = nil
+		d.lock.Unlock()
+		return jsonResponse(req, http.StatusOK, nil)
+
+	default:
+		d.record(strings.ToLower(req.Method)+" "+resourceName(req.URL.Path), "")
+		return jsonResponse(req, http.StatusOK, map[string]interface{}{})
+	}
+}
+
+// dryRunResultParam is the query parameter of the URLs the results of long running operations of dry runs are read
+// from.
+const dryRunResultParam = "capzDryRunResult"
+
+// dryRunResultTTL is how long the result of a long running operation of a dry run can be read.
+const dryRunResultTTL = 10 * time.Minute
+
+type dryRunResult struct {
+	resource map[string]interface{}
+	stored   time.Time
+}
+
+var (
+	dryRunResultsLock sync.Mutex
+	dryRunResults     = map[string]dryRunResult{}
+	dryRunResultCount uint64
+)
+
+// storeDryRunResult stores the result of a long running operation of a dry run, until it is read or expires, and
+// returns its token.
+func storeDryRunResult(resource map[string]interface{}) string {
+	dryRunResultsLock.Lock()
+	defer dryRunResultsLock.Unlock()
+	for token, result := range dryRunResults {
+		if time.Since(result.stored) > dryRunResultTTL {
+			delete(dryRunResults, token)
+		}
+	}
+	dryRunResultCount++
+	token := strconv.FormatUint(dryRunResultCount, 10)
+	dryRunResults[token] = dryRunResult{resource: resource, stored: time.Now()}
+	return token
+}
+
+// withDryRunResults answers the requests for the results of the long running operations of dry runs, which are
+// read without the context of the dry run.
+func withDryRunResults() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			if token := req.URL.Query().Get(dryRunResultParam); token != "" {
+				return readDryRunResult(req, token)
+			}
+			return s.Do(req)
+		})
+	}
+}
+
+// readDryRunResult answers a request for the result of a long running operation of a dry run.
+func readDryRunResult(req *http.Request, token string) (*http.Response, error) {
+	dryRunResultsLock.Lock()
+	result, ok := dryRunResults[token]
+	delete(dryRunResults, token)
+	dryRunResultsLock.Unlock()
+	if !ok {
+		return nil, errors.Errorf("result %s of a dry run expired", token)
+	}
+	return jsonResponse(req, http.StatusOK, result.resource)
+}
+
+// record records a change and logs it, with the diff of the resource if any.
+func (d *DryRun) record(change, diff string) {
+	d.lock.Lock()
+	d.changes = append(d.changes, change)
+	d.lock.Unlock()
+	msg := "dry run, not changing Azure resource"
+	if d.readOnly {
+		msg = "read-only mode, refusing to change Azure resource"
+	}
+	if diff != "" {
+		d.logger.Info(msg, "change", change, "diff", diff)
+		return
+	}
+	d.logger.Info(msg, "change", change)
+}
+
+// existing returns a resource as the dry run left it, or as it exists in Azure, or nil if it doesn't exist.
+func (d *DryRun) existing(req *http.Request, key string, send func(*http.Request) (*http.Response, error)) (map[string]interface{}, error) {
+	d.lock.Lock()
+	resource, changed := d.resources[key]
+	d.lock.Unlock()
+	if changed {
+		return resource, nil
+	}
+	return getResource(req, send)
+}
+
+// getResource returns the resource a request is made for as it exists in Azure, or nil if it doesn't exist.
+func getResource(req *http.Request, send func(*http.Request) (*http.Response, error)) (map[string]interface{}, error) {
+	get := req.Clone(req.Context())
+	get.Method = http.MethodGet
+	get.Body = nil
+	get.GetBody = nil
+	get.ContentLength = 0
+	get.Header.Del("Content-Type")
+	get.Header.Del("If-Match")
+	resp, err := send(get)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", resourceName(req.URL.Path))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get %s: %s", resourceName(req.URL.Path), resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", resourceName(req.URL.Path))
+	}
+	resource := map[string]interface{}{}
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", resourceName(req.URL.Path))
+	}
+	return resource, nil
+}
+
+// readJSONBody returns the JSON object a request sends, leaving the body of the request as it was.
+func readJSONBody(req *http.Request) (map[string]interface{}, error) {
+	object := map[string]interface{}{}
+	if req.Body == nil {
+		return object, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read request body")
+	}
+	req.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+	if len(body) == 0 {
+		return object, nil
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, errors.Wrap(err, "failed to decode request body")
+	}
+	return object, nil
+}
+
+// jsonResponse returns a response of Azure Resource Manager with a JSON body, or no body if object is nil.
+func jsonResponse(req *http.Request, statusCode int, object map[string]interface{}) (*http.Response, error) {
+	var body []byte
+	if object != nil {
+		var err error
+		if body, err = json.Marshal(object); err != nil {
+			return nil, errors.Wrap(err, "failed to encode response body")
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Body:          ioutil.NopCloser(strings.NewReader(string(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// resourceName returns the path of a resource from its provider on, e.g.
+// "Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet", or from its subscription on for resource groups.
+func resourceName(resourcePath string) string {
+	if i := strings.Index(strings.ToLower(resourcePath), "/providers/"); i >= 0 {
+		return resourcePath[i+len("/providers/"):]
+	}
+	return strings.TrimPrefix(subscriptionPath.ReplaceAllString(resourcePath, ""), "/")
+}
+
+// pruneJSON returns the parts of an existing JSON value which are also in the desired one, so read-only properties
+// of existing resources don't show as changes.
+func pruneJSON(existing, desired interface{}) interface{} {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		e, ok := existing.(map[string]interface{})
+		if !ok {
+			return existing
+		}
+		pruned := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			if ev, ok := e[k]; ok {
+				pruned[k] = pruneJSON(ev, v)
+			}
+		}
+		return pruned
+	case []interface{}:
+		e, ok := existing.([]interface{})
+		if !ok || len(e) != len(d) {
+			return existing
+		}
+		pruned := make([]interface{}, len(e))
+		for i := range e {
+			pruned[i] = pruneJSON(e[i], d[i])
+		}
+		return pruned
+	default:
+		return existing
+	}
+}
+
+// mergeJSON returns an existing JSON object with the properties of a patch applied.
+func mergeJSON(existing, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(existing))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if pv, ok := v.(map[string]interface{}); ok {
+			if ev, ok := merged[k].(map[string]interface{}); ok {
+				merged[k] = mergeJSON(ev, pv)
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
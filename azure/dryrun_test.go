@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+)
+
+func TestDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	// Azure only has a virtual network, and fails any request changing resources.
+	var sent []string
+	client := network.NewPublicIPAddressesClient("123")
+	vnetsClient := network.NewVirtualNetworksClient("123")
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			sent = append(sent, req.Method+" "+req.URL.Path)
+			resp := &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}
+			if req.Method != http.MethodGet {
+				resp.StatusCode = http.StatusForbidden
+			} else if strings.HasSuffix(req.URL.Path, "/virtualNetworks/my-vnet") {
+				resp.StatusCode = http.StatusOK
+				body, _ := json.Marshal(network.VirtualNetwork{
+					ID:       to.StringPtr(req.URL.Path),
+					Location: to.StringPtr("westus"),
+					Tags:     map[string]*string{"team": to.StringPtr("infra")},
+					VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+						ProvisioningState: to.StringPtr("Succeeded"),
+					},
+				})
+				resp.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+			}
+			return resp, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{})...)
+	client.Sender = sender
+	vnetsClient.Sender = sender
+
+	dryRun := NewDryRun(klogr.New())
+	ctx := WithDryRun(context.Background(), dryRun)
+
+	// A public IP created by the dry run can be read afterwards, as if it had been created.
+	future, err := client.CreateOrUpdate(ctx, "my-rg", "my-ip", network.PublicIPAddress{Location: to.StringPtr("westus")})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(WaitForCompletion(ctx, &future, client.Client)).To(Succeed())
+	ip, err := future.Result(client)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(to.String(ip.ID)).To(Equal("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"))
+	g.Expect(to.String(ip.Location)).To(Equal("westus"))
+
+	vnetFuture, err := vnetsClient.CreateOrUpdate(ctx, "my-rg", "my-vnet", network.VirtualNetwork{
+		Location: to.StringPtr("westus"),
+		Tags:     map[string]*string{"team": to.StringPtr("platform")},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(WaitForCompletion(ctx, &vnetFuture, vnetsClient.Client)).To(Succeed())
+
+	deleteFuture, err := client.Delete(ctx, "my-rg", "my-ip")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(WaitForCompletion(ctx, &deleteFuture, client.Client)).To(Succeed())
+	_, err = client.Get(ctx, "my-rg", "my-ip", "")
+	g.Expect(ResourceNotFound(err)).To(BeTrue())
+
+	g.Expect(dryRun.Changes()).To(Equal([]string{
+		"create Microsoft.Network/publicIPAddresses/my-ip",
+		"update Microsoft.Network/virtualNetworks/my-vnet",
+		"delete Microsoft.Network/publicIPAddresses/my-ip",
+	}))
+	// Only the resources to change were read from Azure.
+	g.Expect(sent).To(Equal([]string{
+		"GET /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip",
+		"GET /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+	}))
+}
+
+func TestPruneJSON(t *testing.T) {
+	g := NewWithT(t)
+
+	existing := map[string]interface{}{
+		"id":   "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+		"etag": "abc",
+		"tags": map[string]interface{}{"team": "infra"},
+		"properties": map[string]interface{}{
+			"provisioningState": "Succeeded",
+			"subnets":           []interface{}{map[string]interface{}{"name": "my-subnet", "id": "my-subnet-id"}},
+		},
+	}
+	desired := map[string]interface{}{
+		"tags": map[string]interface{}{"team": "platform"},
+		"properties": map[string]interface{}{
+			"subnets": []interface{}{map[string]interface{}{"name": "my-subnet"}},
+		},
+	}
+	g.Expect(pruneJSON(existing, desired)).To(Equal(map[string]interface{}{
+		"tags": map[string]interface{}{"team": "infra"},
+		"properties": map[string]interface{}{
+			"subnets": []interface{}{map[string]interface{}{"name": "my-subnet"}},
+		},
+	}))
+}
+
+func TestResourceName(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(resourceName("/subscriptions/123/resourceGroups/my-rg")).To(Equal("resourceGroups/my-rg"))
+	g.Expect(resourceName("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")).
+		To(Equal("Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet"))
+	g.Expect(resourceName("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip/providers/Microsoft.Resources/tags/default")).
+		To(Equal("Microsoft.Network/publicIPAddresses/my-ip/providers/Microsoft.Resources/tags/default"))
+}
+
+func TestDryRunUnchanged(t *testing.T) {
+	g := NewWithT(t)
+
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			g.Expect(req.Method).To(Equal(http.MethodGet))
+			body := `{"id":"my-id","location":"westus","tags":{"team":"infra"},"properties":{"provisioningState":"Succeeded"}}`
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(body)), Request: req}, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{})...)
+	client := network.NewPublicIPAddressesClient("123")
+	client.Sender = sender
+
+	dryRun := NewDryRun(klogr.New())
+	ctx := WithDryRun(context.Background(), dryRun)
+	future, err := client.CreateOrUpdate(ctx, "my-rg", "my-ip", network.PublicIPAddress{
+		Location: to.StringPtr("westus"),
+		Tags:     map[string]*string{"team": to.StringPtr("infra")},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(WaitForCompletion(ctx, &future, client.Client)).To(Succeed())
+	g.Expect(dryRun.Changes()).To(BeEmpty())
+}
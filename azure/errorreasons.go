@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+// errorReason is the reason of the conditions reporting a failure of Azure Resource Manager, and their severity.
+type errorReason struct {
+	reason   string
+	severity clusterv1.ConditionSeverity
+}
+
+// errorCodeReasons are the condition reasons of the error codes of Azure Resource Manager. Failures reported with
+// the same reason call for the same action, so alerts and runbooks can key off the reason rather than the message.
+var errorCodeReasons = map[string]errorReason{
+	"QuotaExceeded":             {infrav1.QuotaExceededReason, clusterv1.ConditionSeverityError},
+	"PublicIPCountLimitReached": {infrav1.QuotaExceededReason, clusterv1.ConditionSeverityError},
+
+	"SkuNotAvailable": {infrav1.SKUNotAvailableReason, clusterv1.ConditionSeverityError},
+
+	"AllocationFailed":                      {infrav1.CapacityUnavailableReason, clusterv1.ConditionSeverityWarning},
+	"ZonalAllocationFailed":                 {infrav1.CapacityUnavailableReason, clusterv1.ConditionSeverityWarning},
+	"OverconstrainedAllocationRequest":      {infrav1.CapacityUnavailableReason, clusterv1.ConditionSeverityWarning},
+	"OverconstrainedZonalAllocationRequest": {infrav1.CapacityUnavailableReason, clusterv1.ConditionSeverityWarning},
+
+	"AuthorizationFailed":       {infrav1.AuthorizationFailedReason, clusterv1.ConditionSeverityError},
+	"LinkedAuthorizationFailed": {infrav1.AuthorizationFailedReason, clusterv1.ConditionSeverityError},
+
+	"AuthenticationFailed":             {infrav1.AuthenticationFailedReason, clusterv1.ConditionSeverityError},
+	"InvalidAuthenticationToken":       {infrav1.AuthenticationFailedReason, clusterv1.ConditionSeverityError},
+	"InvalidAuthenticationTokenTenant": {infrav1.AuthenticationFailedReason, clusterv1.ConditionSeverityError},
+	"ExpiredAuthenticationToken":       {infrav1.AuthenticationFailedReason, clusterv1.ConditionSeverityError},
+
+	"RequestDisallowedByPolicy": {infrav1.RequestDisallowedByPolicyReason, clusterv1.ConditionSeverityError},
+
+	"MissingSubscriptionRegistration": {infrav1.ResourceProviderNotRegisteredReason, clusterv1.ConditionSeverityError},
+	"NoRegisteredProviderFound":       {infrav1.ResourceProviderNotRegisteredReason, clusterv1.ConditionSeverityError},
+
+	"SubscriptionNotFound":         {infrav1.SubscriptionUnavailableReason, clusterv1.ConditionSeverityError},
+	"ReadOnlyDisabledSubscription": {infrav1.SubscriptionUnavailableReason, clusterv1.ConditionSeverityError},
+
+	"Conflict":                            {infrav1.AzureResourceConflictReason, clusterv1.ConditionSeverityWarning},
+	"AnotherOperationInProgress":          {infrav1.AzureResourceConflictReason, clusterv1.ConditionSeverityWarning},
+	"RetryableErrorDueToAnotherOperation": {infrav1.AzureResourceConflictReason, clusterv1.ConditionSeverityWarning},
+	"ReferencedResourceNotProvisioned":    {infrav1.AzureResourceConflictReason, clusterv1.ConditionSeverityWarning},
+	"InUseSubnetCannotBeDeleted":          {infrav1.AzureResourceConflictReason, clusterv1.ConditionSeverityWarning},
+
+	"ResourceGroupNotFound":    {infrav1.AzureResourceNotFoundReason, clusterv1.ConditionSeverityError},
+	"ResourceNotFound":         {infrav1.AzureResourceNotFoundReason, clusterv1.ConditionSeverityError},
+	"ParentResourceNotFound":   {infrav1.AzureResourceNotFoundReason, clusterv1.ConditionSeverityError},
+	"InvalidResourceReference": {infrav1.AzureResourceNotFoundReason, clusterv1.ConditionSeverityError},
+
+	"InvalidParameter":         {infrav1.InvalidAzureRequestReason, clusterv1.ConditionSeverityError},
+	"InvalidRequestContent":    {infrav1.InvalidAzureRequestReason, clusterv1.ConditionSeverityError},
+	"InvalidRequestFormat":     {infrav1.InvalidAzureRequestReason, clusterv1.ConditionSeverityError},
+	"PropertyChangeNotAllowed": {infrav1.InvalidAzureRequestReason, clusterv1.ConditionSeverityError},
+	"InvalidResourceName":      {infrav1.InvalidAzureRequestReason, clusterv1.ConditionSeverityError},
+	"BadRequest":               {infrav1.InvalidAzureRequestReason, clusterv1.ConditionSeverityError},
+
+	"TooManyRequests":               {infrav1.AzureRequestsThrottledReason, clusterv1.ConditionSeverityWarning},
+	"SubscriptionRequestsThrottled": {infrav1.AzureRequestsThrottledReason, clusterv1.ConditionSeverityWarning},
+
+	"InternalServerError": {infrav1.AzureServiceErrorReason, clusterv1.ConditionSeverityWarning},
+	"RetryableError":      {infrav1.AzureServiceErrorReason, clusterv1.ConditionSeverityWarning},
+	"ServiceUnavailable":  {infrav1.AzureServiceErrorReason, clusterv1.ConditionSeverityWarning},
+}
+
+// ErrorReason returns the reason and severity of a condition reporting err, from the error code of Azure Resource
+// Manager it failed with or else its HTTP status. Errors which aren't failures of Azure Resource Manager, or whose
+// code isn't known, are reported with the given default reason and severity.
+func ErrorReason(err error, defaultReason string, defaultSeverity clusterv1.ConditionSeverity) (string, clusterv1.ConditionSeverity) {
+	statusCode, code, ok := errorCode(err)
+	if !ok {
+		return defaultReason, defaultSeverity
+	}
+	if r, ok := errorCodeReasons[code]; ok {
+		return r.reason, r.severity
+	}
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return infrav1.AuthenticationFailedReason, clusterv1.ConditionSeverityError
+	case statusCode == http.StatusForbidden:
+		return infrav1.AuthorizationFailedReason, clusterv1.ConditionSeverityError
+	case statusCode == http.StatusConflict || statusCode == http.StatusPreconditionFailed:
+		return infrav1.AzureResourceConflictReason, clusterv1.ConditionSeverityWarning
+	case statusCode == http.StatusTooManyRequests:
+		return infrav1.AzureRequestsThrottledReason, clusterv1.ConditionSeverityWarning
+	case statusCode >= 500:
+		return infrav1.AzureServiceErrorReason, clusterv1.ConditionSeverityWarning
+	default:
+		return defaultReason, defaultSeverity
+	}
+}
+
+// errorCode returns the HTTP status and the error code of Azure Resource Manager of a failure of a request, and
+// whether err is one at all. The code is found both in the errors of requests and of long running operations.
+func errorCode(err error) (int, string, bool) {
+	derr := autorest.DetailedError{}
+	if !errors.As(err, &derr) {
+		return 0, "", false
+	}
+	statusCode, _ := derr.StatusCode.(int)
+	rerr := &azure.RequestError{}
+	if errors.As(derr.Original, &rerr) && rerr.ServiceError != nil {
+		return statusCode, rerr.ServiceError.Code, true
+	}
+	serr := &azure.ServiceError{}
+	if errors.As(derr.Original, &serr) {
+		return statusCode, serr.Code, true
+	}
+	return statusCode, "", true
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestErrorReason(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		err              error
+		expectedReason   string
+		expectedSeverity clusterv1.ConditionSeverity
+	}{
+		{
+			err:              autorest.NewErrorWithError(&azure.RequestError{ServiceError: &azure.ServiceError{Code: "SkuNotAvailable"}}, "compute.VirtualMachinesClient", "CreateOrUpdate", &http.Response{StatusCode: http.StatusConflict}, "Failure sending request"),
+			expectedReason:   infrav1.SKUNotAvailableReason,
+			expectedSeverity: clusterv1.ConditionSeverityError,
+		},
+		{
+			err:              fmt.Errorf("failed to create vm: %w", autorest.DetailedError{StatusCode: http.StatusBadRequest, Original: &azure.ServiceError{Code: "QuotaExceeded"}}),
+			expectedReason:   infrav1.QuotaExceededReason,
+			expectedSeverity: clusterv1.ConditionSeverityError,
+		},
+		{
+			err:              autorest.DetailedError{StatusCode: http.StatusForbidden, Original: &azure.ServiceError{Code: "AuthorizationFailed"}},
+			expectedReason:   infrav1.AuthorizationFailedReason,
+			expectedSeverity: clusterv1.ConditionSeverityError,
+		},
+		{
+			err:              autorest.DetailedError{StatusCode: http.StatusConflict},
+			expectedReason:   infrav1.AzureResourceConflictReason,
+			expectedSeverity: clusterv1.ConditionSeverityWarning,
+		},
+		{
+			err:              autorest.DetailedError{StatusCode: http.StatusServiceUnavailable},
+			expectedReason:   infrav1.AzureServiceErrorReason,
+			expectedSeverity: clusterv1.ConditionSeverityWarning,
+		},
+		{
+			err:              autorest.DetailedError{StatusCode: http.StatusBadRequest, Original: &azure.ServiceError{Code: "SomeUnknownCode"}},
+			expectedReason:   infrav1.VMProvisionFailedReason,
+			expectedSeverity: clusterv1.ConditionSeverityError,
+		},
+		{
+			err:              errors.New("some error happened"),
+			expectedReason:   infrav1.VMProvisionFailedReason,
+			expectedSeverity: clusterv1.ConditionSeverityError,
+		},
+	}
+	for _, tc := range tests {
+		reason, severity := ErrorReason(tc.err, infrav1.VMProvisionFailedReason, clusterv1.ConditionSeverityError)
+		g.Expect(reason).To(Equal(tc.expectedReason), tc.err.Error())
+		g.Expect(severity).To(Equal(tc.expectedSeverity), tc.err.Error())
+	}
+}
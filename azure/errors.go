@@ -51,6 +51,21 @@ func ResourceConflict(err error) bool {
 	return errors.As(err, &derr) && derr.StatusCode == 409
 }
 
+// OperationInProgress parses the error to check if Azure rejected a request because another operation on the
+// resource is still in progress.
+func OperationInProgress(err error) bool {
+	var code string
+	rerr := &azure.RequestError{}
+	serr := &azure.ServiceError{}
+	switch {
+	case errors.As(err, &rerr) && rerr.ServiceError != nil:
+		code = rerr.ServiceError.Code
+	case errors.As(err, &serr):
+		code = serr.Code
+	}
+	return code == "OperationNotAllowed" || code == "AnotherOperationInProgress"
+}
+
 // VMDeletedError is returned when a virtual machine is deleted outside of capz.
 type VMDeletedError struct {
 	ProviderID string
@@ -112,6 +127,11 @@ func (t ReconcileError) Is(target error) bool {
 	return errors.As(target, &ReconcileError{})
 }
 
+// Unwrap returns the error wrapped in the ReconcileError, e.g. the error of the request which failed.
+func (t ReconcileError) Unwrap() error {
+	return t.error
+}
+
 // RequeueAfter returns requestAfter value.
 func (t ReconcileError) RequeueAfter() time.Duration {
 	return t.requestAfter
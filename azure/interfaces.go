@@ -58,6 +58,11 @@ type Authorizer interface {
 	HashKey() string
 }
 
+// KeyVaultSecretGetter is an interface which can read the value of a secret in Azure Key Vault.
+type KeyVaultSecretGetter interface {
+	GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error)
+}
+
 // NetworkDescriber is an interface which can get common Azure Cluster Networking information.
 type NetworkDescriber interface {
 	Vnet() *infrav1.VnetSpec
@@ -85,10 +90,14 @@ type ClusterDescriber interface {
 	AdditionalTags() infrav1.Tags
 	AvailabilitySetEnabled() bool
 	CloudProviderConfigOverrides() *infrav1.CloudProviderConfigOverrides
+	CloudProviderIdentityID() string
+	AzureResourceNaming() *infrav1.AzureResourceNaming
+	FailureDomainZone(string) string
 }
 
-// ClusterScoper combines the ClusterDescriber and NetworkDescriber interfaces.
+// ClusterScoper combines the ClusterDescriber, NetworkDescriber and KeyVaultSecretGetter interfaces.
 type ClusterScoper interface {
 	ClusterDescriber
 	NetworkDescriber
+	KeyVaultSecretGetter
 }
@@ -332,6 +332,44 @@ func (mr *MockAuthorizerMockRecorder) TenantID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantID", reflect.TypeOf((*MockAuthorizer)(nil).TenantID))
 }
 
+// MockKeyVaultSecretGetter is a mock of KeyVaultSecretGetter interface.
+type MockKeyVaultSecretGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyVaultSecretGetterMockRecorder
+}
+
+// MockKeyVaultSecretGetterMockRecorder is the mock recorder for MockKeyVaultSecretGetter.
+type MockKeyVaultSecretGetterMockRecorder struct {
+	mock *MockKeyVaultSecretGetter
+}
+
+// NewMockKeyVaultSecretGetter creates a new mock instance.
+func NewMockKeyVaultSecretGetter(ctrl *gomock.Controller) *MockKeyVaultSecretGetter {
+	mock := &MockKeyVaultSecretGetter{ctrl: ctrl}
+	mock.recorder = &MockKeyVaultSecretGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyVaultSecretGetter) EXPECT() *MockKeyVaultSecretGetterMockRecorder {
+	return m.recorder
+}
+
+// GetKeyVaultSecret mocks base method.
+func (m *MockKeyVaultSecretGetter) GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyVaultSecret", ctx, secretURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyVaultSecret indicates an expected call of GetKeyVaultSecret.
+func (mr *MockKeyVaultSecretGetterMockRecorder) GetKeyVaultSecret(ctx, secretURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyVaultSecret", reflect.TypeOf((*MockKeyVaultSecretGetter)(nil).GetKeyVaultSecret), ctx, secretURL)
+}
+
 // MockNetworkDescriber is a mock of NetworkDescriber interface.
 type MockNetworkDescriber struct {
 	ctrl     *gomock.Controller
@@ -614,6 +652,20 @@ func (mr *MockClusterDescriberMockRecorder) AvailabilitySetEnabled() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockClusterDescriber)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockClusterDescriber) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockClusterDescriberMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockClusterDescriber)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockClusterDescriber) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -684,6 +736,20 @@ func (mr *MockClusterDescriberMockRecorder) CloudProviderConfigOverrides() *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockClusterDescriber)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockClusterDescriber) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockClusterDescriberMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockClusterDescriber)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockClusterDescriber) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -698,6 +764,20 @@ func (mr *MockClusterDescriberMockRecorder) ClusterName() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClusterName", reflect.TypeOf((*MockClusterDescriber)(nil).ClusterName))
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockClusterDescriber) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockClusterDescriberMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockClusterDescriber)(nil).FailureDomainZone), arg0)
+}
+
 // HashKey mocks base method.
 func (m *MockClusterDescriber) HashKey() string {
 	m.ctrl.T.Helper()
@@ -861,6 +941,20 @@ func (mr *MockClusterScoperMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockClusterScoper)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockClusterScoper) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockClusterScoperMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockClusterScoper)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockClusterScoper) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -931,6 +1025,20 @@ func (mr *MockClusterScoperMockRecorder) CloudProviderConfigOverrides() *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockClusterScoper)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockClusterScoper) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockClusterScoperMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockClusterScoper)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockClusterScoper) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -973,6 +1081,35 @@ func (mr *MockClusterScoperMockRecorder) ControlPlaneSubnet() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ControlPlaneSubnet", reflect.TypeOf((*MockClusterScoper)(nil).ControlPlaneSubnet))
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockClusterScoper) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockClusterScoperMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockClusterScoper)(nil).FailureDomainZone), arg0)
+}
+
+// GetKeyVaultSecret mocks base method.
+func (m *MockClusterScoper) GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyVaultSecret", ctx, secretURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyVaultSecret indicates an expected call of GetKeyVaultSecret.
+func (mr *MockClusterScoperMockRecorder) GetKeyVaultSecret(ctx, secretURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyVaultSecret", reflect.TypeOf((*MockClusterScoper)(nil).GetKeyVaultSecret), ctx, secretURL)
+}
+
 // GetPrivateDNSZoneName mocks base method.
 func (m *MockClusterScoper) GetPrivateDNSZoneName() string {
 	m.ctrl.T.Helper()
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// RateLimit is a rate of requests allowed, with bursts of up to Burst requests. A QPS of 0 allows any rate.
+type RateLimit struct {
+	QPS   float32
+	Burst int
+}
+
+// rateLimiters limits the requests of all clients of each principal to each subscription, so the reconciliation of
+// one large cluster can't use up the limits of Azure Resource Manager for the subscription on its own.
+type rateLimiters struct {
+	reads  RateLimit
+	writes RateLimit
+
+	lock     sync.Mutex
+	limiters map[throttleBucket]flowcontrol.RateLimiter
+}
+
+func newRateLimiters(reads, writes RateLimit) *rateLimiters {
+	return &rateLimiters{
+		reads:    reads,
+		writes:   writes,
+		limiters: make(map[throttleBucket]flowcontrol.RateLimiter),
+	}
+}
+
+// limiter returns the rate limiter of a throttle bucket, or nil if its requests aren't limited. Deletes count as
+// writes.
+func (l *rateLimiters) limiter(bucket throttleBucket) flowcontrol.RateLimiter {
+	limit := l.writes
+	if bucket.name == "reads" {
+		limit = l.reads
+	} else {
+		bucket.name = "writes"
+	}
+	if limit.QPS <= 0 {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	limiter, ok := l.limiters[bucket]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(limit.QPS, limit.Burst)
+		l.limiters[bucket] = limiter
+	}
+	return limiter
+}
+
+// wait blocks until a request of the throttle bucket is allowed.
+func (l *rateLimiters) wait(ctx context.Context, bucket throttleBucket) error {
+	limiter := l.limiter(bucket)
+	if limiter == nil {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return errors.Wrapf(err, "failed to wait for the rate limit of %s of subscription %s", bucket.name, bucket.subscription)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRateLimiters(t *testing.T) {
+	g := NewWithT(t)
+
+	limiters := newRateLimiters(RateLimit{QPS: 0.001, Burst: 2}, RateLimit{})
+	reads := throttleBucket{subscription: "123", name: "reads"}
+
+	// Reads of a subscription share their limiter, writes and other subscriptions aren't limited by it.
+	limiter := limiters.limiter(reads)
+	g.Expect(limiter).NotTo(BeNil())
+	g.Expect(limiters.limiter(reads)).To(BeIdenticalTo(limiter))
+	g.Expect(limiters.limiter(throttleBucket{subscription: "123", name: "writes"})).To(BeNil())
+	g.Expect(limiters.limiter(throttleBucket{subscription: "123", name: "deletes"})).To(BeNil())
+	g.Expect(limiters.limiter(throttleBucket{subscription: "456", name: "reads"})).NotTo(BeIdenticalTo(limiter))
+
+	g.Expect(limiters.wait(context.Background(), reads)).To(Succeed())
+	g.Expect(limiters.wait(context.Background(), reads)).To(Succeed())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g.Expect(limiters.wait(ctx, reads)).To(HaveOccurred())
+
+	// Deletes share the limiter of writes.
+	limiters = newRateLimiters(RateLimit{}, RateLimit{QPS: 1, Burst: 1})
+	g.Expect(limiters.limiter(throttleBucket{subscription: "123", name: "deletes"})).To(BeIdenticalTo(limiters.limiter(throttleBucket{subscription: "123", name: "writes"})))
+	g.Expect(limiters.limiter(reads)).To(BeNil())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// registrationTimeout is how long a request waits for the registration of its resource provider.
+	registrationTimeout = 2 * time.Minute
+
+	// registrationPollInterval is how often the state of a resource provider registration is checked.
+	registrationPollInterval = 5 * time.Second
+)
+
+// doRegisterResourceProviders returns a send decorator which registers the resource provider of a request when Azure
+// rejects it because the subscription isn't registered for the provider, then sends the request again. Unlike
+// DoRetryWithRegistration of autorest, it doesn't retry other failed requests, throttled ones in particular.
+func doRegisterResourceProviders(authorizer autorest.Authorizer) autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			rr := autorest.NewRetriableRequest(req)
+			if err := rr.Prepare(); err != nil {
+				return nil, err
+			}
+			resp, err := s.Do(rr.Request())
+			if err != nil {
+				return resp, err
+			}
+
+			namespace, ok := missingRegistration(resp)
+			if !ok {
+				return resp, nil
+			}
+			match := subscriptionPath.FindStringSubmatch(req.URL.Path)
+			if match == nil {
+				return resp, nil
+			}
+			if err := registerResourceProvider(req, s, authorizer, match[1], namespace); err != nil {
+				return resp, errors.Wrapf(err, "failed to register resource provider %s", namespace)
+			}
+
+			if err := rr.Prepare(); err != nil {
+				return nil, err
+			}
+			return s.Do(rr.Request())
+		})
+	}
+}
+
+// missingRegistration returns the namespace of the resource provider of a request Azure rejected because the
+// subscription isn't registered for it. The body of the response can still be read afterwards.
+func missingRegistration(resp *http.Response) (string, bool) {
+	if resp.StatusCode != http.StatusConflict || resp.Body == nil {
+		return "", false
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", false
+	}
+
+	var re azure.RequestError
+	if err := json.Unmarshal(body, &re); err != nil || re.ServiceError == nil {
+		return "", false
+	}
+	if re.ServiceError.Code != "MissingSubscriptionRegistration" || len(re.ServiceError.Details) == 0 {
+		return "", false
+	}
+	namespace, ok := re.ServiceError.Details[0]["target"].(string)
+	return namespace, ok && namespace != ""
+}
+
+// registerResourceProvider registers a resource provider for the subscription of a request, and waits until the
+// registration completed. The requests are sent through the sender of the request.
+func registerResourceProvider(req *http.Request, sender autorest.Sender, authorizer autorest.Authorizer, subscription, namespace string) error {
+	providers := resources.NewProvidersClientWithBaseURI(req.URL.Scheme+"://"+req.URL.Host, subscription)
+	providers.Authorizer = authorizer
+	providers.Sender = sender
+	providers.SendDecorators = []autorest.SendDecorator{}
+	AutoRestClientAppendUserAgent(&providers.Client, UserAgent())
+
+	ctx, cancel := context.WithTimeout(req.Context(), registrationTimeout)
+	defer cancel()
+
+	if _, err := providers.Register(ctx, namespace); err != nil {
+		return err
+	}
+	for {
+		provider, err := providers.Get(ctx, namespace, "")
+		if err != nil {
+			return err
+		}
+		if provider.RegistrationState != nil && *provider.RegistrationState == "Registered" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "registration didn't complete in time")
+		case <-time.After(registrationPollInterval):
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+)
+
+func TestDoRegisterResourceProviders(t *testing.T) {
+	g := NewWithT(t)
+
+	registered := false
+	var sent []string
+	sender := autorest.DecorateSender(autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Method+" "+req.URL.Path)
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("{}")), Request: req}
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/register"):
+			registered = true
+			resp.Body = ioutil.NopCloser(strings.NewReader(`{"registrationState":"Registering"}`))
+		case strings.HasSuffix(req.URL.Path, "/providers/Microsoft.ContainerService"):
+			resp.Body = ioutil.NopCloser(strings.NewReader(`{"registrationState":"Registered"}`))
+		case strings.HasSuffix(req.URL.Path, "/throttled"):
+			resp.StatusCode = http.StatusTooManyRequests
+		case !registered:
+			resp.StatusCode = http.StatusConflict
+			resp.Body = ioutil.NopCloser(strings.NewReader(`{"error":{"code":"MissingSubscriptionRegistration","message":"The subscription is not registered.","details":[{"code":"MissingSubscriptionRegistration","target":"Microsoft.ContainerService"}]}}`))
+		}
+		return resp, nil
+	}), doRegisterResourceProviders(autorest.NullAuthorizer{}))
+	do := func(method, path string) *http.Response {
+		req, _ := http.NewRequest(method, "https://management.azure.com"+path, nil)
+		resp, err := sender.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	// A request rejected because of a missing registration is sent again once the resource provider is registered.
+	g.Expect(do(http.MethodPut, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster").StatusCode).To(Equal(http.StatusOK))
+	// Other failed requests are not retried.
+	g.Expect(do(http.MethodGet, "/subscriptions/123/throttled").StatusCode).To(Equal(http.StatusTooManyRequests))
+
+	g.Expect(sent).To(Equal([]string{
+		"PUT /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster",
+		"POST /subscriptions/123/providers/Microsoft.ContainerService/register",
+		"GET /subscriptions/123/providers/Microsoft.ContainerService",
+		"PUT /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster",
+		"GET /subscriptions/123/throttled",
+	}))
+}
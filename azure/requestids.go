@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// requestIDHeader is the header of the ID Azure Resource Manager assigns to a request.
+const requestIDHeader = "x-ms-request-id"
+
+// RequestIDs are the IDs of a request to Azure Resource Manager which Azure support asks for.
+type RequestIDs struct {
+	// CorrelationID is the ID Azure Resource Manager correlates the operations of a request with.
+	CorrelationID string
+	// RequestID is the ID Azure Resource Manager assigned to the request.
+	RequestID string
+}
+
+// String returns the IDs as they are appended to errors and events.
+func (ids RequestIDs) String() string {
+	return fmt.Sprintf("correlation ID %q, request ID %q", ids.CorrelationID, ids.RequestID)
+}
+
+// responseRequestIDs returns the IDs of the request of a response, if it has any. The correlation ID is the one the
+// client set on the request if Azure didn't return it.
+func responseRequestIDs(req *http.Request, resp *http.Response) (RequestIDs, bool) {
+	if resp == nil {
+		return RequestIDs{}, false
+	}
+	ids := RequestIDs{
+		CorrelationID: resp.Header.Get(correlationIDHeader),
+		RequestID:     resp.Header.Get(requestIDHeader),
+	}
+	if ids.CorrelationID == "" && req != nil {
+		ids.CorrelationID = req.Header.Get(correlationIDHeader)
+	}
+	return ids, ids != RequestIDs{}
+}
+
+// RequestIDsError is an error returned by Azure Resource Manager, with the IDs of the request which failed.
+type RequestIDsError struct {
+	error
+	IDs RequestIDs
+	// ActivityLogURL is the link to the entries of the Activity Log of the request in the Azure portal, if it
+	// changes a resource.
+	ActivityLogURL string
+}
+
+// Error returns the error with the IDs of the request, and the link to its Activity Log if it has one.
+func (e RequestIDsError) Error() string {
+	if e.ActivityLogURL != "" {
+		return fmt.Sprintf("%s (%s). Activity Log: %s", e.error.Error(), e.IDs, e.ActivityLogURL)
+	}
+	return fmt.Sprintf("%s (%s)", e.error.Error(), e.IDs)
+}
+
+// Unwrap returns the error returned by Azure Resource Manager.
+func (e RequestIDsError) Unwrap() error {
+	return e.error
+}
+
+// WithRequestIDs appends the IDs of the failed request to an error returned by Azure Resource Manager, so they show
+// in the conditions, events and logs reporting it, along with the link to its Activity Log if it changes a resource.
+// Other errors, and errors which already have them, are returned as they are.
+func WithRequestIDs(err error) error {
+	if err == nil || errors.As(err, &RequestIDsError{}) {
+		return err
+	}
+	derr := autorest.DetailedError{}
+	if !errors.As(err, &derr) || derr.Response == nil {
+		return err
+	}
+	ids, ok := responseRequestIDs(derr.Response.Request, derr.Response)
+	if !ok {
+		return err
+	}
+	activityLog, _ := activityLogURL(derr.Response.Request, derr.Response)
+	return RequestIDsError{error: err, IDs: ids, ActivityLogURL: activityLog}
+}
+
+// ErrorRequestIDs returns the IDs of the failed request of an error returned by Azure Resource Manager, if any.
+func ErrorRequestIDs(err error) (RequestIDs, bool) {
+	idsErr := RequestIDsError{}
+	if errors.As(err, &idsErr) {
+		return idsErr.IDs, true
+	}
+	derr := autorest.DetailedError{}
+	if !errors.As(err, &derr) || derr.Response == nil {
+		return RequestIDs{}, false
+	}
+	return responseRequestIDs(derr.Response.Request, derr.Response)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func TestWithRequestIDs(t *testing.T) {
+	g := NewWithT(t)
+
+	req, _ := http.NewRequest(http.MethodPut, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg", nil)
+	req.Header.Set(correlationIDHeader, "abc")
+	resp := &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}, Request: req}
+	resp.Header.Set(requestIDHeader, "def")
+	resp.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	derr := autorest.NewErrorWithError(errors.New("invalid request"), "resources.GroupsClient", "CreateOrUpdate", resp, "Failure responding to request")
+
+	err := WithRequestIDs(errors.Wrap(derr, "failed to create resource group"))
+	g.Expect(err.Error()).To(HaveSuffix(`invalid request (correlation ID "abc", request ID "def"). Activity Log: ` + testActivityLogURL))
+	g.Expect(errors.As(err, &autorest.DetailedError{})).To(BeTrue())
+	ids, ok := ErrorRequestIDs(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(ids).To(Equal(RequestIDs{CorrelationID: "abc", RequestID: "def"}))
+
+	// The IDs are only appended once.
+	g.Expect(WithRequestIDs(errors.Wrap(err, "failed to reconcile")).Error()).To(Equal("failed to reconcile: " + err.Error()))
+
+	// Errors without a response to a request to Azure are left as they are.
+	g.Expect(WithRequestIDs(nil)).To(BeNil())
+	other := errors.New("not from Azure")
+	g.Expect(WithRequestIDs(other)).To(Equal(other))
+	unanswered := autorest.NewErrorWithError(errors.New("connection reset"), "resources.GroupsClient", "CreateOrUpdate", nil, "Failure sending request")
+	g.Expect(WithRequestIDs(unanswered)).To(Equal(unanswered))
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/go-logr/logr"
+)
+
+const (
+	// requestLogVerbosity is the verbosity the bodies of requests and responses are logged at.
+	requestLogVerbosity = 6
+	// maxLoggedBodySize is how much of a body is logged at most.
+	maxLoggedBodySize = 64 * 1024
+	// redacted replaces the secrets of logged bodies.
+	redacted = "REDACTED"
+)
+
+// secretProperties are the parts of the names of the properties of Azure resources holding secrets, lower-cased,
+// e.g. customData or adminPassword.
+var secretProperties = []string{
+	"accesskey",
+	"connectionstring",
+	"customdata",
+	"password",
+	"primarykey",
+	"privatekey",
+	"protectedsettings",
+	"sastoken",
+	"secondarykey",
+	"secret",
+	"userdata",
+}
+
+// sasSignature matches the signature of SAS tokens in URLs, e.g. of blobs in storage accounts.
+var sasSignature = regexp.MustCompile(`(?i)([?&]sig=)[^&"\s]+`)
+
+// withRequestLogging logs the bodies of the requests changing Azure resources and of their responses to logger, if
+// set, each time they are sent.
+func withRequestLogging(logger logr.Logger) autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		if logger == nil {
+			return s
+		}
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := s.Do(req)
+			logRequest(logger, req, resp, err)
+			return resp, err
+		})
+	}
+}
+
+// logRequest logs the body of a request changing Azure resources and of its response, with their secrets redacted.
+// Requests reading resources aren't logged, as they have no body.
+func logRequest(logger logr.Logger, req *http.Request, resp *http.Response, err error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return
+	}
+	logger = logger.V(requestLogVerbosity)
+	if !logger.Enabled() {
+		return
+	}
+	keysAndValues := []interface{}{
+		"method", req.Method,
+		"url", redactString(req.URL.String()),
+		"requestBody", redactBody(readRequestBody(req)),
+	}
+	if resp != nil {
+		keysAndValues = append(keysAndValues,
+			"statusCode", resp.StatusCode,
+			"correlationID", resp.Header.Get(correlationIDHeader),
+			"responseBody", redactBody(readLoggedResponseBody(resp)))
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	}
+	logger.Info("Azure Resource Manager request", keysAndValues...)
+}
+
+// readRequestBody returns the body of a request, leaving the body of the request as it was.
+func readRequestBody(req *http.Request) []byte {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		defer body.Close()
+		data, _ := ioutil.ReadAll(body)
+		return data
+	}
+	if req.Body == nil {
+		return nil
+	}
+	data, _ := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	req.Body = ioutil.NopCloser(strings.NewReader(string(data)))
+	return data
+}
+
+// readLoggedResponseBody returns the body of a response, leaving the body of the response as it was.
+func readLoggedResponseBody(resp *http.Response) []byte {
+	if resp.Body == nil {
+		return nil
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(strings.NewReader(string(data)))
+	return data
+}
+
+// redactBody returns a body to log, with the values of the properties holding secrets and the signatures of SAS
+// tokens redacted. Bodies which aren't JSON only have their SAS signatures redacted.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	logged := string(body)
+	var object interface{}
+	if err := json.Unmarshal(body, &object); err == nil {
+		// The & of URLs isn't escaped, so the signatures of their SAS tokens are still matched.
+		var redactedBody strings.Builder
+		encoder := json.NewEncoder(&redactedBody)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(redactJSON(object)); err == nil {
+			logged = strings.TrimSuffix(redactedBody.String(), "\n")
+		}
+	}
+	logged = redactString(logged)
+	if len(logged) > maxLoggedBodySize {
+		logged = logged[:maxLoggedBodySize] + "...(truncated)"
+	}
+	return logged
+}
+
+// redactJSON redacts the values of the properties holding secrets in a decoded JSON value.
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, property := range v {
+			if secretProperty(key) && property != nil {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactJSON(property)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	case string:
+		return redactString(v)
+	}
+	return value
+}
+
+// secretProperty returns whether a property holds a secret, from its name.
+func secretProperty(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range secretProperties {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString redacts the signatures of the SAS tokens in a string.
+func redactString(s string) string {
+	return sasSignature.ReplaceAllString(s, "${1}"+redacted)
+}
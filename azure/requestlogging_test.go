@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+)
+
+// recordingLogger records the keys and values of the messages it logs at up to verbosity 6.
+type recordingLogger struct {
+	logr.Logger
+	verbosity int
+	logged    *[]map[string]interface{}
+}
+
+func (l *recordingLogger) Enabled() bool { return l.verbosity <= 6 }
+
+func (l *recordingLogger) V(level int) logr.Logger {
+	return &recordingLogger{verbosity: l.verbosity + level, logged: l.logged}
+}
+
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	values := map[string]interface{}{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		values[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	*l.logged = append(*l.logged, values)
+}
+
+func TestRedactBody(t *testing.T) {
+	g := NewWithT(t)
+
+	body := `{"properties":{"osProfile":{"adminUsername":"capi","adminPassword":"hunter2","customData":"I2Nsb3VkLWNvbmZpZw=="},` +
+		`"extensions":[{"protectedSettings":{"commandToExecute":"echo"}}],` +
+		`"diagnosticsProfile":{"storageUri":"https://sa.blob.core.windows.net/c?sv=2020&sig=abc%2Bdef&se=2021"}}}`
+	g.Expect(redactBody([]byte(body))).To(Equal(`{"properties":{"diagnosticsProfile":{"storageUri":"https://sa.blob.core.windows.net/c?sv=2020&sig=REDACTED&se=2021"},` +
+		`"extensions":[{"protectedSettings":"REDACTED"}],` +
+		`"osProfile":{"adminPassword":"REDACTED","adminUsername":"capi","customData":"REDACTED"}}}`))
+
+	g.Expect(redactBody([]byte("not json ?sig=abc"))).To(Equal("not json ?sig=REDACTED"))
+	g.Expect(redactBody(nil)).To(BeEmpty())
+	g.Expect(redactBody([]byte(`"` + strings.Repeat("a", maxLoggedBodySize) + `"`))).To(HaveSuffix("...(truncated)"))
+}
+
+func TestLogRequest(t *testing.T) {
+	g := NewWithT(t)
+
+	var logged []map[string]interface{}
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			// The body of the request is still sent.
+			if req.Body != nil {
+				body, _ := ioutil.ReadAll(req.Body)
+				g.Expect(string(body)).To(ContainSubstring("hunter2"))
+			}
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header:     http.Header{http.CanonicalHeaderKey(correlationIDHeader): []string{"abc"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":"InvalidParameter","message":"adminPassword is too simple"}}`)),
+			}, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{
+		logger: &recordingLogger{logged: &logged},
+	})...)
+
+	vm := "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm"
+	req, _ := http.NewRequest(http.MethodPut, vm, strings.NewReader(`{"properties":{"osProfile":{"adminPassword":"hunter2"}}}`))
+	resp, err := sender.Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+	body, _ := ioutil.ReadAll(resp.Body)
+	g.Expect(string(body)).To(ContainSubstring("InvalidParameter"))
+
+	// Reads aren't logged.
+	req, _ = http.NewRequest(http.MethodGet, vm, nil)
+	_, _ = sender.Do(req)
+
+	g.Expect(logged).To(Equal([]map[string]interface{}{{
+		"method":        http.MethodPut,
+		"url":           vm,
+		"requestBody":   `{"properties":{"osProfile":{"adminPassword":"REDACTED"}}}`,
+		"statusCode":    http.StatusBadRequest,
+		"correlationID": "abc",
+		"responseBody":  `{"error":{"code":"InvalidParameter","message":"adminPassword is too simple"}}`,
+	}}))
+}
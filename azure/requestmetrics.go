@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capz_azure_requests_total",
+		Help: "Number of requests sent to Azure, by service, subscription, operation and HTTP status code.",
+	}, []string{"service", "subscription", "operation", "code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capz_azure_request_duration_seconds",
+		Help:    "Latency of the requests sent to Azure, by service, subscription and operation.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"service", "subscription", "operation"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(requests, requestDuration)
+}
+
+// requestErrorCode is the code label of the requests which weren't answered.
+const requestErrorCode = "error"
+
+// instrumentedDo sends a request through sender, and records its status and latency. All clients send their
+// requests to Azure through the throttling sender of the ARM client options, so this covers every call to Azure.
+func instrumentedDo(sender autorest.Sender, req *http.Request) (*http.Response, error) {
+	service, subscription, operation := requestService(req), requestSubscription(req), requestOperation(req)
+	start := time.Now()
+	resp, err := sender.Do(req)
+	requestDuration.WithLabelValues(service, subscription, operation).Observe(time.Since(start).Seconds())
+	code := requestErrorCode
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	requests.WithLabelValues(service, subscription, operation, code).Inc()
+	return resp, err
+}
+
+// requestService returns the resource type a request is made to, e.g. Microsoft.Network/virtualNetworks/subnets, or
+// the host of requests to data planes like Key Vault.
+func requestService(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	provider := -1
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "providers") && i+1 < len(segments) {
+			provider = i + 1
+		}
+	}
+	if provider < 0 {
+		switch {
+		case len(segments) >= 3 && strings.EqualFold(segments[0], "subscriptions") && strings.EqualFold(segments[2], "resourceGroups"):
+			return "Microsoft.Resources/resourceGroups"
+		case len(segments) >= 1 && strings.EqualFold(segments[0], "subscriptions"):
+			return "Microsoft.Resources/subscriptions"
+		default:
+			return req.URL.Hostname()
+		}
+	}
+	// The namespace is followed by the types of nested resources, each followed by the name of a resource.
+	service := segments[provider]
+	for i := provider + 1; i < len(segments); i += 2 {
+		service += "/" + segments[i]
+	}
+	return service
+}
+
+// requestSubscription returns the subscription of a request, or an empty string if it isn't made to one.
+func requestSubscription(req *http.Request) string {
+	match := subscriptionPath.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// requestOperation returns the type of operation of a request: read, write, delete or action.
+func requestOperation(req *http.Request) string {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return "read"
+	case http.MethodDelete:
+		return "delete"
+	case http.MethodPost:
+		return "action"
+	default:
+		return "write"
+	}
+}
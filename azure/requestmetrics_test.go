@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRequestLabels(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		method       string
+		url          string
+		service      string
+		subscription string
+		operation    string
+	}{
+		{
+			method:       http.MethodGet,
+			url:          "https://management.azure.com/subscriptions/ABC/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet?api-version=2020-06-01",
+			service:      "Microsoft.Network/virtualNetworks/subnets",
+			subscription: "abc",
+			operation:    "read",
+		},
+		{
+			method:       http.MethodGet,
+			url:          "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines",
+			service:      "Microsoft.Compute/virtualMachines",
+			subscription: "123",
+			operation:    "read",
+		},
+		{
+			method:       http.MethodPut,
+			url:          "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Authorization/roleAssignments/my-role",
+			service:      "Microsoft.Authorization/roleAssignments",
+			subscription: "123",
+			operation:    "write",
+		},
+		{
+			method:       http.MethodPost,
+			url:          "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/restart",
+			service:      "Microsoft.Compute/virtualMachines/restart",
+			subscription: "123",
+			operation:    "action",
+		},
+		{
+			method:       http.MethodDelete,
+			url:          "https://management.azure.com/subscriptions/123/resourceGroups/my-rg",
+			service:      "Microsoft.Resources/resourceGroups",
+			subscription: "123",
+			operation:    "delete",
+		},
+		{
+			method:       http.MethodGet,
+			url:          "https://management.azure.com/subscriptions/123",
+			service:      "Microsoft.Resources/subscriptions",
+			subscription: "123",
+			operation:    "read",
+		},
+		{
+			method:    http.MethodGet,
+			url:       "https://my-vault.vault.azure.net/secrets/my-secret",
+			service:   "my-vault.vault.azure.net",
+			operation: "read",
+		},
+	}
+	for _, tc := range tests {
+		req, _ := http.NewRequest(tc.method, tc.url, nil)
+		g.Expect(requestService(req)).To(Equal(tc.service), tc.url)
+		g.Expect(requestSubscription(req)).To(Equal(tc.subscription), tc.url)
+		g.Expect(requestOperation(req)).To(Equal(tc.operation), tc.url)
+	}
+}
+
+func TestInstrumentedDo(t *testing.T) {
+	g := NewWithT(t)
+
+	sender := autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "unanswered") {
+			return nil, errors.New("connection reset")
+		}
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+	service := "Microsoft.Network/metricsTest"
+	throttled := requests.WithLabelValues(service, "123", "read", "429")
+	unanswered := requests.WithLabelValues(service, "123", "read", requestErrorCode)
+	before, beforeUnanswered := testutil.ToFloat64(throttled), testutil.ToFloat64(unanswered)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/123/providers/Microsoft.Network/metricsTest/throttled", nil)
+	resp, err := instrumentedDo(sender, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+	req, _ = http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/123/providers/Microsoft.Network/metricsTest/unanswered", nil)
+	_, err = instrumentedDo(sender, req)
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(testutil.ToFloat64(throttled)).To(Equal(before + 1))
+	g.Expect(testutil.ToFloat64(unanswered)).To(Equal(beforeUnanswered + 1))
+	g.Expect(testutil.CollectAndCount(requestDuration, "capz_azure_request_duration_seconds")).To(BeNumerically(">=", 1))
+}
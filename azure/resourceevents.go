@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Azure/go-autorest/autorest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ResourceCreatedReason is the reason of the events of Azure resources created for an object.
+	ResourceCreatedReason = "AzureResourceCreated"
+	// ResourceUpdatedReason is the reason of the events of Azure resources updated for an object.
+	ResourceUpdatedReason = "AzureResourceUpdated"
+	// ResourceDeletedReason is the reason of the events of Azure resources deleted for an object.
+	ResourceDeletedReason = "AzureResourceDeleted"
+	// RequestFailedReason is the reason of the events of requests to Azure Resource Manager which failed for an
+	// object.
+	RequestFailedReason = "AzureRequestFailed"
+)
+
+// correlationIDHeader is the header of the ID Azure Resource Manager correlates the operations of a request with,
+// which Azure support asks for.
+const correlationIDHeader = "x-ms-correlation-request-id"
+
+// ResourceEvents records events on an object for the changes the requests made while reconciling it made to Azure
+// resources, and for the errors Azure Resource Manager returned.
+type ResourceEvents struct {
+	recorder record.EventRecorder
+	object   runtime.Object
+}
+
+// NewResourceEvents returns resource events recorded by recorder on object.
+func NewResourceEvents(recorder record.EventRecorder, object runtime.Object) *ResourceEvents {
+	return &ResourceEvents{recorder: recorder, object: object}
+}
+
+type resourceEventsKey struct{}
+
+// WithResourceEvents returns a context in which the responses to the requests of all clients are recorded as events.
+func WithResourceEvents(ctx context.Context, events *ResourceEvents) context.Context {
+	return context.WithValue(ctx, resourceEventsKey{}, events)
+}
+
+// resourceEventsFrom returns the resource events of a context, if any.
+func resourceEventsFrom(ctx context.Context) *ResourceEvents {
+	events, _ := ctx.Value(resourceEventsKey{}).(*ResourceEvents)
+	return events
+}
+
+// withResourceEvents records the responses to requests in the resource events of their context, if any, unless they
+// are sent in a dry run other than in read-only mode.
+func withResourceEvents() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := s.Do(req)
+			if events := resourceEventsFrom(req.Context()); events != nil {
+				if dryRun := DryRunFrom(req.Context()); dryRun == nil || dryRun.ReadOnly() {
+					events.record(req, resp)
+				}
+			}
+			return resp, err
+		})
+	}
+}
+
+// record records an event for the response to a request if it changed a resource or failed. Resources which aren't
+// found aren't failures, as services check whether resources exist before creating them.
+func (e *ResourceEvents) record(req *http.Request, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	name := resourceName(req.URL.Path)
+	ids, _ := responseRequestIDs(req, resp)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && (req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodDelete):
+		return
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		code, message := responseError(resp)
+		if activityLog, ok := activityLogURL(req, resp); ok {
+			e.recorder.Eventf(e.object, corev1.EventTypeWarning, RequestFailedReason, "Failed to %s %s: %s: %s (%s). Activity Log: %s",
+				requestVerb(req.Method), name, code, message, ids, activityLog)
+			return
+		}
+		e.recorder.Eventf(e.object, corev1.EventTypeWarning, RequestFailedReason, "Failed to %s %s: %s: %s (%s)",
+			requestVerb(req.Method), name, code, message, ids)
+	case req.Method == http.MethodPut && resp.StatusCode == http.StatusCreated:
+		e.recorder.Eventf(e.object, corev1.EventTypeNormal, ResourceCreatedReason, "Created %s (%s)", name, ids)
+	case req.Method == http.MethodPut || req.Method == http.MethodPatch:
+		e.recorder.Eventf(e.object, corev1.EventTypeNormal, ResourceUpdatedReason, "Updated %s (%s)", name, ids)
+	// Azure answers deletions of resources which don't exist with 204 No Content.
+	case req.Method == http.MethodDelete && resp.StatusCode != http.StatusNoContent:
+		e.recorder.Eventf(e.object, corev1.EventTypeNormal, ResourceDeletedReason, "Deleted %s (%s)", name, ids)
+	}
+}
+
+// requestVerb returns what a request does to a resource.
+func requestVerb(method string) string {
+	switch method {
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	case http.MethodPost:
+		return "call"
+	default:
+		return "get"
+	}
+}
+
+// responseError returns the code and message of the error a response returns, or its HTTP status without one.
+func responseError(resp *http.Response) (string, string) {
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	readResponseBody(resp, &body)
+	if body.Error.Code == "" {
+		return strconv.Itoa(resp.StatusCode), http.StatusText(resp.StatusCode)
+	}
+	return body.Error.Code, body.Error.Message
+}
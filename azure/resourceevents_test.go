@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestResourceEvents(t *testing.T) {
+	const ip = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"
+
+	cases := map[string]struct {
+		method     string
+		statusCode int
+		body       string
+		event      string
+	}{
+		"created": {
+			method:     http.MethodPut,
+			statusCode: http.StatusCreated,
+			event:      `Normal AzureResourceCreated Created Microsoft.Network/publicIPAddresses/my-ip (correlation ID "abc", request ID "def")`,
+		},
+		"updated": {
+			method:     http.MethodPut,
+			statusCode: http.StatusOK,
+			event:      `Normal AzureResourceUpdated Updated Microsoft.Network/publicIPAddresses/my-ip (correlation ID "abc", request ID "def")`,
+		},
+		"deleted": {
+			method:     http.MethodDelete,
+			statusCode: http.StatusAccepted,
+			event:      `Normal AzureResourceDeleted Deleted Microsoft.Network/publicIPAddresses/my-ip (correlation ID "abc", request ID "def")`,
+		},
+		"already deleted": {
+			method:     http.MethodDelete,
+			statusCode: http.StatusNoContent,
+		},
+		"read": {
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+		},
+		"not found": {
+			method:     http.MethodGet,
+			statusCode: http.StatusNotFound,
+			body:       `{"error":{"code":"ResourceNotFound","message":"not found"}}`,
+		},
+		"failed": {
+			method:     http.MethodPut,
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"code":"PublicIPCountLimitReached","message":"Cannot create more than 10 public IP addresses"}}`,
+			event:      `Warning AzureRequestFailed Failed to update Microsoft.Network/publicIPAddresses/my-ip: PublicIPCountLimitReached: Cannot create more than 10 public IP addresses (correlation ID "abc", request ID "def"). Activity Log: ` + testActivityLogURL,
+		},
+		"failed without error": {
+			method:     http.MethodDelete,
+			statusCode: http.StatusConflict,
+			event:      `Warning AzureRequestFailed Failed to delete Microsoft.Network/publicIPAddresses/my-ip: 409: Conflict (correlation ID "abc", request ID "def"). Activity Log: ` + testActivityLogURL,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			sender := autorest.DecorateSender(&throttlingSender{
+				sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+					resp := &http.Response{
+						StatusCode: tc.statusCode,
+						Header:     http.Header{},
+						Body:       ioutil.NopCloser(strings.NewReader(tc.body)),
+						Request:    req,
+					}
+					resp.Header.Set(correlationIDHeader, "abc")
+					resp.Header.Set(requestIDHeader, "def")
+					resp.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+					return resp, nil
+				}),
+				buckets:  newThrottleBuckets(),
+				limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+			}, sendDecorators(senderOptions{})...)
+			recorder := record.NewFakeRecorder(10)
+			ctx := WithResourceEvents(context.Background(), NewResourceEvents(recorder, &infrav1.AzureCluster{}))
+
+			req, _ := http.NewRequestWithContext(ctx, tc.method, "https://management.azure.com"+ip, nil)
+			resp, err := sender.Do(req)
+			g.Expect(err).NotTo(HaveOccurred())
+			body, _ := ioutil.ReadAll(resp.Body)
+			g.Expect(string(body)).To(Equal(tc.body))
+
+			if tc.event == "" {
+				g.Expect(recorder.Events).To(BeEmpty())
+			} else {
+				g.Expect(recorder.Events).To(Receive(Equal(tc.event)))
+			}
+		})
+	}
+}
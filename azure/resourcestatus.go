@@ -0,0 +1,233 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+// ResourceStatuses records the last known status of the Azure resources an object manages, from the responses of
+// Azure Resource Manager to the requests made while reconciling it.
+type ResourceStatuses struct {
+	lock sync.Mutex
+	// resources are the statuses of the resources, by lower-cased path.
+	resources map[string]infrav1.ResourceStatus
+}
+
+// NewResourceStatuses returns statuses starting from the existing ones of an object.
+func NewResourceStatuses(existing []infrav1.ResourceStatus) *ResourceStatuses {
+	s := &ResourceStatuses{resources: make(map[string]infrav1.ResourceStatus, len(existing))}
+	for _, status := range existing {
+		s.resources[strings.ToLower(status.ID)] = status
+	}
+	return s
+}
+
+type resourceStatusesKey struct{}
+
+// WithResourceStatuses returns a context in which the responses to the requests of all clients update statuses.
+func WithResourceStatuses(ctx context.Context, statuses *ResourceStatuses) context.Context {
+	return context.WithValue(ctx, resourceStatusesKey{}, statuses)
+}
+
+// resourceStatusesFrom returns the resource statuses of a context, if any.
+func resourceStatusesFrom(ctx context.Context) *ResourceStatuses {
+	statuses, _ := ctx.Value(resourceStatusesKey{}).(*ResourceStatuses)
+	return statuses
+}
+
+// List returns the statuses of the resources, ordered by type and name.
+func (s *ResourceStatuses) List() []infrav1.ResourceStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.resources) == 0 {
+		return nil
+	}
+	list := make([]infrav1.ResourceStatus, 0, len(s.resources))
+	for _, status := range s.resources {
+		list = append(list, status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Type != list[j].Type {
+			return list[i].Type < list[j].Type
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// withResourceStatuses updates the resource statuses of the context of requests, if any, from their responses. In
+// read-only mode, the resources read are reported as they exist in Azure, and in other dry runs, nothing is reported.
+func withResourceStatuses() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := s.Do(req)
+			statuses := resourceStatusesFrom(req.Context())
+			if statuses == nil {
+				return resp, err
+			}
+			switch dryRun := DryRunFrom(req.Context()); {
+			case dryRun == nil:
+				statuses.record(req, resp)
+			case dryRun.ReadOnly():
+				statuses.observe(req, resp)
+			}
+			return resp, err
+		})
+	}
+}
+
+// record updates the status of the resource a request was made for from its response. Resources start being tracked
+// when they are created or updated, and stop being tracked once they are gone.
+func (s *ResourceStatuses) record(req *http.Request, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	key := strings.ToLower(req.URL.Path)
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	status, tracked := s.resources[key]
+	switch req.Method {
+	case http.MethodPut, http.MethodPatch:
+		if !tracked {
+			status = infrav1.ResourceStatus{
+				ID:   req.URL.Path,
+				Name: path.Base(req.URL.Path),
+				Type: resourceType(req.URL.Path),
+			}
+		}
+		if success {
+			status.ProvisioningState = responseProvisioningState(resp)
+			status.ErrorCode = ""
+		} else {
+			status.ErrorCode, _ = responseError(resp)
+		}
+		s.resources[key] = status
+
+	case http.MethodGet:
+		if !tracked {
+			return
+		}
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			delete(s.resources, key)
+		case success:
+			status.ProvisioningState = responseProvisioningState(resp)
+			s.resources[key] = status
+		}
+
+	case http.MethodDelete:
+		if !tracked {
+			return
+		}
+		switch {
+		case resp.StatusCode == http.StatusAccepted:
+			status.ProvisioningState = infrav1.Deleting
+			status.ErrorCode = ""
+			s.resources[key] = status
+		case success || resp.StatusCode == http.StatusNotFound:
+			delete(s.resources, key)
+		default:
+			status.ErrorCode, _ = responseError(resp)
+			s.resources[key] = status
+		}
+	}
+}
+
+// observe starts tracking the resource a request read, if the response returns it, before updating its status. In
+// read-only mode, the resources aren't created or updated by the controller, so they are tracked once read.
+func (s *ResourceStatuses) observe(req *http.Request, resp *http.Response) {
+	defer s.record(req, resp)
+	if resp == nil || req.Method != http.MethodGet || resp.StatusCode != http.StatusOK {
+		return
+	}
+	var body struct {
+		ID string `json:"id"`
+	}
+	readResponseBody(resp, &body)
+	// Collections and operations aren't resources.
+	if !strings.EqualFold(body.ID, req.URL.Path) {
+		return
+	}
+
+	key := strings.ToLower(req.URL.Path)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, tracked := s.resources[key]; !tracked {
+		s.resources[key] = infrav1.ResourceStatus{
+			ID:   req.URL.Path,
+			Name: path.Base(req.URL.Path),
+			Type: resourceType(req.URL.Path),
+		}
+	}
+}
+
+// resourceType returns the type of a resource from its path, e.g. "Microsoft.Network/virtualNetworks/subnets", or
+// "Microsoft.Resources/resourceGroups" for resource groups.
+func resourceType(resourcePath string) string {
+	// Extension resources, like role assignments, are under the last provider of their path.
+	i := strings.LastIndex(strings.ToLower(resourcePath), "/providers/")
+	if i < 0 {
+		return "Microsoft.Resources/resourceGroups"
+	}
+	// The provider is followed by pairs of types and names.
+	segments := strings.Split(resourcePath[i+len("/providers/"):], "/")
+	resourceType := []string{segments[0]}
+	for i := 1; i < len(segments); i += 2 {
+		resourceType = append(resourceType, segments[i])
+	}
+	return strings.Join(resourceType, "/")
+}
+
+// responseProvisioningState returns the provisioning state of the resource a response returns, if any.
+func responseProvisioningState(resp *http.Response) infrav1.ProvisioningState {
+	var body struct {
+		Properties struct {
+			ProvisioningState string `json:"provisioningState"`
+		} `json:"properties"`
+	}
+	readResponseBody(resp, &body)
+	return infrav1.ProvisioningState(body.Properties.ProvisioningState)
+}
+
+// readResponseBody decodes the JSON body of a response into object, leaving the body of the response as it was.
+// Bodies which can't be decoded are ignored.
+func readResponseBody(resp *http.Response, object interface{}) {
+	if resp.Body == nil {
+		return
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+	if err != nil || len(body) == 0 {
+		return
+	}
+	_ = json.Unmarshal(body, object)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestResourceStatuses(t *testing.T) {
+	g := NewWithT(t)
+
+	const (
+		group  = "/subscriptions/123/resourceGroups/my-rg"
+		vnet   = group + "/providers/Microsoft.Network/virtualNetworks/my-vnet"
+		subnet = vnet + "/subnets/my-subnet"
+		ip     = group + "/providers/Microsoft.Network/publicIPAddresses/my-ip"
+	)
+
+	// Azure answers each request with the next response of its path.
+	responses := map[string][]*http.Response{}
+	respond := func(method, path string, statusCode int, body string) {
+		responses[method+" "+path] = append(responses[method+" "+path], &http.Response{
+			StatusCode: statusCode,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		})
+	}
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			key := req.Method + " " + req.URL.Path
+			resp := responses[key][0]
+			responses[key] = responses[key][1:]
+			resp.Request = req
+			return resp, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{})...)
+
+	// The resource group was created by an earlier reconciliation.
+	statuses := NewResourceStatuses([]infrav1.ResourceStatus{
+		{ID: group, Name: "my-rg", Type: "Microsoft.Resources/resourceGroups", ProvisioningState: infrav1.Succeeded},
+	})
+	ctx := WithResourceStatuses(context.Background(), statuses)
+	do := func(method, path string) {
+		req, _ := http.NewRequestWithContext(ctx, method, "https://management.azure.com"+path+"?api-version=2021-02-01", nil)
+		resp, err := sender.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		// The body is still there for the clients.
+		body, _ := ioutil.ReadAll(resp.Body)
+		g.Expect(body).NotTo(BeEmpty())
+	}
+
+	respond(http.MethodGet, group, http.StatusOK, `{"properties":{"provisioningState":"Succeeded"}}`)
+	respond(http.MethodPut, vnet, http.StatusCreated, `{"properties":{"provisioningState":"Updating"}}`)
+	respond(http.MethodGet, vnet, http.StatusOK, `{"properties":{"provisioningState":"Succeeded"}}`)
+	respond(http.MethodPut, subnet, http.StatusBadRequest, `{"error":{"code":"NetcfgInvalidSubnet"}}`)
+	respond(http.MethodPut, ip, http.StatusOK, `{"properties":{"provisioningState":"Succeeded"}}`)
+	respond(http.MethodDelete, ip, http.StatusAccepted, `{}`)
+	// Resources which aren't managed aren't tracked when read.
+	respond(http.MethodGet, group+"/providers/Microsoft.Network/routeTables/my-rt", http.StatusOK, `{"properties":{"provisioningState":"Succeeded"}}`)
+
+	do(http.MethodGet, group)
+	do(http.MethodPut, vnet)
+	do(http.MethodGet, vnet)
+	do(http.MethodPut, subnet)
+	do(http.MethodPut, ip)
+	do(http.MethodDelete, ip)
+	do(http.MethodGet, group+"/providers/Microsoft.Network/routeTables/my-rt")
+
+	g.Expect(statuses.List()).To(Equal([]infrav1.ResourceStatus{
+		{ID: ip, Name: "my-ip", Type: "Microsoft.Network/publicIPAddresses", ProvisioningState: infrav1.Deleting},
+		{ID: vnet, Name: "my-vnet", Type: "Microsoft.Network/virtualNetworks", ProvisioningState: infrav1.Succeeded},
+		{ID: subnet, Name: "my-subnet", Type: "Microsoft.Network/virtualNetworks/subnets", ErrorCode: "NetcfgInvalidSubnet"},
+		{ID: group, Name: "my-rg", Type: "Microsoft.Resources/resourceGroups", ProvisioningState: infrav1.Succeeded},
+	}))
+
+	// Resources which are gone aren't tracked anymore.
+	respond(http.MethodGet, ip, http.StatusNotFound, `{"error":{"code":"ResourceNotFound"}}`)
+	respond(http.MethodDelete, subnet, http.StatusOK, `{}`)
+	respond(http.MethodDelete, vnet, http.StatusConflict, `{}`)
+	do(http.MethodGet, ip)
+	do(http.MethodDelete, subnet)
+	do(http.MethodDelete, vnet)
+
+	g.Expect(statuses.List()).To(Equal([]infrav1.ResourceStatus{
+		{ID: vnet, Name: "my-vnet", Type: "Microsoft.Network/virtualNetworks", ProvisioningState: infrav1.Succeeded, ErrorCode: "409"},
+		{ID: group, Name: "my-rg", Type: "Microsoft.Resources/resourceGroups", ProvisioningState: infrav1.Succeeded},
+	}))
+}
+
+func TestResourceStatusesReadOnly(t *testing.T) {
+	g := NewWithT(t)
+
+	const (
+		group = "/subscriptions/123/resourceGroups/my-rg"
+		vnet  = group + "/providers/Microsoft.Network/virtualNetworks/my-vnet"
+		ip    = group + "/providers/Microsoft.Network/publicIPAddresses/my-ip"
+	)
+	sent := map[string]bool{}
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			sent[req.Method+" "+req.URL.Path] = true
+			body := `{"value":[]}`
+			if req.URL.Path == vnet {
+				body = `{"id":"` + vnet + `","properties":{"provisioningState":"Succeeded"}}`
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(body)), Request: req}, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{})...)
+
+	statuses := NewResourceStatuses(nil)
+	ctx := WithDryRun(WithResourceStatuses(context.Background(), statuses), NewReadOnlyDryRun(klogr.New()))
+	do := func(method, path, body string) {
+		req, _ := http.NewRequestWithContext(ctx, method, "https://management.azure.com"+path+"?api-version=2021-02-01", strings.NewReader(body))
+		_, err := sender.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	// Resources read are tracked, unlike lists, and changes are refused.
+	do(http.MethodGet, vnet, "")
+	do(http.MethodGet, group+"/providers/Microsoft.Network/virtualNetworks", "")
+	do(http.MethodPut, ip, `{"location":"westeurope"}`)
+	g.Expect(sent).NotTo(HaveKey(http.MethodPut + " " + ip))
+	g.Expect(statuses.List()).To(Equal([]infrav1.ResourceStatus{
+		{ID: vnet, Name: "my-vnet", Type: "Microsoft.Network/virtualNetworks", ProvisioningState: infrav1.Succeeded},
+	}))
+}
+
+func TestResourceType(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(resourceType("/subscriptions/123/resourceGroups/my-rg")).To(Equal("Microsoft.Resources/resourceGroups"))
+	g.Expect(resourceType("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet/subnets/my-subnet")).
+		To(Equal("Microsoft.Network/virtualNetworks/subnets"))
+	g.Expect(resourceType("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Authorization/roleAssignments/my-role")).
+		To(Equal("Microsoft.Authorization/roleAssignments"))
+}
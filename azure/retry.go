@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// ErrorClass is the class of the failure of a request to Azure Resource Manager, which tells whether it is worth
+// retrying.
+type ErrorClass string
+
+const (
+	// TransientErrorClass is the class of failures which likely won't happen again, like internal errors of Azure or
+	// services being unavailable for a moment. The requests failing with them are retried.
+	TransientErrorClass ErrorClass = "transient"
+	// ConflictErrorClass is the class of failures caused by the state of a resource, like another operation in
+	// progress or a resource modified since it was read, which a later reconciliation may not run into.
+	ConflictErrorClass ErrorClass = "conflict"
+	// TerminalErrorClass is the class of failures which happen again until the request, the resource or the
+	// permissions change, like invalid requests or missing permissions.
+	TerminalErrorClass ErrorClass = "terminal"
+)
+
+const (
+	// DefaultMaxRetries is how many times a request failing with a transient error is retried by default.
+	DefaultMaxRetries = 3
+	// DefaultRetryBudget is how many retries the requests made while reconciling an object may make in total by
+	// default.
+	DefaultRetryBudget = 10
+
+	// retryBackoff is how long the first retry of a request waits, doubled for each further retry, with jitter.
+	retryBackoff = time.Second
+	// maxRetryBackoff is how long a retry waits at most.
+	maxRetryBackoff = 10 * time.Second
+)
+
+// errorCodeClasses are the classes of the error codes of Azure Resource Manager whose class isn't the one of their
+// HTTP status.
+var errorCodeClasses = map[string]ErrorClass{
+	"RetryableError":                      TransientErrorClass,
+	"InternalServerError":                 TransientErrorClass,
+	"AnotherOperationInProgress":          ConflictErrorClass,
+	"ReferencedResourceNotProvisioned":    ConflictErrorClass,
+	"RetryableErrorDueToAnotherOperation": ConflictErrorClass,
+}
+
+var (
+	requestFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capz_azure_request_failures_total",
+		Help: "Number of requests to Azure Resource Manager which failed, by error class.",
+	}, []string{"class"})
+	requestRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capz_azure_request_retries_total",
+		Help: "Number of retries of requests to Azure Resource Manager, by error class.",
+	}, []string{"class"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(requestFailures, requestRetries)
+}
+
+// ClassifyError returns the class of an error returned by a client, and whether it is a failure of a request to
+// Azure Resource Manager at all.
+func ClassifyError(err error) (ErrorClass, bool) {
+	derr := autorest.DetailedError{}
+	if errors.As(err, &derr) {
+		statusCode, ok := derr.StatusCode.(int)
+		if !ok || statusCode == 0 {
+			// The request wasn't answered.
+			return TransientErrorClass, true
+		}
+		code := ""
+		serr := &azure.ServiceError{}
+		if errors.As(derr.Original, &serr) {
+			code = serr.Code
+		}
+		return classifyFailure(statusCode, code), true
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return TransientErrorClass, true
+	}
+	return "", false
+}
+
+// classifyFailure returns the class of a failure from its HTTP status and the error code of Azure Resource Manager.
+func classifyFailure(statusCode int, code string) ErrorClass {
+	if class, ok := errorCodeClasses[code]; ok {
+		return class
+	}
+	switch {
+	case statusCode == http.StatusConflict || statusCode == http.StatusPreconditionFailed:
+		return ConflictErrorClass
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests || statusCode >= 500:
+		return TransientErrorClass
+	default:
+		return TerminalErrorClass
+	}
+}
+
+// failed returns whether a request failed. Resources which aren't found aren't failures, as services check whether
+// resources exist before creating them.
+func failed(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode == http.StatusNotFound && (req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodDelete) {
+		return false
+	}
+	return resp.StatusCode >= 400
+}
+
+// RetryBudget limits how many times the requests made while reconciling an object are retried in total, so a
+// reconciliation running into one transient error after another gives up and is requeued rather than holding a
+// worker of the controller.
+type RetryBudget struct {
+	lock      sync.Mutex
+	remaining int
+}
+
+// NewRetryBudget returns a budget of retries.
+func NewRetryBudget(retries int) *RetryBudget {
+	return &RetryBudget{remaining: retries}
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a context in which the retries of the requests of all clients are taken from budget.
+// Without one, only the retries of each request are limited.
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// retryBudgetFrom returns the retry budget of a context, if any.
+func retryBudgetFrom(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
+
+// take takes a retry from the budget, and returns false if none is left.
+func (b *RetryBudget) take() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// withRetries retries requests with jittered backoff while they fail with a transient error or aren't answered, as
+// long as they have retries left, the retry budget of their context isn't used up and their context leaves time to
+// wait. Throttled requests are held back until the throttling ends rather than retried.
+func withRetries(maxRetries int, backoff time.Duration) autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			rr := autorest.NewRetriableRequest(req)
+			for retries := 0; ; retries++ {
+				if err := rr.Prepare(); err != nil {
+					return nil, err
+				}
+				resp, err := s.Do(rr.Request())
+				class := TransientErrorClass
+				switch {
+				case err != nil:
+					if req.Context().Err() != nil {
+						return resp, err
+					}
+				case resp == nil || !failed(req, resp):
+					return resp, err
+				default:
+					code, _ := responseError(resp)
+					class = classifyFailure(resp.StatusCode, code)
+				}
+				requestFailures.WithLabelValues(string(class)).Inc()
+				if class != TransientErrorClass || resp != nil && resp.StatusCode == http.StatusTooManyRequests || retries >= maxRetries {
+					return resp, err
+				}
+				wait := retryWait(backoff, retries, resp)
+				if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+					return resp, err
+				}
+				if budget := retryBudgetFrom(req.Context()); budget != nil && !budget.take() {
+					return resp, err
+				}
+				requestRetries.WithLabelValues(string(class)).Inc()
+				autorest.DrainResponseBody(resp)
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(wait):
+				}
+			}
+		})
+	}
+}
+
+// retryWait returns how long to wait before a retry of a request, after the given number of retries, unless its
+// response asks for longer.
+func retryWait(backoff time.Duration, retries int, resp *http.Response) time.Duration {
+	wait := backoff << uint(retries)
+	if wait > maxRetryBackoff || wait <= 0 {
+		wait = maxRetryBackoff
+	}
+	// Jitter over the upper half of the backoff spreads the retries of requests which failed together.
+	wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)) // nolint:gosec // No need for a secure random number.
+	if resp == nil {
+		return wait
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(seconds)*time.Second > wait {
+		wait = time.Duration(seconds) * time.Second
+	}
+	return wait
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+)
+
+func TestClassifyError(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		err      error
+		expected ErrorClass
+		ok       bool
+	}{
+		{err: autorest.DetailedError{StatusCode: http.StatusInternalServerError}, expected: TransientErrorClass, ok: true},
+		{err: autorest.DetailedError{StatusCode: http.StatusTooManyRequests}, expected: TransientErrorClass, ok: true},
+		{err: autorest.DetailedError{StatusCode: http.StatusConflict}, expected: ConflictErrorClass, ok: true},
+		{err: autorest.DetailedError{StatusCode: http.StatusPreconditionFailed}, expected: ConflictErrorClass, ok: true},
+		{
+			err:      autorest.DetailedError{StatusCode: http.StatusBadRequest, Original: &azure.ServiceError{Code: "AnotherOperationInProgress"}},
+			expected: ConflictErrorClass,
+			ok:       true,
+		},
+		{
+			err:      fmt.Errorf("failed to get vnet: %w", autorest.DetailedError{StatusCode: http.StatusBadRequest, Original: &azure.ServiceError{Code: "InvalidParameter"}}),
+			expected: TerminalErrorClass,
+			ok:       true,
+		},
+		{err: autorest.DetailedError{StatusCode: http.StatusForbidden}, expected: TerminalErrorClass, ok: true},
+		{err: autorest.DetailedError{Original: errors.New("connection reset")}, expected: TransientErrorClass, ok: true},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: TransientErrorClass, ok: true},
+		{err: errors.New("some error happened"), ok: false},
+	}
+	for _, tc := range tests {
+		class, ok := ClassifyError(tc.err)
+		g.Expect(ok).To(Equal(tc.ok), tc.err.Error())
+		g.Expect(class).To(Equal(tc.expected), tc.err.Error())
+	}
+}
+
+func TestRetries(t *testing.T) {
+	// Azure answers each request with the next status of its path, and the last one once there are no more.
+	statuses := map[string][]int{}
+	var sent []string
+	// A status of 0 stands for a request which isn't answered.
+	throttled := &throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent = append(sent, req.Method+" "+req.URL.Path+" "+string(body))
+			statusCode := statuses[req.URL.Path][0]
+			if len(statuses[req.URL.Path]) > 1 {
+				statuses[req.URL.Path] = statuses[req.URL.Path][1:]
+			}
+			if statusCode == 0 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return &http.Response{StatusCode: statusCode, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("{}")), Request: req}, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}
+	sender := autorest.DecorateSender(throttled, sendDecorators(senderOptions{
+		maxRetries:   2,
+		retryBackoff: time.Millisecond,
+	})...)
+	const path = "/subscriptions/123/resourceGroups/my-rg"
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		budget       *RetryBudget
+		timeout      time.Duration
+		expected     int
+		expectedSent int
+	}{
+		{name: "transient errors are retried", statuses: []int{500, 503, 200}, expected: http.StatusOK, expectedSent: 3},
+		{name: "requests which aren't answered are retried", statuses: []int{0, 200}, expected: http.StatusOK, expectedSent: 2},
+		{name: "retries are limited", statuses: []int{500}, expected: http.StatusInternalServerError, expectedSent: 3},
+		{name: "retries are limited by the budget", statuses: []int{502}, budget: NewRetryBudget(1), expected: http.StatusBadGateway, expectedSent: 2},
+		{name: "retries don't outlast the context", statuses: []int{500}, timeout: time.Microsecond, expected: http.StatusInternalServerError, expectedSent: 1},
+		{name: "conflicts aren't retried", statuses: []int{409, 200}, expected: http.StatusConflict, expectedSent: 1},
+		{name: "terminal errors aren't retried", statuses: []int{400, 200}, expected: http.StatusBadRequest, expectedSent: 1},
+		{name: "throttled requests aren't retried", statuses: []int{429, 200}, expected: http.StatusTooManyRequests, expectedSent: 1},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			statuses[path] = tc.statuses
+			sent = nil
+			throttled.buckets = newThrottleBuckets()
+
+			ctx := context.Background()
+			if tc.budget != nil {
+				ctx = WithRetryBudget(ctx, tc.budget)
+			}
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPut, "https://management.azure.com"+path, strings.NewReader(`{"location":"westeurope"}`))
+			resp, err := sender.Do(req)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(resp.StatusCode).To(Equal(tc.expected))
+			// The body of the request is sent again with each retry.
+			g.Expect(sent).To(HaveLen(tc.expectedSent))
+			for _, s := range sent {
+				g.Expect(s).To(Equal("PUT " + path + ` {"location":"westeurope"}`))
+			}
+		})
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	g := NewWithT(t)
+
+	resp := &http.Response{Header: http.Header{}}
+	for retries, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		wait := retryWait(time.Second, retries, resp)
+		g.Expect(wait).To(BeNumerically(">=", max/2))
+		g.Expect(wait).To(BeNumerically("<=", max))
+	}
+	g.Expect(retryWait(time.Second, 100, resp)).To(BeNumerically("<=", maxRetryBackoff))
+
+	resp.Header.Set("Retry-After", "20")
+	g.Expect(retryWait(time.Second, 0, resp)).To(Equal(20 * time.Second))
+}
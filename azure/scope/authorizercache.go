@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+// authorizers is shared by all scopes of the process. Authorizers refresh their tokens themselves, so sharing
+// them means a token is requested once per identity and resource, rather than once per reconciliation.
+var authorizers = newAuthorizerCache()
+
+// authorizerCache caches authorizers by the credentials they were created for.
+type authorizerCache struct {
+	mu      sync.Mutex
+	entries map[string]authorizerCacheEntry
+}
+
+type authorizerCacheEntry struct {
+	fingerprint string
+	authorizer  autorest.Authorizer
+}
+
+func newAuthorizerCache() *authorizerCache {
+	return &authorizerCache{entries: map[string]authorizerCacheEntry{}}
+}
+
+// get returns the cached authorizer for a key, or creates one if there is none. The fingerprint describes the
+// credentials of the key; when they change, e.g. because an AzureClusterIdentity was updated, the cached
+// authorizer is replaced. Failures to create an authorizer are not cached.
+func (c *authorizerCache) get(key, fingerprint string, create func() (autorest.Authorizer, error)) (autorest.Authorizer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok && entry.fingerprint == fingerprint {
+		return entry.authorizer, nil
+	}
+
+	authorizer, err := create()
+	if err != nil {
+		return nil, err
+	}
+	azure.SetAuthorizerSender(authorizer)
+	c.entries[key] = authorizerCacheEntry{fingerprint: fingerprint, authorizer: authorizer}
+	return authorizer, nil
+}
+
+// fingerprint returns a hash of the given values, which keeps secrets out of the cache keys.
+func fingerprint(values ...interface{}) string {
+	hasher := sha256.New()
+	for _, v := range values {
+		b, _ := json.Marshal(v)
+		_, _ = hasher.Write(b)
+	}
+	return base64.URLEncoding.EncodeToString(hasher.Sum(nil))
+}
+
+// environmentAuthorizerKey returns the cache key and fingerprint of the credentials in environment settings.
+// The subscription is left out, as tokens are valid for all subscriptions of a tenant.
+func environmentAuthorizerKey(settings auth.EnvironmentSettings) (string, string) {
+	credentials := map[string]string{}
+	for _, k := range []string{auth.TenantID, auth.AuxiliaryTenantIDs, auth.ClientID, auth.ClientSecret, auth.CertificatePath, auth.CertificatePassword, auth.Username, auth.Password} {
+		credentials[k] = settings.Values[k]
+	}
+	return "environment/" + settings.Environment.Name + "/" + settings.Values[auth.Resource], fingerprint(credentials, developerCredentialsEnabled)
+}
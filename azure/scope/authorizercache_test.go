@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func TestAuthorizerCache(t *testing.T) {
+	g := NewWithT(t)
+
+	cache := newAuthorizerCache()
+	created := 0
+	create := func() (autorest.Authorizer, error) {
+		created++
+		return autorest.NewBearerAuthorizer(nil), nil
+	}
+
+	first, err := cache.get("identity", fingerprint("v1"), create)
+	g.Expect(err).NotTo(HaveOccurred())
+	second, err := cache.get("identity", fingerprint("v1"), create)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(second).To(BeIdenticalTo(first))
+	g.Expect(created).To(Equal(1))
+
+	// Changed credentials replace the cached authorizer.
+	third, err := cache.get("identity", fingerprint("v2"), create)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(third).NotTo(BeIdenticalTo(first))
+	g.Expect(created).To(Equal(2))
+
+	// Failures are not cached.
+	_, err = cache.get("other", fingerprint("v1"), func() (autorest.Authorizer, error) {
+		return nil, errors.New("throttled")
+	})
+	g.Expect(err).To(MatchError("throttled"))
+	fourth, err := cache.get("other", fingerprint("v1"), create)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(fourth).NotTo(BeNil())
+	g.Expect(created).To(Equal(3))
+}
+
+func TestEnvironmentAuthorizerKey(t *testing.T) {
+	g := NewWithT(t)
+
+	settings := auth.EnvironmentSettings{
+		Values: map[string]string{
+			auth.Resource:       "https://management.azure.com/",
+			auth.SubscriptionID: "subscription-1",
+			auth.TenantID:       "tenant",
+			auth.ClientID:       "client",
+			auth.ClientSecret:   "secret",
+		},
+		Environment: azure.PublicCloud,
+	}
+	key, fp := environmentAuthorizerKey(settings)
+	g.Expect(key).To(Equal("environment/AzurePublicCloud/https://management.azure.com/"))
+	g.Expect(key + fp).NotTo(ContainSubstring("secret"))
+
+	// Clusters in other subscriptions share the authorizer.
+	settings.Values[auth.SubscriptionID] = "subscription-2"
+	_, otherFP := environmentAuthorizerKey(settings)
+	g.Expect(otherFP).To(Equal(fp))
+
+	settings.Values[auth.ClientSecret] = "rotated"
+	_, otherFP = environmentAuthorizerKey(settings)
+	g.Expect(otherFP).NotTo(Equal(fp))
+}
@@ -36,6 +36,9 @@ type AzureClients struct {
 	Authorizer                 autorest.Authorizer
 	ResourceManagerEndpoint    string
 	ResourceManagerVMDNSSuffix string
+
+	// keyVaultAuthorizer returns an authorizer for Key Vault with the same credentials as Authorizer.
+	keyVaultAuthorizer func(ctx context.Context, resource string) (autorest.Authorizer, error)
 }
 
 // CloudEnvironment returns the Azure environment the controller runs in.
@@ -72,6 +75,12 @@ func (c *AzureClients) HashKey() string {
 	return base64.URLEncoding.EncodeToString(hasher.Sum(nil))
 }
 
+// ControllerAuthorizer returns an authorizer with the credentials of the controller, which differ from the
+// ones of the clients when the cluster references an identity.
+func (c *AzureClients) ControllerAuthorizer() (autorest.Authorizer, error) {
+	return newEnvironmentAuthorizer(resourceManagerAudience(c.Environment))
+}
+
 func (c *AzureClients) setCredentials(subscriptionID, environmentName string) error {
 	settings, err := c.getSettingsFromEnvironment(environmentName)
 	if err != nil {
@@ -93,8 +102,17 @@ func (c *AzureClients) setCredentials(subscriptionID, environmentName string) er
 	c.Values[auth.SubscriptionID] = strings.TrimSuffix(subscriptionID, "\n")
 	c.Values[auth.TenantID] = strings.TrimSuffix(c.Values[auth.TenantID], "\n")
 
+	c.keyVaultAuthorizer = func(_ context.Context, resource string) (autorest.Authorizer, error) {
+		keyVaultSettings := auth.EnvironmentSettings{Values: map[string]string{}, Environment: settings.Environment}
+		for k, v := range c.Values {
+			keyVaultSettings.Values[k] = v
+		}
+		keyVaultSettings.Values[auth.Resource] = resource
+		return environmentAuthorizer(keyVaultSettings)
+	}
+
 	if c.Authorizer == nil {
-		c.Authorizer, err = c.GetAuthorizer()
+		c.Authorizer, err = environmentAuthorizer(c.EnvironmentSettings)
 	}
 	return err
 }
@@ -120,8 +138,16 @@ func (c *AzureClients) setCredentialsWithProvider(ctx context.Context, subscript
 	c.ResourceManagerEndpoint = settings.Environment.ResourceManagerEndpoint
 	c.ResourceManagerVMDNSSuffix = settings.Environment.ResourceManagerVMDNSSuffix
 	c.Values[auth.SubscriptionID] = strings.TrimSuffix(subscriptionID, "\n")
+	// The identity may live in another tenant than the controller, which must be reflected in the
+	// tenant and client of the scope, e.g. to keep the caches keyed by HashKey apart.
+	c.Values[auth.TenantID] = credentialsProvider.GetTenantID()
+	c.Values[auth.ClientID] = credentialsProvider.GetClientID()
+
+	c.keyVaultAuthorizer = func(ctx context.Context, resource string) (autorest.Authorizer, error) {
+		return credentialsProvider.GetAuthorizer(ctx, resource, settings.Environment.ActiveDirectoryEndpoint)
+	}
 
-	c.Authorizer, err = credentialsProvider.GetAuthorizer(ctx, c.ResourceManagerEndpoint)
+	c.Authorizer, err = credentialsProvider.GetAuthorizer(ctx, resourceManagerAudience(settings.Environment), settings.Environment.ActiveDirectoryEndpoint)
 	return err
 }
 
@@ -143,10 +169,11 @@ func (c *AzureClients) getSettingsFromEnvironment(environmentName string) (s aut
 	if v := s.Values[auth.EnvironmentName]; v == "" {
 		s.Environment = azure.PublicCloud
 	} else {
-		s.Environment, err = azure.EnvironmentFromName(v)
+		s.Environment, err = environmentFromName(v)
 	}
+	s.Environment = withARMClientOptions(s.Environment)
 	if s.Values[auth.Resource] == "" {
-		s.Values[auth.Resource] = s.Environment.ResourceManagerEndpoint
+		s.Values[auth.Resource] = resourceManagerAudience(s.Environment)
 	}
 	return
 }
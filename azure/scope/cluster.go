@@ -18,15 +18,21 @@ package scope
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2/klogr"
 	"k8s.io/utils/net"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -36,6 +42,7 @@ import (
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 )
 
 // ClusterScopeParams defines the input parameters used to create a new Scope.
@@ -61,6 +68,7 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 		params.Logger = klogr.New()
 	}
 
+	var provisionRoleAssignments bool
 	if params.AzureCluster.Spec.IdentityRef == nil {
 		err := params.AzureClients.setCredentials(params.AzureCluster.Spec.SubscriptionID, params.AzureCluster.Spec.AzureEnvironment)
 		if err != nil {
@@ -75,6 +83,12 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to configure azure settings and credentials for Identity")
 		}
+		provisionRoleAssignments = credentailsProvider.Identity.Spec.ProvisionRoleAssignments
+	}
+
+	serviceClients, err := newServiceClients(ctx, params.Client, params.AzureCluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to configure azure credentials for service identities")
 	}
 
 	helper, err := patch.NewHelper(params.AzureCluster, params.Client)
@@ -83,15 +97,45 @@ func NewClusterScope(ctx context.Context, params ClusterScopeParams) (*ClusterSc
 	}
 
 	return &ClusterScope{
-		Logger:       params.Logger,
-		Client:       params.Client,
-		AzureClients: params.AzureClients,
-		Cluster:      params.Cluster,
-		AzureCluster: params.AzureCluster,
-		patchHelper:  helper,
+		Logger:         params.Logger,
+		Client:         params.Client,
+		AzureClients:   params.AzureClients,
+		Cluster:        params.Cluster,
+		AzureCluster:   params.AzureCluster,
+		patchHelper:    helper,
+		serviceClients: serviceClients,
+
+		provisionRoleAssignments: provisionRoleAssignments,
 	}, nil
 }
 
+// newServiceClients returns the clients of the services whose identity is overridden in the AzureCluster.
+func newServiceClients(ctx context.Context, kubeClient client.Client, azureCluster *infrav1.AzureCluster) (map[infrav1.AzureService]*AzureClients, error) {
+	if len(azureCluster.Spec.ServiceIdentityRefs) == 0 {
+		return nil, nil
+	}
+
+	serviceClients := map[infrav1.AzureService]*AzureClients{}
+	for _, ref := range azureCluster.Spec.ServiceIdentityRefs {
+		if ref.IdentityRef == nil {
+			return nil, errors.Errorf("identity of service %s is not set", ref.Service)
+		}
+		credentialsProvider, err := newAzureClusterCredentialsProvider(ctx, kubeClient, azureCluster, ref.IdentityRef)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to init credentials provider of service %s", ref.Service)
+		}
+		if !IsClusterNamespaceAllowed(ctx, kubeClient, credentialsProvider.Identity.Spec.AllowedNamespaces, azureCluster.Namespace) {
+			return nil, errors.Errorf("AzureClusterIdentity of service %s doesn't allow namespace %s", ref.Service, azureCluster.Namespace)
+		}
+		clients := &AzureClients{}
+		if err := clients.setCredentialsWithProvider(ctx, azureCluster.Spec.SubscriptionID, azureCluster.Spec.AzureEnvironment, credentialsProvider); err != nil {
+			return nil, errors.Wrapf(err, "failed to configure azure credentials of service %s", ref.Service)
+		}
+		serviceClients[ref.Service] = clients
+	}
+	return serviceClients, nil
+}
+
 // ClusterScope defines the basic context for an actuator to operate upon.
 type ClusterScope struct {
 	logr.Logger
@@ -101,6 +145,36 @@ type ClusterScope struct {
 	AzureClients
 	Cluster      *clusterv1.Cluster
 	AzureCluster *infrav1.AzureCluster
+
+	// serviceClients are the clients of the services whose identity is overridden.
+	serviceClients map[infrav1.AzureService]*AzureClients
+
+	// provisionRoleAssignments is set by the AzureClusterIdentity of the cluster, which unlike the AzureCluster is
+	// controlled by the administrators of the management cluster.
+	provisionRoleAssignments bool
+
+	// drift describes the Azure resources found to differ from their specs, recorded by services reconciled
+	// concurrently.
+	drift     []string
+	driftLock sync.Mutex
+	// specHashesLock guards the hashes of the specs of services in the status of the AzureCluster, which are set by
+	// services reconciled concurrently.
+	specHashesLock sync.Mutex
+}
+
+// serviceAuthorizer implements azure.Authorizer with the clients of a service identity.
+type serviceAuthorizer struct {
+	*AzureClients
+}
+
+// BaseURI returns the Azure ResourceManagerEndpoint.
+func (a serviceAuthorizer) BaseURI() string {
+	return a.ResourceManagerEndpoint
+}
+
+// Authorizer returns the Azure client Authorizer of the service identity.
+func (a serviceAuthorizer) Authorizer() autorest.Authorizer {
+	return a.AzureClients.Authorizer
 }
 
 // BaseURI returns the Azure ResourceManagerEndpoint.
@@ -113,6 +187,15 @@ func (s *ClusterScope) Authorizer() autorest.Authorizer {
 	return s.AzureClients.Authorizer
 }
 
+// ServiceAuthorizer returns the authorizer a service of the cluster is reconciled with. It is the one of the
+// cluster unless the identity of the service is overridden in the AzureCluster.
+func (s *ClusterScope) ServiceAuthorizer(service infrav1.AzureService) azure.Authorizer {
+	if clients, ok := s.serviceClients[service]; ok {
+		return serviceAuthorizer{clients}
+	}
+	return s
+}
+
 // PublicIPSpecs returns the public IP specs.
 func (s *ClusterScope) PublicIPSpecs() []azure.PublicIPSpec {
 	var publicIPSpecs []azure.PublicIPSpec
@@ -140,12 +223,12 @@ func (s *ClusterScope) PublicIPSpecs() []azure.PublicIPSpec {
 			// do nothing
 		} else if *loadBalancerNodeOutboundIPs == 1 {
 			nodeOutboundIPSpecs = append(nodeOutboundIPSpecs, azure.PublicIPSpec{
-				Name: azure.GenerateNodeOutboundIPName(s.ClusterName()),
+				Name: s.AzureResourceNaming().ResourceName(infrav1.NodeOutboundPublicIPKind, azure.GenerateNodeOutboundIPName(s.ClusterName()), s.ClusterName(), ""),
 			})
 		} else {
 			for i := 0; i < int(*loadBalancerNodeOutboundIPs); i++ {
 				publicIPSpecs = append(publicIPSpecs, azure.PublicIPSpec{
-					Name: azure.WithIndex(azure.GenerateNodeOutboundIPName(s.ClusterName()), i+1),
+					Name: azure.WithIndex(s.AzureResourceNaming().ResourceName(infrav1.NodeOutboundPublicIPKind, azure.GenerateNodeOutboundIPName(s.ClusterName()), s.ClusterName(), ""), i+1),
 				})
 			}
 		}
@@ -170,28 +253,30 @@ func (s *ClusterScope) LBSpecs() []azure.LBSpec {
 	specs := []azure.LBSpec{
 		{
 			// Control Plane LB
-			Name:                 s.APIServerLB().Name,
-			SubnetName:           s.ControlPlaneSubnet().Name,
-			FrontendIPConfigs:    s.APIServerLB().FrontendIPs,
-			APIServerPort:        s.APIServerPort(),
-			Type:                 s.APIServerLB().Type,
-			SKU:                  infrav1.SKUStandard,
-			Role:                 infrav1.APIServerRole,
-			BackendPoolName:      s.APIServerLBPoolName(s.APIServerLB().Name),
-			IdleTimeoutInMinutes: s.APIServerLB().IdleTimeoutInMinutes,
+			Name:                   s.APIServerLB().Name,
+			SubnetName:             s.ControlPlaneSubnet().Name,
+			FrontendIPConfigs:      s.APIServerLB().FrontendIPs,
+			APIServerPort:          s.APIServerPort(),
+			Type:                   s.APIServerLB().Type,
+			SKU:                    infrav1.SKUStandard,
+			Role:                   infrav1.APIServerRole,
+			BackendPoolName:        s.APIServerLBPoolName(s.APIServerLB().Name),
+			IdleTimeoutInMinutes:   s.APIServerLB().IdleTimeoutInMinutes,
+			AllocatedOutboundPorts: s.APIServerLB().AllocatedOutboundPorts,
 		},
 	}
 
 	// Public Node outbound LB
 	if s.NodeOutboundLB() != nil {
 		specs = append(specs, azure.LBSpec{
-			Name:                 s.NodeOutboundLBName(),
-			FrontendIPConfigs:    s.NodeOutboundLB().FrontendIPs,
-			Type:                 s.NodeOutboundLB().Type,
-			SKU:                  s.NodeOutboundLB().SKU,
-			BackendPoolName:      s.OutboundPoolName(s.NodeOutboundLBName()),
-			IdleTimeoutInMinutes: s.NodeOutboundLB().IdleTimeoutInMinutes,
-			Role:                 infrav1.NodeOutboundRole,
+			Name:                   s.NodeOutboundLBName(),
+			FrontendIPConfigs:      s.NodeOutboundLB().FrontendIPs,
+			Type:                   s.NodeOutboundLB().Type,
+			SKU:                    s.NodeOutboundLB().SKU,
+			BackendPoolName:        s.OutboundPoolName(s.NodeOutboundLBName()),
+			IdleTimeoutInMinutes:   s.NodeOutboundLB().IdleTimeoutInMinutes,
+			AllocatedOutboundPorts: s.NodeOutboundLB().AllocatedOutboundPorts,
+			Role:                   infrav1.NodeOutboundRole,
 		})
 	}
 
@@ -256,6 +341,7 @@ func (s *ClusterScope) SubnetSpecs() []azure.SubnetSpec {
 			SecurityGroupName: s.ControlPlaneSubnet().SecurityGroup.Name,
 			Role:              s.ControlPlaneSubnet().Role,
 			RouteTableName:    s.ControlPlaneSubnet().RouteTable.Name,
+			ServiceEndpoints:  s.ControlPlaneSubnet().ServiceEndpoints,
 		},
 		{
 			Name:              s.NodeSubnet().Name,
@@ -264,6 +350,7 @@ func (s *ClusterScope) SubnetSpecs() []azure.SubnetSpec {
 			SecurityGroupName: s.NodeSubnet().SecurityGroup.Name,
 			RouteTableName:    s.NodeSubnet().RouteTable.Name,
 			Role:              s.NodeSubnet().Role,
+			ServiceEndpoints:  s.NodeSubnet().ServiceEndpoints,
 		},
 	}
 
@@ -291,15 +378,17 @@ func (s *ClusterScope) VNetSpec() azure.VNetSpec {
 	}
 }
 
-// PrivateDNSSpec returns the private dns zone spec.
+// PrivateDNSSpec returns the private dns zone spec, or nil if the cluster has no private dns zone managed by the controller.
 func (s *ClusterScope) PrivateDNSSpec() *azure.PrivateDNSSpec {
 	var spec *azure.PrivateDNSSpec
-	if s.IsAPIServerPrivate() {
+	if s.IsAPIServerPrivate() && !s.AzureCluster.ServiceDisabled(infrav1.PrivateDNSManagedService) {
 		spec = &azure.PrivateDNSSpec{
 			ZoneName:          s.GetPrivateDNSZoneName(),
 			VNetName:          s.Vnet().Name,
 			VNetResourceGroup: s.Vnet().ResourceGroup,
 			LinkName:          azure.GenerateVNetLinkName(s.Vnet().Name),
+			ResourceGroup:     s.AzureCluster.Spec.NetworkSpec.PrivateDNSZoneResourceGroup,
+			SubscriptionID:    s.AzureCluster.Spec.NetworkSpec.PrivateDNSZoneSubscriptionID,
 			Records: []infrav1.AddressRecord{
 				{
 					Hostname: azure.PrivateAPIServerHostname,
@@ -326,6 +415,448 @@ func (s *ClusterScope) BastionSpec() azure.BastionSpec {
 	return ret
 }
 
+// IdentityPermissionsSpecs returns the roles the cluster identity needs in the resource groups of the cluster,
+// or nil if checking the permissions of the identity is not enabled.
+func (s *ClusterScope) IdentityPermissionsSpecs() []azure.IdentityPermissionsSpec {
+	if s.AzureCluster.Spec.IdentityPermissions == nil {
+		return nil
+	}
+
+	specs := []azure.IdentityPermissionsSpec{
+		{
+			ResourceGroup:    s.ResourceGroup(),
+			RoleDefinitionID: azure.VirtualMachineContributorRoleID,
+			Actions: []string{
+				"Microsoft.Compute/virtualMachines/write",
+				"Microsoft.Compute/virtualMachines/delete",
+				"Microsoft.Compute/availabilitySets/write",
+				"Microsoft.Compute/disks/delete",
+			},
+		},
+		{
+			ResourceGroup:    s.ResourceGroup(),
+			RoleDefinitionID: azure.NetworkContributorRoleID,
+			Actions: []string{
+				"Microsoft.Network/networkInterfaces/write",
+				"Microsoft.Network/networkSecurityGroups/write",
+				"Microsoft.Network/routeTables/write",
+				"Microsoft.Network/publicIPAddresses/write",
+				"Microsoft.Network/loadBalancers/write",
+			},
+		},
+	}
+
+	// Permissions are only checked in the subscription of the cluster, so not for zones in other subscriptions.
+	if networkSpec := s.AzureCluster.Spec.NetworkSpec; s.IsAPIServerPrivate() && !s.AzureCluster.ServiceDisabled(infrav1.PrivateDNSManagedService) &&
+		networkSpec.PrivateDNSZoneSubscriptionID == "" {
+		zoneSpec := azure.IdentityPermissionsSpec{
+			ResourceGroup:    s.ResourceGroup(),
+			RoleDefinitionID: azure.PrivateDNSZoneContributorRoleID,
+			Actions: []string{
+				"Microsoft.Network/privateDnsZones/write",
+				"Microsoft.Network/privateDnsZones/virtualNetworkLinks/write",
+			},
+		}
+		// Existing zones are only linked to the virtual network and given records.
+		if networkSpec.PrivateDNSZoneResourceGroup != "" {
+			zoneSpec.ResourceGroup = networkSpec.PrivateDNSZoneResourceGroup
+			zoneSpec.Actions = []string{
+				"Microsoft.Network/privateDnsZones/virtualNetworkLinks/write",
+				"Microsoft.Network/privateDnsZones/A/write",
+			}
+		}
+		specs = append(specs, zoneSpec)
+	}
+
+	if s.AzureCluster.Spec.BastionSpec.AzureBastion != nil {
+		specs[1].Actions = append(specs[1].Actions, "Microsoft.Network/bastionHosts/write")
+	}
+
+	// The cloud provider identity is created and assigned its roles with the credentials of the cluster.
+	if s.AzureCluster.Spec.CloudProviderIdentity != nil {
+		specs = append(specs,
+			azure.IdentityPermissionsSpec{
+				ResourceGroup:    s.ResourceGroup(),
+				RoleDefinitionID: azure.ManagedIdentityContributorRoleID,
+				Actions:          []string{"Microsoft.ManagedIdentity/userAssignedIdentities/write"},
+			},
+			azure.IdentityPermissionsSpec{
+				ResourceGroup:    s.ResourceGroup(),
+				RoleDefinitionID: azure.UserAccessAdministratorRoleID,
+				Actions:          []string{"Microsoft.Authorization/roleAssignments/write"},
+			},
+		)
+	}
+
+	// Enforced tags are patched without updating their resources, which needs a permission of its own.
+	if s.AzureCluster.Spec.EnforceTags {
+		specs = append(specs, azure.IdentityPermissionsSpec{
+			ResourceGroup:    s.ResourceGroup(),
+			RoleDefinitionID: azure.TagContributorRoleID,
+			Actions:          []string{"Microsoft.Resources/tags/write"},
+		})
+	}
+
+	// The virtual network may live in another resource group; the identity manages its subnets there.
+	vnetActions := []string{
+		"Microsoft.Network/virtualNetworks/subnets/write",
+		"Microsoft.Network/virtualNetworks/subnets/join/action",
+	}
+	if s.IsVnetManaged() {
+		vnetActions = append([]string{"Microsoft.Network/virtualNetworks/write"}, vnetActions...)
+	}
+	if vnetGroup := s.Vnet().ResourceGroup; vnetGroup != "" && vnetGroup != s.ResourceGroup() {
+		specs = append(specs, azure.IdentityPermissionsSpec{
+			ResourceGroup:    vnetGroup,
+			RoleDefinitionID: azure.NetworkContributorRoleID,
+			Actions:          vnetActions,
+		})
+	} else {
+		specs[1].Actions = append(specs[1].Actions, vnetActions...)
+	}
+
+	return specs
+}
+
+// ProvisionIdentityRoleAssignments returns true if the controller assigns the roles the cluster identity needs, which
+// only the AzureClusterIdentity of the cluster can enable.
+func (s *ClusterScope) ProvisionIdentityRoleAssignments() bool {
+	return s.AzureCluster.Spec.IdentityPermissions != nil && s.provisionRoleAssignments
+}
+
+// IdentityPermissionsCheckRequested returns true if checking the permissions of the cluster identity was requested.
+func (s *ClusterScope) IdentityPermissionsCheckRequested() bool {
+	_, ok := s.AzureCluster.Annotations[infrav1.CheckIdentityPermissionsAnnotation]
+	return ok
+}
+
+// ServiceReconcileTimeout returns how long the reconciliation of a single Azure service may take.
+func (s *ClusterScope) ServiceReconcileTimeout() time.Duration {
+	return reconciler.DefaultedAzureServiceReconcileTimeout(s.annotatedTimeout(infrav1.ServiceReconcileTimeoutAnnotation))
+}
+
+// AzureCallTimeout returns how long a single call to Azure may take.
+func (s *ClusterScope) AzureCallTimeout() time.Duration {
+	return reconciler.DefaultedAzureCallTimeout(s.annotatedTimeout(infrav1.AzureCallTimeoutAnnotation))
+}
+
+// DryRun returns whether the changes to the Azure resources of the cluster and its machines are only logged, either
+// because the controller runs in dry run mode or because the AzureCluster is annotated for it.
+func (s *ClusterScope) DryRun() bool {
+	if azure.GetARMClientOptions().DryRun {
+		return true
+	}
+	dryRun, err := strconv.ParseBool(s.AzureCluster.Annotations[infrav1.DryRunAnnotation])
+	return err == nil && dryRun
+}
+
+// ReadOnly returns whether the changes to the Azure resources of the cluster and its machines are refused, either
+// because the controller runs in read-only mode or because the AzureCluster is annotated for it.
+func (s *ClusterScope) ReadOnly() bool {
+	if azure.GetARMClientOptions().ReadOnly {
+		return true
+	}
+	readOnly, err := strconv.ParseBool(s.AzureCluster.Annotations[infrav1.ReadOnlyAnnotation])
+	return err == nil && readOnly
+}
+
+// SkippedServices returns the names of the Azure services whose reconciliation is paused by annotations of the
+// AzureCluster, e.g. "loadbalancers".
+func (s *ClusterScope) SkippedServices() map[string]bool {
+	skipped := map[string]bool{}
+	for annotation, value := range s.AzureCluster.Annotations {
+		if !strings.HasPrefix(annotation, infrav1.SkipServiceAnnotationPrefix) {
+			continue
+		}
+		if skip, err := strconv.ParseBool(value); err == nil && skip {
+			skipped[strings.TrimPrefix(annotation, infrav1.SkipServiceAnnotationPrefix)] = true
+		}
+	}
+	return skipped
+}
+
+// DeletionPolicy returns what happens to the Azure resources of the cluster when the AzureCluster is deleted.
+func (s *ClusterScope) DeletionPolicy() infrav1.DeletionPolicy {
+	if s.AzureCluster.Spec.DeletionPolicy == "" {
+		return infrav1.DeletionPolicyDelete
+	}
+	return s.AzureCluster.Spec.DeletionPolicy
+}
+
+// annotatedTimeout returns the timeout set by an annotation of the AzureCluster, or zero if it isn't set. Invalid
+// values are rejected by the webhook, and ignored here.
+func (s *ClusterScope) annotatedTimeout(annotation string) time.Duration {
+	d, err := time.ParseDuration(s.AzureCluster.Annotations[annotation])
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// SetIdentityPermissionsCondition reports the permissions the cluster identity is missing, and removes the
+// request to check them.
+func (s *ClusterScope) SetIdentityPermissionsCondition(missing []string) {
+	delete(s.AzureCluster.Annotations, infrav1.CheckIdentityPermissionsAnnotation)
+	if len(missing) == 0 {
+		conditions.MarkTrue(s.AzureCluster, infrav1.IdentityPermissionsReadyCondition)
+		return
+	}
+	conditions.MarkFalse(s.AzureCluster, infrav1.IdentityPermissionsReadyCondition, infrav1.IdentityPermissionsMissingReason,
+		clusterv1.ConditionSeverityWarning, "identity is missing permissions: %s", strings.Join(missing, ", "))
+}
+
+// DriftDetectionInterval returns how often the Azure resources of the cluster are compared to their specs, or zero
+// if drift detection is not enabled.
+func (s *ClusterScope) DriftDetectionInterval() time.Duration {
+	if s.AzureCluster.Spec.DriftDetection == nil {
+		return 0
+	}
+	return s.AzureCluster.Spec.DriftDetection.Interval.Duration
+}
+
+// DriftRepairEnabled returns false if Azure resources which differ from their specs must be left as they are.
+func (s *ClusterScope) DriftRepairEnabled() bool {
+	return s.AzureCluster.Spec.DriftDetection == nil || s.AzureCluster.Spec.DriftDetection.Mode != infrav1.DriftDetectionModeReport
+}
+
+// RecordDrift records that an Azure resource differs from its spec, e.g. "network security group foo is missing
+// security rule bar".
+func (s *ClusterScope) RecordDrift(drift string) {
+	s.driftLock.Lock()
+	defer s.driftLock.Unlock()
+	s.drift = append(s.drift, drift)
+}
+
+// Drift returns the drift recorded since the scope was created, sorted.
+func (s *ClusterScope) Drift() []string {
+	s.driftLock.Lock()
+	defer s.driftLock.Unlock()
+	drift := append([]string(nil), s.drift...)
+	sort.Strings(drift)
+	return drift
+}
+
+// SetAzureResourcesInSyncCondition reports the recorded drift if drift detection is enabled. Drift which was
+// repaired doesn't make the resources out of sync.
+func (s *ClusterScope) SetAzureResourcesInSyncCondition() {
+	if s.AzureCluster.Spec.DriftDetection == nil {
+		conditions.Delete(s.AzureCluster, infrav1.AzureResourcesInSyncCondition)
+		return
+	}
+	drift := s.Drift()
+	if len(drift) == 0 || s.DriftRepairEnabled() {
+		conditions.MarkTrue(s.AzureCluster, infrav1.AzureResourcesInSyncCondition)
+		return
+	}
+	conditions.MarkFalse(s.AzureCluster, infrav1.AzureResourcesInSyncCondition, infrav1.AzureResourcesDriftedReason,
+		clusterv1.ConditionSeverityWarning, "Azure resources differ from their specs: %s", strings.Join(drift, ", "))
+}
+
+// SpecResyncPeriod returns the period after which the Azure resources of a service whose spec didn't change are
+// reconciled again, or zero if they are reconciled every time, like when drift detection compares them to their specs.
+func (s *ClusterScope) SpecResyncPeriod() time.Duration {
+	if s.DriftDetectionInterval() > 0 {
+		return 0
+	}
+	return reconciler.SpecResyncPeriod()
+}
+
+// ServiceSpecHash returns the hash of the spec of a service when its Azure resources were last reconciled, and when
+// they were, or an empty hash if they never were.
+func (s *ClusterScope) ServiceSpecHash(service string) (string, time.Time) {
+	s.specHashesLock.Lock()
+	defer s.specHashesLock.Unlock()
+	for _, specHash := range s.AzureCluster.Status.ServiceSpecHashes {
+		if specHash.Service == service {
+			return specHash.Hash, specHash.ReconciledAt.Time
+		}
+	}
+	return "", time.Time{}
+}
+
+// SetServiceSpecHash stores the hash of the spec of a service whose Azure resources were just reconciled.
+func (s *ClusterScope) SetServiceSpecHash(service, hash string) {
+	s.specHashesLock.Lock()
+	defer s.specHashesLock.Unlock()
+	specHash := infrav1.ServiceSpecHash{Service: service, Hash: hash, ReconciledAt: metav1.Now()}
+	for i := range s.AzureCluster.Status.ServiceSpecHashes {
+		if s.AzureCluster.Status.ServiceSpecHashes[i].Service == service {
+			s.AzureCluster.Status.ServiceSpecHashes[i] = specHash
+			return
+		}
+	}
+	s.AzureCluster.Status.ServiceSpecHashes = append(s.AzureCluster.Status.ServiceSpecHashes, specHash)
+	sort.Slice(s.AzureCluster.Status.ServiceSpecHashes, func(i, j int) bool {
+		return s.AzureCluster.Status.ServiceSpecHashes[i].Service < s.AzureCluster.Status.ServiceSpecHashes[j].Service
+	})
+}
+
+// TagsSpecs returns the resources of the cluster created with the additional tags of the cluster, so that changes to
+// the additional tags are applied to the existing resources.
+func (s *ClusterScope) TagsSpecs() []azure.TagsSpec {
+	tags := s.AdditionalTags()
+	specs := []azure.TagsSpec{
+		{
+			Scope:       azure.ResourceGroupID(s.SubscriptionID(), s.ResourceGroup()),
+			Tags:        tags,
+			Annotation:  infrav1.ClusterTagsLastAppliedAnnotation,
+			OnlyIfOwned: true,
+		},
+	}
+	if s.IsVnetManaged() {
+		specs = append(specs, azure.TagsSpec{
+			Scope:       azure.VNetID(s.SubscriptionID(), s.Vnet().ResourceGroup, s.Vnet().Name),
+			Tags:        tags,
+			Annotation:  infrav1.ClusterTagsLastAppliedAnnotation,
+			OnlyIfOwned: true,
+		})
+		for _, nsg := range s.NSGSpecs() {
+			specs = append(specs, azure.TagsSpec{
+				Scope:      azure.SecurityGroupID(s.SubscriptionID(), s.ResourceGroup(), nsg.Name),
+				Tags:       tags,
+				Annotation: infrav1.ClusterTagsLastAppliedAnnotation,
+			})
+		}
+	}
+	for _, ip := range s.PublicIPSpecs() {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      azure.PublicIPID(s.SubscriptionID(), s.ResourceGroup(), ip.Name),
+			Tags:       tags,
+			Annotation: infrav1.ClusterTagsLastAppliedAnnotation,
+		})
+	}
+	for _, lb := range s.LBSpecs() {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      azure.LoadBalancerID(s.SubscriptionID(), s.ResourceGroup(), lb.Name),
+			Tags:       tags,
+			Annotation: infrav1.ClusterTagsLastAppliedAnnotation,
+		})
+	}
+	if identity := s.CloudProviderIdentitySpec(); identity != nil {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      s.CloudProviderIdentityID(),
+			Tags:       tags,
+			Annotation: infrav1.ClusterTagsLastAppliedAnnotation,
+		})
+	}
+	return specs
+}
+
+// AnnotationJSON returns a map[string]interface from a JSON annotation of the AzureCluster.
+func (s *ClusterScope) AnnotationJSON(annotation string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	jsonAnnotation := s.AzureCluster.GetAnnotations()[annotation]
+	if len(jsonAnnotation) == 0 {
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(jsonAnnotation), &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// UpdateAnnotationJSON sets the `annotation` of the AzureCluster to `content` marshalled into a JSON string.
+func (s *ClusterScope) UpdateAnnotationJSON(annotation string, content map[string]interface{}) error {
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	if s.AzureCluster.Annotations == nil {
+		s.AzureCluster.Annotations = map[string]string{}
+	}
+	s.AzureCluster.Annotations[annotation] = string(b)
+	return nil
+}
+
+// EnforcedTagsSpecs returns the tags the Azure resources of the cluster must keep, which are the tags the services
+// create them with, or nil if tags are not enforced.
+func (s *ClusterScope) EnforcedTagsSpecs() []azure.EnforcedTagsSpec {
+	if !s.AzureCluster.Spec.EnforceTags {
+		return nil
+	}
+
+	tags := func(name, role string, additional bool) infrav1.Tags {
+		params := infrav1.BuildParams{
+			ClusterName: s.ClusterName(),
+			Lifecycle:   infrav1.ResourceLifecycleOwned,
+		}
+		if name != "" {
+			params.Name = to.StringPtr(name)
+		}
+		if role != "" {
+			params.Role = to.StringPtr(role)
+		}
+		if additional {
+			params.Additional = s.AdditionalTags()
+		}
+		return infrav1.Build(params)
+	}
+
+	specs := []azure.EnforcedTagsSpec{
+		{
+			Name:        "resource group " + s.ResourceGroup(),
+			Scope:       azure.ResourceGroupID(s.SubscriptionID(), s.ResourceGroup()),
+			Tags:        tags(s.ResourceGroup(), infrav1.CommonRole, true),
+			OnlyIfOwned: true,
+		},
+	}
+	if s.IsVnetManaged() {
+		specs = append(specs, azure.EnforcedTagsSpec{
+			Name:        "virtual network " + s.Vnet().Name,
+			Scope:       azure.VNetID(s.SubscriptionID(), s.Vnet().ResourceGroup, s.Vnet().Name),
+			Tags:        tags(s.Vnet().Name, infrav1.CommonRole, true),
+			OnlyIfOwned: true,
+		})
+	}
+	for _, ip := range s.PublicIPSpecs() {
+		specs = append(specs, azure.EnforcedTagsSpec{
+			Name:  "public IP " + ip.Name,
+			Scope: azure.PublicIPID(s.SubscriptionID(), s.ResourceGroup(), ip.Name),
+			Tags:  tags(ip.Name, "", true),
+		})
+	}
+	for _, lb := range s.LBSpecs() {
+		specs = append(specs, azure.EnforcedTagsSpec{
+			Name:  "load balancer " + lb.Name,
+			Scope: azure.LoadBalancerID(s.SubscriptionID(), s.ResourceGroup(), lb.Name),
+			Tags:  tags("", lb.Role, true),
+		})
+	}
+	if identity := s.CloudProviderIdentitySpec(); identity != nil {
+		specs = append(specs, azure.EnforcedTagsSpec{
+			Name:  "user-assigned identity " + identity.Name,
+			Scope: s.CloudProviderIdentityID(),
+			Tags:  tags(identity.Name, infrav1.CommonRole, true),
+		})
+	}
+	if bastion := s.BastionSpec().AzureBastion; bastion != nil {
+		specs = append(specs, azure.EnforcedTagsSpec{
+			Name:  "bastion host " + bastion.Name,
+			Scope: azure.BastionHostID(s.SubscriptionID(), s.ResourceGroup(), bastion.Name),
+			Tags:  tags(bastion.Name, "Bastion", false),
+		})
+	}
+	return specs
+}
+
+// SetTagsEnforcedCondition reports the tags restored by the last reconciliation which restored any, e.g.
+// "foo, bar of public IP my-ip", if tags are enforced.
+func (s *ClusterScope) SetTagsEnforcedCondition(restored []string) {
+	switch {
+	case !s.AzureCluster.Spec.EnforceTags:
+		conditions.Delete(s.AzureCluster, infrav1.TagsEnforcedCondition)
+	case len(restored) > 0:
+		conditions.Set(s.AzureCluster, &clusterv1.Condition{
+			Type:    infrav1.TagsEnforcedCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  infrav1.TagsRestoredReason,
+			Message: "restored tags " + strings.Join(restored, "; "),
+		})
+	case !conditions.IsTrue(s.AzureCluster, infrav1.TagsEnforcedCondition):
+		conditions.MarkTrue(s.AzureCluster, infrav1.TagsEnforcedCondition)
+	}
+}
+
 // Vnet returns the cluster Vnet.
 func (s *ClusterScope) Vnet() *infrav1.VnetSpec {
 	return &s.AzureCluster.Spec.NetworkSpec.Vnet
@@ -433,9 +964,13 @@ func (s *ClusterScope) NodeOutboundLBName() string {
 	return s.ClusterName()
 }
 
-// OutboundLBName returns the name of the outbound LB.
+// OutboundLBName returns the name of the outbound LB, or an empty string if the outbound connectivity of nodes is
+// provided outside of the controller.
 func (s *ClusterScope) OutboundLBName(role string) string {
 	if role == infrav1.Node {
+		if s.AzureCluster.ServiceDisabled(infrav1.NodeOutboundLBManagedService) {
+			return ""
+		}
 		return s.ClusterName()
 	}
 	if s.IsAPIServerPrivate() {
@@ -479,6 +1014,43 @@ func (s *ClusterScope) CloudProviderConfigOverrides() *infrav1.CloudProviderConf
 	return s.AzureCluster.Spec.CloudProviderConfigOverrides
 }
 
+// CloudProviderIdentityID returns the resource ID of the user-assigned identity of the cloud provider,
+// or an empty string if the controller does not manage one.
+func (s *ClusterScope) CloudProviderIdentityID() string {
+	if s.AzureCluster.Spec.CloudProviderIdentity == nil {
+		return ""
+	}
+	return azure.UserAssignedIdentityID(s.SubscriptionID(), s.ResourceGroup(), s.AzureCluster.Spec.CloudProviderIdentity.Name)
+}
+
+// AzureResourceNaming returns the naming of the Azure resources of the cluster, or nil if the default names are used.
+func (s *ClusterScope) AzureResourceNaming() *infrav1.AzureResourceNaming {
+	return s.AzureCluster.Spec.AzureResourceNaming
+}
+
+// CloudProviderIdentitySpec returns the user-assigned identity of the cloud provider and the roles it needs,
+// or nil if the controller does not manage one.
+func (s *ClusterScope) CloudProviderIdentitySpec() *azure.ManagedIdentitySpec {
+	if s.AzureCluster.Spec.CloudProviderIdentity == nil {
+		return nil
+	}
+
+	spec := &azure.ManagedIdentitySpec{
+		Name:          s.AzureCluster.Spec.CloudProviderIdentity.Name,
+		ResourceGroup: s.ResourceGroup(),
+		Roles: []azure.ManagedIdentityRoleSpec{
+			// The cloud provider reads the VMs of nodes and attaches disks to them.
+			{ResourceGroup: s.ResourceGroup(), RoleDefinitionID: azure.VirtualMachineContributorRoleID},
+			// It manages load balancers, public IPs, security rules and routes for services and nodes.
+			{ResourceGroup: s.ResourceGroup(), RoleDefinitionID: azure.NetworkContributorRoleID},
+		},
+	}
+	if vnetGroup := s.Vnet().ResourceGroup; vnetGroup != "" && vnetGroup != s.ResourceGroup() {
+		spec.Roles = append(spec.Roles, azure.ManagedIdentityRoleSpec{ResourceGroup: vnetGroup, RoleDefinitionID: azure.NetworkContributorRoleID})
+	}
+	return spec
+}
+
 // GenerateFQDN generates a fully qualified domain name, based on a hash, cluster name and cluster location.
 func (s *ClusterScope) GenerateFQDN(ipName string) string {
 	h := fnv.New32a()
@@ -525,6 +1097,7 @@ func (s *ClusterScope) PatchObject(ctx context.Context) error {
 		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
 			clusterv1.ReadyCondition,
 			infrav1.NetworkInfrastructureReadyCondition,
+			infrav1.IdentityPermissionsReadyCondition,
 		}})
 }
 
@@ -566,6 +1139,47 @@ func (s *ClusterScope) SetFailureDomain(id string, spec clusterv1.FailureDomainS
 	s.AzureCluster.Status.FailureDomains[id] = spec
 }
 
+// SetFailureDomainsForZones replaces the failure domains with those of the availability zones which aren't excluded by
+// the failure domain overrides, named after their override if it has a name.
+func (s *ClusterScope) SetFailureDomainsForZones(zones []string) {
+	s.AzureCluster.Status.FailureDomains = nil
+	for _, zone := range zones {
+		name := zone
+		if override := s.failureDomainOverride(zone); override != nil {
+			if override.Excluded {
+				continue
+			}
+			if override.Name != "" {
+				name = override.Name
+			}
+		}
+		s.SetFailureDomain(name, clusterv1.FailureDomainSpec{
+			ControlPlane: true,
+		})
+	}
+}
+
+// FailureDomainZone returns the availability zone of a failure domain, which is the failure domain itself unless an
+// override gave the zone a custom name.
+func (s *ClusterScope) FailureDomainZone(failureDomain string) string {
+	for _, override := range s.AzureCluster.Spec.FailureDomainOverrides {
+		if override.Name == failureDomain {
+			return override.Zone
+		}
+	}
+	return failureDomain
+}
+
+// failureDomainOverride returns the failure domain override of an availability zone, or nil if it has none.
+func (s *ClusterScope) failureDomainOverride(zone string) *infrav1.FailureDomainOverride {
+	for i, override := range s.AzureCluster.Spec.FailureDomainOverrides {
+		if override.Zone == zone {
+			return &s.AzureCluster.Spec.FailureDomainOverrides[i]
+		}
+	}
+	return nil
+}
+
 // SetControlPlaneSecurityRules sets the default security rules of the control plane subnet.
 // Note that this is not done in a webhook as it requires a valid Cluster object to exist to get the API Server port.
 func (s *ClusterScope) SetControlPlaneSecurityRules() {
@@ -19,15 +19,21 @@ package scope
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -89,3 +95,475 @@ func TestGettingSecurityRules(t *testing.T) {
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(len(subnet.SecurityGroup.SecurityRules)).To(Equal(2))
 }
+
+func TestIdentityPermissionsSpecs(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{
+		Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+		AzureCluster: &infrav1.AzureCluster{
+			Spec: infrav1.AzureClusterSpec{
+				ResourceGroup: "my-rg",
+				NetworkSpec: infrav1.NetworkSpec{
+					Vnet: infrav1.VnetSpec{
+						ID:            "/subscriptions/123/resourceGroups/my-vnet-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+						ResourceGroup: "my-vnet-rg",
+						Name:          "my-vnet",
+					},
+					APIServerLB: infrav1.LoadBalancerSpec{Type: infrav1.Internal},
+				},
+			},
+		},
+	}
+	g.Expect(clusterScope.IdentityPermissionsSpecs()).To(BeNil())
+
+	clusterScope.AzureCluster.Spec.IdentityPermissions = &infrav1.IdentityPermissions{}
+	specs := clusterScope.IdentityPermissionsSpecs()
+	g.Expect(clusterScope.ProvisionIdentityRoleAssignments()).To(BeFalse())
+	clusterScope.provisionRoleAssignments = true
+	g.Expect(clusterScope.ProvisionIdentityRoleAssignments()).To(BeTrue())
+	g.Expect(specs).To(HaveLen(4))
+	g.Expect(specs[2].RoleDefinitionID).To(Equal(azure.PrivateDNSZoneContributorRoleID))
+	g.Expect(specs[3]).To(Equal(azure.IdentityPermissionsSpec{
+		ResourceGroup:    "my-vnet-rg",
+		RoleDefinitionID: azure.NetworkContributorRoleID,
+		Actions: []string{
+			"Microsoft.Network/virtualNetworks/subnets/write",
+			"Microsoft.Network/virtualNetworks/subnets/join/action",
+		},
+	}))
+
+	clusterScope.AzureCluster.Spec.BastionSpec.AzureBastion = &infrav1.AzureBastion{}
+	clusterScope.AzureCluster.Spec.CloudProviderIdentity = &infrav1.CloudProviderIdentity{}
+	specs = clusterScope.IdentityPermissionsSpecs()
+	g.Expect(specs).To(HaveLen(6))
+	g.Expect(specs[1].Actions).To(ContainElement("Microsoft.Network/bastionHosts/write"))
+	g.Expect(specs[3].RoleDefinitionID).To(Equal(azure.ManagedIdentityContributorRoleID))
+	g.Expect(specs[4]).To(Equal(azure.IdentityPermissionsSpec{
+		ResourceGroup:    "my-rg",
+		RoleDefinitionID: azure.UserAccessAdministratorRoleID,
+		Actions:          []string{"Microsoft.Authorization/roleAssignments/write"},
+	}))
+
+	g.Expect(clusterScope.IdentityPermissionsCheckRequested()).To(BeFalse())
+	clusterScope.AzureCluster.Annotations = map[string]string{infrav1.CheckIdentityPermissionsAnnotation: ""}
+	g.Expect(clusterScope.IdentityPermissionsCheckRequested()).To(BeTrue())
+
+	clusterScope.SetIdentityPermissionsCondition([]string{"Microsoft.Network/loadBalancers/write in resource group my-rg"})
+	g.Expect(clusterScope.IdentityPermissionsCheckRequested()).To(BeFalse())
+	g.Expect(conditions.IsFalse(clusterScope.AzureCluster, infrav1.IdentityPermissionsReadyCondition)).To(BeTrue())
+	g.Expect(conditions.GetMessage(clusterScope.AzureCluster, infrav1.IdentityPermissionsReadyCondition)).To(Equal("identity is missing permissions: Microsoft.Network/loadBalancers/write in resource group my-rg"))
+	clusterScope.SetIdentityPermissionsCondition(nil)
+	g.Expect(conditions.IsTrue(clusterScope.AzureCluster, infrav1.IdentityPermissionsReadyCondition)).To(BeTrue())
+}
+
+func TestServiceSpecHash(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	hash, _ := clusterScope.ServiceSpecHash("subnets")
+	g.Expect(hash).To(BeEmpty())
+
+	clusterScope.SetServiceSpecHash("subnets", "a")
+	clusterScope.SetServiceSpecHash("loadbalancers", "b")
+	clusterScope.SetServiceSpecHash("subnets", "c")
+	hash, reconciledAt := clusterScope.ServiceSpecHash("subnets")
+	g.Expect(hash).To(Equal("c"))
+	g.Expect(reconciledAt).To(BeTemporally("~", time.Now(), time.Minute))
+	g.Expect(clusterScope.AzureCluster.Status.ServiceSpecHashes).To(HaveLen(2))
+	g.Expect(clusterScope.AzureCluster.Status.ServiceSpecHashes[0].Service).To(Equal("loadbalancers"))
+
+	g.Expect(clusterScope.SpecResyncPeriod()).To(Equal(reconciler.SpecResyncPeriod()))
+	clusterScope.AzureCluster.Spec.DriftDetection = &infrav1.DriftDetection{Interval: metav1.Duration{Duration: time.Minute}}
+	g.Expect(clusterScope.SpecResyncPeriod()).To(BeZero())
+}
+
+func TestCloudProviderIdentitySpec(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{
+		AzureClients: AzureClients{
+			EnvironmentSettings: auth.EnvironmentSettings{
+				Values: map[string]string{auth.SubscriptionID: "123"},
+			},
+		},
+		Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+		AzureCluster: &infrav1.AzureCluster{
+			Spec: infrav1.AzureClusterSpec{
+				ResourceGroup: "my-rg",
+				NetworkSpec: infrav1.NetworkSpec{
+					Vnet: infrav1.VnetSpec{ResourceGroup: "my-vnet-rg", Name: "my-vnet"},
+				},
+			},
+		},
+	}
+	g.Expect(clusterScope.CloudProviderIdentitySpec()).To(BeNil())
+	g.Expect(clusterScope.CloudProviderIdentityID()).To(BeEmpty())
+
+	clusterScope.AzureCluster.Spec.CloudProviderIdentity = &infrav1.CloudProviderIdentity{Name: "my-cluster-cloud-provider"}
+	g.Expect(clusterScope.CloudProviderIdentityID()).To(Equal("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-cluster-cloud-provider"))
+	g.Expect(clusterScope.CloudProviderIdentitySpec()).To(Equal(&azure.ManagedIdentitySpec{
+		Name:          "my-cluster-cloud-provider",
+		ResourceGroup: "my-rg",
+		Roles: []azure.ManagedIdentityRoleSpec{
+			{ResourceGroup: "my-rg", RoleDefinitionID: azure.VirtualMachineContributorRoleID},
+			{ResourceGroup: "my-rg", RoleDefinitionID: azure.NetworkContributorRoleID},
+			{ResourceGroup: "my-vnet-rg", RoleDefinitionID: azure.NetworkContributorRoleID},
+		},
+	}))
+}
+
+func TestServiceAuthorizer(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{
+		AzureClients: AzureClients{
+			Authorizer:              autorest.NullAuthorizer{},
+			ResourceManagerEndpoint: "https://management.azure.com/",
+		},
+	}
+	g.Expect(clusterScope.ServiceAuthorizer(infrav1.PrivateDNSService)).To(BeIdenticalTo(clusterScope))
+
+	dnsAuthorizer := autorest.NewBearerAuthorizer(nil)
+	clusterScope.serviceClients = map[infrav1.AzureService]*AzureClients{
+		infrav1.PrivateDNSService: {
+			EnvironmentSettings:     auth.EnvironmentSettings{Values: map[string]string{auth.SubscriptionID: "123"}},
+			Authorizer:              dnsAuthorizer,
+			ResourceManagerEndpoint: "https://management.azure.com/",
+		},
+	}
+	authorizer := clusterScope.ServiceAuthorizer(infrav1.PrivateDNSService)
+	g.Expect(authorizer.Authorizer()).To(BeIdenticalTo(dnsAuthorizer))
+	g.Expect(authorizer.BaseURI()).To(Equal("https://management.azure.com/"))
+	g.Expect(authorizer.SubscriptionID()).To(Equal("123"))
+}
+
+func TestNewServiceClientsNamespaceNotAllowed(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	_ = infrav1.AddToScheme(scheme)
+
+	identity := &infrav1.AzureClusterIdentity{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns-identity", Namespace: "identities"},
+		Spec: infrav1.AzureClusterIdentitySpec{
+			Type:              infrav1.ServicePrincipal,
+			AllowedNamespaces: &infrav1.AllowedNamespaces{NamespaceList: []string{"other"}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(identity).Build()
+	azureCluster := &infrav1.AzureCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "default"},
+		Spec: infrav1.AzureClusterSpec{
+			ServiceIdentityRefs: []infrav1.ServiceIdentityRef{
+				{Service: infrav1.PrivateDNSService, IdentityRef: &corev1.ObjectReference{Name: "dns-identity", Namespace: "identities"}},
+			},
+		},
+	}
+
+	_, err := newServiceClients(context.TODO(), fakeClient, azureCluster)
+	g.Expect(err).To(MatchError("AzureClusterIdentity of service PrivateDNS doesn't allow namespace default"))
+
+	azureCluster.Spec.ServiceIdentityRefs = nil
+	clients, err := newServiceClients(context.TODO(), fakeClient, azureCluster)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clients).To(BeNil())
+}
+
+func TestTimeouts(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	g.Expect(clusterScope.ServiceReconcileTimeout()).To(Equal(reconciler.DefaultAzureServiceReconcileTimeout))
+	g.Expect(clusterScope.AzureCallTimeout()).To(Equal(reconciler.DefaultAzureCallTimeout))
+
+	clusterScope.AzureCluster.Annotations = map[string]string{
+		infrav1.ServiceReconcileTimeoutAnnotation: "1h",
+		infrav1.AzureCallTimeoutAnnotation:        "45m",
+	}
+	g.Expect(clusterScope.ServiceReconcileTimeout()).To(Equal(time.Hour))
+	g.Expect(clusterScope.AzureCallTimeout()).To(Equal(45 * time.Minute))
+
+	clusterScope.AzureCluster.Annotations[infrav1.AzureCallTimeoutAnnotation] = "invalid"
+	g.Expect(clusterScope.AzureCallTimeout()).To(Equal(reconciler.DefaultAzureCallTimeout))
+}
+
+func TestDrift(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	g.Expect(clusterScope.DriftDetectionInterval()).To(BeZero())
+	g.Expect(clusterScope.DriftRepairEnabled()).To(BeTrue())
+	clusterScope.RecordDrift("network security group foo is missing security rules bar")
+	clusterScope.SetAzureResourcesInSyncCondition()
+	g.Expect(conditions.Has(clusterScope.AzureCluster, infrav1.AzureResourcesInSyncCondition)).To(BeFalse())
+
+	clusterScope = &ClusterScope{AzureCluster: &infrav1.AzureCluster{
+		Spec: infrav1.AzureClusterSpec{DriftDetection: &infrav1.DriftDetection{
+			Interval: metav1.Duration{Duration: 10 * time.Minute},
+			Mode:     infrav1.DriftDetectionModeReport,
+		}},
+	}}
+	g.Expect(clusterScope.DriftDetectionInterval()).To(Equal(10 * time.Minute))
+	g.Expect(clusterScope.DriftRepairEnabled()).To(BeFalse())
+	clusterScope.SetAzureResourcesInSyncCondition()
+	g.Expect(conditions.IsTrue(clusterScope.AzureCluster, infrav1.AzureResourcesInSyncCondition)).To(BeTrue())
+
+	clusterScope.RecordDrift("network security group foo is missing security rules bar")
+	clusterScope.RecordDrift("network security group baz is missing security rules qux")
+	g.Expect(clusterScope.Drift()).To(Equal([]string{
+		"network security group baz is missing security rules qux",
+		"network security group foo is missing security rules bar",
+	}))
+	clusterScope.SetAzureResourcesInSyncCondition()
+	g.Expect(conditions.IsFalse(clusterScope.AzureCluster, infrav1.AzureResourcesInSyncCondition)).To(BeTrue())
+	g.Expect(conditions.GetMessage(clusterScope.AzureCluster, infrav1.AzureResourcesInSyncCondition)).To(Equal(
+		"Azure resources differ from their specs: network security group baz is missing security rules qux, network security group foo is missing security rules bar"))
+
+	clusterScope.AzureCluster.Spec.DriftDetection.Mode = infrav1.DriftDetectionModeRepair
+	clusterScope.SetAzureResourcesInSyncCondition()
+	g.Expect(conditions.IsTrue(clusterScope.AzureCluster, infrav1.AzureResourcesInSyncCondition)).To(BeTrue())
+}
+
+func TestEnforcedTagsSpecs(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{
+		AzureClients: AzureClients{
+			EnvironmentSettings: auth.EnvironmentSettings{
+				Values: map[string]string{auth.SubscriptionID: "123"},
+			},
+		},
+		Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+		AzureCluster: &infrav1.AzureCluster{
+			Spec: infrav1.AzureClusterSpec{
+				ResourceGroup:  "my-rg",
+				AdditionalTags: infrav1.Tags{"team": "infra"},
+				NetworkSpec: infrav1.NetworkSpec{
+					Vnet: infrav1.VnetSpec{ResourceGroup: "my-rg", Name: "my-vnet"},
+					APIServerLB: infrav1.LoadBalancerSpec{
+						Name: "my-lb",
+						Type: infrav1.Public,
+						FrontendIPs: []infrav1.FrontendIP{
+							{Name: "my-frontend", PublicIP: &infrav1.PublicIPSpec{Name: "my-ip"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	g.Expect(clusterScope.EnforcedTagsSpecs()).To(BeNil())
+
+	clusterScope.AzureCluster.Spec.EnforceTags = true
+	specs := clusterScope.EnforcedTagsSpecs()
+	var names []string
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	g.Expect(names).To(Equal([]string{"resource group my-rg", "virtual network my-vnet", "public IP my-ip", "load balancer my-lb"}))
+	g.Expect(specs[0].Scope).To(Equal("/subscriptions/123/resourceGroups/my-rg"))
+	g.Expect(specs[0].OnlyIfOwned).To(BeTrue())
+	g.Expect(specs[2].Scope).To(Equal("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"))
+	g.Expect(specs[2].OnlyIfOwned).To(BeFalse())
+	g.Expect(specs[2].Tags).To(Equal(infrav1.Tags{
+		"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+		"Name": "my-ip",
+		"team": "infra",
+	}))
+	g.Expect(specs[3].Tags).To(Equal(infrav1.Tags{
+		"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": "owned",
+		"sigs.k8s.io_cluster-api-provider-azure_role":               "apiserver",
+		"team": "infra",
+	}))
+
+	g.Expect(clusterScope.IdentityPermissionsSpecs()).To(BeNil())
+	clusterScope.AzureCluster.Spec.IdentityPermissions = &infrav1.IdentityPermissions{}
+	g.Expect(clusterScope.IdentityPermissionsSpecs()).To(ContainElement(azure.IdentityPermissionsSpec{
+		ResourceGroup:    "my-rg",
+		RoleDefinitionID: azure.TagContributorRoleID,
+		Actions:          []string{"Microsoft.Resources/tags/write"},
+	}))
+}
+
+func TestTagsSpecs(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{
+		AzureClients: AzureClients{
+			EnvironmentSettings: auth.EnvironmentSettings{
+				Values: map[string]string{auth.SubscriptionID: "123"},
+			},
+		},
+		Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+		AzureCluster: &infrav1.AzureCluster{
+			Spec: infrav1.AzureClusterSpec{
+				ResourceGroup:  "my-rg",
+				AdditionalTags: infrav1.Tags{"team": "infra"},
+				NetworkSpec: infrav1.NetworkSpec{
+					Vnet: infrav1.VnetSpec{ResourceGroup: "my-rg", Name: "my-vnet"},
+					Subnets: infrav1.Subnets{
+						{Role: infrav1.SubnetControlPlane, Name: "cp-subnet", SecurityGroup: infrav1.SecurityGroup{Name: "cp-nsg"}},
+						{Role: infrav1.SubnetNode, Name: "node-subnet", SecurityGroup: infrav1.SecurityGroup{Name: "node-nsg"}},
+					},
+					APIServerLB: infrav1.LoadBalancerSpec{
+						Name: "my-lb",
+						Type: infrav1.Public,
+						FrontendIPs: []infrav1.FrontendIP{
+							{Name: "my-frontend", PublicIP: &infrav1.PublicIPSpec{Name: "my-ip"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	specs := clusterScope.TagsSpecs()
+	var scopes []string
+	for _, spec := range specs {
+		scopes = append(scopes, spec.Scope)
+		g.Expect(spec.Tags).To(Equal(infrav1.Tags{"team": "infra"}))
+		g.Expect(spec.Annotation).To(Equal(infrav1.ClusterTagsLastAppliedAnnotation))
+	}
+	g.Expect(scopes).To(Equal([]string{
+		"/subscriptions/123/resourceGroups/my-rg",
+		"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+		"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/cp-nsg",
+		"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/node-nsg",
+		"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip",
+		"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-lb",
+	}))
+	g.Expect(specs[0].OnlyIfOwned).To(BeTrue())
+	g.Expect(specs[1].OnlyIfOwned).To(BeTrue())
+	g.Expect(specs[2].OnlyIfOwned).To(BeFalse())
+
+	// The vnet and security groups of a pre-existing vnet aren't tagged.
+	clusterScope.AzureCluster.Spec.NetworkSpec.Vnet.ID = "my-vnet-id"
+	g.Expect(clusterScope.TagsSpecs()).To(HaveLen(3))
+
+	annotation, err := clusterScope.AnnotationJSON(infrav1.ClusterTagsLastAppliedAnnotation)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(annotation).To(BeEmpty())
+	g.Expect(clusterScope.UpdateAnnotationJSON(infrav1.ClusterTagsLastAppliedAnnotation, map[string]interface{}{"team": "infra"})).To(Succeed())
+	g.Expect(clusterScope.AzureCluster.Annotations).To(HaveKeyWithValue(infrav1.ClusterTagsLastAppliedAnnotation, `{"team":"infra"}`))
+	annotation, err = clusterScope.AnnotationJSON(infrav1.ClusterTagsLastAppliedAnnotation)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(annotation).To(Equal(map[string]interface{}{"team": "infra"}))
+}
+
+func TestSetTagsEnforcedCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	clusterScope.SetTagsEnforcedCondition([]string{"team of public IP my-ip"})
+	g.Expect(conditions.Has(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(BeFalse())
+
+	clusterScope.AzureCluster.Spec.EnforceTags = true
+	clusterScope.SetTagsEnforcedCondition(nil)
+	g.Expect(conditions.IsTrue(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(BeEmpty())
+
+	clusterScope.SetTagsEnforcedCondition([]string{"team of public IP my-ip", "Name of load balancer my-lb"})
+	g.Expect(conditions.IsTrue(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(Equal(infrav1.TagsRestoredReason))
+	g.Expect(conditions.GetMessage(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(Equal("restored tags team of public IP my-ip; Name of load balancer my-lb"))
+
+	// The last restored tags are kept until tags are restored again.
+	clusterScope.SetTagsEnforcedCondition(nil)
+	g.Expect(conditions.GetReason(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(Equal(infrav1.TagsRestoredReason))
+
+	clusterScope.AzureCluster.Spec.EnforceTags = false
+	clusterScope.SetTagsEnforcedCondition(nil)
+	g.Expect(conditions.Has(clusterScope.AzureCluster, infrav1.TagsEnforcedCondition)).To(BeFalse())
+}
+
+func TestClusterScopeDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	g.Expect(clusterScope.DryRun()).To(BeFalse())
+
+	clusterScope.AzureCluster.Annotations = map[string]string{infrav1.DryRunAnnotation: "true"}
+	g.Expect(clusterScope.DryRun()).To(BeTrue())
+
+	clusterScope.AzureCluster.Annotations[infrav1.DryRunAnnotation] = "false"
+	g.Expect(clusterScope.DryRun()).To(BeFalse())
+
+	azure.SetARMClientOptions(azure.ARMClientOptions{DryRun: true})
+	defer azure.SetARMClientOptions(azure.ARMClientOptions{})
+	g.Expect(clusterScope.DryRun()).To(BeTrue())
+}
+
+func TestClusterScopeSkippedServices(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	g.Expect(clusterScope.SkippedServices()).To(BeEmpty())
+
+	clusterScope.AzureCluster.Annotations = map[string]string{
+		infrav1.SkipServiceAnnotationPrefix + "loadbalancers": "true",
+		infrav1.SkipServiceAnnotationPrefix + "subnets":       "false",
+		infrav1.SkipServiceAnnotationPrefix + "routetables":   "invalid",
+		infrav1.DryRunAnnotation:                              "true",
+	}
+	g.Expect(clusterScope.SkippedServices()).To(Equal(map[string]bool{"loadbalancers": true}))
+}
+
+func TestClusterScopeDeletionPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	g.Expect(clusterScope.DeletionPolicy()).To(Equal(infrav1.DeletionPolicyDelete))
+
+	clusterScope.AzureCluster.Spec.DeletionPolicy = infrav1.DeletionPolicyRetainResourceGroup
+	g.Expect(clusterScope.DeletionPolicy()).To(Equal(infrav1.DeletionPolicyRetainResourceGroup))
+}
+
+func TestClusterScopeFailureDomainOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{AzureCluster: &infrav1.AzureCluster{
+		Spec: infrav1.AzureClusterSpec{
+			FailureDomainOverrides: []infrav1.FailureDomainOverride{
+				{Zone: "1", Name: "zone-a"},
+				{Zone: "2", Excluded: true},
+			},
+		},
+		Status: infrav1.AzureClusterStatus{
+			FailureDomains: clusterv1.FailureDomains{
+				"1": {ControlPlane: true},
+				"2": {ControlPlane: true},
+			},
+		},
+	}}
+
+	clusterScope.SetFailureDomainsForZones([]string{"1", "2", "3"})
+	g.Expect(clusterScope.AzureCluster.Status.FailureDomains).To(Equal(clusterv1.FailureDomains{
+		"zone-a": {ControlPlane: true},
+		"3":      {ControlPlane: true},
+	}))
+	g.Expect(clusterScope.FailureDomainZone("zone-a")).To(Equal("1"))
+	g.Expect(clusterScope.FailureDomainZone("3")).To(Equal("3"))
+}
+
+func TestClusterScopeDisabledServices(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &ClusterScope{
+		Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+		AzureCluster: &infrav1.AzureCluster{
+			Spec: infrav1.AzureClusterSpec{
+				NetworkSpec: infrav1.NetworkSpec{
+					APIServerLB: infrav1.LoadBalancerSpec{
+						Type:        infrav1.Internal,
+						FrontendIPs: []infrav1.FrontendIP{{PrivateIPAddress: "10.0.0.100"}},
+					},
+				},
+			},
+		},
+	}
+	g.Expect(clusterScope.PrivateDNSSpec()).NotTo(BeNil())
+	g.Expect(clusterScope.OutboundLBName(infrav1.Node)).To(Equal("my-cluster"))
+
+	clusterScope.AzureCluster.Spec.DisabledServices = []infrav1.ManagedService{infrav1.PrivateDNSManagedService, infrav1.NodeOutboundLBManagedService}
+	g.Expect(clusterScope.PrivateDNSSpec()).To(BeNil())
+	g.Expect(clusterScope.OutboundLBName(infrav1.Node)).To(BeEmpty())
+}
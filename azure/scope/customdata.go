@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+const (
+	// customDataBoundary is the boundary of the parts of merged custom data. It is fixed so that the custom data of a
+	// machine is the same on every reconciliation, which would otherwise update the model of scale sets.
+	customDataBoundary = "cluster-api-provider-azure-custom-data"
+
+	// customDataMergeType is how cloud-init merges the cloud-config of additional custom data into the one of the
+	// bootstrap data: lists, e.g. runcmd and write_files, are appended to, and the values set by the bootstrap data
+	// are kept.
+	customDataMergeType = "list(append)+dict(no_replace,recurse_list)+str()"
+
+	// defaultSecretKey is the key of the data of secrets referenced without a key, like bootstrap data secrets.
+	defaultSecretKey = "value"
+)
+
+// getSecretKeyData returns the data of a key of a Secret.
+func getSecretKeyData(ctx context.Context, c client.Client, namespace string, ref infrav1.SecretKeyReference) ([]byte, error) {
+	secretKey := ref.Key
+	if secretKey == "" {
+		secretKey = defaultSecretKey
+	}
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s", key)
+	}
+	value, ok := secret.Data[secretKey]
+	if !ok {
+		return nil, errors.Errorf("secret %s has no key %s", key, secretKey)
+	}
+	return value, nil
+}
+
+// mergeCustomData returns a cloud-init MIME multi-part archive of the bootstrap data followed by additional custom
+// data, which cloud-init runs in this order.
+func mergeCustomData(bootstrapData, additionalData []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(customDataBoundary); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%q\r\n\r\n", w.Boundary())
+
+	parts := []struct {
+		data      []byte
+		mergeType string
+	}{
+		{data: bootstrapData},
+		{data: additionalData, mergeType: customDataMergeType},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("%s; charset=%q", customDataContentType(part.data), "utf-8"))
+		header.Set("MIME-Version", "1.0")
+		if part.mergeType != "" {
+			header.Set("Merge-Type", part.mergeType)
+		}
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(part.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// customDataContentType returns the content type of a part of custom data. cloud-init detects the type of text/plain
+// parts from their first line, e.g. for the jinja templates of kubeadm bootstrap data.
+func customDataContentType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("#cloud-config")):
+		return "text/cloud-config"
+	case bytes.HasPrefix(data, []byte("#!")):
+		return "text/x-shellscript"
+	default:
+		return "text/plain"
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestGetSecretKeyData(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom-data", Namespace: "default"},
+		Data: map[string][]byte{
+			"value":      []byte("#cloud-config\n"),
+			"snippet.sh": []byte("#!/bin/sh\n"),
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(secret).Build()
+
+	tests := []struct {
+		name    string
+		ref     infrav1.SecretKeyReference
+		want    []byte
+		wantErr string
+	}{
+		{
+			name: "default key",
+			ref:  infrav1.SecretKeyReference{Name: "custom-data"},
+			want: []byte("#cloud-config\n"),
+		},
+		{
+			name: "key",
+			ref:  infrav1.SecretKeyReference{Name: "custom-data", Key: "snippet.sh"},
+			want: []byte("#!/bin/sh\n"),
+		},
+		{
+			name:    "missing key",
+			ref:     infrav1.SecretKeyReference{Name: "custom-data", Key: "other"},
+			wantErr: "secret default/custom-data has no key other",
+		},
+		{
+			name:    "missing secret",
+			ref:     infrav1.SecretKeyReference{Name: "other"},
+			wantErr: "failed to get secret default/other",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			data, err := getSecretKeyData(context.TODO(), fakeClient, "default", tc.ref)
+			if tc.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(data).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestMergeCustomData(t *testing.T) {
+	g := NewWithT(t)
+
+	bootstrapData := []byte("## template: jinja\n#cloud-config\nruncmd:\n  - kubeadm init\n")
+	additionalData := []byte("#cloud-config\nruncmd:\n  - echo done\n")
+
+	merged, err := mergeCustomData(bootstrapData, additionalData)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	again, err := mergeCustomData(bootstrapData, additionalData)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again).To(Equal(merged))
+
+	msg, err := mail.ReadMessage(bytes.NewReader(merged))
+	g.Expect(err).NotTo(HaveOccurred())
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mediaType).To(Equal("multipart/mixed"))
+
+	type part struct {
+		contentType string
+		mergeType   string
+		data        []byte
+	}
+	var parts []part
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		data, err := ioutil.ReadAll(p)
+		g.Expect(err).NotTo(HaveOccurred())
+		parts = append(parts, part{contentType: p.Header.Get("Content-Type"), mergeType: p.Header.Get("Merge-Type"), data: data})
+	}
+	g.Expect(parts).To(Equal([]part{
+		{contentType: `text/plain; charset="utf-8"`, data: bootstrapData},
+		{contentType: `text/cloud-config; charset="utf-8"`, mergeType: customDataMergeType, data: additionalData},
+	}))
+}
+
+func TestCustomDataContentType(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(customDataContentType([]byte("#cloud-config\n"))).To(Equal("text/cloud-config"))
+	g.Expect(customDataContentType([]byte("#!/bin/bash\n"))).To(Equal("text/x-shellscript"))
+	g.Expect(customDataContentType([]byte("## template: jinja\n#cloud-config\n"))).To(Equal("text/plain"))
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/azure/cli"
+	"github.com/pkg/errors"
+)
+
+// developerTokenRefreshWindow is how long before their expiry tokens of developer tools are renewed.
+const developerTokenRefreshWindow = 5 * time.Minute
+
+// developerCredentialsEnabled makes the controller fall back to the credentials of developer tools.
+var developerCredentialsEnabled bool
+
+// EnableDeveloperCredentials makes the controller fall back to the credentials of the Azure CLI and the Azure
+// Developer CLI of the user running it when no credentials are set in its environment. This is meant for running
+// the controller locally against a sandbox subscription and must not be enabled in production.
+func EnableDeveloperCredentials() {
+	developerCredentialsEnabled = true
+}
+
+// developerTokenSources are tried in order to acquire a token for a resource.
+var developerTokenSources = []func(resource string) (adal.Token, error){
+	azureCLIToken,
+	azureDeveloperCLIToken,
+}
+
+// environmentAuthorizer returns an authorizer with the credentials of the environment settings, falling back to
+// the credentials of developer tools if enabled and no credentials are set.
+func environmentAuthorizer(settings auth.EnvironmentSettings) (autorest.Authorizer, error) {
+	key, fingerprint := environmentAuthorizerKey(settings)
+	return authorizers.get(key, fingerprint, func() (autorest.Authorizer, error) {
+		if developerCredentialsEnabled && !hasEnvironmentCredentials(settings) {
+			return newDeveloperAuthorizer(settings.Values[auth.Resource])
+		}
+		return settings.GetAuthorizer()
+	})
+}
+
+// newEnvironmentAuthorizer returns an authorizer for a resource with the credentials of the controller environment.
+func newEnvironmentAuthorizer(resource string) (autorest.Authorizer, error) {
+	// The settings of the controller may reference a custom environment, which go-autorest does not know about.
+	settings, err := (&AzureClients{}).getSettingsFromEnvironment(os.Getenv(auth.EnvironmentName))
+	if err != nil {
+		return nil, err
+	}
+	settings.Values[auth.Resource] = resource
+	return environmentAuthorizer(settings)
+}
+
+// hasEnvironmentCredentials returns true if a client secret, client certificate or username and password is set.
+func hasEnvironmentCredentials(settings auth.EnvironmentSettings) bool {
+	if _, err := settings.GetClientCredentials(); err == nil {
+		return true
+	}
+	if _, err := settings.GetClientCertificate(); err == nil {
+		return true
+	}
+	_, err := settings.GetUsernamePassword()
+	return err == nil
+}
+
+// newDeveloperAuthorizer returns an authorizer with a token of the first developer tool the user is logged in with.
+func newDeveloperAuthorizer(resource string) (autorest.Authorizer, error) {
+	provider := &developerTokenProvider{resource: resource, sources: developerTokenSources}
+	if err := provider.RefreshWithContext(context.Background()); err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(provider), nil
+}
+
+// developerTokenProvider implements adal.OAuthTokenProvider and adal.RefresherWithContext by acquiring
+// tokens from developer tools, renewing them before they expire.
+type developerTokenProvider struct {
+	resource string
+	sources  []func(resource string) (adal.Token, error)
+
+	mu    sync.Mutex
+	token adal.Token
+}
+
+var _ adal.RefresherWithContext = (*developerTokenProvider)(nil)
+
+// OAuthToken implements the adal.OAuthTokenProvider interface.
+func (p *developerTokenProvider) OAuthToken() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token.AccessToken
+}
+
+// EnsureFreshWithContext renews the token if it expires soon.
+func (p *developerTokenProvider) EnsureFreshWithContext(ctx context.Context) error {
+	p.mu.Lock()
+	expiring := p.token.WillExpireIn(developerTokenRefreshWindow)
+	p.mu.Unlock()
+	if !expiring {
+		return nil
+	}
+	return p.RefreshWithContext(ctx)
+}
+
+// RefreshWithContext acquires a new token from the first developer tool that returns one.
+func (p *developerTokenProvider) RefreshWithContext(ctx context.Context) error {
+	return p.RefreshExchangeWithContext(ctx, p.resource)
+}
+
+// RefreshExchangeWithContext acquires a new token for a resource from the first developer tool that returns one.
+func (p *developerTokenProvider) RefreshExchangeWithContext(_ context.Context, resource string) error {
+	var failures []string
+	for _, source := range p.sources {
+		token, err := source(resource)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		p.mu.Lock()
+		p.token = token
+		p.resource = resource
+		p.mu.Unlock()
+		return nil
+	}
+	return errors.Errorf("failed to get token from developer tools: %s", strings.Join(failures, "; "))
+}
+
+// azureCLIToken returns a token of the user logged in with the Azure CLI.
+func azureCLIToken(resource string) (adal.Token, error) {
+	token, err := cli.GetTokenFromCLI(resource)
+	if err != nil {
+		return adal.Token{}, errors.Wrap(err, "Azure CLI")
+	}
+	adalToken, err := token.ToADALToken()
+	if err != nil {
+		return adal.Token{}, errors.Wrap(err, "Azure CLI")
+	}
+	return adalToken, nil
+}
+
+// azureDeveloperCLIToken returns a token of the user logged in with the Azure Developer CLI.
+func azureDeveloperCLIToken(resource string) (adal.Token, error) {
+	cmd := exec.Command("azd", "auth", "token", "--output", "json", "--scope", strings.TrimSuffix(resource, "/")+"/.default")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return adal.Token{}, errors.Errorf("Azure Developer CLI: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseAzureDeveloperCLIToken(output)
+}
+
+// parseAzureDeveloperCLIToken converts the output of `azd auth token` to an adal.Token.
+func parseAzureDeveloperCLIToken(output []byte) (adal.Token, error) {
+	token := struct {
+		Token     string    `json:"token"`
+		ExpiresOn time.Time `json:"expiresOn"`
+	}{}
+	if err := json.Unmarshal(output, &token); err != nil {
+		return adal.Token{}, errors.Wrap(err, "Azure Developer CLI: failed to decode token")
+	}
+	return adal.Token{
+		AccessToken: token.Token,
+		ExpiresOn:   json.Number(strconv.FormatInt(token.ExpiresOn.Unix(), 10)),
+		Type:        "Bearer",
+	}, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func fakeDeveloperToken(accessToken string, expiresIn time.Duration) adal.Token {
+	return adal.Token{
+		AccessToken: accessToken,
+		ExpiresOn:   json.Number(strconv.FormatInt(time.Now().Add(expiresIn).Unix(), 10)),
+	}
+}
+
+func TestDeveloperTokenProvider(t *testing.T) {
+	g := NewWithT(t)
+
+	var resources []string
+	calls := 0
+	provider := &developerTokenProvider{
+		resource: "https://management.azure.com/",
+		sources: []func(string) (adal.Token, error){
+			func(string) (adal.Token, error) {
+				return adal.Token{}, errors.New("Azure CLI: not logged in")
+			},
+			func(resource string) (adal.Token, error) {
+				calls++
+				resources = append(resources, resource)
+				return fakeDeveloperToken("token-"+strconv.Itoa(calls), time.Minute), nil
+			},
+		},
+	}
+
+	g.Expect(provider.EnsureFreshWithContext(context.Background())).To(Succeed())
+	g.Expect(provider.OAuthToken()).To(Equal("token-1"))
+	g.Expect(resources).To(Equal([]string{"https://management.azure.com/"}))
+
+	// The token expires within the refresh window, so it is renewed.
+	g.Expect(provider.EnsureFreshWithContext(context.Background())).To(Succeed())
+	g.Expect(provider.OAuthToken()).To(Equal("token-2"))
+
+	provider.token = fakeDeveloperToken("token-3", time.Hour)
+	g.Expect(provider.EnsureFreshWithContext(context.Background())).To(Succeed())
+	g.Expect(provider.OAuthToken()).To(Equal("token-3"))
+	g.Expect(calls).To(Equal(2))
+}
+
+func TestDeveloperTokenProviderFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	provider := &developerTokenProvider{
+		resource: "https://management.azure.com/",
+		sources: []func(string) (adal.Token, error){
+			func(string) (adal.Token, error) {
+				return adal.Token{}, errors.New("Azure CLI: not logged in")
+			},
+			func(string) (adal.Token, error) {
+				return adal.Token{}, errors.New("Azure Developer CLI: not logged in")
+			},
+		},
+	}
+	g.Expect(provider.RefreshWithContext(context.Background())).To(MatchError("failed to get token from developer tools: Azure CLI: not logged in; Azure Developer CLI: not logged in"))
+}
+
+func TestEnvironmentAuthorizer(t *testing.T) {
+	g := NewWithT(t)
+
+	developerCredentialsEnabled = true
+	developerTokenSources = []func(string) (adal.Token, error){
+		func(string) (adal.Token, error) {
+			return fakeDeveloperToken("developer-token", time.Hour), nil
+		},
+	}
+	defer func() {
+		developerCredentialsEnabled = false
+		developerTokenSources = []func(string) (adal.Token, error){azureCLIToken, azureDeveloperCLIToken}
+	}()
+
+	settings := auth.EnvironmentSettings{
+		Values:      map[string]string{auth.Resource: "https://management.azure.com/"},
+		Environment: azure.PublicCloud,
+	}
+	authorizer, err := environmentAuthorizer(settings)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(authorizer).To(BeAssignableToTypeOf(&autorest.BearerAuthorizer{}))
+	g.Expect(authorizer.(*autorest.BearerAuthorizer).TokenProvider().OAuthToken()).To(Equal("developer-token"))
+
+	// Credentials set in the environment take precedence over the ones of developer tools.
+	settings.Values[auth.TenantID] = "tenant"
+	settings.Values[auth.ClientID] = "client"
+	settings.Values[auth.ClientSecret] = "secret"
+	g.Expect(hasEnvironmentCredentials(settings)).To(BeTrue())
+	authorizer, err = environmentAuthorizer(settings)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(authorizer.(*autorest.BearerAuthorizer).TokenProvider()).To(BeAssignableToTypeOf(&adal.ServicePrincipalToken{}))
+}
+
+func TestParseAzureDeveloperCLIToken(t *testing.T) {
+	g := NewWithT(t)
+
+	token, err := parseAzureDeveloperCLIToken([]byte(`{"token":"access-token","expiresOn":"2021-08-01T10:00:00Z"}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(token.AccessToken).To(Equal("access-token"))
+	g.Expect(token.Expires()).To(Equal(time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)))
+
+	_, err = parseAzureDeveloperCLIToken([]byte(`ERROR: not logged in`))
+	g.Expect(err).To(HaveOccurred())
+}
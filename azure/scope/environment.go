@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+var (
+	customEnvironmentsMu sync.RWMutex
+	// customEnvironments holds the environments of Azure Stack Hub and other clouds with custom endpoints,
+	// keyed by their upper case name.
+	customEnvironments = map[string]azureautorest.Environment{}
+)
+
+// LoadCustomEnvironments reads the environments of clouds with custom endpoints from a ConfigMap. Every key of the
+// ConfigMap names an environment, and its value is the environment in the JSON format of go-autorest, i.e. the format of
+// the file referenced by AZURE_ENVIRONMENT_FILEPATH. AzureClusters select the environments by name in spec.azureEnvironment.
+func LoadCustomEnvironments(ctx context.Context, c corev1client.ConfigMapsGetter, namespace, name string) error {
+	configMap, err := c.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get ConfigMap %s/%s", namespace, name)
+	}
+	environments, err := parseCustomEnvironments(configMap.Data)
+	if err != nil {
+		return errors.Wrapf(err, "invalid ConfigMap %s/%s", namespace, name)
+	}
+
+	customEnvironmentsMu.Lock()
+	defer customEnvironmentsMu.Unlock()
+	customEnvironments = environments
+	return nil
+}
+
+// parseCustomEnvironments parses and validates the environments of a ConfigMap.
+func parseCustomEnvironments(data map[string]string) (map[string]azureautorest.Environment, error) {
+	environments := make(map[string]azureautorest.Environment, len(data))
+	for name, value := range data {
+		var env azureautorest.Environment
+		if err := json.Unmarshal([]byte(value), &env); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse environment %s", name)
+		}
+		if env.ResourceManagerEndpoint == "" || env.ActiveDirectoryEndpoint == "" {
+			return nil, errors.Errorf("environment %s must set resourceManagerEndpoint and activeDirectoryEndpoint", name)
+		}
+		// The name of the environment is reported to the cloud provider, so it must match the key it is selected by.
+		env.Name = name
+		environments[strings.ToUpper(name)] = env
+	}
+	return environments, nil
+}
+
+// environmentFromName returns the custom environment with the given name, falling back to the environments
+// known to go-autorest.
+func environmentFromName(name string) (azureautorest.Environment, error) {
+	customEnvironmentsMu.RLock()
+	env, ok := customEnvironments[strings.ToUpper(name)]
+	customEnvironmentsMu.RUnlock()
+	if ok {
+		return env, nil
+	}
+	return azureautorest.EnvironmentFromName(name)
+}
+
+// withARMClientOptions returns an environment with the Azure Resource Manager endpoint of the ARM client options.
+// The audience of the environment is kept, as tokens of a private endpoint are acquired for the public one.
+func withARMClientOptions(env azureautorest.Environment) azureautorest.Environment {
+	endpoint := azure.GetARMClientOptions().ResourceManagerEndpoint
+	if endpoint == "" {
+		return env
+	}
+	env.TokenAudience = resourceManagerAudience(env)
+	env.ResourceManagerEndpoint = endpoint
+	return env
+}
+
+// resourceManagerAudience returns the resource tokens for Azure Resource Manager are acquired for. It differs from the
+// endpoint of Azure Resource Manager in Azure Stack Hub.
+func resourceManagerAudience(env azureautorest.Environment) string {
+	if env.TokenAudience != "" {
+		return env.TokenAudience
+	}
+	return env.ResourceManagerEndpoint
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	capzazure "sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+const azureStackEnvironment = `{
+	"name": "ignored",
+	"resourceManagerEndpoint": "https://management.local.azurestack.external/",
+	"activeDirectoryEndpoint": "https://login.microsoftonline.com/",
+	"resourceManagerVMDNSSuffix": "cloudapp.local.azurestack.external",
+	"tokenAudience": "https://management.azurestackci.onmicrosoft.com/",
+	"resourceIdentifiers": {"graph": "https://graph.windows.net/"}
+}`
+
+func TestParseCustomEnvironments(t *testing.T) {
+	g := NewWithT(t)
+
+	environments, err := parseCustomEnvironments(map[string]string{"AzureStackCloud": azureStackEnvironment})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(environments).To(HaveKey("AZURESTACKCLOUD"))
+	env := environments["AZURESTACKCLOUD"]
+	g.Expect(env.Name).To(Equal("AzureStackCloud"))
+	g.Expect(env.ResourceManagerEndpoint).To(Equal("https://management.local.azurestack.external/"))
+	g.Expect(env.ResourceIdentifiers.Graph).To(Equal("https://graph.windows.net/"))
+
+	_, err = parseCustomEnvironments(map[string]string{"Broken": "{"})
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = parseCustomEnvironments(map[string]string{"Incomplete": `{"resourceManagerEndpoint": "https://management.local.azurestack.external/"}`})
+	g.Expect(err).To(MatchError("environment Incomplete must set resourceManagerEndpoint and activeDirectoryEndpoint"))
+}
+
+func TestLoadCustomEnvironments(t *testing.T) {
+	g := NewWithT(t)
+
+	defer func() { customEnvironments = map[string]azure.Environment{} }()
+
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "capz-system", Name: "azure-environments"},
+		Data:       map[string]string{"AzureStackCloud": azureStackEnvironment},
+	})
+	g.Expect(LoadCustomEnvironments(context.TODO(), clientset.CoreV1(), "capz-system", "missing")).To(HaveOccurred())
+	g.Expect(LoadCustomEnvironments(context.TODO(), clientset.CoreV1(), "capz-system", "azure-environments")).To(Succeed())
+
+	c := AzureClients{
+		Authorizer: autorest.NullAuthorizer{},
+	}
+	g.Expect(c.setCredentials("1234", "azurestackcloud")).To(Succeed())
+	g.Expect(c.CloudEnvironment()).To(Equal("AzureStackCloud"))
+	g.Expect(c.ResourceManagerEndpoint).To(Equal("https://management.local.azurestack.external/"))
+	g.Expect(c.ResourceManagerVMDNSSuffix).To(Equal("cloudapp.local.azurestack.external"))
+	g.Expect(c.Values[auth.Resource]).To(Equal("https://management.azurestackci.onmicrosoft.com/"))
+
+	// The environments known to go-autorest are still available.
+	g.Expect(c.setCredentials("1234", "AzureChinaCloud")).To(Succeed())
+	g.Expect(c.ResourceManagerEndpoint).To(Equal("https://management.chinacloudapi.cn/"))
+}
+
+func TestWithARMClientOptions(t *testing.T) {
+	g := NewWithT(t)
+	defer capzazure.SetARMClientOptions(capzazure.ARMClientOptions{})
+
+	g.Expect(withARMClientOptions(azure.PublicCloud)).To(Equal(azure.PublicCloud))
+
+	capzazure.SetARMClientOptions(capzazure.ARMClientOptions{ResourceManagerEndpoint: "https://arm.privatelink.example.com/"})
+	env := withARMClientOptions(azure.Environment{
+		Name:                    "Custom",
+		ResourceManagerEndpoint: "https://management.custom.example.com/",
+	})
+	g.Expect(env.ResourceManagerEndpoint).To(Equal("https://arm.privatelink.example.com/"))
+	// Tokens are still acquired for the endpoint of the environment.
+	g.Expect(resourceManagerAudience(env)).To(Equal("https://management.custom.example.com/"))
+}
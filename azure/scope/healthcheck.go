@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+// azureHealthCheckTimeout is how long the checks of all clouds may take.
+const azureHealthCheckTimeout = 30 * time.Second
+
+// AzureHealthChecker checks that the controller can obtain tokens from Azure Active Directory and reach Azure
+// Resource Manager in each cloud it is configured for, so that monitoring can tell Azure being unreachable or expired
+// credentials from the controller being down. The outcome of a check is reused for an interval, so probes don't send
+// requests to Azure every time.
+type AzureHealthChecker struct {
+	interval time.Duration
+	now      func() time.Time
+	check    func(ctx context.Context) error
+
+	lock      sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// NewAzureHealthChecker returns a checker checking Azure at most once per interval.
+func NewAzureHealthChecker(interval time.Duration) *AzureHealthChecker {
+	return &AzureHealthChecker{
+		interval: interval,
+		now:      time.Now,
+		check:    checkEnvironments,
+	}
+}
+
+// Check checks Azure, unless it was checked less than an interval ago. It is a healthz.Checker.
+func (c *AzureHealthChecker) Check(req *http.Request) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.checkedAt.IsZero() && c.now().Sub(c.checkedAt) < c.interval {
+		return c.err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), azureHealthCheckTimeout)
+	defer cancel()
+	c.err = c.check(ctx)
+	c.checkedAt = c.now()
+	return c.err
+}
+
+// checkEnvironments checks the environment of the controller, and the custom environments.
+func checkEnvironments(ctx context.Context) error {
+	var errs []error
+	for _, name := range configuredEnvironments() {
+		if err := checkEnvironment(ctx, name); err != nil {
+			if name == "" {
+				name = "AzurePublicCloud"
+			}
+			errs = append(errs, errors.Wrapf(err, "environment %s", name))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// configuredEnvironments returns the names of the environment of the controller and of the custom environments.
+func configuredEnvironments() []string {
+	names := []string{os.Getenv(auth.EnvironmentName)}
+	customEnvironmentsMu.RLock()
+	defer customEnvironmentsMu.RUnlock()
+	var custom []string
+	for _, env := range customEnvironments {
+		if !strings.EqualFold(env.Name, names[0]) {
+			custom = append(custom, env.Name)
+		}
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// checkEnvironment lists the subscriptions of the credentials of the controller in an environment, which requires a
+// token from Azure Active Directory and Azure Resource Manager to accept it. Without credentials, as when all clusters
+// reference identities, it only checks that Azure Resource Manager answers.
+func checkEnvironment(ctx context.Context, name string) error {
+	settings, err := (&AzureClients{}).getSettingsFromEnvironment(name)
+	if err != nil {
+		return err
+	}
+	var authorizer autorest.Authorizer = autorest.NullAuthorizer{}
+	authorized := developerCredentialsEnabled || hasEnvironmentCredentials(settings)
+	if authorized {
+		if authorizer, err = environmentAuthorizer(settings); err != nil {
+			return errors.Wrap(err, "failed to get the credentials of the controller")
+		}
+	}
+	client := autorest.NewClientWithUserAgent("")
+	azure.SetAutoRestClientDefaults(&client, authorizer)
+
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsGet(),
+		autorest.WithBaseURL(settings.Environment.ResourceManagerEndpoint),
+		autorest.WithPath("/subscriptions"),
+		autorest.WithQueryParameters(map[string]interface{}{"api-version": "2020-01-01"}),
+		client.WithAuthorization(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain a token from Azure Active Directory")
+	}
+	resp, err := client.Send(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reach Azure Resource Manager at %s", settings.Environment.ResourceManagerEndpoint)
+	}
+	defer resp.Body.Close()
+	if authorized && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+		return errors.Errorf("Azure Resource Manager rejected the token of the controller: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+)
+
+func TestAzureHealthChecker(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	checks := 0
+	checker := NewAzureHealthChecker(time.Minute)
+	checker.now = func() time.Time { return now }
+	checker.check = func(_ context.Context) error {
+		checks++
+		if checks == 1 {
+			return errors.New("token expired")
+		}
+		return nil
+	}
+	req := httptest.NewRequest(http.MethodGet, "/healthz/azure", nil)
+
+	// Checks are reused for an interval.
+	g.Expect(checker.Check(req)).To(MatchError("token expired"))
+	now = now.Add(30 * time.Second)
+	g.Expect(checker.Check(req)).To(MatchError("token expired"))
+	g.Expect(checks).To(Equal(1))
+
+	now = now.Add(30 * time.Second)
+	g.Expect(checker.Check(req)).To(Succeed())
+	g.Expect(checks).To(Equal(2))
+}
+
+func TestCheckEnvironment(t *testing.T) {
+	g := NewWithT(t)
+	defer func() { customEnvironments = map[string]azure.Environment{} }()
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		// Without credentials, Azure Resource Manager only needs to answer.
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	customEnvironments = map[string]azure.Environment{
+		"AZURESTACKCLOUD": {Name: "AzureStackCloud", ResourceManagerEndpoint: server.URL, ActiveDirectoryEndpoint: server.URL},
+	}
+
+	g.Expect(configuredEnvironments()).To(ContainElement("AzureStackCloud"))
+	g.Expect(checkEnvironment(context.Background(), "AzureStackCloud")).To(Succeed())
+	g.Expect(requested).To(Equal([]string{"/subscriptions"}))
+
+	server.Close()
+	g.Expect(checkEnvironment(context.Background(), "AzureStackCloud")).To(MatchError(ContainSubstring("failed to reach Azure Resource Manager")))
+}
@@ -19,6 +19,7 @@ package scope
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"reflect"
 
 	"github.com/Azure/go-autorest/autorest"
@@ -40,7 +41,9 @@ import (
 
 // CredentialsProvider defines the behavior for azure identity based credential providers.
 type CredentialsProvider interface {
-	GetAuthorizer(ctx context.Context, resourceManagerEndpoint string) (autorest.Authorizer, error)
+	GetAuthorizer(ctx context.Context, resourceManagerEndpoint, activeDirectoryEndpoint string) (autorest.Authorizer, error)
+	GetClientID() string
+	GetTenantID() string
 }
 
 // AzureCredentialsProvider represents a credential provider with azure cluster identity.
@@ -69,8 +72,11 @@ func NewAzureClusterCredentialsProvider(ctx context.Context, kubeClient client.C
 	if azureCluster.Spec.IdentityRef == nil {
 		return nil, errors.New("failed to generate new AzureClusterCredentialsProvider from empty identityName")
 	}
+	return newAzureClusterCredentialsProvider(ctx, kubeClient, azureCluster, azureCluster.Spec.IdentityRef)
+}
 
-	ref := azureCluster.Spec.IdentityRef
+// newAzureClusterCredentialsProvider creates a new AzureClusterCredentialsProvider for an identity of the AzureCluster.
+func newAzureClusterCredentialsProvider(ctx context.Context, kubeClient client.Client, azureCluster *infrav1.AzureCluster, ref *corev1.ObjectReference) (*AzureClusterCredentialsProvider, error) {
 	// if the namespace isn't specified then assume it's in the same namespace as the AzureCluster
 	namespace := ref.Namespace
 	if namespace == "" {
@@ -82,8 +88,8 @@ func NewAzureClusterCredentialsProvider(ctx context.Context, kubeClient client.C
 		return nil, errors.Errorf("failed to retrieve AzureClusterIdentity external object %q/%q: %v", key.Namespace, key.Name, err)
 	}
 
-	if identity.Spec.Type != infrav1.ServicePrincipal {
-		return nil, errors.New("AzureClusterIdentity is not of type Service Principal")
+	if err := validateIdentity(identity); err != nil {
+		return nil, err
 	}
 
 	return &AzureClusterCredentialsProvider{
@@ -96,8 +102,8 @@ func NewAzureClusterCredentialsProvider(ctx context.Context, kubeClient client.C
 }
 
 // GetAuthorizer returns an Azure authorizer based on the provided azure identity. It delegates to AzureCredentialsProvider with AzureCluster metadata.
-func (p *AzureClusterCredentialsProvider) GetAuthorizer(ctx context.Context, resourceManagerEndpoint string) (autorest.Authorizer, error) {
-	return p.AzureCredentialsProvider.GetAuthorizer(ctx, resourceManagerEndpoint, p.AzureCluster.ObjectMeta)
+func (p *AzureClusterCredentialsProvider) GetAuthorizer(ctx context.Context, resourceManagerEndpoint, activeDirectoryEndpoint string) (autorest.Authorizer, error) {
+	return p.AzureCredentialsProvider.GetAuthorizer(ctx, resourceManagerEndpoint, activeDirectoryEndpoint, p.AzureCluster.ObjectMeta)
 }
 
 // NewManagedControlPlaneCredentialsProvider creates a new ManagedControlPlaneCredentialsProvider from the supplied inputs.
@@ -118,8 +124,8 @@ func NewManagedControlPlaneCredentialsProvider(ctx context.Context, kubeClient c
 		return nil, errors.Errorf("failed to retrieve AzureClusterIdentity external object %q/%q: %v", key.Namespace, key.Name, err)
 	}
 
-	if identity.Spec.Type != infrav1.ServicePrincipal {
-		return nil, errors.New("AzureClusterIdentity is not of type Service Principal")
+	if err := validateIdentity(identity); err != nil {
+		return nil, err
 	}
 
 	return &ManagedControlPlaneCredentialsProvider{
@@ -132,12 +138,26 @@ func NewManagedControlPlaneCredentialsProvider(ctx context.Context, kubeClient c
 }
 
 // GetAuthorizer returns an Azure authorizer based on the provided azure identity. It delegates to AzureCredentialsProvider with AzureManagedControlPlane metadata.
-func (p *ManagedControlPlaneCredentialsProvider) GetAuthorizer(ctx context.Context, resourceManagerEndpoint string) (autorest.Authorizer, error) {
-	return p.AzureCredentialsProvider.GetAuthorizer(ctx, resourceManagerEndpoint, p.AzureManagedControlPlane.ObjectMeta)
+func (p *ManagedControlPlaneCredentialsProvider) GetAuthorizer(ctx context.Context, resourceManagerEndpoint, activeDirectoryEndpoint string) (autorest.Authorizer, error) {
+	return p.AzureCredentialsProvider.GetAuthorizer(ctx, resourceManagerEndpoint, activeDirectoryEndpoint, p.AzureManagedControlPlane.ObjectMeta)
 }
 
 // GetAuthorizer returns an Azure authorizer based on the provided azure identity and cluster metadata.
-func (p *AzureCredentialsProvider) GetAuthorizer(ctx context.Context, resourceManagerEndpoint string, clusterMeta metav1.ObjectMeta) (autorest.Authorizer, error) {
+func (p *AzureCredentialsProvider) GetAuthorizer(ctx context.Context, resourceManagerEndpoint, activeDirectoryEndpoint string, clusterMeta metav1.ObjectMeta) (autorest.Authorizer, error) {
+	// Workload identities and certificates acquire their tokens themselves and need no aad-pod-identity.
+	// Their authorizers are shared by all clusters using the identity.
+	key := fmt.Sprintf("identity/%s/%s/%s", p.Identity.Namespace, p.Identity.Name, resourceManagerEndpoint)
+	switch p.Identity.Spec.Type {
+	case infrav1.WorkloadIdentity:
+		return authorizers.get(key, fingerprint(p.Identity.Spec, activeDirectoryEndpoint), func() (autorest.Authorizer, error) {
+			return p.getWorkloadIdentityAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint)
+		})
+	case infrav1.ServicePrincipalCertificate:
+		return authorizers.get(key, fingerprint(p.Identity.Spec, activeDirectoryEndpoint), func() (autorest.Authorizer, error) {
+			return p.getKeyVaultCertificateAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint)
+		})
+	}
+
 	azureIdentityType, err := getAzureIdentityType(p.Identity)
 	if err != nil {
 		return nil, err
@@ -198,21 +218,135 @@ func (p *AzureCredentialsProvider) GetAuthorizer(ctx context.Context, resourceMa
 		return nil, errors.Errorf("failed to create AzureIdentityBinding %s in %s: %v", copiedIdentity.Name, system.GetManagerNamespace(), err)
 	}
 
-	var spt *adal.ServicePrincipalToken
 	msiEndpoint, err := adal.GetMSIVMEndpoint()
 	if err != nil {
 		return nil, errors.Errorf("failed to get MSI endpoint: %v", err)
 	}
-	if p.Identity.Spec.Type == infrav1.ServicePrincipal {
-		spt, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(msiEndpoint, resourceManagerEndpoint, p.Identity.Spec.ClientID)
+	if p.Identity.Spec.Type == infrav1.UserAssignedMSI {
+		return nil, errors.Errorf("UserAssignedMSI not supported: %v", err)
+	}
+
+	// aad-pod-identity reads the client secret whenever it acquires a token, so a rotated secret takes
+	// effect as soon as the authorizer is replaced rather than when its cached token expires.
+	secretVersion, err := p.clientSecretVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// aad-pod-identity serves the tokens of a client ID to the controller regardless of the cluster.
+	key = fmt.Sprintf("msi/%s/%s", p.Identity.Spec.ClientID, resourceManagerEndpoint)
+	return authorizers.get(key, fingerprint(msiEndpoint, secretVersion), func() (autorest.Authorizer, error) {
+		spt, err := adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(msiEndpoint, resourceManagerEndpoint, p.Identity.Spec.ClientID)
 		if err != nil {
 			return nil, errors.Errorf("failed to get token from service principal identity: %v", err)
 		}
-	} else if p.Identity.Spec.Type == infrav1.UserAssignedMSI {
-		return nil, errors.Errorf("UserAssignedMSI not supported: %v", err)
+		return autorest.NewBearerAuthorizer(spt), nil
+	})
+}
+
+// clientSecretVersion returns the resource version of the Secret holding the client secret of the identity,
+// which changes whenever the secret is rotated.
+func (p *AzureCredentialsProvider) clientSecretVersion(ctx context.Context) (string, error) {
+	ref := p.Identity.Spec.ClientSecret
+	if ref.Name == "" {
+		return "", nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = p.Identity.Namespace
+	}
+	secret := &corev1.Secret{}
+	if err := p.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return "", errors.Wrapf(err, "failed to get client secret %s/%s of AzureClusterIdentity", namespace, ref.Name)
+	}
+	return secret.ResourceVersion, nil
+}
+
+// newSecretAuthorizer returns an authorizer acquiring tokens of the identity with the given secret, in the
+// primary tenant of the identity and in all of its auxiliary tenants.
+func (p *AzureCredentialsProvider) newSecretAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint string, secret adal.ServicePrincipalSecret) (autorest.Authorizer, error) {
+	newToken := func(tenantID string) (*adal.ServicePrincipalToken, error) {
+		oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create OAuth config for tenant %s", tenantID)
+		}
+		spt, err := adal.NewServicePrincipalTokenWithSecret(*oauthConfig, p.Identity.Spec.ClientID, resourceManagerEndpoint, secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get token from %s identity in tenant %s", p.Identity.Spec.Type, tenantID)
+		}
+		return spt, nil
+	}
+
+	primary, err := newToken(p.Identity.Spec.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Identity.Spec.AuxiliaryTenantIDs) == 0 {
+		return autorest.NewBearerAuthorizer(primary), nil
+	}
+
+	// Tokens of the auxiliary tenants are sent along with the primary token in the
+	// x-ms-authorization-auxiliary header of every request.
+	mt := &adal.MultiTenantServicePrincipalToken{PrimaryToken: primary}
+	for _, tenantID := range p.Identity.Spec.AuxiliaryTenantIDs {
+		spt, err := newToken(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		mt.AuxiliaryTokens = append(mt.AuxiliaryTokens, spt)
+	}
+	return autorest.NewMultiTenantServicePrincipalTokenAuthorizer(mt), nil
+}
+
+// GetClientID returns the client ID of the identity.
+func (p *AzureCredentialsProvider) GetClientID() string {
+	return p.Identity.Spec.ClientID
+}
+
+// GetTenantID returns the tenant the identity acquires its primary token in.
+func (p *AzureCredentialsProvider) GetTenantID() string {
+	return p.Identity.Spec.TenantID
+}
+
+// validateIdentity returns an error if an AzureClusterIdentity can't be used by a credentials provider.
+func validateIdentity(identity *infrav1.AzureClusterIdentity) error {
+	switch identity.Spec.Type {
+	case infrav1.ServicePrincipal, infrav1.WorkloadIdentity:
+	case infrav1.ServicePrincipalCertificate:
+		if identity.Spec.KeyVaultCertificate == nil {
+			return errors.New("AzureClusterIdentity of type Service Principal Certificate requires a Key Vault certificate")
+		}
+	default:
+		return errors.New("AzureClusterIdentity is not of type Service Principal, Service Principal Certificate or Workload Identity")
+	}
+
+	// Tokens of aad-pod-identity are only valid in the primary tenant of the identity.
+	if len(identity.Spec.AuxiliaryTenantIDs) > 0 && identity.Spec.Type == infrav1.ServicePrincipal {
+		return errors.New("AzureClusterIdentity auxiliary tenants are not supported for Service Principal")
+	}
+
+	// Workload identities exchange tokens of service accounts of the controller, which any Azure AD application
+	// federated with them accepts, so they are only accepted where only administrators create identities.
+	if identity.Spec.Type == infrav1.WorkloadIdentity && identity.Namespace != system.GetManagerNamespace() {
+		return errors.Errorf("AzureClusterIdentity of type Workload Identity must be in namespace %s", system.GetManagerNamespace())
+	}
+
+	if settings := identity.Spec.WorkloadIdentity; settings != nil && identity.Spec.Type == infrav1.WorkloadIdentity {
+		if settings.TokenFilePath != "" && !filepath.IsAbs(settings.TokenFilePath) {
+			return errors.New("AzureClusterIdentity workload identity token file path must be absolute")
+		}
+		if settings.TokenFilePath != "" && settings.ServiceAccountName != "" {
+			return errors.New("AzureClusterIdentity workload identity token file path and service account name are mutually exclusive")
+		}
+		if settings.TokenRefreshInterval != nil && settings.TokenRefreshInterval.Duration < 0 {
+			return errors.New("AzureClusterIdentity workload identity token refresh interval must not be negative")
+		}
+		if err := validateWorkloadIdentitySettings(settings); err != nil {
+			return err
+		}
 	}
 
-	return autorest.NewBearerAuthorizer(spt), nil
+	return nil
 }
 
 func getAzureIdentityType(identity *infrav1.AzureClusterIdentity) (aadpodv1.IdentityType, error) {
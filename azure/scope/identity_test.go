@@ -27,6 +27,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -133,3 +134,41 @@ func TestAllowedNamespaces(t *testing.T) {
 		})
 	}
 }
+
+func TestClientSecretVersion(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-secret", Namespace: "default"},
+		Data:       map[string][]byte{"clientSecret": []byte("secret")},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(secret).Build()
+	provider := &AzureCredentialsProvider{
+		Client: fakeClient,
+		Identity: &infrav1.AzureClusterIdentity{
+			ObjectMeta: metav1.ObjectMeta{Name: "identity", Namespace: "default"},
+			Spec: infrav1.AzureClusterIdentitySpec{
+				Type:         infrav1.ServicePrincipal,
+				ClientSecret: corev1.SecretReference{Name: "client-secret"},
+			},
+		},
+	}
+
+	version, err := provider.clientSecretVersion(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(version).NotTo(BeEmpty())
+
+	// Rotating the secret changes its version, which replaces the cached authorizer.
+	g.Expect(fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+	secret.Data["clientSecret"] = []byte("rotated")
+	g.Expect(fakeClient.Update(context.TODO(), secret)).To(Succeed())
+	rotated, err := provider.clientSecretVersion(context.TODO())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotated).NotTo(Equal(version))
+
+	provider.Identity.Spec.ClientSecret = corev1.SecretReference{Name: "missing", Namespace: "other"}
+	_, err = provider.clientSecretVersion(context.TODO())
+	g.Expect(err).To(HaveOccurred())
+}
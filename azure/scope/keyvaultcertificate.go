@@ -0,0 +1,250 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+const (
+	// keyVaultCertificateRefreshInterval is how long a certificate read from Key Vault is used before the
+	// controller checks for a new version of it.
+	keyVaultCertificateRefreshInterval = 5 * time.Minute
+
+	pkcs12ContentType = "application/x-pkcs12"
+	pemContentType    = "application/x-pem-file"
+)
+
+// keyVaultSecretsClient reads the secrets backing Key Vault certificates.
+type keyVaultSecretsClient interface {
+	GetSecret(ctx context.Context, vaultBaseURL, secretName, secretVersion string) (keyvault.SecretBundle, error)
+}
+
+// newKeyVaultSecretsClient returns a client for the given vault authenticating with the credentials of the controller.
+var newKeyVaultSecretsClient = func(vaultURL string) (keyVaultSecretsClient, error) {
+	resource, err := keyVaultResource(vaultURL)
+	if err != nil {
+		return nil, err
+	}
+	authorizer, err := newEnvironmentAuthorizer(resource)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get authorizer for Key Vault")
+	}
+	client := keyvault.New()
+	azure.SetAutoRestClientDefaults(&client.Client, authorizer)
+	return client, nil
+}
+
+var (
+	keyVaultCertificatesMu sync.Mutex
+	// keyVaultCertificates caches the certificates of all identities, so they are not read from Key Vault
+	// on every reconciliation.
+	keyVaultCertificates = map[string]*keyVaultCertificateSecret{}
+)
+
+// keyVaultCertificateSecret implements adal.ServicePrincipalSecret by signing client assertions with the
+// latest version of a certificate in Key Vault.
+type keyVaultCertificateSecret struct {
+	client   keyVaultSecretsClient
+	vaultURL string
+	name     string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	version   string
+	refreshed time.Time
+	secret    *adal.ServicePrincipalCertificateSecret
+}
+
+var _ adal.ServicePrincipalSecret = (*keyVaultCertificateSecret)(nil)
+
+// getKeyVaultCertificateAuthorizer returns an authorizer authenticating the identity with its client certificate in Key Vault.
+func (p *AzureCredentialsProvider) getKeyVaultCertificateAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint string) (autorest.Authorizer, error) {
+	ref := p.Identity.Spec.KeyVaultCertificate
+	if ref == nil {
+		return nil, errors.New("failed to get token for service principal certificate: Key Vault certificate is not set")
+	}
+
+	secret, err := getKeyVaultCertificateSecret(ref.VaultURL, ref.CertificateName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.newSecretAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint, secret)
+}
+
+// getKeyVaultCertificateSecret returns the cached secret of a certificate, creating it on first use.
+func getKeyVaultCertificateSecret(vaultURL, name string) (*keyVaultCertificateSecret, error) {
+	keyVaultCertificatesMu.Lock()
+	defer keyVaultCertificatesMu.Unlock()
+
+	key := strings.TrimSuffix(vaultURL, "/") + "/" + name
+	if secret, ok := keyVaultCertificates[key]; ok {
+		return secret, nil
+	}
+
+	client, err := newKeyVaultSecretsClient(vaultURL)
+	if err != nil {
+		return nil, err
+	}
+	secret := &keyVaultCertificateSecret{
+		client:   client,
+		vaultURL: vaultURL,
+		name:     name,
+		now:      time.Now,
+	}
+	keyVaultCertificates[key] = secret
+	return secret, nil
+}
+
+// SetAuthenticationValues is a method of the interface adal.ServicePrincipalSecret.
+// It signs the client assertion with the current version of the certificate.
+func (s *keyVaultCertificateSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, v *url.Values) error {
+	secret, err := s.certificate(context.TODO())
+	if err != nil {
+		return err
+	}
+	return secret.SetAuthenticationValues(spt, v)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s *keyVaultCertificateSecret) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("marshalling keyVaultCertificateSecret is not supported")
+}
+
+// certificate returns the current version of the certificate, reading it from Key Vault when the refresh
+// interval has passed. A certificate that was read before keeps being used while Key Vault is unavailable.
+func (s *keyVaultCertificateSecret) certificate(ctx context.Context) (*adal.ServicePrincipalCertificateSecret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.secret != nil && s.now().Sub(s.refreshed) < keyVaultCertificateRefreshInterval {
+		return s.secret, nil
+	}
+
+	// The secret backing a certificate holds the certificate including its private key.
+	bundle, err := s.client.GetSecret(ctx, s.vaultURL, s.name, "")
+	if err != nil {
+		if s.secret != nil {
+			return s.secret, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get certificate %s from Key Vault %s", s.name, s.vaultURL)
+	}
+	s.refreshed = s.now()
+
+	version := to.String(bundle.ID)
+	if s.secret != nil && version == s.version {
+		return s.secret, nil
+	}
+
+	certificate, key, err := decodeKeyVaultCertificate(to.String(bundle.ContentType), to.String(bundle.Value))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode certificate %s from Key Vault %s", s.name, s.vaultURL)
+	}
+	s.version = version
+	s.secret = &adal.ServicePrincipalCertificateSecret{Certificate: certificate, PrivateKey: key}
+	return s.secret, nil
+}
+
+// decodeKeyVaultCertificate decodes the value of the secret backing a Key Vault certificate.
+func decodeKeyVaultCertificate(contentType, value string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	switch contentType {
+	case pkcs12ContentType:
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return adal.DecodePfxCertificateData(data, "")
+	case pemContentType:
+		return decodePEMCertificate([]byte(value))
+	default:
+		return nil, nil, errors.Errorf("unsupported content type %q", contentType)
+	}
+}
+
+// decodePEMCertificate returns the first certificate and the RSA private key in PEM encoded data.
+func decodePEMCertificate(data []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var certificate *x509.Certificate
+	var key *rsa.PrivateKey
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			if certificate != nil {
+				continue
+			}
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			certificate = c
+		case "PRIVATE KEY":
+			k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			rsaKey, ok := k.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, errors.New("private key is not an RSA key")
+			}
+			key = rsaKey
+		case "RSA PRIVATE KEY":
+			k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			key = k
+		}
+	}
+
+	if certificate == nil {
+		return nil, nil, errors.New("no certificate found")
+	}
+	if key == nil {
+		return nil, nil, errors.New("no private key found")
+	}
+	return certificate, key, nil
+}
+
+// keyVaultResource returns the resource Key Vault tokens are acquired for, derived from the DNS suffix of the vault,
+// e.g. https://vault.azure.net for https://myvault.vault.azure.net/.
+func keyVaultResource(vaultURL string) (string, error) {
+	u, err := url.Parse(vaultURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse Key Vault URL %s", vaultURL)
+	}
+	parts := strings.SplitN(u.Hostname(), ".", 2)
+	if u.Scheme != "https" || len(parts) != 2 {
+		return "", errors.Errorf("invalid Key Vault URL %s", vaultURL)
+	}
+	return "https://" + parts[1], nil
+}
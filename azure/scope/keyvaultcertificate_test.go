@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+type fakeKeyVaultSecretsClient struct {
+	bundle keyvault.SecretBundle
+	err    error
+	calls  int
+}
+
+func (f *fakeKeyVaultSecretsClient) GetSecret(_ context.Context, _, _, _ string) (keyvault.SecretBundle, error) {
+	f.calls++
+	return f.bundle, f.err
+}
+
+func fakePEMCertificate(t *testing.T, commonName string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestKeyVaultCertificateRotation(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	client := &fakeKeyVaultSecretsClient{
+		bundle: keyvault.SecretBundle{
+			ID:          to.StringPtr("https://vault.vault.azure.net/secrets/cert/v1"),
+			ContentType: to.StringPtr(pemContentType),
+			Value:       to.StringPtr(fakePEMCertificate(t, "v1")),
+		},
+	}
+	secret := &keyVaultCertificateSecret{
+		client:   client,
+		vaultURL: "https://vault.vault.azure.net/",
+		name:     "cert",
+		now:      func() time.Time { return now },
+	}
+
+	certificate, err := secret.certificate(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(certificate.Certificate.Subject.CommonName).To(Equal("v1"))
+
+	// The certificate is cached until the refresh interval passed.
+	client.bundle.ID = to.StringPtr("https://vault.vault.azure.net/secrets/cert/v2")
+	client.bundle.Value = to.StringPtr(fakePEMCertificate(t, "v2"))
+	certificate, err = secret.certificate(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(certificate.Certificate.Subject.CommonName).To(Equal("v1"))
+	g.Expect(client.calls).To(Equal(1))
+
+	// A rotated certificate is picked up after the refresh interval.
+	now = now.Add(keyVaultCertificateRefreshInterval)
+	certificate, err = secret.certificate(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(certificate.Certificate.Subject.CommonName).To(Equal("v2"))
+	g.Expect(client.calls).To(Equal(2))
+
+	// The last certificate keeps being used while Key Vault is unavailable.
+	now = now.Add(keyVaultCertificateRefreshInterval)
+	client.err = errors.New("service unavailable")
+	certificate, err = secret.certificate(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(certificate.Certificate.Subject.CommonName).To(Equal("v2"))
+}
+
+func TestKeyVaultCertificateErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		bundle        keyvault.SecretBundle
+		err           error
+		expectedError string
+	}{
+		{
+			name:          "Key Vault unavailable",
+			err:           errors.New("service unavailable"),
+			expectedError: "failed to get certificate cert from Key Vault https://vault.vault.azure.net/: service unavailable",
+		},
+		{
+			name:          "unsupported content type",
+			bundle:        keyvault.SecretBundle{ContentType: to.StringPtr("text/plain"), Value: to.StringPtr("secret")},
+			expectedError: `failed to decode certificate cert from Key Vault https://vault.vault.azure.net/: unsupported content type "text/plain"`,
+		},
+		{
+			name:          "empty certificate",
+			bundle:        keyvault.SecretBundle{ContentType: to.StringPtr(pemContentType), Value: to.StringPtr("")},
+			expectedError: "failed to decode certificate cert from Key Vault https://vault.vault.azure.net/: no certificate found",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			secret := &keyVaultCertificateSecret{
+				client:   &fakeKeyVaultSecretsClient{bundle: tc.bundle, err: tc.err},
+				vaultURL: "https://vault.vault.azure.net/",
+				name:     "cert",
+				now:      time.Now,
+			}
+			_, err := secret.certificate(context.Background())
+			g.Expect(err).To(MatchError(tc.expectedError))
+		})
+	}
+}
+
+func TestKeyVaultResource(t *testing.T) {
+	g := NewWithT(t)
+
+	resource, err := keyVaultResource("https://myvault.vault.azure.net/")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resource).To(Equal("https://vault.azure.net"))
+
+	resource, err = keyVaultResource("https://myvault.vault.usgovcloudapi.net")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resource).To(Equal("https://vault.usgovcloudapi.net"))
+
+	_, err = keyVaultResource("http://myvault")
+	g.Expect(err).To(HaveOccurred())
+}
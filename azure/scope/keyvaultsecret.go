@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// newKeyVaultSecretsClientWithAuthorizer returns a client for Key Vault secrets using the given authorizer.
+var newKeyVaultSecretsClientWithAuthorizer = func(authorizer autorest.Authorizer) keyVaultSecretsClient {
+	client := keyvault.New()
+	azure.SetAutoRestClientDefaults(&client.Client, authorizer)
+	return client
+}
+
+// GetKeyVaultSecret returns the value of a secret in Azure Key Vault, read with the credentials of the cluster.
+// The secret URL may reference a specific version of the secret, otherwise its latest version is returned.
+func (c *AzureClients) GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error) {
+	ctx, span := tele.Tracer().Start(ctx, "scope.AzureClients.GetKeyVaultSecret")
+	defer span.End()
+
+	vaultURL, name, version, err := parseKeyVaultSecretURL(secretURL)
+	if err != nil {
+		return "", err
+	}
+	if c.keyVaultAuthorizer == nil {
+		return "", errors.New("failed to get Key Vault secret: credentials are not set")
+	}
+
+	resource, err := keyVaultResource(vaultURL)
+	if err != nil {
+		return "", err
+	}
+	authorizer, err := c.keyVaultAuthorizer(ctx, resource)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get authorizer for Key Vault")
+	}
+
+	bundle, err := newKeyVaultSecretsClientWithAuthorizer(authorizer).GetSecret(ctx, vaultURL, name, version)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get secret %s from Key Vault %s", name, vaultURL)
+	}
+	if bundle.Value == nil {
+		return "", errors.Errorf("secret %s in Key Vault %s has no value", name, vaultURL)
+	}
+	return to.String(bundle.Value), nil
+}
+
+// parseKeyVaultSecretURL splits a secret URL like https://myvault.vault.azure.net/secrets/name/version into the
+// URL of the vault, the name of the secret and its optional version.
+func parseKeyVaultSecretURL(secretURL string) (vaultURL, name, version string, err error) {
+	u, err := url.Parse(secretURL)
+	if err != nil {
+		return "", "", "", errors.Wrapf(err, "failed to parse Key Vault secret URL %s", secretURL)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Scheme != "https" || u.Host == "" || len(parts) < 2 || len(parts) > 3 || parts[0] != "secrets" || parts[1] == "" {
+		return "", "", "", errors.Errorf("invalid Key Vault secret URL %s", secretURL)
+	}
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+	return "https://" + u.Host + "/", parts[1], version, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.1/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func TestParseKeyVaultSecretURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		vault   string
+		name    string
+		version string
+		wantErr bool
+	}{
+		{url: "https://my-vault.vault.azure.net/secrets/my-secret", vault: "https://my-vault.vault.azure.net/", name: "my-secret"},
+		{url: "https://my-vault.vault.azure.net/secrets/my-secret/", vault: "https://my-vault.vault.azure.net/", name: "my-secret"},
+		{url: "https://my-vault.vault.azure.net/secrets/my-secret/0123abcd", vault: "https://my-vault.vault.azure.net/", name: "my-secret", version: "0123abcd"},
+		{url: "http://my-vault.vault.azure.net/secrets/my-secret", wantErr: true},
+		{url: "https://my-vault.vault.azure.net/keys/my-key", wantErr: true},
+		{url: "https://my-vault.vault.azure.net/secrets/", wantErr: true},
+		{url: "https://my-vault.vault.azure.net/secrets/my-secret/0123abcd/extra", wantErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.url, func(t *testing.T) {
+			g := NewWithT(t)
+			vault, name, version, err := parseKeyVaultSecretURL(tc.url)
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(vault).To(Equal(tc.vault))
+			g.Expect(name).To(Equal(tc.name))
+			g.Expect(version).To(Equal(tc.version))
+		})
+	}
+}
+
+func TestGetKeyVaultSecret(t *testing.T) {
+	g := NewWithT(t)
+
+	client := &fakeKeyVaultSecretsClient{bundle: keyvault.SecretBundle{Value: to.StringPtr("ssh-rsa AAAA")}}
+	original := newKeyVaultSecretsClientWithAuthorizer
+	defer func() { newKeyVaultSecretsClientWithAuthorizer = original }()
+	newKeyVaultSecretsClientWithAuthorizer = func(_ autorest.Authorizer) keyVaultSecretsClient {
+		return client
+	}
+
+	c := &AzureClients{}
+	_, err := c.GetKeyVaultSecret(context.TODO(), "https://my-vault.vault.azure.net/secrets/ssh-key")
+	g.Expect(err).To(MatchError("failed to get Key Vault secret: credentials are not set"))
+
+	var resource string
+	c.keyVaultAuthorizer = func(_ context.Context, r string) (autorest.Authorizer, error) {
+		resource = r
+		return autorest.NullAuthorizer{}, nil
+	}
+	value, err := c.GetKeyVaultSecret(context.TODO(), "https://my-vault.vault.azure.net/secrets/ssh-key")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(value).To(Equal("ssh-rsa AAAA"))
+	g.Expect(resource).To(Equal("https://vault.azure.net"))
+
+	client.err = errors.New("forbidden")
+	_, err = c.GetKeyVaultSecret(context.TODO(), "https://my-vault.vault.azure.net/secrets/ssh-key")
+	g.Expect(err).To(MatchError("failed to get secret ssh-key from Key Vault https://my-vault.vault.azure.net/: forbidden"))
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+var (
+	longRunningOperationsDesc = prometheus.NewDesc(
+		"capz_long_running_operations",
+		"Number of long running operations of Azure Resource Manager in flight, by cluster, service and type of operation.",
+		[]string{"namespace", "cluster", "service", "type"}, nil,
+	)
+	oldestLongRunningOperationDesc = prometheus.NewDesc(
+		"capz_long_running_operation_oldest_age_seconds",
+		"Age of the oldest long running operation of Azure Resource Manager in flight, by cluster, service and type of operation.",
+		[]string{"namespace", "cluster", "service", "type"}, nil,
+	)
+	stuckLongRunningOperationsDesc = prometheus.NewDesc(
+		"capz_long_running_operations_stuck",
+		"Number of long running operations of Azure Resource Manager in flight for longer than the warning age, by cluster, service and type of operation.",
+		[]string{"namespace", "cluster", "service", "type"}, nil,
+	)
+	longRunningOperationAgeDesc = prometheus.NewDesc(
+		"capz_long_running_operation_age_seconds",
+		"Age of the long running operation of Azure Resource Manager in flight for an object, by object, service and type of operation.",
+		[]string{"namespace", "cluster", "kind", "name", "service", "type"}, nil,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(inFlightOperations)
+}
+
+// longRunningOperation is a long running operation in flight for an object.
+type longRunningOperation struct {
+	namespace     string
+	cluster       string
+	kind          string
+	name          string
+	service       string
+	operationType string
+	startTime     time.Time
+}
+
+// longRunningOperationGroup is a cluster, service and type of operation the long running operations are summarized
+// by.
+type longRunningOperationGroup struct {
+	namespace     string
+	cluster       string
+	service       string
+	operationType string
+}
+
+// longRunningOperationSummary summarizes the long running operations of a type in flight for a service of a cluster.
+type longRunningOperationSummary struct {
+	count     int
+	stuck     int
+	oldestAge time.Duration
+}
+
+// longRunningOperations tracks the long running operations in flight for all objects, by kind, namespace and name.
+type longRunningOperations struct {
+	lock       sync.Mutex
+	operations map[string]longRunningOperation
+	now        func() time.Time
+}
+
+func newLongRunningOperations() *longRunningOperations {
+	return &longRunningOperations{
+		operations: make(map[string]longRunningOperation),
+		now:        time.Now,
+	}
+}
+
+// inFlightOperations is shared by all scopes, so the operations can be summarized per cluster.
+var inFlightOperations = newLongRunningOperations()
+
+// set tracks the long running operation of an object, or stops tracking it if nil.
+func (o *longRunningOperations) set(key string, operation *longRunningOperation) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if operation == nil {
+		delete(o.operations, key)
+		return
+	}
+	o.operations[key] = *operation
+}
+
+// inFlight returns the operations in flight. Operations older than the maximum age, e.g. of objects deleted without
+// clearing their operation, are dropped.
+func (o *longRunningOperations) inFlight() []longRunningOperation {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	operations := make([]longRunningOperation, 0, len(o.operations))
+	for key, operation := range o.operations {
+		if o.now().Sub(operation.startTime) > reconciler.LongRunningOperationMaxAge() {
+			delete(o.operations, key)
+			continue
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// summary returns the operations in flight by namespace, cluster, service and type of operation.
+func (o *longRunningOperations) summary() map[longRunningOperationGroup]longRunningOperationSummary {
+	summaries := map[longRunningOperationGroup]longRunningOperationSummary{}
+	for _, operation := range o.inFlight() {
+		age := o.now().Sub(operation.startTime)
+		group := longRunningOperationGroup{namespace: operation.namespace, cluster: operation.cluster, service: operation.service, operationType: operation.operationType}
+		summary := summaries[group]
+		summary.count++
+		if age > reconciler.LongRunningOperationWarningAge() {
+			summary.stuck++
+		}
+		if age > summary.oldestAge {
+			summary.oldestAge = age
+		}
+		summaries[group] = summary
+	}
+	return summaries
+}
+
+// Describe implements prometheus.Collector.
+func (o *longRunningOperations) Describe(ch chan<- *prometheus.Desc) {
+	ch <- longRunningOperationsDesc
+	ch <- oldestLongRunningOperationDesc
+	ch <- stuckLongRunningOperationsDesc
+	ch <- longRunningOperationAgeDesc
+}
+
+// Collect implements prometheus.Collector.
+func (o *longRunningOperations) Collect(ch chan<- prometheus.Metric) {
+	for group, summary := range o.summary() {
+		ch <- prometheus.MustNewConstMetric(longRunningOperationsDesc, prometheus.GaugeValue, float64(summary.count), group.namespace, group.cluster, group.service, group.operationType)
+		ch <- prometheus.MustNewConstMetric(oldestLongRunningOperationDesc, prometheus.GaugeValue, summary.oldestAge.Seconds(), group.namespace, group.cluster, group.service, group.operationType)
+		ch <- prometheus.MustNewConstMetric(stuckLongRunningOperationsDesc, prometheus.GaugeValue, float64(summary.stuck), group.namespace, group.cluster, group.service, group.operationType)
+	}
+	for _, operation := range o.inFlight() {
+		ch <- prometheus.MustNewConstMetric(longRunningOperationAgeDesc, prometheus.GaugeValue, o.now().Sub(operation.startTime).Seconds(),
+			operation.namespace, operation.cluster, operation.kind, operation.name, operation.service, operation.operationType)
+	}
+}
+
+// trackLongRunningOperation returns the long running operation of a service to keep on an object. An operation without
+// a start time starts now, and an operation older than the maximum age is dropped, so the resource is reconciled from
+// its current state rather than waiting for an operation which may never complete. The operations kept are tracked for
+// the summary of their cluster.
+func trackLongRunningOperation(logger logr.Logger, kind string, obj metav1.Object, cluster, service string, future *infrav1.Future) *infrav1.Future {
+	key := kind + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	if future == nil {
+		inFlightOperations.set(key, nil)
+		return nil
+	}
+
+	if future.StartTime == nil {
+		now := metav1.NewTime(inFlightOperations.now())
+		future.StartTime = &now
+	}
+	if age := inFlightOperations.now().Sub(future.StartTime.Time); age > reconciler.LongRunningOperationMaxAge() {
+		logger.Info("Dropping long running operation older than the maximum age", "type", future.Type, "resource", future.Name, "age", age.Round(time.Second))
+		inFlightOperations.set(key, nil)
+		return nil
+	}
+
+	inFlightOperations.set(key, &longRunningOperation{
+		namespace:     obj.GetNamespace(),
+		cluster:       cluster,
+		kind:          kind,
+		name:          obj.GetName(),
+		service:       service,
+		operationType: future.Type,
+		startTime:     future.StartTime.Time,
+	})
+	return future
+}
+
+// LongRunningOperationStuck returns the age of a long running operation stored on an object, and whether it is older
+// than the warning age, in which case it should be reported as stuck.
+func LongRunningOperationStuck(future *infrav1.Future) (time.Duration, bool) {
+	if future == nil || future.StartTime == nil {
+		return 0, false
+	}
+	age := inFlightOperations.now().Sub(future.StartTime.Time)
+	return age, age > reconciler.LongRunningOperationWarningAge()
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+func TestTrackLongRunningOperation(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	operations := newLongRunningOperations()
+	operations.now = func() time.Time { return now }
+	defer func(previous *longRunningOperations) { inFlightOperations = previous }(inFlightOperations)
+	inFlightOperations = operations
+
+	reconciler.SetLongRunningOperationMaxAge(time.Hour)
+	defer reconciler.SetLongRunningOperationMaxAge(0)
+	reconciler.SetLongRunningOperationWarningAge(20 * time.Minute)
+	defer reconciler.SetLongRunningOperationWarningAge(0)
+
+	pool := &infrav1exp.AzureMachinePool{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pool-0"}}
+	machine := &infrav1exp.AzureMachinePoolMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pool-0-machine"}}
+
+	// New operations start now.
+	future := trackLongRunningOperation(klogr.New(), "AzureMachinePool", pool, "my-cluster", "scalesets", &infrav1.Future{Type: "PUT", Name: "pool-0"})
+	g.Expect(future).NotTo(BeNil())
+	g.Expect(future.StartTime.Time).To(BeTemporally("==", now))
+	_, stuck := LongRunningOperationStuck(future)
+	g.Expect(stuck).To(BeFalse())
+
+	now = now.Add(30 * time.Minute)
+	g.Expect(trackLongRunningOperation(klogr.New(), "AzureMachinePool", pool, "my-cluster", "scalesets", future)).To(Equal(future))
+	age, stuck := LongRunningOperationStuck(future)
+	g.Expect(stuck).To(BeTrue())
+	g.Expect(age).To(Equal(30 * time.Minute))
+	g.Expect(trackLongRunningOperation(klogr.New(), "AzureMachinePoolMachine", machine, "my-cluster", "scalesetvms", &infrav1.Future{Type: "DELETE", Name: "pool-0"})).NotTo(BeNil())
+	g.Expect(operations.summary()).To(Equal(map[longRunningOperationGroup]longRunningOperationSummary{
+		{namespace: "default", cluster: "my-cluster", service: "scalesets", operationType: "PUT"}:      {count: 1, stuck: 1, oldestAge: 30 * time.Minute},
+		{namespace: "default", cluster: "my-cluster", service: "scalesetvms", operationType: "DELETE"}: {count: 1},
+	}))
+
+	g.Expect(testutil.CollectAndCount(operations, "capz_long_running_operation_age_seconds")).To(Equal(2))
+	g.Expect(testutil.CollectAndCount(operations, "capz_long_running_operations_stuck")).To(Equal(2))
+
+	// Operations older than the maximum age are dropped.
+	now = now.Add(31 * time.Minute)
+	g.Expect(trackLongRunningOperation(klogr.New(), "AzureMachinePool", pool, "my-cluster", "scalesets", future)).To(BeNil())
+	g.Expect(operations.summary()).To(Equal(map[longRunningOperationGroup]longRunningOperationSummary{
+		{namespace: "default", cluster: "my-cluster", service: "scalesetvms", operationType: "DELETE"}: {count: 1, stuck: 1, oldestAge: 31 * time.Minute},
+	}))
+
+	// Operations of objects which are gone are dropped from the summary once they are older than the maximum age.
+	now = now.Add(30 * time.Minute)
+	g.Expect(operations.summary()).To(BeEmpty())
+	g.Expect(trackLongRunningOperation(klogr.New(), "AzureMachinePoolMachine", machine, "my-cluster", "scalesetvms", nil)).To(BeNil())
+}
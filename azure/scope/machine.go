@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
@@ -93,31 +94,73 @@ type MachineScope struct {
 
 // VMSpec returns the VM spec.
 func (m *MachineScope) VMSpec() azure.VMSpec {
+	identity, userAssignedIdentities := vmIdentity(m.AzureMachine.Spec.Identity, m.AzureMachine.Spec.UserAssignedIdentities, m.CloudProviderIdentityID())
 	return azure.VMSpec{
 		Name:                   m.Name(),
 		Role:                   m.Role(),
 		NICNames:               m.NICNames(),
+		OSDiskName:             m.OSDiskName(),
 		SSHKeyData:             m.AzureMachine.Spec.SSHPublicKey,
+		SSHKeySecretURL:        keyVaultSecretURL(m.AzureMachine.Spec.SSHPublicKeySecret),
+		AdminPasswordSecretURL: keyVaultSecretURL(m.AzureMachine.Spec.AdminPasswordSecret),
 		Size:                   m.AzureMachine.Spec.VMSize,
 		OSDisk:                 m.AzureMachine.Spec.OSDisk,
 		DataDisks:              m.AzureMachine.Spec.DataDisks,
 		Zone:                   m.AvailabilityZone(),
-		Identity:               m.AzureMachine.Spec.Identity,
-		UserAssignedIdentities: m.AzureMachine.Spec.UserAssignedIdentities,
+		Identity:               identity,
+		UserAssignedIdentities: userAssignedIdentities,
 		SpotVMOptions:          m.AzureMachine.Spec.SpotVMOptions,
 		SecurityProfile:        m.AzureMachine.Spec.SecurityProfile,
 	}
 }
 
-// TagsSpecs returns the tags for the AzureMachine.
+// vmIdentity returns the identity of a VM or VMSS. Machines without an identity of their own use the
+// user-assigned identity of the cloud provider, if the cluster has one.
+func vmIdentity(identity infrav1.VMIdentity, userAssignedIdentities []infrav1.UserAssignedIdentity, cloudProviderIdentityID string) (infrav1.VMIdentity, []infrav1.UserAssignedIdentity) {
+	if cloudProviderIdentityID == "" || (identity != "" && identity != infrav1.VMIdentityNone) {
+		return identity, userAssignedIdentities
+	}
+	return infrav1.VMIdentityUserAssigned, []infrav1.UserAssignedIdentity{{ProviderID: azure.ProviderIDPrefix + cloudProviderIdentityID}}
+}
+
+// keyVaultSecretURL returns the URL of a referenced Key Vault secret, or an empty string if there is no reference.
+func keyVaultSecretURL(ref *infrav1.KeyVaultSecretReference) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.SecretURL
+}
+
+// TagsSpecs returns the tags for the VM and the network interfaces of the AzureMachine.
 func (m *MachineScope) TagsSpecs() []azure.TagsSpec {
-	return []azure.TagsSpec{
+	specs := []azure.TagsSpec{
 		{
 			Scope:      azure.VMID(m.SubscriptionID(), m.ResourceGroup(), m.Name()),
 			Tags:       m.AdditionalTags(),
 			Annotation: infrav1.VMTagsLastAppliedAnnotation,
 		},
 	}
+	for _, nic := range m.NICSpecs() {
+		specs = append(specs, azure.TagsSpec{
+			Scope:      azure.NetworkInterfaceID(m.SubscriptionID(), m.ResourceGroup(), nic.Name),
+			Tags:       m.AdditionalTags(),
+			Annotation: infrav1.NICTagsLastAppliedAnnotation,
+		})
+	}
+	return specs
+}
+
+// RetainedResourceIDs returns the IDs of the VM, network interfaces and public IPs of the AzureMachine, which are
+// tagged as retained when the AzureMachine is deleted with the Retain deletion policy.
+func (m *MachineScope) RetainedResourceIDs() []string {
+	ids := []string{azure.VMID(m.SubscriptionID(), m.ResourceGroup(), m.Name())}
+	for _, nic := range m.NICSpecs() {
+		ids = append(ids, azure.NetworkInterfaceID(m.SubscriptionID(), m.ResourceGroup(), nic.Name))
+	}
+	for _, ip := range m.PublicIPSpecs() {
+		ids = append(ids, azure.PublicIPID(m.SubscriptionID(), m.ResourceGroup(), ip.Name))
+	}
+	return ids
 }
 
 // PublicIPSpecs returns the public IP specs.
@@ -125,7 +168,7 @@ func (m *MachineScope) PublicIPSpecs() []azure.PublicIPSpec {
 	var spec []azure.PublicIPSpec
 	if m.AzureMachine.Spec.AllocatePublicIP {
 		spec = append(spec, azure.PublicIPSpec{
-			Name: azure.GenerateNodePublicIPName(m.Name()),
+			Name: m.NodePublicIPName(),
 		})
 	}
 	return spec
@@ -147,7 +190,7 @@ func (m *MachineScope) InboundNatSpecs() []azure.InboundNatSpec {
 // NICSpecs returns the network interface specs.
 func (m *MachineScope) NICSpecs() []azure.NICSpec {
 	spec := azure.NICSpec{
-		Name:                    azure.GenerateNICName(m.Name()),
+		Name:                    m.NICName(),
 		MachineName:             m.Name(),
 		VNetName:                m.Vnet().Name,
 		VNetResourceGroup:       m.Vnet().ResourceGroup,
@@ -171,12 +214,12 @@ func (m *MachineScope) NICSpecs() []azure.NICSpec {
 	specs := []azure.NICSpec{spec}
 	if m.AzureMachine.Spec.AllocatePublicIP {
 		specs = append(specs, azure.NICSpec{
-			Name:                  azure.GeneratePublicNICName(m.Name()),
+			Name:                  m.PublicNICName(),
 			MachineName:           m.Name(),
 			VNetName:              m.Vnet().Name,
 			VNetResourceGroup:     m.Vnet().ResourceGroup,
 			SubnetName:            m.Subnet().Name,
-			PublicIPName:          azure.GenerateNodePublicIPName(m.Name()),
+			PublicIPName:          m.NodePublicIPName(),
 			VMSize:                m.AzureMachine.Spec.VMSize,
 			AcceleratedNetworking: m.AzureMachine.Spec.AcceleratedNetworking,
 		})
@@ -185,6 +228,31 @@ func (m *MachineScope) NICSpecs() []azure.NICSpec {
 	return specs
 }
 
+// NICName returns the name of the network interface of the machine.
+func (m *MachineScope) NICName() string {
+	return m.resourceName(infrav1.NetworkInterfaceKind, azure.GenerateNICName(m.Name()))
+}
+
+// PublicNICName returns the name of the network interface of the public IP of the machine.
+func (m *MachineScope) PublicNICName() string {
+	return m.resourceName(infrav1.PublicNetworkInterfaceKind, azure.GeneratePublicNICName(m.Name()))
+}
+
+// NodePublicIPName returns the name of the public IP of the machine.
+func (m *MachineScope) NodePublicIPName() string {
+	return m.resourceName(infrav1.NodePublicIPKind, azure.GenerateNodePublicIPName(m.Name()))
+}
+
+// OSDiskName returns the name of the OS disk of the machine.
+func (m *MachineScope) OSDiskName() string {
+	return m.resourceName(infrav1.OSDiskKind, azure.GenerateOSDiskName(m.Name()))
+}
+
+// resourceName returns the name of a resource of the machine of a kind, following the naming of the cluster.
+func (m *MachineScope) resourceName(kind infrav1.AzureResourceKind, defaultName string) string {
+	return m.AzureResourceNaming().ResourceName(kind, defaultName, m.ClusterName(), m.Name())
+}
+
 // NICNames returns the NIC names.
 func (m *MachineScope) NICNames() []string {
 	nicNames := make([]string, len(m.NICSpecs()))
@@ -198,7 +266,7 @@ func (m *MachineScope) NICNames() []string {
 func (m *MachineScope) DiskSpecs() []azure.DiskSpec {
 	disks := make([]azure.DiskSpec, 1+len(m.AzureMachine.Spec.DataDisks))
 	disks[0] = azure.DiskSpec{
-		Name: azure.GenerateOSDiskName(m.Name()),
+		Name: m.OSDiskName(),
 	}
 
 	for i, dd := range m.AzureMachine.Spec.DataDisks {
@@ -248,18 +316,18 @@ func (m *MachineScope) Subnet() infrav1.SubnetSpec {
 	return m.NodeSubnet()
 }
 
-// AvailabilityZone returns the AzureMachine Availability Zone.
+// AvailabilityZone returns the AzureMachine Availability Zone, mapping custom failure domain names to their zone.
 // Priority for selecting the AZ is
 //   1) Machine.Spec.FailureDomain
 //   2) AzureMachine.Spec.FailureDomain (This is to support deprecated AZ)
 //   3) No AZ
 func (m *MachineScope) AvailabilityZone() string {
 	if m.Machine.Spec.FailureDomain != nil {
-		return *m.Machine.Spec.FailureDomain
+		return m.FailureDomainZone(*m.Machine.Spec.FailureDomain)
 	}
 	// DEPRECATED: to support old clients
 	if m.AzureMachine.Spec.FailureDomain != nil {
-		return *m.AzureMachine.Spec.FailureDomain
+		return m.FailureDomainZone(*m.AzureMachine.Spec.FailureDomain)
 	}
 
 	return ""
@@ -423,6 +491,19 @@ func (m *MachineScope) SetAnnotation(key, value string) {
 	m.AzureMachine.Annotations[key] = value
 }
 
+// BootDiagnosticsRequested returns true if SAS URLs of the boot diagnostics of the VM were requested.
+func (m *MachineScope) BootDiagnosticsRequested() bool {
+	_, ok := m.AzureMachine.Annotations[infrav1.BootDiagnosticsAnnotation]
+	return ok
+}
+
+// SetBootDiagnostics records the SAS URLs of the boot diagnostics of the VM in an event and removes the request.
+func (m *MachineScope) SetBootDiagnostics(serialConsoleLogURL, screenshotURL string, expiration time.Duration) {
+	record.Eventf(m.AzureMachine, "BootDiagnostics", "Boot diagnostics of VM %s, valid for %s: serial log %s, screenshot %s",
+		m.Name(), expiration, serialConsoleLogURL, screenshotURL)
+	delete(m.AzureMachine.Annotations, infrav1.BootDiagnosticsAnnotation)
+}
+
 // AnnotationJSON returns a map[string]interface from a JSON annotation.
 func (m *MachineScope) AnnotationJSON(annotation string) (map[string]interface{}, error) {
 	out := map[string]interface{}{}
@@ -509,6 +590,16 @@ func (m *MachineScope) GetBootstrapData(ctx context.Context) (string, error) {
 	if !ok {
 		return "", errors.New("error retrieving bootstrap data: secret value key is missing")
 	}
+
+	if ref := m.AzureMachine.Spec.AdditionalCustomDataSecret; ref != nil {
+		additionalData, err := getSecretKeyData(ctx, m.client, m.Namespace(), *ref)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retrieve additional custom data")
+		}
+		if value, err = mergeCustomData(value, additionalData); err != nil {
+			return "", errors.Wrap(err, "failed to merge additional custom data")
+		}
+	}
 	return base64.StdEncoding.EncodeToString(value), nil
 }
 
@@ -17,13 +17,17 @@ limitations under the License.
 package scope
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Azure/go-autorest/autorest/to"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
@@ -223,3 +227,171 @@ func TestMachineScope_ProviderID(t *testing.T) {
 		})
 	}
 }
+
+func TestVMIdentity(t *testing.T) {
+	cloudProviderIdentityID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-cluster-cloud-provider"
+	ownIdentities := []infrav1.UserAssignedIdentity{{ProviderID: "azure:///subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/my-identity"}}
+	tests := []struct {
+		name                       string
+		identity                   infrav1.VMIdentity
+		userAssignedIdentities     []infrav1.UserAssignedIdentity
+		cloudProviderIdentityID    string
+		wantIdentity               infrav1.VMIdentity
+		wantUserAssignedIdentities []infrav1.UserAssignedIdentity
+	}{
+		{
+			name:         "no identity without cloud provider identity",
+			identity:     infrav1.VMIdentityNone,
+			wantIdentity: infrav1.VMIdentityNone,
+		},
+		{
+			name:                       "cloud provider identity for machine without identity",
+			identity:                   infrav1.VMIdentityNone,
+			cloudProviderIdentityID:    cloudProviderIdentityID,
+			wantIdentity:               infrav1.VMIdentityUserAssigned,
+			wantUserAssignedIdentities: []infrav1.UserAssignedIdentity{{ProviderID: "azure://" + cloudProviderIdentityID}},
+		},
+		{
+			name:                    "system-assigned identity of machine",
+			identity:                infrav1.VMIdentitySystemAssigned,
+			cloudProviderIdentityID: cloudProviderIdentityID,
+			wantIdentity:            infrav1.VMIdentitySystemAssigned,
+		},
+		{
+			name:                       "user-assigned identities of machine",
+			identity:                   infrav1.VMIdentityUserAssigned,
+			userAssignedIdentities:     ownIdentities,
+			cloudProviderIdentityID:    cloudProviderIdentityID,
+			wantIdentity:               infrav1.VMIdentityUserAssigned,
+			wantUserAssignedIdentities: ownIdentities,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, userAssignedIdentities := vmIdentity(tt.identity, tt.userAssignedIdentities, tt.cloudProviderIdentityID)
+			if identity != tt.wantIdentity {
+				t.Errorf("vmIdentity() identity = %v, want %v", identity, tt.wantIdentity)
+			}
+			if !reflect.DeepEqual(userAssignedIdentities, tt.wantUserAssignedIdentities) {
+				t.Errorf("vmIdentity() userAssignedIdentities = %v, want %v", userAssignedIdentities, tt.wantUserAssignedIdentities)
+			}
+		})
+	}
+}
+
+func TestMachineScope_BootDiagnostics(t *testing.T) {
+	machineScope := MachineScope{
+		AzureMachine: &infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "my-machine",
+				Annotations: map[string]string{infrav1.BootDiagnosticsAnnotation: ""},
+			},
+		},
+	}
+
+	if !machineScope.BootDiagnosticsRequested() {
+		t.Fatal("expected boot diagnostics to be requested")
+	}
+	machineScope.SetBootDiagnostics("https://storage/serial.log?sig=abc", "https://storage/screenshot.bmp?sig=abc", 15*time.Minute)
+	if machineScope.BootDiagnosticsRequested() {
+		t.Error("expected the boot diagnostics request to be removed")
+	}
+}
+
+func TestMachineScope_ResourceNames(t *testing.T) {
+	newMachineScope := func(naming *infrav1.AzureResourceNaming) MachineScope {
+		return MachineScope{
+			ClusterScoper: &ClusterScope{
+				Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+				AzureCluster: &infrav1.AzureCluster{
+					Spec: infrav1.AzureClusterSpec{AzureResourceNaming: naming},
+				},
+			},
+			AzureMachine: &infrav1.AzureMachine{ObjectMeta: metav1.ObjectMeta{Name: "my-machine"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		naming   *infrav1.AzureResourceNaming
+		expected []string
+	}{
+		{
+			name:     "default names",
+			expected: []string{"my-machine-nic", "my-machine-public-nic", "pip-my-machine", "my-machine_OSDisk"},
+		},
+		{
+			name: "custom naming",
+			naming: &infrav1.AzureResourceNaming{
+				Prefix: "weu-",
+				Templates: []infrav1.AzureResourceNamingTemplate{
+					{Kind: infrav1.NetworkInterfaceKind, Pattern: "nic-{machine}"},
+					{Kind: infrav1.OSDiskKind, Pattern: "disk-{cluster}-{machine}-os"},
+				},
+			},
+			expected: []string{"weu-nic-my-machine", "weu-my-machine-public-nic", "weu-pip-my-machine", "weu-disk-my-cluster-my-machine-os"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := newMachineScope(tc.naming)
+			got := []string{machineScope.NICName(), machineScope.PublicNICName(), machineScope.NodePublicIPName(), machineScope.OSDiskName()}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected names %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMachineScope_TagsSpecs(t *testing.T) {
+	machineScope := MachineScope{
+		ClusterScoper: &ClusterScope{
+			AzureClients: AzureClients{
+				EnvironmentSettings: auth.EnvironmentSettings{
+					Values: map[string]string{auth.SubscriptionID: "123"},
+				},
+			},
+			Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+			AzureCluster: &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					ResourceGroup:  "my-rg",
+					AdditionalTags: infrav1.Tags{"team": "infra"},
+				},
+			},
+		},
+		AzureMachine: &infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-machine"},
+			Spec: infrav1.AzureMachineSpec{
+				AllocatePublicIP: true,
+				AdditionalTags:   infrav1.Tags{"app": "web"},
+			},
+		},
+		Machine: &clusterv1.Machine{},
+	}
+
+	expectedTags := infrav1.Tags{
+		"team": "infra",
+		"app":  "web",
+		"kubernetes.io_cluster_my-cluster": "owned",
+	}
+	expected := []azure.TagsSpec{
+		{
+			Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-machine",
+			Tags:       expectedTags,
+			Annotation: infrav1.VMTagsLastAppliedAnnotation,
+		},
+		{
+			Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/my-machine-nic",
+			Tags:       expectedTags,
+			Annotation: infrav1.NICTagsLastAppliedAnnotation,
+		},
+		{
+			Scope:      "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/my-machine-public-nic",
+			Tags:       expectedTags,
+			Annotation: infrav1.NICTagsLastAppliedAnnotation,
+		},
+	}
+	if got := machineScope.TagsSpecs(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected tags specs %v, got %v", expected, got)
+	}
+}
@@ -109,11 +109,14 @@ func NewMachinePoolScope(params MachinePoolScopeParams) (*MachinePoolScope, erro
 
 // ScaleSetSpec returns the scale set spec.
 func (m *MachinePoolScope) ScaleSetSpec() azure.ScaleSetSpec {
+	identity, userAssignedIdentities := vmIdentity(m.AzureMachinePool.Spec.Identity, m.AzureMachinePool.Spec.UserAssignedIdentities, m.CloudProviderIdentityID())
 	return azure.ScaleSetSpec{
 		Name:                    m.Name(),
 		Size:                    m.AzureMachinePool.Spec.Template.VMSize,
 		Capacity:                int64(to.Int32(m.MachinePool.Spec.Replicas)),
 		SSHKeyData:              m.AzureMachinePool.Spec.Template.SSHPublicKey,
+		SSHKeySecretURL:         keyVaultSecretURL(m.AzureMachinePool.Spec.Template.SSHPublicKeySecret),
+		AdminPasswordSecretURL:  keyVaultSecretURL(m.AzureMachinePool.Spec.Template.AdminPasswordSecret),
 		OSDisk:                  m.AzureMachinePool.Spec.Template.OSDisk,
 		DataDisks:               m.AzureMachinePool.Spec.Template.DataDisks,
 		SubnetName:              m.NodeSubnet().Name,
@@ -122,14 +125,31 @@ func (m *MachinePoolScope) ScaleSetSpec() azure.ScaleSetSpec {
 		PublicLBName:            m.OutboundLBName(infrav1.Node),
 		PublicLBAddressPoolName: azure.GenerateOutboundBackendAddressPoolName(m.OutboundLBName(infrav1.Node)),
 		AcceleratedNetworking:   m.AzureMachinePool.Spec.Template.AcceleratedNetworking,
-		Identity:                m.AzureMachinePool.Spec.Identity,
-		UserAssignedIdentities:  m.AzureMachinePool.Spec.UserAssignedIdentities,
+		Identity:                identity,
+		UserAssignedIdentities:  userAssignedIdentities,
 		SecurityProfile:         m.AzureMachinePool.Spec.Template.SecurityProfile,
 		SpotVMOptions:           m.AzureMachinePool.Spec.Template.SpotVMOptions,
-		FailureDomains:          m.MachinePool.Spec.FailureDomains,
+		FailureDomains:          m.availabilityZones(),
 	}
 }
 
+// availabilityZones returns the availability zones of the failure domains of the MachinePool.
+func (m *MachinePoolScope) availabilityZones() []string {
+	if m.MachinePool.Spec.FailureDomains == nil {
+		return nil
+	}
+	zones := make([]string, 0, len(m.MachinePool.Spec.FailureDomains))
+	for _, failureDomain := range m.MachinePool.Spec.FailureDomains {
+		zones = append(zones, m.FailureDomainZone(failureDomain))
+	}
+	return zones
+}
+
+// Namespace returns the namespace of the AzureMachinePool, which is the namespace of its cluster.
+func (m *MachinePoolScope) Namespace() string {
+	return m.AzureMachinePool.Namespace
+}
+
 // Name returns the Azure Machine Pool Name.
 func (m *MachinePoolScope) Name() string {
 	// Windows Machine pools names cannot be longer than 9 chars
@@ -373,12 +393,13 @@ func (m *MachinePoolScope) createMachine(ctx context.Context, machine azure.VMSS
 // SetLongRunningOperationState will set the future on the AzureMachinePool status to allow the resource to continue
 // in the next reconciliation.
 func (m *MachinePoolScope) SetLongRunningOperationState(future *infrav1.Future) {
-	m.AzureMachinePool.Status.LongRunningOperationState = future
+	m.AzureMachinePool.Status.LongRunningOperationState = trackLongRunningOperation(m, "AzureMachinePool", m.AzureMachinePool, m.ClusterName(), "scalesets", future)
 }
 
 // GetLongRunningOperationState will get the future on the AzureMachinePool status to allow the resource to continue
-// in the next reconciliation.
+// in the next reconciliation. Futures older than the maximum age of long running operations are dropped.
 func (m *MachinePoolScope) GetLongRunningOperationState() *infrav1.Future {
+	m.SetLongRunningOperationState(m.AzureMachinePool.Status.LongRunningOperationState)
 	return m.AzureMachinePool.Status.LongRunningOperationState
 }
 
@@ -492,7 +513,9 @@ func (m *MachinePoolScope) Close(ctx context.Context) error {
 	ctx, span := tele.Tracer().Start(ctx, "scope.MachinePoolScope.Close")
 	defer span.End()
 
-	if m.vmssState != nil {
+	// The scale set read during a dry run may be the one the dry run would have created or updated, whose instances
+	// don't exist in Azure. In read-only mode, it is the one in Azure as long as no change was refused.
+	if dryRun := azure.DryRunFrom(ctx); m.vmssState != nil && (dryRun == nil || dryRun.ReadOnly() && len(dryRun.Changes()) == 0) {
 		if err := m.applyAzureMachinePoolMachines(ctx); err != nil {
 			m.Error(err, "failed to apply changes to the AzureMachinePoolMachines")
 			return errors.Wrap(err, "failed to apply changes to AzureMachinePoolMachines")
@@ -526,6 +549,16 @@ func (m *MachinePoolScope) GetBootstrapData(ctx context.Context) (string, error)
 	if !ok {
 		return "", errors.New("error retrieving bootstrap data: secret value key is missing")
 	}
+
+	if ref := m.AzureMachinePool.Spec.Template.AdditionalCustomDataSecret; ref != nil {
+		additionalData, err := getSecretKeyData(ctx, m.client, m.AzureMachinePool.Namespace, *ref)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retrieve additional custom data")
+		}
+		if value, err = mergeCustomData(value, additionalData); err != nil {
+			return "", errors.Wrap(err, "failed to merge additional custom data")
+		}
+	}
 	return base64.StdEncoding.EncodeToString(value), nil
 }
 
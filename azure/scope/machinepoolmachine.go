@@ -147,6 +147,11 @@ func NewMachinePoolMachineScope(params MachinePoolMachineScopeParams) (*MachineP
 	}, nil
 }
 
+// Namespace is the namespace of the Machine Pool Machine, which is the namespace of its cluster.
+func (s *MachinePoolMachineScope) Namespace() string {
+	return s.AzureMachinePoolMachine.Namespace
+}
+
 // Name is the name of the Machine Pool Machine.
 func (s *MachinePoolMachineScope) Name() string {
 	return s.AzureMachinePoolMachine.Name
@@ -163,13 +168,15 @@ func (s *MachinePoolMachineScope) ScaleSetName() string {
 }
 
 // GetLongRunningOperationState gets a future representing the current state of a long-running operation if one exists.
+// Futures older than the maximum age of long running operations are dropped.
 func (s *MachinePoolMachineScope) GetLongRunningOperationState() *infrav1.Future {
+	s.SetLongRunningOperationState(s.AzureMachinePoolMachine.Status.LongRunningOperationState)
 	return s.AzureMachinePoolMachine.Status.LongRunningOperationState
 }
 
 // SetLongRunningOperationState sets a future representing the current state of a long-running operation.
 func (s *MachinePoolMachineScope) SetLongRunningOperationState(future *infrav1.Future) {
-	s.AzureMachinePoolMachine.Status.LongRunningOperationState = future
+	s.AzureMachinePoolMachine.Status.LongRunningOperationState = trackLongRunningOperation(s, "AzureMachinePoolMachine", s.AzureMachinePoolMachine, s.ClusterName(), "scalesetvms", future)
 }
 
 // SetVMSSVM update the scope with the current state of the VMSS VM.
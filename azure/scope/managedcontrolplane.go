@@ -121,6 +121,11 @@ func (s *ManagedControlPlaneScope) ClusterName() string {
 	return s.Cluster.Name
 }
 
+// Namespace returns the namespace of the cluster.
+func (s *ManagedControlPlaneScope) Namespace() string {
+	return s.Cluster.Namespace
+}
+
 // Location returns the managed control plane's Azure location, or an empty string.
 func (s *ManagedControlPlaneScope) Location() string {
 	if s.ControlPlane == nil {
@@ -269,3 +274,44 @@ func (s *ManagedControlPlaneScope) GetPrivateDNSZoneName() string {
 func (s *ManagedControlPlaneScope) CloudProviderConfigOverrides() *infrav1.CloudProviderConfigOverrides {
 	return nil
 }
+
+// CloudProviderIdentityID returns an empty string, as AKS manages the identity of its cloud provider.
+func (s *ManagedControlPlaneScope) CloudProviderIdentityID() string {
+	return ""
+}
+
+// AzureResourceNaming returns nil, as AKS names the resources of managed clusters.
+func (s *ManagedControlPlaneScope) AzureResourceNaming() *infrav1.AzureResourceNaming {
+	return nil
+}
+
+// FailureDomainZone returns the failure domain, as the failure domains of managed clusters are their availability zones.
+func (s *ManagedControlPlaneScope) FailureDomainZone(failureDomain string) string {
+	return failureDomain
+}
+
+// AKSBackupSpec returns the backup spec of the managed cluster, or nil if backup is not configured.
+func (s *ManagedControlPlaneScope) AKSBackupSpec() *azure.AKSBackupSpec {
+	backup := s.ControlPlane.Spec.Backup
+	if backup == nil {
+		return nil
+	}
+
+	spec := &azure.AKSBackupSpec{
+		ManagedClusterName:  s.ControlPlane.Name,
+		TenantID:            s.TenantID(),
+		VaultName:           backup.VaultName,
+		StorageAccountID:    backup.StorageAccountID,
+		BlobContainer:       backup.BlobContainer,
+		BackupIntervalHours: backup.BackupIntervalHours,
+		RetentionDays:       backup.RetentionDays,
+		IncludedNamespaces:  backup.IncludedNamespaces,
+	}
+	if spec.BackupIntervalHours == 0 {
+		spec.BackupIntervalHours = 24
+	}
+	if spec.RetentionDays == 0 {
+		spec.RetentionDays = 7
+	}
+	return spec
+}
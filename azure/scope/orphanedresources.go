@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"time"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+// OrphanedResourcesScope defines the scope of the purge of the Azure resources owned by a cluster which neither the
+// cluster nor its machines refer to anymore.
+type OrphanedResourcesScope struct {
+	*ClusterScope
+	// AzureMachines are the machines of the cluster.
+	AzureMachines []infrav1.AzureMachine
+	// MinAge is the age under which orphaned resources are kept.
+	MinAge time.Duration
+}
+
+// OrphanedResourcesSpec returns the resources the cluster and its machines refer to. Resources which may exist
+// depending on the spec of a machine, like the public IP and public NIC of a node, are always expected so they are
+// never purged.
+func (s *OrphanedResourcesScope) OrphanedResourcesSpec() azure.OrphanedResourcesSpec {
+	expected := map[string][]string{}
+	add := func(resourceType, name string) {
+		if name != "" {
+			expected[resourceType] = append(expected[resourceType], name)
+		}
+	}
+
+	for _, spec := range s.PublicIPSpecs() {
+		add("Microsoft.Network/publicIPAddresses", spec.Name)
+	}
+	for _, spec := range s.LBSpecs() {
+		add("Microsoft.Network/loadBalancers", spec.Name)
+	}
+	if bastion := s.BastionSpec().AzureBastion; bastion != nil {
+		add("Microsoft.Network/bastionHosts", bastion.Name)
+	}
+	for i := range s.AzureMachines {
+		// The resources of a machine are named after its VM, which may differ from the name of the AzureMachine.
+		machine := &MachineScope{ClusterScoper: s.ClusterScope, AzureMachine: &s.AzureMachines[i]}
+		add("Microsoft.Compute/virtualMachines", machine.Name())
+		add("Microsoft.Network/networkInterfaces", machine.NICName())
+		add("Microsoft.Network/networkInterfaces", machine.PublicNICName())
+		add("Microsoft.Network/publicIPAddresses", machine.NodePublicIPName())
+	}
+
+	return azure.OrphanedResourcesSpec{
+		Expected: expected,
+		MinAge:   s.MinAge,
+	}
+}
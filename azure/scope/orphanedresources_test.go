@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+func TestOrphanedResourcesSpec(t *testing.T) {
+	g := NewWithT(t)
+
+	scope := &OrphanedResourcesScope{
+		ClusterScope: &ClusterScope{
+			Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+			AzureCluster: &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					NetworkSpec: infrav1.NetworkSpec{
+						APIServerLB: infrav1.LoadBalancerSpec{
+							Name: "my-cluster-public-lb",
+							Type: infrav1.Public,
+							FrontendIPs: []infrav1.FrontendIP{
+								{PublicIP: &infrav1.PublicIPSpec{Name: "my-cluster-apiserver-ip"}},
+							},
+						},
+					},
+					BastionSpec: infrav1.BastionSpec{
+						AzureBastion: &infrav1.AzureBastion{
+							Name:     "my-bastion",
+							PublicIP: infrav1.PublicIPSpec{Name: "my-bastion-ip"},
+						},
+					},
+				},
+			},
+		},
+		AzureMachines: []infrav1.AzureMachine{
+			{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-md-0-abcde"}},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-md-win-abcde"},
+				Spec: infrav1.AzureMachineSpec{
+					ProviderID: to.StringPtr("azure:///subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-clust-abcde"),
+				},
+			},
+		},
+		MinAge: time.Hour,
+	}
+
+	g.Expect(scope.OrphanedResourcesSpec()).To(Equal(azure.OrphanedResourcesSpec{
+		Expected: map[string][]string{
+			"Microsoft.Network/publicIPAddresses": {
+				"my-cluster-apiserver-ip",
+				"my-bastion-ip",
+				"pip-my-cluster-md-0-abcde",
+				"pip-my-clust-abcde",
+			},
+			"Microsoft.Network/loadBalancers": {"my-cluster-public-lb"},
+			"Microsoft.Network/bastionHosts":  {"my-bastion"},
+			// The VM of a machine with a provider ID is named after it.
+			"Microsoft.Compute/virtualMachines": {"my-cluster-md-0-abcde", "my-clust-abcde"},
+			"Microsoft.Network/networkInterfaces": {
+				"my-cluster-md-0-abcde-nic",
+				"my-cluster-md-0-abcde-public-nic",
+				"my-clust-abcde-nic",
+				"my-clust-abcde-public-nic",
+			},
+		},
+		MinAge: time.Hour,
+	}))
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+var (
+	quotaLabels = []string{"subscription", "location", "provider", "quota"}
+	quotaLimit  = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capz_subscription_quota_limit",
+		Help: "Quota of a subscription in a location, by resource provider and quota, e.g. the cores of a VM family.",
+	}, quotaLabels)
+	quotaUsage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capz_subscription_quota_usage",
+		Help: "Usage of a quota of a subscription in a location, by resource provider and quota.",
+	}, quotaLabels)
+	quotaRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capz_subscription_quota_remaining",
+		Help: "Remaining quota of a subscription in a location, by resource provider and quota.",
+	}, quotaLabels)
+)
+
+func init() {
+	metrics.Registry.MustRegister(quotaLimit, quotaUsage, quotaRemaining)
+}
+
+// QuotaScope defines the scope of the check of the quotas of the subscription of a cluster in its location.
+type QuotaScope struct {
+	*ClusterScope
+	// Demand is what the machines and machine pools of the cluster are yet to create.
+	Demand azure.QuotaDemand
+}
+
+// QuotaDemand returns what the machines and machine pools of the cluster are yet to create.
+func (s *QuotaScope) QuotaDemand() azure.QuotaDemand {
+	return s.Demand
+}
+
+// SetQuotaUsages exports the usages of the quotas of the subscription of the cluster as metrics, and sets the
+// QuotaAvailable condition of the cluster from the quotas its machines and machine pools would exceed by scaling up.
+// Quotas without a limit are ignored.
+func (s *QuotaScope) SetQuotaUsages(usages []azure.QuotaUsage) {
+	var exceeded []string
+	for _, usage := range usages {
+		if usage.Limit <= 0 && usage.Current == 0 {
+			continue
+		}
+		labels := prometheus.Labels{
+			"subscription": s.SubscriptionID(),
+			"location":     s.Location(),
+			"provider":     usage.Provider,
+			"quota":        usage.Name,
+		}
+		quotaLimit.With(labels).Set(float64(usage.Limit))
+		quotaUsage.With(labels).Set(float64(usage.Current))
+		quotaRemaining.With(labels).Set(float64(usage.Limit - usage.Current))
+		if usage.Pending > 0 && usage.Current+usage.Pending > usage.Limit {
+			exceeded = append(exceeded, fmt.Sprintf("scaling up needs %d more %s of %s, %d of %d left",
+				usage.Pending, usage.Name, usage.Provider, usage.Limit-usage.Current, usage.Limit))
+		}
+	}
+
+	if len(exceeded) == 0 {
+		conditions.MarkTrue(s.AzureCluster, infrav1.QuotaAvailableCondition)
+		return
+	}
+	conditions.MarkFalse(s.AzureCluster, infrav1.QuotaAvailableCondition, infrav1.QuotaExceededReason, clusterv1.ConditionSeverityWarning,
+		"quota exceeded in location %s: %s", s.Location(), strings.Join(exceeded, "; "))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+func TestSetQuotaUsages(t *testing.T) {
+	newScope := func() *QuotaScope {
+		clusterScope := &ClusterScope{
+			Cluster:      &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+			AzureCluster: &infrav1.AzureCluster{Spec: infrav1.AzureClusterSpec{Location: "westeurope"}},
+		}
+		clusterScope.AzureClients.Values = map[string]string{auth.SubscriptionID: "quota-test"}
+		return &QuotaScope{ClusterScope: clusterScope}
+	}
+
+	t.Run("quotas leave room to scale up", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope()
+
+		scope.SetQuotaUsages([]azure.QuotaUsage{
+			{Provider: "Microsoft.Compute", Name: "standardDSv3Family", Current: 8, Limit: 10, Pending: 2},
+			{Provider: "Microsoft.Compute", Name: "standardNCFamily", Current: 0, Limit: 0},
+		})
+
+		g.Expect(conditions.IsTrue(scope.AzureCluster, infrav1.QuotaAvailableCondition)).To(BeTrue())
+		labels := []string{"quota-test", "westeurope", "Microsoft.Compute", "standardDSv3Family"}
+		g.Expect(testutil.ToFloat64(quotaLimit.WithLabelValues(labels...))).To(Equal(10.0))
+		g.Expect(testutil.ToFloat64(quotaUsage.WithLabelValues(labels...))).To(Equal(8.0))
+		g.Expect(testutil.ToFloat64(quotaRemaining.WithLabelValues(labels...))).To(Equal(2.0))
+		// Quotas without a limit nor usage aren't exported.
+		g.Expect(testutil.CollectAndCount(quotaLimit, "capz_subscription_quota_limit")).To(Equal(1))
+	})
+
+	t.Run("scaling up would exceed quotas", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope()
+
+		scope.SetQuotaUsages([]azure.QuotaUsage{
+			{Provider: "Microsoft.Compute", Name: "standardDSv3Family", Current: 8, Limit: 10, Pending: 4},
+			{Provider: "Microsoft.Compute", Name: "cores", Current: 8, Limit: 100, Pending: 4},
+			{Provider: "Microsoft.Network", Name: "PublicIPAddresses", Current: 10, Limit: 10, Pending: 1},
+		})
+
+		g.Expect(conditions.Get(scope.AzureCluster, infrav1.QuotaAvailableCondition)).To(Equal(&clusterv1.Condition{
+			Type:     infrav1.QuotaAvailableCondition,
+			Status:   "False",
+			Severity: clusterv1.ConditionSeverityWarning,
+			Reason:   infrav1.QuotaExceededReason,
+			Message: "quota exceeded in location westeurope: scaling up needs 4 more standardDSv3Family of Microsoft.Compute, 2 of 10 left; " +
+				"scaling up needs 1 more PublicIPAddresses of Microsoft.Network, 0 of 10 left",
+			LastTransitionTime: conditions.Get(scope.AzureCluster, infrav1.QuotaAvailableCondition).LastTransitionTime,
+		}))
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+// ResourceHealthScope defines the scope of the check of the Azure resources of a cluster and its machines against
+// Azure Resource Health.
+type ResourceHealthScope struct {
+	*ClusterScope
+	// AzureMachines are the machines of the cluster.
+	AzureMachines []*infrav1.AzureMachine
+}
+
+// SetUnhealthyResources sets the AzureResourcesHealthy condition of the machines of the cluster from the resources of
+// their VMs, and that of the cluster from all other resources. The spec hashes of the services of a cluster whose
+// resources become unhealthy are cleared, so its next reconciliation checks all its resources again.
+func (s *ResourceHealthScope) SetUnhealthyResources(unhealthy []azure.UnhealthyResource) {
+	owners := map[string]*infrav1.AzureMachine{}
+	for _, azureMachine := range s.AzureMachines {
+		// The resources of a machine are named after its VM, which may differ from the name of the AzureMachine.
+		machine := &MachineScope{ClusterScoper: s.ClusterScope, AzureMachine: azureMachine}
+		owners[resourceKey("Microsoft.Compute/virtualMachines", machine.Name())] = azureMachine
+		owners[resourceKey("Microsoft.Compute/disks", machine.OSDiskName())] = azureMachine
+		owners[resourceKey("Microsoft.Network/networkInterfaces", machine.NICName())] = azureMachine
+		owners[resourceKey("Microsoft.Network/networkInterfaces", machine.PublicNICName())] = azureMachine
+		owners[resourceKey("Microsoft.Network/publicIPAddresses", machine.NodePublicIPName())] = azureMachine
+	}
+
+	sort.Slice(unhealthy, func(i, j int) bool {
+		return resourceKey(unhealthy[i].Type, unhealthy[i].Name) < resourceKey(unhealthy[j].Type, unhealthy[j].Name)
+	})
+	var clusterResources []azure.UnhealthyResource
+	machineResources := map[*infrav1.AzureMachine][]azure.UnhealthyResource{}
+	for _, resource := range unhealthy {
+		if azureMachine, ok := owners[resourceKey(resource.Type, resource.Name)]; ok {
+			machineResources[azureMachine] = append(machineResources[azureMachine], resource)
+		} else {
+			clusterResources = append(clusterResources, resource)
+		}
+	}
+
+	if len(clusterResources) > 0 && !conditions.IsFalse(s.AzureCluster, infrav1.AzureResourcesHealthyCondition) {
+		s.AzureCluster.Status.ServiceSpecHashes = nil
+	}
+	setResourcesHealthy(s.AzureCluster, clusterResources)
+	for _, azureMachine := range s.AzureMachines {
+		setResourcesHealthy(azureMachine, machineResources[azureMachine])
+	}
+}
+
+// setResourcesHealthy marks the AzureResourcesHealthy condition of an object false if any of its resources are
+// unhealthy, and true otherwise.
+func setResourcesHealthy(obj conditions.Setter, unhealthy []azure.UnhealthyResource) {
+	if len(unhealthy) == 0 {
+		conditions.MarkTrue(obj, infrav1.AzureResourcesHealthyCondition)
+		return
+	}
+	descriptions := make([]string, 0, len(unhealthy))
+	for _, resource := range unhealthy {
+		description := fmt.Sprintf("%s %s is %s", resource.Type, resource.Name, strings.ToLower(resource.State))
+		if resource.Summary != "" {
+			description += ": " + resource.Summary
+		}
+		descriptions = append(descriptions, description)
+	}
+	conditions.MarkFalse(obj, infrav1.AzureResourcesHealthyCondition, infrav1.AzureResourcesUnavailableReason, clusterv1.ConditionSeverityWarning, "%s", strings.Join(descriptions, "; "))
+}
+
+// resourceKey identifies a resource of a resource group by its type and name, which Azure compares case-insensitively.
+func resourceKey(resourceType, name string) string {
+	return strings.ToLower(resourceType + "/" + name)
+}
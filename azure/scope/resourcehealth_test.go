@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+func TestSetUnhealthyResources(t *testing.T) {
+	newScope := func() *ResourceHealthScope {
+		return &ResourceHealthScope{
+			ClusterScope: &ClusterScope{
+				Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+				AzureCluster: &infrav1.AzureCluster{
+					Status: infrav1.AzureClusterStatus{
+						ServiceSpecHashes: []infrav1.ServiceSpecHash{{Service: "loadbalancers", Hash: "abc"}},
+					},
+				},
+			},
+			AzureMachines: []*infrav1.AzureMachine{
+				{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-md-0-abcde"}},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-cluster-md-win-abcde"},
+					Spec: infrav1.AzureMachineSpec{
+						ProviderID: to.StringPtr("azure:///subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-clust-abcde"),
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("all resources healthy", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope()
+
+		scope.SetUnhealthyResources(nil)
+
+		g.Expect(conditions.IsTrue(scope.AzureCluster, infrav1.AzureResourcesHealthyCondition)).To(BeTrue())
+		for _, azureMachine := range scope.AzureMachines {
+			g.Expect(conditions.IsTrue(azureMachine, infrav1.AzureResourcesHealthyCondition)).To(BeTrue())
+		}
+		g.Expect(scope.AzureCluster.Status.ServiceSpecHashes).To(HaveLen(1))
+	})
+
+	t.Run("unhealthy resources are reported on their owners", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope()
+
+		scope.SetUnhealthyResources([]azure.UnhealthyResource{
+			{Type: "Microsoft.Network/loadBalancers", Name: "my-cluster-public-lb", State: "Unavailable", Summary: "All health probes are down."},
+			// The VM of a machine with a provider ID is named after it, and Azure doesn't preserve the case of resource types.
+			{Type: "Microsoft.Compute/virtualmachines", Name: "my-clust-abcde", State: "Unavailable", Summary: "The VM is being auto-recovered."},
+			{Type: "Microsoft.Network/networkInterfaces", Name: "my-clust-abcde-nic", State: "Unavailable"},
+		})
+
+		g.Expect(conditions.Get(scope.AzureCluster, infrav1.AzureResourcesHealthyCondition)).To(Equal(&clusterv1.Condition{
+			Type:               infrav1.AzureResourcesHealthyCondition,
+			Status:             corev1.ConditionFalse,
+			Severity:           clusterv1.ConditionSeverityWarning,
+			Reason:             infrav1.AzureResourcesUnavailableReason,
+			Message:            "Microsoft.Network/loadBalancers my-cluster-public-lb is unavailable: All health probes are down.",
+			LastTransitionTime: conditions.Get(scope.AzureCluster, infrav1.AzureResourcesHealthyCondition).LastTransitionTime,
+		}))
+		g.Expect(conditions.IsTrue(scope.AzureMachines[0], infrav1.AzureResourcesHealthyCondition)).To(BeTrue())
+		g.Expect(conditions.GetMessage(scope.AzureMachines[1], infrav1.AzureResourcesHealthyCondition)).To(Equal(
+			"Microsoft.Compute/virtualmachines my-clust-abcde is unavailable: The VM is being auto-recovered.; " +
+				"Microsoft.Network/networkInterfaces my-clust-abcde-nic is unavailable"))
+		// The services of the cluster check all their resources again.
+		g.Expect(scope.AzureCluster.Status.ServiceSpecHashes).To(BeEmpty())
+	})
+
+	t.Run("spec hashes are only cleared when cluster resources become unhealthy", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope()
+		conditions.MarkFalse(scope.AzureCluster, infrav1.AzureResourcesHealthyCondition, infrav1.AzureResourcesUnavailableReason, clusterv1.ConditionSeverityWarning, "")
+
+		scope.SetUnhealthyResources([]azure.UnhealthyResource{
+			{Type: "Microsoft.Network/loadBalancers", Name: "my-cluster-public-lb", State: "Unavailable"},
+		})
+
+		g.Expect(conditions.IsFalse(scope.AzureCluster, infrav1.AzureResourcesHealthyCondition)).To(BeTrue())
+		g.Expect(scope.AzureCluster.Status.ServiceSpecHashes).To(HaveLen(1))
+	})
+}
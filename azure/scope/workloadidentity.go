@@ -0,0 +1,256 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/system"
+)
+
+const (
+	// defaultWorkloadIdentityAudience is the audience Azure AD expects in federated tokens by default.
+	defaultWorkloadIdentityAudience = "api://AzureADTokenExchange"
+
+	// workloadIdentityTokenExpirationSeconds is the lifetime of the minted service account tokens beyond
+	// the refresh interval they are reused for.
+	workloadIdentityTokenExpirationSeconds = int64(600)
+)
+
+// serviceAccountsClient mints the service account tokens of workload identities.
+var serviceAccountsClient corev1client.ServiceAccountsGetter
+
+// SetServiceAccountsClient sets the client used to mint the service account tokens of workload identities.
+// It must be set before AzureClusterIdentities of type WorkloadIdentity can be used.
+func SetServiceAccountsClient(c corev1client.ServiceAccountsGetter) {
+	serviceAccountsClient = c
+}
+
+// WorkloadIdentityAllowlist is what the settings of workload identities may refer to. Whoever creates an
+// AzureClusterIdentity chooses its settings, while the allowlist is set by the administrators of the controller.
+type WorkloadIdentityAllowlist struct {
+	// ServiceAccounts are the service accounts in the namespace of the controller, besides its own, tokens may be
+	// minted for.
+	ServiceAccounts []string
+	// Audiences are the audiences tokens may be minted for.
+	Audiences []string
+	// TokenFilePaths are the projected service account tokens which may be read.
+	TokenFilePaths []string
+}
+
+// workloadIdentityAllowlist only allows minting tokens of the controller for Azure AD by default.
+var workloadIdentityAllowlist = WorkloadIdentityAllowlist{Audiences: []string{defaultWorkloadIdentityAudience}}
+
+// SetWorkloadIdentityAllowlist sets what the settings of workload identities may refer to.
+func SetWorkloadIdentityAllowlist(allowlist WorkloadIdentityAllowlist) {
+	workloadIdentityAllowlist = allowlist
+}
+
+// validateWorkloadIdentitySettings returns an error if the settings of a workload identity refer to a service
+// account, an audience or a token file which isn't allowed.
+func validateWorkloadIdentitySettings(settings *infrav1.WorkloadIdentitySettings) error {
+	if settings == nil {
+		return nil
+	}
+	if settings.TokenFilePath != "" {
+		if !containsString(workloadIdentityAllowlist.TokenFilePaths, settings.TokenFilePath) {
+			return errors.Errorf("AzureClusterIdentity workload identity token file path %s is not allowed", settings.TokenFilePath)
+		}
+		// The audience of read tokens is the one of their projected volume.
+		return nil
+	}
+	if settings.ServiceAccountName != "" && settings.ServiceAccountName != system.GetManagerServiceAccount() &&
+		!containsString(workloadIdentityAllowlist.ServiceAccounts, settings.ServiceAccountName) {
+		return errors.Errorf("AzureClusterIdentity workload identity service account %s is not allowed", settings.ServiceAccountName)
+	}
+	if settings.Audience != "" && !containsString(workloadIdentityAllowlist.Audiences, settings.Audience) {
+		return errors.Errorf("AzureClusterIdentity workload identity audience %s is not allowed", settings.Audience)
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// federatedTokenSecret implements adal.ServicePrincipalSecret by passing a service account token to Azure AD
+// as client assertion. The token is minted or read from a projected volume, and reused for the refresh interval.
+type federatedTokenSecret struct {
+	client          corev1client.ServiceAccountsGetter
+	namespace       string
+	serviceAccount  string
+	audience        string
+	issuer          string
+	tokenFilePath   string
+	refreshInterval time.Duration
+
+	mu        sync.Mutex
+	cached    string
+	fetchedAt time.Time
+}
+
+var _ adal.ServicePrincipalSecret = (*federatedTokenSecret)(nil)
+
+// getWorkloadIdentityAuthorizer returns an authorizer exchanging service account tokens of the controller
+// for Azure tokens of the identity.
+func (p *AzureCredentialsProvider) getWorkloadIdentityAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint string) (autorest.Authorizer, error) {
+	settings := p.Identity.Spec.WorkloadIdentity
+	if serviceAccountsClient == nil && (settings == nil || settings.TokenFilePath == "") {
+		return nil, errors.New("failed to get token for workload identity: service account token client is not set up")
+	}
+
+	secret := &federatedTokenSecret{
+		client:         serviceAccountsClient,
+		namespace:      system.GetManagerNamespace(),
+		serviceAccount: system.GetManagerServiceAccount(),
+		audience:       defaultWorkloadIdentityAudience,
+	}
+	if settings != nil {
+		if settings.ServiceAccountName != "" {
+			secret.serviceAccount = settings.ServiceAccountName
+		}
+		if settings.Audience != "" {
+			secret.audience = settings.Audience
+		}
+		secret.issuer = settings.Issuer
+		secret.tokenFilePath = settings.TokenFilePath
+		if settings.TokenRefreshInterval != nil {
+			secret.refreshInterval = settings.TokenRefreshInterval.Duration
+		}
+	}
+
+	return p.newSecretAuthorizer(resourceManagerEndpoint, activeDirectoryEndpoint, secret)
+}
+
+// SetAuthenticationValues is a method of the interface adal.ServicePrincipalSecret.
+// It populates the form submitted during OAuth token acquisition with a freshly minted service account token.
+func (s *federatedTokenSecret) SetAuthenticationValues(_ *adal.ServicePrincipalToken, v *url.Values) error {
+	token, err := s.token(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	v.Set("client_assertion", token)
+	v.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s *federatedTokenSecret) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("marshalling federatedTokenSecret is not supported")
+}
+
+// token returns a service account token and checks its issuer. The token is reused until the refresh
+// interval has passed.
+func (s *federatedTokenSecret) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != "" && time.Since(s.fetchedAt) < s.refreshInterval {
+		return s.cached, nil
+	}
+
+	var token string
+	var err error
+	if s.tokenFilePath != "" {
+		token, err = s.readToken()
+	} else {
+		token, err = s.mintToken(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if s.issuer != "" {
+		issuer, err := tokenIssuer(token)
+		if err != nil {
+			return "", err
+		}
+		if issuer != s.issuer {
+			return "", errors.Errorf("service account token was issued by %q, expected %q", issuer, s.issuer)
+		}
+	}
+
+	s.cached, s.fetchedAt = token, time.Now()
+	return token, nil
+}
+
+// mintToken mints a service account token with the configured audience, valid for longer than it is reused.
+func (s *federatedTokenSecret) mintToken(ctx context.Context) (string, error) {
+	expirationSeconds := workloadIdentityTokenExpirationSeconds + int64(s.refreshInterval.Seconds())
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{s.audience},
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+	result, err := s.client.ServiceAccounts(s.namespace).CreateToken(ctx, s.serviceAccount, request, metav1.CreateOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to mint token for service account %s/%s", s.namespace, s.serviceAccount)
+	}
+	return result.Status.Token, nil
+}
+
+// readToken reads a projected service account token, which the kubelet keeps rotating.
+func (s *federatedTokenSecret) readToken() (string, error) {
+	data, err := ioutil.ReadFile(s.tokenFilePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read service account token from %s", s.tokenFilePath)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// tokenIssuer returns the issuer claim of a JWT without verifying its signature.
+func tokenIssuer(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("service account token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode service account token")
+	}
+
+	claims := struct {
+		Issuer string `json:"iss"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", errors.Wrap(err, "failed to decode service account token")
+	}
+	return claims.Issuer, nil
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/system"
+)
+
+func fakeJWT(issuer string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + issuer + `","sub":"system:serviceaccount:capz-system:capz-manager"}`))
+	return "eyJhbGciOiJSUzI1NiJ9." + payload + ".c2lnbmF0dXJl"
+}
+
+func fakeTokenClient(token string, requests *[]*authenticationv1.TokenRequest, serviceAccounts *[]string) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		create := action.(clienttesting.CreateAction)
+		request := create.GetObject().(*authenticationv1.TokenRequest)
+		*requests = append(*requests, request)
+		*serviceAccounts = append(*serviceAccounts, action.GetNamespace()+"/"+create.(clienttesting.CreateActionImpl).Name)
+		return true, &authenticationv1.TokenRequest{Status: authenticationv1.TokenRequestStatus{Token: token}}, nil
+	})
+	return clientset
+}
+
+func TestFederatedTokenSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		issuer        string
+		expectedError string
+	}{
+		{
+			name:  "token without issuer check",
+			token: fakeJWT("https://oidc.example.com"),
+		},
+		{
+			name:   "token with matching issuer",
+			token:  fakeJWT("https://oidc.example.com"),
+			issuer: "https://oidc.example.com",
+		},
+		{
+			name:          "token with another issuer",
+			token:         fakeJWT("https://kubernetes.default.svc"),
+			issuer:        "https://oidc.example.com",
+			expectedError: `service account token was issued by "https://kubernetes.default.svc", expected "https://oidc.example.com"`,
+		},
+		{
+			name:          "token that is no JWT",
+			token:         "not-a-jwt",
+			issuer:        "https://oidc.example.com",
+			expectedError: "service account token is not a JWT",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var requests []*authenticationv1.TokenRequest
+			var serviceAccounts []string
+
+			secret := &federatedTokenSecret{
+				client:         fakeTokenClient(tc.token, &requests, &serviceAccounts).CoreV1(),
+				namespace:      "capz-system",
+				serviceAccount: "capz-manager",
+				audience:       "api://AzureADTokenExchange",
+				issuer:         tc.issuer,
+			}
+
+			values := url.Values{}
+			err := secret.SetAuthenticationValues(nil, &values)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(values.Get("client_assertion")).To(Equal(tc.token))
+			g.Expect(values.Get("client_assertion_type")).To(Equal("urn:ietf:params:oauth:client-assertion-type:jwt-bearer"))
+			g.Expect(serviceAccounts).To(Equal([]string{"capz-system/capz-manager"}))
+			g.Expect(requests).To(HaveLen(1))
+			g.Expect(requests[0].Spec.Audiences).To(Equal([]string{"api://AzureADTokenExchange"}))
+		})
+	}
+}
+
+func TestTokenIssuer(t *testing.T) {
+	g := NewWithT(t)
+
+	issuer, err := tokenIssuer(fakeJWT("https://oidc.example.com"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(issuer).To(Equal("https://oidc.example.com"))
+
+	_, err = tokenIssuer("a.b!.c")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFederatedTokenSecretSettings(t *testing.T) {
+	g := NewWithT(t)
+	var requests []*authenticationv1.TokenRequest
+	var serviceAccounts []string
+
+	secret := &federatedTokenSecret{
+		client:         fakeTokenClient(fakeJWT("issuer"), &requests, &serviceAccounts).CoreV1(),
+		namespace:      "capz",
+		serviceAccount: "workload-identity",
+		audience:       "api://custom",
+	}
+	_, err := secret.token(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(serviceAccounts).To(Equal([]string{"capz/workload-identity"}))
+	g.Expect(requests[0].Spec.Audiences).To(Equal([]string{"api://custom"}))
+	g.Expect(*requests[0].Spec.ExpirationSeconds).To(Equal(workloadIdentityTokenExpirationSeconds))
+}
+
+func TestFederatedTokenSecretRefreshInterval(t *testing.T) {
+	g := NewWithT(t)
+	var requests []*authenticationv1.TokenRequest
+	var serviceAccounts []string
+
+	secret := &federatedTokenSecret{
+		client:          fakeTokenClient(fakeJWT("issuer"), &requests, &serviceAccounts).CoreV1(),
+		namespace:       "capz-system",
+		serviceAccount:  "capz-manager",
+		audience:        "api://AzureADTokenExchange",
+		refreshInterval: time.Hour,
+	}
+	for i := 0; i < 2; i++ {
+		token, err := secret.token(context.Background())
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(token).To(Equal(fakeJWT("issuer")))
+	}
+	g.Expect(requests).To(HaveLen(1))
+	g.Expect(*requests[0].Spec.ExpirationSeconds).To(Equal(workloadIdentityTokenExpirationSeconds + 3600))
+
+	// The token is replaced once the refresh interval has passed.
+	secret.fetchedAt = time.Now().Add(-2 * time.Hour)
+	_, err := secret.token(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requests).To(HaveLen(2))
+}
+
+func TestFederatedTokenSecretFromFile(t *testing.T) {
+	g := NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "azure-identity-token")
+	g.Expect(ioutil.WriteFile(path, []byte(fakeJWT("https://oidc.example.com")+"\n"), 0600)).To(Succeed())
+
+	secret := &federatedTokenSecret{
+		tokenFilePath:   path,
+		issuer:          "https://oidc.example.com",
+		refreshInterval: time.Hour,
+	}
+	token, err := secret.token(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(token).To(Equal(fakeJWT("https://oidc.example.com")))
+
+	// Rotated tokens are read once the refresh interval has passed.
+	g.Expect(ioutil.WriteFile(path, []byte(fakeJWT("https://other.example.com")), 0600)).To(Succeed())
+	token, err = secret.token(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(token).To(Equal(fakeJWT("https://oidc.example.com")))
+	secret.fetchedAt = time.Time{}
+	_, err = secret.token(context.Background())
+	g.Expect(err).To(MatchError(`service account token was issued by "https://other.example.com", expected "https://oidc.example.com"`))
+
+	secret.tokenFilePath = filepath.Join(t.TempDir(), "missing")
+	_, err = secret.token(context.Background())
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGetWorkloadIdentityAuthorizer(t *testing.T) {
+	tests := []struct {
+		name               string
+		auxiliaryTenantIDs []string
+		expected           interface{}
+	}{
+		{
+			name:     "identity in a single tenant",
+			expected: &autorest.BearerAuthorizer{},
+		},
+		{
+			name:               "identity with auxiliary tenants",
+			auxiliaryTenantIDs: []string{"aux-tenant-1", "aux-tenant-2"},
+			expected:           &autorest.MultiTenantBearerAuthorizer{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var requests []*authenticationv1.TokenRequest
+			var serviceAccounts []string
+			SetServiceAccountsClient(fakeTokenClient(fakeJWT("issuer"), &requests, &serviceAccounts).CoreV1())
+			defer SetServiceAccountsClient(nil)
+
+			provider := &AzureCredentialsProvider{
+				Identity: &infrav1.AzureClusterIdentity{
+					Spec: infrav1.AzureClusterIdentitySpec{
+						Type:               infrav1.WorkloadIdentity,
+						ClientID:           "client-id",
+						TenantID:           "tenant-id",
+						AuxiliaryTenantIDs: tc.auxiliaryTenantIDs,
+					},
+				},
+			}
+			authorizer, err := provider.getWorkloadIdentityAuthorizer(azure.PublicCloud.ResourceManagerEndpoint, azure.PublicCloud.ActiveDirectoryEndpoint)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(authorizer).To(BeAssignableToTypeOf(tc.expected))
+		})
+	}
+}
+
+func TestValidateIdentity(t *testing.T) {
+	tests := []struct {
+		name          string
+		namespace     string
+		spec          infrav1.AzureClusterIdentitySpec
+		expectedError string
+	}{
+		{
+			name: "service principal",
+			spec: infrav1.AzureClusterIdentitySpec{Type: infrav1.ServicePrincipal},
+		},
+		{
+			name: "workload identity with auxiliary tenants",
+			spec: infrav1.AzureClusterIdentitySpec{Type: infrav1.WorkloadIdentity, AuxiliaryTenantIDs: []string{"aux-tenant"}},
+		},
+		{
+			name: "workload identity with allowed service account and audience",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:             infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{ServiceAccountName: "workload-identity", Audience: "api://AzureADTokenExchange"},
+			},
+		},
+		{
+			name: "workload identity with service account which isn't allowed",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:             infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{ServiceAccountName: "other"},
+			},
+			expectedError: "AzureClusterIdentity workload identity service account other is not allowed",
+		},
+		{
+			name: "workload identity with audience which isn't allowed",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:             infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{Audience: "https://kubernetes.default.svc"},
+			},
+			expectedError: "AzureClusterIdentity workload identity audience https://kubernetes.default.svc is not allowed",
+		},
+		{
+			name: "workload identity with token file which isn't allowed",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:             infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{TokenFilePath: "/etc/passwd"},
+			},
+			expectedError: "AzureClusterIdentity workload identity token file path /etc/passwd is not allowed",
+		},
+		{
+			name:          "workload identity outside of the namespace of the controller",
+			namespace:     "default",
+			spec:          infrav1.AzureClusterIdentitySpec{Type: infrav1.WorkloadIdentity},
+			expectedError: "AzureClusterIdentity of type Workload Identity must be in namespace capz-system",
+		},
+		{
+			name: "workload identity with token file",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type: infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{
+					TokenFilePath:        "/var/run/secrets/azure/tokens/azure-identity-token",
+					TokenRefreshInterval: &metav1.Duration{Duration: time.Minute},
+				},
+			},
+		},
+		{
+			name: "workload identity with relative token file path",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:             infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{TokenFilePath: "tokens/azure-identity-token"},
+			},
+			expectedError: "AzureClusterIdentity workload identity token file path must be absolute",
+		},
+		{
+			name: "workload identity with token file and service account",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type: infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{
+					TokenFilePath:      "/var/run/secrets/azure/tokens/azure-identity-token",
+					ServiceAccountName: "workload-identity",
+				},
+			},
+			expectedError: "AzureClusterIdentity workload identity token file path and service account name are mutually exclusive",
+		},
+		{
+			name: "workload identity with negative refresh interval",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:             infrav1.WorkloadIdentity,
+				WorkloadIdentity: &infrav1.WorkloadIdentitySettings{TokenRefreshInterval: &metav1.Duration{Duration: -time.Minute}},
+			},
+			expectedError: "AzureClusterIdentity workload identity token refresh interval must not be negative",
+		},
+		{
+			name:          "service principal with auxiliary tenants",
+			spec:          infrav1.AzureClusterIdentitySpec{Type: infrav1.ServicePrincipal, AuxiliaryTenantIDs: []string{"aux-tenant"}},
+			expectedError: "AzureClusterIdentity auxiliary tenants are not supported for Service Principal",
+		},
+		{
+			name: "certificate from Key Vault with auxiliary tenants",
+			spec: infrav1.AzureClusterIdentitySpec{
+				Type:                infrav1.ServicePrincipalCertificate,
+				KeyVaultCertificate: &infrav1.KeyVaultCertificateReference{VaultURL: "https://vault.vault.azure.net/", CertificateName: "cert"},
+				AuxiliaryTenantIDs:  []string{"aux-tenant"},
+			},
+		},
+		{
+			name:          "certificate without Key Vault certificate",
+			spec:          infrav1.AzureClusterIdentitySpec{Type: infrav1.ServicePrincipalCertificate},
+			expectedError: "AzureClusterIdentity of type Service Principal Certificate requires a Key Vault certificate",
+		},
+		{
+			name:          "user assigned identity",
+			spec:          infrav1.AzureClusterIdentitySpec{Type: infrav1.UserAssignedMSI},
+			expectedError: "AzureClusterIdentity is not of type Service Principal, Service Principal Certificate or Workload Identity",
+		},
+	}
+
+	SetWorkloadIdentityAllowlist(WorkloadIdentityAllowlist{
+		ServiceAccounts: []string{"workload-identity"},
+		Audiences:       []string{"api://AzureADTokenExchange"},
+		TokenFilePaths:  []string{"/var/run/secrets/azure/tokens/azure-identity-token"},
+	})
+	defer SetWorkloadIdentityAllowlist(WorkloadIdentityAllowlist{Audiences: []string{defaultWorkloadIdentityAudience}})
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			namespace := tc.namespace
+			if namespace == "" {
+				namespace = system.GetManagerNamespace()
+			}
+			err := validateIdentity(&infrav1.AzureClusterIdentity{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}, Spec: tc.spec})
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
@@ -18,26 +18,99 @@ package agentpools
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2020-02-01/containerservice"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
+const (
+	// replacementOfTag is the tag of a temporary agent pool which holds the workloads of the agent pool named by its
+	// value while that agent pool is replaced to change its availability zones.
+	replacementOfTag = infrav1.NameAzureProviderPrefix + "replacement-of"
+
+	// replacementPoolTag is the tag of a replaced agent pool whose temporary agent pool, named by its value, has not
+	// been removed yet.
+	replacementPoolTag = infrav1.NameAzureProviderPrefix + "replacement-pool"
+
+	// maxAgentPoolNameLength and maxWindowsAgentPoolNameLength are the maximum lengths of agent pool names.
+	maxAgentPoolNameLength        = 12
+	maxWindowsAgentPoolNameLength = 6
+
+	// replacementRequeueAfter is how long to wait before the replacement of an agent pool is retried.
+	replacementRequeueAfter = 20 * time.Second
+)
+
 // Spec contains properties to create a agent pool.
 type Spec struct {
 	Name          string
 	ResourceGroup string
 	Cluster       string
-	Version       *string
-	SKU           string
-	Replicas      int32
-	OSDiskSizeGB  int32
-	VnetSubnetID  string
+
+	// Version is the Kubernetes version of the agent pool. When nil, an existing agent pool keeps its version.
+	Version *string
+
+	// DefaultVersion is the Kubernetes version a new agent pool is created with when Version is nil.
+	DefaultVersion *string
+
+	SKU          string
+	Replicas     int32
+	OSDiskSizeGB int32
+	OSType       string
+	VnetSubnetID string
+
+	// AvailabilityZones are the availability zones of the nodes. Changing them replaces the agent pool.
+	AvailabilityZones []string
+
+	// ScaleDownMode is the scale down mode of the agent pool. Possible values include: 'Delete', 'Deallocate'.
+	ScaleDownMode string
+
+	// MaxSurge is the number or percentage of extra nodes created during an upgrade.
+	MaxSurge string
+
+	// DrainTimeoutInMinutes is the time to wait for the eviction of the pods of a node during an upgrade.
+	DrainTimeoutInMinutes *int32
+
+	// NodeSoakDurationInMinutes is the time to wait after draining a node during an upgrade.
+	NodeSoakDurationInMinutes *int32
+
+	// UndrainableNodeBehavior is the behavior for nodes that can not be drained during an upgrade.
+	// Possible values include: 'Cordon', 'Schedule'.
+	UndrainableNodeBehavior string
+}
+
+// additionalProperties returns the agent pool properties which are not modelled by the
+// containerservice SDK package in use.
+func (s *Spec) additionalProperties() map[string]interface{} {
+	props := map[string]interface{}{}
+	if s.ScaleDownMode != "" {
+		props["scaleDownMode"] = s.ScaleDownMode
+	}
+
+	upgradeSettings := map[string]interface{}{}
+	if s.MaxSurge != "" {
+		upgradeSettings["maxSurge"] = s.MaxSurge
+	}
+	if s.DrainTimeoutInMinutes != nil {
+		upgradeSettings["drainTimeoutInMinutes"] = *s.DrainTimeoutInMinutes
+	}
+	if s.NodeSoakDurationInMinutes != nil {
+		upgradeSettings["nodeSoakDurationInMinutes"] = *s.NodeSoakDurationInMinutes
+	}
+	if s.UndrainableNodeBehavior != "" {
+		upgradeSettings["undrainableNodeBehavior"] = s.UndrainableNodeBehavior
+	}
+	if len(upgradeSettings) > 0 {
+		props["upgradeSettings"] = upgradeSettings
+	}
+	return props
 }
 
 // Reconcile idempotently creates or updates a agent pool, if possible.
@@ -50,10 +123,15 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 		return errors.New("invalid agent pool specification")
 	}
 
+	osType := containerservice.Linux
+	if agentPoolSpec.OSType != "" {
+		osType = containerservice.OSType(agentPoolSpec.OSType)
+	}
+
 	profile := containerservice.AgentPool{
 		ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 			VMSize:              containerservice.VMSizeTypes(agentPoolSpec.SKU),
-			OsType:              containerservice.Linux,
+			OsType:              osType,
 			OsDiskSizeGB:        &agentPoolSpec.OSDiskSizeGB,
 			Count:               &agentPoolSpec.Replicas,
 			Type:                containerservice.VirtualMachineScaleSets,
@@ -61,8 +139,12 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 			VnetSubnetID:        &agentPoolSpec.VnetSubnetID,
 		},
 	}
+	if len(agentPoolSpec.AvailabilityZones) > 0 {
+		profile.AvailabilityZones = &agentPoolSpec.AvailabilityZones
+	}
 
-	existingPool, err := s.Client.Get(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name)
+	additionalProperties := agentPoolSpec.additionalProperties()
+	existingPool, existingProperties, err := s.Client.Get(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name, len(additionalProperties) > 0)
 	if err != nil && !azure.ResourceNotFound(err) {
 		return errors.Wrap(err, "failed to get existing agent pool")
 	}
@@ -73,7 +155,21 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 	// to strip/clean to match what we expect.
 	isCreate := azure.ResourceNotFound(err)
 	if isCreate {
-		err = s.Client.CreateOrUpdate(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name, profile)
+		if profile.OrchestratorVersion == nil {
+			profile.OrchestratorVersion = agentPoolSpec.DefaultVersion
+		}
+
+		// The agent pool may have been deleted by a replacement which was interrupted before it was recreated.
+		replacementName := replacementPoolName(agentPoolSpec.Name, osType)
+		replacement, err := s.getAgentPool(ctx, agentPoolSpec, replacementName)
+		if err != nil {
+			return err
+		}
+		if isReplacementOf(replacement, agentPoolSpec.Name) {
+			return s.recreateAgentPool(ctx, agentPoolSpec, profile, additionalProperties, replacementName)
+		}
+
+		err = s.Client.CreateOrUpdate(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name, profile, additionalProperties)
 		if err != nil {
 			return errors.Wrap(err, "failed to create or update agent pool")
 		}
@@ -84,24 +180,39 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 			return nil
 		}
 
+		if profile.OrchestratorVersion == nil {
+			profile.OrchestratorVersion = existingPool.ManagedClusterAgentPoolProfileProperties.OrchestratorVersion
+		}
+
+		// Finish a replacement whose temporary agent pool has not been removed yet.
+		if replacementName := existingPool.Tags[replacementPoolTag]; replacementName != nil {
+			return s.deleteReplacementPool(ctx, agentPoolSpec, profile, additionalProperties, *replacementName)
+		}
+
+		// AKS does not allow to change the availability zones of an agent pool, so it is replaced.
+		if !zonesEqual(profile.AvailabilityZones, existingPool.AvailabilityZones) {
+			return s.replaceAgentPool(ctx, agentPoolSpec, profile, additionalProperties, osType)
+		}
+
 		// Normalize individual agent pools to diff in case we need to update
 		existingProfile := containerservice.AgentPool{
 			ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 				VMSize:              existingPool.ManagedClusterAgentPoolProfileProperties.VMSize,
-				OsType:              containerservice.Linux,
+				OsType:              existingPool.ManagedClusterAgentPoolProfileProperties.OsType,
 				OsDiskSizeGB:        existingPool.ManagedClusterAgentPoolProfileProperties.OsDiskSizeGB,
 				Count:               existingPool.ManagedClusterAgentPoolProfileProperties.Count,
 				Type:                containerservice.VirtualMachineScaleSets,
 				OrchestratorVersion: existingPool.ManagedClusterAgentPoolProfileProperties.OrchestratorVersion,
 				VnetSubnetID:        existingPool.ManagedClusterAgentPoolProfileProperties.VnetSubnetID,
+				AvailabilityZones:   profile.AvailabilityZones,
 			},
 		}
 
 		// Diff and check if we require an update
-		diff := cmp.Diff(profile, existingProfile)
+		diff := cmp.Diff(profile, existingProfile) + azure.DiffAdditionalProperties(additionalProperties, existingProperties)
 		if diff != "" {
 			klog.V(2).Infof("Update required (+new -old):\n%s", diff)
-			err = s.Client.CreateOrUpdate(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name, profile)
+			err = s.Client.CreateOrUpdate(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name, profile, additionalProperties)
 			if err != nil {
 				return errors.Wrap(err, "failed to create or update agent pool")
 			}
@@ -113,6 +224,152 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 	return nil
 }
 
+// replaceAgentPool replaces an agent pool to change its availability zones. The workloads of the agent pool are moved
+// to a temporary agent pool with the new zones while the agent pool is deleted and recreated.
+func (s *Service) replaceAgentPool(ctx context.Context, spec *Spec, profile containerservice.AgentPool, additionalProperties map[string]interface{}, osType containerservice.OSType) error {
+	ctx, span := tele.Tracer().Start(ctx, "agentpools.Service.replaceAgentPool")
+	defer span.End()
+
+	replacementName := replacementPoolName(spec.Name, osType)
+	replacement, err := s.getAgentPool(ctx, spec, replacementName)
+	if err != nil {
+		return err
+	}
+	switch {
+	case replacement == nil:
+		klog.V(2).Infof("Creating agent pool %s to replace agent pool %s", replacementName, spec.Name)
+		err = s.Client.CreateOrUpdate(ctx, spec.ResourceGroup, spec.Cluster, replacementName, withTags(profile, map[string]*string{replacementOfTag: &spec.Name}), additionalProperties)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create agent pool %s to replace agent pool %s", replacementName, spec.Name)
+		}
+	case !isReplacementOf(replacement, spec.Name):
+		return errors.Errorf("can't replace agent pool %s to change its availability zones: agent pool %s already exists", spec.Name, replacementName)
+	case replacement.ProvisioningState == nil || *replacement.ProvisioningState != "Succeeded":
+		return azure.WithTransientError(errors.Errorf("agent pool %s replacing agent pool %s is not ready yet", replacementName, spec.Name), replacementRequeueAfter)
+	}
+
+	if err := s.Drainer.DrainNodes(ctx, spec.Name); err != nil {
+		return azure.WithTransientError(errors.Wrapf(err, "failed to drain agent pool %s", spec.Name), replacementRequeueAfter)
+	}
+	if err := s.deleteAgentPool(ctx, spec, spec.Name); err != nil {
+		return err
+	}
+
+	return s.recreateAgentPool(ctx, spec, profile, additionalProperties, replacementName)
+}
+
+// recreateAgentPool creates a replaced agent pool with its new availability zones and removes its temporary agent pool.
+func (s *Service) recreateAgentPool(ctx context.Context, spec *Spec, profile containerservice.AgentPool, additionalProperties map[string]interface{}, replacementName string) error {
+	klog.V(2).Infof("Recreating agent pool %s", spec.Name)
+	err := s.Client.CreateOrUpdate(ctx, spec.ResourceGroup, spec.Cluster, spec.Name, withTags(profile, map[string]*string{replacementPoolTag: &replacementName}), additionalProperties)
+	if err != nil {
+		return errors.Wrap(err, "failed to create or update agent pool")
+	}
+
+	return s.deleteReplacementPool(ctx, spec, profile, additionalProperties, replacementName)
+}
+
+// deleteReplacementPool drains and deletes the temporary agent pool of a recreated agent pool, then removes the tag
+// which marks the replacement as unfinished.
+func (s *Service) deleteReplacementPool(ctx context.Context, spec *Spec, profile containerservice.AgentPool, additionalProperties map[string]interface{}, replacementName string) error {
+	if err := s.Drainer.DrainNodes(ctx, replacementName); err != nil {
+		return azure.WithTransientError(errors.Wrapf(err, "failed to drain agent pool %s", replacementName), replacementRequeueAfter)
+	}
+	if err := s.deleteAgentPool(ctx, spec, replacementName); err != nil {
+		return err
+	}
+
+	err := s.Client.CreateOrUpdate(ctx, spec.ResourceGroup, spec.Cluster, spec.Name, withTags(profile, map[string]*string{}), additionalProperties)
+	if err != nil {
+		return errors.Wrap(err, "failed to create or update agent pool")
+	}
+
+	klog.V(2).Infof("Successfully replaced agent pool %s", spec.Name)
+	return nil
+}
+
+// getAgentPool returns the agent pool with the given name in the cluster of the spec, or nil if it does not exist.
+func (s *Service) getAgentPool(ctx context.Context, spec *Spec, name string) (*containerservice.AgentPool, error) {
+	agentPool, _, err := s.Client.Get(ctx, spec.ResourceGroup, spec.Cluster, name, false)
+	if azure.ResourceNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get agent pool %s", name)
+	}
+	return &agentPool, nil
+}
+
+// deleteAgentPool deletes the agent pool with the given name in the cluster of the spec, if it exists.
+func (s *Service) deleteAgentPool(ctx context.Context, spec *Spec, name string) error {
+	klog.V(2).Infof("deleting agent pool  %s ", name)
+	err := s.Client.Delete(ctx, spec.ResourceGroup, spec.Cluster, name)
+	if err != nil {
+		if azure.ResourceNotFound(err) {
+			// already deleted
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete agent pool %s in resource group %s", name, spec.ResourceGroup)
+	}
+
+	klog.V(2).Infof("Successfully deleted agent pool %s ", name)
+	return nil
+}
+
+// replacementPoolName returns the name of the temporary agent pool which replaces the agent pool with the given name.
+func replacementPoolName(name string, osType containerservice.OSType) string {
+	maxLength := maxAgentPoolNameLength
+	if osType == containerservice.Windows {
+		maxLength = maxWindowsAgentPoolNameLength
+	}
+	prefix := name
+	if len(prefix) >= maxLength {
+		prefix = prefix[:maxLength-1]
+	}
+	if prefix+"r" == name {
+		return prefix + "s"
+	}
+	return prefix + "r"
+}
+
+// isReplacementOf returns true if the agent pool is the temporary agent pool of the agent pool with the given name.
+func isReplacementOf(agentPool *containerservice.AgentPool, name string) bool {
+	if agentPool == nil || agentPool.ManagedClusterAgentPoolProfileProperties == nil {
+		return false
+	}
+	replaced := agentPool.Tags[replacementOfTag]
+	return replaced != nil && *replaced == name
+}
+
+// withTags returns a copy of the agent pool with the given tags.
+func withTags(agentPool containerservice.AgentPool, tags map[string]*string) containerservice.AgentPool {
+	properties := *agentPool.ManagedClusterAgentPoolProfileProperties
+	properties.Tags = tags
+	return containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: &properties}
+}
+
+// zonesEqual returns true if both lists contain the same availability zones, in any order.
+func zonesEqual(a, b *[]string) bool {
+	var x, y []string
+	if a != nil {
+		x = append(x, *a...)
+	}
+	if b != nil {
+		y = append(y, *b...)
+	}
+	if len(x) != len(y) {
+		return false
+	}
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // Delete deletes the virtual network with the provided name.
 func (s *Service) Delete(ctx context.Context, spec interface{}) error {
 	ctx, span := tele.Tracer().Start(ctx, "agentpools.Service.Delete")
@@ -123,16 +380,18 @@ func (s *Service) Delete(ctx context.Context, spec interface{}) error {
 		return errors.New("invalid agent pool specification")
 	}
 
-	klog.V(2).Infof("deleting agent pool  %s ", agentPoolSpec.Name)
-	err := s.Client.Delete(ctx, agentPoolSpec.ResourceGroup, agentPoolSpec.Cluster, agentPoolSpec.Name)
-	if err != nil {
-		if azure.ResourceNotFound(err) {
-			// already deleted
-			return nil
-		}
-		return errors.Wrapf(err, "failed to delete agent pool %s in resource group %s", agentPoolSpec.Name, agentPoolSpec.ResourceGroup)
+	if err := s.deleteAgentPool(ctx, agentPoolSpec, agentPoolSpec.Name); err != nil {
+		return err
 	}
 
-	klog.V(2).Infof("Successfully deleted agent pool %s ", agentPoolSpec.Name)
+	// Delete the temporary agent pool of an unfinished replacement.
+	replacementName := replacementPoolName(agentPoolSpec.Name, containerservice.OSType(agentPoolSpec.OSType))
+	replacement, err := s.getAgentPool(ctx, agentPoolSpec, replacementName)
+	if err != nil {
+		return err
+	}
+	if isReplacementOf(replacement, agentPoolSpec.Name) {
+		return s.deleteAgentPool(ctx, agentPoolSpec, replacementName)
+	}
 	return nil
 }
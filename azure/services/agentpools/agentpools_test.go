@@ -18,6 +18,7 @@ package agentpools
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 
@@ -78,10 +79,10 @@ func TestReconcile(t *testing.T) {
 			provisioningStatesToTest: []string{"Canceled", "Succeeded", "Failed"},
 			expectedError:            "",
 			expect: func(m *mock_agentpools.MockClientMockRecorder, provisioningstate string) {
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", gomock.Any()).Return(nil)
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool").Return(containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", gomock.Any(), gomock.Any()).Return(nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", false).Return(containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 					ProvisioningState: &provisioningstate,
-				}}, nil)
+				}}, nil, nil)
 			},
 		},
 		{
@@ -94,9 +95,9 @@ func TestReconcile(t *testing.T) {
 			provisioningStatesToTest: []string{"Deleting", "InProgress", "randomStringHere"},
 			expectedError:            "",
 			expect: func(m *mock_agentpools.MockClientMockRecorder, provisioningstate string) {
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool").Return(containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", false).Return(containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 					ProvisioningState: &provisioningstate,
-				}}, nil)
+				}}, nil, nil)
 			},
 		},
 	}
@@ -136,6 +137,7 @@ func TestReconcile(t *testing.T) {
 		agentPoolsSpec Spec
 		expectedError  string
 		expect         func(m *mock_agentpools.MockClientMockRecorder)
+		expectDrain    func(d *mock_agentpools.MockNodeDrainerMockRecorder)
 	}{
 		{
 			name: "no agentpool exists",
@@ -146,8 +148,9 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", gomock.Any()).Return(nil)
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool").Return(containerservice.AgentPool{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", gomock.Any(), gomock.Any()).Return(nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpool", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agentpoor", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
 			},
 		},
 		{
@@ -163,7 +166,7 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "failed to get existing agent pool: #: Internal Server Error: StatusCode=500",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool").Return(containerservice.AgentPool{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
 			},
 		},
 		{
@@ -179,8 +182,66 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool").Return(containerservice.AgentPool{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{})).Return(nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-por", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{}), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "can create a Windows Agent Pool",
+			agentPoolsSpec: Spec{
+				Name:          "win0",
+				ResourceGroup: "my-rg",
+				Cluster:       "my-cluster",
+				SKU:           "SKU123",
+				Version:       to.StringPtr("9.99.9999"),
+				Replicas:      2,
+				OSDiskSizeGB:  100,
+				OSType:        "Windows",
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "win0", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "win0r", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "win0", containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						VMSize:              containerservice.VMSizeTypes("SKU123"),
+						OsType:              containerservice.Windows,
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						Count:               to.Int32Ptr(2),
+						Type:                containerservice.VirtualMachineScaleSets,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						VnetSubnetID:        to.StringPtr(""),
+					},
+				}, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "create an Agent Pool without a version with the default version",
+			agentPoolsSpec: Spec{
+				Name:           "my-agent-pool",
+				ResourceGroup:  "my-rg",
+				Cluster:        "my-cluster",
+				SKU:            "SKU123",
+				DefaultVersion: to.StringPtr("9.99.9999"),
+				Replicas:       2,
+				OSDiskSizeGB:   100,
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-por", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						VMSize:              containerservice.VMSizeTypes("SKU123"),
+						OsType:              containerservice.Linux,
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						Count:               to.Int32Ptr(2),
+						Type:                containerservice.VirtualMachineScaleSets,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						VnetSubnetID:        to.StringPtr(""),
+					},
+				}, gomock.Any()).Return(nil)
 			},
 		},
 		{
@@ -196,8 +257,9 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "failed to create or update agent pool: #: Internal Server Error: StatusCode=500",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool").Return(containerservice.AgentPool{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{})).Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-por", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{}), gomock.Any()).Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
 			},
 		},
 		{
@@ -213,7 +275,7 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "failed to create or update agent pool: #: Internal Server Error: StatusCode=500",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool").Return(containerservice.AgentPool{
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{
 					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 						Count:               to.Int32Ptr(3),
 						OsDiskSizeGB:        to.Int32Ptr(20),
@@ -221,8 +283,8 @@ func TestReconcile(t *testing.T) {
 						OrchestratorVersion: to.StringPtr("9.99.9999"),
 						ProvisioningState:   to.StringPtr("Failed"),
 					},
-				}, nil)
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{})).Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
+				}, nil, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{}), gomock.Any()).Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
 			},
 		},
 		{
@@ -238,7 +300,33 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
-				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool").Return(containerservice.AgentPool{
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						Count:               to.Int32Ptr(2),
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						VMSize:              containerservice.VMSizeTypesStandardD2sV3,
+						OsType:              containerservice.Linux,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						ProvisioningState:   to.StringPtr("Succeeded"),
+						VnetSubnetID:        to.StringPtr(""),
+					},
+				}, nil, nil)
+			},
+		},
+		{
+			name: "no update needed on Agent Pool without a version",
+			agentPoolsSpec: Spec{
+				Name:           "my-agent-pool",
+				ResourceGroup:  "my-rg",
+				Cluster:        "my-cluster",
+				SKU:            "Standard_D2s_v3",
+				DefaultVersion: to.StringPtr("10.0.0"),
+				Replicas:       2,
+				OSDiskSizeGB:   100,
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", false).Return(containerservice.AgentPool{
 					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
 						Count:               to.Int32Ptr(2),
 						OsDiskSizeGB:        to.Int32Ptr(100),
@@ -248,7 +336,285 @@ func TestReconcile(t *testing.T) {
 						ProvisioningState:   to.StringPtr("Succeeded"),
 						VnetSubnetID:        to.StringPtr(""),
 					},
-				}, nil)
+				}, nil, nil)
+			},
+		},
+		{
+			name: "no update needed on Agent Pool with zones in another order and matching scale down mode",
+			agentPoolsSpec: Spec{
+				Name:              "my-agent-pool",
+				ResourceGroup:     "my-rg",
+				Cluster:           "my-cluster",
+				SKU:               "Standard_D2s_v3",
+				Version:           to.StringPtr("9.99.9999"),
+				Replicas:          2,
+				OSDiskSizeGB:      100,
+				AvailabilityZones: []string{"1", "2"},
+				ScaleDownMode:     "Deallocate",
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", true).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						Count:               to.Int32Ptr(2),
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						VMSize:              containerservice.VMSizeTypesStandardD2sV3,
+						OsType:              containerservice.Linux,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						ProvisioningState:   to.StringPtr("Succeeded"),
+						VnetSubnetID:        to.StringPtr(""),
+						AvailabilityZones:   &[]string{"2", "1"},
+					},
+				}, map[string]interface{}{"scaleDownMode": "Deallocate"}, nil)
+			},
+		},
+		{
+			name: "update Agent Pool when the scale down mode changed",
+			agentPoolsSpec: Spec{
+				Name:          "my-agent-pool",
+				ResourceGroup: "my-rg",
+				Cluster:       "my-cluster",
+				SKU:           "Standard_D2s_v3",
+				Version:       to.StringPtr("9.99.9999"),
+				Replicas:      2,
+				OSDiskSizeGB:  100,
+				ScaleDownMode: "Deallocate",
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", true).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						Count:               to.Int32Ptr(2),
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						VMSize:              containerservice.VMSizeTypesStandardD2sV3,
+						OsType:              containerservice.Linux,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						ProvisioningState:   to.StringPtr("Succeeded"),
+						VnetSubnetID:        to.StringPtr(""),
+					},
+				}, map[string]interface{}{"scaleDownMode": "Delete"}, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{}), map[string]interface{}{"scaleDownMode": "Deallocate"}).Return(nil)
+			},
+		},
+		{
+			name: "update Agent Pool in place when the upgrade settings changed",
+			agentPoolsSpec: Spec{
+				Name:                      "my-agent-pool",
+				ResourceGroup:             "my-rg",
+				Cluster:                   "my-cluster",
+				SKU:                       "Standard_D2s_v3",
+				Version:                   to.StringPtr("9.99.9999"),
+				Replicas:                  2,
+				OSDiskSizeGB:              100,
+				MaxSurge:                  "33%",
+				DrainTimeoutInMinutes:     to.Int32Ptr(45),
+				NodeSoakDurationInMinutes: to.Int32Ptr(5),
+				UndrainableNodeBehavior:   "Cordon",
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", true).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						Count:               to.Int32Ptr(2),
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						VMSize:              containerservice.VMSizeTypesStandardD2sV3,
+						OsType:              containerservice.Linux,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						ProvisioningState:   to.StringPtr("Succeeded"),
+						VnetSubnetID:        to.StringPtr(""),
+					},
+				}, map[string]interface{}{"upgradeSettings": map[string]interface{}{"maxSurge": "10%", "drainTimeoutInMinutes": float64(30)}}, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", gomock.AssignableToTypeOf(containerservice.AgentPool{}), map[string]interface{}{
+					"upgradeSettings": map[string]interface{}{
+						"maxSurge":                  "33%",
+						"drainTimeoutInMinutes":     int32(45),
+						"nodeSoakDurationInMinutes": int32(5),
+						"undrainableNodeBehavior":   "Cordon",
+					},
+				}).Return(nil)
+			},
+		},
+		{
+			name: "no update needed on Agent Pool with matching upgrade settings",
+			agentPoolsSpec: Spec{
+				Name:                  "my-agent-pool",
+				ResourceGroup:         "my-rg",
+				Cluster:               "my-cluster",
+				SKU:                   "Standard_D2s_v3",
+				Version:               to.StringPtr("9.99.9999"),
+				Replicas:              2,
+				OSDiskSizeGB:          100,
+				MaxSurge:              "1",
+				DrainTimeoutInMinutes: to.Int32Ptr(30),
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool", true).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						Count:               to.Int32Ptr(2),
+						OsDiskSizeGB:        to.Int32Ptr(100),
+						VMSize:              containerservice.VMSizeTypesStandardD2sV3,
+						OsType:              containerservice.Linux,
+						OrchestratorVersion: to.StringPtr("9.99.9999"),
+						ProvisioningState:   to.StringPtr("Succeeded"),
+						VnetSubnetID:        to.StringPtr(""),
+					},
+				}, map[string]interface{}{"upgradeSettings": map[string]interface{}{"maxSurge": "1", "drainTimeoutInMinutes": float64(30), "nodeSoakDurationInMinutes": float64(0)}}, nil)
+			},
+		},
+		{
+			name: "replace Agent Pool through a temporary agent pool when the availability zones changed",
+			agentPoolsSpec: Spec{
+				Name:              "pool0",
+				ResourceGroup:     "my-rg",
+				Cluster:           "my-cluster",
+				SKU:               "Standard_D2s_v3",
+				Version:           to.StringPtr("9.99.9999"),
+				Replicas:          2,
+				OSDiskSizeGB:      100,
+				AvailabilityZones: []string{"1", "2", "3"},
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				gomock.InOrder(
+					m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(containerservice.AgentPool{
+						ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+							ProvisioningState: to.StringPtr("Succeeded"),
+							AvailabilityZones: &[]string{"1"},
+						},
+					}, nil, nil),
+					m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found")),
+					m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r", agentPoolWithTags(map[string]*string{replacementOfTag: to.StringPtr("pool0")}), gomock.Any()).Return(nil),
+					m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0").Return(nil),
+					m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", agentPoolWithTags(map[string]*string{replacementPoolTag: to.StringPtr("pool0r")}), gomock.Any()).Return(nil),
+					m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r").Return(nil),
+					m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", agentPoolWithTags(map[string]*string{}), gomock.Any()).Return(nil),
+				)
+			},
+			expectDrain: func(d *mock_agentpools.MockNodeDrainerMockRecorder) {
+				gomock.InOrder(
+					d.DrainNodes(gomockinternal.AContext(), "pool0").Return(nil),
+					d.DrainNodes(gomockinternal.AContext(), "pool0r").Return(nil),
+				)
+			},
+		},
+		{
+			name: "requeue the replacement of an Agent Pool when its nodes can't be drained",
+			agentPoolsSpec: Spec{
+				Name:              "pool0",
+				ResourceGroup:     "my-rg",
+				Cluster:           "my-cluster",
+				SKU:               "Standard_D2s_v3",
+				Version:           to.StringPtr("9.99.9999"),
+				Replicas:          2,
+				OSDiskSizeGB:      100,
+				AvailabilityZones: []string{"1", "2", "3"},
+			},
+			expectedError: "transient reconcile error occurred: failed to drain agent pool pool0: pod disruption budget violated. Object will be requeued after 20s",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						ProvisioningState: to.StringPtr("Succeeded"),
+						AvailabilityZones: &[]string{"1"},
+					},
+				}, nil, nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r", false).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						ProvisioningState: to.StringPtr("Succeeded"),
+						Tags:              map[string]*string{replacementOfTag: to.StringPtr("pool0")},
+					},
+				}, nil, nil)
+			},
+			expectDrain: func(d *mock_agentpools.MockNodeDrainerMockRecorder) {
+				d.DrainNodes(gomockinternal.AContext(), "pool0").Return(errors.New("pod disruption budget violated"))
+			},
+		},
+		{
+			name: "fail to replace an Agent Pool when an agent pool with the name of the temporary agent pool exists",
+			agentPoolsSpec: Spec{
+				Name:              "pool0",
+				ResourceGroup:     "my-rg",
+				Cluster:           "my-cluster",
+				SKU:               "Standard_D2s_v3",
+				Version:           to.StringPtr("9.99.9999"),
+				Replicas:          2,
+				OSDiskSizeGB:      100,
+				AvailabilityZones: []string{"1", "2", "3"},
+			},
+			expectedError: "can't replace agent pool pool0 to change its availability zones: agent pool pool0r already exists",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						ProvisioningState: to.StringPtr("Succeeded"),
+						AvailabilityZones: &[]string{"1"},
+					},
+				}, nil, nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r", false).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						ProvisioningState: to.StringPtr("Succeeded"),
+					},
+				}, nil, nil)
+			},
+		},
+		{
+			name: "recreate an Agent Pool deleted by an interrupted replacement",
+			agentPoolsSpec: Spec{
+				Name:              "pool0",
+				ResourceGroup:     "my-rg",
+				Cluster:           "my-cluster",
+				SKU:               "Standard_D2s_v3",
+				Version:           to.StringPtr("9.99.9999"),
+				Replicas:          2,
+				OSDiskSizeGB:      100,
+				AvailabilityZones: []string{"1", "2", "3"},
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				gomock.InOrder(
+					m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found")),
+					m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r", false).Return(containerservice.AgentPool{
+						ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+							ProvisioningState: to.StringPtr("Succeeded"),
+							Tags:              map[string]*string{replacementOfTag: to.StringPtr("pool0")},
+						},
+					}, nil, nil),
+					m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", agentPoolWithTags(map[string]*string{replacementPoolTag: to.StringPtr("pool0r")}), gomock.Any()).Return(nil),
+					m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r").Return(nil),
+					m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", agentPoolWithTags(map[string]*string{}), gomock.Any()).Return(nil),
+				)
+			},
+			expectDrain: func(d *mock_agentpools.MockNodeDrainerMockRecorder) {
+				d.DrainNodes(gomockinternal.AContext(), "pool0r").Return(nil)
+			},
+		},
+		{
+			name: "remove the temporary agent pool of a recreated Agent Pool",
+			agentPoolsSpec: Spec{
+				Name:              "pool0",
+				ResourceGroup:     "my-rg",
+				Cluster:           "my-cluster",
+				SKU:               "Standard_D2s_v3",
+				Version:           to.StringPtr("9.99.9999"),
+				Replicas:          2,
+				OSDiskSizeGB:      100,
+				AvailabilityZones: []string{"1", "2", "3"},
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				gomock.InOrder(
+					m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(containerservice.AgentPool{
+						ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+							ProvisioningState: to.StringPtr("Succeeded"),
+							AvailabilityZones: &[]string{"1", "2", "3"},
+							Tags:              map[string]*string{replacementPoolTag: to.StringPtr("pool0r")},
+						},
+					}, nil, nil),
+					m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0r").Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found")),
+					m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", agentPoolWithTags(map[string]*string{}), gomock.Any()).Return(nil),
+				)
+			},
+			expectDrain: func(d *mock_agentpools.MockNodeDrainerMockRecorder) {
+				d.DrainNodes(gomockinternal.AContext(), "pool0r").Return(nil)
 			},
 		},
 	}
@@ -263,11 +629,16 @@ func TestReconcile(t *testing.T) {
 			defer mockCtrl.Finish()
 
 			agentpoolsMock := mock_agentpools.NewMockClient(mockCtrl)
+			drainerMock := mock_agentpools.NewMockNodeDrainer(mockCtrl)
 
 			tc.expect(agentpoolsMock.EXPECT())
+			if tc.expectDrain != nil {
+				tc.expectDrain(drainerMock.EXPECT())
+			}
 
 			s := &Service{
-				Client: agentpoolsMock,
+				Client:  agentpoolsMock,
+				Drainer: drainerMock,
 			}
 
 			err := s.Reconcile(context.TODO(), &tc.agentPoolsSpec)
@@ -281,6 +652,23 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+// agentPoolWithTags returns the agent pool of the availability zone replacement test cases with the given tags.
+func agentPoolWithTags(tags map[string]*string) containerservice.AgentPool {
+	return containerservice.AgentPool{
+		ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+			VMSize:              containerservice.VMSizeTypesStandardD2sV3,
+			OsType:              containerservice.Linux,
+			OsDiskSizeGB:        to.Int32Ptr(100),
+			Count:               to.Int32Ptr(2),
+			Type:                containerservice.VirtualMachineScaleSets,
+			OrchestratorVersion: to.StringPtr("9.99.9999"),
+			VnetSubnetID:        to.StringPtr(""),
+			AvailabilityZones:   &[]string{"1", "2", "3"},
+			Tags:                tags,
+		},
+	}
+}
+
 func TestDeleteAgentPools(t *testing.T) {
 	testcases := []struct {
 		name           string
@@ -298,6 +686,7 @@ func TestDeleteAgentPools(t *testing.T) {
 			expectedError: "",
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
 				m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool")
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-por", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
 			},
 		},
 		{
@@ -311,6 +700,26 @@ func TestDeleteAgentPools(t *testing.T) {
 			expect: func(m *mock_agentpools.MockClientMockRecorder) {
 				m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-pool").
 					Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "my-agent-por", false).Return(containerservice.AgentPool{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+			},
+		},
+		{
+			name: "delete the temporary agent pool of an unfinished replacement",
+			agentPoolsSpec: Spec{
+				Name:          "win0",
+				ResourceGroup: "my-rg",
+				Cluster:       "my-cluster",
+				OSType:        "Windows",
+			},
+			expectedError: "",
+			expect: func(m *mock_agentpools.MockClientMockRecorder) {
+				m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "win0")
+				m.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "win0r", false).Return(containerservice.AgentPool{
+					ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+						Tags: map[string]*string{replacementOfTag: to.StringPtr("win0")},
+					},
+				}, nil, nil)
+				m.Delete(gomockinternal.AContext(), "my-rg", "my-cluster", "win0r")
 			},
 		},
 		{
@@ -354,3 +763,24 @@ func TestDeleteAgentPools(t *testing.T) {
 		})
 	}
 }
+
+func TestReplacementPoolName(t *testing.T) {
+	testcases := []struct {
+		name     string
+		osType   containerservice.OSType
+		expected string
+	}{
+		{name: "pool0", osType: containerservice.Linux, expected: "pool0r"},
+		{name: "abcdefghijkl", osType: containerservice.Linux, expected: "abcdefghijkr"},
+		{name: "abcdefghijkr", osType: containerservice.Linux, expected: "abcdefghijks"},
+		{name: "win0", osType: containerservice.Windows, expected: "win0r"},
+		{name: "win012", osType: containerservice.Windows, expected: "win01r"},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(replacementPoolName(tc.name, tc.osType)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -29,11 +29,20 @@ import (
 
 // Client wraps go-sdk.
 type Client interface {
-	Get(context.Context, string, string, string) (containerservice.AgentPool, error)
-	CreateOrUpdate(context.Context, string, string, string, containerservice.AgentPool) error
+	Get(context.Context, string, string, string, bool) (containerservice.AgentPool, map[string]interface{}, error)
+	CreateOrUpdate(context.Context, string, string, string, containerservice.AgentPool, map[string]interface{}) error
 	Delete(context.Context, string, string, string) error
 }
 
+// NodeDrainer cordons and drains the nodes of agent pools.
+type NodeDrainer interface {
+	DrainNodes(context.Context, string) error
+}
+
+// additionalPropertiesAPIVersion is the AKS API version used to read and write the agent pool properties
+// which are not modelled by the containerservice SDK package in use.
+const additionalPropertiesAPIVersion = "2024-05-01"
+
 // AzureClient contains the Azure go-sdk Client.
 type AzureClient struct {
 	agentpools containerservice.AgentPoolsClient
@@ -54,24 +63,63 @@ func newAgentPoolsClient(subscriptionID string, baseURI string, authorizer autor
 	return agentPoolsClient
 }
 
-// Get gets an agent pool.
-func (ac *AzureClient) Get(ctx context.Context, resourceGroupName, cluster, name string) (containerservice.AgentPool, error) {
+// Get gets an agent pool, along with its properties as returned by the AKS API. With additional properties, the
+// agent pool is read with the API version the additional properties need, and the properties include those not
+// modelled by the containerservice SDK package in use. Otherwise, it is read with the API version of the package.
+func (ac *AzureClient) Get(ctx context.Context, resourceGroupName, cluster, name string, withAdditionalProperties bool) (containerservice.AgentPool, map[string]interface{}, error) {
 	ctx, span := tele.Tracer().Start(ctx, "agentpools.AzureClient.Get")
 	defer span.End()
 
-	return ac.agentpools.Get(ctx, resourceGroupName, cluster, name)
+	req, err := ac.agentpools.GetPreparer(ctx, resourceGroupName, cluster, name)
+	if err != nil {
+		return containerservice.AgentPool{}, nil, autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "Get", nil, "Failure preparing request")
+	}
+
+	if withAdditionalProperties {
+		req, err = autorest.Prepare(req, azure.WithAPIVersion(additionalPropertiesAPIVersion))
+		if err != nil {
+			return containerservice.AgentPool{}, nil, autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "Get", nil, "Failure preparing request")
+		}
+	}
+
+	resp, err := ac.agentpools.GetSender(req)
+	if err != nil {
+		return containerservice.AgentPool{}, nil, autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "Get", resp, "Failure sending request")
+	}
+
+	var properties map[string]interface{}
+	if err := autorest.Respond(resp, azure.ByCapturingAdditionalProperties(&properties)); err != nil {
+		return containerservice.AgentPool{}, nil, autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "Get", resp, "Failure responding to request")
+	}
+
+	agentPool, err := ac.agentpools.GetResponder(resp)
+	if err != nil {
+		return agentPool, nil, autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "Get", resp, "Failure responding to request")
+	}
+	return agentPool, properties, nil
 }
 
-// CreateOrUpdate creates or updates an agent pool.
-func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, cluster, name string, properties containerservice.AgentPool) error {
+// CreateOrUpdate creates or updates an agent pool. Additional properties, if any, are merged into the
+// properties of the request body.
+func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, cluster, name string, properties containerservice.AgentPool, additionalProperties map[string]interface{}) error {
 	ctx, span := tele.Tracer().Start(ctx, "agentpools.AzureClient.CreateOrUpdate")
 	defer span.End()
 
-	future, err := ac.agentpools.CreateOrUpdate(ctx, resourceGroupName, cluster, name, properties)
+	req, err := ac.agentpools.CreateOrUpdatePreparer(ctx, resourceGroupName, cluster, name, properties)
+	if err != nil {
+		return autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "CreateOrUpdate", nil, "Failure preparing request")
+	}
+
+	req, err = autorest.Prepare(req, azure.WithAdditionalProperties(additionalPropertiesAPIVersion, additionalProperties))
+	if err != nil {
+		return autorest.NewErrorWithError(err, "containerservice.AgentPoolsClient", "CreateOrUpdate", nil, "Failure preparing request")
+	}
+
+	future, err := ac.agentpools.CreateOrUpdateSender(req)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin operation")
 	}
-	if err := future.WaitForCompletionRef(ctx, ac.agentpools.Client); err != nil {
+	if err := azure.WaitForCompletion(ctx, &future, ac.agentpools.Client); err != nil {
 		return errors.Wrap(err, "failed to end operation")
 	}
 	_, err = future.Result(ac.agentpools)
@@ -87,7 +135,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, cluster, n
 	if err != nil {
 		return errors.Wrap(err, "failed to begin operation")
 	}
-	if err := future.WaitForCompletionRef(ctx, ac.agentpools.Client); err != nil {
+	if err := azure.WaitForCompletion(ctx, &future, ac.agentpools.Client); err != nil {
 		return errors.Wrap(err, "failed to end operation")
 	}
 	_, err = future.Result(ac.agentpools)
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentpools
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/kubectl/pkg/drain"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// agentPoolNodeLabel is the label AKS sets on nodes to the name of their agent pool.
+	agentPoolNodeLabel = "agentpool"
+
+	// drainTimeout is how long the pods of a node are evicted before the drain fails and is retried on the next
+	// reconciliation, as the Machine controller of Cluster API does.
+	drainTimeout = 20 * time.Second
+)
+
+// workloadClusterDrainer drains nodes of the AKS cluster of a Cluster, which it accesses with the kubeconfig secret
+// of the Cluster.
+type workloadClusterDrainer struct {
+	kubeclient client.Client
+	cluster    client.ObjectKey
+}
+
+var _ NodeDrainer = &workloadClusterDrainer{}
+
+// NewNodeDrainer creates a NodeDrainer for the nodes of the AKS cluster of the given Cluster.
+func NewNodeDrainer(kubeclient client.Client, cluster client.ObjectKey) NodeDrainer {
+	return &workloadClusterDrainer{
+		kubeclient: kubeclient,
+		cluster:    cluster,
+	}
+}
+
+// DrainNodes cordons the nodes of an agent pool and evicts their pods, ignoring DaemonSet pods.
+func (d *workloadClusterDrainer) DrainNodes(ctx context.Context, agentPoolName string) error {
+	ctx, span := tele.Tracer().Start(ctx, "agentpools.workloadClusterDrainer.DrainNodes")
+	defer span.End()
+
+	restConfig, err := remote.RESTConfig(ctx, "capz-agentpools", d.kubeclient, d.cluster)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the REST config of the workload cluster")
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to create a client for the workload cluster")
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set{agentPoolNodeLabel: agentPoolName}.String(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the nodes of agent pool %s", agentPoolName)
+	}
+
+	helper := &drain.Helper{
+		Ctx:                 ctx,
+		Client:              clientset,
+		Force:               true,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  true,
+		GracePeriodSeconds:  -1,
+		Timeout:             drainTimeout,
+		Out:                 writer{klog.Info},
+		ErrOut:              writer{klog.Error},
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if err := drain.RunCordonOrUncordon(helper, node, true); err != nil {
+			return errors.Wrapf(err, "failed to cordon node %s", node.Name)
+		}
+		if err := drain.RunNodeDrain(helper, node.Name); err != nil {
+			return errors.Wrapf(err, "failed to drain node %s", node.Name)
+		}
+		klog.V(2).Infof("Drained node %s of agent pool %s", node.Name, agentPoolName)
+	}
+	return nil
+}
+
+// writer implements io.Writer interface as a pass-through for klog.
+type writer struct {
+	logFunc func(args ...interface{})
+}
+
+// Write passes string(p) into writer's logFunc and always returns len(p).
+func (w writer) Write(p []byte) (n int, err error) {
+	w.logFunc(string(p))
+	return len(p), nil
+}
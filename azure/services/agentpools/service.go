@@ -23,11 +23,13 @@ import (
 // Service provides operations on Azure resources.
 type Service struct {
 	Client
+	Drainer NodeDrainer
 }
 
 // NewService creates a new service.
-func NewService(auth azure.Authorizer) *Service {
+func NewService(auth azure.Authorizer, drainer NodeDrainer) *Service {
 	return &Service{
-		Client: NewClient(auth),
+		Client:  NewClient(auth),
+		Drainer: drainer,
 	}
 }
@@ -0,0 +1,399 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aksbackup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	dataProtectionAPIVersion          = "2023-05-01"
+	extensionsAPIVersion              = "2023-05-01"
+	managedClustersAPIVersion         = "2023-05-01"
+	trustedAccessAPIVersion           = "2023-05-01"
+	roleAssignmentsAPIVersion         = "2022-04-01"
+	extensionName                     = "azure-aks-backup"
+	extensionType                     = "microsoft.dataprotection.kubernetes"
+	managedClusterDatasourceType      = "Microsoft.ContainerService/managedClusters"
+	backupOperatorRole                = "Microsoft.DataProtection/backupVaults/backup-operator"
+	readerRoleDefinitionID            = "acdd72a7-3385-48ef-bd42-f606fba81ae7"
+	contributorRoleDefinitionID       = "b24988ac-6180-42a0-ab88-20f7382dd24c"
+	storageBlobContributorRoleDefinID = "ba92f5b4-2d11-453d-a403-e96b0029c9fe"
+)
+
+// BackupScope defines the scope interface for an AKS backup service.
+type BackupScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	AKSBackupSpec() *azure.AKSBackupSpec
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope BackupScope
+	client
+}
+
+// New creates a new service.
+func New(scope BackupScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: newClient(scope),
+	}
+}
+
+// Reconcile makes sure the managed cluster is backed up by a Backup Vault. It creates the vault, installs
+// the backup extension into the cluster, grants the required permissions and creates the backup policy
+// and instance. It does nothing if no backup is configured.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "aksbackup.Service.Reconcile")
+	defer span.End()
+
+	spec := s.Scope.AKSBackupSpec()
+	if spec == nil {
+		return nil
+	}
+
+	storageAccount, err := azureautorest.ParseResourceID(spec.StorageAccountID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse storage account ID %s", spec.StorageAccountID)
+	}
+
+	clusterID := azure.ManagedClusterID(s.Scope.SubscriptionID(), s.Scope.ResourceGroup(), spec.ManagedClusterName)
+	vaultID := azure.BackupVaultID(s.Scope.SubscriptionID(), s.Scope.ResourceGroup(), spec.VaultName)
+	resourceGroupID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", s.Scope.SubscriptionID(), s.Scope.ResourceGroup())
+
+	cluster, err := s.client.Get(ctx, clusterID, managedClustersAPIVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get managed cluster %s", spec.ManagedClusterName)
+	}
+	if cluster.Identity == nil || cluster.Identity.PrincipalID == nil {
+		return errors.Errorf("managed cluster %s has no system assigned identity", spec.ManagedClusterName)
+	}
+
+	vault, err := s.reconcileResource(ctx, vaultID, dataProtectionAPIVersion, resources.GenericResource{
+		Location: to.StringPtr(s.Scope.Location()),
+		Identity: &resources.Identity{Type: resources.SystemAssigned},
+		Properties: map[string]interface{}{
+			"storageSettings": []interface{}{
+				map[string]interface{}{
+					"datastoreType": "VaultStore",
+					"type":          "LocallyRedundant",
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconcile backup vault %s", spec.VaultName)
+	}
+	if vault.Identity == nil || vault.Identity.PrincipalID == nil {
+		return errors.Errorf("backup vault %s has no system assigned identity yet", spec.VaultName)
+	}
+
+	extensionSettings := map[string]interface{}{
+		"configuration.backupStorageLocation.bucket":                spec.BlobContainer,
+		"configuration.backupStorageLocation.config.resourceGroup":  storageAccount.ResourceGroup,
+		"configuration.backupStorageLocation.config.storageAccount": storageAccount.ResourceName,
+		"configuration.backupStorageLocation.config.subscriptionId": storageAccount.SubscriptionID,
+		"credentials.tenantId": spec.TenantID,
+	}
+	extension, err := s.reconcileResource(ctx, clusterID+"/providers/Microsoft.KubernetesConfiguration/extensions/"+extensionName, extensionsAPIVersion, resources.GenericResource{
+		Properties: map[string]interface{}{
+			"extensionType":           extensionType,
+			"releaseTrain":            "stable",
+			"autoUpgradeMinorVersion": true,
+			"configurationSettings":   extensionSettings,
+		},
+	}, map[string]interface{}{"configurationSettings": extensionSettings})
+	if err != nil {
+		return errors.Wrap(err, "failed to reconcile backup extension")
+	}
+	extensionPrincipalID := extensionPrincipalID(extension)
+	if extensionPrincipalID == "" {
+		return errors.New("backup extension has no identity yet")
+	}
+
+	if _, err := s.reconcileResource(ctx, clusterID+"/trustedAccessRoleBindings/"+spec.VaultName, trustedAccessAPIVersion, resources.GenericResource{
+		Properties: map[string]interface{}{
+			"sourceResourceId": vaultID,
+			"roles":            []interface{}{backupOperatorRole},
+		},
+	}, nil); err != nil {
+		return errors.Wrap(err, "failed to reconcile trusted access role binding for backup vault")
+	}
+
+	roleAssignments := []struct {
+		scope, roleDefinitionID, principalID string
+	}{
+		{clusterID, readerRoleDefinitionID, *vault.Identity.PrincipalID},
+		{resourceGroupID, readerRoleDefinitionID, *vault.Identity.PrincipalID},
+		{resourceGroupID, contributorRoleDefinitionID, *cluster.Identity.PrincipalID},
+		{spec.StorageAccountID, storageBlobContributorRoleDefinID, extensionPrincipalID},
+	}
+	for _, ra := range roleAssignments {
+		if err := s.reconcileRoleAssignment(ctx, ra.scope, ra.roleDefinitionID, ra.principalID); err != nil {
+			return err
+		}
+	}
+
+	policyID := vaultID + "/backupPolicies/" + spec.ManagedClusterName
+	if err := s.reconcileBackupPolicy(ctx, policyID, backupPolicyProperties(spec)); err != nil {
+		return errors.Wrapf(err, "failed to reconcile backup policy %s", spec.ManagedClusterName)
+	}
+
+	datasource := map[string]interface{}{
+		"datasourceType":   managedClusterDatasourceType,
+		"resourceID":       clusterID,
+		"resourceName":     spec.ManagedClusterName,
+		"resourceType":     managedClusterDatasourceType,
+		"resourceLocation": s.Scope.Location(),
+		"resourceUri":      clusterID,
+	}
+	policyInfo := map[string]interface{}{
+		"policyId": policyID,
+	}
+	if _, err := s.reconcileResource(ctx, vaultID+"/backupInstances/"+spec.ManagedClusterName, dataProtectionAPIVersion, resources.GenericResource{
+		Properties: map[string]interface{}{
+			"objectType":        "BackupInstance",
+			"friendlyName":      spec.ManagedClusterName,
+			"dataSourceInfo":    withObjectType(datasource, "Datasource"),
+			"dataSourceSetInfo": withObjectType(datasource, "DatasourceSet"),
+			"policyInfo": map[string]interface{}{
+				"policyId": policyID,
+				"policyParameters": map[string]interface{}{
+					"dataStoreParametersList": []interface{}{
+						map[string]interface{}{
+							"objectType":      "AzureOperationalStoreParameters",
+							"dataStoreType":   "OperationalStore",
+							"resourceGroupId": resourceGroupID,
+						},
+					},
+					"backupDatasourceParametersList": []interface{}{
+						map[string]interface{}{
+							"objectType":                   "KubernetesClusterBackupDatasourceParameters",
+							"includeClusterScopeResources": true,
+							"snapshotVolumes":              true,
+							"includedNamespaces":           spec.IncludedNamespaces,
+						},
+					},
+				},
+			},
+		},
+	}, map[string]interface{}{"policyInfo": policyInfo}); err != nil {
+		return errors.Wrapf(err, "failed to reconcile backup instance %s", spec.ManagedClusterName)
+	}
+
+	return nil
+}
+
+// Delete removes the backup instance of the managed cluster. The Backup Vault is kept, together with the
+// recovery points of the cluster and any other backups it holds, as are the backups in the storage account.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "aksbackup.Service.Delete")
+	defer span.End()
+
+	spec := s.Scope.AKSBackupSpec()
+	if spec == nil {
+		return nil
+	}
+
+	vaultID := azure.BackupVaultID(s.Scope.SubscriptionID(), s.Scope.ResourceGroup(), spec.VaultName)
+
+	s.Scope.V(2).Info("deleting backup instance", "backup instance", spec.ManagedClusterName)
+	if err := s.client.Delete(ctx, vaultID+"/backupInstances/"+spec.ManagedClusterName, dataProtectionAPIVersion); err != nil && !azure.ResourceNotFound(err) {
+		return errors.Wrapf(err, "failed to delete backup instance %s", spec.ManagedClusterName)
+	}
+
+	s.Scope.V(2).Info("successfully deleted backup instance", "backup instance", spec.ManagedClusterName)
+	return nil
+}
+
+// reconcileResource creates a resource if it does not exist yet. An existing resource is updated when
+// one of the properties in compare differs from what Azure reports; when compare is nil, existing
+// resources are left untouched.
+func (s *Service) reconcileResource(ctx context.Context, id, apiVersion string, resource resources.GenericResource, compare map[string]interface{}) (resources.GenericResource, error) {
+	existing, err := s.client.Get(ctx, id, apiVersion)
+	if err != nil && !azure.ResourceNotFound(err) {
+		return resources.GenericResource{}, err
+	}
+
+	if err == nil {
+		existingProperties, _ := existing.Properties.(map[string]interface{})
+		diff := azure.DiffAdditionalProperties(compare, existingProperties)
+		if diff == "" {
+			return existing, nil
+		}
+		s.Scope.V(2).Info("updating resource", "id", id, "diff", diff)
+	} else {
+		s.Scope.V(2).Info("creating resource", "id", id)
+	}
+
+	return s.client.CreateOrUpdate(ctx, id, apiVersion, resource)
+}
+
+// reconcileBackupPolicy creates the backup policy of the cluster if it does not exist yet, and updates it
+// in place when its schedule or retention differ from the desired ones.
+func (s *Service) reconcileBackupPolicy(ctx context.Context, id string, properties map[string]interface{}) error {
+	existing, err := s.client.Get(ctx, id, dataProtectionAPIVersion)
+	if err != nil && !azure.ResourceNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		existingProperties, _ := existing.Properties.(map[string]interface{})
+		diff := azure.DiffAdditionalProperties(backupPolicySettings(properties), backupPolicySettings(existingProperties))
+		if diff == "" {
+			return nil
+		}
+		s.Scope.V(2).Info("updating backup policy", "id", id, "diff", diff)
+	} else {
+		s.Scope.V(2).Info("creating backup policy", "id", id)
+	}
+
+	_, err = s.client.CreateOrUpdate(ctx, id, dataProtectionAPIVersion, resources.GenericResource{Properties: properties})
+	return err
+}
+
+// reconcileRoleAssignment assigns a role to a principal. The name of the role assignment is derived from
+// its scope, role and principal, so that it is only created once.
+func (s *Service) reconcileRoleAssignment(ctx context.Context, scope, roleDefinitionID, principalID string) error {
+	name := uuid.NewSHA1(uuid.NameSpaceURL, []byte(scope+roleDefinitionID+principalID)).String()
+	if _, err := s.reconcileResource(ctx, scope+"/providers/Microsoft.Authorization/roleAssignments/"+name, roleAssignmentsAPIVersion, resources.GenericResource{
+		Properties: map[string]interface{}{
+			"roleDefinitionId": fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", s.Scope.SubscriptionID(), roleDefinitionID),
+			"principalId":      principalID,
+			"principalType":    "ServicePrincipal",
+		},
+	}, nil); err != nil {
+		return errors.Wrapf(err, "failed to assign role %s on %s", roleDefinitionID, scope)
+	}
+	return nil
+}
+
+// backupPolicyProperties returns the properties of a backup policy taking operational tier backups of
+// a managed cluster in the configured interval and keeping them for the configured number of days.
+func backupPolicyProperties(spec *azure.AKSBackupSpec) map[string]interface{} {
+	operationalStore := map[string]interface{}{
+		"dataStoreType": "OperationalStore",
+		"objectType":    "DataStoreInfoBase",
+	}
+	return map[string]interface{}{
+		"objectType":      "BackupPolicy",
+		"datasourceTypes": []interface{}{managedClusterDatasourceType},
+		"policyRules": []interface{}{
+			map[string]interface{}{
+				"objectType": "AzureBackupRule",
+				"name":       "BackupHourly",
+				"backupParameters": map[string]interface{}{
+					"objectType": "AzureBackupParams",
+					"backupType": "Incremental",
+				},
+				"dataStore": operationalStore,
+				"trigger": map[string]interface{}{
+					"objectType": "ScheduleBasedTriggerContext",
+					"schedule": map[string]interface{}{
+						"repeatingTimeIntervals": []interface{}{fmt.Sprintf("R/2021-01-01T00:00:00+00:00/PT%dH", spec.BackupIntervalHours)},
+						"timeZone":               "UTC",
+					},
+					"taggingCriteria": []interface{}{
+						map[string]interface{}{
+							"isDefault":       true,
+							"taggingPriority": 99,
+							"tagInfo": map[string]interface{}{
+								"id":      "Default_",
+								"tagName": "Default",
+							},
+						},
+					},
+				},
+			},
+			map[string]interface{}{
+				"objectType": "AzureRetentionRule",
+				"name":       "Default",
+				"isDefault":  true,
+				"lifecycles": []interface{}{
+					map[string]interface{}{
+						"deleteAfter": map[string]interface{}{
+							"objectType": "AbsoluteDeleteOption",
+							"duration":   fmt.Sprintf("P%dD", spec.RetentionDays),
+						},
+						"sourceDataStore":             operationalStore,
+						"targetDataStoreCopySettings": []interface{}{},
+					},
+				},
+			},
+		},
+	}
+}
+
+// backupPolicySettings returns the schedule and the retention of the rules of a backup policy. The rules
+// are compared by these settings only, as Azure adds defaults to the rules it returns.
+func backupPolicySettings(properties map[string]interface{}) map[string]interface{} {
+	schedules := []interface{}{}
+	retentions := []interface{}{}
+	rules, _ := properties["policyRules"].([]interface{})
+	for _, r := range rules {
+		rule, _ := r.(map[string]interface{})
+		switch rule["objectType"] {
+		case "AzureBackupRule":
+			trigger, _ := rule["trigger"].(map[string]interface{})
+			schedule, _ := trigger["schedule"].(map[string]interface{})
+			if intervals, ok := schedule["repeatingTimeIntervals"].([]interface{}); ok {
+				schedules = append(schedules, intervals...)
+			}
+		case "AzureRetentionRule":
+			lifecycles, _ := rule["lifecycles"].([]interface{})
+			for _, l := range lifecycles {
+				lifecycle, _ := l.(map[string]interface{})
+				deleteAfter, _ := lifecycle["deleteAfter"].(map[string]interface{})
+				retentions = append(retentions, deleteAfter["duration"])
+			}
+		}
+	}
+	return map[string]interface{}{
+		"schedules":  schedules,
+		"retentions": retentions,
+	}
+}
+
+// extensionPrincipalID returns the principal ID of the identity AKS assigned to an extension.
+func extensionPrincipalID(extension resources.GenericResource) string {
+	properties, _ := extension.Properties.(map[string]interface{})
+	identity, _ := properties["aksAssignedIdentity"].(map[string]interface{})
+	principalID, _ := identity["principalId"].(string)
+	return principalID
+}
+
+// withObjectType returns a copy of properties with the given object type.
+func withObjectType(properties map[string]interface{}, objectType string) map[string]interface{} {
+	out := map[string]interface{}{"objectType": objectType}
+	for k, v := range properties {
+		out[k] = v
+	}
+	return out
+}
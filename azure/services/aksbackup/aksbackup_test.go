@@ -0,0 +1,285 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aksbackup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/aksbackup/mock_aksbackup"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+const (
+	clusterID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster"
+	vaultID   = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.DataProtection/backupVaults/my-vault"
+	storageID = "/subscriptions/456/resourceGroups/storage-rg/providers/Microsoft.Storage/storageAccounts/backups"
+)
+
+var notFound = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found")
+
+func fakeSpec() *azure.AKSBackupSpec {
+	return &azure.AKSBackupSpec{
+		ManagedClusterName:  "my-cluster",
+		TenantID:            "tenant",
+		VaultName:           "my-vault",
+		StorageAccountID:    storageID,
+		BlobContainer:       "velero",
+		BackupIntervalHours: 24,
+		RetentionDays:       7,
+	}
+}
+
+func withIdentity(principalID string) resources.GenericResource {
+	return resources.GenericResource{Identity: &resources.Identity{PrincipalID: to.StringPtr(principalID)}}
+}
+
+func existingExtension() resources.GenericResource {
+	return resources.GenericResource{
+		Properties: map[string]interface{}{
+			"configurationSettings": map[string]interface{}{
+				"configuration.backupStorageLocation.bucket":                "velero",
+				"configuration.backupStorageLocation.config.resourceGroup":  "storage-rg",
+				"configuration.backupStorageLocation.config.storageAccount": "backups",
+				"configuration.backupStorageLocation.config.subscriptionId": "456",
+				"credentials.tenantId":                                      "tenant",
+			},
+			"aksAssignedIdentity": map[string]interface{}{
+				"principalId": "extension-principal",
+			},
+		},
+	}
+}
+
+// existingPolicy returns the backup policy for spec as Azure reports it, with the defaults Azure adds to
+// its rules.
+func existingPolicy(spec *azure.AKSBackupSpec) resources.GenericResource {
+	properties := map[string]interface{}{}
+	b, _ := json.Marshal(backupPolicyProperties(spec))
+	_ = json.Unmarshal(b, &properties)
+	for _, r := range properties["policyRules"].([]interface{}) {
+		r.(map[string]interface{})["dataStore"] = map[string]interface{}{
+			"dataStoreType": "OperationalStore",
+			"objectType":    "DataStoreInfoBase",
+		}
+	}
+	return resources.GenericResource{Properties: properties}
+}
+
+func existingInstance(policyName string) resources.GenericResource {
+	return resources.GenericResource{
+		Properties: map[string]interface{}{
+			"policyInfo": map[string]interface{}{
+				"policyId": vaultID + "/backupPolicies/" + policyName,
+			},
+		},
+	}
+}
+
+func TestReconcileAKSBackup(t *testing.T) {
+	testcases := []struct {
+		name          string
+		spec          *azure.AKSBackupSpec
+		expect        func(m *mock_aksbackup.MockclientMockRecorder)
+		expectedError string
+	}{
+		{
+			name:   "no backup configured",
+			spec:   nil,
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {},
+		},
+		{
+			name: "invalid storage account ID",
+			spec: func() *azure.AKSBackupSpec {
+				spec := fakeSpec()
+				spec.StorageAccountID = "not-an-id"
+				return spec
+			}(),
+			expect:        func(m *mock_aksbackup.MockclientMockRecorder) {},
+			expectedError: "failed to parse storage account ID not-an-id: parsing failed for not-an-id. Invalid resource Id format",
+		},
+		{
+			name: "backup vault without identity",
+			spec: fakeSpec(),
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Get(gomockinternal.AContext(), clusterID, managedClustersAPIVersion).Return(withIdentity("cluster-principal"), nil)
+				m.Get(gomockinternal.AContext(), vaultID, dataProtectionAPIVersion).Return(resources.GenericResource{}, notFound)
+				m.CreateOrUpdate(gomockinternal.AContext(), vaultID, dataProtectionAPIVersion, gomock.Any()).Return(resources.GenericResource{}, nil)
+			},
+			expectedError: "backup vault my-vault has no system assigned identity yet",
+		},
+		{
+			name: "backup resources up to date",
+			spec: fakeSpec(),
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Get(gomockinternal.AContext(), clusterID, managedClustersAPIVersion).Return(withIdentity("cluster-principal"), nil)
+				m.Get(gomockinternal.AContext(), vaultID, dataProtectionAPIVersion).Return(withIdentity("vault-principal"), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/providers/Microsoft.KubernetesConfiguration/extensions/azure-aks-backup", extensionsAPIVersion).Return(existingExtension(), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/trustedAccessRoleBindings/my-vault", trustedAccessAPIVersion).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), gomock.Any(), roleAssignmentsAPIVersion).Times(4).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), vaultID+"/backupPolicies/my-cluster", dataProtectionAPIVersion).Return(existingPolicy(fakeSpec()), nil)
+				m.Get(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion).Return(existingInstance("my-cluster"), nil)
+			},
+		},
+		{
+			name: "backup policy updated in place",
+			spec: func() *azure.AKSBackupSpec {
+				spec := fakeSpec()
+				spec.BackupIntervalHours = 4
+				spec.RetentionDays = 30
+				return spec
+			}(),
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Get(gomockinternal.AContext(), clusterID, managedClustersAPIVersion).Return(withIdentity("cluster-principal"), nil)
+				m.Get(gomockinternal.AContext(), vaultID, dataProtectionAPIVersion).Return(withIdentity("vault-principal"), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/providers/Microsoft.KubernetesConfiguration/extensions/azure-aks-backup", extensionsAPIVersion).Return(existingExtension(), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/trustedAccessRoleBindings/my-vault", trustedAccessAPIVersion).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), gomock.Any(), roleAssignmentsAPIVersion).Times(4).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), vaultID+"/backupPolicies/my-cluster", dataProtectionAPIVersion).Return(existingPolicy(fakeSpec()), nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), vaultID+"/backupPolicies/my-cluster", dataProtectionAPIVersion, gomock.Any()).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion).Return(existingInstance("my-cluster"), nil)
+			},
+		},
+		{
+			name: "backup instance moved to the policy of the cluster",
+			spec: fakeSpec(),
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Get(gomockinternal.AContext(), clusterID, managedClustersAPIVersion).Return(withIdentity("cluster-principal"), nil)
+				m.Get(gomockinternal.AContext(), vaultID, dataProtectionAPIVersion).Return(withIdentity("vault-principal"), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/providers/Microsoft.KubernetesConfiguration/extensions/azure-aks-backup", extensionsAPIVersion).Return(existingExtension(), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/trustedAccessRoleBindings/my-vault", trustedAccessAPIVersion).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), gomock.Any(), roleAssignmentsAPIVersion).Times(4).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), vaultID+"/backupPolicies/my-cluster", dataProtectionAPIVersion).Return(resources.GenericResource{}, notFound)
+				m.CreateOrUpdate(gomockinternal.AContext(), vaultID+"/backupPolicies/my-cluster", dataProtectionAPIVersion, gomock.Any()).Return(resources.GenericResource{}, nil)
+				m.Get(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion).Return(existingInstance("my-cluster-24h-7d"), nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion, gomock.Any()).Return(resources.GenericResource{}, nil)
+			},
+		},
+		{
+			name: "extension identity not assigned yet",
+			spec: fakeSpec(),
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Get(gomockinternal.AContext(), clusterID, managedClustersAPIVersion).Return(withIdentity("cluster-principal"), nil)
+				m.Get(gomockinternal.AContext(), vaultID, dataProtectionAPIVersion).Return(withIdentity("vault-principal"), nil)
+				m.Get(gomockinternal.AContext(), clusterID+"/providers/Microsoft.KubernetesConfiguration/extensions/azure-aks-backup", extensionsAPIVersion).Return(resources.GenericResource{}, notFound)
+				m.CreateOrUpdate(gomockinternal.AContext(), clusterID+"/providers/Microsoft.KubernetesConfiguration/extensions/azure-aks-backup", extensionsAPIVersion, gomock.Any()).Return(resources.GenericResource{}, nil)
+			},
+			expectedError: "backup extension has no identity yet",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_aksbackup.NewMockBackupScope(mockCtrl)
+			clientMock := mock_aksbackup.NewMockclient(mockCtrl)
+
+			scopeMock.EXPECT().AKSBackupSpec().Return(tc.spec)
+			scopeMock.EXPECT().V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+			scopeMock.EXPECT().SubscriptionID().AnyTimes().Return("123")
+			scopeMock.EXPECT().ResourceGroup().AnyTimes().Return("my-rg")
+			scopeMock.EXPECT().Location().AnyTimes().Return("westeurope")
+			tc.expect(clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteAKSBackup(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expect        func(m *mock_aksbackup.MockclientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "delete backup instance and keep the vault",
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Delete(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion)
+			},
+		},
+		{
+			name: "backup instance already deleted",
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Delete(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion).Return(notFound)
+			},
+		},
+		{
+			name: "error deleting backup instance",
+			expect: func(m *mock_aksbackup.MockclientMockRecorder) {
+				m.Delete(gomockinternal.AContext(), vaultID+"/backupInstances/my-cluster", dataProtectionAPIVersion).Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
+			},
+			expectedError: "failed to delete backup instance my-cluster: #: Internal Server Error: StatusCode=500",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_aksbackup.NewMockBackupScope(mockCtrl)
+			clientMock := mock_aksbackup.NewMockclient(mockCtrl)
+
+			scopeMock.EXPECT().AKSBackupSpec().Return(fakeSpec())
+			scopeMock.EXPECT().V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+			scopeMock.EXPECT().SubscriptionID().AnyTimes().Return("123")
+			scopeMock.EXPECT().ResourceGroup().AnyTimes().Return("my-rg")
+			tc.expect(clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aksbackup
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk. The resources involved in backing up an AKS cluster are not modelled by the
+// SDK version in use, so they are managed as generic resources with an explicit API version.
+type client interface {
+	Get(context.Context, string, string) (resources.GenericResource, error)
+	CreateOrUpdate(context.Context, string, string, resources.GenericResource) (resources.GenericResource, error)
+	Delete(context.Context, string, string) error
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	resources resources.Client
+}
+
+var _ client = (*azureClient)(nil)
+
+// newClient creates a new generic resources client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := newResourcesClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
+	return &azureClient{
+		resources: c,
+	}
+}
+
+// newResourcesClient creates a new generic resources client from subscription ID.
+func newResourcesClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) resources.Client {
+	resourcesClient := resources.NewClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&resourcesClient.Client, authorizer)
+	return resourcesClient
+}
+
+// Get gets a resource by ID.
+func (ac *azureClient) Get(ctx context.Context, id, apiVersion string) (resources.GenericResource, error) {
+	ctx, span := tele.Tracer().Start(ctx, "aksbackup.AzureClient.Get")
+	defer span.End()
+
+	return ac.resources.GetByID(ctx, id, apiVersion)
+}
+
+// CreateOrUpdate creates or updates a resource by ID and waits for the operation to complete.
+func (ac *azureClient) CreateOrUpdate(ctx context.Context, id, apiVersion string, resource resources.GenericResource) (resources.GenericResource, error) {
+	ctx, span := tele.Tracer().Start(ctx, "aksbackup.AzureClient.CreateOrUpdate")
+	defer span.End()
+
+	future, err := ac.resources.CreateOrUpdateByID(ctx, id, apiVersion, resource)
+	if err != nil {
+		return resources.GenericResource{}, err
+	}
+	err = azure.WaitForCompletion(ctx, &future, ac.resources.Client)
+	if err != nil {
+		return resources.GenericResource{}, err
+	}
+	return future.Result(ac.resources)
+}
+
+// Delete deletes a resource by ID and waits for the operation to complete.
+func (ac *azureClient) Delete(ctx context.Context, id, apiVersion string) error {
+	ctx, span := tele.Tracer().Start(ctx, "aksbackup.AzureClient.Delete")
+	defer span.End()
+
+	future, err := ac.resources.DeleteByID(ctx, id, apiVersion)
+	if err != nil {
+		return err
+	}
+	err = azure.WaitForCompletion(ctx, &future, ac.resources.Client)
+	if err != nil {
+		return err
+	}
+	_, err = future.Result(ac.resources)
+	return err
+}
@@ -109,6 +109,20 @@ func (mr *MockAvailabilitySetScopeMockRecorder) AvailabilitySetEnabled() *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockAvailabilitySetScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockAvailabilitySetScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockAvailabilitySetScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockAvailabilitySetScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockAvailabilitySetScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -179,6 +193,20 @@ func (mr *MockAvailabilitySetScopeMockRecorder) CloudProviderConfigOverrides() *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockAvailabilitySetScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockAvailabilitySetScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockAvailabilitySetScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockAvailabilitySetScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockAvailabilitySetScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -224,6 +252,20 @@ func (mr *MockAvailabilitySetScopeMockRecorder) Error(err, msg interface{}, keys
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockAvailabilitySetScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockAvailabilitySetScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockAvailabilitySetScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockAvailabilitySetScope)(nil).FailureDomainZone), arg0)
+}
+
 // HashKey mocks base method.
 func (m *MockAvailabilitySetScope) HashKey() string {
 	m.ctrl.T.Helper()
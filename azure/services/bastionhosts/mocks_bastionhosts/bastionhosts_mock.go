@@ -123,6 +123,20 @@ func (mr *MockBastionScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockBastionScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockBastionScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockBastionScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockBastionScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockBastionScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -207,6 +221,20 @@ func (mr *MockBastionScopeMockRecorder) CloudProviderConfigOverrides() *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockBastionScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockBastionScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockBastionScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockBastionScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockBastionScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -280,6 +308,20 @@ func (mr *MockBastionScopeMockRecorder) Error(err, msg interface{}, keysAndValue
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockBastionScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockBastionScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockBastionScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockBastionScope)(nil).FailureDomainZone), arg0)
+}
+
 // GetPrivateDNSZoneName mocks base method.
 func (m *MockBastionScope) GetPrivateDNSZoneName() string {
 	m.ctrl.T.Helper()
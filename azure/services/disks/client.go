@@ -59,7 +59,7 @@ func (ac *azureClient) Delete(ctx context.Context, resourceGroupName, name strin
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.disks.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.disks.Client)
 	if err != nil {
 		return err
 	}
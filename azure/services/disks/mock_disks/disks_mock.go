@@ -95,6 +95,20 @@ func (mr *MockDiskScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockDiskScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockDiskScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockDiskScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockDiskScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockDiskScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -165,6 +179,20 @@ func (mr *MockDiskScopeMockRecorder) CloudProviderConfigOverrides() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockDiskScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockDiskScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockDiskScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockDiskScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockDiskScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -224,6 +252,20 @@ func (mr *MockDiskScopeMockRecorder) Error(err, msg interface{}, keysAndValues .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockDiskScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockDiskScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockDiskScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockDiskScope)(nil).FailureDomainZone), arg0)
+}
+
 // HashKey mocks base method.
 func (m *MockDiskScope) HashKey() string {
 	m.ctrl.T.Helper()
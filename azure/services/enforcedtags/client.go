@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enforcedtags
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk.
+type client interface {
+	GetAtScope(context.Context, string) (resources.TagsResource, error)
+	UpdateAtScope(context.Context, string, resources.TagsPatchResource) (resources.TagsResource, error)
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	tags resources.TagsClient
+}
+
+var _ client = (*azureClient)(nil)
+
+// newClient creates a new tags client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	tags := resources.NewTagsClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&tags.Client, auth.Authorizer())
+	return &azureClient{tags}
+}
+
+// GetAtScope gets the tags of a resource.
+func (ac *azureClient) GetAtScope(ctx context.Context, scope string) (resources.TagsResource, error) {
+	ctx, span := tele.Tracer().Start(ctx, "enforcedtags.AzureClient.GetAtScope")
+	defer span.End()
+
+	return ac.tags.GetAtScope(ctx, scope)
+}
+
+// UpdateAtScope patches the tags of a resource, leaving the resource itself as it is.
+func (ac *azureClient) UpdateAtScope(ctx context.Context, scope string, parameters resources.TagsPatchResource) (resources.TagsResource, error) {
+	ctx, span := tele.Tracer().Start(ctx, "enforcedtags.AzureClient.UpdateAtScope")
+	defer span.End()
+
+	return ac.tags.UpdateAtScope(ctx, scope, parameters)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enforcedtags
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// EnforcedTagsScope defines the scope interface for an enforced tags service.
+type EnforcedTagsScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	EnforcedTagsSpecs() []azure.EnforcedTagsSpec
+	SetTagsEnforcedCondition(restored []string)
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope EnforcedTagsScope
+	client
+}
+
+// New creates a new service.
+func New(scope EnforcedTagsScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: newClient(scope),
+	}
+}
+
+// Reconcile restores the tags of the resources of the cluster which were removed or changed outside of the
+// controller. Only the differing tags are patched, so tags added outside of the controller are kept.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "enforcedtags.Service.Reconcile")
+	defer span.End()
+
+	var restored []string
+	for _, spec := range s.Scope.EnforcedTagsSpecs() {
+		result, err := s.client.GetAtScope(ctx, spec.Scope)
+		if azure.ResourceNotFound(err) {
+			// The resource doesn't exist anymore, its service recreates it with its tags.
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to get tags of %s", spec.Name)
+		}
+		existing := infrav1.Tags{}
+		if result.Properties != nil {
+			for k, v := range result.Properties.Tags {
+				existing[k] = to.String(v)
+			}
+		}
+		if spec.OnlyIfOwned && !existing.HasOwned(s.Scope.ClusterName()) {
+			s.Scope.V(4).Info("skipping tags of resource not owned by the cluster", "resource", spec.Name)
+			continue
+		}
+
+		changed := spec.Tags.Difference(existing)
+		if len(changed) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(changed))
+		tags := make(map[string]*string, len(changed))
+		for k, v := range changed {
+			keys = append(keys, k)
+			tags[k] = to.StringPtr(v)
+		}
+		sort.Strings(keys)
+
+		s.Scope.Info("restoring tags", "resource", spec.Name, "tags", keys)
+		if _, err := s.client.UpdateAtScope(ctx, spec.Scope, resources.TagsPatchResource{
+			Operation:  resources.TagsPatchOperationMerge,
+			Properties: &resources.Tags{Tags: tags},
+		}); err != nil {
+			return errors.Wrapf(err, "failed to restore tags of %s", spec.Name)
+		}
+		restored = append(restored, fmt.Sprintf("%s of %s", strings.Join(keys, ", "), spec.Name))
+	}
+
+	s.Scope.SetTagsEnforcedCondition(restored)
+	return nil
+}
+
+// Delete is a no-op as the tags get deleted with their resources.
+func (s *Service) Delete(ctx context.Context) error {
+	_, span := tele.Tracer().Start(ctx, "enforcedtags.Service.Delete")
+	defer span.End()
+
+	return nil
+}
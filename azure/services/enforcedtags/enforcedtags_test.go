@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package enforcedtags
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/enforcedtags/mock_enforcedtags"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+const (
+	fakeRGID = "/subscriptions/123/resourceGroups/my-rg"
+	fakeIPID = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"
+	ownedTag = "sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster"
+)
+
+var (
+	fakeSpecs = []azure.EnforcedTagsSpec{
+		{
+			Name:        "resource group my-rg",
+			Scope:       fakeRGID,
+			Tags:        map[string]string{ownedTag: "owned", "team": "infra"},
+			OnlyIfOwned: true,
+		},
+		{
+			Name:  "public IP my-ip",
+			Scope: fakeIPID,
+			Tags:  map[string]string{ownedTag: "owned", "Name": "my-ip", "team": "infra"},
+		},
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	notFoundError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+)
+
+func fakeTags(tags map[string]string) resources.TagsResource {
+	result := resources.TagsResource{Properties: &resources.Tags{Tags: map[string]*string{}}}
+	for k, v := range tags {
+		result.Properties.Tags[k] = to.StringPtr(v)
+	}
+	return result
+}
+
+func TestReconcileEnforcedTags(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_enforcedtags.MockEnforcedTagsScopeMockRecorder, m *mock_enforcedtags.MockclientMockRecorder)
+	}{
+		{
+			name: "tags are not enforced",
+			expect: func(s *mock_enforcedtags.MockEnforcedTagsScopeMockRecorder, m *mock_enforcedtags.MockclientMockRecorder) {
+				s.EnforcedTagsSpecs().Return(nil)
+				s.SetTagsEnforcedCondition(nil)
+			},
+		},
+		{
+			name: "tags are unchanged",
+			expect: func(s *mock_enforcedtags.MockEnforcedTagsScopeMockRecorder, m *mock_enforcedtags.MockclientMockRecorder) {
+				s.EnforcedTagsSpecs().Return(fakeSpecs)
+				s.ClusterName().Return("my-cluster")
+				m.GetAtScope(gomockinternal.AContext(), fakeRGID).Return(fakeTags(map[string]string{ownedTag: "owned", "team": "infra"}), nil)
+				m.GetAtScope(gomockinternal.AContext(), fakeIPID).Return(fakeTags(map[string]string{ownedTag: "owned", "Name": "my-ip", "team": "infra", "extra": "kept"}), nil)
+				s.SetTagsEnforcedCondition(nil)
+			},
+		},
+		{
+			name: "removed and changed tags are restored",
+			expect: func(s *mock_enforcedtags.MockEnforcedTagsScopeMockRecorder, m *mock_enforcedtags.MockclientMockRecorder) {
+				s.EnforcedTagsSpecs().Return(fakeSpecs)
+				s.ClusterName().Return("my-cluster")
+				s.Info(gomock.Any(), gomock.Any()).AnyTimes()
+				m.GetAtScope(gomockinternal.AContext(), fakeRGID).Return(fakeTags(map[string]string{ownedTag: "owned", "team": "other"}), nil)
+				m.UpdateAtScope(gomockinternal.AContext(), fakeRGID, resources.TagsPatchResource{
+					Operation:  resources.TagsPatchOperationMerge,
+					Properties: &resources.Tags{Tags: map[string]*string{"team": to.StringPtr("infra")}},
+				})
+				m.GetAtScope(gomockinternal.AContext(), fakeIPID).Return(fakeTags(map[string]string{"extra": "kept"}), nil)
+				m.UpdateAtScope(gomockinternal.AContext(), fakeIPID, resources.TagsPatchResource{
+					Operation: resources.TagsPatchOperationMerge,
+					Properties: &resources.Tags{Tags: map[string]*string{
+						ownedTag: to.StringPtr("owned"),
+						"Name":   to.StringPtr("my-ip"),
+						"team":   to.StringPtr("infra"),
+					}},
+				})
+				s.SetTagsEnforcedCondition([]string{
+					"team of resource group my-rg",
+					"Name, " + ownedTag + ", team of public IP my-ip",
+				})
+			},
+		},
+		{
+			name: "resources not owned by the cluster or not found are skipped",
+			expect: func(s *mock_enforcedtags.MockEnforcedTagsScopeMockRecorder, m *mock_enforcedtags.MockclientMockRecorder) {
+				s.EnforcedTagsSpecs().Return(fakeSpecs)
+				s.ClusterName().Return("my-cluster")
+				s.V(gomock.AssignableToTypeOf(4)).Return(klogr.New())
+				m.GetAtScope(gomockinternal.AContext(), fakeRGID).Return(fakeTags(map[string]string{"team": "other"}), nil)
+				m.GetAtScope(gomockinternal.AContext(), fakeIPID).Return(resources.TagsResource{}, notFoundError)
+				s.SetTagsEnforcedCondition(nil)
+			},
+		},
+		{
+			name:          "fail to get tags",
+			expectedError: "failed to get tags of resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_enforcedtags.MockEnforcedTagsScopeMockRecorder, m *mock_enforcedtags.MockclientMockRecorder) {
+				s.EnforcedTagsSpecs().Return(fakeSpecs)
+				m.GetAtScope(gomockinternal.AContext(), fakeRGID).Return(resources.TagsResource{}, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_enforcedtags.NewMockEnforcedTagsScope(mockCtrl)
+			clientMock := mock_enforcedtags.NewMockclient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
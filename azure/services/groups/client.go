@@ -80,7 +80,7 @@ func (ac *azureClient) Delete(ctx context.Context, name string) error {
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.groups.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.groups.Client)
 	if err != nil {
 		return err
 	}
@@ -94,6 +94,20 @@ func (mr *MockGroupScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockGroupScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockGroupScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockGroupScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockGroupScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockGroupScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -164,6 +178,20 @@ func (mr *MockGroupScopeMockRecorder) CloudProviderConfigOverrides() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockGroupScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockGroupScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockGroupScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockGroupScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockGroupScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -209,6 +237,20 @@ func (mr *MockGroupScopeMockRecorder) Error(err, msg interface{}, keysAndValues
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockGroupScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockGroupScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockGroupScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockGroupScope)(nil).FailureDomainZone), arg0)
+}
+
 // HashKey mocks base method.
 func (m *MockGroupScope) HashKey() string {
 	m.ctrl.T.Helper()
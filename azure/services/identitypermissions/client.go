@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identitypermissions
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/Azure/go-autorest/autorest"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk to read the permissions of the cluster identity.
+type client interface {
+	ListPermissions(context.Context, string) ([]authorization.Permission, error)
+}
+
+// roleAssignmentsClient wraps go-sdk to manage role assignments with the credentials of the controller.
+type roleAssignmentsClient interface {
+	CreateRoleAssignment(context.Context, string, string, authorization.RoleAssignmentCreateParameters) error
+	DeleteRoleAssignment(context.Context, string, string) error
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	permissions authorization.PermissionsClient
+}
+
+var _ client = (*azureClient)(nil)
+
+// azureRoleAssignmentsClient contains the Azure go-sdk Client for role assignments.
+type azureRoleAssignmentsClient struct {
+	roleassignments authorization.RoleAssignmentsClient
+}
+
+var _ roleAssignmentsClient = (*azureRoleAssignmentsClient)(nil)
+
+// newClient creates a new permissions client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := authorization.NewPermissionsClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&c.Client, auth.Authorizer())
+	return &azureClient{c}
+}
+
+// newRoleAssignmentsClient creates a new role assignments client authenticating with the given authorizer.
+func newRoleAssignmentsClient(subscriptionID string, baseURI string, authorizer autorest.Authorizer) roleAssignmentsClient {
+	c := authorization.NewRoleAssignmentsClientWithBaseURI(baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&c.Client, authorizer)
+	return &azureRoleAssignmentsClient{c}
+}
+
+// ListPermissions returns the permissions the caller has in a resource group.
+func (ac *azureClient) ListPermissions(ctx context.Context, resourceGroupName string) ([]authorization.Permission, error) {
+	ctx, span := tele.Tracer().Start(ctx, "identitypermissions.AzureClient.ListPermissions")
+	defer span.End()
+
+	var permissions []authorization.Permission
+	iter, err := ac.permissions.ListForResourceGroupComplete(ctx, resourceGroupName)
+	if err != nil {
+		return nil, err
+	}
+	for ; iter.NotDone(); err = iter.NextWithContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, iter.Value())
+	}
+	return permissions, nil
+}
+
+// CreateRoleAssignment creates a role assignment.
+func (ac *azureRoleAssignmentsClient) CreateRoleAssignment(ctx context.Context, scope string, roleAssignmentName string, parameters authorization.RoleAssignmentCreateParameters) error {
+	ctx, span := tele.Tracer().Start(ctx, "identitypermissions.AzureClient.CreateRoleAssignment")
+	defer span.End()
+
+	_, err := ac.roleassignments.Create(ctx, scope, roleAssignmentName, parameters)
+	return err
+}
+
+// DeleteRoleAssignment deletes a role assignment.
+func (ac *azureRoleAssignmentsClient) DeleteRoleAssignment(ctx context.Context, scope string, roleAssignmentName string) error {
+	ctx, span := tele.Tracer().Start(ctx, "identitypermissions.AzureClient.DeleteRoleAssignment")
+	defer span.End()
+
+	_, err := ac.roleassignments.Delete(ctx, scope, roleAssignmentName)
+	return err
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identitypermissions
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/groups"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// IdentityPermissionsScope defines the scope interface for an identity permissions service.
+type IdentityPermissionsScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	IdentityPermissionsSpecs() []azure.IdentityPermissionsSpec
+	ProvisionIdentityRoleAssignments() bool
+	ControllerAuthorizer() (autorest.Authorizer, error)
+	IdentityPermissionsCheckRequested() bool
+	SetIdentityPermissionsCondition(missing []string)
+}
+
+const (
+	// checkValidity is how long a successful check of the permissions of a cluster identity is trusted.
+	checkValidity = time.Hour
+
+	// missingPermissionsRequeueAfter is how long reconciling a cluster is delayed while its identity is missing permissions.
+	missingPermissionsRequeueAfter = time.Minute
+)
+
+// checks remembers the successful checks of all clusters, so permissions are checked when the controller starts, when
+// the specs of a cluster change, on request and periodically, rather than on every reconciliation.
+var checks = newCheckCache()
+
+// groupManager tells whether the resource group of the cluster was created by the controller.
+type groupManager interface {
+	IsGroupManaged(context.Context) (bool, error)
+}
+
+// Service provides operations on the permissions of the cluster identity.
+type Service struct {
+	Scope IdentityPermissionsScope
+	client
+	groups                   groupManager
+	newRoleAssignmentsClient func(subscriptionID, baseURI string, authorizer autorest.Authorizer) roleAssignmentsClient
+	checks                   *checkCache
+}
+
+// New creates a new service.
+func New(scope IdentityPermissionsScope) *Service {
+	return &Service{
+		Scope:                    scope,
+		client:                   newClient(scope),
+		groups:                   groups.New(scope),
+		newRoleAssignmentsClient: newRoleAssignmentsClient,
+		checks:                   checks,
+	}
+}
+
+// Reconcile assigns the roles the cluster identity needs if enabled, and reports the permissions it is missing.
+// It returns an error while permissions are missing, so no other resources are created or updated with the identity.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "identitypermissions.Service.Reconcile")
+	defer span.End()
+
+	specs := s.Scope.IdentityPermissionsSpecs()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	provision := s.Scope.ProvisionIdentityRoleAssignments()
+	key := s.Scope.HashKey() + "/" + s.Scope.ClusterName()
+	fingerprint := fmt.Sprintf("%t/%v", provision, specs)
+	if !s.Scope.IdentityPermissionsCheckRequested() && s.checks.valid(key, fingerprint) {
+		return nil
+	}
+
+	if provision {
+		if err := s.reconcileRoleAssignments(ctx, specs); err != nil {
+			return err
+		}
+	}
+
+	var missing []string
+	permissionsByGroup := map[string][]authorization.Permission{}
+	for _, spec := range specs {
+		permissions, ok := permissionsByGroup[spec.ResourceGroup]
+		if !ok {
+			var err error
+			permissions, err = s.client.ListPermissions(ctx, spec.ResourceGroup)
+			if err != nil {
+				return errors.Wrapf(err, "failed to list permissions of cluster identity in resource group %s", spec.ResourceGroup)
+			}
+			permissionsByGroup[spec.ResourceGroup] = permissions
+		}
+		for _, action := range spec.Actions {
+			if !isActionPermitted(permissions, action) {
+				missing = append(missing, fmt.Sprintf("%s in resource group %s", action, spec.ResourceGroup))
+			}
+		}
+	}
+
+	s.Scope.SetIdentityPermissionsCondition(missing)
+	if len(missing) > 0 {
+		s.Scope.V(2).Info("cluster identity is missing permissions", "missing", missing)
+		s.checks.forget(key)
+		return azure.WithTransientError(errors.Errorf("cluster identity is missing %d permissions", len(missing)), missingPermissionsRequeueAfter)
+	}
+	s.checks.set(key, fingerprint)
+	return nil
+}
+
+// Delete removes the role assignments provisioned for the cluster identity.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "identitypermissions.Service.Delete")
+	defer span.End()
+
+	specs := s.provisionedSpecs(s.Scope.IdentityPermissionsSpecs())
+	if len(specs) == 0 || !s.Scope.ProvisionIdentityRoleAssignments() {
+		return nil
+	}
+
+	// The role assignments were deleted along with the resource group, or never provisioned if it isn't managed.
+	managed, err := s.groups.IsGroupManaged(ctx)
+	if azure.ResourceNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not get resource group management state")
+	}
+	if !managed {
+		return nil
+	}
+
+	roleAssignments, principalID, err := s.roleAssignmentsClient(ctx)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		scope := s.resourceGroupScope(spec.ResourceGroup)
+		name := roleAssignmentName(scope, spec.RoleDefinitionID, principalID)
+		if err := roleAssignments.DeleteRoleAssignment(ctx, scope, name); err != nil && !azure.ResourceNotFound(err) {
+			return errors.Wrapf(err, "failed to delete role assignment %s in resource group %s", name, spec.ResourceGroup)
+		}
+	}
+	return nil
+}
+
+// reconcileRoleAssignments assigns the roles of the specs to the cluster identity with the credentials of the controller.
+// Roles are only assigned in the resource group of the cluster, and only if the controller created it: other resource
+// groups, like the one of a virtual network, may be shared with resources the cluster must not be granted access to.
+func (s *Service) reconcileRoleAssignments(ctx context.Context, specs []azure.IdentityPermissionsSpec) error {
+	ctx, span := tele.Tracer().Start(ctx, "identitypermissions.Service.reconcileRoleAssignments")
+	defer span.End()
+
+	specs = s.provisionedSpecs(specs)
+	if len(specs) == 0 {
+		return nil
+	}
+	managed, err := s.groups.IsGroupManaged(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not get resource group management state")
+	}
+	if !managed {
+		s.Scope.V(2).Info("not assigning roles to cluster identity in unmanaged resource group", "resource group", s.Scope.ResourceGroup())
+		return nil
+	}
+
+	roleAssignments, principalID, err := s.roleAssignmentsClient(ctx)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		scope := s.resourceGroupScope(spec.ResourceGroup)
+		name := roleAssignmentName(scope, spec.RoleDefinitionID, principalID)
+		params := authorization.RoleAssignmentCreateParameters{
+			Properties: &authorization.RoleAssignmentProperties{
+				RoleDefinitionID: to.StringPtr(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", s.Scope.SubscriptionID(), spec.RoleDefinitionID)),
+				PrincipalID:      to.StringPtr(principalID),
+			},
+		}
+		// A conflict means the role is assigned to the identity already, e.g. by the user.
+		if err := roleAssignments.CreateRoleAssignment(ctx, scope, name, params); err != nil && !azure.ResourceConflict(err) {
+			return errors.Wrapf(err, "failed to assign role %s in resource group %s to cluster identity", spec.RoleDefinitionID, spec.ResourceGroup)
+		}
+	}
+	return nil
+}
+
+// roleAssignmentsClient returns a client with the credentials of the controller, and the principal ID of the cluster identity.
+func (s *Service) roleAssignmentsClient(ctx context.Context) (roleAssignmentsClient, string, error) {
+	principalID, err := principalID(ctx, s.Scope.Authorizer())
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to get principal ID of cluster identity")
+	}
+	authorizer, err := s.Scope.ControllerAuthorizer()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to get authorizer of controller")
+	}
+	return s.newRoleAssignmentsClient(s.Scope.SubscriptionID(), s.Scope.BaseURI(), authorizer), principalID, nil
+}
+
+// provisionedSpecs returns the specs whose roles may be assigned by the controller, i.e. the ones in the resource group
+// of the cluster.
+func (s *Service) provisionedSpecs(specs []azure.IdentityPermissionsSpec) []azure.IdentityPermissionsSpec {
+	var provisioned []azure.IdentityPermissionsSpec
+	for _, spec := range specs {
+		if spec.ResourceGroup == s.Scope.ResourceGroup() {
+			provisioned = append(provisioned, spec)
+		}
+	}
+	return provisioned
+}
+
+func (s *Service) resourceGroupScope(resourceGroup string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", s.Scope.SubscriptionID(), resourceGroup)
+}
+
+// roleAssignmentName returns a stable name for a role assignment, so it is created only once.
+func roleAssignmentName(scope, roleDefinitionID, principalID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(scope+roleDefinitionID+principalID)).String()
+}
+
+// isActionPermitted returns true if any of the permissions allows an action without excluding it.
+func isActionPermitted(permissions []authorization.Permission, action string) bool {
+	for _, permission := range permissions {
+		if permission.Actions == nil || !matchesAnyAction(*permission.Actions, action) {
+			continue
+		}
+		if permission.NotActions != nil && matchesAnyAction(*permission.NotActions, action) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchesAnyAction returns true if one of the patterns matches an action. Patterns may contain * wildcards.
+func matchesAnyAction(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		if matchAction(strings.ToLower(pattern), strings.ToLower(action)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAction(pattern, action string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == action
+	}
+	if !strings.HasPrefix(action, parts[0]) {
+		return false
+	}
+	action = action[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(action, part)
+		if i < 0 {
+			return false
+		}
+		action = action[i+len(part):]
+	}
+	return strings.HasSuffix(action, parts[len(parts)-1])
+}
+
+// principalID returns the object ID of the identity an authorizer acquires tokens for, read from the oid claim
+// of its access token.
+func principalID(ctx context.Context, authorizer autorest.Authorizer) (string, error) {
+	var provider interface{}
+	var token func() string
+	switch a := authorizer.(type) {
+	case *autorest.BearerAuthorizer:
+		provider = a.TokenProvider()
+		token = a.TokenProvider().OAuthToken
+	case *autorest.MultiTenantBearerAuthorizer:
+		provider = a.TokenProvider()
+		token = a.TokenProvider().PrimaryOAuthToken
+	default:
+		return "", errors.Errorf("unsupported authorizer %T", authorizer)
+	}
+
+	if refresher, ok := provider.(interface {
+		EnsureFreshWithContext(context.Context) error
+	}); ok {
+		if err := refresher.EnsureFreshWithContext(ctx); err != nil {
+			return "", errors.Wrap(err, "failed to refresh token")
+		}
+	}
+
+	parts := strings.Split(token(), ".")
+	if len(parts) != 3 {
+		return "", errors.New("access token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode access token")
+	}
+	claims := struct {
+		ObjectID string `json:"oid"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", errors.Wrap(err, "failed to decode access token")
+	}
+	if claims.ObjectID == "" {
+		return "", errors.New("access token has no oid claim")
+	}
+	return claims.ObjectID, nil
+}
+
+// checkCache remembers when the permissions of cluster identities were found complete.
+type checkCache struct {
+	mu      sync.Mutex
+	checked map[string]checkRecord
+	now     func() time.Time
+}
+
+type checkRecord struct {
+	fingerprint string
+	at          time.Time
+}
+
+func newCheckCache() *checkCache {
+	return &checkCache{
+		checked: map[string]checkRecord{},
+		now:     time.Now,
+	}
+}
+
+// valid returns true if the permissions were found complete for the same specs within checkValidity.
+func (c *checkCache) valid(key, fingerprint string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	record, ok := c.checked[key]
+	return ok && record.fingerprint == fingerprint && c.now().Sub(record.at) < checkValidity
+}
+
+func (c *checkCache) set(key, fingerprint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked[key] = checkRecord{fingerprint: fingerprint, at: c.now()}
+}
+
+func (c *checkCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.checked, key)
+}
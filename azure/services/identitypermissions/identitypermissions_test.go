@@ -0,0 +1,411 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identitypermissions
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/identitypermissions/mock_identitypermissions"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	fakeSpecs = []azure.IdentityPermissionsSpec{
+		{
+			ResourceGroup:    "my-rg",
+			RoleDefinitionID: azure.VirtualMachineContributorRoleID,
+			Actions:          []string{"Microsoft.Compute/virtualMachines/write"},
+		},
+		{
+			ResourceGroup:    "my-rg",
+			RoleDefinitionID: azure.NetworkContributorRoleID,
+			Actions:          []string{"Microsoft.Network/loadBalancers/write"},
+		},
+		{
+			ResourceGroup:    "my-vnet-rg",
+			RoleDefinitionID: azure.NetworkContributorRoleID,
+			Actions:          []string{"Microsoft.Network/virtualNetworks/subnets/join/action"},
+		},
+	}
+
+	allPermissions = []authorization.Permission{{Actions: &[]string{"*"}}}
+	internalError  = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	conflictError  = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusConflict}, "Conflict")
+	notFoundError  = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+)
+
+// fakeGroups reports the management state of the resource group of the cluster.
+type fakeGroups struct {
+	managed bool
+	err     error
+}
+
+func (f fakeGroups) IsGroupManaged(context.Context) (bool, error) {
+	return f.managed, f.err
+}
+
+// fakeAuthorizer returns an authorizer with a token of the identity with the given object ID.
+func fakeAuthorizer(t *testing.T, objectID string) autorest.Authorizer {
+	oauthConfig, err := adal.NewOAuthConfig("https://login.microsoftonline.com/", "tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, _ := json.Marshal(map[string]string{"oid": objectID})
+	spt, err := adal.NewServicePrincipalTokenFromManualToken(*oauthConfig, "client", "https://management.azure.com/", adal.Token{
+		AccessToken: "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(claims) + ".c2lnbmF0dXJl",
+		ExpiresOn:   json.Number(strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return autorest.NewBearerAuthorizer(spt)
+}
+
+func TestReconcileIdentityPermissions(t *testing.T) {
+	testcases := []struct {
+		name          string
+		checked       map[string]checkRecord
+		groups        fakeGroups
+		expect        func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "checking permissions is not enabled",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(nil)
+			},
+		},
+		{
+			name: "identity has all permissions",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(allPermissions, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(allPermissions, nil)
+				s.SetIdentityPermissionsCondition(nil)
+			},
+		},
+		{
+			name:    "permissions were checked recently",
+			checked: map[string]checkRecord{"hash/my-cluster": {fingerprint: "false/" + fmt.Sprintf("%v", fakeSpecs), at: time.Now()}},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+			},
+		},
+		{
+			name:    "permissions were checked before the specs changed",
+			checked: map[string]checkRecord{"hash/my-cluster": {fingerprint: "false/" + fmt.Sprintf("%v", fakeSpecs[:1]), at: time.Now()}},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(allPermissions, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(allPermissions, nil)
+				s.SetIdentityPermissionsCondition(nil)
+			},
+		},
+		{
+			name:    "permissions were checked too long ago",
+			checked: map[string]checkRecord{"hash/my-cluster": {fingerprint: "false/" + fmt.Sprintf("%v", fakeSpecs), at: time.Now().Add(-2 * checkValidity)}},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(allPermissions, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(allPermissions, nil)
+				s.SetIdentityPermissionsCondition(nil)
+			},
+		},
+		{
+			name:    "check of permissions is requested",
+			checked: map[string]checkRecord{"hash/my-cluster": {fingerprint: "false/" + fmt.Sprintf("%v", fakeSpecs), at: time.Now()}},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(true)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(allPermissions, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(allPermissions, nil)
+				s.SetIdentityPermissionsCondition(nil)
+			},
+		},
+		{
+			name:          "identity is missing permissions",
+			expectedError: "transient reconcile error occurred: cluster identity is missing 2 permissions. Object will be requeued after 1m0s",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return([]authorization.Permission{
+					{Actions: &[]string{"Microsoft.Compute/*", "Microsoft.Network/*"}, NotActions: &[]string{"Microsoft.Network/loadBalancers/*"}},
+				}, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(nil, nil)
+				s.SetIdentityPermissionsCondition([]string{
+					"Microsoft.Network/loadBalancers/write in resource group my-rg",
+					"Microsoft.Network/virtualNetworks/subnets/join/action in resource group my-vnet-rg",
+				})
+			},
+		},
+		{
+			name:   "role assignments are provisioned in the resource group of the cluster",
+			groups: fakeGroups{managed: true},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.BaseURI().AnyTimes().Return("https://management.azure.com/")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				s.Authorizer().Return(fakeAuthorizer(t, "object-id"))
+				s.ControllerAuthorizer().Return(autorest.NullAuthorizer{}, nil)
+				r.CreateRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", roleAssignmentName("/subscriptions/12345/resourceGroups/my-rg", azure.VirtualMachineContributorRoleID, "object-id"), authorization.RoleAssignmentCreateParameters{
+					Properties: &authorization.RoleAssignmentProperties{
+						RoleDefinitionID: to.StringPtr("/subscriptions/12345/providers/Microsoft.Authorization/roleDefinitions/" + azure.VirtualMachineContributorRoleID),
+						PrincipalID:      to.StringPtr("object-id"),
+					},
+				})
+				r.CreateRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", gomock.Any(), gomock.Any()).Return(conflictError)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(allPermissions, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(allPermissions, nil)
+				s.SetIdentityPermissionsCondition(nil)
+			},
+		},
+		{
+			name:   "role assignments are not provisioned in an unmanaged resource group",
+			groups: fakeGroups{managed: false},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(allPermissions, nil)
+				m.ListPermissions(gomockinternal.AContext(), "my-vnet-rg").Return(allPermissions, nil)
+				s.SetIdentityPermissionsCondition(nil)
+			},
+		},
+		{
+			name:          "role assignment fails",
+			groups:        fakeGroups{managed: true},
+			expectedError: "failed to assign role 9980e02c-c2be-4d73-94e8-173b1dc7cf3c in resource group my-rg to cluster identity: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.BaseURI().AnyTimes().Return("https://management.azure.com/")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				s.Authorizer().Return(fakeAuthorizer(t, "object-id"))
+				s.ControllerAuthorizer().Return(autorest.NullAuthorizer{}, nil)
+				r.CreateRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", gomock.Any(), gomock.Any()).Return(internalError)
+			},
+		},
+		{
+			name:          "listing permissions fails",
+			expectedError: "failed to list permissions of cluster identity in resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, m *mock_identitypermissions.MockclientMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+				s.HashKey().Return("hash")
+				s.ClusterName().Return("my-cluster")
+				s.IdentityPermissionsCheckRequested().Return(false)
+				m.ListPermissions(gomockinternal.AContext(), "my-rg").Return(nil, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_identitypermissions.NewMockIdentityPermissionsScope(mockCtrl)
+			clientMock := mock_identitypermissions.NewMockclient(mockCtrl)
+			roleAssignmentsMock := mock_identitypermissions.NewMockroleAssignmentsClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT(), roleAssignmentsMock.EXPECT())
+
+			checks := newCheckCache()
+			for key, record := range tc.checked {
+				checks.checked[key] = record
+			}
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+				groups: tc.groups,
+				newRoleAssignmentsClient: func(_, _ string, _ autorest.Authorizer) roleAssignmentsClient {
+					return roleAssignmentsMock
+				},
+				checks: checks,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteIdentityPermissions(t *testing.T) {
+	testcases := []struct {
+		name          string
+		groups        fakeGroups
+		expect        func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "role assignments are not provisioned",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(false)
+			},
+		},
+		{
+			name:   "role assignments are deleted along with the resource group",
+			groups: fakeGroups{err: notFoundError},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+			},
+		},
+		{
+			name:   "role assignments are not provisioned in an unmanaged resource group",
+			groups: fakeGroups{managed: false},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+			},
+		},
+		{
+			name:   "role assignments are deleted",
+			groups: fakeGroups{managed: true},
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.BaseURI().AnyTimes().Return("https://management.azure.com/")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+				s.Authorizer().Return(fakeAuthorizer(t, "object-id"))
+				s.ControllerAuthorizer().Return(autorest.NullAuthorizer{}, nil)
+				r.DeleteRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", roleAssignmentName("/subscriptions/12345/resourceGroups/my-rg", azure.VirtualMachineContributorRoleID, "object-id")).Return(notFoundError)
+				r.DeleteRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", roleAssignmentName("/subscriptions/12345/resourceGroups/my-rg", azure.NetworkContributorRoleID, "object-id"))
+			},
+		},
+		{
+			name:          "role assignment delete fails",
+			groups:        fakeGroups{managed: true},
+			expectedError: "failed to delete role assignment " + roleAssignmentName("/subscriptions/12345/resourceGroups/my-rg", azure.VirtualMachineContributorRoleID, "object-id") + " in resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_identitypermissions.MockIdentityPermissionsScopeMockRecorder, r *mock_identitypermissions.MockroleAssignmentsClientMockRecorder) {
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.BaseURI().AnyTimes().Return("https://management.azure.com/")
+				s.IdentityPermissionsSpecs().Return(fakeSpecs)
+				s.ProvisionIdentityRoleAssignments().Return(true)
+				s.Authorizer().Return(fakeAuthorizer(t, "object-id"))
+				s.ControllerAuthorizer().Return(autorest.NullAuthorizer{}, nil)
+				r.DeleteRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", gomock.Any()).Return(internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_identitypermissions.NewMockIdentityPermissionsScope(mockCtrl)
+			roleAssignmentsMock := mock_identitypermissions.NewMockroleAssignmentsClient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), roleAssignmentsMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				groups: tc.groups,
+				newRoleAssignmentsClient: func(_, _ string, _ autorest.Authorizer) roleAssignmentsClient {
+					return roleAssignmentsMock
+				},
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestMatchAction(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(matchesAnyAction([]string{"*"}, "Microsoft.Compute/virtualMachines/write")).To(BeTrue())
+	g.Expect(matchesAnyAction([]string{"Microsoft.Compute/*"}, "Microsoft.Compute/virtualMachines/write")).To(BeTrue())
+	g.Expect(matchesAnyAction([]string{"microsoft.compute/virtualmachines/write"}, "Microsoft.Compute/virtualMachines/write")).To(BeTrue())
+	g.Expect(matchesAnyAction([]string{"Microsoft.Compute/*/read"}, "Microsoft.Compute/virtualMachines/read")).To(BeTrue())
+	g.Expect(matchesAnyAction([]string{"Microsoft.Compute/*/read"}, "Microsoft.Compute/virtualMachines/write")).To(BeFalse())
+	g.Expect(matchesAnyAction([]string{"Microsoft.Network/*"}, "Microsoft.Compute/virtualMachines/write")).To(BeFalse())
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/go-autorest/autorest"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// Client wraps go-sdk.
+type Client interface {
+	GetMarketplaceImage(ctx context.Context, location, publisher, offer, sku, version string) (compute.VirtualMachineImage, error)
+	ListMarketplaceImageVersions(ctx context.Context, location, publisher, offer, sku string) ([]compute.VirtualMachineImageResource, error)
+	GetGalleryImage(ctx context.Context, subscriptionID, resourceGroup, gallery, name string) (compute.GalleryImage, error)
+	GetGalleryImageVersion(ctx context.Context, subscriptionID, resourceGroup, gallery, name, version string) (compute.GalleryImageVersion, error)
+}
+
+// AzureClient contains the Azure go-sdk Client.
+type AzureClient struct {
+	images     compute.VirtualMachineImagesClient
+	baseURI    string
+	authorizer autorest.Authorizer
+}
+
+var _ Client = &AzureClient{}
+
+// NewClient creates a new images client from subscription ID. Gallery images are got with the same credentials, in
+// the subscription of their gallery.
+func NewClient(auth azure.Authorizer) *AzureClient {
+	images := compute.NewVirtualMachineImagesClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&images.Client, auth.Authorizer())
+	return &AzureClient{
+		images:     images,
+		baseURI:    auth.BaseURI(),
+		authorizer: auth.Authorizer(),
+	}
+}
+
+// GetMarketplaceImage gets a version of a marketplace image.
+func (ac *AzureClient) GetMarketplaceImage(ctx context.Context, location, publisher, offer, sku, version string) (compute.VirtualMachineImage, error) {
+	ctx, span := tele.Tracer().Start(ctx, "images.AzureClient.GetMarketplaceImage")
+	defer span.End()
+
+	return ac.images.Get(ctx, location, publisher, offer, sku, version)
+}
+
+// ListMarketplaceImageVersions lists the versions of a marketplace image.
+func (ac *AzureClient) ListMarketplaceImageVersions(ctx context.Context, location, publisher, offer, sku string) ([]compute.VirtualMachineImageResource, error) {
+	ctx, span := tele.Tracer().Start(ctx, "images.AzureClient.ListMarketplaceImageVersions")
+	defer span.End()
+
+	result, err := ac.images.List(ctx, location, publisher, offer, sku, "", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if result.Value == nil {
+		return nil, nil
+	}
+	return *result.Value, nil
+}
+
+// GetGalleryImage gets the definition of a gallery image.
+func (ac *AzureClient) GetGalleryImage(ctx context.Context, subscriptionID, resourceGroup, gallery, name string) (compute.GalleryImage, error) {
+	ctx, span := tele.Tracer().Start(ctx, "images.AzureClient.GetGalleryImage")
+	defer span.End()
+
+	c := compute.NewGalleryImagesClientWithBaseURI(ac.baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&c.Client, ac.authorizer)
+	return c.Get(ctx, resourceGroup, gallery, name)
+}
+
+// GetGalleryImageVersion gets a version of a gallery image.
+func (ac *AzureClient) GetGalleryImageVersion(ctx context.Context, subscriptionID, resourceGroup, gallery, name, version string) (compute.GalleryImageVersion, error) {
+	ctx, span := tele.Tracer().Start(ctx, "images.AzureClient.GetGalleryImageVersion")
+	defer span.End()
+
+	c := compute.NewGalleryImageVersionsClientWithBaseURI(ac.baseURI, subscriptionID)
+	azure.SetAutoRestClientDefaults(&c.Client, ac.authorizer)
+	return c.Get(ctx, resourceGroup, gallery, name, version, "")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+// latestVersion is the version of an image selecting its latest version.
+const latestVersion = "latest"
+
+// Checker checks that the images of machines exist.
+type Checker struct {
+	client Client
+}
+
+// NewChecker creates a new image checker.
+func NewChecker(auth azure.Authorizer) *Checker {
+	return &Checker{
+		client: NewClient(auth),
+	}
+}
+
+// ImageExists returns whether a marketplace image is offered in a location, or a shared gallery image version
+// exists. Images referenced by ID aren't checked.
+func (c *Checker) ImageExists(ctx context.Context, location string, image *infrav1.Image) (bool, error) {
+	switch {
+	case image.Marketplace != nil:
+		mp := image.Marketplace
+		if strings.EqualFold(mp.Version, latestVersion) {
+			versions, err := c.client.ListMarketplaceImageVersions(ctx, location, mp.Publisher, mp.Offer, mp.SKU)
+			if exists, err := found(err, "failed to list marketplace image versions"); !exists || err != nil {
+				return exists, err
+			}
+			return len(versions) > 0, nil
+		}
+		_, err := c.client.GetMarketplaceImage(ctx, location, mp.Publisher, mp.Offer, mp.SKU, mp.Version)
+		return found(err, "failed to get marketplace image")
+	case image.SharedGallery != nil:
+		sig := image.SharedGallery
+		if strings.EqualFold(sig.Version, latestVersion) {
+			_, err := c.client.GetGalleryImage(ctx, sig.SubscriptionID, sig.ResourceGroup, sig.Gallery, sig.Name)
+			return found(err, "failed to get gallery image")
+		}
+		_, err := c.client.GetGalleryImageVersion(ctx, sig.SubscriptionID, sig.ResourceGroup, sig.Gallery, sig.Name, sig.Version)
+		return found(err, "failed to get gallery image version")
+	}
+	return true, nil
+}
+
+// found returns whether the error of getting a resource means it was found.
+func found(err error, message string) (bool, error) {
+	if azure.ResourceNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, message)
+	}
+	return true, nil
+}
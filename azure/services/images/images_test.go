@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/images/mock_images"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+func TestImageExists(t *testing.T) {
+	notFound := autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found")
+	internalError := autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error")
+	marketplaceImage := func(version string) *infrav1.Image {
+		return &infrav1.Image{Marketplace: &infrav1.AzureMarketplaceImage{Publisher: "cncf-upstream", Offer: "capi", SKU: "ubuntu-1804", Version: version}}
+	}
+	galleryImage := func(version string) *infrav1.Image {
+		return &infrav1.Image{SharedGallery: &infrav1.AzureSharedGalleryImage{SubscriptionID: "123", ResourceGroup: "my-rg", Gallery: "my-gallery", Name: "my-image", Version: version}}
+	}
+
+	testcases := []struct {
+		name          string
+		image         *infrav1.Image
+		expect        func(m *mock_images.MockClientMockRecorder)
+		exists        bool
+		expectedError string
+	}{
+		{
+			name:  "marketplace image version exists",
+			image: marketplaceImage("1.21.2"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.GetMarketplaceImage(gomockinternal.AContext(), "westeurope", "cncf-upstream", "capi", "ubuntu-1804", "1.21.2").Return(compute.VirtualMachineImage{}, nil)
+			},
+			exists: true,
+		},
+		{
+			name:  "marketplace image version doesn't exist",
+			image: marketplaceImage("1.21.99"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.GetMarketplaceImage(gomockinternal.AContext(), "westeurope", "cncf-upstream", "capi", "ubuntu-1804", "1.21.99").Return(compute.VirtualMachineImage{}, notFound)
+			},
+		},
+		{
+			name:  "latest marketplace image without versions",
+			image: marketplaceImage("latest"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.ListMarketplaceImageVersions(gomockinternal.AContext(), "westeurope", "cncf-upstream", "capi", "ubuntu-1804").Return(nil, nil)
+			},
+		},
+		{
+			name:  "latest marketplace image",
+			image: marketplaceImage("latest"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.ListMarketplaceImageVersions(gomockinternal.AContext(), "westeurope", "cncf-upstream", "capi", "ubuntu-1804").Return([]compute.VirtualMachineImageResource{{}}, nil)
+			},
+			exists: true,
+		},
+		{
+			name:  "gallery image version doesn't exist",
+			image: galleryImage("1.0.0"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.GetGalleryImageVersion(gomockinternal.AContext(), "123", "my-rg", "my-gallery", "my-image", "1.0.0").Return(compute.GalleryImageVersion{}, notFound)
+			},
+		},
+		{
+			name:  "latest gallery image",
+			image: galleryImage("latest"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.GetGalleryImage(gomockinternal.AContext(), "123", "my-rg", "my-gallery", "my-image").Return(compute.GalleryImage{}, nil)
+			},
+			exists: true,
+		},
+		{
+			name:  "error getting gallery image version",
+			image: galleryImage("1.0.0"),
+			expect: func(m *mock_images.MockClientMockRecorder) {
+				m.GetGalleryImageVersion(gomockinternal.AContext(), "123", "my-rg", "my-gallery", "my-image", "1.0.0").Return(compute.GalleryImageVersion{}, internalError)
+			},
+			expectedError: "failed to get gallery image version: #: Internal Server Error: StatusCode=500",
+		},
+		{
+			name:   "image by ID",
+			image:  &infrav1.Image{ID: to.StringPtr("my-image-id")},
+			expect: func(m *mock_images.MockClientMockRecorder) {},
+			exists: true,
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			clientMock := mock_images.NewMockClient(mockCtrl)
+			tc.expect(clientMock.EXPECT())
+
+			c := &Checker{client: clientMock}
+			exists, err := c.ImageExists(context.TODO(), "westeurope", tc.image)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(exists).To(Equal(tc.exists))
+		})
+	}
+}
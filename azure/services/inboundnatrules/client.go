@@ -70,7 +70,7 @@ func (ac *azureClient) CreateOrUpdate(ctx context.Context, resourceGroupName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.inboundnatrules.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.inboundnatrules.Client)
 	if err != nil {
 		return err
 	}
@@ -87,7 +87,7 @@ func (ac *azureClient) Delete(ctx context.Context, resourceGroupName, lbName, in
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.inboundnatrules.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.inboundnatrules.Client)
 	if err != nil {
 		return err
 	}
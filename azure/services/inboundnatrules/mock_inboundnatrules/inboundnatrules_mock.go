@@ -95,6 +95,20 @@ func (mr *MockInboundNatScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockInboundNatScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockInboundNatScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockInboundNatScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockInboundNatScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockInboundNatScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -165,6 +179,20 @@ func (mr *MockInboundNatScopeMockRecorder) CloudProviderConfigOverrides() *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockInboundNatScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockInboundNatScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockInboundNatScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockInboundNatScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockInboundNatScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -210,6 +238,20 @@ func (mr *MockInboundNatScopeMockRecorder) Error(err, msg interface{}, keysAndVa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockInboundNatScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockInboundNatScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockInboundNatScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockInboundNatScope)(nil).FailureDomainZone), arg0)
+}
+
 // HashKey mocks base method.
 func (m *MockInboundNatScope) HashKey() string {
 	m.ctrl.T.Helper()
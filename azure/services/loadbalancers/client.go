@@ -87,7 +87,7 @@ func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName str
 		return err
 	}
 
-	err = future.WaitForCompletionRef(ctx, ac.loadbalancers.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.loadbalancers.Client)
 	if err != nil {
 		return err
 	}
@@ -105,7 +105,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, lbName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.loadbalancers.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.loadbalancers.Client)
 	if err != nil {
 		return err
 	}
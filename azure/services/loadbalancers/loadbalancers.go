@@ -77,6 +77,8 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			probes              = make([]network.Probe, 0)
 		)
 
+		// Only the tags of an existing LB are replaced if nothing else changes.
+		putCtx := ctx
 		existingLB, err := s.Client.Get(ctx, s.Scope.ResourceGroup(), lbSpec.Name)
 		switch {
 		case err != nil && !azure.ResourceNotFound(err):
@@ -86,6 +88,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			s.Scope.V(2).Info("found existing load balancer, checking if updates are needed", "load balancer", lbSpec.Name)
 			// We append the existing LB etag to the header to ensure we only apply the updates if the LB has not been modified.
 			etag = existingLB.Etag
+			putCtx = azure.WithExistingResource(ctx, to.String(existingLB.ID), existingLB)
 			update := false
 
 			// merge existing LB properties with desired properties
@@ -163,7 +166,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			},
 		}
 
-		err = s.Client.CreateOrUpdate(ctx, s.Scope.ResourceGroup(), lbSpec.Name, lb)
+		err = s.Client.CreateOrUpdate(putCtx, s.Scope.ResourceGroup(), lbSpec.Name, lb)
 
 		if err != nil {
 			return errors.Wrapf(err, "failed to create load balancer \"%s\"", lbSpec.Name)
@@ -236,6 +239,7 @@ func (s *Service) getOutboundRules(lbSpec azure.LBSpec, frontendIDs []network.Su
 			OutboundRulePropertiesFormat: &network.OutboundRulePropertiesFormat{
 				Protocol:                 network.LoadBalancerOutboundRuleProtocolAll,
 				IdleTimeoutInMinutes:     lbSpec.IdleTimeoutInMinutes,
+				AllocatedOutboundPorts:   lbSpec.AllocatedOutboundPorts,
 				FrontendIPConfigurations: &frontendIDs,
 				BackendAddressPool: &network.SubResource{
 					ID: to.StringPtr(azure.AddressPoolID(s.Scope.SubscriptionID(), s.Scope.ResourceGroup(), lbSpec.Name, lbSpec.BackendPoolName)),
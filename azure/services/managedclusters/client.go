@@ -29,12 +29,16 @@ import (
 
 // Client wraps go-sdk.
 type Client interface {
-	Get(context.Context, string, string) (containerservice.ManagedCluster, error)
+	Get(context.Context, string, string) (containerservice.ManagedCluster, map[string]interface{}, error)
 	GetCredentials(context.Context, string, string) ([]byte, error)
-	CreateOrUpdate(context.Context, string, string, containerservice.ManagedCluster) error
+	CreateOrUpdate(context.Context, string, string, containerservice.ManagedCluster, map[string]interface{}) error
 	Delete(context.Context, string, string) error
 }
 
+// additionalPropertiesAPIVersion is the AKS API version used to read and write the managed cluster
+// fields which are not modelled by the containerservice SDK package in use.
+const additionalPropertiesAPIVersion = "2024-05-01"
+
 // AzureClient contains the Azure go-sdk Client.
 type AzureClient struct {
 	managedclusters containerservice.ManagedClustersClient
@@ -56,9 +60,37 @@ func newManagedClustersClient(subscriptionID string, baseURI string, authorizer
 	return managedClustersClient
 }
 
-// Get gets a managed cluster.
-func (ac *AzureClient) Get(ctx context.Context, resourceGroupName, name string) (containerservice.ManagedCluster, error) {
-	return ac.managedclusters.Get(ctx, resourceGroupName, name)
+// Get gets a managed cluster, along with its fields as returned by the AKS API. The latter include the
+// fields and properties not modelled by the containerservice SDK package in use.
+func (ac *AzureClient) Get(ctx context.Context, resourceGroupName, name string) (containerservice.ManagedCluster, map[string]interface{}, error) {
+	ctx, span := tele.Tracer().Start(ctx, "managedclusters.AzureClient.Get")
+	defer span.End()
+
+	req, err := ac.managedclusters.GetPreparer(ctx, resourceGroupName, name)
+	if err != nil {
+		return containerservice.ManagedCluster{}, nil, autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "Get", nil, "Failure preparing request")
+	}
+
+	req, err = autorest.Prepare(req, azure.WithAPIVersion(additionalPropertiesAPIVersion))
+	if err != nil {
+		return containerservice.ManagedCluster{}, nil, autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "Get", nil, "Failure preparing request")
+	}
+
+	resp, err := ac.managedclusters.GetSender(req)
+	if err != nil {
+		return containerservice.ManagedCluster{}, nil, autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "Get", resp, "Failure sending request")
+	}
+
+	var fields map[string]interface{}
+	if err := autorest.Respond(resp, azure.ByCapturingAdditionalFields(&fields)); err != nil {
+		return containerservice.ManagedCluster{}, nil, autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "Get", resp, "Failure responding to request")
+	}
+
+	managedCluster, err := ac.managedclusters.GetResponder(resp)
+	if err != nil {
+		return managedCluster, nil, autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "Get", resp, "Failure responding to request")
+	}
+	return managedCluster, fields, nil
 }
 
 // GetCredentials fetches the admin kubeconfig for a managed cluster.
@@ -78,16 +110,27 @@ func (ac *AzureClient) GetCredentials(ctx context.Context, resourceGroupName, na
 	return *(*credentialList.Kubeconfigs)[0].Value, nil
 }
 
-// CreateOrUpdate creates or updates a managed cluster.
-func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, name string, cluster containerservice.ManagedCluster) error {
+// CreateOrUpdate creates or updates a managed cluster. Additional fields, if any, are merged into the
+// request body.
+func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, name string, cluster containerservice.ManagedCluster, additionalFields map[string]interface{}) error {
 	ctx, span := tele.Tracer().Start(ctx, "managedclusters.AzureClient.CreateOrUpdate")
 	defer span.End()
 
-	future, err := ac.managedclusters.CreateOrUpdate(ctx, resourceGroupName, name, cluster)
+	req, err := ac.managedclusters.CreateOrUpdatePreparer(ctx, resourceGroupName, name, cluster)
+	if err != nil {
+		return autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "CreateOrUpdate", nil, "Failure preparing request")
+	}
+
+	req, err = autorest.Prepare(req, azure.WithAdditionalFields(additionalPropertiesAPIVersion, additionalFields))
+	if err != nil {
+		return autorest.NewErrorWithError(err, "containerservice.ManagedClustersClient", "CreateOrUpdate", nil, "Failure preparing request")
+	}
+
+	future, err := ac.managedclusters.CreateOrUpdateSender(req)
 	if err != nil {
 		return errors.Wrap(err, "failed to begin operation")
 	}
-	if err := future.WaitForCompletionRef(ctx, ac.managedclusters.Client); err != nil {
+	if err := azure.WaitForCompletion(ctx, &future, ac.managedclusters.Client); err != nil {
 		return errors.Wrap(err, "failed to end operation")
 	}
 	_, err = future.Result(ac.managedclusters)
@@ -106,7 +149,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, name strin
 		}
 		return errors.Wrap(err, "failed to begin operation")
 	}
-	if err := future.WaitForCompletionRef(ctx, ac.managedclusters.Client); err != nil {
+	if err := azure.WaitForCompletion(ctx, &future, ac.managedclusters.Client); err != nil {
 		return errors.Wrap(err, "failed to end operation")
 	}
 	_, err = future.Result(ac.managedclusters)
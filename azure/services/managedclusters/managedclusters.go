@@ -28,6 +28,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/generators"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -82,6 +83,44 @@ type Spec struct {
 
 	// DNSServiceIP is an IP address assigned to the Kubernetes DNS service
 	DNSServiceIP *string
+
+	// WebAppRouting configures the web app routing addon of the ingress profile.
+	WebAppRouting *WebAppRouting
+
+	// WindowsProfile configures the Windows nodes of this cluster.
+	WindowsProfile *WindowsProfile
+
+	// CostAnalysis enables the cost analysis addon, which exports per-namespace costs to Azure Cost Management.
+	CostAnalysis *bool
+
+	// SKUTier is the tier of the managed cluster. Possible values include: 'Free', 'Standard', 'Premium'.
+	// AKS creates clusters of the Free tier when it is empty.
+	SKUTier string
+}
+
+// WindowsProfile contains the settings of the Windows nodes of a managed cluster.
+type WindowsProfile struct {
+	// LicenseType is the license type of the Windows VMs. Possible values include: 'None', 'Windows_Server'.
+	LicenseType string
+
+	// GMSA configures group managed service accounts for the Windows nodes.
+	GMSA *GMSAProfile
+}
+
+// GMSAProfile contains the group managed service account settings of the Windows nodes.
+type GMSAProfile struct {
+	Enabled        bool
+	DNSServer      string
+	RootDomainName string
+}
+
+// WebAppRouting contains the web app routing addon settings.
+type WebAppRouting struct {
+	// Enabled toggles the addon.
+	Enabled bool
+
+	// DNSZoneResourceIDs are the resource IDs of the DNS zones the addon manages records in.
+	DNSZoneResourceIDs []string
 }
 
 // PoolSpec contains agent pool specification details.
@@ -90,6 +129,92 @@ type PoolSpec struct {
 	SKU          string
 	Replicas     int32
 	OSDiskSizeGB int32
+
+	// AvailabilityZones are the availability zones of the nodes.
+	AvailabilityZones []string
+}
+
+// additionalProperties returns the managed cluster properties which are not modelled by the
+// containerservice SDK package in use.
+func (s *Spec) additionalProperties() map[string]interface{} {
+	props := map[string]interface{}{}
+	if s.WebAppRouting != nil {
+		webAppRouting := map[string]interface{}{
+			"enabled": s.WebAppRouting.Enabled,
+		}
+		if len(s.WebAppRouting.DNSZoneResourceIDs) > 0 {
+			webAppRouting["dnsZoneResourceIds"] = s.WebAppRouting.DNSZoneResourceIDs
+		}
+		props["ingressProfile"] = map[string]interface{}{
+			"webAppRouting": webAppRouting,
+		}
+	}
+	if s.WindowsProfile != nil {
+		windowsProfile := map[string]interface{}{}
+		if s.WindowsProfile.LicenseType != "" {
+			windowsProfile["licenseType"] = s.WindowsProfile.LicenseType
+		}
+		if gmsa := s.WindowsProfile.GMSA; gmsa != nil {
+			gmsaProfile := map[string]interface{}{
+				"enabled": gmsa.Enabled,
+			}
+			if gmsa.DNSServer != "" {
+				gmsaProfile["dnsServer"] = gmsa.DNSServer
+				gmsaProfile["rootDomainName"] = gmsa.RootDomainName
+			}
+			windowsProfile["gmsaProfile"] = gmsaProfile
+		}
+		if len(windowsProfile) > 0 {
+			props["windowsProfile"] = windowsProfile
+		}
+	}
+	if s.CostAnalysis != nil {
+		props["metricsProfile"] = map[string]interface{}{
+			"costAnalysis": map[string]interface{}{
+				"enabled": *s.CostAnalysis,
+			},
+		}
+	}
+	return props
+}
+
+// additionalFields returns the managed cluster fields and properties which are not modelled by the
+// containerservice SDK package in use.
+func (s *Spec) additionalFields() map[string]interface{} {
+	fields := map[string]interface{}{}
+	if props := s.additionalProperties(); len(props) > 0 {
+		fields["properties"] = props
+	}
+	if s.SKUTier != "" {
+		fields["sku"] = map[string]interface{}{
+			"name": "Base",
+			"tier": s.SKUTier,
+		}
+	}
+	return fields
+}
+
+// webAppRoutingEnabled returns whether the web app routing addon is enabled in the fields of a managed cluster.
+func webAppRoutingEnabled(fields map[string]interface{}) bool {
+	props, _ := fields["properties"].(map[string]interface{})
+	ingressProfile, _ := props["ingressProfile"].(map[string]interface{})
+	webAppRouting, _ := ingressProfile["webAppRouting"].(map[string]interface{})
+	enabled, _ := webAppRouting["enabled"].(bool)
+	return enabled
+}
+
+// disableWebAppRouting sets the web app routing addon as disabled in the fields of a managed cluster.
+func disableWebAppRouting(fields map[string]interface{}) {
+	props, ok := fields["properties"].(map[string]interface{})
+	if !ok {
+		props = map[string]interface{}{}
+		fields["properties"] = props
+	}
+	props["ingressProfile"] = map[string]interface{}{
+		"webAppRouting": map[string]interface{}{
+			"enabled": false,
+		},
+	}
 }
 
 // Get fetches a managed cluster from Azure.
@@ -101,7 +226,8 @@ func (s *Service) Get(ctx context.Context, spec interface{}) (interface{}, error
 	if !ok {
 		return nil, errors.New("expected managed cluster specification")
 	}
-	return s.Client.Get(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name)
+	managedCluster, _, err := s.Client.Get(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name)
+	return managedCluster, err
 }
 
 // GetCredentials fetches a managed cluster kubeconfig from Azure.
@@ -186,17 +312,29 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 			Type:         containerservice.VirtualMachineScaleSets,
 			VnetSubnetID: &managedClusterSpec.VnetSubnetID,
 		}
+		if len(pool.AvailabilityZones) > 0 {
+			profile.AvailabilityZones = &pool.AvailabilityZones
+		}
 		*managedCluster.AgentPoolProfiles = append(*managedCluster.AgentPoolProfiles, profile)
 	}
 
-	existingMC, err := s.Client.Get(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name)
+	existingMC, existingFields, err := s.Client.Get(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name)
 	if err != nil && !azure.ResourceNotFound(err) {
 		return errors.Wrap(err, "failed to get existing managed cluster")
 	}
 
 	isCreate := azure.ResourceNotFound(err)
 	if isCreate {
-		err = s.Client.CreateOrUpdate(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name, managedCluster)
+		// Windows agent pools can only be added to clusters that were created with a Windows profile.
+		// The admin password is never used by CAPZ.
+		if managedClusterSpec.WindowsProfile != nil {
+			managedCluster.WindowsProfile = &containerservice.ManagedClusterWindowsProfile{
+				AdminUsername: &defaultUser,
+				AdminPassword: to.StringPtr(generators.SudoRandomPassword(123)),
+			}
+		}
+
+		err = s.Client.CreateOrUpdate(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name, managedCluster, managedClusterSpec.additionalFields())
 		if err != nil {
 			return fmt.Errorf("failed to create managed cluster, %w", err)
 		}
@@ -207,6 +345,14 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 			return nil
 		}
 
+		// AKS rejects updates of the Windows profile without the admin username, which can't be changed after
+		// the cluster was created.
+		if existingMC.WindowsProfile != nil && existingMC.WindowsProfile.AdminUsername != nil {
+			managedCluster.WindowsProfile = &containerservice.ManagedClusterWindowsProfile{
+				AdminUsername: existingMC.WindowsProfile.AdminUsername,
+			}
+		}
+
 		// Normalize properties for the desired (CR spec) and existing managed
 		// cluster, so that we check only those fields that were specified in
 		// the initial CreateOrUpdate request and that can be modified.
@@ -220,10 +366,17 @@ func (s *Service) Reconcile(ctx context.Context, spec interface{}) error {
 			KubernetesVersion: existingMC.ManagedClusterProperties.KubernetesVersion,
 		}
 
-		diff := cmp.Diff(propertiesNormalized, existingMCPropertiesNormalized)
+		// AKS keeps the properties missing from an update, so the web app routing addon is disabled explicitly
+		// once it is removed from the spec.
+		additionalFields := managedClusterSpec.additionalFields()
+		if managedClusterSpec.WebAppRouting == nil && webAppRoutingEnabled(existingFields) {
+			disableWebAppRouting(additionalFields)
+		}
+
+		diff := cmp.Diff(propertiesNormalized, existingMCPropertiesNormalized) + azure.DiffAdditionalProperties(additionalFields, existingFields)
 		if diff != "" {
 			klog.V(2).Infof("Update required (+new -old):\n%s", diff)
-			err = s.Client.CreateOrUpdate(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name, managedCluster)
+			err = s.Client.CreateOrUpdate(ctx, managedClusterSpec.ResourceGroupName, managedClusterSpec.Name, managedCluster, additionalFields)
 			if err != nil {
 				return fmt.Errorf("failed to update managed cluster, %w", err)
 			}
@@ -18,11 +18,13 @@ package managedclusters
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2020-02-01/containerservice"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
 
@@ -47,10 +49,10 @@ func TestReconcile(t *testing.T) {
 			provisioningStatesToTest: []string{"Canceled", "Succeeded", "Failed"},
 			expectedError:            "",
 			expect: func(m *mock_managedclusters.MockClientMockRecorder, provisioningstate string) {
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any()).Return(nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any(), gomock.Any()).Return(nil)
 				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
 					ProvisioningState: &provisioningstate,
-				}}, nil)
+				}}, nil, nil)
 			},
 		},
 		{
@@ -64,7 +66,7 @@ func TestReconcile(t *testing.T) {
 			expect: func(m *mock_managedclusters.MockClientMockRecorder, provisioningstate string) {
 				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
 					ProvisioningState: &provisioningstate,
-				}}, nil)
+				}}, nil, nil)
 			},
 		},
 	}
@@ -111,8 +113,264 @@ func TestReconcile(t *testing.T) {
 			},
 			expectedError: "",
 			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
-				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any()).Return(nil)
-				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any(), gomock.Any()).Return(nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+			},
+		},
+		{
+			name: "create managedcluster with windows profile",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				NetworkPlugin:     "azure",
+				WindowsProfile: &WindowsProfile{
+					LicenseType: "Windows_Server",
+					GMSA: &GMSAProfile{
+						Enabled:        true,
+						DNSServer:      "10.0.0.4",
+						RootDomainName: "contoso.com",
+					},
+				},
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.AssignableToTypeOf(containerservice.ManagedCluster{}), map[string]interface{}{
+					"properties": map[string]interface{}{
+						"windowsProfile": map[string]interface{}{
+							"licenseType": "Windows_Server",
+							"gmsaProfile": map[string]interface{}{
+								"enabled":        true,
+								"dnsServer":      "10.0.0.4",
+								"rootDomainName": "contoso.com",
+							},
+						},
+					},
+				}).DoAndReturn(func(_ context.Context, _, _ string, cluster containerservice.ManagedCluster, _ map[string]interface{}) error {
+					if cluster.WindowsProfile == nil || cluster.WindowsProfile.AdminPassword == nil {
+						return errors.New("expected a windows profile with an admin password")
+					}
+					return nil
+				})
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+			},
+		},
+		{
+			name: "create managedcluster with azure network plugin and no windows profile",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				NetworkPlugin:     "azure",
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.AssignableToTypeOf(containerservice.ManagedCluster{}), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, cluster containerservice.ManagedCluster, _ map[string]interface{}) error {
+						if cluster.WindowsProfile != nil {
+							return errors.New("expected no windows profile")
+						}
+						return nil
+					})
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+			},
+		},
+		{
+			name: "update managedcluster with windows profile",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				Version:           "1.20.7",
+				NetworkPlugin:     "azure",
+				WindowsProfile: &WindowsProfile{
+					LicenseType: "Windows_Server",
+				},
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+					ProvisioningState: to.StringPtr("Succeeded"),
+					KubernetesVersion: to.StringPtr("1.20.7"),
+					WindowsProfile: &containerservice.ManagedClusterWindowsProfile{
+						AdminUsername: to.StringPtr("azureuser"),
+					},
+				}}, map[string]interface{}{
+					"properties": map[string]interface{}{
+						"windowsProfile": map[string]interface{}{
+							"licenseType": "None",
+						},
+					},
+				}, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.AssignableToTypeOf(containerservice.ManagedCluster{}), map[string]interface{}{
+					"properties": map[string]interface{}{
+						"windowsProfile": map[string]interface{}{
+							"licenseType": "Windows_Server",
+						},
+					},
+				}).DoAndReturn(func(_ context.Context, _, _ string, cluster containerservice.ManagedCluster, _ map[string]interface{}) error {
+					if cluster.WindowsProfile == nil || to.String(cluster.WindowsProfile.AdminUsername) != "azureuser" {
+						return errors.New("expected a windows profile with the existing admin username")
+					}
+					if cluster.WindowsProfile.AdminPassword != nil {
+						return errors.New("expected no admin password")
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "create managedcluster with web app routing",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				WebAppRouting: &WebAppRouting{
+					Enabled:            true,
+					DNSZoneResourceIDs: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/dnszones/example.com"},
+				},
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any(), map[string]interface{}{
+					"properties": map[string]interface{}{
+						"ingressProfile": map[string]interface{}{
+							"webAppRouting": map[string]interface{}{
+								"enabled":            true,
+								"dnsZoneResourceIds": []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/dnszones/example.com"},
+							},
+						},
+					},
+				}).Return(nil)
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{}, nil, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not Found"))
+			},
+		},
+		{
+			name: "update managedcluster when cost analysis is toggled",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				Version:           "1.20.7",
+				CostAnalysis:      to.BoolPtr(true),
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+					ProvisioningState: to.StringPtr("Succeeded"),
+					KubernetesVersion: to.StringPtr("1.20.7"),
+				}}, map[string]interface{}{
+					"properties": map[string]interface{}{
+						"metricsProfile": map[string]interface{}{
+							"costAnalysis": map[string]interface{}{"enabled": false},
+						},
+					},
+				}, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any(), map[string]interface{}{
+					"properties": map[string]interface{}{
+						"metricsProfile": map[string]interface{}{
+							"costAnalysis": map[string]interface{}{"enabled": true},
+						},
+					},
+				}).Return(nil)
+			},
+		},
+		{
+			name: "no update when cost analysis matches",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				Version:           "1.20.7",
+				CostAnalysis:      to.BoolPtr(true),
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+					ProvisioningState: to.StringPtr("Succeeded"),
+					KubernetesVersion: to.StringPtr("1.20.7"),
+				}}, map[string]interface{}{
+					"properties": map[string]interface{}{
+						"metricsProfile": map[string]interface{}{
+							"costAnalysis": map[string]interface{}{"enabled": true},
+						},
+					},
+				}, nil)
+			},
+		},
+		{
+			name: "disable web app routing when it is removed from the spec",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				Version:           "1.20.7",
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+					ProvisioningState: to.StringPtr("Succeeded"),
+					KubernetesVersion: to.StringPtr("1.20.7"),
+				}}, map[string]interface{}{
+					"properties": map[string]interface{}{
+						"ingressProfile": map[string]interface{}{
+							"webAppRouting": map[string]interface{}{"enabled": true},
+						},
+					},
+				}, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any(), map[string]interface{}{
+					"properties": map[string]interface{}{
+						"ingressProfile": map[string]interface{}{
+							"webAppRouting": map[string]interface{}{"enabled": false},
+						},
+					},
+				}).Return(nil)
+			},
+		},
+		{
+			name: "no update when web app routing is disabled and not in the spec",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				Version:           "1.20.7",
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+					ProvisioningState: to.StringPtr("Succeeded"),
+					KubernetesVersion: to.StringPtr("1.20.7"),
+				}}, map[string]interface{}{
+					"properties": map[string]interface{}{
+						"ingressProfile": map[string]interface{}{
+							"webAppRouting": map[string]interface{}{"enabled": false},
+						},
+					},
+				}, nil)
+			},
+		},
+		{
+			name: "update managedcluster when the sku tier changes",
+			managedclusterspec: Spec{
+				Name:              "my-managedcluster",
+				ResourceGroupName: "my-rg",
+				Version:           "1.20.7",
+				CostAnalysis:      to.BoolPtr(true),
+				SKUTier:           "Standard",
+			},
+			expectedError: "",
+			expect: func(m *mock_managedclusters.MockClientMockRecorder) {
+				m.Get(gomockinternal.AContext(), "my-rg", "my-managedcluster").Return(containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+					ProvisioningState: to.StringPtr("Succeeded"),
+					KubernetesVersion: to.StringPtr("1.20.7"),
+				}}, map[string]interface{}{
+					"sku": map[string]interface{}{"name": "Base", "tier": "Free"},
+					"properties": map[string]interface{}{
+						"metricsProfile": map[string]interface{}{
+							"costAnalysis": map[string]interface{}{"enabled": false},
+						},
+					},
+				}, nil)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-managedcluster", gomock.Any(), map[string]interface{}{
+					"sku": map[string]interface{}{"name": "Base", "tier": "Standard"},
+					"properties": map[string]interface{}{
+						"metricsProfile": map[string]interface{}{
+							"costAnalysis": map[string]interface{}{"enabled": true},
+						},
+					},
+				}).Return(nil)
 			},
 		},
 	}
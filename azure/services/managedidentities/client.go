@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedidentities
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/Azure/azure-sdk-for-go/services/msi/mgmt/2018-11-30/msi"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk.
+type client interface {
+	GetIdentity(context.Context, string, string) (msi.Identity, error)
+	CreateOrUpdateIdentity(context.Context, string, string, msi.Identity) (msi.Identity, error)
+	DeleteIdentity(context.Context, string, string) error
+	CreateRoleAssignment(context.Context, string, string, authorization.RoleAssignmentCreateParameters) error
+	DeleteRoleAssignment(context.Context, string, string) error
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	identities      msi.UserAssignedIdentitiesClient
+	roleassignments authorization.RoleAssignmentsClient
+}
+
+var _ client = (*azureClient)(nil)
+
+// newClient creates a new managed identities client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	identities := msi.NewUserAssignedIdentitiesClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&identities.Client, auth.Authorizer())
+	roleassignments := authorization.NewRoleAssignmentsClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&roleassignments.Client, auth.Authorizer())
+	return &azureClient{identities, roleassignments}
+}
+
+// GetIdentity gets a user-assigned identity.
+func (ac *azureClient) GetIdentity(ctx context.Context, resourceGroupName, name string) (msi.Identity, error) {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.AzureClient.GetIdentity")
+	defer span.End()
+
+	return ac.identities.Get(ctx, resourceGroupName, name)
+}
+
+// CreateOrUpdateIdentity creates or updates a user-assigned identity.
+func (ac *azureClient) CreateOrUpdateIdentity(ctx context.Context, resourceGroupName, name string, identity msi.Identity) (msi.Identity, error) {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.AzureClient.CreateOrUpdateIdentity")
+	defer span.End()
+
+	return ac.identities.CreateOrUpdate(ctx, resourceGroupName, name, identity)
+}
+
+// DeleteIdentity deletes a user-assigned identity.
+func (ac *azureClient) DeleteIdentity(ctx context.Context, resourceGroupName, name string) error {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.AzureClient.DeleteIdentity")
+	defer span.End()
+
+	_, err := ac.identities.Delete(ctx, resourceGroupName, name)
+	return err
+}
+
+// CreateRoleAssignment creates a role assignment.
+func (ac *azureClient) CreateRoleAssignment(ctx context.Context, scope, roleAssignmentName string, parameters authorization.RoleAssignmentCreateParameters) error {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.AzureClient.CreateRoleAssignment")
+	defer span.End()
+
+	_, err := ac.roleassignments.Create(ctx, scope, roleAssignmentName, parameters)
+	return err
+}
+
+// DeleteRoleAssignment deletes a role assignment.
+func (ac *azureClient) DeleteRoleAssignment(ctx context.Context, scope, roleAssignmentName string) error {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.AzureClient.DeleteRoleAssignment")
+	defer span.End()
+
+	_, err := ac.roleassignments.Delete(ctx, scope, roleAssignmentName)
+	return err
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedidentities
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/Azure/azure-sdk-for-go/services/msi/mgmt/2018-11-30/msi"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// ManagedIdentityScope defines the scope interface for a managed identities service.
+type ManagedIdentityScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	CloudProviderIdentitySpec() *azure.ManagedIdentitySpec
+}
+
+// Service provides operations on user-assigned identities.
+type Service struct {
+	Scope ManagedIdentityScope
+	client
+}
+
+// New creates a new service.
+func New(scope ManagedIdentityScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: newClient(scope),
+	}
+}
+
+// Reconcile creates the user-assigned identity of the cloud provider and assigns it the roles it needs.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.Service.Reconcile")
+	defer span.End()
+
+	spec := s.Scope.CloudProviderIdentitySpec()
+	if spec == nil {
+		return nil
+	}
+
+	s.Scope.V(2).Info("creating user-assigned identity", "identity", spec.Name)
+	identity, err := s.client.CreateOrUpdateIdentity(ctx, spec.ResourceGroup, spec.Name, msi.Identity{
+		Location: to.StringPtr(s.Scope.Location()),
+		Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+			ClusterName: s.Scope.ClusterName(),
+			Lifecycle:   infrav1.ResourceLifecycleOwned,
+			Name:        to.StringPtr(spec.Name),
+			Role:        to.StringPtr(infrav1.CommonRole),
+			Additional:  s.Scope.AdditionalTags(),
+		})),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create user-assigned identity %s in resource group %s", spec.Name, spec.ResourceGroup)
+	}
+
+	principalID, err := identityPrincipalID(identity)
+	if err != nil {
+		return err
+	}
+	for _, role := range spec.Roles {
+		scope := s.resourceGroupScope(role.ResourceGroup)
+		params := authorization.RoleAssignmentCreateParameters{
+			Properties: &authorization.RoleAssignmentProperties{
+				RoleDefinitionID: to.StringPtr(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", s.Scope.SubscriptionID(), role.RoleDefinitionID)),
+				PrincipalID:      to.StringPtr(principalID),
+			},
+		}
+		// A conflict means the role is assigned to the identity already.
+		// The principal of a new identity may not have replicated yet, in which case the assignment is retried on the next reconciliation.
+		if err := s.client.CreateRoleAssignment(ctx, scope, roleAssignmentName(scope, role.RoleDefinitionID, principalID), params); err != nil && !azure.ResourceConflict(err) {
+			return errors.Wrapf(err, "failed to assign role %s in resource group %s to user-assigned identity %s", role.RoleDefinitionID, role.ResourceGroup, spec.Name)
+		}
+	}
+
+	s.Scope.V(2).Info("successfully created user-assigned identity", "identity", spec.Name)
+	return nil
+}
+
+// Delete removes the role assignments and the user-assigned identity of the cloud provider, if owned by the cluster.
+func (s *Service) Delete(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "managedidentities.Service.Delete")
+	defer span.End()
+
+	spec := s.Scope.CloudProviderIdentitySpec()
+	if spec == nil {
+		return nil
+	}
+
+	identity, err := s.client.GetIdentity(ctx, spec.ResourceGroup, spec.Name)
+	if azure.ResourceNotFound(err) {
+		// The identity was deleted with the resource group of the cluster, or never created.
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to get user-assigned identity %s in resource group %s", spec.Name, spec.ResourceGroup)
+	}
+	if !converters.MapToTags(identity.Tags).HasOwned(s.Scope.ClusterName()) {
+		s.Scope.V(4).Info("skipping deletion of unmanaged user-assigned identity", "identity", spec.Name)
+		return nil
+	}
+
+	// Role assignments outlive their principal, so they are removed before the identity.
+	principalID, err := identityPrincipalID(identity)
+	if err != nil {
+		return err
+	}
+	for _, role := range spec.Roles {
+		scope := s.resourceGroupScope(role.ResourceGroup)
+		name := roleAssignmentName(scope, role.RoleDefinitionID, principalID)
+		if err := s.client.DeleteRoleAssignment(ctx, scope, name); err != nil && !azure.ResourceNotFound(err) {
+			return errors.Wrapf(err, "failed to delete role assignment %s in resource group %s", name, role.ResourceGroup)
+		}
+	}
+
+	s.Scope.V(2).Info("deleting user-assigned identity", "identity", spec.Name)
+	if err := s.client.DeleteIdentity(ctx, spec.ResourceGroup, spec.Name); err != nil && !azure.ResourceNotFound(err) {
+		return errors.Wrapf(err, "failed to delete user-assigned identity %s in resource group %s", spec.Name, spec.ResourceGroup)
+	}
+
+	s.Scope.V(2).Info("successfully deleted user-assigned identity", "identity", spec.Name)
+	return nil
+}
+
+func (s *Service) resourceGroupScope(resourceGroup string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", s.Scope.SubscriptionID(), resourceGroup)
+}
+
+// identityPrincipalID returns the object ID of the service principal of a user-assigned identity.
+func identityPrincipalID(identity msi.Identity) (string, error) {
+	if identity.UserAssignedIdentityProperties == nil || identity.PrincipalID == nil {
+		return "", errors.Errorf("user-assigned identity %s has no principal ID", to.String(identity.Name))
+	}
+	return identity.PrincipalID.String(), nil
+}
+
+// roleAssignmentName returns a stable name for a role assignment, so it is created only once.
+func roleAssignmentName(scope, roleDefinitionID, principalID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(scope+roleDefinitionID+principalID)).String()
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedidentities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/msi/mgmt/2018-11-30/msi"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedidentities/mock_managedidentities"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+const fakePrincipalID = "6c1b7f0a-5a1e-4b0c-9d2b-0f2f1c1e8e4a"
+
+var (
+	fakeSpec = &azure.ManagedIdentitySpec{
+		Name:          "my-cluster-cloud-provider",
+		ResourceGroup: "my-rg",
+		Roles: []azure.ManagedIdentityRoleSpec{
+			{ResourceGroup: "my-rg", RoleDefinitionID: azure.VirtualMachineContributorRoleID},
+			{ResourceGroup: "my-vnet-rg", RoleDefinitionID: azure.NetworkContributorRoleID},
+		},
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	conflictError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusConflict}, "Conflict")
+	notFoundError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+)
+
+// fakeIdentity returns a user-assigned identity as returned by Azure, with read-only properties set.
+func fakeIdentity(t *testing.T, owner string) msi.Identity {
+	var identity msi.Identity
+	data := `{"name": "my-cluster-cloud-provider", "properties": {"principalId": "` + fakePrincipalID + `"}, "tags": {"sigs.k8s.io_cluster-api-provider-azure_cluster_` + owner + `": "owned"}}`
+	if err := json.Unmarshal([]byte(data), &identity); err != nil {
+		t.Fatal(err)
+	}
+	return identity
+}
+
+func TestReconcileManagedIdentities(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "cloud provider identity is not enabled",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.CloudProviderIdentitySpec().Return(nil)
+			},
+		},
+		{
+			name: "identity is created and assigned roles",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.Location().Return("westus")
+				s.ClusterName().Return("my-cluster")
+				s.AdditionalTags().Return(nil)
+				m.CreateOrUpdateIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider", gomock.AssignableToTypeOf(msi.Identity{})).Return(fakeIdentity(t, "my-cluster"), nil)
+				m.CreateRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", roleAssignmentName("/subscriptions/12345/resourceGroups/my-rg", azure.VirtualMachineContributorRoleID, fakePrincipalID), gomock.Any())
+				m.CreateRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-vnet-rg", gomock.Any(), gomock.Any()).Return(conflictError)
+			},
+		},
+		{
+			name:          "identity creation fails",
+			expectedError: "failed to create user-assigned identity my-cluster-cloud-provider in resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				s.Location().Return("westus")
+				s.ClusterName().Return("my-cluster")
+				s.AdditionalTags().Return(nil)
+				m.CreateOrUpdateIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider", gomock.Any()).Return(msi.Identity{}, internalError)
+			},
+		},
+		{
+			name:          "role assignment fails",
+			expectedError: "failed to assign role 9980e02c-c2be-4d73-94e8-173b1dc7cf3c in resource group my-rg to user-assigned identity my-cluster-cloud-provider: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.Location().Return("westus")
+				s.ClusterName().Return("my-cluster")
+				s.AdditionalTags().Return(nil)
+				m.CreateOrUpdateIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider", gomock.Any()).Return(fakeIdentity(t, "my-cluster"), nil)
+				m.CreateRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", gomock.Any(), gomock.Any()).Return(internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_managedidentities.NewMockManagedIdentityScope(mockCtrl)
+			clientMock := mock_managedidentities.NewMockclient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestDeleteManagedIdentities(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "cloud provider identity is not enabled",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.CloudProviderIdentitySpec().Return(nil)
+			},
+		},
+		{
+			name: "identity was already deleted",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				m.GetIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider").Return(msi.Identity{}, notFoundError)
+			},
+		},
+		{
+			name: "unmanaged identity is not deleted",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(4)).AnyTimes().Return(klogr.New())
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				s.ClusterName().Return("my-cluster")
+				m.GetIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider").Return(fakeIdentity(t, "other-cluster"), nil)
+			},
+		},
+		{
+			name: "role assignments and identity are deleted",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.ClusterName().Return("my-cluster")
+				m.GetIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider").Return(fakeIdentity(t, "my-cluster"), nil)
+				m.DeleteRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-rg", roleAssignmentName("/subscriptions/12345/resourceGroups/my-rg", azure.VirtualMachineContributorRoleID, fakePrincipalID))
+				m.DeleteRoleAssignment(gomockinternal.AContext(), "/subscriptions/12345/resourceGroups/my-vnet-rg", gomock.Any()).Return(notFoundError)
+				m.DeleteIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider")
+			},
+		},
+		{
+			name:          "identity deletion fails",
+			expectedError: "failed to delete user-assigned identity my-cluster-cloud-provider in resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_managedidentities.MockManagedIdentityScopeMockRecorder, m *mock_managedidentities.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.CloudProviderIdentitySpec().Return(fakeSpec)
+				s.SubscriptionID().AnyTimes().Return("12345")
+				s.ClusterName().Return("my-cluster")
+				m.GetIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider").Return(fakeIdentity(t, "my-cluster"), nil)
+				m.DeleteRoleAssignment(gomockinternal.AContext(), gomock.Any(), gomock.Any()).Times(2)
+				m.DeleteIdentity(gomockinternal.AContext(), "my-rg", "my-cluster-cloud-provider").Return(internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_managedidentities.NewMockManagedIdentityScope(mockCtrl)
+			clientMock := mock_managedidentities.NewMockclient(mockCtrl)
+
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Delete(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
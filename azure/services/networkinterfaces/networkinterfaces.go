@@ -24,7 +24,9 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
@@ -145,6 +147,12 @@ func (s *Service) Reconcile(ctx context.Context) error {
 				nicSpec.Name,
 				network.Interface{
 					Location: to.StringPtr(s.Scope.Location()),
+					Tags: converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+						ClusterName: s.Scope.ClusterName(),
+						Lifecycle:   infrav1.ResourceLifecycleOwned,
+						Name:        to.StringPtr(nicSpec.Name),
+						Additional:  s.Scope.AdditionalTags(),
+					})),
 					InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
 						EnableAcceleratedNetworking: nicSpec.AcceleratedNetworking,
 						IPConfigurations:            &ipConfigurations,
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanedresources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk.
+type client interface {
+	ListOwned(context.Context, string, string) ([]resources.GenericResourceExpanded, error)
+	ListRetained(context.Context, string) ([]resources.GenericResourceExpanded, error)
+	DeleteByID(context.Context, string, string) error
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	resources resources.Client
+}
+
+var _ client = (*azureClient)(nil)
+
+// newClient creates a new resources client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := resources.NewClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&c.Client, auth.Authorizer())
+	return &azureClient{c}
+}
+
+// ListOwned lists the resources of a resource group tagged as owned by a cluster, with their creation time.
+func (ac *azureClient) ListOwned(ctx context.Context, resourceGroupName, clusterName string) ([]resources.GenericResourceExpanded, error) {
+	ctx, span := tele.Tracer().Start(ctx, "orphanedresources.AzureClient.ListOwned")
+	defer span.End()
+
+	filter := fmt.Sprintf("tagName eq '%s' and tagValue eq '%s'", infrav1.ClusterTagKey(clusterName), infrav1.ResourceLifecycleOwned)
+	return ac.list(ctx, resourceGroupName, filter, "createdTime")
+}
+
+// ListRetained lists the resources of a resource group tagged as retained by the deletion policy of their machine.
+// Azure doesn't return the tags of resources listed with a tag filter, so retained resources are listed separately
+// from owned ones.
+func (ac *azureClient) ListRetained(ctx context.Context, resourceGroupName string) ([]resources.GenericResourceExpanded, error) {
+	ctx, span := tele.Tracer().Start(ctx, "orphanedresources.AzureClient.ListRetained")
+	defer span.End()
+
+	return ac.list(ctx, resourceGroupName, fmt.Sprintf("tagName eq '%s'", infrav1.NameAzureProviderRetained), "")
+}
+
+// list lists the resources of a resource group matching a filter.
+func (ac *azureClient) list(ctx context.Context, resourceGroupName, filter, expand string) ([]resources.GenericResourceExpanded, error) {
+	iter, err := ac.resources.ListByResourceGroupComplete(ctx, resourceGroupName, filter, expand, nil)
+	if err != nil {
+		return nil, err
+	}
+	var listed []resources.GenericResourceExpanded
+	for ; iter.NotDone(); err = iter.NextWithContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, iter.Value())
+	}
+	return listed, nil
+}
+
+// DeleteByID deletes a resource by its ID, with the API version of its resource type, and waits for the deletion to
+// complete.
+func (ac *azureClient) DeleteByID(ctx context.Context, resourceID, apiVersion string) error {
+	ctx, span := tele.Tracer().Start(ctx, "orphanedresources.AzureClient.DeleteByID")
+	defer span.End()
+
+	future, err := ac.resources.DeleteByID(ctx, resourceID, apiVersion)
+	if err != nil {
+		return err
+	}
+	if err := azure.WaitForCompletion(ctx, &future, ac.resources.Client); err != nil {
+		return err
+	}
+	_, err = future.Result(ac.resources)
+	return err
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanedresources
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// OrphanedResourcesScope defines the scope interface for an orphaned resources service.
+type OrphanedResourcesScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	OrphanedResourcesSpec() azure.OrphanedResourcesSpec
+}
+
+// purgedType is a resource type purged when orphaned, with the API version to delete its resources with.
+type purgedType struct {
+	name       string
+	apiVersion string
+}
+
+// purgedTypes are the resource types purged when orphaned, in the order they are deleted so resources are deleted
+// before the resources they use, e.g. virtual machines before their network interfaces. Other resource types are never
+// purged, even if they are owned by the cluster.
+var purgedTypes = []purgedType{
+	{name: "Microsoft.Compute/virtualMachines", apiVersion: "2020-06-30"},
+	{name: "Microsoft.Network/bastionHosts", apiVersion: "2019-06-01"},
+	{name: "Microsoft.Network/networkInterfaces", apiVersion: "2019-06-01"},
+	{name: "Microsoft.Network/loadBalancers", apiVersion: "2019-06-01"},
+	{name: "Microsoft.Network/publicIPAddresses", apiVersion: "2019-06-01"},
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope OrphanedResourcesScope
+	client
+	now func() time.Time
+}
+
+// New creates a new service.
+func New(scope OrphanedResourcesScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: newClient(scope),
+		now:    time.Now,
+	}
+}
+
+// Reconcile deletes the resources of the resource group of the cluster which are tagged as owned by the cluster but
+// which no spec of the cluster or its machines refers to anymore, e.g. because the spec referring to them was renamed
+// or deleted without the resources being deleted. Resources tagged as retained by the deletion policy of their
+// AzureMachine are never deleted.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "orphanedresources.Service.Reconcile")
+	defer span.End()
+
+	spec := s.Scope.OrphanedResourcesSpec()
+	expected := map[string]bool{}
+	for resourceType, names := range spec.Expected {
+		for _, name := range names {
+			expected[resourceKey(resourceType, name)] = true
+		}
+	}
+
+	owned, err := s.client.ListOwned(ctx, s.Scope.ResourceGroup(), s.Scope.ClusterName())
+	if err != nil {
+		return errors.Wrapf(err, "failed to list resources owned by the cluster in resource group %s", s.Scope.ResourceGroup())
+	}
+	retainedResources, err := s.client.ListRetained(ctx, s.Scope.ResourceGroup())
+	if err != nil {
+		return errors.Wrapf(err, "failed to list retained resources in resource group %s", s.Scope.ResourceGroup())
+	}
+	retained := map[string]bool{}
+	for _, resource := range retainedResources {
+		retained[strings.ToLower(to.String(resource.ID))] = true
+	}
+
+	var errs []error
+	for _, purged := range purgedTypes {
+		for _, resource := range owned {
+			if !strings.EqualFold(to.String(resource.Type), purged.name) || expected[resourceKey(purged.name, to.String(resource.Name))] {
+				continue
+			}
+			if retained[strings.ToLower(to.String(resource.ID))] {
+				s.Scope.V(2).Info("keeping orphaned resource retained by the deletion policy of its machine", "type", purged.name, "name", to.String(resource.Name))
+				continue
+			}
+			if !s.oldEnough(resource, spec.MinAge) {
+				s.Scope.V(2).Info("keeping orphaned resource until it is old enough", "type", purged.name, "name", to.String(resource.Name))
+				continue
+			}
+
+			s.Scope.Info("deleting orphaned resource", "type", purged.name, "name", to.String(resource.Name))
+			if err := s.client.DeleteByID(ctx, to.String(resource.ID), purged.apiVersion); err != nil && !azure.ResourceNotFound(err) {
+				errs = append(errs, errors.Wrapf(err, "failed to delete orphaned resource %s", to.String(resource.ID)))
+			}
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// Delete is a no-op, as the resources of a deleted cluster are deleted by their own services.
+func (s *Service) Delete(ctx context.Context) error {
+	return nil
+}
+
+// oldEnough returns true if a resource is older than the minimum age of orphaned resources. Resources without a
+// creation time are kept.
+func (s *Service) oldEnough(resource resources.GenericResourceExpanded, minAge time.Duration) bool {
+	return resource.CreatedTime != nil && s.now().Sub(resource.CreatedTime.Time) >= minAge
+}
+
+// resourceKey identifies a resource of a resource group by its type and name, which Azure compares case-insensitively.
+func resourceKey(resourceType, name string) string {
+	return strings.ToLower(resourceType + "/" + name)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanedresources
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/date"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/orphanedresources/mock_orphanedresources"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var (
+	now = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	fakeSpec = azure.OrphanedResourcesSpec{
+		Expected: map[string][]string{
+			"Microsoft.Compute/virtualMachines":   {"my-vm"},
+			"Microsoft.Network/networkInterfaces": {"my-vm-nic"},
+			"Microsoft.Network/publicIPAddresses": {"my-cluster-apiserver-ip"},
+			"Microsoft.Network/loadBalancers":     {"my-cluster-public-lb"},
+		},
+		MinAge: time.Hour,
+	}
+
+	internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+	notFoundError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found")
+)
+
+// fakeResource returns a resource of the resource group my-rg created the given duration ago.
+func fakeResource(resourceType, name string, age time.Duration) resources.GenericResourceExpanded {
+	return resources.GenericResourceExpanded{
+		ID:          to.StringPtr("/subscriptions/123/resourceGroups/my-rg/providers/" + resourceType + "/" + name),
+		Name:        to.StringPtr(name),
+		Type:        to.StringPtr(resourceType),
+		CreatedTime: &date.Time{Time: now.Add(-age)},
+	}
+}
+
+func TestReconcileOrphanedResources(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder)
+	}{
+		{
+			name:          "no orphaned resources",
+			expectedError: "",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Compute/virtualMachines", "my-vm", 2*time.Hour),
+					// Azure doesn't preserve the case of resource types.
+					fakeResource("Microsoft.Network/networkinterfaces", "MY-VM-NIC", 2*time.Hour),
+					fakeResource("Microsoft.Network/publicIPAddresses", "my-cluster-apiserver-ip", 2*time.Hour),
+				}, nil)
+				m.ListRetained(gomockinternal.AContext(), "my-rg")
+			},
+		},
+		{
+			name:          "orphaned resources are deleted, using resources before the resources they use",
+			expectedError: "",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Network/publicIPAddresses", "my-old-vm-ip", 2*time.Hour),
+					fakeResource("Microsoft.Network/networkInterfaces", "my-old-vm-nic", 2*time.Hour),
+					fakeResource("Microsoft.Compute/virtualMachines", "my-old-vm", 2*time.Hour),
+					fakeResource("Microsoft.Compute/virtualMachines", "my-vm", 2*time.Hour),
+				}, nil)
+				m.ListRetained(gomockinternal.AContext(), "my-rg")
+				gomock.InOrder(
+					m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-old-vm", "2020-06-30"),
+					m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/my-old-vm-nic", "2019-06-01"),
+					m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-old-vm-ip", "2019-06-01"),
+				)
+			},
+		},
+		{
+			name:          "recent resources and resources of other types are kept",
+			expectedError: "",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				resourceWithoutCreatedTime := fakeResource("Microsoft.Network/loadBalancers", "my-old-lb", 0)
+				resourceWithoutCreatedTime.CreatedTime = nil
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Compute/virtualMachines", "my-new-vm", 10*time.Minute),
+					resourceWithoutCreatedTime,
+					fakeResource("Microsoft.Network/virtualNetworks", "my-old-vnet", 2*time.Hour),
+					fakeResource("Microsoft.Compute/availabilitySets", "my-old-as", 2*time.Hour),
+				}, nil)
+				m.ListRetained(gomockinternal.AContext(), "my-rg")
+			},
+		},
+		{
+			name:          "retained resources are kept",
+			expectedError: "",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Compute/virtualMachines", "my-retained-vm", 2*time.Hour),
+					fakeResource("Microsoft.Network/networkInterfaces", "my-retained-vm-nic", 2*time.Hour),
+					fakeResource("Microsoft.Network/publicIPAddresses", "my-old-vm-ip", 2*time.Hour),
+				}, nil)
+				// Azure doesn't preserve the case of resource IDs.
+				retainedNIC := fakeResource("Microsoft.Network/networkInterfaces", "my-retained-vm-nic", 2*time.Hour)
+				retainedNIC.ID = to.StringPtr("/subscriptions/123/resourcegroups/my-rg/providers/Microsoft.Network/networkInterfaces/my-retained-vm-nic")
+				m.ListRetained(gomockinternal.AContext(), "my-rg").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Compute/virtualMachines", "my-retained-vm", 2*time.Hour),
+					retainedNIC,
+				}, nil)
+				m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-old-vm-ip", "2019-06-01")
+			},
+		},
+		{
+			name:          "fail to list retained resources",
+			expectedError: "failed to list retained resources in resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Compute/virtualMachines", "my-old-vm", 2*time.Hour),
+				}, nil)
+				m.ListRetained(gomockinternal.AContext(), "my-rg").Return(nil, internalError)
+			},
+		},
+		{
+			name:          "fail to list owned resources",
+			expectedError: "failed to list resources owned by the cluster in resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return(nil, internalError)
+			},
+		},
+		{
+			name:          "failing deletions don't prevent other deletions",
+			expectedError: "failed to delete orphaned resource /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-old-vm: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_orphanedresources.MockOrphanedResourcesScopeMockRecorder, m *mock_orphanedresources.MockclientMockRecorder) {
+				s.OrphanedResourcesSpec().Return(fakeSpec)
+				m.ListOwned(gomockinternal.AContext(), "my-rg", "my-cluster").Return([]resources.GenericResourceExpanded{
+					fakeResource("Microsoft.Compute/virtualMachines", "my-old-vm", 2*time.Hour),
+					fakeResource("Microsoft.Network/publicIPAddresses", "my-old-vm-ip", 2*time.Hour),
+					fakeResource("Microsoft.Network/loadBalancers", "my-old-lb", 2*time.Hour),
+				}, nil)
+				m.ListRetained(gomockinternal.AContext(), "my-rg")
+				m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-old-vm", "2020-06-30").Return(internalError)
+				m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/loadBalancers/my-old-lb", "2019-06-01").Return(notFoundError)
+				m.DeleteByID(gomockinternal.AContext(), "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-old-vm-ip", "2019-06-01")
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_orphanedresources.NewMockOrphanedResourcesScope(mockCtrl)
+			clientMock := mock_orphanedresources.NewMockclient(mockCtrl)
+
+			scopeMock.EXPECT().ResourceGroup().AnyTimes().Return("my-rg")
+			scopeMock.EXPECT().ClusterName().AnyTimes().Return("my-cluster")
+			scopeMock.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+			scopeMock.EXPECT().V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+				now:    func() time.Time { return now },
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -45,11 +45,15 @@ type azureClient struct {
 
 var _ client = (*azureClient)(nil)
 
-// newClient creates a new VM client from subscription ID.
-func newClient(auth azure.Authorizer) *azureClient {
-	c := newPrivateZonesClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
-	v := newVirtualNetworkLinksClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
-	r := newRecordSetsClient(auth.SubscriptionID(), auth.BaseURI(), auth.Authorizer())
+// newClient creates a new private DNS client for the zones of a subscription, or of the subscription of auth if
+// empty.
+func newClient(auth azure.Authorizer, subscriptionID string) *azureClient {
+	if subscriptionID == "" {
+		subscriptionID = auth.SubscriptionID()
+	}
+	c := newPrivateZonesClient(subscriptionID, auth.BaseURI(), auth.Authorizer())
+	v := newVirtualNetworkLinksClient(subscriptionID, auth.BaseURI(), auth.Authorizer())
+	r := newRecordSetsClient(subscriptionID, auth.BaseURI(), auth.Authorizer())
 	return &azureClient{c, v, r}
 }
 
@@ -83,7 +87,7 @@ func (ac *azureClient) CreateOrUpdateZone(ctx context.Context, resourceGroupName
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.privatezones.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.privatezones.Client)
 	if err != nil {
 		return err
 	}
@@ -100,7 +104,7 @@ func (ac *azureClient) DeleteZone(ctx context.Context, resourceGroupName, name s
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.privatezones.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.privatezones.Client)
 	if err != nil {
 		return err
 	}
@@ -117,7 +121,7 @@ func (ac *azureClient) CreateOrUpdateLink(ctx context.Context, resourceGroupName
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.vnetlinks.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.vnetlinks.Client)
 	if err != nil {
 		return err
 	}
@@ -134,7 +138,7 @@ func (ac *azureClient) DeleteLink(ctx context.Context, resourceGroupName, privat
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.vnetlinks.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.vnetlinks.Client)
 	if err != nil {
 		return err
 	}
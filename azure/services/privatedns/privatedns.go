@@ -24,6 +24,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
@@ -34,6 +35,7 @@ type Scope interface {
 	logr.Logger
 	azure.ClusterDescriber
 	PrivateDNSSpec() *azure.PrivateDNSSpec
+	ServiceAuthorizer(service infrav1.AzureService) azure.Authorizer
 }
 
 // Service provides operations on Azure resources.
@@ -42,11 +44,16 @@ type Service struct {
 	client
 }
 
-// New creates a new private dns service.
+// New creates a new private dns service. Zones in another subscription than the one of the cluster are managed with a
+// client of their subscription.
 func New(scope Scope) *Service {
+	var subscriptionID string
+	if zoneSpec := scope.PrivateDNSSpec(); zoneSpec != nil {
+		subscriptionID = zoneSpec.SubscriptionID
+	}
 	return &Service{
 		Scope:  scope,
-		client: newClient(scope),
+		client: newClient(scope.ServiceAuthorizer(infrav1.PrivateDNSService), subscriptionID),
 	}
 }
 
@@ -57,13 +64,17 @@ func (s *Service) Reconcile(ctx context.Context) error {
 
 	zoneSpec := s.Scope.PrivateDNSSpec()
 	if zoneSpec != nil {
-		// Create the private DNS zone.
-		s.Scope.V(2).Info("creating private DNS zone", "private dns zone", zoneSpec.ZoneName)
-		err := s.client.CreateOrUpdateZone(ctx, s.Scope.ResourceGroup(), zoneSpec.ZoneName, privatedns.PrivateZone{Location: to.StringPtr(azure.Global)})
-		if err != nil {
-			return errors.Wrapf(err, "failed to create private DNS zone %s", zoneSpec.ZoneName)
+		resourceGroup := zoneSpec.ResourceGroup
+		if resourceGroup == "" {
+			// Create the private DNS zone.
+			resourceGroup = s.Scope.ResourceGroup()
+			s.Scope.V(2).Info("creating private DNS zone", "private dns zone", zoneSpec.ZoneName)
+			err := s.client.CreateOrUpdateZone(ctx, resourceGroup, zoneSpec.ZoneName, privatedns.PrivateZone{Location: to.StringPtr(azure.Global)})
+			if err != nil {
+				return errors.Wrapf(err, "failed to create private DNS zone %s", zoneSpec.ZoneName)
+			}
+			s.Scope.V(2).Info("successfully created private DNS zone", "private dns zone", zoneSpec.ZoneName)
 		}
-		s.Scope.V(2).Info("successfully created private DNS zone", "private dns zone", zoneSpec.ZoneName)
 
 		// Link the virtual network.
 		s.Scope.V(2).Info("creating a virtual network link", "virtual network", zoneSpec.VNetName, "private dns zone", zoneSpec.ZoneName)
@@ -76,7 +87,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			},
 			Location: to.StringPtr(azure.Global),
 		}
-		err = s.client.CreateOrUpdateLink(ctx, s.Scope.ResourceGroup(), zoneSpec.ZoneName, zoneSpec.LinkName, link)
+		err := s.client.CreateOrUpdateLink(ctx, resourceGroup, zoneSpec.ZoneName, zoneSpec.LinkName, link)
 		if err != nil {
 			return errors.Wrapf(err, "failed to create virtual network link %s", zoneSpec.LinkName)
 		}
@@ -100,7 +111,7 @@ func (s *Service) Reconcile(ctx context.Context) error {
 					Ipv6Address: &record.IP,
 				}}
 			}
-			err := s.client.CreateOrUpdateRecordSet(ctx, s.Scope.ResourceGroup(), zoneSpec.ZoneName, recordType, record.Hostname, set)
+			err := s.client.CreateOrUpdateRecordSet(ctx, resourceGroup, zoneSpec.ZoneName, recordType, record.Hostname, set)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create record %s in private DNS zone %s", record.Hostname, zoneSpec.ZoneName)
 			}
@@ -110,29 +121,46 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return nil
 }
 
-// Delete deletes the private zone.
+// Delete deletes the private zone. Of existing zones, only the link to the virtual network and the records of the
+// cluster are deleted.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, span := tele.Tracer().Start(ctx, "privatedns.Service.Delete")
 	defer span.End()
 
 	zoneSpec := s.Scope.PrivateDNSSpec()
 	if zoneSpec != nil {
+		resourceGroup := zoneSpec.ResourceGroup
+		if resourceGroup == "" {
+			resourceGroup = s.Scope.ResourceGroup()
+		}
+
 		// Remove the virtual network link.
 		s.Scope.V(2).Info("removing virtual network link", "virtual network", zoneSpec.VNetName, "private dns zone", zoneSpec.ZoneName)
-		err := s.client.DeleteLink(ctx, s.Scope.ResourceGroup(), zoneSpec.ZoneName, zoneSpec.LinkName)
+		err := s.client.DeleteLink(ctx, resourceGroup, zoneSpec.ZoneName, zoneSpec.LinkName)
 		if err != nil && !azure.ResourceNotFound(err) {
-			return errors.Wrapf(err, "failed to delete virtual network link %s with zone %s in resource group %s", zoneSpec.VNetName, zoneSpec.ZoneName, s.Scope.ResourceGroup())
+			return errors.Wrapf(err, "failed to delete virtual network link %s with zone %s in resource group %s", zoneSpec.VNetName, zoneSpec.ZoneName, resourceGroup)
+		}
+
+		if zoneSpec.ResourceGroup != "" {
+			for _, record := range zoneSpec.Records {
+				s.Scope.V(2).Info("deleting record set", "private dns zone", zoneSpec.ZoneName, "record", record.Hostname)
+				err := s.client.DeleteRecordSet(ctx, resourceGroup, zoneSpec.ZoneName, converters.GetRecordType(record.IP), record.Hostname)
+				if err != nil && !azure.ResourceNotFound(err) {
+					return errors.Wrapf(err, "failed to delete record %s in private DNS zone %s", record.Hostname, zoneSpec.ZoneName)
+				}
+			}
+			return nil
 		}
 
 		// Delete the private DNS zone, which also deletes all records.
 		s.Scope.V(2).Info("deleting private dns zone", "private dns zone", zoneSpec.ZoneName)
-		err = s.client.DeleteZone(ctx, s.Scope.ResourceGroup(), zoneSpec.ZoneName)
+		err = s.client.DeleteZone(ctx, resourceGroup, zoneSpec.ZoneName)
 		if err != nil && azure.ResourceNotFound(err) {
 			// already deleted
 			return nil
 		}
 		if err != nil && !azure.ResourceNotFound(err) {
-			return errors.Wrapf(err, "failed to delete private dns zone %s in resource group %s", zoneSpec.ZoneName, s.Scope.ResourceGroup())
+			return errors.Wrapf(err, "failed to delete private dns zone %s in resource group %s", zoneSpec.ZoneName, resourceGroup)
 		}
 		s.Scope.V(2).Info("successfully deleted private dns zone", "private dns zone", zoneSpec.ZoneName)
 	}
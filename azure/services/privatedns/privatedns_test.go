@@ -89,6 +89,48 @@ func TestReconcilePrivateDNS(t *testing.T) {
 				})
 			},
 		},
+		{
+			name:          "link an existing zone of another subscription",
+			expectedError: "",
+			expect: func(s *mock_privatedns.MockScopeMockRecorder, m *mock_privatedns.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.PrivateDNSSpec().Return(&azure.PrivateDNSSpec{
+					ZoneName:          "my-dns-zone",
+					VNetName:          "my-vnet",
+					VNetResourceGroup: "vnet-rg",
+					LinkName:          "my-link",
+					Records: []infrav1.AddressRecord{
+						{
+							Hostname: "hostname-1",
+							IP:       "10.0.0.8",
+						},
+					},
+					ResourceGroup:  "hub-rg",
+					SubscriptionID: "456",
+				})
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.SubscriptionID().Return("123")
+				m.CreateOrUpdateLink(gomockinternal.AContext(), "hub-rg", "my-dns-zone", "my-link", privatedns.VirtualNetworkLink{
+					VirtualNetworkLinkProperties: &privatedns.VirtualNetworkLinkProperties{
+						VirtualNetwork: &privatedns.SubResource{
+							ID: to.StringPtr("/subscriptions/123/resourceGroups/vnet-rg/providers/Microsoft.Network/virtualNetworks/my-vnet"),
+						},
+						RegistrationEnabled: to.BoolPtr(false),
+					},
+					Location: to.StringPtr(azure.Global),
+				})
+				m.CreateOrUpdateRecordSet(gomockinternal.AContext(), "hub-rg", "my-dns-zone", privatedns.A, "hostname-1", privatedns.RecordSet{
+					RecordSetProperties: &privatedns.RecordSetProperties{
+						TTL: to.Int64Ptr(300),
+						ARecords: &[]privatedns.ARecord{
+							{
+								Ipv4Address: to.StringPtr("10.0.0.8"),
+							},
+						},
+					},
+				})
+			},
+		},
 		{
 			name:          "create ipv6 private dns successfully",
 			expectedError: "",
@@ -228,6 +270,30 @@ func TestDeletePrivateDNS(t *testing.T) {
 				m.DeleteZone(gomockinternal.AContext(), "my-rg", "my-dns-zone")
 			},
 		},
+		{
+			name:          "keep an existing zone",
+			expectedError: "",
+			expect: func(s *mock_privatedns.MockScopeMockRecorder, m *mock_privatedns.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.PrivateDNSSpec().Return(&azure.PrivateDNSSpec{
+					ZoneName:          "my-dns-zone",
+					VNetName:          "my-vnet",
+					VNetResourceGroup: "vnet-rg",
+					LinkName:          "my-link",
+					Records: []infrav1.AddressRecord{
+						{
+							Hostname: "hostname-1",
+							IP:       "10.0.0.8",
+						},
+					},
+					ResourceGroup:  "hub-rg",
+					SubscriptionID: "456",
+				})
+				m.DeleteLink(gomockinternal.AContext(), "hub-rg", "my-dns-zone", "my-link")
+				m.DeleteRecordSet(gomockinternal.AContext(), "hub-rg", "my-dns-zone", privatedns.A, "hostname-1").
+					Return(autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+			},
+		},
 		{
 			name:          "link already deleted",
 			expectedError: "",
@@ -70,7 +70,7 @@ func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.publicips.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.publicips.Client)
 	if err != nil {
 		return err
 	}
@@ -87,7 +87,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, ipName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.publicips.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.publicips.Client)
 	if err != nil {
 		return err
 	}
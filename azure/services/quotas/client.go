@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quotas
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	computeProvider = "Microsoft.Compute"
+	networkProvider = "Microsoft.Network"
+)
+
+// client wraps go-sdk.
+type client interface {
+	ListComputeUsages(context.Context, string) ([]azure.QuotaUsage, error)
+	ListNetworkUsages(context.Context, string) ([]azure.QuotaUsage, error)
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	compute compute.UsageClient
+	network network.UsagesClient
+}
+
+var _ client = (*azureClient)(nil)
+
+// newClient creates a new usages client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	computeClient := compute.NewUsageClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&computeClient.Client, auth.Authorizer())
+	networkClient := network.NewUsagesClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&networkClient.Client, auth.Authorizer())
+	return &azureClient{compute: computeClient, network: networkClient}
+}
+
+// ListComputeUsages returns the usages of the compute quotas of the subscription in a location, e.g. of the cores of
+// each VM family.
+func (ac *azureClient) ListComputeUsages(ctx context.Context, location string) ([]azure.QuotaUsage, error) {
+	ctx, span := tele.Tracer().Start(ctx, "quotas.AzureClient.ListComputeUsages")
+	defer span.End()
+
+	iter, err := ac.compute.ListComplete(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	var usages []azure.QuotaUsage
+	for ; iter.NotDone(); err = iter.NextWithContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		usage := iter.Value()
+		if usage.Name == nil {
+			continue
+		}
+		usages = append(usages, azure.QuotaUsage{
+			Provider: computeProvider,
+			Name:     to.String(usage.Name.Value),
+			Current:  int64(to.Int32(usage.CurrentValue)),
+			Limit:    to.Int64(usage.Limit),
+		})
+	}
+	return usages, nil
+}
+
+// ListNetworkUsages returns the usages of the network quotas of the subscription in a location, e.g. of public IP
+// addresses.
+func (ac *azureClient) ListNetworkUsages(ctx context.Context, location string) ([]azure.QuotaUsage, error) {
+	ctx, span := tele.Tracer().Start(ctx, "quotas.AzureClient.ListNetworkUsages")
+	defer span.End()
+
+	iter, err := ac.network.ListComplete(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	var usages []azure.QuotaUsage
+	for ; iter.NotDone(); err = iter.NextWithContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		usage := iter.Value()
+		if usage.Name == nil {
+			continue
+		}
+		usages = append(usages, azure.QuotaUsage{
+			Provider: networkProvider,
+			Name:     to.String(usage.Name.Value),
+			Current:  to.Int64(usage.CurrentValue),
+			Limit:    to.Int64(usage.Limit),
+		})
+	}
+	return usages, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quotas
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// coresQuota is the compute quota of the cores of all VM families of a location.
+	coresQuota = "cores"
+	// virtualMachinesQuota is the compute quota of the VMs of a location.
+	virtualMachinesQuota = "virtualMachines"
+	// publicIPAddressesQuota is the network quota of the public IP addresses of a location.
+	publicIPAddressesQuota = "PublicIPAddresses"
+)
+
+// QuotaScope defines the scope interface for the quotas service.
+type QuotaScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	QuotaDemand() azure.QuotaDemand
+	SetQuotaUsages([]azure.QuotaUsage)
+}
+
+// Service provides operations on the quotas of the subscription of a cluster.
+type Service struct {
+	Scope QuotaScope
+	client
+	resourceSKUCache *resourceskus.Cache
+}
+
+// New creates a new quotas service.
+func New(scope QuotaScope, skuCache *resourceskus.Cache) *Service {
+	return &Service{
+		Scope:            scope,
+		client:           newClient(scope),
+		resourceSKUCache: skuCache,
+	}
+}
+
+// Reconcile lists the usages of the compute and network quotas of the subscription of the cluster in its location,
+// along with how much more of them the machines and machine pools of the cluster need to scale up.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "quotas.Service.Reconcile")
+	defer span.End()
+
+	computeUsages, err := s.client.ListComputeUsages(ctx, s.Scope.Location())
+	if err != nil {
+		return errors.Wrapf(err, "failed to list compute usages in location %s", s.Scope.Location())
+	}
+	networkUsages, err := s.client.ListNetworkUsages(ctx, s.Scope.Location())
+	if err != nil {
+		return errors.Wrapf(err, "failed to list network usages in location %s", s.Scope.Location())
+	}
+
+	pending, err := s.pendingUsages(ctx, s.Scope.QuotaDemand())
+	if err != nil {
+		return err
+	}
+	usages := append(computeUsages, networkUsages...)
+	for i := range usages {
+		usages[i].Pending = pending[quotaKey(usages[i].Provider, usages[i].Name)]
+	}
+	s.Scope.SetQuotaUsages(usages)
+	return nil
+}
+
+// Delete is a no-op, as quotas aren't owned by clusters.
+func (s *Service) Delete(ctx context.Context) error {
+	return nil
+}
+
+// pendingUsages returns how much more of each quota a demand needs: the cores of the families of the VM sizes and of
+// the location, the VMs and the public IP addresses.
+func (s *Service) pendingUsages(ctx context.Context, demand azure.QuotaDemand) (map[string]int64, error) {
+	pending := map[string]int64{}
+	sizes := make([]string, 0, len(demand.VMs))
+	for size := range demand.VMs {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+	for _, size := range sizes {
+		count := demand.VMs[size]
+		sku, err := s.resourceSKUCache.Get(ctx, size, resourceskus.VirtualMachines)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the resource SKU of VM size %s", size)
+		}
+		vCPUs := int64(0)
+		if value, ok := sku.GetCapability(resourceskus.VCPUs); ok {
+			vCPUs, _ = strconv.ParseInt(value, 10, 64)
+		}
+		if family := to.String(sku.Family); family != "" {
+			pending[quotaKey(computeProvider, family)] += count * vCPUs
+		}
+		pending[quotaKey(computeProvider, coresQuota)] += count * vCPUs
+		pending[quotaKey(computeProvider, virtualMachinesQuota)] += count
+	}
+	pending[quotaKey(networkProvider, publicIPAddressesQuota)] += demand.PublicIPs
+	return pending, nil
+}
+
+// quotaKey identifies a quota by its provider and name, which Azure compares case-insensitively.
+func quotaKey(provider, name string) string {
+	return strings.ToLower(provider + "/" + name)
+}
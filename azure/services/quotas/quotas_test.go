@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quotas
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/quotas/mock_quotas"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+
+func TestReconcileQuotas(t *testing.T) {
+	skus := []compute.ResourceSku{
+		{
+			Name:         to.StringPtr("Standard_D4s_v3"),
+			ResourceType: to.StringPtr(string(resourceskus.VirtualMachines)),
+			Family:       to.StringPtr("standardDSv3Family"),
+			Capabilities: &[]compute.ResourceSkuCapabilities{{Name: to.StringPtr(resourceskus.VCPUs), Value: to.StringPtr("4")}},
+		},
+	}
+
+	testcases := []struct {
+		name          string
+		demand        azure.QuotaDemand
+		expectedError string
+		expect        func(s *mock_quotas.MockQuotaScopeMockRecorder, m *mock_quotas.MockclientMockRecorder)
+	}{
+		{
+			name:          "usages without demand",
+			expectedError: "",
+			expect: func(s *mock_quotas.MockQuotaScopeMockRecorder, m *mock_quotas.MockclientMockRecorder) {
+				m.ListComputeUsages(gomockinternal.AContext(), "westeurope").Return([]azure.QuotaUsage{
+					{Provider: "Microsoft.Compute", Name: "cores", Current: 8, Limit: 100},
+				}, nil)
+				m.ListNetworkUsages(gomockinternal.AContext(), "westeurope").Return([]azure.QuotaUsage{
+					{Provider: "Microsoft.Network", Name: "PublicIPAddresses", Current: 2, Limit: 10},
+				}, nil)
+				s.SetQuotaUsages([]azure.QuotaUsage{
+					{Provider: "Microsoft.Compute", Name: "cores", Current: 8, Limit: 100},
+					{Provider: "Microsoft.Network", Name: "PublicIPAddresses", Current: 2, Limit: 10},
+				})
+			},
+		},
+		{
+			name:          "usages with the demand of pending VMs and public IPs",
+			demand:        azure.QuotaDemand{VMs: map[string]int64{"Standard_D4s_v3": 3}, PublicIPs: 1},
+			expectedError: "",
+			expect: func(s *mock_quotas.MockQuotaScopeMockRecorder, m *mock_quotas.MockclientMockRecorder) {
+				m.ListComputeUsages(gomockinternal.AContext(), "westeurope").Return([]azure.QuotaUsage{
+					{Provider: "Microsoft.Compute", Name: "cores", Current: 8, Limit: 100},
+					{Provider: "Microsoft.Compute", Name: "virtualMachines", Current: 2, Limit: 25000},
+					{Provider: "Microsoft.Compute", Name: "standardDSv3Family", Current: 8, Limit: 10},
+					{Provider: "Microsoft.Compute", Name: "standardNCFamily", Current: 0, Limit: 0},
+				}, nil)
+				m.ListNetworkUsages(gomockinternal.AContext(), "westeurope").Return([]azure.QuotaUsage{
+					{Provider: "Microsoft.Network", Name: "PublicIPAddresses", Current: 2, Limit: 10},
+				}, nil)
+				s.SetQuotaUsages([]azure.QuotaUsage{
+					{Provider: "Microsoft.Compute", Name: "cores", Current: 8, Limit: 100, Pending: 12},
+					{Provider: "Microsoft.Compute", Name: "virtualMachines", Current: 2, Limit: 25000, Pending: 3},
+					{Provider: "Microsoft.Compute", Name: "standardDSv3Family", Current: 8, Limit: 10, Pending: 12},
+					{Provider: "Microsoft.Compute", Name: "standardNCFamily", Current: 0, Limit: 0},
+					{Provider: "Microsoft.Network", Name: "PublicIPAddresses", Current: 2, Limit: 10, Pending: 1},
+				})
+			},
+		},
+		{
+			name:          "fail to list compute usages",
+			expectedError: "failed to list compute usages in location westeurope: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_quotas.MockQuotaScopeMockRecorder, m *mock_quotas.MockclientMockRecorder) {
+				m.ListComputeUsages(gomockinternal.AContext(), "westeurope").Return(nil, internalError)
+			},
+		},
+		{
+			name:          "fail to get the SKU of a pending VM size",
+			demand:        azure.QuotaDemand{VMs: map[string]int64{"Standard_Unknown": 1}},
+			expectedError: "failed to get the resource SKU of VM size Standard_Unknown: resource sku with name 'Standard_Unknown' and category 'virtualMachines' not found in location 'westeurope'",
+			expect: func(s *mock_quotas.MockQuotaScopeMockRecorder, m *mock_quotas.MockclientMockRecorder) {
+				m.ListComputeUsages(gomockinternal.AContext(), "westeurope").Return(nil, nil)
+				m.ListNetworkUsages(gomockinternal.AContext(), "westeurope").Return(nil, nil)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_quotas.NewMockQuotaScope(mockCtrl)
+			clientMock := mock_quotas.NewMockclient(mockCtrl)
+
+			scopeMock.EXPECT().Location().AnyTimes().Return("westeurope")
+			scopeMock.EXPECT().QuotaDemand().AnyTimes().Return(tc.demand)
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:            scopeMock,
+				client:           clientMock,
+				resourceSKUCache: resourceskus.NewStaticCache(skus, "westeurope"),
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcehealth
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcehealth/mgmt/2017-07-01/resourcehealth"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// client wraps go-sdk.
+type client interface {
+	ListAvailabilityStatuses(context.Context, string) ([]resourcehealth.AvailabilityStatus, error)
+}
+
+// azureClient contains the Azure go-sdk Client.
+type azureClient struct {
+	availabilityStatuses resourcehealth.AvailabilityStatusesClient
+}
+
+var _ client = (*azureClient)(nil)
+
+// newClient creates a new availability statuses client from subscription ID.
+func newClient(auth azure.Authorizer) *azureClient {
+	c := resourcehealth.NewAvailabilityStatusesClientWithBaseURI(auth.BaseURI(), auth.SubscriptionID())
+	azure.SetAutoRestClientDefaults(&c.Client, auth.Authorizer())
+	return &azureClient{c}
+}
+
+// ListAvailabilityStatuses lists the current availability statuses of the resources of a resource group.
+func (ac *azureClient) ListAvailabilityStatuses(ctx context.Context, resourceGroupName string) ([]resourcehealth.AvailabilityStatus, error) {
+	ctx, span := tele.Tracer().Start(ctx, "resourcehealth.AzureClient.ListAvailabilityStatuses")
+	defer span.End()
+
+	iter, err := ac.availabilityStatuses.ListByResourceGroupComplete(ctx, resourceGroupName, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var statuses []resourcehealth.AvailabilityStatus
+	for ; iter.NotDone(); err = iter.NextWithContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, iter.Value())
+	}
+	return statuses, nil
+}
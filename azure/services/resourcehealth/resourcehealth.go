@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcehealth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcehealth/mgmt/2017-07-01/resourcehealth"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// availabilityStatusSuffix ends the ID of the current availability status of a resource, which otherwise is the ID of
+// the resource.
+const availabilityStatusSuffix = "/providers/microsoft.resourcehealth/availabilitystatuses/current"
+
+// ResourceHealthScope defines the scope interface for a resource health service.
+type ResourceHealthScope interface {
+	logr.Logger
+	azure.ClusterDescriber
+	SetUnhealthyResources([]azure.UnhealthyResource)
+}
+
+// Service provides operations on Azure resources.
+type Service struct {
+	Scope ResourceHealthScope
+	client
+}
+
+// New creates a new service.
+func New(scope ResourceHealthScope) *Service {
+	return &Service{
+		Scope:  scope,
+		client: newClient(scope),
+	}
+}
+
+// Reconcile reports to the scope the resources of the resource group of the cluster which Resource Health reports as
+// unavailable. Resources whose availability is unknown are considered healthy, as Resource Health doesn't know the
+// state of resources which were just created or aren't monitored.
+func (s *Service) Reconcile(ctx context.Context) error {
+	ctx, span := tele.Tracer().Start(ctx, "resourcehealth.Service.Reconcile")
+	defer span.End()
+
+	statuses, err := s.client.ListAvailabilityStatuses(ctx, s.Scope.ResourceGroup())
+	if err != nil {
+		return errors.Wrapf(err, "failed to list availability statuses of resource group %s", s.Scope.ResourceGroup())
+	}
+
+	var unhealthy []azure.UnhealthyResource
+	for _, status := range statuses {
+		if status.Properties == nil || status.Properties.AvailabilityState != resourcehealth.Unavailable {
+			continue
+		}
+		resourceType, name, ok := parseResourceID(to.String(status.ID))
+		if !ok {
+			s.Scope.V(4).Info("ignoring availability status of an unexpected resource", "id", to.String(status.ID))
+			continue
+		}
+		unhealthy = append(unhealthy, azure.UnhealthyResource{
+			Type:    resourceType,
+			Name:    name,
+			State:   string(status.Properties.AvailabilityState),
+			Summary: to.String(status.Properties.Summary),
+		})
+	}
+	s.Scope.SetUnhealthyResources(unhealthy)
+	return nil
+}
+
+// Delete is a no-op, as Resource Health has nothing to clean up.
+func (s *Service) Delete(ctx context.Context) error {
+	return nil
+}
+
+// parseResourceID returns the type and name of the resource an availability status refers to, e.g.
+// "Microsoft.Compute/virtualMachines" and "my-vm".
+func parseResourceID(id string) (string, string, bool) {
+	lower := strings.ToLower(id)
+	if !strings.HasSuffix(lower, availabilityStatusSuffix) {
+		return "", "", false
+	}
+	id = id[:len(id)-len(availabilityStatusSuffix)]
+	i := strings.LastIndex(strings.ToLower(id), "/providers/")
+	if i < 0 {
+		return "", "", false
+	}
+	parts := strings.Split(id[i+len("/providers/"):], "/")
+	if len(parts) < 3 {
+		return "", "", false
+	}
+	return parts[0] + "/" + parts[1], parts[len(parts)-1], true
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcehealth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcehealth/mgmt/2017-07-01/resourcehealth"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"k8s.io/klog/v2/klogr"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcehealth/mock_resourcehealth"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+var internalError = autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusInternalServerError}, "Internal Server Error")
+
+// fakeStatus returns the current availability status of a resource of the resource group my-rg.
+func fakeStatus(resourceType, name string, state resourcehealth.AvailabilityStateValues, summary string) resourcehealth.AvailabilityStatus {
+	return resourcehealth.AvailabilityStatus{
+		ID: to.StringPtr("/subscriptions/123/resourceGroups/my-rg/providers/" + resourceType + "/" + name + "/providers/Microsoft.ResourceHealth/availabilityStatuses/current"),
+		Properties: &resourcehealth.AvailabilityStatusProperties{
+			AvailabilityState: state,
+			Summary:           to.StringPtr(summary),
+		},
+	}
+}
+
+func TestReconcileResourceHealth(t *testing.T) {
+	testcases := []struct {
+		name          string
+		expectedError string
+		expect        func(s *mock_resourcehealth.MockResourceHealthScopeMockRecorder, m *mock_resourcehealth.MockclientMockRecorder)
+	}{
+		{
+			name:          "all resources available",
+			expectedError: "",
+			expect: func(s *mock_resourcehealth.MockResourceHealthScopeMockRecorder, m *mock_resourcehealth.MockclientMockRecorder) {
+				m.ListAvailabilityStatuses(gomockinternal.AContext(), "my-rg").Return([]resourcehealth.AvailabilityStatus{
+					fakeStatus("Microsoft.Compute/virtualMachines", "my-vm", resourcehealth.Available, "There aren't any known Azure platform problems affecting this virtual machine."),
+					// Resource Health doesn't know the state of resources which were just created.
+					fakeStatus("Microsoft.Network/loadBalancers", "my-lb", resourcehealth.Unknown, ""),
+				}, nil)
+				s.SetUnhealthyResources(nil)
+			},
+		},
+		{
+			name:          "unavailable resources are reported",
+			expectedError: "",
+			expect: func(s *mock_resourcehealth.MockResourceHealthScopeMockRecorder, m *mock_resourcehealth.MockclientMockRecorder) {
+				m.ListAvailabilityStatuses(gomockinternal.AContext(), "my-rg").Return([]resourcehealth.AvailabilityStatus{
+					fakeStatus("Microsoft.Compute/virtualMachines", "my-vm", resourcehealth.Unavailable, "The VM is being auto-recovered."),
+					fakeStatus("Microsoft.Network/loadBalancers", "my-lb", resourcehealth.Unavailable, "All health probes are down."),
+					fakeStatus("Microsoft.Network/publicIPAddresses", "my-ip", resourcehealth.Available, ""),
+					// Availability statuses without properties or with an unexpected ID are ignored.
+					{ID: to.StringPtr("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkInterfaces/my-nic/providers/Microsoft.ResourceHealth/availabilityStatuses/current")},
+					{ID: to.StringPtr("/subscriptions/123/resourceGroups/my-rg"), Properties: &resourcehealth.AvailabilityStatusProperties{AvailabilityState: resourcehealth.Unavailable}},
+				}, nil)
+				s.SetUnhealthyResources([]azure.UnhealthyResource{
+					{Type: "Microsoft.Compute/virtualMachines", Name: "my-vm", State: "Unavailable", Summary: "The VM is being auto-recovered."},
+					{Type: "Microsoft.Network/loadBalancers", Name: "my-lb", State: "Unavailable", Summary: "All health probes are down."},
+				})
+			},
+		},
+		{
+			name:          "fail to list availability statuses",
+			expectedError: "failed to list availability statuses of resource group my-rg: #: Internal Server Error: StatusCode=500",
+			expect: func(s *mock_resourcehealth.MockResourceHealthScopeMockRecorder, m *mock_resourcehealth.MockclientMockRecorder) {
+				m.ListAvailabilityStatuses(gomockinternal.AContext(), "my-rg").Return(nil, internalError)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			scopeMock := mock_resourcehealth.NewMockResourceHealthScope(mockCtrl)
+			clientMock := mock_resourcehealth.NewMockclient(mockCtrl)
+
+			scopeMock.EXPECT().ResourceGroup().AnyTimes().Return("my-rg")
+			scopeMock.EXPECT().V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				client: clientMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -66,6 +66,10 @@ const (
 	EncryptionAtHost = "EncryptionAtHostSupported"
 	// MaximumPlatformFaultDomainCount identifies the maximum fault domain count for an availability set in a region.
 	MaximumPlatformFaultDomainCount = "MaximumPlatformFaultDomainCount"
+	// PremiumIO identifies the capability for premium storage support.
+	PremiumIO = "PremiumIO"
+	// HyperVGenerations identifies the capability listing the supported VM generations, e.g. "V1,V2".
+	HyperVGenerations = "HyperVGenerations"
 )
 
 // HasCapability return true for a capability which can be either
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retailprices
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// DefaultBaseURI is the endpoint of the Azure Retail Prices API, which doesn't require authentication.
+const DefaultBaseURI = "https://prices.azure.com"
+
+// Price is a retail price of an Azure meter, as returned by the Azure Retail Prices API.
+type Price struct {
+	CurrencyCode  string  `json:"currencyCode"`
+	RetailPrice   float64 `json:"retailPrice"`
+	ArmRegionName string  `json:"armRegionName"`
+	ArmSkuName    string  `json:"armSkuName"`
+	ServiceName   string  `json:"serviceName"`
+	ProductName   string  `json:"productName"`
+	SkuName       string  `json:"skuName"`
+	MeterName     string  `json:"meterName"`
+	UnitOfMeasure string  `json:"unitOfMeasure"`
+	Type          string  `json:"type"`
+}
+
+// pricesPage is a page of the prices returned by the Azure Retail Prices API.
+type pricesPage struct {
+	Items        []Price `json:"Items"`
+	NextPageLink string  `json:"NextPageLink"`
+}
+
+// Client wraps the Azure Retail Prices API.
+type Client interface {
+	List(ctx context.Context, currency, filter string) ([]Price, error)
+}
+
+// AzureClient lists prices from the Azure Retail Prices API.
+type AzureClient struct {
+	client  autorest.Client
+	baseURI string
+}
+
+var _ Client = &AzureClient{}
+
+// NewClient returns a client of the Azure Retail Prices API at baseURI. Its requests go through the same proxy, rate
+// limits and metrics as the requests to Azure Resource Manager.
+func NewClient(baseURI string) *AzureClient {
+	c := autorest.NewClientWithUserAgent("")
+	azure.SetAutoRestClientDefaults(&c, autorest.NullAuthorizer{})
+	return &AzureClient{client: c, baseURI: baseURI}
+}
+
+// List returns all the prices in currency matching an OData filter, e.g. "serviceName eq 'Virtual Machines'".
+func (ac *AzureClient) List(ctx context.Context, currency, filter string) ([]Price, error) {
+	ctx, span := tele.Tracer().Start(ctx, "retailprices.AzureClient.List")
+	defer span.End()
+
+	req, err := autorest.Prepare((&http.Request{}).WithContext(ctx),
+		autorest.AsGet(),
+		autorest.WithBaseURL(ac.baseURI),
+		autorest.WithPath("/api/retail/prices"),
+		autorest.WithQueryParameters(map[string]interface{}{
+			"currencyCode": autorest.Encode("query", "'"+currency+"'"),
+			"$filter":      autorest.Encode("query", filter),
+		}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prepare request for retail prices")
+	}
+
+	var prices []Price
+	for req != nil {
+		page, err := ac.listPage(req)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, page.Items...)
+		req = nil
+		if page.NextPageLink != "" {
+			req, err = autorest.Prepare((&http.Request{}).WithContext(ctx), autorest.AsGet(), autorest.WithBaseURL(page.NextPageLink))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to prepare request for the next page of retail prices")
+			}
+		}
+	}
+	return prices, nil
+}
+
+// listPage sends a request for a page of prices.
+func (ac *AzureClient) listPage(req *http.Request) (pricesPage, error) {
+	page := pricesPage{}
+	resp, err := ac.client.Send(req)
+	if err != nil {
+		return page, errors.Wrap(err, "failed to list retail prices")
+	}
+	err = autorest.Respond(resp,
+		autorest.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&page),
+		autorest.ByClosing())
+	return page, errors.Wrap(err, "failed to list retail prices")
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retailprices
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestList(t *testing.T) {
+	g := NewWithT(t)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := pricesPage{}
+		if r.URL.Query().Get("$skip") == "" {
+			g.Expect(r.URL.Path).To(Equal("/api/retail/prices"))
+			g.Expect(r.URL.Query().Get("currencyCode")).To(Equal("'EUR'"))
+			g.Expect(r.URL.Query().Get("$filter")).To(Equal("serviceName eq 'Load Balancer'"))
+			page.Items = []Price{{MeterName: "first"}}
+			page.NextPageLink = server.URL + "/api/retail/prices?$skip=100"
+		} else {
+			page.Items = []Price{{MeterName: "second"}}
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	prices, err := NewClient(server.URL).List(context.TODO(), "EUR", "serviceName eq 'Load Balancer'")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(prices).To(Equal([]Price{{MeterName: "first"}, {MeterName: "second"}}))
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retailprices
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/util/cache/ttllru"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// DefaultCurrency is the currency prices are estimated in by default.
+	DefaultCurrency = "USD"
+	// hoursPerMonth is how many hours Azure bills monthly prices for.
+	hoursPerMonth = 730
+	// cacheTTL is how long prices are cached for. Retail prices change rarely.
+	cacheTTL = 24 * time.Hour
+)
+
+// diskTiers are the sizes in GiB of the tiers of managed disks, which are billed by tier rather than by size.
+var diskTiers = []struct {
+	tier   int
+	sizeGB int32
+}{
+	{4, 32}, {6, 64}, {10, 128}, {15, 256}, {20, 512}, {30, 1024}, {40, 2048}, {50, 4096}, {60, 8192}, {70, 16384}, {80, 32767},
+}
+
+// diskTypes are the prefixes of the tiers and the products of the storage account types of managed disks.
+var diskTypes = map[string]struct {
+	prefix     string
+	redundancy string
+	product    string
+}{
+	"Premium_LRS":     {"P", "LRS", "Premium SSD Managed Disks"},
+	"Premium_ZRS":     {"P", "ZRS", "Premium SSD Managed Disks"},
+	"StandardSSD_LRS": {"E", "LRS", "Standard SSD Managed Disks"},
+	"StandardSSD_ZRS": {"E", "ZRS", "Standard SSD Managed Disks"},
+	"Standard_LRS":    {"S", "LRS", "Standard HDD Managed Disks"},
+}
+
+// Estimator estimates the hourly cost of Azure resources from their retail prices, which are cached.
+type Estimator struct {
+	client   Client
+	currency string
+	cache    ttllru.PeekingCacher
+}
+
+// NewEstimator returns an estimator of costs in currency, from the prices listed by client.
+func NewEstimator(client Client, currency string) (*Estimator, error) {
+	cache, err := ttllru.New(1024, cacheTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating LRU cache for retail prices")
+	}
+	return &Estimator{client: client, currency: currency, cache: cache}, nil
+}
+
+// Currency returns the currency of the estimated costs.
+func (e *Estimator) Currency() string {
+	return e.currency
+}
+
+// VirtualMachine returns the hourly cost of a virtual machine of a size, running Linux or Windows, as a regular or a
+// spot virtual machine.
+func (e *Estimator) VirtualMachine(ctx context.Context, location, size string, windows, spot bool) (float64, error) {
+	ctx, span := tele.Tracer().Start(ctx, "retailprices.Estimator.VirtualMachine")
+	defer span.End()
+
+	filter := fmt.Sprintf("serviceName eq 'Virtual Machines' and armRegionName eq '%s' and armSkuName eq '%s' and priceType eq 'Consumption'", location, size)
+	return e.hourlyPrice(ctx, filter, fmt.Sprintf("virtual machine size %s", size), func(p Price) bool {
+		if strings.HasSuffix(p.ProductName, " Windows") != windows || strings.Contains(p.SkuName, "Low Priority") {
+			return false
+		}
+		return strings.HasSuffix(p.SkuName, " Spot") == spot
+	})
+}
+
+// Disk returns the hourly cost of a managed disk of a storage account type and size. Disks of storage account types
+// which aren't billed by tier, e.g. Ultra disks, aren't supported.
+func (e *Estimator) Disk(ctx context.Context, location, storageAccountType string, sizeGB int32) (float64, error) {
+	ctx, span := tele.Tracer().Start(ctx, "retailprices.Estimator.Disk")
+	defer span.End()
+
+	diskType, ok := diskTypes[storageAccountType]
+	if !ok {
+		return 0, errors.Errorf("can't estimate the cost of disks of storage account type %s", storageAccountType)
+	}
+	tier := diskTier(diskType.prefix, sizeGB)
+	sku := tier + " " + diskType.redundancy
+	filter := fmt.Sprintf("serviceName eq 'Storage' and armRegionName eq '%s' and productName eq '%s' and skuName eq '%s' and priceType eq 'Consumption'", location, diskType.product, sku)
+	return e.hourlyPrice(ctx, filter, fmt.Sprintf("disk tier %s", sku), func(p Price) bool {
+		// Disks are also billed for their transactions and bursting, which aren't estimated.
+		return strings.HasSuffix(p.MeterName, " Disk") || strings.HasSuffix(p.MeterName, " Disks")
+	})
+}
+
+// PublicIP returns the hourly cost of a standard static public IP address.
+func (e *Estimator) PublicIP(ctx context.Context, location string) (float64, error) {
+	ctx, span := tele.Tracer().Start(ctx, "retailprices.Estimator.PublicIP")
+	defer span.End()
+
+	filter := fmt.Sprintf("serviceName eq 'Virtual Network' and armRegionName eq '%s' and productName eq 'IP Addresses' and priceType eq 'Consumption'", location)
+	return e.hourlyPrice(ctx, filter, "public IP address", func(p Price) bool {
+		return p.MeterName == "Standard IPv4 Static Public IP"
+	})
+}
+
+// LoadBalancer returns the hourly cost of a load balancer of a SKU with up to 5 rules. Basic load balancers are free.
+func (e *Estimator) LoadBalancer(ctx context.Context, location, sku string) (float64, error) {
+	ctx, span := tele.Tracer().Start(ctx, "retailprices.Estimator.LoadBalancer")
+	defer span.End()
+
+	if strings.EqualFold(sku, "Basic") {
+		return 0, nil
+	}
+	filter := fmt.Sprintf("serviceName eq 'Load Balancer' and armRegionName eq '%s' and skuName eq '%s' and priceType eq 'Consumption'", location, sku)
+	return e.hourlyPrice(ctx, filter, fmt.Sprintf("load balancer SKU %s", sku), func(p Price) bool {
+		// The data processed by load balancers is billed too, which isn't estimated.
+		return strings.Contains(p.MeterName, "Included LB Rules")
+	})
+}
+
+// hourlyPrice returns the hourly price of the first of the prices matching filter selected by match, listing them
+// unless they are cached.
+func (e *Estimator) hourlyPrice(ctx context.Context, filter, description string, match func(Price) bool) (float64, error) {
+	prices, err := e.list(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	for _, price := range prices {
+		if !match(price) {
+			continue
+		}
+		if hourly, ok := hourlyPrice(price); ok {
+			return hourly, nil
+		}
+	}
+	return 0, errors.Errorf("no retail price found for %s", description)
+}
+
+// list returns the prices matching filter, from the cache if they were listed before.
+func (e *Estimator) list(ctx context.Context, filter string) ([]Price, error) {
+	if prices, ok := e.cache.Get(filter); ok {
+		return prices.([]Price), nil
+	}
+	prices, err := e.client.List(ctx, e.currency, filter)
+	if err != nil {
+		return nil, err
+	}
+	// Prices which aren't found are cached too, so they aren't listed over and over.
+	_ = e.cache.Add(filter, prices)
+	return prices, nil
+}
+
+// hourlyPrice returns the hourly price of a price billed by the hour or by the month.
+func hourlyPrice(p Price) (float64, bool) {
+	switch p.UnitOfMeasure {
+	case "1 Hour":
+		return p.RetailPrice, true
+	case "1/Month", "1 Month":
+		return p.RetailPrice / hoursPerMonth, true
+	default:
+		return 0, false
+	}
+}
+
+// diskTier returns the tier of a managed disk of a size, e.g. P10 for a premium disk of 128 GiB.
+func diskTier(prefix string, sizeGB int32) string {
+	for _, t := range diskTiers {
+		if sizeGB <= t.sizeGB {
+			return fmt.Sprintf("%s%d", prefix, t.tier)
+		}
+	}
+	return fmt.Sprintf("%s%d", prefix, diskTiers[len(diskTiers)-1].tier)
+}
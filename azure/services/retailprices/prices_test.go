@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retailprices
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeClient returns the same prices for all filters, and counts the calls made to it.
+type fakeClient struct {
+	prices []Price
+	calls  int
+}
+
+func (c *fakeClient) List(_ context.Context, _, _ string) ([]Price, error) {
+	c.calls++
+	return c.prices, nil
+}
+
+func TestVirtualMachine(t *testing.T) {
+	g := NewWithT(t)
+
+	client := &fakeClient{prices: []Price{
+		{ProductName: "Virtual Machines DSv3 Series", SkuName: "D2s v3 Low Priority", RetailPrice: 0.02, UnitOfMeasure: "1 Hour"},
+		{ProductName: "Virtual Machines DSv3 Series", SkuName: "D2s v3 Spot", RetailPrice: 0.01, UnitOfMeasure: "1 Hour"},
+		{ProductName: "Virtual Machines DSv3 Series", SkuName: "D2s v3", RetailPrice: 0.096, UnitOfMeasure: "1 Hour"},
+		{ProductName: "Virtual Machines DSv3 Series Windows", SkuName: "D2s v3", RetailPrice: 0.188, UnitOfMeasure: "1 Hour"},
+	}}
+	e, err := NewEstimator(client, DefaultCurrency)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(e.VirtualMachine(context.TODO(), "westeurope", "Standard_D2s_v3", false, false)).To(Equal(0.096))
+	g.Expect(e.VirtualMachine(context.TODO(), "westeurope", "Standard_D2s_v3", true, false)).To(Equal(0.188))
+	g.Expect(e.VirtualMachine(context.TODO(), "westeurope", "Standard_D2s_v3", false, true)).To(Equal(0.01))
+	_, err = e.VirtualMachine(context.TODO(), "westeurope", "Standard_D2s_v3", true, true)
+	g.Expect(err).To(MatchError("no retail price found for virtual machine size Standard_D2s_v3"))
+	// The prices of the same size and location are listed once.
+	g.Expect(client.calls).To(Equal(1))
+}
+
+func TestDisk(t *testing.T) {
+	g := NewWithT(t)
+
+	client := &fakeClient{prices: []Price{
+		{MeterName: "P10 LRS Disk Mount", RetailPrice: 0.1, UnitOfMeasure: "1 Hour"},
+		{MeterName: "P10 LRS Disk", RetailPrice: 19.71, UnitOfMeasure: "1/Month"},
+	}}
+	e, err := NewEstimator(client, DefaultCurrency)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(e.Disk(context.TODO(), "westeurope", "Premium_LRS", 128)).To(BeNumerically("~", 0.027, 0.0001))
+	_, err = e.Disk(context.TODO(), "westeurope", "UltraSSD_LRS", 128)
+	g.Expect(err).To(MatchError("can't estimate the cost of disks of storage account type UltraSSD_LRS"))
+}
+
+func TestDiskTier(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(diskTier("P", 0)).To(Equal("P4"))
+	g.Expect(diskTier("P", 30)).To(Equal("P4"))
+	g.Expect(diskTier("E", 128)).To(Equal("E10"))
+	g.Expect(diskTier("S", 129)).To(Equal("S15"))
+	g.Expect(diskTier("P", 65536)).To(Equal("P80"))
+}
+
+func TestLoadBalancer(t *testing.T) {
+	g := NewWithT(t)
+
+	client := &fakeClient{prices: []Price{
+		{MeterName: "Standard Data Processed", RetailPrice: 0.005, UnitOfMeasure: "1 GB"},
+		{MeterName: "Standard Included LB Rules and Outbound Rules", RetailPrice: 0.025, UnitOfMeasure: "1 Hour"},
+	}}
+	e, err := NewEstimator(client, DefaultCurrency)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(e.LoadBalancer(context.TODO(), "westeurope", "Standard")).To(Equal(0.025))
+	g.Expect(e.LoadBalancer(context.TODO(), "westeurope", "Basic")).To(Equal(0.0))
+	g.Expect(client.calls).To(Equal(1))
+}
@@ -70,7 +70,7 @@ func (ac *azureClient) CreateOrUpdate(ctx context.Context, resourceGroupName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.routetables.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.routetables.Client)
 	if err != nil {
 		return err
 	}
@@ -87,7 +87,7 @@ func (ac *azureClient) Delete(ctx context.Context, resourceGroupName, rtName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.routetables.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.routetables.Client)
 	if err != nil {
 		return err
 	}
@@ -127,7 +127,8 @@ func (ac *AzureClient) ListInstances(ctx context.Context, resourceGroupName, vms
 	ctx, span := tele.Tracer().Start(ctx, "scalesets.AzureClient.ListInstances")
 	defer span.End()
 
-	itr, err := ac.scalesetvms.ListComplete(ctx, resourceGroupName, vmssName, "", "", "")
+	// Expand the instance view, so that callers can inspect the power state of the instances.
+	itr, err := ac.scalesetvms.ListComplete(ctx, resourceGroupName, vmssName, "", "", "instanceView")
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +182,7 @@ func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, vm
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.scalesets.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.scalesets.Client)
 	if err != nil {
 		return err
 	}
@@ -224,7 +225,7 @@ func (ac *AzureClient) Update(ctx context.Context, resourceGroupName, vmssName s
 		return compute.VirtualMachineScaleSet{}, errors.Wrapf(err, "failed updating vmss named %q", vmssName)
 	}
 
-	err = future.WaitForCompletionRef(ctx, ac.scalesets.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.scalesets.Client)
 	if err != nil {
 		return compute.VirtualMachineScaleSet{}, errors.Wrapf(err, "failed waiting for completion of operation for vmss named %q", vmssName)
 	}
@@ -337,7 +338,7 @@ func (ac *AzureClient) UpdateInstances(ctx context.Context, resourceGroupName, v
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.scalesets.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.scalesets.Client)
 	if err != nil {
 		return err
 	}
@@ -354,7 +355,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, vmssName s
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.scalesets.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.scalesets.Client)
 	if err != nil {
 		return err
 	}
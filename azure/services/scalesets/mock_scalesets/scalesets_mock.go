@@ -96,6 +96,20 @@ func (mr *MockScaleSetScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockScaleSetScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockScaleSetScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockScaleSetScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockScaleSetScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockScaleSetScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -166,6 +180,20 @@ func (mr *MockScaleSetScopeMockRecorder) CloudProviderConfigOverrides() *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockScaleSetScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockScaleSetScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockScaleSetScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockScaleSetScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockScaleSetScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -211,6 +239,20 @@ func (mr *MockScaleSetScopeMockRecorder) Error(err, msg interface{}, keysAndValu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockScaleSetScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockScaleSetScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockScaleSetScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockScaleSetScope)(nil).FailureDomainZone), arg0)
+}
+
 // GetBootstrapData mocks base method.
 func (m *MockScaleSetScope) GetBootstrapData(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
@@ -226,6 +268,21 @@ func (mr *MockScaleSetScopeMockRecorder) GetBootstrapData(ctx interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBootstrapData", reflect.TypeOf((*MockScaleSetScope)(nil).GetBootstrapData), ctx)
 }
 
+// GetKeyVaultSecret mocks base method.
+func (m *MockScaleSetScope) GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyVaultSecret", ctx, secretURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyVaultSecret indicates an expected call of GetKeyVaultSecret.
+func (mr *MockScaleSetScopeMockRecorder) GetKeyVaultSecret(ctx, secretURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyVaultSecret", reflect.TypeOf((*MockScaleSetScope)(nil).GetKeyVaultSecret), ctx, secretURL)
+}
+
 // GetLongRunningOperationState mocks base method.
 func (m *MockScaleSetScope) GetLongRunningOperationState() *v1alpha4.Future {
 	m.ctrl.T.Helper()
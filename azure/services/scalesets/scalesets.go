@@ -18,7 +18,9 @@ package scalesets
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -42,6 +44,7 @@ type (
 	ScaleSetScope interface {
 		logr.Logger
 		azure.ClusterDescriber
+		azure.KeyVaultSecretGetter
 		GetBootstrapData(ctx context.Context) (string, error)
 		GetLongRunningOperationState() *infrav1.Future
 		GetVMImage() (*infrav1.Image, error)
@@ -104,6 +107,22 @@ func (s *Service) Reconcile(ctx context.Context) (retErr error) {
 		}
 	}()
 
+	// An operation creating or updating the VMSS with parameters the spec changed since is superseded. It is no longer
+	// polled, and the VMSS is created or updated with the changed parameters right away. Azure can't cancel the
+	// superseded operation and rejects other operations on the VMSS while it runs, which requeues the reconciliation
+	// until it completed.
+	if future != nil && future.ParametersHash != "" {
+		superseded, err := s.isOperationSuperseded(ctx, future)
+		if err != nil {
+			return err
+		}
+		if superseded {
+			s.Scope.V(2).Info("long running operation superseded by a change to the spec", "scale set", future.Name, "type", future.Type)
+			s.Scope.SetLongRunningOperationState(nil)
+			future = nil
+		}
+	}
+
 	if future == nil {
 		fetchedVMSS, err = s.getVirtualMachineScaleSet(ctx)
 	} else {
@@ -213,8 +232,14 @@ func (s *Service) createVMSS(ctx context.Context) (*infrav1.Future, error) {
 
 	future, err := s.Client.CreateOrUpdateAsync(ctx, s.Scope.ResourceGroup(), spec.Name, vmss)
 	if err != nil {
+		if azure.OperationInProgress(err) {
+			return future, azure.WithTransientError(err, 30*time.Second)
+		}
 		return future, errors.Wrap(err, "cannot create VMSS")
 	}
+	if future != nil {
+		future.ParametersHash = parametersHash(vmss)
+	}
 
 	s.Scope.V(2).Info("starting to create VMSS", "scale set", spec.Name)
 	s.Scope.SetLongRunningOperationState(future)
@@ -259,17 +284,53 @@ func (s *Service) patchVMSSIfNeeded(ctx context.Context, infraVMSS *azure.VMSS)
 	s.Scope.V(4).Info("patching vmss", "scale set", spec.Name, "patch", patch)
 	future, err := s.UpdateAsync(ctx, s.Scope.ResourceGroup(), spec.Name, patch)
 	if err != nil {
-		if azure.ResourceConflict(err) {
+		if azure.ResourceConflict(err) || azure.OperationInProgress(err) {
 			return future, azure.WithTransientError(err, 30*time.Second)
 		}
 		return future, errors.Wrap(err, "failed updating VMSS")
 	}
+	if future != nil {
+		future.ParametersHash = parametersHash(vmss)
+	}
 
 	s.Scope.SetLongRunningOperationState(future)
 	s.Scope.V(2).Info("successfully started to update vmss", "scale set", spec.Name)
 	return future, err
 }
 
+// isOperationSuperseded returns true if the parameters of the operation creating or updating the VMSS changed since
+// it started.
+func (s *Service) isOperationSuperseded(ctx context.Context, future *infrav1.Future) (bool, error) {
+	if future.Type != PutFuture && future.Type != PatchFuture {
+		return false, nil
+	}
+	vmss, err := s.buildVMSSFromSpec(ctx, s.Scope.ScaleSetSpec())
+	if err != nil {
+		return false, errors.Wrap(err, "failed building VMSS from spec")
+	}
+	return parametersHash(vmss) != future.ParametersHash, nil
+}
+
+// parametersHash returns the hash of the parameters of an operation creating or updating a VMSS, apart from the admin
+// password of Windows VMSS, which is generated anew for every operation.
+func parametersHash(vmss compute.VirtualMachineScaleSet) string {
+	if vmss.VirtualMachineScaleSetProperties != nil && vmss.VirtualMachineProfile != nil && vmss.VirtualMachineProfile.OsProfile != nil {
+		properties := *vmss.VirtualMachineScaleSetProperties
+		profile := *properties.VirtualMachineProfile
+		osProfile := *profile.OsProfile
+		osProfile.AdminPassword = nil
+		profile.OsProfile = &osProfile
+		properties.VirtualMachineProfile = &profile
+		vmss.VirtualMachineScaleSetProperties = &properties
+	}
+	data, err := json.Marshal(vmss)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
 func hasModelModifyingDifferences(infraVMSS *azure.VMSS, vmss compute.VirtualMachineScaleSet) bool {
 	other := converters.SDKToVMSS(vmss, []compute.VirtualMachineScaleSetVM{})
 	return infraVMSS.HasModelChanges(*other)
@@ -586,9 +647,9 @@ func (s *Service) generateStorageProfile(vmssSpec azure.ScaleSetSpec, sku resour
 }
 
 func (s *Service) generateOSProfile(ctx context.Context, vmssSpec azure.ScaleSetSpec) (*compute.VirtualMachineScaleSetOSProfile, error) {
-	sshKey, err := base64.StdEncoding.DecodeString(vmssSpec.SSHKeyData)
+	sshKey, err := s.getSSHPublicKey(ctx, vmssSpec.SSHKeyData, vmssSpec.SSHKeySecretURL)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode ssh public key")
+		return nil, err
 	}
 	bootstrapData, err := s.Scope.GetBootstrapData(ctx)
 	if err != nil {
@@ -610,7 +671,15 @@ func (s *Service) generateOSProfile(ctx context.Context, vmssSpec azure.ScaleSet
 		// but the password on the VM will NOT be the same as created here.
 		// Access is provided via SSH public key that is set during deployment
 		// Azure also provides a way to reset user passwords in the case of need.
-		osProfile.AdminPassword = to.StringPtr(generators.SudoRandomPassword(123))
+		// A password may also be referenced in Key Vault, so it is known to the owner of the vault.
+		adminPassword := generators.SudoRandomPassword(123)
+		if vmssSpec.AdminPasswordSecretURL != "" {
+			adminPassword, err = s.Scope.GetKeyVaultSecret(ctx, vmssSpec.AdminPasswordSecretURL)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to retrieve admin password from Key Vault")
+			}
+		}
+		osProfile.AdminPassword = to.StringPtr(adminPassword)
 		osProfile.WindowsConfiguration = &compute.WindowsConfiguration{
 			EnableAutomaticUpdates: to.BoolPtr(false),
 		}
@@ -621,7 +690,7 @@ func (s *Service) generateOSProfile(ctx context.Context, vmssSpec azure.ScaleSet
 				PublicKeys: &[]compute.SSHPublicKey{
 					{
 						Path:    to.StringPtr(fmt.Sprintf("/home/%s/.ssh/authorized_keys", azure.DefaultUserName)),
-						KeyData: to.StringPtr(string(sshKey)),
+						KeyData: to.StringPtr(sshKey),
 					},
 				},
 			},
@@ -660,3 +729,20 @@ func getSecurityProfile(vmssSpec azure.ScaleSetSpec, sku resourceskus.SKU) (*com
 		EncryptionAtHost: to.BoolPtr(*vmssSpec.SecurityProfile.EncryptionAtHost),
 	}, nil
 }
+
+// getSSHPublicKey returns the SSH public key in OpenSSH format, either decoded from the base64 encoded key data or
+// read from Key Vault.
+func (s *Service) getSSHPublicKey(ctx context.Context, sshKeyData, secretURL string) (string, error) {
+	if secretURL != "" {
+		sshKey, err := s.Scope.GetKeyVaultSecret(ctx, secretURL)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retrieve ssh public key from Key Vault")
+		}
+		return sshKey, nil
+	}
+	sshKey, err := base64.StdEncoding.DecodeString(sshKeyData)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode ssh public key")
+	}
+	return string(sshKey), nil
+}
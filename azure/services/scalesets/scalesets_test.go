@@ -23,6 +23,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	"github.com/Azure/go-autorest/autorest"
+	azureautorest "github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
@@ -350,10 +351,9 @@ func TestReconcileVMSS(t *testing.T) {
 					MaxPrice: to.Float64Ptr(0.001),
 				}
 				vmss.VirtualMachineScaleSetProperties.VirtualMachineProfile.EvictionPolicy = compute.Deallocate
-				vmss = setHashOnVMSS(g, vmss)
 				m.CreateOrUpdateAsync(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName, gomockinternal.DiffEq(vmss)).
 					Return(putFuture, nil)
-				setupCreatingSucceededExpectations(s, m, putFuture)
+				setupCreatingSucceededExpectations(s, m, newDefaultExistingVMSS(), putFuture)
 			},
 		},
 		{
@@ -465,6 +465,83 @@ func TestReconcileVMSS(t *testing.T) {
 				m.ListInstances(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(instances, nil)
 			},
 		},
+		{
+			name:          "should update the vmss right away when a long running operation is superseded by a spec change",
+			expectedError: "failed to get VMSS my-vmss after create or update: failed to get result from future: operation type PATCH on Azure resource my-rg/my-vmss is not done",
+			expect: func(g *WithT, s *mock_scalesets.MockScaleSetScopeMockRecorder, m *mock_scalesets.MockClientMockRecorder) {
+				spec := newDefaultVMSSSpec()
+				spec.Capacity = 2
+				s.ScaleSetSpec().Return(spec).AnyTimes()
+
+				// The parameters are built once to compare them to the ones of the operation, and once to update the VMSS.
+				setupUpdateVMSSExpectations(s)
+				setupUpdateVMSSExpectations(s)
+				staleFuture := &infrav1.Future{
+					Type:           PatchFuture,
+					ResourceGroup:  defaultResourceGroup,
+					Name:           defaultVMSSName,
+					ParametersHash: "stale",
+				}
+				s.GetLongRunningOperationState().Return(staleFuture)
+				s.SetLongRunningOperationState(nil)
+				s.SetProviderID(azure.ProviderIDPrefix + "vmss-id")
+				s.MaxSurge().Return(1, nil)
+				s.SetVMSSState(gomock.Any())
+				existingVMSS := newDefaultExistingVMSS()
+				existingVMSS.Sku.Capacity = to.Int64Ptr(2)
+				instances := newDefaultInstances()
+				m.Get(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(existingVMSS, nil)
+				m.ListInstances(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(instances, nil)
+
+				clone := newDefaultExistingVMSS()
+				clone.Sku.Capacity = to.Int64Ptr(3)
+				patchVMSS, err := getVMSSUpdateFromVMSS(clone)
+				g.Expect(err).NotTo(HaveOccurred())
+				patchVMSS.VirtualMachineProfile.StorageProfile.ImageReference.Version = to.StringPtr("2.0")
+				patchVMSS.VirtualMachineProfile.NetworkProfile = nil
+				future := &infrav1.Future{Type: PatchFuture, ResourceGroup: defaultResourceGroup, Name: defaultVMSSName}
+				m.UpdateAsync(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName, gomockinternal.DiffEq(patchVMSS)).
+					Return(future, nil)
+				s.SetLongRunningOperationState(gomock.Any()).Do(func(future *infrav1.Future) {
+					g.Expect(future.ParametersHash).NotTo(BeEmpty())
+					g.Expect(future.ParametersHash).NotTo(Equal("stale"))
+				})
+				m.GetResultIfDone(gomockinternal.AContext(), future).Return(compute.VirtualMachineScaleSet{}, azure.NewOperationNotDoneError(future))
+				m.Get(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(clone, nil)
+				m.ListInstances(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(instances, nil)
+			},
+		},
+		{
+			name:          "should requeue the update of the vmss while a superseded long running operation is in progress",
+			expectedError: "failed to start updating VMSS: transient reconcile error occurred: compute.VirtualMachineScaleSetsClient#Update: Failure sending request: StatusCode=0 -- Original Error: Code=\"OperationNotAllowed\" Message=\"Operation 'Update' is not allowed on VM scale set 'my-vmss' since another operation is in progress.\". Object will be requeued after 30s",
+			expect: func(g *WithT, s *mock_scalesets.MockScaleSetScopeMockRecorder, m *mock_scalesets.MockClientMockRecorder) {
+				spec := newDefaultVMSSSpec()
+				spec.Capacity = 2
+				s.ScaleSetSpec().Return(spec).AnyTimes()
+				setupUpdateVMSSExpectations(s)
+				setupUpdateVMSSExpectations(s)
+				staleFuture := &infrav1.Future{
+					Type:           PatchFuture,
+					ResourceGroup:  defaultResourceGroup,
+					Name:           defaultVMSSName,
+					ParametersHash: "stale",
+				}
+				s.GetLongRunningOperationState().Return(staleFuture)
+				s.SetLongRunningOperationState(nil)
+				s.SetProviderID(azure.ProviderIDPrefix + "vmss-id")
+				s.MaxSurge().Return(1, nil)
+				s.SetVMSSState(gomock.Any())
+				existingVMSS := newDefaultExistingVMSS()
+				existingVMSS.Sku.Capacity = to.Int64Ptr(2)
+				m.Get(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(existingVMSS, nil)
+				m.ListInstances(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName).Return(newDefaultInstances(), nil)
+				m.UpdateAsync(gomockinternal.AContext(), defaultResourceGroup, defaultVMSSName, gomock.Any()).
+					Return(nil, autorest.NewErrorWithError(&azureautorest.ServiceError{
+						Code:    "OperationNotAllowed",
+						Message: "Operation 'Update' is not allowed on VM scale set 'my-vmss' since another operation is in progress.",
+					}, "compute.VirtualMachineScaleSetsClient", "Update", nil, "Failure sending request"))
+			},
+		},
 		{
 			name:          "less than 2 vCPUs",
 			expectedError: "reconcile error that cannot be recovered occurred: vm size should be bigger or equal to at least 2 vCPUs. Object will not be requeued",
@@ -85,7 +85,7 @@ func (ac *azureClient) CreateOrUpdate(ctx context.Context, resourceGroupName str
 		return err
 	}
 
-	err = future.WaitForCompletionRef(ctx, ac.securitygroups.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.securitygroups.Client)
 	if err != nil {
 		return err
 	}
@@ -102,7 +102,7 @@ func (ac *azureClient) Delete(ctx context.Context, resourceGroupName, sgName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.securitygroups.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.securitygroups.Client)
 	if err != nil {
 		return err
 	}
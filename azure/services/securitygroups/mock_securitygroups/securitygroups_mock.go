@@ -123,6 +123,20 @@ func (mr *MockNSGScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockNSGScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockNSGScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockNSGScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockNSGScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockNSGScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -193,6 +207,20 @@ func (mr *MockNSGScopeMockRecorder) CloudProviderConfigOverrides() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockNSGScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockNSGScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockNSGScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockNSGScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockNSGScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -235,6 +263,20 @@ func (mr *MockNSGScopeMockRecorder) ControlPlaneSubnet() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ControlPlaneSubnet", reflect.TypeOf((*MockNSGScope)(nil).ControlPlaneSubnet))
 }
 
+// DriftRepairEnabled mocks base method.
+func (m *MockNSGScope) DriftRepairEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DriftRepairEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// DriftRepairEnabled indicates an expected call of DriftRepairEnabled.
+func (mr *MockNSGScopeMockRecorder) DriftRepairEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DriftRepairEnabled", reflect.TypeOf((*MockNSGScope)(nil).DriftRepairEnabled))
+}
+
 // Enabled mocks base method.
 func (m *MockNSGScope) Enabled() bool {
 	m.ctrl.T.Helper()
@@ -266,6 +308,20 @@ func (mr *MockNSGScopeMockRecorder) Error(err, msg interface{}, keysAndValues ..
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockNSGScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockNSGScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockNSGScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockNSGScope)(nil).FailureDomainZone), arg0)
+}
+
 // GetPrivateDNSZoneName mocks base method.
 func (m *MockNSGScope) GetPrivateDNSZoneName() string {
 	m.ctrl.T.Helper()
@@ -437,6 +493,18 @@ func (mr *MockNSGScopeMockRecorder) OutboundPoolName(arg0 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundPoolName", reflect.TypeOf((*MockNSGScope)(nil).OutboundPoolName), arg0)
 }
 
+// RecordDrift mocks base method.
+func (m *MockNSGScope) RecordDrift(drift string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordDrift", drift)
+}
+
+// RecordDrift indicates an expected call of RecordDrift.
+func (mr *MockNSGScopeMockRecorder) RecordDrift(drift interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordDrift", reflect.TypeOf((*MockNSGScope)(nil).RecordDrift), drift)
+}
+
 // ResourceGroup mocks base method.
 func (m *MockNSGScope) ResourceGroup() string {
 	m.ctrl.T.Helper()
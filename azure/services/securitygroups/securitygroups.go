@@ -18,6 +18,7 @@ package securitygroups
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
@@ -25,6 +26,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
@@ -36,6 +38,8 @@ type NSGScope interface {
 	azure.ClusterDescriber
 	azure.NetworkDescriber
 	NSGSpecs() []azure.NSGSpec
+	DriftRepairEnabled() bool
+	RecordDrift(drift string)
 }
 
 // Service provides operations on Azure resources.
@@ -65,7 +69,10 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	for _, nsgSpec := range s.Scope.NSGSpecs() {
 		securityRules := make([]network.SecurityRule, 0)
 		var etag *string
+		var tags map[string]*string
 
+		// Only the tags of an existing NSG are replaced if nothing else changes.
+		putCtx := ctx
 		existingNSG, err := s.client.Get(ctx, s.Scope.ResourceGroup(), nsgSpec.Name)
 		switch {
 		case err != nil && !azure.ResourceNotFound(err):
@@ -74,23 +81,37 @@ func (s *Service) Reconcile(ctx context.Context) error {
 			// security group already exists
 			// We append the existing NSG etag to the header to ensure we only apply the updates if the NSG has not been modified.
 			etag = existingNSG.Etag
+			putCtx = azure.WithExistingResource(ctx, to.String(existingNSG.ID), existingNSG)
+			// Keep the existing tags, e.g. the additional tags applied by the tags service.
+			tags = existingNSG.Tags
 			// Check if the expected rules are present
-			update := false
+			var missing []string
 			securityRules = *existingNSG.SecurityRules
 			for _, rule := range nsgSpec.SecurityRules {
 				sdkRule := converters.SecurityRuleToSDK(rule)
 				if !ruleExists(securityRules, sdkRule) {
-					update = true
+					missing = append(missing, rule.Name)
 					securityRules = append(securityRules, sdkRule)
 				}
 			}
-			if !update {
+			if len(missing) == 0 {
 				// Skip update for NSG as the required default rules are present
 				s.Scope.V(2).Info("security group exists and no default rules are missing, skipping update", "security group", nsgSpec.Name)
 				continue
 			}
+			s.Scope.RecordDrift(fmt.Sprintf("network security group %s is missing security rules %s", nsgSpec.Name, strings.Join(missing, ", ")))
+			if !s.Scope.DriftRepairEnabled() {
+				s.Scope.Info("security group is missing security rules, skipping update as drift repair is disabled", "security group", nsgSpec.Name, "rules", missing)
+				continue
+			}
 		default:
 			s.Scope.V(2).Info("creating security group", "security group", nsgSpec.Name)
+			tags = converters.TagsToMap(infrav1.Build(infrav1.BuildParams{
+				ClusterName: s.Scope.ClusterName(),
+				Lifecycle:   infrav1.ResourceLifecycleOwned,
+				Name:        to.StringPtr(nsgSpec.Name),
+				Additional:  s.Scope.AdditionalTags(),
+			}))
 			for _, rule := range nsgSpec.SecurityRules {
 				securityRules = append(securityRules, converters.SecurityRuleToSDK(rule))
 			}
@@ -101,8 +122,9 @@ func (s *Service) Reconcile(ctx context.Context) error {
 				SecurityRules: &securityRules,
 			},
 			Etag: etag,
+			Tags: tags,
 		}
-		err = s.client.CreateOrUpdate(ctx, s.Scope.ResourceGroup(), nsgSpec.Name, sg)
+		err = s.client.CreateOrUpdate(putCtx, s.Scope.ResourceGroup(), nsgSpec.Name, sg)
 		if err != nil {
 			return errors.Wrapf(err, "failed to create or update security group %s in resource group %s", nsgSpec.Name, s.Scope.ResourceGroup())
 		}
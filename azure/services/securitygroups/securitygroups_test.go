@@ -78,6 +78,8 @@ func TestReconcileSecurityGroups(t *testing.T) {
 				s.IsVnetManaged().Return(true)
 				s.ResourceGroup().AnyTimes().Return("my-rg")
 				s.Location().AnyTimes().Return("test-location")
+				s.ClusterName().AnyTimes().Return("my-cluster")
+				s.AdditionalTags().AnyTimes().Return(infrav1.Tags{"foo": "bar"})
 				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
 				m.Get(gomockinternal.AContext(), "my-rg", "nsg-one").Return(network.SecurityGroup{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
 				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "nsg-one", gomockinternal.DiffEq(network.SecurityGroup{
@@ -115,6 +117,11 @@ func TestReconcileSecurityGroups(t *testing.T) {
 					},
 					Etag:     nil,
 					Location: to.StringPtr("test-location"),
+					Tags: map[string]*string{
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": to.StringPtr("owned"),
+						"Name": to.StringPtr("nsg-one"),
+						"foo":  to.StringPtr("bar"),
+					},
 				}))
 				m.Get(gomockinternal.AContext(), "my-rg", "nsg-two").Return(network.SecurityGroup{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
 				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "nsg-two", gomockinternal.DiffEq(network.SecurityGroup{
@@ -123,6 +130,11 @@ func TestReconcileSecurityGroups(t *testing.T) {
 					},
 					Etag:     nil,
 					Location: to.StringPtr("test-location"),
+					Tags: map[string]*string{
+						"sigs.k8s.io_cluster-api-provider-azure_cluster_my-cluster": to.StringPtr("owned"),
+						"Name": to.StringPtr("nsg-two"),
+						"foo":  to.StringPtr("bar"),
+					},
 				}))
 			},
 		}, {
@@ -177,6 +189,7 @@ func TestReconcileSecurityGroups(t *testing.T) {
 					Etag: to.StringPtr("test-etag"),
 					ID:   to.StringPtr("fake/nsg/id"),
 					Name: to.StringPtr("nsg-one"),
+					Tags: map[string]*string{"foo": to.StringPtr("bar")},
 				}, nil)
 				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "nsg-one", gomockinternal.DiffEq(network.SecurityGroup{
 					SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
@@ -213,7 +226,10 @@ func TestReconcileSecurityGroups(t *testing.T) {
 					},
 					Etag:     to.StringPtr("test-etag"),
 					Location: to.StringPtr("test-location"),
+					Tags:     map[string]*string{"foo": to.StringPtr("bar")},
 				}))
+				s.RecordDrift("network security group nsg-one is missing security rules first-rule")
+				s.DriftRepairEnabled().Return(true)
 				m.Get(gomockinternal.AContext(), "my-rg", "nsg-two").Return(network.SecurityGroup{
 					Response: autorest.Response{},
 					SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
@@ -224,6 +240,41 @@ func TestReconcileSecurityGroups(t *testing.T) {
 					Name: to.StringPtr("nsg-two"),
 				}, nil)
 			},
+		}, {
+			name: "security group is missing rules and drift repair is disabled",
+			expect: func(s *mock_securitygroups.MockNSGScopeMockRecorder, m *mock_securitygroups.MockclientMockRecorder) {
+				s.NSGSpecs().Return([]azure.NSGSpec{
+					{
+						Name: "nsg-one",
+						SecurityRules: infrav1.SecurityRules{
+							{
+								Name:             "first-rule",
+								Description:      "a test rule",
+								Protocol:         "*",
+								Priority:         400,
+								SourcePorts:      to.StringPtr("*"),
+								DestinationPorts: to.StringPtr("*"),
+								Source:           to.StringPtr("*"),
+								Destination:      to.StringPtr("*"),
+								Direction:        infrav1.SecurityRuleDirectionOutbound,
+							},
+						},
+					},
+				})
+				s.IsVnetManaged().AnyTimes().Return(true)
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				m.Get(gomockinternal.AContext(), "my-rg", "nsg-one").Return(network.SecurityGroup{
+					SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+						SecurityRules: &[]network.SecurityRule{},
+					},
+					Etag: to.StringPtr("test-etag"),
+					Name: to.StringPtr("nsg-one"),
+				}, nil)
+				s.RecordDrift("network security group nsg-one is missing security rules first-rule")
+				s.DriftRepairEnabled().Return(false)
+				s.Info(gomock.Any(), gomock.Any())
+			},
 		}, {
 			name: "skipping network security group reconcile in custom VNet mode",
 			expect: func(s *mock_securitygroups.MockNSGScopeMockRecorder, m *mock_securitygroups.MockclientMockRecorder) {
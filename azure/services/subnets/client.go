@@ -70,7 +70,7 @@ func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, vn
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.subnets.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.subnets.Client)
 	if err != nil {
 		return err
 	}
@@ -87,7 +87,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, vnetName,
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.subnets.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.subnets.Client)
 	if err != nil {
 		return err
 	}
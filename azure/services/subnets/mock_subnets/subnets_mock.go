@@ -21,6 +21,7 @@ limitations under the License.
 package mock_subnets
 
 import (
+	context "context"
 	reflect "reflect"
 
 	autorest "github.com/Azure/go-autorest/autorest"
@@ -123,6 +124,20 @@ func (mr *MockSubnetScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockSubnetScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockSubnetScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockSubnetScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockSubnetScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockSubnetScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -193,6 +208,20 @@ func (mr *MockSubnetScopeMockRecorder) CloudProviderConfigOverrides() *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockSubnetScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockSubnetScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockSubnetScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockSubnetScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockSubnetScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -266,6 +295,35 @@ func (mr *MockSubnetScopeMockRecorder) Error(err, msg interface{}, keysAndValues
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockSubnetScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockSubnetScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockSubnetScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockSubnetScope)(nil).FailureDomainZone), arg0)
+}
+
+// GetKeyVaultSecret mocks base method.
+func (m *MockSubnetScope) GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyVaultSecret", ctx, secretURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyVaultSecret indicates an expected call of GetKeyVaultSecret.
+func (mr *MockSubnetScopeMockRecorder) GetKeyVaultSecret(ctx, secretURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyVaultSecret", reflect.TypeOf((*MockSubnetScope)(nil).GetKeyVaultSecret), ctx, secretURL)
+}
+
 // GetPrivateDNSZoneName mocks base method.
 func (m *MockSubnetScope) GetPrivateDNSZoneName() string {
 	m.ctrl.T.Helper()
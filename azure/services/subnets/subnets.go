@@ -19,6 +19,7 @@ package subnets
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -62,6 +63,17 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		case err != nil && !azure.ResourceNotFound(err):
 			return errors.Wrapf(err, "failed to get subnet %s", subnetSpec.Name)
 		case err == nil:
+			// Service endpoints are updated in place, but only in managed vnets, and only once some are listed so that
+			// endpoints managed out-of-band are left untouched. A route table association removed out-of-band is restored
+			// in managed vnets as well.
+			updateServiceEndpoints := len(subnetSpec.ServiceEndpoints) != 0 && !serviceEndpointsUpToDate(existingSubnet.ServiceEndpoints, subnetSpec.ServiceEndpoints)
+			restoreRouteTable := subnetSpec.RouteTableName != "" && existingSubnet.RouteTable.ID == ""
+			if (updateServiceEndpoints || restoreRouteTable) && s.Scope.IsVnetManaged() {
+				if err := s.updateSubnet(ctx, subnetSpec, updateServiceEndpoints, restoreRouteTable); err != nil {
+					return err
+				}
+			}
+
 			// subnet already exists, update the spec and skip creation
 			var subnet infrav1.SubnetSpec
 			if subnetSpec.Role == infrav1.SubnetControlPlane {
@@ -105,6 +117,10 @@ func (s *Service) Reconcile(ctx context.Context) error {
 				}
 			}
 
+			if len(subnetSpec.ServiceEndpoints) != 0 {
+				subnetProperties.ServiceEndpoints = serviceEndpoints(subnetSpec.ServiceEndpoints)
+			}
+
 			s.Scope.V(2).Info("creating subnet in vnet", "subnet", subnetSpec.Name, "vnet", subnetSpec.VNetName)
 			err = s.Client.CreateOrUpdate(
 				ctx,
@@ -167,12 +183,107 @@ func (s *Service) getExisting(ctx context.Context, rgName string, spec azure.Sub
 		addresses = to.StringSlice(subnet.SubnetPropertiesFormat.AddressPrefixes)
 	}
 
+	var endpoints []infrav1.ServiceEndpointSpec
+	if subnet.SubnetPropertiesFormat != nil && subnet.SubnetPropertiesFormat.ServiceEndpoints != nil {
+		for _, endpoint := range *subnet.SubnetPropertiesFormat.ServiceEndpoints {
+			endpoints = append(endpoints, infrav1.ServiceEndpointSpec{
+				Service:   to.String(endpoint.Service),
+				Locations: to.StringSlice(endpoint.Locations),
+			})
+		}
+	}
+
 	subnetSpec := &infrav1.SubnetSpec{
-		Role:       spec.Role,
-		Name:       to.String(subnet.Name),
-		ID:         to.String(subnet.ID),
-		CIDRBlocks: addresses,
+		Role:             spec.Role,
+		Name:             to.String(subnet.Name),
+		ID:               to.String(subnet.ID),
+		CIDRBlocks:       addresses,
+		ServiceEndpoints: endpoints,
+	}
+	if subnet.SubnetPropertiesFormat != nil && subnet.SubnetPropertiesFormat.RouteTable != nil {
+		subnetSpec.RouteTable.ID = to.String(subnet.SubnetPropertiesFormat.RouteTable.ID)
 	}
 
 	return subnetSpec, nil
 }
+
+// updateSubnet replaces the service endpoints of an existing subnet and restores its route table association,
+// keeping its other properties.
+func (s *Service) updateSubnet(ctx context.Context, spec azure.SubnetSpec, updateServiceEndpoints, restoreRouteTable bool) error {
+	ctx, span := tele.Tracer().Start(ctx, "subnets.Service.updateSubnet")
+	defer span.End()
+
+	subnet, err := s.Client.Get(ctx, s.Scope.Vnet().ResourceGroup, spec.VNetName, spec.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch subnet named %s in vnet %s", spec.Name, spec.VNetName)
+	}
+	if subnet.SubnetPropertiesFormat == nil {
+		subnet.SubnetPropertiesFormat = &network.SubnetPropertiesFormat{}
+	}
+	if updateServiceEndpoints {
+		subnet.SubnetPropertiesFormat.ServiceEndpoints = serviceEndpoints(spec.ServiceEndpoints)
+	}
+	if restoreRouteTable {
+		subnet.SubnetPropertiesFormat.RouteTable = &network.RouteTable{
+			ID: to.StringPtr(azure.RouteTableID(s.Scope.SubscriptionID(), s.Scope.ResourceGroup(), spec.RouteTableName)),
+		}
+	}
+
+	s.Scope.V(2).Info("updating subnet in vnet", "subnet", spec.Name, "vnet", spec.VNetName, "serviceEndpoints", updateServiceEndpoints, "routeTable", restoreRouteTable)
+	if err := s.Client.CreateOrUpdate(ctx, s.Scope.Vnet().ResourceGroup, spec.VNetName, spec.Name, subnet); err != nil {
+		return errors.Wrapf(err, "failed to update subnet %s in resource group %s", spec.Name, s.Scope.Vnet().ResourceGroup)
+	}
+	s.Scope.V(2).Info("successfully updated subnet in vnet", "subnet", spec.Name, "vnet", spec.VNetName)
+	return nil
+}
+
+// serviceEndpoints converts service endpoint specs to the service endpoints of an Azure subnet.
+func serviceEndpoints(specs []infrav1.ServiceEndpointSpec) *[]network.ServiceEndpointPropertiesFormat {
+	endpoints := make([]network.ServiceEndpointPropertiesFormat, 0, len(specs))
+	for _, spec := range specs {
+		locations := spec.Locations
+		endpoints = append(endpoints, network.ServiceEndpointPropertiesFormat{
+			Service:   to.StringPtr(spec.Service),
+			Locations: &locations,
+		})
+	}
+	return &endpoints
+}
+
+// serviceEndpointsUpToDate returns true if a subnet has the service endpoints of its spec, and no others. Azure adds
+// the paired region to the locations of some services, so additional existing locations are ignored.
+func serviceEndpointsUpToDate(existing []infrav1.ServiceEndpointSpec, desired []infrav1.ServiceEndpointSpec) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	for _, want := range desired {
+		var found bool
+		for _, have := range existing {
+			if strings.EqualFold(have.Service, want.Service) {
+				found = containsAllLocations(have.Locations, want.Locations)
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAllLocations returns true if all wanted locations are among the given ones.
+func containsAllLocations(locations []string, wanted []string) bool {
+	for _, want := range wanted {
+		var found bool
+		for _, location := range locations {
+			if strings.EqualFold(location, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
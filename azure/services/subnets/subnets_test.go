@@ -266,6 +266,192 @@ func TestReconcileSubnets(t *testing.T) {
 				}).Times(1)
 			},
 		},
+		{
+			name:          "subnet with service endpoints does not exist",
+			expectedError: "",
+			expect: func(s *mock_subnets.MockSubnetScopeMockRecorder, m *mock_subnets.MockClientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.SubnetSpecs().Return([]azure.SubnetSpec{
+					{
+						Name:              "my-subnet",
+						CIDRs:             []string{"10.0.0.0/16"},
+						VNetName:          "my-vnet",
+						RouteTableName:    "my-subnet_route_table",
+						SecurityGroupName: "my-sg",
+						Role:              infrav1.SubnetNode,
+						ServiceEndpoints: []infrav1.ServiceEndpointSpec{
+							{Service: "Microsoft.Storage", Locations: []string{"westeurope"}},
+						},
+					},
+				})
+				s.Vnet().AnyTimes().Return(&infrav1.VnetSpec{Name: "my-vnet"})
+				s.ClusterName().AnyTimes().Return("fake-cluster")
+				s.SubscriptionID().AnyTimes().Return("123")
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.IsVnetManaged().Return(true)
+				m.Get(gomockinternal.AContext(), "", "my-vnet", "my-subnet").
+					Return(network.Subnet{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.CreateOrUpdate(gomockinternal.AContext(), "", "my-vnet", "my-subnet", gomockinternal.DiffEq(network.Subnet{
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						AddressPrefix:        to.StringPtr("10.0.0.0/16"),
+						NetworkSecurityGroup: &network.SecurityGroup{ID: to.StringPtr("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/my-sg")},
+						RouteTable:           &network.RouteTable{ID: to.StringPtr("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/routeTables/my-subnet_route_table")},
+						ServiceEndpoints: &[]network.ServiceEndpointPropertiesFormat{
+							{Service: to.StringPtr("Microsoft.Storage"), Locations: &[]string{"westeurope"}},
+						},
+					},
+				}))
+			},
+		},
+		{
+			name:          "service endpoints of existing subnet are updated",
+			expectedError: "",
+			expect: func(s *mock_subnets.MockSubnetScopeMockRecorder, m *mock_subnets.MockClientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.SubnetSpecs().Return([]azure.SubnetSpec{
+					{
+						Name:              "my-subnet",
+						CIDRs:             []string{"10.0.0.0/16"},
+						VNetName:          "my-vnet",
+						RouteTableName:    "my-subnet_route_table",
+						SecurityGroupName: "my-sg",
+						Role:              infrav1.SubnetNode,
+						ServiceEndpoints: []infrav1.ServiceEndpointSpec{
+							{Service: "Microsoft.Storage", Locations: []string{"westeurope"}},
+							{Service: "Microsoft.KeyVault", Locations: []string{"*"}},
+						},
+					},
+				})
+				s.Vnet().AnyTimes().Return(&infrav1.VnetSpec{Name: "my-vnet"})
+				s.NodeSubnet().AnyTimes().Return(infrav1.SubnetSpec{
+					Name: "my-subnet",
+					Role: infrav1.SubnetNode,
+				})
+				s.IsVnetManaged().Return(true)
+				existing := network.Subnet{
+					ID:   to.StringPtr("subnet-id"),
+					Name: to.StringPtr("my-subnet"),
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						AddressPrefix: to.StringPtr("10.0.0.0/16"),
+						RouteTable:    &network.RouteTable{ID: to.StringPtr("route-table-id")},
+						ServiceEndpoints: &[]network.ServiceEndpointPropertiesFormat{
+							{Service: to.StringPtr("Microsoft.Storage"), Locations: &[]string{"westeurope", "northeurope"}},
+						},
+					},
+				}
+				m.Get(gomockinternal.AContext(), "", "my-vnet", "my-subnet").Return(existing, nil).Times(2)
+				m.CreateOrUpdate(gomockinternal.AContext(), "", "my-vnet", "my-subnet", gomockinternal.DiffEq(network.Subnet{
+					ID:   to.StringPtr("subnet-id"),
+					Name: to.StringPtr("my-subnet"),
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						AddressPrefix: to.StringPtr("10.0.0.0/16"),
+						RouteTable:    &network.RouteTable{ID: to.StringPtr("route-table-id")},
+						ServiceEndpoints: &[]network.ServiceEndpointPropertiesFormat{
+							{Service: to.StringPtr("Microsoft.Storage"), Locations: &[]string{"westeurope"}},
+							{Service: to.StringPtr("Microsoft.KeyVault"), Locations: &[]string{"*"}},
+						},
+					},
+				}))
+				s.SetSubnet(infrav1.SubnetSpec{
+					ID:         "subnet-id",
+					Name:       "my-subnet",
+					Role:       infrav1.SubnetNode,
+					CIDRBlocks: []string{"10.0.0.0/16"},
+				}).Times(1)
+			},
+		},
+		{
+			name:          "service endpoints of existing subnet are up to date",
+			expectedError: "",
+			expect: func(s *mock_subnets.MockSubnetScopeMockRecorder, m *mock_subnets.MockClientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.SubnetSpecs().Return([]azure.SubnetSpec{
+					{
+						Name:              "my-subnet",
+						CIDRs:             []string{"10.0.0.0/16"},
+						VNetName:          "my-vnet",
+						RouteTableName:    "my-subnet_route_table",
+						SecurityGroupName: "my-sg",
+						Role:              infrav1.SubnetNode,
+						ServiceEndpoints: []infrav1.ServiceEndpointSpec{
+							{Service: "Microsoft.Storage", Locations: []string{"westeurope"}},
+						},
+					},
+				})
+				s.Vnet().AnyTimes().Return(&infrav1.VnetSpec{Name: "my-vnet"})
+				s.NodeSubnet().AnyTimes().Return(infrav1.SubnetSpec{
+					Name: "my-subnet",
+					Role: infrav1.SubnetNode,
+				})
+				m.Get(gomockinternal.AContext(), "", "my-vnet", "my-subnet").
+					Return(network.Subnet{
+						ID:   to.StringPtr("subnet-id"),
+						Name: to.StringPtr("my-subnet"),
+						SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+							AddressPrefix: to.StringPtr("10.0.0.0/16"),
+							RouteTable:    &network.RouteTable{ID: to.StringPtr("route-table-id")},
+							ServiceEndpoints: &[]network.ServiceEndpointPropertiesFormat{
+								{Service: to.StringPtr("Microsoft.Storage"), Locations: &[]string{"westeurope", "northeurope"}},
+							},
+						},
+					}, nil)
+				s.SetSubnet(infrav1.SubnetSpec{
+					ID:         "subnet-id",
+					Name:       "my-subnet",
+					Role:       infrav1.SubnetNode,
+					CIDRBlocks: []string{"10.0.0.0/16"},
+				}).Times(1)
+			},
+		},
+		{
+			name:          "route table association of existing subnet is restored",
+			expectedError: "",
+			expect: func(s *mock_subnets.MockSubnetScopeMockRecorder, m *mock_subnets.MockClientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.SubnetSpecs().Return([]azure.SubnetSpec{
+					{
+						Name:              "my-subnet",
+						CIDRs:             []string{"10.0.0.0/16"},
+						VNetName:          "my-vnet",
+						RouteTableName:    "my-subnet_route_table",
+						SecurityGroupName: "my-sg",
+						Role:              infrav1.SubnetNode,
+					},
+				})
+				s.Vnet().AnyTimes().Return(&infrav1.VnetSpec{Name: "my-vnet"})
+				s.SubscriptionID().AnyTimes().Return("123")
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.NodeSubnet().AnyTimes().Return(infrav1.SubnetSpec{
+					Name: "my-subnet",
+					Role: infrav1.SubnetNode,
+				})
+				s.IsVnetManaged().Return(true)
+				existing := network.Subnet{
+					ID:   to.StringPtr("subnet-id"),
+					Name: to.StringPtr("my-subnet"),
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						AddressPrefix:        to.StringPtr("10.0.0.0/16"),
+						NetworkSecurityGroup: &network.SecurityGroup{ID: to.StringPtr("sg-id")},
+					},
+				}
+				m.Get(gomockinternal.AContext(), "", "my-vnet", "my-subnet").Return(existing, nil).Times(2)
+				m.CreateOrUpdate(gomockinternal.AContext(), "", "my-vnet", "my-subnet", gomockinternal.DiffEq(network.Subnet{
+					ID:   to.StringPtr("subnet-id"),
+					Name: to.StringPtr("my-subnet"),
+					SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+						AddressPrefix:        to.StringPtr("10.0.0.0/16"),
+						NetworkSecurityGroup: &network.SecurityGroup{ID: to.StringPtr("sg-id")},
+						RouteTable:           &network.RouteTable{ID: to.StringPtr("/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/routeTables/my-subnet_route_table")},
+					},
+				}))
+				s.SetSubnet(infrav1.SubnetSpec{
+					ID:         "subnet-id",
+					Name:       "my-subnet",
+					Role:       infrav1.SubnetNode,
+					CIDRBlocks: []string{"10.0.0.0/16"},
+				}).Times(1)
+			},
+		},
 		{
 			name:          "vnet for ipv6 is provided",
 			expectedError: "",
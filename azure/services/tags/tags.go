@@ -24,7 +24,9 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/converters"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -51,45 +53,96 @@ func New(scope TagScope) *Service {
 	}
 }
 
-// Reconcile ensures tags are correct.
+// Reconcile ensures tags are correct. The tags of a resource are merged with its existing tags, so that only the
+// tags added, changed or removed since the last applied tags recorded in the annotation of its spec are updated. As
+// several resources can share an annotation, annotations are only updated once the tags of all the resources are.
 func (s *Service) Reconcile(ctx context.Context) error {
 	ctx, span := tele.Tracer().Start(ctx, "tags.Service.Reconcile")
 	defer span.End()
 
+	var updated []string
+	newAnnotations := map[string]map[string]interface{}{}
 	for _, tagsSpec := range s.Scope.TagsSpecs() {
 		annotation, err := s.Scope.AnnotationJSON(tagsSpec.Annotation)
 		if err != nil {
 			return err
 		}
 		changed, created, deleted, newAnnotation := tagsChanged(annotation, tagsSpec.Tags)
-		if changed {
-			s.Scope.V(2).Info("Updating tags")
-			result, err := s.client.GetAtScope(ctx, tagsSpec.Scope)
-			if err != nil {
-				return errors.Wrap(err, "failed to get existing tags")
-			}
-			tags := make(map[string]*string)
-			if result.Properties != nil && result.Properties.Tags != nil {
-				tags = result.Properties.Tags
-			}
-			for k, v := range created {
-				tags[k] = to.StringPtr(v)
-			}
-
-			for k := range deleted {
-				delete(tags, k)
-			}
-
-			if _, err := s.client.CreateOrUpdateAtScope(ctx, tagsSpec.Scope, resources.TagsResource{Properties: &resources.Tags{Tags: tags}}); err != nil {
-				return errors.Wrap(err, "cannot update tags")
-			}
-
-			// We also need to update the annotation if anything changed.
-			if err = s.Scope.UpdateAnnotationJSON(tagsSpec.Annotation, newAnnotation); err != nil {
-				return err
-			}
-			s.Scope.V(2).Info("successfully updated tags")
+		if !changed {
+			continue
+		}
+		if _, ok := newAnnotations[tagsSpec.Annotation]; !ok {
+			updated = append(updated, tagsSpec.Annotation)
+		}
+		newAnnotations[tagsSpec.Annotation] = newAnnotation
+
+		s.Scope.V(2).Info("Updating tags", "scope", tagsSpec.Scope)
+		result, err := s.client.GetAtScope(ctx, tagsSpec.Scope)
+		if azure.ResourceNotFound(err) {
+			// The resource doesn't exist yet, its service creates it with its tags.
+			s.Scope.V(4).Info("skipping tags of missing resource", "scope", tagsSpec.Scope)
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "failed to get existing tags")
+		}
+		tags := make(map[string]*string)
+		if result.Properties != nil && result.Properties.Tags != nil {
+			tags = result.Properties.Tags
+		}
+		if tagsSpec.OnlyIfOwned && !converters.MapToTags(tags).HasOwned(s.Scope.ClusterName()) {
+			s.Scope.V(4).Info("skipping tags of resource not owned by the cluster", "scope", tagsSpec.Scope)
+			continue
+		}
+		for k, v := range created {
+			tags[k] = to.StringPtr(v)
+		}
+
+		for k := range deleted {
+			delete(tags, k)
+		}
+
+		if _, err := s.client.CreateOrUpdateAtScope(ctx, tagsSpec.Scope, resources.TagsResource{Properties: &resources.Tags{Tags: tags}}); err != nil {
+			return errors.Wrap(err, "cannot update tags")
+		}
+		s.Scope.V(2).Info("successfully updated tags", "scope", tagsSpec.Scope)
+	}
+
+	// We also need to update the annotations if anything changed.
+	for _, annotation := range updated {
+		if err := s.Scope.UpdateAnnotationJSON(annotation, newAnnotations[annotation]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkRetained tags the resources in the given scopes as retained, keeping their other tags, so that they are no
+// longer purged as orphaned resources of the cluster once their AzureMachine is deleted. Missing resources are skipped.
+func (s *Service) MarkRetained(ctx context.Context, scopes []string) error {
+	ctx, span := tele.Tracer().Start(ctx, "tags.Service.MarkRetained")
+	defer span.End()
+
+	for _, scope := range scopes {
+		result, err := s.client.GetAtScope(ctx, scope)
+		if azure.ResourceNotFound(err) {
+			s.Scope.V(4).Info("skipping retained tag of missing resource", "scope", scope)
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "failed to get existing tags")
+		}
+		tags := make(map[string]*string)
+		if result.Properties != nil && result.Properties.Tags != nil {
+			tags = result.Properties.Tags
+		}
+		if _, ok := tags[infrav1.NameAzureProviderRetained]; ok {
+			continue
+		}
+		tags[infrav1.NameAzureProviderRetained] = to.StringPtr("true")
+
+		if _, err := s.client.CreateOrUpdateAtScope(ctx, scope, resources.TagsResource{Properties: &resources.Tags{Tags: tags}}); err != nil {
+			return errors.Wrap(err, "cannot update tags")
 		}
+		s.Scope.V(2).Info("successfully tagged resource as retained", "scope", scope)
 	}
 	return nil
 }
@@ -28,6 +28,7 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/klog/v2/klogr"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags/mock_tags"
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
@@ -128,6 +129,54 @@ func TestReconcileTags(t *testing.T) {
 				}).Return(resources.TagsResource{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 500}, "Internal Server Error"))
 			},
 		},
+		{
+			name:          "shared annotation updated once all resources are tagged",
+			expectedError: "",
+			expect: func(s *mock_tags.MockTagScopeMockRecorder, m *mock_tags.MockclientMockRecorder) {
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.ClusterName().AnyTimes().Return("my-cluster")
+				s.TagsSpecs().Return([]azure.TagsSpec{
+					{
+						Scope:       "/sub/123/resourceGroups/my-rg",
+						Tags:        map[string]string{"foo": "baz"},
+						Annotation:  "my-annotation",
+						OnlyIfOwned: true,
+					},
+					{
+						Scope:      "/sub/123/missing/scope",
+						Tags:       map[string]string{"foo": "baz"},
+						Annotation: "my-annotation",
+					},
+					{
+						Scope:      "/sub/123/fake/scope",
+						Tags:       map[string]string{"foo": "baz"},
+						Annotation: "my-annotation",
+					},
+				})
+				s.AnnotationJSON("my-annotation").Times(3).Return(map[string]interface{}{"foo": "bar", "removed": "tag"}, nil)
+				// The resource group isn't owned by the cluster, so its tags are left untouched.
+				m.GetAtScope(gomockinternal.AContext(), "/sub/123/resourceGroups/my-rg").Return(resources.TagsResource{
+					Properties: &resources.Tags{Tags: map[string]*string{"removed": to.StringPtr("tag")}},
+				}, nil)
+				m.GetAtScope(gomockinternal.AContext(), "/sub/123/missing/scope").Return(resources.TagsResource{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				m.GetAtScope(gomockinternal.AContext(), "/sub/123/fake/scope").Return(resources.TagsResource{
+					Properties: &resources.Tags{Tags: map[string]*string{
+						"foo":     to.StringPtr("bar"),
+						"removed": to.StringPtr("tag"),
+						"other":   to.StringPtr("value"),
+					}},
+				}, nil)
+				m.CreateOrUpdateAtScope(gomockinternal.AContext(), "/sub/123/fake/scope", resources.TagsResource{
+					Properties: &resources.Tags{
+						Tags: map[string]*string{
+							"foo":   to.StringPtr("baz"),
+							"other": to.StringPtr("value"),
+						},
+					},
+				})
+				s.UpdateAnnotationJSON("my-annotation", map[string]interface{}{"foo": "baz"}).Times(1)
+			},
+		},
 		{
 			name:          "tags unchanged",
 			expectedError: "",
@@ -175,6 +224,32 @@ func TestReconcileTags(t *testing.T) {
 	}
 }
 
+func TestMarkRetained(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	scopeMock := mock_tags.NewMockTagScope(mockCtrl)
+	clientMock := mock_tags.NewMockclient(mockCtrl)
+
+	scopeMock.EXPECT().V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+	clientMock.EXPECT().GetAtScope(gomockinternal.AContext(), "/sub/123/vm").Return(resources.TagsResource{Properties: &resources.Tags{
+		Tags: map[string]*string{"foo": to.StringPtr("bar")},
+	}}, nil)
+	clientMock.EXPECT().CreateOrUpdateAtScope(gomockinternal.AContext(), "/sub/123/vm", resources.TagsResource{Properties: &resources.Tags{
+		Tags: map[string]*string{"foo": to.StringPtr("bar"), infrav1.NameAzureProviderRetained: to.StringPtr("true")},
+	}})
+	clientMock.EXPECT().GetAtScope(gomockinternal.AContext(), "/sub/123/nic").Return(resources.TagsResource{Properties: &resources.Tags{
+		Tags: map[string]*string{infrav1.NameAzureProviderRetained: to.StringPtr("true")},
+	}}, nil)
+	clientMock.EXPECT().GetAtScope(gomockinternal.AContext(), "/sub/123/ip").Return(resources.TagsResource{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: http.StatusNotFound}, "Not Found"))
+
+	s := &Service{
+		Scope:  scopeMock,
+		client: clientMock,
+	}
+	g.Expect(s.MarkRetained(context.TODO(), []string{"/sub/123/vm", "/sub/123/nic", "/sub/123/ip"})).To(Succeed())
+}
+
 func TestTagsChanged(t *testing.T) {
 	g := NewWithT(t)
 
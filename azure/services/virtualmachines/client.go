@@ -18,6 +18,7 @@ package virtualmachines
 
 import (
 	"context"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	"github.com/Azure/go-autorest/autorest"
@@ -32,6 +33,7 @@ type Client interface {
 	Get(context.Context, string, string) (compute.VirtualMachine, error)
 	CreateOrUpdate(context.Context, string, string, compute.VirtualMachine) error
 	Delete(context.Context, string, string) error
+	RetrieveBootDiagnosticsData(context.Context, string, string, time.Duration) (compute.RetrieveBootDiagnosticsDataResult, error)
 }
 
 // AzureClient contains the Azure go-sdk Client.
@@ -71,7 +73,7 @@ func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, vm
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.virtualmachines.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.virtualmachines.Client)
 	if err != nil {
 		return err
 	}
@@ -90,10 +92,19 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, vmName str
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.virtualmachines.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.virtualmachines.Client)
 	if err != nil {
 		return err
 	}
 	_, err = future.Result(ac.virtualmachines)
 	return err
 }
+
+// RetrieveBootDiagnosticsData returns SAS URIs of the serial log and the screenshot of a virtual machine, which expire
+// after the given duration.
+func (ac *AzureClient) RetrieveBootDiagnosticsData(ctx context.Context, resourceGroupName, vmName string, expiration time.Duration) (compute.RetrieveBootDiagnosticsDataResult, error) {
+	ctx, span := tele.Tracer().Start(ctx, "virtualmachines.AzureClient.RetrieveBootDiagnosticsData")
+	defer span.End()
+
+	return ac.virtualmachines.RetrieveBootDiagnosticsData(ctx, resourceGroupName, vmName, to.Int32Ptr(int32(expiration.Minutes())))
+}
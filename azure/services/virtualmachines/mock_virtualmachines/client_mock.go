@@ -23,6 +23,7 @@ package mock_virtualmachines
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	compute "github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	gomock "github.com/golang/mock/gomock"
@@ -93,3 +94,18 @@ func (mr *MockClientMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), arg0, arg1, arg2)
 }
+
+// RetrieveBootDiagnosticsData mocks base method.
+func (m *MockClient) RetrieveBootDiagnosticsData(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) (compute.RetrieveBootDiagnosticsDataResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveBootDiagnosticsData", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(compute.RetrieveBootDiagnosticsDataResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveBootDiagnosticsData indicates an expected call of RetrieveBootDiagnosticsData.
+func (mr *MockClientMockRecorder) RetrieveBootDiagnosticsData(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveBootDiagnosticsData", reflect.TypeOf((*MockClient)(nil).RetrieveBootDiagnosticsData), arg0, arg1, arg2, arg3)
+}
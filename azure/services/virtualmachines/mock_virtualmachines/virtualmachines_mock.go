@@ -23,6 +23,7 @@ package mock_virtualmachines
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	autorest "github.com/Azure/go-autorest/autorest"
 	logr "github.com/go-logr/logr"
@@ -112,6 +113,20 @@ func (mr *MockVMScopeMockRecorder) AvailabilitySetEnabled() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailabilitySetEnabled", reflect.TypeOf((*MockVMScope)(nil).AvailabilitySetEnabled))
 }
 
+// AzureResourceNaming mocks base method.
+func (m *MockVMScope) AzureResourceNaming() *v1alpha4.AzureResourceNaming {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AzureResourceNaming")
+	ret0, _ := ret[0].(*v1alpha4.AzureResourceNaming)
+	return ret0
+}
+
+// AzureResourceNaming indicates an expected call of AzureResourceNaming.
+func (mr *MockVMScopeMockRecorder) AzureResourceNaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AzureResourceNaming", reflect.TypeOf((*MockVMScope)(nil).AzureResourceNaming))
+}
+
 // BaseURI mocks base method.
 func (m *MockVMScope) BaseURI() string {
 	m.ctrl.T.Helper()
@@ -126,6 +141,20 @@ func (mr *MockVMScopeMockRecorder) BaseURI() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BaseURI", reflect.TypeOf((*MockVMScope)(nil).BaseURI))
 }
 
+// BootDiagnosticsRequested mocks base method.
+func (m *MockVMScope) BootDiagnosticsRequested() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BootDiagnosticsRequested")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// BootDiagnosticsRequested indicates an expected call of BootDiagnosticsRequested.
+func (mr *MockVMScopeMockRecorder) BootDiagnosticsRequested() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootDiagnosticsRequested", reflect.TypeOf((*MockVMScope)(nil).BootDiagnosticsRequested))
+}
+
 // ClientID mocks base method.
 func (m *MockVMScope) ClientID() string {
 	m.ctrl.T.Helper()
@@ -182,6 +211,20 @@ func (mr *MockVMScopeMockRecorder) CloudProviderConfigOverrides() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderConfigOverrides", reflect.TypeOf((*MockVMScope)(nil).CloudProviderConfigOverrides))
 }
 
+// CloudProviderIdentityID mocks base method.
+func (m *MockVMScope) CloudProviderIdentityID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloudProviderIdentityID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CloudProviderIdentityID indicates an expected call of CloudProviderIdentityID.
+func (mr *MockVMScopeMockRecorder) CloudProviderIdentityID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloudProviderIdentityID", reflect.TypeOf((*MockVMScope)(nil).CloudProviderIdentityID))
+}
+
 // ClusterName mocks base method.
 func (m *MockVMScope) ClusterName() string {
 	m.ctrl.T.Helper()
@@ -227,6 +270,20 @@ func (mr *MockVMScopeMockRecorder) Error(err, msg interface{}, keysAndValues ...
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockVMScope)(nil).Error), varargs...)
 }
 
+// FailureDomainZone mocks base method.
+func (m *MockVMScope) FailureDomainZone(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailureDomainZone", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FailureDomainZone indicates an expected call of FailureDomainZone.
+func (mr *MockVMScopeMockRecorder) FailureDomainZone(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailureDomainZone", reflect.TypeOf((*MockVMScope)(nil).FailureDomainZone), arg0)
+}
+
 // GetBootstrapData mocks base method.
 func (m *MockVMScope) GetBootstrapData(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
@@ -242,6 +299,21 @@ func (mr *MockVMScopeMockRecorder) GetBootstrapData(ctx interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBootstrapData", reflect.TypeOf((*MockVMScope)(nil).GetBootstrapData), ctx)
 }
 
+// GetKeyVaultSecret mocks base method.
+func (m *MockVMScope) GetKeyVaultSecret(ctx context.Context, secretURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyVaultSecret", ctx, secretURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyVaultSecret indicates an expected call of GetKeyVaultSecret.
+func (mr *MockVMScopeMockRecorder) GetKeyVaultSecret(ctx, secretURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyVaultSecret", reflect.TypeOf((*MockVMScope)(nil).GetKeyVaultSecret), ctx, secretURL)
+}
+
 // GetVMImage mocks base method.
 func (m *MockVMScope) GetVMImage() (*v1alpha4.Image, error) {
 	m.ctrl.T.Helper()
@@ -354,6 +426,18 @@ func (mr *MockVMScopeMockRecorder) SetAnnotation(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAnnotation", reflect.TypeOf((*MockVMScope)(nil).SetAnnotation), arg0, arg1)
 }
 
+// SetBootDiagnostics mocks base method.
+func (m *MockVMScope) SetBootDiagnostics(serialConsoleLogURL, screenshotURL string, expiration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetBootDiagnostics", serialConsoleLogURL, screenshotURL, expiration)
+}
+
+// SetBootDiagnostics indicates an expected call of SetBootDiagnostics.
+func (mr *MockVMScopeMockRecorder) SetBootDiagnostics(serialConsoleLogURL, screenshotURL, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBootDiagnostics", reflect.TypeOf((*MockVMScope)(nil).SetBootDiagnostics), serialConsoleLogURL, screenshotURL, expiration)
+}
+
 // SetProviderID mocks base method.
 func (m *MockVMScope) SetProviderID(arg0 string) {
 	m.ctrl.T.Helper()
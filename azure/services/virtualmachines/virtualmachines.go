@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -39,10 +40,14 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
+// bootDiagnosticsSASExpiration is how long the SAS URLs of the boot diagnostics of a VM are valid.
+const bootDiagnosticsSASExpiration = 15 * time.Minute
+
 // VMScope defines the scope interface for a virtual machines service.
 type VMScope interface {
 	logr.Logger
 	azure.ClusterDescriber
+	azure.KeyVaultSecretGetter
 	VMSpec() azure.VMSpec
 	GetBootstrapData(ctx context.Context) (string, error)
 	GetVMImage() (*infrav1.Image, error)
@@ -53,6 +58,8 @@ type VMScope interface {
 	SetAddresses([]corev1.NodeAddress)
 	SetVMState(infrav1.ProvisioningState)
 	UpdateStatus()
+	BootDiagnosticsRequested() bool
+	SetBootDiagnostics(serialConsoleLogURL, screenshotURL string, expiration time.Duration)
 }
 
 // Service provides operations on Azure resources.
@@ -99,6 +106,12 @@ func (s *Service) Reconcile(ctx context.Context) error {
 		s.Scope.SetAddresses(existingVM.Addresses)
 		s.Scope.SetVMState(existingVM.State)
 		s.Scope.UpdateStatus()
+
+		if s.Scope.BootDiagnosticsRequested() {
+			if err := s.retrieveBootDiagnostics(ctx, vmSpec.Name); err != nil {
+				return err
+			}
+		}
 	default:
 		s.Scope.V(2).Info("creating VM", "vm", vmSpec.Name)
 		sku, err := s.resourceSKUCache.Get(ctx, vmSpec.Size, resourceskus.VirtualMachines)
@@ -203,6 +216,20 @@ func (s *Service) Reconcile(ctx context.Context) error {
 	return nil
 }
 
+// retrieveBootDiagnostics passes short-lived SAS URLs of the boot diagnostics of a VM to the scope, so operators can
+// debug boot failures without access to the storage account.
+func (s *Service) retrieveBootDiagnostics(ctx context.Context, name string) error {
+	ctx, span := tele.Tracer().Start(ctx, "virtualmachines.Service.retrieveBootDiagnostics")
+	defer span.End()
+
+	result, err := s.Client.RetrieveBootDiagnosticsData(ctx, s.Scope.ResourceGroup(), name, bootDiagnosticsSASExpiration)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve boot diagnostics of VM %s", name)
+	}
+	s.Scope.SetBootDiagnostics(to.String(result.SerialConsoleLogBlobURI), to.String(result.ConsoleScreenshotBlobURI), bootDiagnosticsSASExpiration)
+	return nil
+}
+
 // Delete deletes the virtual machine with the provided name.
 func (s *Service) Delete(ctx context.Context) error {
 	ctx, span := tele.Tracer().Start(ctx, "virtualmachines.Service.Delete")
@@ -346,7 +373,7 @@ func (s *Service) generateStorageProfile(ctx context.Context, vmSpec azure.VMSpe
 
 	storageProfile := &compute.StorageProfile{
 		OsDisk: &compute.OSDisk{
-			Name:         to.StringPtr(azure.GenerateOSDiskName(vmSpec.Name)),
+			Name:         to.StringPtr(vmSpec.OSDiskName),
 			OsType:       compute.OperatingSystemTypes(vmSpec.OSDisk.OSType),
 			CreateOption: compute.DiskCreateOptionTypesFromImage,
 			DiskSizeGB:   vmSpec.OSDisk.DiskSizeGB,
@@ -440,9 +467,9 @@ func getResourceNameByID(resourceID string) string {
 }
 
 func (s *Service) generateOSProfile(ctx context.Context, vmSpec azure.VMSpec) (*compute.OSProfile, error) {
-	sshKey, err := base64.StdEncoding.DecodeString(vmSpec.SSHKeyData)
+	sshKey, err := s.getSSHPublicKey(ctx, vmSpec.SSHKeyData, vmSpec.SSHKeySecretURL)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode ssh public key")
+		return nil, err
 	}
 	bootstrapData, err := s.Scope.GetBootstrapData(ctx)
 	if err != nil {
@@ -464,7 +491,15 @@ func (s *Service) generateOSProfile(ctx context.Context, vmSpec azure.VMSpec) (*
 		// but the password on the VM will NOT be the same as created here.
 		// Access is provided via SSH public key that is set during deployment
 		// Azure also provides a way to reset user passwords in the case of need.
-		osProfile.AdminPassword = to.StringPtr(generators.SudoRandomPassword(123))
+		// A password may also be referenced in Key Vault, so it is known to the owner of the vault.
+		adminPassword := generators.SudoRandomPassword(123)
+		if vmSpec.AdminPasswordSecretURL != "" {
+			adminPassword, err = s.Scope.GetKeyVaultSecret(ctx, vmSpec.AdminPasswordSecretURL)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to retrieve admin password from Key Vault")
+			}
+		}
+		osProfile.AdminPassword = to.StringPtr(adminPassword)
 		osProfile.WindowsConfiguration = &compute.WindowsConfiguration{
 			EnableAutomaticUpdates: to.BoolPtr(false),
 		}
@@ -475,7 +510,7 @@ func (s *Service) generateOSProfile(ctx context.Context, vmSpec azure.VMSpec) (*
 				PublicKeys: &[]compute.SSHPublicKey{
 					{
 						Path:    to.StringPtr(fmt.Sprintf("/home/%s/.ssh/authorized_keys", azure.DefaultUserName)),
-						KeyData: to.StringPtr(string(sshKey)),
+						KeyData: to.StringPtr(sshKey),
 					},
 				},
 			},
@@ -498,3 +533,20 @@ func getSecurityProfile(vmSpec azure.VMSpec, sku resourceskus.SKU) (*compute.Sec
 		EncryptionAtHost: to.BoolPtr(*vmSpec.SecurityProfile.EncryptionAtHost),
 	}, nil
 }
+
+// getSSHPublicKey returns the SSH public key in OpenSSH format, either decoded from the base64 encoded key data or
+// read from Key Vault.
+func (s *Service) getSSHPublicKey(ctx context.Context, sshKeyData, secretURL string) (string, error) {
+	if secretURL != "" {
+		sshKey, err := s.Scope.GetKeyVaultSecret(ctx, secretURL)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retrieve ssh public key from Key Vault")
+		}
+		return sshKey, nil
+	}
+	sshKey, err := base64.StdEncoding.DecodeString(sshKeyData)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode ssh public key")
+	}
+	return string(sshKey), nil
+}
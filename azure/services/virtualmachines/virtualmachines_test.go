@@ -20,6 +20,7 @@ import (
 	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
@@ -290,6 +291,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -484,6 +486,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:                   "my-vm",
 					Role:                   infrav1.Node,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "1",
@@ -555,6 +558,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:                   "my-vm",
 					Role:                   infrav1.Node,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "1",
@@ -626,6 +630,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:                   "my-vm",
 					Role:                   infrav1.Node,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "1",
@@ -699,6 +704,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -778,6 +784,97 @@ func TestReconcileVM(t *testing.T) {
 				svc.resourceSKUCache = resourceSkusCache
 			},
 		},
+		{
+			Name: "can create a windows vm with an ssh public key and admin password from key vault",
+			Expect: func(g *WithT, s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder, mnic *mock_networkinterfaces.MockClientMockRecorder, mpip *mock_publicips.MockClientMockRecorder) {
+				s.VMSpec().Return(azure.VMSpec{
+
+					Name:                   "my-vm",
+					Role:                   infrav1.ControlPlane,
+					NICNames:               []string{"my-nic", "second-nic"},
+					OSDiskName:             "my-vm_OSDisk",
+					SSHKeySecretURL:        "https://my-vault.vault.azure.net/secrets/ssh-key",
+					AdminPasswordSecretURL: "https://my-vault.vault.azure.net/secrets/admin-password",
+					Size:                   "Standard_D2v3",
+					Zone:                   "1",
+					Identity:               infrav1.VMIdentityNone,
+					OSDisk: infrav1.OSDisk{
+						OSType:     "Windows",
+						DiskSizeGB: to.Int32Ptr(128),
+						ManagedDisk: &infrav1.ManagedDiskParameters{
+							StorageAccountType: "Premium_LRS",
+						},
+					},
+					DataDisks: []infrav1.DataDisk{
+						{
+							NameSuffix: "mydisk",
+							DiskSizeGB: 64,
+							Lun:        to.Int32Ptr(0),
+						},
+					},
+					UserAssignedIdentities: nil,
+					SpotVMOptions:          nil,
+				},
+				)
+				s.SubscriptionID().AnyTimes().Return("123")
+				s.ResourceGroup().AnyTimes().Return("my-rg")
+				s.V(gomock.AssignableToTypeOf(2)).AnyTimes().Return(klogr.New())
+				s.AdditionalTags()
+				s.Location().Return("test-location")
+				s.ClusterName().Return("my-cluster")
+				s.ProviderID().Return("")
+				m.Get(gomockinternal.AContext(), "my-rg", "my-vm").
+					Return(compute.VirtualMachine{}, autorest.NewErrorWithResponse("", "", &http.Response{StatusCode: 404}, "Not found"))
+				s.GetVMImage().AnyTimes().Return(&infrav1.Image{
+					Marketplace: &infrav1.AzureMarketplaceImage{
+						Publisher: "fake-publisher",
+						Offer:     "my-offer",
+						SKU:       "sku-id",
+						Version:   "1.0",
+					},
+				}, nil)
+				s.GetKeyVaultSecret(gomockinternal.AContext(), "https://my-vault.vault.azure.net/secrets/ssh-key").Return("fakesshkey", nil)
+				s.GetKeyVaultSecret(gomockinternal.AContext(), "https://my-vault.vault.azure.net/secrets/admin-password").Return("fake-admin-password", nil)
+				s.GetBootstrapData(gomockinternal.AContext()).Return("fake-bootstrap-data", nil)
+				s.AvailabilitySet().Return("", false)
+				m.CreateOrUpdate(gomockinternal.AContext(), "my-rg", "my-vm", gomock.AssignableToTypeOf(compute.VirtualMachine{})).Do(func(_, _, _ interface{}, vm compute.VirtualMachine) {
+					g.Expect(vm.VirtualMachineProperties.StorageProfile.OsDisk.OsType).To(Equal(compute.Windows))
+					g.Expect(*vm.VirtualMachineProperties.OsProfile.AdminPassword).Should(Equal("fake-admin-password"))
+					g.Expect(*vm.VirtualMachineProperties.OsProfile.AdminUsername).Should(Equal("capi"))
+					g.Expect(*vm.VirtualMachineProperties.OsProfile.WindowsConfiguration.EnableAutomaticUpdates).Should(Equal(false))
+				})
+			},
+			ExpectedError: "",
+			SetupSKUs: func(svc *Service) {
+				skus := []compute.ResourceSku{
+					{
+						Name: to.StringPtr("Standard_D2v3"),
+						Kind: to.StringPtr(string(resourceskus.VirtualMachines)),
+						Locations: &[]string{
+							"test-location",
+						},
+						LocationInfo: &[]compute.ResourceSkuLocationInfo{
+							{
+								Location: to.StringPtr("test-location"),
+								Zones:    &[]string{"1"},
+							},
+						},
+						Capabilities: &[]compute.ResourceSkuCapabilities{
+							{
+								Name:  to.StringPtr(resourceskus.VCPUs),
+								Value: to.StringPtr("2"),
+							},
+							{
+								Name:  to.StringPtr(resourceskus.MemoryGB),
+								Value: to.StringPtr("4"),
+							},
+						},
+					},
+				}
+				resourceSkusCache := resourceskus.NewStaticCache(skus, "")
+				svc.resourceSKUCache = resourceSkusCache
+			},
+		},
 		{
 			Name: "can create a vm with encryption",
 			Expect: func(g *WithT, s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder, mnic *mock_networkinterfaces.MockClientMockRecorder, mpip *mock_publicips.MockClientMockRecorder) {
@@ -785,6 +882,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.Node,
 					NICNames:   []string{"my-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "fakesshpublickey",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -861,6 +959,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:            "my-vm",
 					Role:            infrav1.Node,
 					NICNames:        []string{"my-nic"},
+					OSDiskName:      "my-vm_OSDisk",
 					SSHKeyData:      "fakesshpublickey",
 					Size:            "Standard_D2v3",
 					Zone:            "1",
@@ -932,6 +1031,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "",
@@ -1088,6 +1188,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:            "my-vm",
 					Role:            infrav1.Node,
 					NICNames:        []string{"my-nic"},
+					OSDiskName:      "my-vm_OSDisk",
 					SSHKeyData:      "fakesshpublickey",
 					Size:            "Standard_D2v3",
 					OSDisk:          infrav1.OSDisk{},
@@ -1145,6 +1246,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:                   "my-vm",
 					Role:                   infrav1.ControlPlane,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "1",
@@ -1213,6 +1315,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D1v3",
 					Zone:       "1",
@@ -1279,6 +1382,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -1345,6 +1449,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -1418,6 +1523,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -1582,6 +1688,7 @@ func TestReconcileVM(t *testing.T) {
 					Name:       "my-vm",
 					Role:       infrav1.ControlPlane,
 					NICNames:   []string{"my-nic", "second-nic"},
+					OSDiskName: "my-vm_OSDisk",
 					SSHKeyData: "ZmFrZXNzaGtleQo=",
 					Size:       "Standard_D2v3",
 					Zone:       "1",
@@ -1792,6 +1899,85 @@ func TestReconcileVM(t *testing.T) {
 	}
 }
 
+func TestReconcileVMBootDiagnostics(t *testing.T) {
+	existingVM := compute.VirtualMachine{
+		ID:   to.StringPtr("my-id"),
+		Name: to.StringPtr("my-vm"),
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			ProvisioningState: to.StringPtr("Failed"),
+			NetworkProfile:    &compute.NetworkProfile{},
+		},
+	}
+
+	testcases := []struct {
+		name          string
+		expect        func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder)
+		expectedError string
+	}{
+		{
+			name: "boot diagnostics not requested",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder) {
+				s.BootDiagnosticsRequested().Return(false)
+			},
+		},
+		{
+			name: "records sas urls of boot diagnostics when requested",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder) {
+				s.BootDiagnosticsRequested().Return(true)
+				m.RetrieveBootDiagnosticsData(gomockinternal.AContext(), "my-rg", "my-vm", 15*time.Minute).Return(compute.RetrieveBootDiagnosticsDataResult{
+					SerialConsoleLogBlobURI:  to.StringPtr("https://storage/serial.log?sig=abc"),
+					ConsoleScreenshotBlobURI: to.StringPtr("https://storage/screenshot.bmp?sig=abc"),
+				}, nil)
+				s.SetBootDiagnostics("https://storage/serial.log?sig=abc", "https://storage/screenshot.bmp?sig=abc", 15*time.Minute)
+			},
+		},
+		{
+			name: "fails when boot diagnostics cannot be retrieved",
+			expect: func(s *mock_virtualmachines.MockVMScopeMockRecorder, m *mock_virtualmachines.MockClientMockRecorder) {
+				s.BootDiagnosticsRequested().Return(true)
+				m.RetrieveBootDiagnosticsData(gomockinternal.AContext(), "my-rg", "my-vm", 15*time.Minute).
+					Return(compute.RetrieveBootDiagnosticsDataResult{}, autorest.NewError("", "", "Internal Server Error"))
+			},
+			expectedError: "failed to retrieve boot diagnostics of VM my-vm: #: Internal Server Error: StatusCode=0",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			t.Parallel()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			scopeMock := mock_virtualmachines.NewMockVMScope(mockCtrl)
+			clientMock := mock_virtualmachines.NewMockClient(mockCtrl)
+
+			scopeMock.EXPECT().VMSpec().Return(azure.VMSpec{Name: "my-vm"})
+			scopeMock.EXPECT().ResourceGroup().AnyTimes().Return("my-rg")
+			scopeMock.EXPECT().SetProviderID("azure://my-id")
+			scopeMock.EXPECT().SetAnnotation("cluster-api-provider-azure", "true")
+			scopeMock.EXPECT().SetAddresses([]corev1.NodeAddress{})
+			scopeMock.EXPECT().SetVMState(infrav1.Failed)
+			scopeMock.EXPECT().UpdateStatus()
+			clientMock.EXPECT().Get(gomockinternal.AContext(), "my-rg", "my-vm").Return(existingVM, nil)
+			tc.expect(scopeMock.EXPECT(), clientMock.EXPECT())
+
+			s := &Service{
+				Scope:  scopeMock,
+				Client: clientMock,
+			}
+
+			err := s.Reconcile(context.TODO())
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
 func TestDeleteVM(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -1806,6 +1992,7 @@ func TestDeleteVM(t *testing.T) {
 					Name:                   "my-existing-vm",
 					Role:                   infrav1.ControlPlane,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-existing-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "",
@@ -1828,6 +2015,7 @@ func TestDeleteVM(t *testing.T) {
 					Name:                   "my-vm",
 					Role:                   infrav1.ControlPlane,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "",
@@ -1851,6 +2039,7 @@ func TestDeleteVM(t *testing.T) {
 					Name:                   "my-vm",
 					Role:                   infrav1.ControlPlane,
 					NICNames:               []string{"my-nic"},
+					OSDiskName:             "my-vm_OSDisk",
 					SSHKeyData:             "fakesshpublickey",
 					Size:                   "Standard_D2v3",
 					Zone:                   "",
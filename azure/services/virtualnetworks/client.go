@@ -73,7 +73,7 @@ func (ac *AzureClient) CreateOrUpdate(ctx context.Context, resourceGroupName, vn
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.virtualnetworks.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.virtualnetworks.Client)
 	if err != nil {
 		return err
 	}
@@ -90,7 +90,7 @@ func (ac *AzureClient) Delete(ctx context.Context, resourceGroupName, vnetName s
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.virtualnetworks.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.virtualnetworks.Client)
 	if err != nil {
 		return err
 	}
@@ -78,7 +78,7 @@ func (ac *azureClient) Delete(ctx context.Context, resourceGroupName, vmName, na
 	if err != nil {
 		return err
 	}
-	err = future.WaitForCompletionRef(ctx, ac.vmextensions.Client)
+	err = azure.WaitForCompletion(ctx, &future, ac.vmextensions.Client)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+// ServiceStatuses records the observed state of the Azure resources of the services of an object, from the responses
+// of Azure Resource Manager to the requests each service made while reconciling it.
+type ServiceStatuses struct {
+	lock sync.Mutex
+	// services are the statuses of the services, by service name.
+	services map[string]*infrav1.ServiceStatus
+}
+
+// NewServiceStatuses returns statuses starting from the existing ones of an object.
+func NewServiceStatuses(existing []infrav1.ServiceStatus) *ServiceStatuses {
+	s := &ServiceStatuses{services: make(map[string]*infrav1.ServiceStatus, len(existing))}
+	for _, status := range existing {
+		status := status.DeepCopy()
+		s.services[status.Service] = status
+	}
+	return s
+}
+
+type serviceStatusesKey struct{}
+
+// WithServiceStatuses returns a context in which the responses to the requests made for a service update statuses.
+func WithServiceStatuses(ctx context.Context, statuses *ServiceStatuses) context.Context {
+	return context.WithValue(ctx, serviceStatusesKey{}, statuses)
+}
+
+// serviceStatusesFrom returns the service statuses of a context, if any.
+func serviceStatusesFrom(ctx context.Context) *ServiceStatuses {
+	statuses, _ := ctx.Value(serviceStatusesKey{}).(*ServiceStatuses)
+	return statuses
+}
+
+type serviceNameKey struct{}
+
+// WithServiceName returns a context in which requests are made for the service of the given name, e.g.
+// "loadbalancers".
+func WithServiceName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, serviceNameKey{}, name)
+}
+
+// serviceNameFrom returns the name of the service of a context, or an empty string if it has none.
+func serviceNameFrom(ctx context.Context) string {
+	name, _ := ctx.Value(serviceNameKey{}).(string)
+	return name
+}
+
+// ServiceReconciled clears the last error of a service which was reconciled successfully, in the service statuses of
+// a context.
+func ServiceReconciled(ctx context.Context, service string) {
+	s := serviceStatusesFrom(ctx)
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if status, ok := s.services[service]; ok {
+		status.LastError = ""
+	}
+}
+
+// List returns the statuses of the services ordered by name, completed with the hashes of their specs.
+func (s *ServiceStatuses) List(specHashes []infrav1.ServiceSpecHash) []infrav1.ServiceStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, specHash := range specHashes {
+		status := s.status(specHash.Service)
+		status.SpecHash = specHash.Hash
+		reconciledAt := specHash.ReconciledAt
+		status.ReconciledAt = &reconciledAt
+	}
+	if len(s.services) == 0 {
+		return nil
+	}
+	list := make([]infrav1.ServiceStatus, 0, len(s.services))
+	for _, status := range s.services {
+		list = append(list, *status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Service < list[j].Service
+	})
+	return list
+}
+
+// withServiceStatuses updates the service statuses of the context of requests, if any, from their responses, unless
+// they are sent in a dry run.
+func withServiceStatuses() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := s.Do(req)
+			if statuses := serviceStatusesFrom(req.Context()); statuses != nil && DryRunFrom(req.Context()) == nil {
+				statuses.record(req, resp)
+			}
+			return resp, err
+		})
+	}
+}
+
+// record updates the status of the service a request was made for from its response: the resources it creates or
+// updates are added to the resources of the service, those it deletes are removed, and errors are kept as last error.
+func (s *ServiceStatuses) record(req *http.Request, resp *http.Response) {
+	service := serviceNameFrom(req.Context())
+	if service == "" || resp == nil {
+		return
+	}
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	gone := resp.StatusCode == http.StatusNotFound && (req.Method == http.MethodGet || req.Method == http.MethodDelete)
+	var lastError string
+	if !success && !gone {
+		code, message := responseError(resp)
+		lastError = code + ": " + message
+		if ids, ok := responseRequestIDs(req, resp); ok {
+			lastError += " (" + ids.String() + ")"
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch {
+	case lastError != "":
+		s.status(service).LastError = lastError
+	case success && (req.Method == http.MethodPut || req.Method == http.MethodPatch):
+		status := s.status(service)
+		status.ResourceIDs = addResourceID(status.ResourceIDs, req.URL.Path)
+	case (success && req.Method == http.MethodDelete && resp.StatusCode != http.StatusAccepted) || gone:
+		if status, ok := s.services[service]; ok {
+			status.ResourceIDs = removeResourceID(status.ResourceIDs, req.URL.Path)
+		}
+	}
+}
+
+// status returns the status of a service, adding it if it has none yet. It must be called with the lock held.
+func (s *ServiceStatuses) status(service string) *infrav1.ServiceStatus {
+	status, ok := s.services[service]
+	if !ok {
+		status = &infrav1.ServiceStatus{Service: service}
+		s.services[service] = status
+	}
+	return status
+}
+
+// addResourceID adds a resource ID to sorted IDs, unless it is already there, comparing IDs case-insensitively.
+func addResourceID(ids []string, id string) []string {
+	for _, existing := range ids {
+		if strings.EqualFold(existing, id) {
+			return ids
+		}
+	}
+	ids = append(ids, id)
+	sort.Strings(ids)
+	return ids
+}
+
+// removeResourceID removes a resource ID from IDs, comparing IDs case-insensitively.
+func removeResourceID(ids []string, id string) []string {
+	for i, existing := range ids {
+		if strings.EqualFold(existing, id) {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
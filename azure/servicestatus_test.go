@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestServiceStatuses(t *testing.T) {
+	g := NewWithT(t)
+
+	const (
+		group  = "/subscriptions/123/resourceGroups/my-rg"
+		vnet   = group + "/providers/Microsoft.Network/virtualNetworks/my-vnet"
+		subnet = vnet + "/subnets/my-subnet"
+		ip     = group + "/providers/Microsoft.Network/publicIPAddresses/my-ip"
+	)
+
+	// Azure answers each request with the next response of its path.
+	responses := map[string][]*http.Response{}
+	respond := func(method, path string, statusCode int, body string) {
+		responses[method+" "+path] = append(responses[method+" "+path], &http.Response{
+			StatusCode: statusCode,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		})
+	}
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			key := req.Method + " " + req.URL.Path
+			resp := responses[key][0]
+			responses[key] = responses[key][1:]
+			resp.Request = req
+			return resp, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{})...)
+
+	// The public IP was created by an earlier reconciliation.
+	statuses := NewServiceStatuses([]infrav1.ServiceStatus{
+		{Service: "publicips", ResourceIDs: []string{ip}},
+	})
+	ctx := WithServiceStatuses(context.Background(), statuses)
+	do := func(service, method, path string) {
+		req, _ := http.NewRequestWithContext(WithServiceName(ctx, service), method, "https://management.azure.com"+path+"?api-version=2021-02-01", nil)
+		_, err := sender.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	respond(http.MethodGet, vnet, http.StatusNotFound, `{"error":{"code":"ResourceNotFound"}}`)
+	respond(http.MethodPut, vnet, http.StatusCreated, `{}`)
+	respond(http.MethodPut, subnet, http.StatusBadRequest, `{"error":{"code":"NetcfgInvalidSubnet","message":"Subnet is invalid."}}`)
+	respond(http.MethodGet, ip, http.StatusOK, `{}`)
+	// Requests made outside of a service aren't recorded.
+	respond(http.MethodPut, group, http.StatusOK, `{}`)
+
+	do("virtualnetworks", http.MethodGet, vnet)
+	do("virtualnetworks", http.MethodPut, vnet)
+	do("subnets", http.MethodPut, subnet)
+	do("publicips", http.MethodGet, ip)
+	do("", http.MethodPut, group)
+
+	reconciledAt := metav1.NewTime(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+	g.Expect(statuses.List([]infrav1.ServiceSpecHash{{Service: "virtualnetworks", Hash: "abc", ReconciledAt: reconciledAt}})).To(Equal([]infrav1.ServiceStatus{
+		{Service: "publicips", ResourceIDs: []string{ip}},
+		{Service: "subnets", LastError: "NetcfgInvalidSubnet: Subnet is invalid."},
+		{Service: "virtualnetworks", SpecHash: "abc", ReconciledAt: &reconciledAt, ResourceIDs: []string{vnet}},
+	}))
+
+	// Resources which are gone aren't listed anymore, and errors are cleared once their service succeeds.
+	respond(http.MethodDelete, ip, http.StatusAccepted, `{}`)
+	respond(http.MethodGet, ip, http.StatusNotFound, `{"error":{"code":"ResourceNotFound"}}`)
+	respond(http.MethodPut, subnet, http.StatusOK, `{}`)
+	do("publicips", http.MethodDelete, ip)
+	g.Expect(statuses.List(nil)[0].ResourceIDs).To(Equal([]string{ip}))
+	do("publicips", http.MethodGet, ip)
+	do("subnets", http.MethodPut, subnet)
+	ServiceReconciled(ctx, "subnets")
+
+	g.Expect(statuses.List(nil)).To(Equal([]infrav1.ServiceStatus{
+		{Service: "publicips"},
+		{Service: "subnets", ResourceIDs: []string{subnet}},
+		{Service: "virtualnetworks", SpecHash: "abc", ReconciledAt: &reconciledAt, ResourceIDs: []string{vnet}},
+	}))
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+)
+
+// tagsAPIVersion is the API version of the tags of resources.
+const tagsAPIVersion = "2021-04-01"
+
+// withTagsUpdates sends the requests creating or updating resources through updateTags, unless they are sent in a dry
+// run.
+func withTagsUpdates() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			if DryRunFrom(req.Context()) != nil {
+				return s.Do(req)
+			}
+			return updateTags(req, s.Do)
+		})
+	}
+}
+
+type existingResourceKey struct{}
+
+// existingResource is a resource as a service last read it from Azure.
+type existingResource struct {
+	id       string
+	resource map[string]interface{}
+}
+
+// WithExistingResource returns a context in which a request creating or updating the resource with the given ID only
+// replaces its tags if they are the only change, see updateTags. resource is the resource as the service already read
+// it from Azure, e.g. a network.SecurityGroup, so the tags can be compared without reading it again.
+func WithExistingResource(ctx context.Context, id string, resource interface{}) context.Context {
+	data, err := json.Marshal(resource)
+	if err != nil || id == "" {
+		return ctx
+	}
+	existing := map[string]interface{}{}
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return ctx
+	}
+	// The SDK leaves the etag out of the resources it sends, but it is compared to the one a request must match.
+	if v := reflect.Indirect(reflect.ValueOf(resource)); v.Kind() == reflect.Struct {
+		if field := v.FieldByName("Etag"); field.IsValid() {
+			if etag, ok := field.Interface().(*string); ok && etag != nil {
+				existing["etag"] = *etag
+			}
+		}
+	}
+	return context.WithValue(ctx, existingResourceKey{}, existingResource{id: id, resource: existing})
+}
+
+// existingResourceFrom returns a copy of the existing resource of the context of a request, if it is the resource the
+// request is made for.
+func existingResourceFrom(req *http.Request) (map[string]interface{}, bool) {
+	existing, ok := req.Context().Value(existingResourceKey{}).(existingResource)
+	if !ok || !strings.EqualFold(existing.id, req.URL.Path) {
+		return nil, false
+	}
+	resource := make(map[string]interface{}, len(existing.resource))
+	for k, v := range existing.resource {
+		resource[k] = v
+	}
+	return resource, true
+}
+
+// updateTags sends a request creating or updating a resource. If the context of the request holds the resource as it
+// exists, see WithExistingResource, and its tags are the only change the request would make, only the tags are
+// replaced through the tags of the resource, and the response is the one Azure Resource Manager would have returned to
+// the request. Unlike updates of whole resources, this doesn't start a long running operation, nor does it reapply the
+// other properties of resources like load balancers or network security groups. If the tags can't be replaced, e.g.
+// without permission to, the update of the whole resource is sent instead, unless replacing them was throttled or
+// failed with a transient error.
+func updateTags(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if req.Method != http.MethodPut {
+		return send(req)
+	}
+	existing, ok := existingResourceFrom(req)
+	if !ok {
+		return send(req)
+	}
+	desired, err := readJSONBody(req)
+	if err != nil {
+		return send(req)
+	}
+	desiredTags, ok := desired["tags"].(map[string]interface{})
+	// Tags can't be replaced by no tags.
+	if !ok || len(desiredTags) == 0 || reflect.DeepEqual(existing["tags"], desired["tags"]) {
+		return send(req)
+	}
+	if etag := req.Header.Get("If-Match"); etag != "" && existing["etag"] != etag {
+		return send(req)
+	}
+	untagged := make(map[string]interface{}, len(desired))
+	for k, v := range desired {
+		if k != "tags" {
+			untagged[k] = v
+		}
+	}
+	if cmp.Diff(pruneJSON(existing, untagged), untagged) != "" {
+		return send(req)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"operation":  "Replace",
+		"properties": map[string]interface{}{"tags": desiredTags},
+	})
+	if err != nil {
+		return send(req)
+	}
+	patch := req.Clone(req.Context())
+	patch.Method = http.MethodPatch
+	patchURL := *req.URL
+	patchURL.Path += "/providers/Microsoft.Resources/tags/default"
+	patchURL.RawPath = ""
+	patchURL.RawQuery = "api-version=" + tagsAPIVersion
+	patch.URL = &patchURL
+	patch.Header.Del("If-Match")
+	patch.Body = ioutil.NopCloser(bytes.NewReader(body))
+	patch.GetBody = nil
+	patch.ContentLength = int64(len(body))
+	resp, err := send(patch)
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		code, _ := responseError(resp)
+		if classifyFailure(resp.StatusCode, code) == TransientErrorClass {
+			return resp, nil
+		}
+		resp.Body.Close()
+		return send(req)
+	}
+	resp.Body.Close()
+
+	existing["tags"] = desiredTags
+	return jsonResponse(req, http.StatusOK, existing)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+)
+
+func TestUpdateTags(t *testing.T) {
+	const (
+		nsg      = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/my-nsg"
+		existing = `{"id":"` + nsg + `","etag":"W/\"1\"","location":"westeurope","tags":{"owned":"true"},"properties":{"provisioningState":"Succeeded","securityRules":[]}}`
+	)
+
+	testcases := []struct {
+		name           string
+		body           string
+		ifMatch        string
+		existingID     string
+		patchStatus    int
+		expectedStatus int
+		expectedSent   []string
+	}{
+		{
+			name:           "only the tags change",
+			body:           `{"location":"westeurope","tags":{"owned":"true","team":"a"},"properties":{"securityRules":[]}}`,
+			existingID:     nsg,
+			patchStatus:    http.StatusOK,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PATCH " + nsg + "/providers/Microsoft.Resources/tags/default"},
+		},
+		{
+			name:           "other properties change",
+			body:           `{"location":"westeurope","tags":{"team":"a"},"properties":{"securityRules":[{"name":"ssh"}]}}`,
+			existingID:     nsg,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PUT " + nsg},
+		},
+		{
+			name:           "the tags don't change",
+			body:           `{"location":"westeurope","tags":{"owned":"true"},"properties":{"securityRules":[]}}`,
+			existingID:     nsg,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PUT " + nsg},
+		},
+		{
+			name:           "the existing resource isn't known",
+			body:           `{"location":"westeurope","tags":{"team":"a"},"properties":{"securityRules":[]}}`,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PUT " + nsg},
+		},
+		{
+			name:           "another existing resource is known",
+			body:           `{"location":"westeurope","tags":{"team":"a"},"properties":{"securityRules":[]}}`,
+			existingID:     nsg + "-other",
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PUT " + nsg},
+		},
+		{
+			name:           "the resource was modified since it was read",
+			body:           `{"location":"westeurope","tags":{"team":"a"},"properties":{"securityRules":[]}}`,
+			ifMatch:        `W/"0"`,
+			existingID:     nsg,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PUT " + nsg},
+		},
+		{
+			name:           "the tags can't be updated",
+			body:           `{"location":"westeurope","tags":{"team":"a"},"properties":{"securityRules":[]}}`,
+			existingID:     nsg,
+			patchStatus:    http.StatusForbidden,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PATCH " + nsg + "/providers/Microsoft.Resources/tags/default", "PUT " + nsg},
+		},
+		{
+			name:           "updating the tags is throttled",
+			body:           `{"location":"westeurope","tags":{"team":"a"},"properties":{"securityRules":[]}}`,
+			existingID:     nsg,
+			patchStatus:    http.StatusTooManyRequests,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedSent:   []string{"PATCH " + nsg + "/providers/Microsoft.Resources/tags/default"},
+		},
+		{
+			name:           "no tags",
+			body:           `{"location":"westeurope","properties":{"securityRules":[]}}`,
+			existingID:     nsg,
+			expectedStatus: http.StatusOK,
+			expectedSent:   []string{"PUT " + nsg},
+		},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var sent []string
+			var patched map[string]interface{}
+			send := func(req *http.Request) (*http.Response, error) {
+				sent = append(sent, req.Method+" "+req.URL.Path)
+				statusCode := http.StatusOK
+				if req.Method == http.MethodPatch {
+					g.Expect(req.URL.Query().Get("api-version")).To(Equal(tagsAPIVersion))
+					g.Expect(json.NewDecoder(req.Body).Decode(&patched)).To(Succeed())
+					statusCode = tc.patchStatus
+				}
+				return &http.Response{StatusCode: statusCode, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(`{}`)), Request: req}, nil
+			}
+
+			ctx := context.Background()
+			if tc.existingID != "" {
+				ctx = WithExistingResource(ctx, tc.existingID, json.RawMessage(existing))
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPut, "https://management.azure.com"+nsg+"?api-version=2021-02-01", strings.NewReader(tc.body))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			resp, err := updateTags(req, send)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(resp.StatusCode).To(Equal(tc.expectedStatus))
+			g.Expect(sent).To(Equal(tc.expectedSent))
+			if tc.patchStatus != http.StatusOK {
+				return
+			}
+
+			// The tags are replaced, and the response is the resource with its new tags.
+			g.Expect(patched).To(Equal(map[string]interface{}{
+				"operation":  "Replace",
+				"properties": map[string]interface{}{"tags": map[string]interface{}{"owned": "true", "team": "a"}},
+			}))
+			var resource map[string]interface{}
+			g.Expect(json.NewDecoder(resp.Body).Decode(&resource)).To(Succeed())
+			g.Expect(resource["tags"]).To(Equal(map[string]interface{}{"owned": "true", "team": "a"}))
+			g.Expect(resource["id"]).To(Equal(nsg))
+		})
+	}
+}
+
+func TestWithExistingResource(t *testing.T) {
+	g := NewWithT(t)
+
+	const nsg = "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/networkSecurityGroups/my-nsg"
+	ctx := WithExistingResource(context.Background(), nsg, network.SecurityGroup{
+		ID:       to.StringPtr(nsg),
+		Etag:     to.StringPtr(`W/"1"`),
+		Location: to.StringPtr("westeurope"),
+		Tags:     map[string]*string{"owned": to.StringPtr("true")},
+	})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, "https://management.azure.com"+strings.ToUpper(nsg), nil)
+	existing, ok := existingResourceFrom(req)
+	g.Expect(ok).To(BeTrue())
+	// The etag is kept, although the SDK leaves it out of the resources it sends.
+	g.Expect(existing).To(Equal(map[string]interface{}{
+		"id":       nsg,
+		"etag":     `W/"1"`,
+		"location": "westeurope",
+		"tags":     map[string]interface{}{"owned": "true"},
+	}))
+}
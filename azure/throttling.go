@@ -0,0 +1,308 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultThrottlingRetryAfter is how long requests are held back after Azure throttled them without a Retry-After.
+const DefaultThrottlingRetryAfter = 30 * time.Second
+
+var (
+	throttledResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capz_azure_throttled_responses_total",
+		Help: "Number of requests to Azure Resource Manager throttled by Azure, by subscription, resource provider and throttling bucket.",
+	}, []string{"subscription", "provider", "bucket"})
+	heldBackRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capz_azure_held_back_requests_total",
+		Help: "Number of requests to Azure Resource Manager not sent because their subscription, resource provider and throttling bucket were throttled.",
+	}, []string{"subscription", "provider", "bucket"})
+	remainingRequests = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capz_azure_ratelimit_remaining_requests",
+		Help: "Number of requests Azure Resource Manager last reported as remaining before throttling, by subscription and throttling bucket.",
+	}, []string{"subscription", "bucket"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(throttledResponses, heldBackRequests, remainingRequests)
+}
+
+var (
+	// subscriptionPath matches the subscription of requests to Azure Resource Manager.
+	subscriptionPath = regexp.MustCompile(`(?i)^/subscriptions/([^/]+)`)
+
+	// providerPath matches the resource provider namespaces of requests to Azure Resource Manager. The last one
+	// serves the request.
+	providerPath = regexp.MustCompile(`(?i)/providers/([^/]+)`)
+)
+
+// defaultProvider is the resource provider of requests to resource groups and deployments, which are served by
+// Azure Resource Manager itself.
+const defaultProvider = "microsoft.resources"
+
+// throttleBucket is a subscription, resource provider and principal, and the kind of requests Azure throttles together
+// for them, "reads", "writes" or "deletes".
+type throttleBucket struct {
+	subscription string
+
+	// provider is the lower case namespace of the resource provider of the requests, e.g. "microsoft.compute". It is
+	// empty for the bucket of the requests to all resource providers, which Azure Resource Manager throttles at the
+	// subscription level.
+	provider string
+
+	// principal is the object ID of the identity sending the requests, as Azure throttles the requests of each
+	// principal separately. It is empty if the requests have no token to read it from.
+	principal string
+
+	name string
+}
+
+// subscriptionLevel returns the bucket of the requests of the same kind to all resource providers of the subscription.
+func (b throttleBucket) subscriptionLevel() throttleBucket {
+	b.provider = ""
+	return b
+}
+
+// requestBucket returns the throttle bucket of a request, if it is a request to a subscription.
+func requestBucket(req *http.Request) (throttleBucket, bool) {
+	match := subscriptionPath.FindStringSubmatch(req.URL.Path)
+	if match == nil {
+		return throttleBucket{}, false
+	}
+	bucket := throttleBucket{
+		subscription: strings.ToLower(match[1]),
+		provider:     defaultProvider,
+		principal:    requestPrincipal(req),
+		name:         "writes",
+	}
+	if providers := providerPath.FindAllStringSubmatch(req.URL.Path, -1); len(providers) > 0 {
+		bucket.provider = strings.ToLower(providers[len(providers)-1][1])
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		bucket.name = "reads"
+	case http.MethodDelete:
+		bucket.name = "deletes"
+	}
+	return bucket, true
+}
+
+// requestPrincipal returns the object ID of the principal of the bearer token of a request, or an empty string if it
+// has none. The token isn't verified, as it is only used to tell the throttle buckets of principals apart.
+func requestPrincipal(req *http.Request) string {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		OID string `json:"oid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.OID
+}
+
+// subscriptionThrottled returns true if Azure Resource Manager throttled a request at the subscription level, rather
+// than the resource provider of the request throttling it.
+func subscriptionThrottled(resp *http.Response, bucket throttleBucket) bool {
+	remaining, err := strconv.Atoi(resp.Header.Get("x-ms-ratelimit-remaining-subscription-" + bucket.name))
+	return err == nil && remaining <= 0
+}
+
+// throttleBuckets tracks until when the throttle buckets are throttled.
+type throttleBuckets struct {
+	lock           sync.Mutex
+	throttledUntil map[throttleBucket]time.Time
+	now            func() time.Time
+}
+
+func newThrottleBuckets() *throttleBuckets {
+	return &throttleBuckets{
+		throttledUntil: make(map[throttleBucket]time.Time),
+		now:            time.Now,
+	}
+}
+
+// throttle holds back the requests of a bucket for the given duration.
+func (b *throttleBuckets) throttle(bucket throttleBucket, retryAfter time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	until := b.now().Add(retryAfter)
+	if until.After(b.throttledUntil[bucket]) {
+		b.throttledUntil[bucket] = until
+	}
+}
+
+// retryAfter returns how long the requests of a bucket are still held back, if they are.
+func (b *throttleBuckets) retryAfter(bucket throttleBucket) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	until, ok := b.throttledUntil[bucket]
+	if !ok {
+		return 0
+	}
+	retryAfter := until.Sub(b.now())
+	if retryAfter <= 0 {
+		delete(b.throttledUntil, bucket)
+		return 0
+	}
+	return retryAfter
+}
+
+// throttling is shared by all clients, as Azure Resource Manager throttles the requests of all of them together.
+var throttling = newThrottleBuckets()
+
+// throttlingSender sends requests to Azure Resource Manager within the rate limits of their subscription, unless
+// their throttle bucket or all requests of the same kind to the subscription are throttled, in which case it answers
+// them with a 429 Too Many Requests response itself. It is the innermost sender of the clients, see
+// armSendDecorators.
+type throttlingSender struct {
+	sender   autorest.Sender
+	buckets  *throttleBuckets
+	limiters *rateLimiters
+}
+
+// newThrottlingSender returns a sender holding back requests to throttled subscriptions and sending the others
+// through sender, or through a default client if nil.
+func newThrottlingSender(sender *http.Client) *throttlingSender {
+	if sender == nil {
+		sender = defaultSender
+	}
+	return &throttlingSender{
+		sender:   sender,
+		buckets:  throttling,
+		limiters: armRateLimiters,
+	}
+}
+
+// Do sends a request once its rate limit allows it, unless its throttle bucket is throttled.
+func (s *throttlingSender) Do(req *http.Request) (*http.Response, error) {
+	bucket, ok := requestBucket(req)
+	if !ok {
+		return instrumentedDo(s.sender, req)
+	}
+	for _, b := range []throttleBucket{bucket.subscriptionLevel(), bucket} {
+		if retryAfter := s.buckets.retryAfter(b); retryAfter > 0 {
+			heldBackRequests.WithLabelValues(bucket.subscription, bucket.provider, bucket.name).Inc()
+			return throttledResponse(req, b, retryAfter), nil
+		}
+	}
+	if err := s.limiters.wait(req.Context(), bucket.subscriptionLevel()); err != nil {
+		return nil, err
+	}
+
+	resp, err := instrumentedDo(s.sender, req)
+	if resp == nil {
+		return resp, err
+	}
+	if remaining, convErr := strconv.Atoi(resp.Header.Get("x-ms-ratelimit-remaining-subscription-" + bucket.name)); convErr == nil {
+		remainingRequests.WithLabelValues(bucket.subscription, bucket.name).Set(float64(remaining))
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		throttledResponses.WithLabelValues(bucket.subscription, bucket.provider, bucket.name).Inc()
+		// Only throttling at the subscription level holds back the requests to other resource providers.
+		if subscriptionThrottled(resp, bucket) {
+			s.buckets.throttle(bucket.subscriptionLevel(), retryAfter(resp))
+		} else {
+			s.buckets.throttle(bucket, retryAfter(resp))
+		}
+	}
+	return resp, err
+}
+
+// throttledResponse returns the response to a request held back because its throttle bucket is throttled. It looks
+// like the responses of Azure Resource Manager, so the clients handle it the same way.
+func throttledResponse(req *http.Request, bucket throttleBucket, retryAfter time.Duration) *http.Response {
+	scope := "subscription " + bucket.subscription
+	if bucket.provider != "" {
+		scope = bucket.provider + " in " + scope
+	}
+	body := fmt.Sprintf(`{"error":{"code":"TooManyRequests","message":"%s of %s are throttled, held back for %s"}}`,
+		bucket.name, scope, retryAfter.Round(time.Second))
+	return &http.Response{
+		Status:     "429 Too Many Requests",
+		StatusCode: http.StatusTooManyRequests,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type": []string{"application/json; charset=utf-8"},
+			"Retry-After":  []string{strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))},
+		},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// retryAfter returns how long to wait before retrying a throttled request, from the Retry-After header of its
+// response.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return DefaultThrottlingRetryAfter
+	}
+	header := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
+	}
+	return DefaultThrottlingRetryAfter
+}
+
+// ThrottledRetryAfter parses the error to check if Azure throttled the request, and returns how long to wait before
+// retrying it if so.
+func ThrottledRetryAfter(err error) (time.Duration, bool) {
+	derr := autorest.DetailedError{}
+	if !errors.As(err, &derr) || derr.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	return retryAfter(derr.Response), true
+}
+
+// defaultSender sends the requests of clients without a sender of their own, like the default sender of autorest.
+var defaultSender = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{Jar: jar, Transport: transport}
+}()
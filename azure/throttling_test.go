@@ -0,0 +1,239 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+func TestRequestBucket(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		method   string
+		url      string
+		expected throttleBucket
+		ok       bool
+	}{
+		{
+			method:   http.MethodGet,
+			url:      "https://management.azure.com/subscriptions/123/resourceGroups/my-rg?api-version=2020-06-01",
+			expected: throttleBucket{subscription: "123", provider: "microsoft.resources", name: "reads"},
+			ok:       true,
+		},
+		{
+			method:   http.MethodPut,
+			url:      "https://management.azure.com/Subscriptions/ABC/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+			expected: throttleBucket{subscription: "abc", provider: "microsoft.compute", name: "writes"},
+			ok:       true,
+		},
+		{
+			method:   http.MethodDelete,
+			url:      "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm/providers/Microsoft.Authorization/locks/my-lock",
+			expected: throttleBucket{subscription: "123", provider: "microsoft.authorization", name: "deletes"},
+			ok:       true,
+		},
+		{
+			method: http.MethodGet,
+			url:    "https://management.azure.com/providers/Microsoft.Network/operations",
+		},
+	}
+	for _, tc := range tests {
+		req, _ := http.NewRequest(tc.method, tc.url, nil)
+		bucket, ok := requestBucket(req)
+		g.Expect(ok).To(Equal(tc.ok), tc.url)
+		g.Expect(bucket).To(Equal(tc.expected), tc.url)
+	}
+}
+
+func TestRequestPrincipal(t *testing.T) {
+	g := NewWithT(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg", nil)
+	g.Expect(requestPrincipal(req)).To(BeEmpty())
+
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"oid":"00000000-0000-0000-0000-000000000001","tid":"tenant"}`))
+	req.Header.Set("Authorization", "Bearer header."+claims+".signature")
+	g.Expect(requestPrincipal(req)).To(Equal("00000000-0000-0000-0000-000000000001"))
+
+	req.Header.Set("Authorization", "Bearer not-a-jwt")
+	g.Expect(requestPrincipal(req)).To(BeEmpty())
+}
+
+func TestThrottlingSender(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	buckets := newThrottleBuckets()
+	buckets.now = func() time.Time { return now }
+
+	var sent []string
+	sender := autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			sent = append(sent, req.Method+" "+req.URL.Path)
+			resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}
+			if strings.HasSuffix(req.URL.Path, "throttled") {
+				resp.StatusCode = http.StatusTooManyRequests
+				resp.Header.Set("Retry-After", "17")
+			}
+			if strings.HasSuffix(req.URL.Path, "subscription-throttled") {
+				resp.Header.Set("x-ms-ratelimit-remaining-subscription-writes", "0")
+			}
+			return resp, nil
+		}),
+		buckets:  buckets,
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{})...)
+	do := func(method, path string) *http.Response {
+		req, _ := http.NewRequest(method, "https://management.azure.com"+path, nil)
+		resp, err := sender.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	g.Expect(do(http.MethodGet, "/subscriptions/123/providers/Microsoft.Compute/throttled").StatusCode).To(Equal(http.StatusTooManyRequests))
+
+	// Reads of the resource provider in the subscription are held back until the throttling ends, other requests are
+	// still sent.
+	now = now.Add(5 * time.Second)
+	resp := do(http.MethodGet, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm")
+	g.Expect(resp.StatusCode).To(Equal(http.StatusTooManyRequests))
+	g.Expect(resp.Header.Get("Retry-After")).To(Equal("12"))
+	g.Expect(do(http.MethodPut, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm").StatusCode).To(Equal(http.StatusOK))
+	g.Expect(do(http.MethodGet, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet").StatusCode).To(Equal(http.StatusOK))
+	g.Expect(do(http.MethodGet, "/subscriptions/456/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm").StatusCode).To(Equal(http.StatusOK))
+	g.Expect(do(http.MethodGet, "/providers/Microsoft.Network/operations").StatusCode).To(Equal(http.StatusOK))
+
+	now = now.Add(12 * time.Second)
+	g.Expect(do(http.MethodGet, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm").StatusCode).To(Equal(http.StatusOK))
+
+	// Throttling at the subscription level holds back the writes to all resource providers.
+	g.Expect(do(http.MethodPut, "/subscriptions/123/providers/Microsoft.Compute/subscription-throttled").StatusCode).To(Equal(http.StatusTooManyRequests))
+	g.Expect(do(http.MethodPut, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet").StatusCode).To(Equal(http.StatusTooManyRequests))
+	g.Expect(do(http.MethodGet, "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet").StatusCode).To(Equal(http.StatusOK))
+
+	g.Expect(sent).To(Equal([]string{
+		"GET /subscriptions/123/providers/Microsoft.Compute/throttled",
+		"PUT /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+		"GET /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+		"GET /subscriptions/456/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+		"GET /providers/Microsoft.Network/operations",
+		"GET /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+		"PUT /subscriptions/123/providers/Microsoft.Compute/subscription-throttled",
+		"GET /subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+	}))
+}
+
+func TestThrottledRetryAfter(t *testing.T) {
+	g := NewWithT(t)
+
+	throttled := func(retryAfter string) error {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return errors.Wrap(autorest.NewErrorWithError(errors.New("too many requests"), "network.PublicIPAddressesClient", "Get", resp, "Failure responding to request"), "failed to get public IP")
+	}
+
+	retryAfter, ok := ThrottledRetryAfter(throttled("42"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(retryAfter).To(Equal(42 * time.Second))
+
+	retryAfter, ok = ThrottledRetryAfter(throttled(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(retryAfter).To(BeNumerically("~", time.Minute, 2*time.Second))
+
+	retryAfter, ok = ThrottledRetryAfter(throttled(""))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(retryAfter).To(Equal(DefaultThrottlingRetryAfter))
+
+	_, ok = ThrottledRetryAfter(autorest.DetailedError{StatusCode: http.StatusNotFound})
+	g.Expect(ok).To(BeFalse())
+	_, ok = ThrottledRetryAfter(errors.New("some error happened"))
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestWaitForCompletionThrottled(t *testing.T) {
+	g := NewWithT(t)
+
+	req, _ := http.NewRequest(http.MethodPut, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip", nil)
+	future, err := azure.NewFutureFromResponse(&http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Azure-Asyncoperation": []string{"https://management.azure.com/subscriptions/123/providers/Microsoft.Network/locations/westus/operations/op"}},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Without stopping on throttling, polling would back off for hours.
+	client := autorest.Client{
+		RetryAttempts: 3,
+		RetryDuration: time.Hour,
+		PollingDelay:  time.Hour,
+		Sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"25"}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	err = WaitForCompletion(context.Background(), &future, client)
+	retryAfter, ok := ThrottledRetryAfter(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(retryAfter).To(Equal(25 * time.Second))
+}
+
+func TestClientsDontRetryThrottledRequests(t *testing.T) {
+	g := NewWithT(t)
+
+	sent := 0
+	client := network.NewPublicIPAddressesClient("123")
+	SetAutoRestClientDefaults(&client.Client, autorest.NullAuthorizer{})
+	client.Sender = autorest.DecorateSender(&throttlingSender{
+		sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			sent++
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"1"}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+				Request:    req,
+			}, nil
+		}),
+		buckets:  newThrottleBuckets(),
+		limiters: newRateLimiters(RateLimit{}, RateLimit{}),
+	}, sendDecorators(senderOptions{maxRetries: 3, retryBackoff: time.Millisecond})...)
+
+	_, err := client.Get(context.Background(), "my-rg", "my-ip", "")
+	_, ok := ThrottledRetryAfter(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(sent).To(Equal(1))
+}
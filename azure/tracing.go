@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// correlationIDKey is the span attribute of the ID Azure Resource Manager correlates the operations of a request
+	// with.
+	correlationIDKey = attribute.Key("azure.correlation_id")
+	// requestIDKey is the span attribute of the ID Azure Resource Manager assigned to a request.
+	requestIDKey = attribute.Key("azure.request_id")
+)
+
+// withRequestSpans traces each request by a span propagated to Azure Resource Manager.
+func withRequestSpans() autorest.SendDecorator {
+	return func(s autorest.Sender) autorest.Sender {
+		return autorest.SenderFunc(func(req *http.Request) (resp *http.Response, err error) {
+			req, span := startRequestSpan(req)
+			defer func() {
+				endRequestSpan(span, req, resp, err)
+			}()
+			return s.Do(req)
+		})
+	}
+}
+
+// startRequestSpan starts the span of a request to Azure Resource Manager, as a child of the span of its context, and
+// propagates the trace context in the headers of the request, so the request can be correlated with the
+// reconciliation which sent it. The span carries the attributes of the context, e.g. the object reconciled and the
+// service sending the request. The spans of the requests polling long running operations are named after
+// tele.PollSpanName, so they can be sampled apart from the others.
+func startRequestSpan(req *http.Request) (*http.Request, trace.Span) {
+	name := "azure.Request"
+	if pollsOperation(req) {
+		name = tele.PollSpanName
+	}
+	ctx, span := tele.Tracer().Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			tele.ResourceIDKey.String(resourceID(req.URL.Path)),
+		))
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req, span
+}
+
+// endRequestSpan ends the span of a request to Azure Resource Manager with the status code and IDs of its response,
+// or the error sending it.
+func endRequestSpan(span trace.Span, req *http.Request, resp *http.Response, err error) {
+	defer span.End()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	if resp == nil {
+		return
+	}
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if ids, ok := responseRequestIDs(req, resp); ok {
+		span.SetAttributes(correlationIDKey.String(ids.CorrelationID), requestIDKey.String(ids.RequestID))
+	}
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(resp.StatusCode))
+}
+
+// operationPathSegments are the path segments of the status URLs of long running operations of Azure Resource
+// Manager, which futures poll.
+var operationPathSegments = []string{"/operations/", "/operationresults/", "/operationstatuses/", "/asyncoperations/"}
+
+// pollsOperation returns whether a request polls the status of a long running operation.
+func pollsOperation(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	path := strings.ToLower(req.URL.Path)
+	for _, segment := range operationPathSegments {
+		if strings.Contains(path, segment) {
+			return true
+		}
+	}
+	return false
+}
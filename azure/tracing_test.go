@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+func TestRequestSpan(t *testing.T) {
+	g := NewWithT(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	}()
+
+	ctx := tele.WithAttributes(context.Background(), tele.ObjectAttributes("AzureCluster", "default", "my-cluster")...)
+	ctx = tele.WithAttributes(ctx, tele.ServiceKey.String("publicips"))
+	ctx, parent := tele.Tracer().Start(ctx, "parent")
+	resourceID := "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, "https://management.azure.com"+resourceID+"?api-version=2019-06-01", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	req.Header.Set(correlationIDHeader, "my-correlation-id")
+
+	req, span := startRequestSpan(req)
+	// The trace context of the request span is propagated to Azure Resource Manager.
+	traceparent := req.Header.Get("traceparent")
+	g.Expect(traceparent).To(ContainSubstring(span.SpanContext().TraceID().String()))
+	g.Expect(traceparent).To(ContainSubstring(span.SpanContext().SpanID().String()))
+	resp := &http.Response{StatusCode: http.StatusConflict, Header: http.Header{}}
+	resp.Header.Set(requestIDHeader, "my-request-id")
+	endRequestSpan(span, req, resp, nil)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	g.Expect(spans).To(HaveLen(2))
+	requestSpan := spans[0]
+	g.Expect(requestSpan.Name).To(Equal("azure.Request"))
+	g.Expect(requestSpan.Parent.SpanID()).To(Equal(parent.SpanContext().SpanID()))
+	g.Expect(requestSpan.SpanKind).To(Equal(trace.SpanKindClient))
+	g.Expect(requestSpan.Status.Code).To(Equal(codes.Error))
+	attributes := attribute.NewSet(requestSpan.Attributes...)
+	for key, expected := range map[attribute.Key]string{
+		tele.KindKey:          "AzureCluster",
+		tele.NameKey:          "my-cluster",
+		tele.ServiceKey:       "publicips",
+		tele.ResourceIDKey:    strings.ToLower(resourceID),
+		semconv.HTTPMethodKey: http.MethodPut,
+		correlationIDKey:      "my-correlation-id",
+		requestIDKey:          "my-request-id",
+	} {
+		value, ok := attributes.Value(key)
+		g.Expect(ok).To(BeTrue(), "missing attribute %s", key)
+		g.Expect(strings.ToLower(value.AsString())).To(Equal(strings.ToLower(expected)), "attribute %s", key)
+	}
+	value, _ := attributes.Value(semconv.HTTPStatusCodeKey)
+	g.Expect(value.AsInt64()).To(Equal(int64(http.StatusConflict)))
+}
+
+func TestPollsOperation(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected bool
+	}{
+		{method: http.MethodGet, path: "/subscriptions/123/providers/Microsoft.Network/locations/westus2/operations/456", expected: true},
+		{method: http.MethodGet, path: "/subscriptions/123/providers/Microsoft.Compute/locations/westus2/operationResults/456", expected: true},
+		{method: http.MethodGet, path: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip", expected: false},
+		{method: http.MethodPut, path: "/subscriptions/123/providers/Microsoft.Network/locations/westus2/operations/456", expected: false},
+	}
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, "https://management.azure.com"+tc.path, nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(pollsOperation(req)).To(Equal(tc.expected), tc.path)
+	}
+}
@@ -18,6 +18,7 @@ package azure
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
@@ -57,15 +58,16 @@ type DiskSpec struct {
 
 // LBSpec defines the specification for a Load Balancer.
 type LBSpec struct {
-	Name                 string
-	Role                 string
-	Type                 infrav1.LBType
-	SKU                  infrav1.SKU
-	SubnetName           string
-	BackendPoolName      string
-	FrontendIPConfigs    []infrav1.FrontendIP
-	APIServerPort        int32
-	IdleTimeoutInMinutes *int32
+	Name                   string
+	Role                   string
+	Type                   infrav1.LBType
+	SKU                    infrav1.SKU
+	SubnetName             string
+	BackendPoolName        string
+	FrontendIPConfigs      []infrav1.FrontendIP
+	APIServerPort          int32
+	IdleTimeoutInMinutes   *int32
+	AllocatedOutboundPorts *int32
 }
 
 // RouteTableRole defines the unique role of a route table.
@@ -91,6 +93,7 @@ type SubnetSpec struct {
 	RouteTableName    string
 	SecurityGroupName string
 	Role              infrav1.SubnetRole
+	ServiceEndpoints  []infrav1.ServiceEndpointSpec
 }
 
 // VNetSpec defines the specification for a Virtual Network.
@@ -107,6 +110,27 @@ type RoleAssignmentSpec struct {
 	ResourceType string
 }
 
+// IdentityPermissionsSpec defines a built-in role the cluster identity needs in a resource group, and the
+// actions the identity is checked for.
+type IdentityPermissionsSpec struct {
+	ResourceGroup    string
+	RoleDefinitionID string
+	Actions          []string
+}
+
+// ManagedIdentitySpec defines the specification for a user-assigned identity and the built-in roles it is assigned.
+type ManagedIdentitySpec struct {
+	Name          string
+	ResourceGroup string
+	Roles         []ManagedIdentityRoleSpec
+}
+
+// ManagedIdentityRoleSpec defines a built-in role assigned to a user-assigned identity in a resource group.
+type ManagedIdentityRoleSpec struct {
+	ResourceGroup    string
+	RoleDefinitionID string
+}
+
 // ResourceType defines the type azure resource being reconciled.
 // Eg. Virtual Machine, Virtual Machine Scale Sets.
 type ResourceType string
@@ -131,7 +155,10 @@ type VMSpec struct {
 	Name                   string
 	Role                   string
 	NICNames               []string
+	OSDiskName             string
 	SSHKeyData             string
+	SSHKeySecretURL        string
+	AdminPasswordSecretURL string
 	Size                   string
 	Zone                   string
 	Identity               infrav1.VMIdentity
@@ -161,6 +188,8 @@ type ScaleSetSpec struct {
 	Size                         string
 	Capacity                     int64
 	SSHKeyData                   string
+	SSHKeySecretURL              string
+	AdminPasswordSecretURL       string
 	OSDisk                       infrav1.OSDisk
 	DataDisks                    []infrav1.DataDisk
 	SubnetName                   string
@@ -182,6 +211,62 @@ type TagsSpec struct {
 	Scope      string
 	Tags       infrav1.Tags
 	Annotation string
+	// OnlyIfOwned skips the resource if it isn't tagged as owned by the cluster, as it may be brought by the user.
+	OnlyIfOwned bool
+}
+
+// EnforcedTagsSpec defines the tags a resource of the cluster must keep.
+type EnforcedTagsSpec struct {
+	// Name describes the resource, e.g. "public IP my-ip".
+	Name string
+	// Scope is the ID of the resource.
+	Scope string
+	Tags  infrav1.Tags
+	// OnlyIfOwned skips the resource if it isn't tagged as owned by the cluster, as it may be brought by the user.
+	OnlyIfOwned bool
+}
+
+// OrphanedResourcesSpec defines the Azure resources of a cluster which must be kept by the purge of the resources it
+// owns but no spec refers to anymore.
+type OrphanedResourcesSpec struct {
+	// Expected are the names of the resources the specs of the cluster and its machines refer to, by resource type,
+	// e.g. "Microsoft.Network/publicIPAddresses".
+	Expected map[string][]string
+	// MinAge is the age under which resources are kept, as the spec referring to them may not be visible yet.
+	MinAge time.Duration
+}
+
+// UnhealthyResource is an Azure resource which Resource Health reports as unavailable.
+type UnhealthyResource struct {
+	// Type is the type of the resource, e.g. "Microsoft.Compute/virtualMachines".
+	Type string
+	Name string
+	// State is the availability state of the resource, e.g. "Unavailable".
+	State string
+	// Summary describes why the resource is unavailable.
+	Summary string
+}
+
+// QuotaUsage is the usage of a quota of a subscription in a location, e.g. of the cores of a VM family.
+type QuotaUsage struct {
+	// Provider is the resource provider of the quota, e.g. "Microsoft.Compute".
+	Provider string
+	// Name is the name of the quota, e.g. "standardDSv3Family" or "PublicIPAddresses".
+	Name string
+	// Current is how much of the quota is used.
+	Current int64
+	// Limit is the quota.
+	Limit int64
+	// Pending is how much more of the quota the machines and machine pools of a cluster need to scale up.
+	Pending int64
+}
+
+// QuotaDemand is what the machines and machine pools of a cluster are yet to create.
+type QuotaDemand struct {
+	// VMs is the number of VMs of each size to create.
+	VMs map[string]int64
+	// PublicIPs is the number of public IP addresses to create.
+	PublicIPs int64
 }
 
 // PrivateDNSSpec defines the specification for a private DNS zone.
@@ -191,6 +276,11 @@ type PrivateDNSSpec struct {
 	VNetResourceGroup string
 	LinkName          string
 	Records           []infrav1.AddressRecord
+	// ResourceGroup is the resource group of an existing zone, which is neither created nor deleted. The zone is
+	// created in the resource group of the cluster if empty.
+	ResourceGroup string
+	// SubscriptionID is the subscription of an existing zone, if it isn't the one of the cluster.
+	SubscriptionID string
 }
 
 // AvailabilitySetSpec defines the specification for an availability set.
@@ -299,3 +389,15 @@ func (vmss VMSS) HasLatestModelApplied(vm VMSSVM) bool {
 	// if the images match, then the VM is of the same model
 	return reflect.DeepEqual(vm.Image, vmss.Image)
 }
+
+// AKSBackupSpec defines the specification for the Azure Backup of an AKS cluster.
+type AKSBackupSpec struct {
+	ManagedClusterName  string
+	TenantID            string
+	VaultName           string
+	StorageAccountID    string
+	BlobContainer       string
+	BackupIntervalHours int32
+	RetentionDays       int32
+	IncludedNamespaces  []string
+}
@@ -18,10 +18,9 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"time"
 
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/go-logr/logr"
@@ -43,6 +42,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
@@ -56,6 +56,9 @@ type AzureClusterReconciler struct {
 	ReconcileTimeout          time.Duration
 	WatchFilterValue          string
 	createAzureClusterService azureClusterServiceCreator
+	errorBackoffs             *errorBackoffs
+	summaryEvents             *summaryEvents
+	readiness                 *readinessTracker
 }
 
 type azureClusterServiceCreator func(clusterScope *scope.ClusterScope) (*azureClusterService, error)
@@ -68,6 +71,9 @@ func NewAzureClusterReconciler(client client.Client, log logr.Logger, recorder r
 		Recorder:         recorder,
 		ReconcileTimeout: reconcileTimeout,
 		WatchFilterValue: watchFilterValue,
+		errorBackoffs:    newErrorBackoffs(),
+		summaryEvents:    newSummaryEvents(),
+		readiness:        newReadinessTracker(),
 	}
 
 	acr.createAzureClusterService = newAzureClusterService
@@ -97,6 +103,14 @@ func (r *AzureClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.
 		return errors.Wrap(err, "failed adding a watch for ready clusters")
 	}
 
+	// Add a watch on the client secrets of AzureClusterIdentities, so rotated secrets are used right away.
+	if err = c.Watch(
+		&source.Kind{Type: &corev1.Secret{}},
+		handler.EnqueueRequestsFromMapFunc(ClusterIdentitySecretToAzureClustersMapper(ctx, r.Client, log)),
+	); err != nil {
+		return errors.Wrap(err, "failed adding a watch for identity secrets")
+	}
+
 	return nil
 }
 
@@ -105,6 +119,8 @@ func (r *AzureClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremachinetemplates;azuremachinetemplates/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azureclusteridentities;azureclusteridentities/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 // Reconcile idempotently gets, creates, and updates a cluster.
 func (r *AzureClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -112,12 +128,8 @@ func (r *AzureClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	defer cancel()
 	log := r.Log.WithValues("namespace", req.Namespace, "azureCluster", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureClusterReconciler.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureCluster"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureCluster", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureClusterReconciler.Reconcile")
 	defer span.End()
 
 	// Fetch the AzureCluster instance
@@ -152,6 +164,21 @@ func (r *AzureClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
+	if !InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
+	// The reconciliation of objects which keeps failing is held back, so that they don't use up the requests Azure
+	// allows.
+	if wait := r.errorBackoffs.wait(azureCluster); wait > 0 {
+		log.V(2).Info("Reconciliation failed recently, backing off", "retryAfter", wait)
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+	defer func() {
+		r.errorBackoffs.observe(azureCluster, reterr)
+	}()
+
 	if azureCluster.Spec.IdentityRef != nil {
 		identity, err := GetClusterIdentityFromRef(ctx, r.Client, azureCluster.Namespace, azureCluster.Spec.IdentityRef)
 		if err != nil {
@@ -185,6 +212,12 @@ func (r *AzureClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "CreateClusterScopeFailed", err.Error())
 		return reconcile.Result{}, err
 	}
+	ctx = WithTraceAttributes(ctx, clusterScope)
+	ctx = WithAzureTimeouts(ctx, clusterScope)
+	ctx = azure.WithRetryBudget(ctx, azure.NewRetryBudget(azure.GetARMClientOptions().RetryBudget))
+	ctx = WithSkippedServices(ctx, clusterScope, log)
+	ctx, dryRun := WithDryRun(ctx, clusterScope, log)
+	original := azureCluster.DeepCopy()
 
 	// Always close the scope when exiting this function so we can persist any AzureMachine changes.
 	defer func() {
@@ -193,6 +226,53 @@ func (r *AzureClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}()
 
+	// A dry run leaves the AzureCluster as it was, apart from reporting the changes it would have made, or refused to
+	// make in read-only mode.
+	defer func() {
+		if dryRun != nil {
+			azureCluster.Annotations = original.Annotations
+			azureCluster.Finalizers = original.Finalizers
+			azureCluster.Spec = original.Spec
+			resources := azureCluster.Status.Resources
+			inSync := conditions.Get(azureCluster, infrav1.AzureResourcesInSyncCondition)
+			azureCluster.Status = original.Status
+			// In read-only mode, the Azure resources and their drift are reported as they were read.
+			if dryRun.ReadOnly() {
+				azureCluster.Status.Resources = resources
+				if inSync != nil {
+					conditions.Set(azureCluster, inSync)
+				}
+			}
+		}
+		ReportDryRun(r.Recorder, azureCluster, dryRun)
+	}()
+
+	// The status of the Azure resources of the AzureCluster is updated from the responses to the requests made for it.
+	statuses := azure.NewResourceStatuses(azureCluster.Status.Resources)
+	ctx = azure.WithResourceStatuses(ctx, statuses)
+	defer func() {
+		azureCluster.Status.Resources = statuses.List()
+	}()
+	// The status of the services of the AzureCluster is updated from the responses to the requests made by each service.
+	serviceStatuses := azure.NewServiceStatuses(azureCluster.Status.Services)
+	ctx = azure.WithServiceStatuses(ctx, serviceStatuses)
+	defer func() {
+		azureCluster.Status.Services = serviceStatuses.List(azureCluster.Status.ServiceSpecHashes)
+	}()
+	defer func() {
+		markDegraded(r.errorBackoffs, azureCluster, reterr)
+	}()
+	// The changes made to Azure resources, and the errors returned by Azure, are recorded as events of the AzureCluster.
+	ctx = azure.WithResourceEvents(ctx, azure.NewResourceEvents(r.Recorder, azureCluster))
+	// The time the AzureCluster takes to become ready, and to complete each phase of its provisioning, is measured
+	// from the outcome of its services.
+	ctx, summary := withServiceSummary(ctx)
+	defer func() {
+		if dryRun == nil {
+			r.readiness.observeCluster(original, azureCluster, summary)
+		}
+	}()
+
 	// Handle deleted clusters
 	if !azureCluster.DeletionTimestamp.IsZero() {
 		return r.reconcileDelete(ctx, clusterScope)
@@ -221,9 +301,21 @@ func (r *AzureClusterReconciler) reconcileNormal(ctx context.Context, clusterSco
 		return reconcile.Result{}, errors.Wrap(err, "failed to create a new AzureClusterReconciler")
 	}
 
-	if err := acr.Reconcile(ctx); err != nil {
+	err = acr.Reconcile(ctx)
+	r.summaryEvents.emit(r.Recorder, azureCluster, serviceSummaryFrom(ctx))
+	if err != nil {
+		err = azure.WithRequestIDs(err)
+		// Handle transient errors
+		var reconcileError azure.ReconcileError
+		if errors.As(err, &reconcileError) && reconcileError.IsTransient() {
+			clusterScope.Error(err, "transient failure to reconcile AzureCluster, retrying")
+			return reconcile.Result{RequeueAfter: reconcileError.RequeueAfter()}, nil
+		}
+
 		wrappedErr := errors.Wrap(err, "failed to reconcile cluster services")
 		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "ClusterReconcilerNormalFailed", wrappedErr.Error())
+		reason, severity := azure.ErrorReason(err, infrav1.NetworkInfrastructureProvisionFailedReason, clusterv1.ConditionSeverityError)
+		conditions.MarkFalse(azureCluster, infrav1.NetworkInfrastructureReadyCondition, reason, severity, err.Error())
 		return reconcile.Result{}, wrappedErr
 	}
 
@@ -237,7 +329,18 @@ func (r *AzureClusterReconciler) reconcileNormal(ctx context.Context, clusterSco
 	azureCluster.Status.Ready = true
 	conditions.MarkTrue(azureCluster, infrav1.NetworkInfrastructureReadyCondition)
 
-	return reconcile.Result{}, nil
+	clusterScope.SetAzureResourcesInSyncCondition()
+	if drift := clusterScope.Drift(); len(drift) > 0 {
+		action := "left as they are"
+		if clusterScope.DriftRepairEnabled() {
+			action = "repaired"
+		}
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, infrav1.AzureResourcesDriftedReason, "Azure resources differ from their specs and were %s: %s", action, strings.Join(drift, ", "))
+	}
+
+	// Without changes to the AzureCluster, resources are only compared to their specs again on the next resync of
+	// the controller, unless drift detection asks for it sooner.
+	return reconcile.Result{RequeueAfter: clusterScope.DriftDetectionInterval()}, nil
 }
 
 func (r *AzureClusterReconciler) reconcileDelete(ctx context.Context, clusterScope *scope.ClusterScope) (reconcile.Result, error) {
@@ -252,20 +355,42 @@ func (r *AzureClusterReconciler) reconcileDelete(ctx context.Context, clusterSco
 		return reconcile.Result{}, err
 	}
 
+	if clusterScope.DeletionPolicy() == infrav1.DeletionPolicyRetain {
+		clusterScope.Info("Retaining the Azure resources of the cluster as requested by its deletion policy")
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeNormal, "AzureResourcesRetained", "Azure resources of the cluster in resource group %s are retained", clusterScope.ResourceGroup())
+		controllerutil.RemoveFinalizer(clusterScope.AzureCluster, infrav1.ClusterFinalizer)
+		DeleteServiceMetrics(clusterScope.Namespace(), clusterScope.ClusterName())
+		r.summaryEvents.forget(azureCluster)
+		r.readiness.forget(azureCluster)
+		return reconcile.Result{}, nil
+	}
+
 	acr, err := r.createAzureClusterService(clusterScope)
 	if err != nil {
 		return reconcile.Result{}, errors.Wrap(err, "failed to create a new AzureClusterReconciler")
 	}
 
 	if err := acr.Delete(ctx); err != nil {
+		err = azure.WithRequestIDs(err)
+		// Handle transient errors
+		var reconcileError azure.ReconcileError
+		if errors.As(err, &reconcileError) && reconcileError.IsTransient() {
+			clusterScope.Error(err, "transient failure to delete AzureCluster, retrying")
+			return reconcile.Result{RequeueAfter: reconcileError.RequeueAfter()}, nil
+		}
+
 		wrappedErr := errors.Wrapf(err, "error deleting AzureCluster %s/%s", azureCluster.Namespace, azureCluster.Name)
 		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "ClusterReconcilerDeleteFailed", wrappedErr.Error())
-		conditions.MarkFalse(azureCluster, infrav1.NetworkInfrastructureReadyCondition, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+		reason, severity := azure.ErrorReason(err, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning)
+		conditions.MarkFalse(azureCluster, infrav1.NetworkInfrastructureReadyCondition, reason, severity, err.Error())
 		return reconcile.Result{}, wrappedErr
 	}
 
 	// Cluster is deleted so remove the finalizer.
 	controllerutil.RemoveFinalizer(clusterScope.AzureCluster, infrav1.ClusterFinalizer)
+	DeleteServiceMetrics(clusterScope.Namespace(), clusterScope.ClusterName())
+	r.summaryEvents.forget(azureCluster)
+	r.readiness.forget(azureCluster)
 
 	return reconcile.Result{}, nil
 }
@@ -20,36 +20,44 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
-	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/bastionhosts"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/enforcedtags"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/groups"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/identitypermissions"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/loadbalancers"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedidentities"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/privatedns"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/routetables"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/securitygroups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/tags"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
 // azureClusterService is the reconciler called by the AzureCluster controller.
 type azureClusterService struct {
-	scope            *scope.ClusterScope
-	groupsSvc        azure.Reconciler
-	vnetSvc          azure.Reconciler
-	securityGroupSvc azure.Reconciler
-	routeTableSvc    azure.Reconciler
-	subnetsSvc       azure.Reconciler
-	publicIPSvc      azure.Reconciler
-	loadBalancerSvc  azure.Reconciler
-	privateDNSSvc    azure.Reconciler
-	bastionSvc       azure.Reconciler
-	skuCache         *resourceskus.Cache
+	scope                  *scope.ClusterScope
+	groupsSvc              azure.Reconciler
+	identityPermissionsSvc azure.Reconciler
+	managedIdentitiesSvc   azure.Reconciler
+	vnetSvc                azure.Reconciler
+	securityGroupSvc       azure.Reconciler
+	routeTableSvc          azure.Reconciler
+	subnetsSvc             azure.Reconciler
+	publicIPSvc            azure.Reconciler
+	loadBalancerSvc        azure.Reconciler
+	privateDNSSvc          azure.Reconciler
+	bastionSvc             azure.Reconciler
+	tagsSvc                azure.Reconciler
+	enforcedTagsSvc        azure.Reconciler
+	skuCache               *resourceskus.Cache
 }
 
 // newAzureClusterService populates all the services based on input scope.
@@ -59,24 +67,47 @@ func newAzureClusterService(scope *scope.ClusterScope) (*azureClusterService, er
 		return nil, errors.Wrap(err, "failed creating a NewCache")
 	}
 
+	specs := clusterServiceSpecs(scope)
 	return &azureClusterService{
-		scope:            scope,
-		groupsSvc:        groups.New(scope),
-		vnetSvc:          virtualnetworks.New(scope),
-		securityGroupSvc: securitygroups.New(scope),
-		routeTableSvc:    routetables.New(scope),
-		subnetsSvc:       subnets.New(scope),
-		publicIPSvc:      publicips.New(scope),
-		loadBalancerSvc:  loadbalancers.New(scope),
-		privateDNSSvc:    privatedns.New(scope),
-		bastionSvc:       bastionhosts.New(scope),
-		skuCache:         skuCache,
+		scope:                  scope,
+		groupsSvc:              wrapClusterService("groups", scope, specs, groups.New(scope)),
+		identityPermissionsSvc: wrapClusterService("identitypermissions", scope, specs, identitypermissions.New(scope)),
+		managedIdentitiesSvc:   wrapClusterService("managedidentities", scope, specs, managedidentities.New(scope)),
+		vnetSvc:                wrapClusterService("virtualnetworks", scope, specs, virtualnetworks.New(scope)),
+		securityGroupSvc:       wrapClusterService("securitygroups", scope, specs, securitygroups.New(scope)),
+		routeTableSvc:          wrapClusterService("routetables", scope, specs, routetables.New(scope)),
+		subnetsSvc:             wrapClusterService("subnets", scope, specs, subnets.New(scope)),
+		publicIPSvc:            wrapClusterService("publicips", scope, specs, publicips.New(scope)),
+		loadBalancerSvc:        wrapClusterService("loadbalancers", scope, specs, loadbalancers.New(scope)),
+		privateDNSSvc:          wrapClusterService("privatedns", scope, specs, privatedns.New(scope)),
+		bastionSvc:             wrapClusterService("bastionhosts", scope, specs, bastionhosts.New(scope)),
+		tagsSvc:                wrapClusterService("tags", scope, specs, tags.New(scope)),
+		enforcedTagsSvc:        wrapClusterService("enforcedtags", scope, specs, enforcedtags.New(scope)),
+		skuCache:               skuCache,
 	}, nil
 }
 
+// clusterServiceSpecs returns the functions rendering the specs of the services of a cluster which are only
+// reconciled when their spec changes. The identity permissions, tags and enforced tags aren't, as they check the Azure
+// resources or the last applied tags on every reconciliation.
+func clusterServiceSpecs(scope *scope.ClusterScope) map[string]func() interface{} {
+	return map[string]func() interface{}{
+		"groups":            func() interface{} { return []string{scope.ResourceGroup(), scope.Location()} },
+		"managedidentities": func() interface{} { return scope.CloudProviderIdentitySpec() },
+		"virtualnetworks":   func() interface{} { return scope.VNetSpec() },
+		"securitygroups":    func() interface{} { return scope.NSGSpecs() },
+		"routetables":       func() interface{} { return scope.RouteTableSpecs() },
+		"subnets":           func() interface{} { return scope.SubnetSpecs() },
+		"publicips":         func() interface{} { return scope.PublicIPSpecs() },
+		"loadbalancers":     func() interface{} { return scope.LBSpecs() },
+		"privatedns":        func() interface{} { return scope.PrivateDNSSpec() },
+		"bastionhosts":      func() interface{} { return scope.BastionSpec() },
+	}
+}
+
 var _ azure.Reconciler = (*azureClusterService)(nil)
 
-// Reconcile reconciles all the services in a predetermined order.
+// Reconcile reconciles all the services, concurrently where they don't depend on each other.
 func (s *azureClusterService) Reconcile(ctx context.Context) error {
 	ctx, span := tele.Tracer().Start(ctx, "controllers.azureClusterService.Reconcile")
 	defer span.End()
@@ -88,92 +119,85 @@ func (s *azureClusterService) Reconcile(ctx context.Context) error {
 	s.scope.SetDNSName()
 	s.scope.SetControlPlaneSecurityRules()
 
-	if err := s.groupsSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile resource group")
-	}
-
-	if err := s.vnetSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile virtual network")
-	}
-
-	if err := s.securityGroupSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile network security group")
-	}
-
-	if err := s.routeTableSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile route table")
-	}
-
-	if err := s.subnetsSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile subnet")
-	}
-
-	if err := s.publicIPSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile public IP")
-	}
-
-	if err := s.loadBalancerSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile load balancer")
-	}
-
-	if err := s.privateDNSSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile private dns")
-	}
+	return reconcileServiceGraph(ctx, s.serviceGraph())
+}
 
-	if err := s.bastionSvc.Reconcile(ctx); err != nil {
-		return errors.Wrap(err, "failed to reconcile bastion")
+// serviceGraph returns the services of the cluster and their dependencies. Besides depending on the Azure resources
+// of the services before them, services writing to the scope, e.g. the route tables and subnets setting the IDs of
+// subnets, are ordered before the services reading what they write.
+func (s *azureClusterService) serviceGraph() []serviceNode {
+	return []serviceNode{
+		{name: "resource group", service: s.groupsSvc},
+		// Permissions are checked in the resource groups of the cluster, so they can only be checked once the resource
+		// group exists, but before any other resource is created or updated.
+		{name: "identity permissions", service: s.identityPermissionsSvc, dependsOn: []string{"resource group"}},
+		{name: "virtual network", service: s.vnetSvc, dependsOn: []string{"identity permissions"}},
+		{name: "public IP", service: s.publicIPSvc, dependsOn: []string{"identity permissions"}},
+		{name: "cloud provider identity", service: s.managedIdentitiesSvc, dependsOn: []string{"virtual network"}},
+		{name: "network security group", service: s.securityGroupSvc, dependsOn: []string{"virtual network"}},
+		{name: "route table", service: s.routeTableSvc, dependsOn: []string{"network security group"}},
+		{name: "subnet", service: s.subnetsSvc, dependsOn: []string{"route table"}},
+		{name: "load balancer", service: s.loadBalancerSvc, dependsOn: []string{"subnet", "public IP"}},
+		{name: "private dns", service: s.privateDNSSvc, dependsOn: []string{"virtual network"}},
+		{name: "bastion", service: s.bastionSvc, dependsOn: []string{"subnet", "public IP"}},
+		// Changes to the additional tags are applied, then tags are enforced, once all tagged resources exist.
+		{name: "tags", service: s.tagsSvc, dependsOn: []string{"load balancer", "cloud provider identity"}},
+		{name: "enforced tags", service: s.enforcedTagsSvc, dependsOn: []string{"tags", "bastion"}},
 	}
-
-	return nil
 }
 
-// Delete reconciles all the services in a predetermined order.
+// Delete deletes the resources of the cluster: its whole resource group if the resource group is managed and the
+// deletion policy doesn't retain it, or else the resources of each service following their delete graph.
 func (s *azureClusterService) Delete(ctx context.Context) error {
 	ctx, span := tele.Tracer().Start(ctx, "controllers.azureClusterService.Delete")
 	defer span.End()
 
-	if err := s.groupsSvc.Delete(ctx); err != nil {
-		if errors.Is(err, azure.ErrNotOwned) {
-			if err := s.privateDNSSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete private dns")
-			}
-
-			if err := s.loadBalancerSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete load balancer")
-			}
-
-			if err := s.publicIPSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete public IP")
-			}
-
-			if err := s.subnetsSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete subnet")
-			}
-
-			if err := s.routeTableSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete route table")
-			}
-
-			if err := s.securityGroupSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete network security group")
-			}
-
-			if err := s.vnetSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete virtual network")
-			}
+	// The roles of the cloud provider identity may be assigned outside of the resource group of the cluster.
+	if err := s.managedIdentitiesSvc.Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete cloud provider identity")
+	}
 
-			if err := s.bastionSvc.Delete(ctx); err != nil {
-				return errors.Wrap(err, "failed to delete bastion")
-			}
-		} else {
+	// Resources are deleted one by one if the resource group must be kept, or isn't managed by the controller.
+	if s.scope.DeletionPolicy() == infrav1.DeletionPolicyRetainResourceGroup {
+		s.scope.Info("Keeping the resource group of the cluster as requested by its deletion policy")
+		if err := deleteServiceGraph(ctx, s.deleteGraph()); err != nil {
+			return err
+		}
+	} else if err := s.groupsSvc.Delete(ctx); err != nil {
+		if !errors.Is(err, azure.ErrNotOwned) {
 			return errors.Wrap(err, "failed to delete resource group")
 		}
+		if err := deleteServiceGraph(ctx, s.deleteGraph()); err != nil {
+			return err
+		}
+	}
+
+	if err := s.identityPermissionsSvc.Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete identity permissions")
 	}
 
 	return nil
 }
 
-// setFailureDomainsForLocation sets the AzureCluster Status failure domains based on which Azure Availability Zones are available in the cluster location.
+// deleteGraph returns the services deleting the resources of the cluster in its resource group, and the services to
+// delete before each of them, as Azure rejects deleting resources still referenced by other resources, e.g. a subnet
+// used by a load balancer frontend.
+func (s *azureClusterService) deleteGraph() []serviceNode {
+	return []serviceNode{
+		{name: "private dns", service: s.privateDNSSvc},
+		{name: "load balancer", service: s.loadBalancerSvc},
+		{name: "bastion", service: s.bastionSvc},
+		{name: "public IP", service: s.publicIPSvc, dependsOn: []string{"load balancer", "bastion"}},
+		{name: "subnet", service: s.subnetsSvc, dependsOn: []string{"load balancer", "bastion"}},
+		{name: "route table", service: s.routeTableSvc, dependsOn: []string{"subnet"}},
+		{name: "network security group", service: s.securityGroupSvc, dependsOn: []string{"subnet"}},
+		// The virtual network links of the private DNS zone reference the virtual network.
+		{name: "virtual network", service: s.vnetSvc, dependsOn: []string{"subnet", "private dns"}},
+	}
+}
+
+// setFailureDomainsForLocation sets the AzureCluster Status failure domains based on which Azure Availability Zones are available in the cluster location,
+// minus the zones excluded by the failure domain overrides.
 // Note that this is not done in a webhook as it requires API calls to fetch the availability zones.
 func (s *azureClusterService) setFailureDomainsForLocation(ctx context.Context) error {
 	zones, err := s.skuCache.GetZones(ctx, s.scope.Location())
@@ -181,11 +205,7 @@ func (s *azureClusterService) setFailureDomainsForLocation(ctx context.Context)
 		return errors.Wrapf(err, "failed to get zones for location %s", s.scope.Location())
 	}
 
-	for _, zone := range zones {
-		s.scope.SetFailureDomain(zone, clusterv1.FailureDomainSpec{
-			ControlPlane: true,
-		})
-	}
+	s.scope.SetFailureDomainsForZones(zones)
 
 	return nil
 }
@@ -20,10 +20,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
@@ -33,64 +37,116 @@ import (
 	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
 )
 
-type expect func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder)
+type expect func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder)
+
+// expectDeleteGraph expects the services of the delete graph of a cluster to be deleted after the given call, in the
+// order of their dependencies, and returns the calls deleting the services no other service waits for.
+func expectDeleteGraph(after *gomock.Call, vnet, sg, rt, sn, pip, lb, dns, bastion *mocks.MockReconcilerMockRecorder) []*gomock.Call {
+	dnsCall := dns.Delete(gomockinternal.AContext()).After(after)
+	lbCall := lb.Delete(gomockinternal.AContext()).After(after)
+	bastionCall := bastion.Delete(gomockinternal.AContext()).After(after)
+	pipCall := pip.Delete(gomockinternal.AContext()).After(lbCall).After(bastionCall)
+	snCall := sn.Delete(gomockinternal.AContext()).After(lbCall).After(bastionCall)
+	rtCall := rt.Delete(gomockinternal.AContext()).After(snCall)
+	sgCall := sg.Delete(gomockinternal.AContext()).After(snCall)
+	vnetCall := vnet.Delete(gomockinternal.AContext()).After(snCall).After(dnsCall)
+	return []*gomock.Call{pipCall, rtCall, sgCall, vnetCall}
+}
 
 func TestAzureClusterReconcilerDelete(t *testing.T) {
+	// Failed deletions are retried right away.
+	retryInterval := serviceDeleteRetryInterval
+	serviceDeleteRetryInterval = 0
+	t.Cleanup(func() { serviceDeleteRetryInterval = retryInterval })
+
 	cases := map[string]struct {
-		expectedError string
-		expect        expect
+		deletionPolicy infrav1.DeletionPolicy
+		expectedError  string
+		expect         expect
 	}{
 		"Resource Group is deleted successfully": {
 			expectedError: "",
-			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder) {
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
 				gomock.InOrder(
-					grp.Delete(gomockinternal.AContext()).Return(nil))
+					mi.Delete(gomockinternal.AContext()),
+					grp.Delete(gomockinternal.AContext()).Return(nil),
+					perm.Delete(gomockinternal.AContext()))
 			},
 		},
 		"Resource Group delete fails": {
 			expectedError: "failed to delete resource group: internal error",
-			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder) {
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
 				gomock.InOrder(
+					mi.Delete(gomockinternal.AContext()),
 					grp.Delete(gomockinternal.AContext()).Return(errors.New("internal error")))
 			},
 		},
 		"Resource Group not owned by cluster": {
 			expectedError: "",
-			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder) {
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
+				miCall := mi.Delete(gomockinternal.AContext())
+				grpCall := grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned).After(miCall)
+				deleted := expectDeleteGraph(grpCall, vnet, sg, rt, sn, pip, lb, dns, bastion)
+				permCall := perm.Delete(gomockinternal.AContext())
+				for _, call := range deleted {
+					permCall.After(call)
+				}
+			},
+		},
+		"Resource Group retained by deletion policy": {
+			deletionPolicy: infrav1.DeletionPolicyRetainResourceGroup,
+			expectedError:  "",
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
+				miCall := mi.Delete(gomockinternal.AContext())
+				deleted := expectDeleteGraph(miCall, vnet, sg, rt, sn, pip, lb, dns, bastion)
+				permCall := perm.Delete(gomockinternal.AContext())
+				for _, call := range deleted {
+					permCall.After(call)
+				}
+			},
+		},
+		"Cloud provider identity delete fails": {
+			expectedError: "failed to delete cloud provider identity: some error happened",
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
 				gomock.InOrder(
-					grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned),
-					dns.Delete(gomockinternal.AContext()),
-					lb.Delete(gomockinternal.AContext()),
-					pip.Delete(gomockinternal.AContext()),
-					sn.Delete(gomockinternal.AContext()),
-					rt.Delete(gomockinternal.AContext()),
-					sg.Delete(gomockinternal.AContext()),
-					vnet.Delete(gomockinternal.AContext()),
-					bastion.Delete(gomockinternal.AContext()),
-				)
+					mi.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")))
 			},
 		},
 		"Load Balancer delete fails": {
 			expectedError: "failed to delete load balancer: some error happened",
-			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder) {
-				gomock.InOrder(
-					grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned),
-					dns.Delete(gomockinternal.AContext()),
-					lb.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")),
-				)
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
+				miCall := mi.Delete(gomockinternal.AContext())
+				grpCall := grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned).After(miCall)
+				dns.Delete(gomockinternal.AContext()).After(grpCall)
+				bastion.Delete(gomockinternal.AContext()).After(grpCall)
+				// The deletion of the load balancer is retried once, as the first attempt deleted other services, while
+				// the services deleted after it are skipped.
+				lb.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")).After(grpCall).Times(2)
 			},
 		},
 		"Route table delete fails": {
 			expectedError: "failed to delete route table: some error happened",
-			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder) {
-				gomock.InOrder(
-					grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned),
-					dns.Delete(gomockinternal.AContext()),
-					lb.Delete(gomockinternal.AContext()),
-					pip.Delete(gomockinternal.AContext()),
-					sn.Delete(gomockinternal.AContext()),
-					rt.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")),
-				)
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
+				miCall := mi.Delete(gomockinternal.AContext())
+				grpCall := grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned).After(miCall)
+				dnsCall := dns.Delete(gomockinternal.AContext()).After(grpCall)
+				lbCall := lb.Delete(gomockinternal.AContext()).After(grpCall)
+				bastionCall := bastion.Delete(gomockinternal.AContext()).After(grpCall)
+				pip.Delete(gomockinternal.AContext()).After(lbCall).After(bastionCall)
+				snCall := sn.Delete(gomockinternal.AContext()).After(lbCall).After(bastionCall)
+				sg.Delete(gomockinternal.AContext()).After(snCall)
+				vnet.Delete(gomockinternal.AContext()).After(snCall).After(dnsCall)
+				rt.Delete(gomockinternal.AContext()).Return(errors.New("some error happened")).After(snCall).Times(2)
+			},
+		},
+		"Transient errors are not retried": {
+			expectedError: "failed to delete load balancer: transient reconcile error occurred: operation in progress. Object will be requeued after 15s",
+			expect: func(grp *mocks.MockReconcilerMockRecorder, vnet *mocks.MockReconcilerMockRecorder, sg *mocks.MockReconcilerMockRecorder, rt *mocks.MockReconcilerMockRecorder, sn *mocks.MockReconcilerMockRecorder, pip *mocks.MockReconcilerMockRecorder, lb *mocks.MockReconcilerMockRecorder, dns *mocks.MockReconcilerMockRecorder, bastion *mocks.MockReconcilerMockRecorder, perm *mocks.MockReconcilerMockRecorder, mi *mocks.MockReconcilerMockRecorder) {
+				miCall := mi.Delete(gomockinternal.AContext())
+				grpCall := grp.Delete(gomockinternal.AContext()).Return(azure.ErrNotOwned).After(miCall)
+				dns.Delete(gomockinternal.AContext()).After(grpCall)
+				bastion.Delete(gomockinternal.AContext()).After(grpCall)
+				lb.Delete(gomockinternal.AContext()).Return(azure.WithTransientError(errors.New("operation in progress"), 15*time.Second)).After(grpCall)
 			},
 		},
 	}
@@ -112,23 +168,30 @@ func TestAzureClusterReconcilerDelete(t *testing.T) {
 			lbMock := mocks.NewMockReconciler(mockCtrl)
 			dnsMock := mocks.NewMockReconciler(mockCtrl)
 			bastionMock := mocks.NewMockReconciler(mockCtrl)
+			permissionsMock := mocks.NewMockReconciler(mockCtrl)
+			managedIdentitiesMock := mocks.NewMockReconciler(mockCtrl)
 
-			tc.expect(groupsMock.EXPECT(), vnetMock.EXPECT(), sgMock.EXPECT(), rtMock.EXPECT(), subnetsMock.EXPECT(), publicIPMock.EXPECT(), lbMock.EXPECT(), dnsMock.EXPECT(), bastionMock.EXPECT())
+			tc.expect(groupsMock.EXPECT(), vnetMock.EXPECT(), sgMock.EXPECT(), rtMock.EXPECT(), subnetsMock.EXPECT(), publicIPMock.EXPECT(), lbMock.EXPECT(), dnsMock.EXPECT(), bastionMock.EXPECT(), permissionsMock.EXPECT(), managedIdentitiesMock.EXPECT())
 
 			s := &azureClusterService{
 				scope: &scope.ClusterScope{
-					AzureCluster: &infrav1.AzureCluster{},
+					Logger: klogr.New(),
+					AzureCluster: &infrav1.AzureCluster{
+						Spec: infrav1.AzureClusterSpec{DeletionPolicy: tc.deletionPolicy},
+					},
 				},
-				groupsSvc:        groupsMock,
-				vnetSvc:          vnetMock,
-				securityGroupSvc: sgMock,
-				routeTableSvc:    rtMock,
-				subnetsSvc:       subnetsMock,
-				publicIPSvc:      publicIPMock,
-				loadBalancerSvc:  lbMock,
-				privateDNSSvc:    dnsMock,
-				bastionSvc:       bastionMock,
-				skuCache:         resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
+				groupsSvc:              groupsMock,
+				identityPermissionsSvc: permissionsMock,
+				managedIdentitiesSvc:   managedIdentitiesMock,
+				vnetSvc:                vnetMock,
+				securityGroupSvc:       sgMock,
+				routeTableSvc:          rtMock,
+				subnetsSvc:             subnetsMock,
+				publicIPSvc:            publicIPMock,
+				loadBalancerSvc:        lbMock,
+				privateDNSSvc:          dnsMock,
+				bastionSvc:             bastionMock,
+				skuCache:               resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
 			}
 
 			err := s.Delete(context.TODO())
@@ -141,3 +204,51 @@ func TestAzureClusterReconcilerDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureClusterReconcilerReconcile(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	groupsMock := mocks.NewMockReconciler(mockCtrl)
+	permissionsMock := mocks.NewMockReconciler(mockCtrl)
+	vnetMock := mocks.NewMockReconciler(mockCtrl)
+	publicIPMock := mocks.NewMockReconciler(mockCtrl)
+
+	// The public IPs don't depend on the virtual network, so they are still reconciled when it fails, while the
+	// services depending on it are not.
+	groupsCall := groupsMock.EXPECT().Reconcile(gomockinternal.AContext())
+	permissionsCall := permissionsMock.EXPECT().Reconcile(gomockinternal.AContext()).After(groupsCall)
+	vnetMock.EXPECT().Reconcile(gomockinternal.AContext()).Return(errors.New("some error happened")).After(permissionsCall)
+	publicIPMock.EXPECT().Reconcile(gomockinternal.AContext()).After(permissionsCall)
+
+	s := &azureClusterService{
+		scope: &scope.ClusterScope{
+			Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}},
+			AzureCluster: &infrav1.AzureCluster{
+				Spec: infrav1.AzureClusterSpec{
+					NetworkSpec: infrav1.NetworkSpec{
+						APIServerLB: infrav1.LoadBalancerSpec{Name: "my-lb", Type: infrav1.Internal},
+						Subnets:     infrav1.Subnets{{Role: infrav1.SubnetControlPlane, Name: "my-subnet"}},
+					},
+				},
+			},
+		},
+		groupsSvc:              groupsMock,
+		identityPermissionsSvc: permissionsMock,
+		managedIdentitiesSvc:   mocks.NewMockReconciler(mockCtrl),
+		vnetSvc:                vnetMock,
+		securityGroupSvc:       mocks.NewMockReconciler(mockCtrl),
+		routeTableSvc:          mocks.NewMockReconciler(mockCtrl),
+		subnetsSvc:             mocks.NewMockReconciler(mockCtrl),
+		publicIPSvc:            publicIPMock,
+		loadBalancerSvc:        mocks.NewMockReconciler(mockCtrl),
+		privateDNSSvc:          mocks.NewMockReconciler(mockCtrl),
+		bastionSvc:             mocks.NewMockReconciler(mockCtrl),
+		tagsSvc:                mocks.NewMockReconciler(mockCtrl),
+		enforcedTagsSvc:        mocks.NewMockReconciler(mockCtrl),
+		skuCache:               resourceskus.NewStaticCache([]compute.ResourceSku{}, ""),
+	}
+
+	g.Expect(s.Reconcile(context.TODO())).To(MatchError("failed to reconcile virtual network: some error happened"))
+}
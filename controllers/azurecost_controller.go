@@ -0,0 +1,278 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// The resource types the cost of clusters is estimated by.
+const (
+	virtualMachinesCost   = "virtualMachines"
+	disksCost             = "disks"
+	publicIPAddressesCost = "publicIPAddresses"
+	loadBalancersCost     = "loadBalancers"
+)
+
+var costResourceTypes = []string{virtualMachinesCost, disksCost, publicIPAddressesCost, loadBalancersCost}
+
+var (
+	clusterEstimatedHourlyCost = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capz_cluster_estimated_hourly_cost",
+		Help: "Estimated hourly cost of the Azure resources of a cluster at retail prices, by resource type and currency.",
+	}, []string{"namespace", "cluster", "resource_type", "currency"})
+	clusterUnpricedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "capz_cluster_unpriced_resources",
+		Help: "Number of the Azure resources of a cluster whose cost couldn't be estimated, by resource type.",
+	}, []string{"namespace", "cluster", "resource_type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(clusterEstimatedHourlyCost, clusterUnpricedResources)
+}
+
+// CostEstimator estimates the hourly cost of Azure resources, e.g. from their retail prices.
+type CostEstimator interface {
+	Currency() string
+	VirtualMachine(ctx context.Context, location, size string, windows, spot bool) (float64, error)
+	Disk(ctx context.Context, location, storageAccountType string, sizeGB int32) (float64, error)
+	PublicIP(ctx context.Context, location string) (float64, error)
+	LoadBalancer(ctx context.Context, location, sku string) (float64, error)
+}
+
+// AzureCostReconciler periodically estimates the hourly cost of the virtual machines, disks, public IP addresses and
+// load balancers of a cluster from their specs, and exposes it as metrics for chargeback. The estimates don't include
+// usage-based costs, e.g. of bandwidth, nor discounts.
+type AzureCostReconciler struct {
+	client.Client
+	Log              logr.Logger
+	ReconcileTimeout time.Duration
+	WatchFilterValue string
+	// Interval is how often the cost of a cluster is estimated.
+	Interval time.Duration
+	// Estimator estimates the cost of the resources of clusters.
+	Estimator CostEstimator
+}
+
+// SetupWithManager initializes this controller with a manager.
+func (r *AzureCostReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		Named("azurecost").
+		WithOptions(options).
+		For(&infrav1.AzureCluster{}).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "error creating controller")
+	}
+	return nil
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azureclusters;azuremachines;azuremachinepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+// Reconcile estimates the cost of a cluster, and requeues the cluster after the interval.
+func (r *AzureCostReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultedLoopTimeout(r.ReconcileTimeout))
+	defer cancel()
+	log := r.Log.WithValues("namespace", req.Namespace, "azureCluster", req.Name)
+
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureCluster", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureCostReconciler.Reconcile")
+	defer span.End()
+
+	azureCluster := &infrav1.AzureCluster{}
+	if err := r.Get(ctx, req.NamespacedName, azureCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.deleteClusterCost(req.Namespace, req.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !azureCluster.DeletionTimestamp.IsZero() {
+		r.deleteClusterCost(req.Namespace, req.Name)
+		return reconcile.Result{}, nil
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, azureCluster.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cluster == nil {
+		log.V(2).Info("Cluster Controller has not yet set OwnerRef")
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("cluster", cluster.Name)
+
+	if !InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't estimate its cost")
+		return reconcile.Result{}, nil
+	}
+
+	azureMachineList := &infrav1.AzureMachineList{}
+	if err := r.List(ctx, azureMachineList, client.InNamespace(azureCluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to list AzureMachines")
+	}
+	azureMachinePoolList := &infrav1exp.AzureMachinePoolList{}
+	if err := r.List(ctx, azureMachinePoolList, client.InNamespace(azureCluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		// Machine pools are an experimental feature, whose CRD may not be installed.
+		log.V(4).Info("failed to list AzureMachinePools, not estimating their cost", "error", err.Error())
+	}
+
+	estimate := estimateClusterCost(ctx, r.Estimator, azureCluster, azureMachineList.Items, azureMachinePoolList.Items, log)
+	for _, resourceType := range costResourceTypes {
+		clusterEstimatedHourlyCost.WithLabelValues(azureCluster.Namespace, azureCluster.Name, resourceType, r.Estimator.Currency()).Set(estimate.cost[resourceType])
+		clusterUnpricedResources.WithLabelValues(azureCluster.Namespace, azureCluster.Name, resourceType).Set(float64(estimate.unpriced[resourceType]))
+	}
+
+	return reconcile.Result{RequeueAfter: r.Interval}, nil
+}
+
+// deleteClusterCost deletes the metrics of the cost of a cluster which was deleted.
+func (r *AzureCostReconciler) deleteClusterCost(namespace, name string) {
+	for _, resourceType := range costResourceTypes {
+		clusterEstimatedHourlyCost.DeleteLabelValues(namespace, name, resourceType, r.Estimator.Currency())
+		clusterUnpricedResources.DeleteLabelValues(namespace, name, resourceType)
+	}
+}
+
+// clusterCost is the estimated hourly cost of the resources of a cluster, by resource type, and the number of
+// resources whose cost couldn't be estimated.
+type clusterCost struct {
+	cost     map[string]float64
+	unpriced map[string]int
+	log      logr.Logger
+}
+
+// add adds the cost of count resources of a type, or counts them as unpriced if it couldn't be estimated.
+func (c *clusterCost) add(resourceType string, count int, cost float64, err error) {
+	if count <= 0 {
+		return
+	}
+	if err != nil {
+		c.log.V(2).Info("failed to estimate the cost of a resource", "resourceType", resourceType, "error", err.Error())
+		c.unpriced[resourceType] += count
+		return
+	}
+	c.cost[resourceType] += float64(count) * cost
+}
+
+// estimateClusterCost estimates the hourly cost of the load balancers and public IP addresses of a cluster, and of
+// the virtual machines and disks of its machines and machine pools.
+func estimateClusterCost(ctx context.Context, estimator CostEstimator, azureCluster *infrav1.AzureCluster, azureMachines []infrav1.AzureMachine, azureMachinePools []infrav1exp.AzureMachinePool, log logr.Logger) clusterCost {
+	c := clusterCost{cost: map[string]float64{}, unpriced: map[string]int{}, log: log}
+	location := azureCluster.Spec.Location
+
+	network := azureCluster.Spec.NetworkSpec
+	lbs := []*infrav1.LoadBalancerSpec{&network.APIServerLB, network.NodeOutboundLB}
+	for _, lb := range lbs {
+		if lb == nil || lb.Name == "" {
+			continue
+		}
+		cost, err := estimator.LoadBalancer(ctx, location, string(lb.SKU))
+		c.add(loadBalancersCost, 1, cost, err)
+		publicIPs := 0
+		for _, frontendIP := range lb.FrontendIPs {
+			if frontendIP.PublicIP != nil {
+				publicIPs++
+			}
+		}
+		if lb.FrontendIPsCount != nil && int(*lb.FrontendIPsCount) > publicIPs && lb.Type != infrav1.Internal {
+			publicIPs = int(*lb.FrontendIPsCount)
+		}
+		cost, err = estimator.PublicIP(ctx, location)
+		c.add(publicIPAddressesCost, publicIPs, cost, err)
+	}
+	if azureCluster.Spec.BastionSpec.AzureBastion != nil {
+		cost, err := estimator.PublicIP(ctx, location)
+		c.add(publicIPAddressesCost, 1, cost, err)
+	}
+
+	for i := range azureMachines {
+		m := &azureMachines[i]
+		if !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+		c.addVirtualMachines(ctx, estimator, location, 1, m.Spec.VMSize, m.Spec.OSDisk, m.Spec.DataDisks, m.Spec.SpotVMOptions != nil)
+		if m.Spec.AllocatePublicIP {
+			cost, err := estimator.PublicIP(ctx, location)
+			c.add(publicIPAddressesCost, 1, cost, err)
+		}
+	}
+
+	for i := range azureMachinePools {
+		mp := &azureMachinePools[i]
+		if !mp.DeletionTimestamp.IsZero() {
+			continue
+		}
+		poolLocation := mp.Spec.Location
+		if poolLocation == "" {
+			poolLocation = location
+		}
+		template := mp.Spec.Template
+		c.addVirtualMachines(ctx, estimator, poolLocation, int(mp.Status.Replicas), template.VMSize, template.OSDisk, template.DataDisks, template.SpotVMOptions != nil)
+	}
+
+	return c
+}
+
+// addVirtualMachines adds the cost of count virtual machines and of their disks. Ephemeral OS disks are free.
+func (c *clusterCost) addVirtualMachines(ctx context.Context, estimator CostEstimator, location string, count int, vmSize string, osDisk infrav1.OSDisk, dataDisks []infrav1.DataDisk, spot bool) {
+	cost, err := estimator.VirtualMachine(ctx, location, vmSize, strings.EqualFold(osDisk.OSType, azure.WindowsOS), spot)
+	c.add(virtualMachinesCost, count, cost, err)
+
+	if osDisk.DiffDiskSettings == nil {
+		// OS disks without a size have the size of their image, which fits the smallest tier for most images.
+		c.addDisks(ctx, estimator, location, count, osDisk.ManagedDisk, to.Int32(osDisk.DiskSizeGB))
+	}
+	for _, dataDisk := range dataDisks {
+		c.addDisks(ctx, estimator, location, count, dataDisk.ManagedDisk, dataDisk.DiskSizeGB)
+	}
+}
+
+// addDisks adds the cost of count managed disks. The cost of disks without a storage account type, which Azure
+// picks, isn't estimated.
+func (c *clusterCost) addDisks(ctx context.Context, estimator CostEstimator, location string, count int, managedDisk *infrav1.ManagedDiskParameters, sizeGB int32) {
+	if managedDisk == nil || managedDisk.StorageAccountType == "" {
+		c.add(disksCost, count, 0, errors.New("disk has no storage account type"))
+		return
+	}
+	cost, err := estimator.Disk(ctx, location, managedDisk.StorageAccountType, sizeGB)
+	c.add(disksCost, count, cost, err)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+)
+
+// fakeCostEstimator prices all virtual machines at 1, spot ones at 0.25, Windows ones at 2, disks at 0.01, public IPs
+// at 0.005 and standard load balancers at 0.025.
+type fakeCostEstimator struct{}
+
+func (fakeCostEstimator) Currency() string { return "USD" }
+
+func (fakeCostEstimator) VirtualMachine(_ context.Context, _, size string, windows, spot bool) (float64, error) {
+	switch {
+	case size == "Standard_Unknown":
+		return 0, errors.New("no retail price found")
+	case spot:
+		return 0.25, nil
+	case windows:
+		return 2, nil
+	}
+	return 1, nil
+}
+
+func (fakeCostEstimator) Disk(_ context.Context, _, _ string, _ int32) (float64, error) {
+	return 0.01, nil
+}
+
+func (fakeCostEstimator) PublicIP(_ context.Context, _ string) (float64, error) {
+	return 0.005, nil
+}
+
+func (fakeCostEstimator) LoadBalancer(_ context.Context, _, sku string) (float64, error) {
+	if sku == "Basic" {
+		return 0, nil
+	}
+	return 0.025, nil
+}
+
+func TestEstimateClusterCost(t *testing.T) {
+	g := NewWithT(t)
+
+	premium := &infrav1.ManagedDiskParameters{StorageAccountType: "Premium_LRS"}
+	azureCluster := &infrav1.AzureCluster{
+		Spec: infrav1.AzureClusterSpec{
+			Location: "westeurope",
+			NetworkSpec: infrav1.NetworkSpec{
+				APIServerLB: infrav1.LoadBalancerSpec{
+					Name:        "my-cluster-public-lb",
+					SKU:         infrav1.SKUStandard,
+					Type:        infrav1.Public,
+					FrontendIPs: []infrav1.FrontendIP{{Name: "ip", PublicIP: &infrav1.PublicIPSpec{Name: "my-ip"}}},
+				},
+				NodeOutboundLB: &infrav1.LoadBalancerSpec{
+					Name:             "my-cluster",
+					SKU:              infrav1.SKUStandard,
+					Type:             infrav1.Public,
+					FrontendIPsCount: to.Int32Ptr(2),
+				},
+			},
+		},
+	}
+	deleting := metav1.Now()
+	azureMachines := []infrav1.AzureMachine{
+		{
+			Spec: infrav1.AzureMachineSpec{
+				VMSize:           "Standard_D2s_v3",
+				OSDisk:           infrav1.OSDisk{OSType: "Linux", DiskSizeGB: to.Int32Ptr(128), ManagedDisk: premium},
+				DataDisks:        []infrav1.DataDisk{{DiskSizeGB: 32, ManagedDisk: premium}},
+				AllocatePublicIP: true,
+			},
+		},
+		{
+			// Ephemeral OS disks are free, and disks without a storage account type aren't priced.
+			Spec: infrav1.AzureMachineSpec{
+				VMSize:    "Standard_D2s_v3",
+				OSDisk:    infrav1.OSDisk{OSType: "Windows", DiffDiskSettings: &infrav1.DiffDiskSettings{Option: "Local"}},
+				DataDisks: []infrav1.DataDisk{{DiskSizeGB: 32}},
+			},
+		},
+		{
+			// Machines being deleted aren't counted.
+			ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &deleting},
+			Spec:       infrav1.AzureMachineSpec{VMSize: "Standard_D2s_v3"},
+		},
+	}
+	azureMachinePools := []infrav1exp.AzureMachinePool{
+		{
+			Spec: infrav1exp.AzureMachinePoolSpec{
+				Template: infrav1exp.AzureMachinePoolMachineTemplate{
+					VMSize:        "Standard_D2s_v3",
+					OSDisk:        infrav1.OSDisk{OSType: "Linux", DiskSizeGB: to.Int32Ptr(32), ManagedDisk: premium},
+					SpotVMOptions: &infrav1.SpotVMOptions{},
+				},
+			},
+			Status: infrav1exp.AzureMachinePoolStatus{Replicas: 3},
+		},
+		{
+			Spec: infrav1exp.AzureMachinePoolSpec{
+				Template: infrav1exp.AzureMachinePoolMachineTemplate{
+					VMSize: "Standard_Unknown",
+					OSDisk: infrav1.OSDisk{OSType: "Linux", DiskSizeGB: to.Int32Ptr(32), ManagedDisk: premium},
+				},
+			},
+			Status: infrav1exp.AzureMachinePoolStatus{Replicas: 2},
+		},
+	}
+
+	estimate := estimateClusterCost(context.TODO(), fakeCostEstimator{}, azureCluster, azureMachines, azureMachinePools, klogr.New())
+	g.Expect(estimate.cost[loadBalancersCost]).To(BeNumerically("~", 0.05, 1e-9))
+	g.Expect(estimate.cost[publicIPAddressesCost]).To(BeNumerically("~", 0.02, 1e-9))
+	g.Expect(estimate.cost[virtualMachinesCost]).To(BeNumerically("~", 1+2+0.75, 1e-9))
+	g.Expect(estimate.unpriced[virtualMachinesCost]).To(Equal(2))
+	g.Expect(estimate.unpriced[disksCost]).To(Equal(1))
+	g.Expect(estimate.cost[disksCost]).To(BeNumerically("~", 0.07, 1e-9))
+}
@@ -27,8 +27,6 @@ import (
 	aadpodv1 "github.com/Azure/aad-pod-identity/pkg/apis/aadpodidentity/v1"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
@@ -103,12 +101,8 @@ func (r *AzureIdentityReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	defer cancel()
 	log := r.Log.WithValues("namespace", req.Namespace, "azureIdentity", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureIdentityReconciler.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureCluster"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureCluster", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureIdentityReconciler.Reconcile")
 	defer span.End()
 
 	// identityOwner is the resource that created the identity. This could be either an AzureCluster or AzureManagedControlPlane (if AKS is enabled).
@@ -174,6 +168,12 @@ func (r *AzureIdentityReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				}
 			}
 			expectedIdentityName = identity.GetAzureIdentityName(azCluster.Name, azCluster.Namespace, azCluster.Spec.IdentityRef.Name)
+			// Services with their own identity use bindings of that identity.
+			for _, ref := range azCluster.Spec.ServiceIdentityRefs {
+				if ref.IdentityRef != nil && binding.Spec.AzureIdentity == identity.GetAzureIdentityName(azCluster.Name, azCluster.Namespace, ref.IdentityRef.Name) {
+					expectedIdentityName = binding.Spec.AzureIdentity
+				}
+			}
 		case infraexpv1.AzureManagedControlPlane:
 			azManagedControlPlane := &infraexpv1.AzureManagedControlPlane{}
 			if err := r.Get(ctx, key, azManagedControlPlane); err != nil {
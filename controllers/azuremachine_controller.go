@@ -22,8 +22,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
@@ -56,6 +54,8 @@ type AzureMachineReconciler struct {
 	ReconcileTimeout          time.Duration
 	WatchFilterValue          string
 	createAzureMachineService azureMachineServiceCreator
+	errorBackoffs             *errorBackoffs
+	readiness                 *readinessTracker
 }
 
 type azureMachineServiceCreator func(machineScope *scope.MachineScope) (*azureMachineService, error)
@@ -68,6 +68,8 @@ func NewAzureMachineReconciler(client client.Client, log logr.Logger, recorder r
 		Recorder:         recorder,
 		ReconcileTimeout: reconcileTimeout,
 		WatchFilterValue: watchFilterValue,
+		errorBackoffs:    newErrorBackoffs(),
+		readiness:        newReadinessTracker(),
 	}
 
 	amr.createAzureMachineService = newAzureMachineService
@@ -132,12 +134,8 @@ func (r *AzureMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	defer cancel()
 	logger := r.Log.WithValues("namespace", req.Namespace, "azureMachine", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureMachineReconciler.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureMachine"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureMachine", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureMachineReconciler.Reconcile")
 	defer span.End()
 
 	// Fetch the AzureMachine VM.
@@ -192,6 +190,21 @@ func (r *AzureMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	logger = logger.WithValues("AzureCluster", azureCluster.Name)
 
+	if !InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		logger.V(2).Info("Cluster is not in the shard of this manager. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
+	// The reconciliation of objects which keeps failing is held back, so that they don't use up the requests Azure
+	// allows.
+	if wait := r.errorBackoffs.wait(azureMachine); wait > 0 {
+		logger.V(2).Info("Reconciliation failed recently, backing off", "retryAfter", wait)
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+	defer func() {
+		r.errorBackoffs.observe(azureMachine, reterr)
+	}()
+
 	// Create the cluster scope
 	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
 		Client:       r.Client,
@@ -203,6 +216,10 @@ func (r *AzureMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "Error creating the cluster scope", err.Error())
 		return reconcile.Result{}, err
 	}
+	ctx = WithTraceAttributes(ctx, clusterScope)
+	ctx = WithAzureTimeouts(ctx, clusterScope)
+	ctx = azure.WithRetryBudget(ctx, azure.NewRetryBudget(azure.GetARMClientOptions().RetryBudget))
+	ctx = WithSkippedServices(ctx, clusterScope, logger)
 
 	// Create the machine scope
 	machineScope, err := scope.NewMachineScope(scope.MachineScopeParams{
@@ -224,6 +241,44 @@ func (r *AzureMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}()
 
+	// A dry run leaves the AzureMachine as it was, apart from reporting the changes it would have made, or refused to
+	// make in read-only mode.
+	ctx, dryRun := WithDryRun(ctx, clusterScope, logger)
+	original := azureMachine.DeepCopy()
+	defer func() {
+		if dryRun != nil {
+			azureMachine.Annotations = original.Annotations
+			azureMachine.Finalizers = original.Finalizers
+			azureMachine.Spec = original.Spec
+			resources := azureMachine.Status.Resources
+			azureMachine.Status = original.Status
+			// In read-only mode, the Azure resources are reported as they were read.
+			if dryRun.ReadOnly() {
+				azureMachine.Status.Resources = resources
+			}
+		}
+		ReportDryRun(r.Recorder, azureMachine, dryRun)
+	}()
+
+	// The status of the Azure resources of the AzureMachine is updated from the responses to the requests made for it.
+	statuses := azure.NewResourceStatuses(azureMachine.Status.Resources)
+	ctx = azure.WithResourceStatuses(ctx, statuses)
+	defer func() {
+		azureMachine.Status.Resources = statuses.List()
+	}()
+	defer func() {
+		markDegraded(r.errorBackoffs, azureMachine, reterr)
+	}()
+	// The changes made to Azure resources, and the errors returned by Azure, are recorded as events of the AzureMachine.
+	ctx = azure.WithResourceEvents(ctx, azure.NewResourceEvents(r.Recorder, azureMachine))
+	// The time the AzureMachine takes to become ready, and to complete each phase of its provisioning, is measured
+	// from its conditions.
+	defer func() {
+		if dryRun == nil {
+			r.readiness.observeMachine(original, azureMachine)
+		}
+	}()
+
 	// Handle deleted machines
 	if !azureMachine.ObjectMeta.DeletionTimestamp.IsZero() {
 		return r.reconcileDelete(ctx, machineScope, clusterScope)
@@ -270,6 +325,7 @@ func (r *AzureMachineReconciler) reconcileNormal(ctx context.Context, machineSco
 	}
 
 	if err := ams.Reconcile(ctx); err != nil {
+		err = azure.WithRequestIDs(err)
 		// This means that a VM was created and managed by this controller, but is not present anymore.
 		// In this case, we mark it as failed and leave it to MHC for remediation
 		if errors.As(err, &azure.VMDeletedError{}) {
@@ -286,6 +342,8 @@ func (r *AzureMachineReconciler) reconcileNormal(ctx context.Context, machineSco
 		if errors.As(err, &reconcileError) {
 			if reconcileError.IsTerminal() {
 				r.Recorder.Eventf(machineScope.AzureMachine, corev1.EventTypeWarning, "ReconcileError", errors.Wrapf(err, "failed to reconcile AzureMachine").Error())
+				reason, severity := azure.ErrorReason(err, infrav1.VMProvisionFailedReason, clusterv1.ConditionSeverityError)
+				conditions.MarkFalse(machineScope.AzureMachine, infrav1.VMRunningCondition, reason, severity, err.Error())
 				machineScope.Error(err, "failed to reconcile AzureMachine", "name", machineScope.Name())
 				machineScope.SetFailureReason(capierrors.CreateMachineError)
 				machineScope.SetFailureMessage(err)
@@ -301,7 +359,8 @@ func (r *AzureMachineReconciler) reconcileNormal(ctx context.Context, machineSco
 			}
 		}
 		r.Recorder.Eventf(machineScope.AzureMachine, corev1.EventTypeWarning, "ReconcileError", errors.Wrapf(err, "failed to reconcile AzureMachine").Error())
-		conditions.MarkFalse(machineScope.AzureMachine, infrav1.VMRunningCondition, infrav1.VMProvisionFailedReason, clusterv1.ConditionSeverityError, err.Error())
+		reason, severity := azure.ErrorReason(err, infrav1.VMProvisionFailedReason, clusterv1.ConditionSeverityError)
+		conditions.MarkFalse(machineScope.AzureMachine, infrav1.VMRunningCondition, reason, severity, err.Error())
 		return reconcile.Result{}, errors.Wrap(err, "failed to reconcile AzureMachine")
 	}
 
@@ -325,10 +384,29 @@ func (r *AzureMachineReconciler) reconcileDelete(ctx context.Context, machineSco
 		if reterr == nil {
 			machineScope.Info("Removing finalizer from AzureMachine")
 			controllerutil.RemoveFinalizer(machineScope.AzureMachine, infrav1.MachineFinalizer)
+			r.readiness.forget(machineScope.AzureMachine)
 		}
 	}()
 
-	if ShouldDeleteIndividualResources(ctx, clusterScope) {
+	deleteIndividualResources := ShouldDeleteIndividualResources(ctx, clusterScope)
+	if machineDeletionPolicy(machineScope, clusterScope) == infrav1.DeletionPolicyRetain && !deleteIndividualResources {
+		// The resource group of the cluster is deleted along with the cluster, and the resources of the machine with it.
+		machineScope.Info("Cannot retain the Azure resources of the AzureMachine, the resource group of the cluster is deleted with the cluster")
+		r.Recorder.Eventf(machineScope.AzureMachine, corev1.EventTypeWarning, "AzureResourcesNotRetained", "Azure resources of the machine are deleted with resource group %s, as the deletion policy of the cluster is %s", clusterScope.ResourceGroup(), clusterScope.DeletionPolicy())
+	} else if machineDeletionPolicy(machineScope, clusterScope) == infrav1.DeletionPolicyRetain {
+		machineScope.Info("Retaining the Azure resources of the AzureMachine as requested by its deletion policy")
+		ams, err := r.createAzureMachineService(machineScope)
+		if err != nil {
+			reterr = errors.Wrap(err, "failed to create azure machine service")
+			return
+		}
+		// Retained resources keep the owned tag of the cluster, the retained tag keeps them from being purged as orphaned.
+		if err := ams.Retain(ctx, machineScope); err != nil {
+			reterr = errors.Wrapf(azure.WithRequestIDs(err), "error retaining AzureMachine %s/%s", clusterScope.Namespace(), clusterScope.ClusterName())
+			return
+		}
+		r.Recorder.Eventf(machineScope.AzureMachine, corev1.EventTypeNormal, "AzureResourcesRetained", "Azure resources of the machine are retained")
+	} else if deleteIndividualResources {
 		machineScope.Info("Deleting AzureMachine")
 		ams, err := r.createAzureMachineService(machineScope)
 		if err != nil {
@@ -337,8 +415,10 @@ func (r *AzureMachineReconciler) reconcileDelete(ctx context.Context, machineSco
 		}
 
 		if err := ams.Delete(ctx); err != nil {
+			err = azure.WithRequestIDs(err)
 			r.Recorder.Eventf(machineScope.AzureMachine, corev1.EventTypeWarning, "Error deleting AzureMachine", errors.Wrapf(err, "error deleting AzureMachine %s/%s", clusterScope.Namespace(), clusterScope.ClusterName()).Error())
-			conditions.MarkFalse(machineScope.AzureMachine, infrav1.VMRunningCondition, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning, err.Error())
+			reason, severity := azure.ErrorReason(err, clusterv1.DeletionFailedReason, clusterv1.ConditionSeverityWarning)
+			conditions.MarkFalse(machineScope.AzureMachine, infrav1.VMRunningCondition, reason, severity, err.Error())
 			reterr = errors.Wrapf(err, "error deleting AzureMachine %s/%s", clusterScope.Namespace(), clusterScope.ClusterName())
 			return
 		}
@@ -45,6 +45,7 @@ type azureMachineService struct {
 	disksSvc             azure.Reconciler
 	publicIPsSvc         azure.Reconciler
 	tagsSvc              azure.Reconciler
+	retainedTagger       retainedTagger
 	vmExtensionsSvc      azure.Reconciler
 	availabilitySetsSvc  azure.Reconciler
 	skuCache             *resourceskus.Cache
@@ -52,6 +53,11 @@ type azureMachineService struct {
 
 var _ azure.Reconciler = (*azureMachineService)(nil)
 
+// retainedTagger tags Azure resources as retained by the deletion policy of their AzureMachine.
+type retainedTagger interface {
+	MarkRetained(ctx context.Context, scopes []string) error
+}
+
 // newAzureMachineService populates all the services based on input scope.
 func newAzureMachineService(machineScope *scope.MachineScope) (*azureMachineService, error) {
 	cache, err := resourceskus.GetCache(machineScope, machineScope.Location())
@@ -60,15 +66,16 @@ func newAzureMachineService(machineScope *scope.MachineScope) (*azureMachineServ
 	}
 
 	return &azureMachineService{
-		inboundNatRulesSvc:   inboundnatrules.New(machineScope),
-		networkInterfacesSvc: networkinterfaces.New(machineScope, cache),
-		virtualMachinesSvc:   virtualmachines.New(machineScope, cache),
-		roleAssignmentsSvc:   roleassignments.New(machineScope),
-		disksSvc:             disks.New(machineScope),
-		publicIPsSvc:         publicips.New(machineScope),
-		tagsSvc:              tags.New(machineScope),
-		vmExtensionsSvc:      vmextensions.New(machineScope),
-		availabilitySetsSvc:  availabilitysets.New(machineScope, cache),
+		inboundNatRulesSvc:   wrapService("inboundnatrules", machineScope, inboundnatrules.New(machineScope)),
+		networkInterfacesSvc: wrapService("networkinterfaces", machineScope, networkinterfaces.New(machineScope, cache)),
+		virtualMachinesSvc:   wrapService("virtualmachines", machineScope, virtualmachines.New(machineScope, cache)),
+		roleAssignmentsSvc:   wrapService("roleassignments", machineScope, roleassignments.New(machineScope)),
+		disksSvc:             wrapService("disks", machineScope, disks.New(machineScope)),
+		publicIPsSvc:         wrapService("publicips", machineScope, publicips.New(machineScope)),
+		tagsSvc:              wrapService("tags", machineScope, tags.New(machineScope)),
+		retainedTagger:       tags.New(machineScope),
+		vmExtensionsSvc:      wrapService("vmextensions", machineScope, vmextensions.New(machineScope)),
+		availabilitySetsSvc:  wrapService("availabilitysets", machineScope, availabilitysets.New(machineScope, cache)),
 		skuCache:             cache,
 	}, nil
 }
@@ -144,3 +151,14 @@ func (s *azureMachineService) Delete(ctx context.Context) error {
 
 	return nil
 }
+
+// Retain tags the Azure resources of the machine as retained, so that they outlive their AzureMachine.
+func (s *azureMachineService) Retain(ctx context.Context, machineScope *scope.MachineScope) error {
+	ctx, span := tele.Tracer().Start(ctx, "controllers.azureMachineService.Retain")
+	defer span.End()
+
+	if err := s.retainedTagger.MarkRetained(ctx, machineScope.RetainedResourceIDs()); err != nil {
+		return errors.Wrap(err, "failed to tag retained resources")
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/orphanedresources"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// AzureOrphanedResourcesReconciler periodically deletes the Azure resources tagged as owned by a cluster which
+// neither the AzureCluster nor its AzureMachines refer to anymore.
+type AzureOrphanedResourcesReconciler struct {
+	client.Client
+	Log              logr.Logger
+	Recorder         record.EventRecorder
+	ReconcileTimeout time.Duration
+	WatchFilterValue string
+	// Interval is how often the resources of a cluster are checked.
+	Interval time.Duration
+	// MinAge is the age under which orphaned resources are kept, as the spec referring to them may not be visible yet.
+	MinAge time.Duration
+}
+
+// SetupWithManager initializes this controller with a manager.
+func (r *AzureOrphanedResourcesReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		Named("azureorphanedresources").
+		WithOptions(options).
+		For(&infrav1.AzureCluster{}).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(predicates.ResourceIsNotExternallyManaged(ctrl.LoggerFrom(ctx))).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "error creating controller")
+	}
+	return nil
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azureclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
+
+// Reconcile deletes the orphaned Azure resources of a cluster, and requeues the cluster after the interval.
+func (r *AzureOrphanedResourcesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultedLoopTimeout(r.ReconcileTimeout))
+	defer cancel()
+	log := r.Log.WithValues("namespace", req.Namespace, "azureCluster", req.Name)
+
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureCluster", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureOrphanedResourcesReconciler.Reconcile")
+	defer span.End()
+
+	azureCluster := &infrav1.AzureCluster{}
+	if err := r.Get(ctx, req.NamespacedName, azureCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, azureCluster.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cluster == nil {
+		log.V(2).Info("Cluster Controller has not yet set OwnerRef")
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("cluster", cluster.Name)
+
+	// The resources of clusters which are paused, not provisioned yet or being deleted are left alone, as their
+	// specs may not reflect the resources they own.
+	if annotations.IsPaused(cluster, azureCluster) || !azureCluster.DeletionTimestamp.IsZero() || !azureCluster.Status.Ready {
+		log.V(2).Info("Not purging orphaned resources of a cluster which is paused, not ready or being deleted")
+		return reconcile.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	if !InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't purge orphaned resources")
+		return reconcile.Result{}, nil
+	}
+
+	// Machines which aren't labelled with their cluster yet are kept with those of the cluster, so their resources are
+	// never mistaken for orphaned ones.
+	azureMachineList := &infrav1.AzureMachineList{}
+	if err := r.List(ctx, azureMachineList, client.InNamespace(azureCluster.Namespace)); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to list AzureMachines")
+	}
+	var azureMachines []infrav1.AzureMachine
+	for _, azureMachine := range azureMachineList.Items {
+		if name, ok := azureMachine.Labels[clusterv1.ClusterLabelName]; !ok || name == cluster.Name {
+			azureMachines = append(azureMachines, azureMachine)
+		}
+	}
+
+	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
+		Client:       r.Client,
+		Logger:       log,
+		Cluster:      cluster,
+		AzureCluster: azureCluster,
+	})
+	if err != nil {
+		err = errors.Errorf("failed to create scope: %+v", err)
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "CreateClusterScopeFailed", err.Error())
+		return reconcile.Result{}, err
+	}
+	ctx = WithTraceAttributes(ctx, clusterScope)
+	ctx = WithAzureTimeouts(ctx, clusterScope)
+	ctx = azure.WithRetryBudget(ctx, azure.NewRetryBudget(azure.GetARMClientOptions().RetryBudget))
+	ctx = WithSkippedServices(ctx, clusterScope, log)
+	// A dry run only logs the resources which would be deleted.
+	ctx, _ = WithDryRun(ctx, clusterScope, log)
+
+	orphanedResourcesScope := &scope.OrphanedResourcesScope{
+		ClusterScope:  clusterScope,
+		AzureMachines: azureMachines,
+		MinAge:        r.MinAge,
+	}
+	svc := wrapService("orphanedresources", clusterScope, orphanedresources.New(orphanedResourcesScope))
+	if err := svc.Reconcile(ctx); err != nil {
+		var reconcileError azure.ReconcileError
+		if errors.As(err, &reconcileError) && reconcileError.IsTransient() {
+			log.Error(err, "transient failure to purge orphaned resources, retrying")
+			return reconcile.Result{RequeueAfter: reconcileError.RequeueAfter()}, nil
+		}
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "PurgeOrphanedResourcesFailed", err.Error())
+		return reconcile.Result{}, errors.Wrap(err, "failed to purge orphaned resources")
+	}
+
+	return reconcile.Result{RequeueAfter: r.Interval}, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestAzureOrphanedResourcesReconcilerSkipsClusters(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "default"},
+	}
+	ownedAzureCluster := func(mutate func(*infrav1.AzureCluster)) *infrav1.AzureCluster {
+		azureCluster := &infrav1.AzureCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-azure-cluster",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "my-cluster"},
+				},
+			},
+			Status: infrav1.AzureClusterStatus{Ready: true},
+		}
+		mutate(azureCluster)
+		return azureCluster
+	}
+
+	cases := map[string]struct {
+		objects []runtime.Object
+		result  ctrl.Result
+	}{
+		"AzureCluster not found": {
+			objects: []runtime.Object{cluster},
+		},
+		"AzureCluster without owner": {
+			objects: []runtime.Object{cluster, ownedAzureCluster(func(c *infrav1.AzureCluster) { c.OwnerReferences = nil })},
+		},
+		"AzureCluster not ready": {
+			objects: []runtime.Object{cluster, ownedAzureCluster(func(c *infrav1.AzureCluster) { c.Status.Ready = false })},
+			result:  ctrl.Result{RequeueAfter: time.Hour},
+		},
+		"AzureCluster paused": {
+			objects: []runtime.Object{cluster, ownedAzureCluster(func(c *infrav1.AzureCluster) {
+				c.Annotations = map[string]string{clusterv1.PausedAnnotation: "true"}
+			})},
+			result: ctrl.Result{RequeueAfter: time.Hour},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			scheme := runtime.NewScheme()
+			_ = clusterv1.AddToScheme(scheme)
+			_ = infrav1.AddToScheme(scheme)
+
+			reconciler := &AzureOrphanedResourcesReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tc.objects...).Build(),
+				Log:      klogr.New(),
+				Recorder: record.NewFakeRecorder(10),
+				Interval: time.Hour,
+				MinAge:   time.Hour,
+			}
+
+			result, err := reconciler.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: "default", Name: "my-azure-cluster"},
+			})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result).To(Equal(tc.result))
+		})
+	}
+}
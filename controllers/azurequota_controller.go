@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/quotas"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// AzureQuotaReconciler periodically exports the usage of the compute and network quotas of the subscription of a
+// cluster in its location as metrics, and sets the QuotaAvailable condition of the AzureCluster when scaling up its
+// machines or machine pools would exceed a quota.
+type AzureQuotaReconciler struct {
+	client.Client
+	Log              logr.Logger
+	Recorder         record.EventRecorder
+	ReconcileTimeout time.Duration
+	WatchFilterValue string
+	// Interval is how often the quotas of a cluster are checked.
+	Interval time.Duration
+}
+
+// SetupWithManager initializes this controller with a manager.
+func (r *AzureQuotaReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		Named("azurequota").
+		WithOptions(options).
+		For(&infrav1.AzureCluster{}).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "error creating controller")
+	}
+	return nil
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azureclusters;azureclusters/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremachines;azuremachinepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machinepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
+
+// Reconcile checks the quotas of the subscription of a cluster, and requeues the cluster after the interval.
+func (r *AzureQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultedLoopTimeout(r.ReconcileTimeout))
+	defer cancel()
+	log := r.Log.WithValues("namespace", req.Namespace, "azureCluster", req.Name)
+
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureCluster", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureQuotaReconciler.Reconcile")
+	defer span.End()
+
+	azureCluster := &infrav1.AzureCluster{}
+	if err := r.Get(ctx, req.NamespacedName, azureCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, azureCluster.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cluster == nil {
+		log.V(2).Info("Cluster Controller has not yet set OwnerRef")
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("cluster", cluster.Name)
+
+	if annotations.IsPaused(cluster, azureCluster) || !azureCluster.DeletionTimestamp.IsZero() {
+		log.V(2).Info("Not checking the quotas of a cluster which is paused or being deleted")
+		return reconcile.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	if !InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't check its quotas")
+		return reconcile.Result{}, nil
+	}
+
+	demand, err := r.quotaDemand(ctx, azureCluster, cluster.Name, log)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Only the QuotaAvailable condition is patched, so the conditions owned by the AzureCluster controller are left
+	// alone.
+	patchHelper, err := patch.NewHelper(azureCluster, r.Client)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to init patch helper")
+	}
+
+	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
+		Client:       r.Client,
+		Logger:       log,
+		Cluster:      cluster,
+		AzureCluster: azureCluster,
+	})
+	if err != nil {
+		err = errors.Errorf("failed to create scope: %+v", err)
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "CreateClusterScopeFailed", err.Error())
+		return reconcile.Result{}, err
+	}
+	ctx = WithTraceAttributes(ctx, clusterScope)
+	ctx = WithAzureTimeouts(ctx, clusterScope)
+	ctx = azure.WithRetryBudget(ctx, azure.NewRetryBudget(azure.GetARMClientOptions().RetryBudget))
+	ctx = WithSkippedServices(ctx, clusterScope, log)
+
+	skuCache, err := resourceskus.GetCache(clusterScope, clusterScope.Location())
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to get resource SKUs cache")
+	}
+	quotaScope := &scope.QuotaScope{
+		ClusterScope: clusterScope,
+		Demand:       demand,
+	}
+	svc := wrapService("quotas", clusterScope, quotas.New(quotaScope, skuCache))
+	if err := svc.Reconcile(ctx); err != nil {
+		var reconcileError azure.ReconcileError
+		if errors.As(err, &reconcileError) && reconcileError.IsTransient() {
+			log.Error(err, "transient failure to check the quotas of the subscription, retrying")
+			return reconcile.Result{RequeueAfter: reconcileError.RequeueAfter()}, nil
+		}
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "CheckQuotasFailed", err.Error())
+		return reconcile.Result{}, errors.Wrap(err, "failed to check the quotas of the subscription")
+	}
+
+	patchOptions := []patch.Option{patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{infrav1.QuotaAvailableCondition}}}
+	if err := patchHelper.Patch(ctx, azureCluster, patchOptions...); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to patch the QuotaAvailable condition")
+	}
+
+	return reconcile.Result{RequeueAfter: r.Interval}, nil
+}
+
+// quotaDemand returns what the machines and machine pools of a cluster are yet to create: the VMs of the machines
+// without a provider ID and the public IPs they allocate, and the VMs of the replicas machine pools scale up to.
+func (r *AzureQuotaReconciler) quotaDemand(ctx context.Context, azureCluster *infrav1.AzureCluster, clusterName string, log logr.Logger) (azure.QuotaDemand, error) {
+	demand := azure.QuotaDemand{VMs: map[string]int64{}}
+
+	azureMachineList := &infrav1.AzureMachineList{}
+	if err := r.List(ctx, azureMachineList, client.InNamespace(azureCluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		return demand, errors.Wrap(err, "failed to list AzureMachines")
+	}
+	for _, azureMachine := range azureMachineList.Items {
+		if !azureMachine.DeletionTimestamp.IsZero() || azureMachine.Spec.ProviderID != nil {
+			continue
+		}
+		demand.VMs[azureMachine.Spec.VMSize]++
+		if azureMachine.Spec.AllocatePublicIP {
+			demand.PublicIPs++
+		}
+	}
+
+	azureMachinePoolList := &infrav1exp.AzureMachinePoolList{}
+	if err := r.List(ctx, azureMachinePoolList, client.InNamespace(azureCluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		// Machine pools are an experimental feature, whose CRD may not be installed.
+		log.V(4).Info("failed to list AzureMachinePools, not checking the quotas they need", "error", err.Error())
+		return demand, nil
+	}
+	for _, azureMachinePool := range azureMachinePoolList.Items {
+		if !azureMachinePool.DeletionTimestamp.IsZero() {
+			continue
+		}
+		machinePool, err := GetOwnerMachinePool(ctx, r.Client, azureMachinePool.ObjectMeta)
+		if err != nil {
+			return demand, errors.Wrap(err, "failed to get the owner MachinePool")
+		}
+		if machinePool == nil || machinePool.Spec.Replicas == nil {
+			continue
+		}
+		if pending := *machinePool.Spec.Replicas - azureMachinePool.Status.Replicas; pending > 0 {
+			demand.VMs[azureMachinePool.Spec.Template.VMSize] += int64(pending)
+		}
+	}
+	return demand, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterexpv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	infraexpv1 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+)
+
+func TestQuotaDemand(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := newScheme()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clusterLabels := map[string]string{clusterv1.ClusterLabelName: "my-cluster"}
+	azureCluster := &infrav1.AzureCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-azure-cluster", Namespace: "default"}}
+	machinePool := &clusterexpv1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-machine-pool", Namespace: "default"},
+		Spec:       clusterexpv1.MachinePoolSpec{Replicas: to.Int32Ptr(5)},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(
+		azureCluster,
+		// A machine already provisioned, which needs no more quota.
+		&infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "provisioned", Namespace: "default", Labels: clusterLabels},
+			Spec:       infrav1.AzureMachineSpec{VMSize: "Standard_D2s_v3", ProviderID: to.StringPtr("azure:///vm")},
+		},
+		&infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default", Labels: clusterLabels},
+			Spec:       infrav1.AzureMachineSpec{VMSize: "Standard_D2s_v3", AllocatePublicIP: true},
+		},
+		// A machine of another cluster.
+		&infrav1.AzureMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", Labels: map[string]string{clusterv1.ClusterLabelName: "other-cluster"}},
+			Spec:       infrav1.AzureMachineSpec{VMSize: "Standard_D2s_v3"},
+		},
+		machinePool,
+		&infraexpv1.AzureMachinePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-azure-machine-pool",
+				Namespace: "default",
+				Labels:    clusterLabels,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: clusterexpv1.GroupVersion.String(),
+					Kind:       "MachinePool",
+					Name:       machinePool.Name,
+				}},
+			},
+			Spec:   infraexpv1.AzureMachinePoolSpec{Template: infraexpv1.AzureMachinePoolMachineTemplate{VMSize: "Standard_D4s_v3"}},
+			Status: infraexpv1.AzureMachinePoolStatus{Replicas: 2},
+		},
+	).Build()
+
+	reconciler := &AzureQuotaReconciler{Client: client, Log: klogr.New()}
+	demand, err := reconciler.quotaDemand(context.TODO(), azureCluster, "my-cluster", klogr.New())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(demand).To(Equal(azure.QuotaDemand{
+		VMs:       map[string]int64{"Standard_D2s_v3": 1, "Standard_D4s_v3": 3},
+		PublicIPs: 1,
+	}))
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourcehealth"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+// AzureResourceHealthReconciler periodically checks the Azure resources of a cluster against Azure Resource Health,
+// and sets the AzureResourcesHealthy condition of the AzureCluster and AzureMachines owning them. As a change of
+// condition triggers the reconciliation of the object, resource-level failures are reconciled without waiting for the
+// periodic resync.
+type AzureResourceHealthReconciler struct {
+	client.Client
+	Log              logr.Logger
+	Recorder         record.EventRecorder
+	ReconcileTimeout time.Duration
+	WatchFilterValue string
+	// Interval is how often the resources of a cluster are checked.
+	Interval time.Duration
+}
+
+// SetupWithManager initializes this controller with a manager.
+func (r *AzureResourceHealthReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	_, err := ctrl.NewControllerManagedBy(mgr).
+		Named("azureresourcehealth").
+		WithOptions(options).
+		For(&infrav1.AzureCluster{}).
+		WithEventFilter(predicates.ResourceNotPausedAndHasFilterLabel(ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		WithEventFilter(predicates.ResourceIsNotExternallyManaged(ctrl.LoggerFrom(ctx))).
+		Build(r)
+	if err != nil {
+		return errors.Wrap(err, "error creating controller")
+	}
+	return nil
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azureclusters;azureclusters/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremachines;azuremachines/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
+
+// Reconcile checks the health of the Azure resources of a cluster, and requeues the cluster after the interval.
+func (r *AzureResourceHealthReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultedLoopTimeout(r.ReconcileTimeout))
+	defer cancel()
+	log := r.Log.WithValues("namespace", req.Namespace, "azureCluster", req.Name)
+
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureCluster", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureResourceHealthReconciler.Reconcile")
+	defer span.End()
+
+	azureCluster := &infrav1.AzureCluster{}
+	if err := r.Get(ctx, req.NamespacedName, azureCluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, azureCluster.ObjectMeta)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if cluster == nil {
+		log.V(2).Info("Cluster Controller has not yet set OwnerRef")
+		return reconcile.Result{}, nil
+	}
+	log = log.WithValues("cluster", cluster.Name)
+
+	if annotations.IsPaused(cluster, azureCluster) || !azureCluster.DeletionTimestamp.IsZero() || !azureCluster.Status.Ready {
+		log.V(2).Info("Not checking the health of the resources of a cluster which is paused, not ready or being deleted")
+		return reconcile.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	if !InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't check the health of its resources")
+		return reconcile.Result{}, nil
+	}
+
+	azureMachineList := &infrav1.AzureMachineList{}
+	if err := r.List(ctx, azureMachineList, client.InNamespace(azureCluster.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: cluster.Name}); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to list AzureMachines")
+	}
+
+	// Only the AzureResourcesHealthy condition is patched, so the conditions owned by the AzureCluster and AzureMachine
+	// controllers are left alone.
+	patchOptions := []patch.Option{patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{infrav1.AzureResourcesHealthyCondition}}}
+	clusterPatchHelper, err := patch.NewHelper(azureCluster, r.Client)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to init patch helper")
+	}
+	azureMachines := make([]*infrav1.AzureMachine, 0, len(azureMachineList.Items))
+	machinePatchHelpers := make([]*patch.Helper, 0, len(azureMachineList.Items))
+	for i := range azureMachineList.Items {
+		azureMachine := &azureMachineList.Items[i]
+		if !azureMachine.DeletionTimestamp.IsZero() {
+			continue
+		}
+		helper, err := patch.NewHelper(azureMachine, r.Client)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to init patch helper")
+		}
+		azureMachines = append(azureMachines, azureMachine)
+		machinePatchHelpers = append(machinePatchHelpers, helper)
+	}
+
+	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
+		Client:       r.Client,
+		Logger:       log,
+		Cluster:      cluster,
+		AzureCluster: azureCluster,
+	})
+	if err != nil {
+		err = errors.Errorf("failed to create scope: %+v", err)
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "CreateClusterScopeFailed", err.Error())
+		return reconcile.Result{}, err
+	}
+	ctx = WithTraceAttributes(ctx, clusterScope)
+	ctx = WithAzureTimeouts(ctx, clusterScope)
+	ctx = azure.WithRetryBudget(ctx, azure.NewRetryBudget(azure.GetARMClientOptions().RetryBudget))
+	ctx = WithSkippedServices(ctx, clusterScope, log)
+
+	resourceHealthScope := &scope.ResourceHealthScope{
+		ClusterScope:  clusterScope,
+		AzureMachines: azureMachines,
+	}
+	svc := wrapService("resourcehealth", clusterScope, resourcehealth.New(resourceHealthScope))
+	if err := svc.Reconcile(ctx); err != nil {
+		var reconcileError azure.ReconcileError
+		if errors.As(err, &reconcileError) && reconcileError.IsTransient() {
+			log.Error(err, "transient failure to check the health of Azure resources, retrying")
+			return reconcile.Result{RequeueAfter: reconcileError.RequeueAfter()}, nil
+		}
+		r.Recorder.Eventf(azureCluster, corev1.EventTypeWarning, "CheckResourceHealthFailed", err.Error())
+		return reconcile.Result{}, errors.Wrap(err, "failed to check the health of Azure resources")
+	}
+
+	// The patches trigger the reconciliation of the objects whose condition changed.
+	errs := []error{clusterPatchHelper.Patch(ctx, azureCluster, patchOptions...)}
+	for i, azureMachine := range azureMachines {
+		errs = append(errs, machinePatchHelpers[i].Patch(ctx, azureMachine, patchOptions...))
+	}
+	if err := kerrors.NewAggregate(errs); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to patch the AzureResourcesHealthy condition")
+	}
+
+	return reconcile.Result{RequeueAfter: r.Interval}, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+// errorBackoffs tracks the consecutive failed reconciliations of objects, so that objects whose reconciliation keeps
+// failing, e.g. because of exhausted quotas or expired credentials, are reconciled less and less often instead of
+// using up the requests Azure allows at the rate of healthy objects.
+type errorBackoffs struct {
+	lock     sync.Mutex
+	backoffs map[types.NamespacedName]errorBackoff
+	now      func() time.Time
+}
+
+// errorBackoff is the backoff of an object.
+type errorBackoff struct {
+	failures int
+	retryAt  time.Time
+	// generation is the generation of the object when its reconciliation last failed.
+	generation int64
+}
+
+func newErrorBackoffs() *errorBackoffs {
+	return &errorBackoffs{
+		backoffs: make(map[types.NamespacedName]errorBackoff),
+		now:      time.Now,
+	}
+}
+
+// wait returns how long the reconciliation of an object is still held back. Changes to the spec of an object end its
+// backoff, as they may fix its reconciliation.
+func (b *errorBackoffs) wait(obj client.Object) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	backoff, ok := b.backoffs[client.ObjectKeyFromObject(obj)]
+	if !ok || backoff.generation != obj.GetGeneration() {
+		return 0
+	}
+	if wait := backoff.retryAt.Sub(b.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// observe records the outcome of a reconciliation of an object.
+func (b *errorBackoffs) observe(obj client.Object, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	key := client.ObjectKeyFromObject(obj)
+	if err == nil {
+		delete(b.backoffs, key)
+		return
+	}
+	backoff := b.backoffs[key]
+	backoff.failures++
+	backoff.retryAt = b.now().Add(reconciler.ErrorBackoff(backoff.failures))
+	backoff.generation = obj.GetGeneration()
+	b.backoffs[key] = backoff
+}
+
+// failures returns the number of consecutive failed reconciliations of an object observed so far.
+func (b *errorBackoffs) failures(obj client.Object) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.backoffs[client.ObjectKeyFromObject(obj)].failures
+}
+
+// markDegraded marks an object as degraded if its reconciliation, with the outcome of the current one which isn't
+// observed yet, failed enough times in a row.
+func markDegraded(backoffs *errorBackoffs, obj conditions.Setter, err error) {
+	if err == nil {
+		conditions.Delete(obj, infrav1.DegradedCondition)
+		return
+	}
+	failures := backoffs.failures(obj) + 1
+	if failures < reconciler.DegradedAfterFailures() {
+		return
+	}
+	conditions.Set(obj, &clusterv1.Condition{
+		Type:     infrav1.DegradedCondition,
+		Status:   corev1.ConditionTrue,
+		Severity: clusterv1.ConditionSeverityWarning,
+		Reason:   infrav1.ReconcileFailingReason,
+		Message:  fmt.Sprintf("%d reconciliations failed in a row, next one in %s: %s", failures, reconciler.ErrorBackoff(failures), err.Error()),
+	})
+}
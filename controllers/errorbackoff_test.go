@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+func TestErrorBackoffs(t *testing.T) {
+	g := NewWithT(t)
+	reconciler.SetErrorBackoff(time.Minute, 10*time.Minute, 3)
+	defer reconciler.SetErrorBackoff(0, 0, 0)
+
+	now := time.Now()
+	backoffs := newErrorBackoffs()
+	backoffs.now = func() time.Time { return now }
+	azureCluster := &infrav1.AzureCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster", Generation: 1}}
+	err := errors.New("credentials expired")
+
+	// Each failure in a row doubles the backoff.
+	g.Expect(backoffs.wait(azureCluster)).To(BeZero())
+	backoffs.observe(azureCluster, err)
+	g.Expect(backoffs.wait(azureCluster)).To(Equal(time.Minute))
+	backoffs.observe(azureCluster, err)
+	g.Expect(backoffs.wait(azureCluster)).To(Equal(2 * time.Minute))
+	now = now.Add(2 * time.Minute)
+	g.Expect(backoffs.wait(azureCluster)).To(BeZero())
+
+	// The object is degraded once it failed enough times in a row.
+	markDegraded(backoffs, azureCluster, err)
+	backoffs.observe(azureCluster, err)
+	g.Expect(conditions.IsTrue(azureCluster, infrav1.DegradedCondition)).To(BeTrue())
+	g.Expect(conditions.GetMessage(azureCluster, infrav1.DegradedCondition)).To(Equal("3 reconciliations failed in a row, next one in 4m0s: credentials expired"))
+	g.Expect(backoffs.wait(azureCluster)).To(Equal(4 * time.Minute))
+
+	// Changes to the spec end the backoff.
+	changed := azureCluster.DeepCopy()
+	changed.Generation = 2
+	g.Expect(backoffs.wait(changed)).To(BeZero())
+
+	// A successful reconciliation resets the backoff.
+	markDegraded(backoffs, azureCluster, nil)
+	backoffs.observe(azureCluster, nil)
+	g.Expect(conditions.Has(azureCluster, infrav1.DegradedCondition)).To(BeFalse())
+	g.Expect(backoffs.wait(azureCluster)).To(BeZero())
+	backoffs.observe(azureCluster, err)
+	g.Expect(backoffs.wait(azureCluster)).To(Equal(time.Minute))
+}
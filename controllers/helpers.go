@@ -18,11 +18,17 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
@@ -42,6 +49,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	capiv1exp "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
@@ -109,6 +117,68 @@ func AzureClusterToAzureMachinesMapper(ctx context.Context, c client.Client, ro
 	}, nil
 }
 
+// ClusterIdentitySecretToAzureClustersMapper creates a mapping handler to transform Secrets into the AzureClusters using
+// an AzureClusterIdentity with the Secret as client secret, so rotated secrets are picked up right away.
+func ClusterIdentitySecretToAzureClustersMapper(ctx context.Context, c client.Client, log logr.Logger) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		ctx, cancel := context.WithTimeout(ctx, reconciler.DefaultMappingTimeout)
+		defer cancel()
+
+		secret, ok := o.(*corev1.Secret)
+		if !ok {
+			log.Error(errors.Errorf("expected a Secret, got %T instead", o), "failed to map Secret")
+			return nil
+		}
+
+		identities := &infrav1.AzureClusterIdentityList{}
+		if err := c.List(ctx, identities); err != nil {
+			log.Error(err, "failed to list AzureClusterIdentities")
+			return nil
+		}
+		referencing := map[client.ObjectKey]bool{}
+		for _, identity := range identities.Items {
+			ref := identity.Spec.ClientSecret
+			namespace := ref.Namespace
+			if namespace == "" {
+				namespace = identity.Namespace
+			}
+			if ref.Name == secret.Name && namespace == secret.Namespace {
+				referencing[client.ObjectKey{Namespace: identity.Namespace, Name: identity.Name}] = true
+			}
+		}
+		if len(referencing) == 0 {
+			return nil
+		}
+
+		azureClusters := &infrav1.AzureClusterList{}
+		if err := c.List(ctx, azureClusters); err != nil {
+			log.Error(err, "failed to list AzureClusters")
+			return nil
+		}
+		var results []ctrl.Request
+		for _, azureCluster := range azureClusters.Items {
+			refs := []*corev1.ObjectReference{azureCluster.Spec.IdentityRef}
+			for _, serviceRef := range azureCluster.Spec.ServiceIdentityRefs {
+				refs = append(refs, serviceRef.IdentityRef)
+			}
+			for _, ref := range refs {
+				if ref == nil {
+					continue
+				}
+				namespace := ref.Namespace
+				if namespace == "" {
+					namespace = azureCluster.Namespace
+				}
+				if referencing[client.ObjectKey{Namespace: namespace, Name: ref.Name}] {
+					results = append(results, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: azureCluster.Namespace, Name: azureCluster.Name}})
+					break
+				}
+			}
+		}
+		return results
+	}
+}
+
 // GetOwnerClusterName returns the name of the owning Cluster by finding a clusterv1.Cluster in the ownership references.
 func GetOwnerClusterName(obj metav1.ObjectMeta) (string, bool) {
 	for _, ref := range obj.OwnerReferences {
@@ -170,6 +240,12 @@ func GetCloudProviderSecret(d azure.ClusterScoper, namespace, name string, owner
 		},
 	}
 
+	// Machines without an identity of their own use the cloud provider identity of the cluster, if it has one.
+	if identityType == infrav1.VMIdentityNone && d.CloudProviderIdentityID() != "" {
+		identityType = infrav1.VMIdentityUserAssigned
+		userIdentityID = d.CloudProviderIdentityID()
+	}
+
 	var controlPlaneConfig, workerNodeConfig *CloudProviderConfig
 
 	switch identityType {
@@ -547,11 +623,12 @@ func GetAzureMachinePoolByName(ctx context.Context, c client.Client, namespace,
 
 // ShouldDeleteIndividualResources returns false if the resource group is managed and the whole cluster is being deleted
 // meaning that we can rely on a single resource group delete operation as opposed to deleting every individual VM resource.
+// Resources are always deleted individually if the deletion policy of the cluster keeps the resource group.
 func ShouldDeleteIndividualResources(ctx context.Context, clusterScope *scope.ClusterScope) bool {
 	ctx, span := tele.Tracer().Start(ctx, "controllers.ShouldDeleteIndividualResources")
 	defer span.End()
 
-	if clusterScope.Cluster.DeletionTimestamp.IsZero() {
+	if clusterScope.Cluster.DeletionTimestamp.IsZero() || clusterScope.DeletionPolicy() != infrav1.DeletionPolicyDelete {
 		return true
 	}
 	grpSvc := groups.New(clusterScope)
@@ -561,6 +638,15 @@ func ShouldDeleteIndividualResources(ctx context.Context, clusterScope *scope.Cl
 	return err != nil || !managed
 }
 
+// machineDeletionPolicy returns what happens to the Azure resources of a machine when its AzureMachine is deleted,
+// which defaults to the deletion policy of its cluster.
+func machineDeletionPolicy(machineScope *scope.MachineScope, clusterScope *scope.ClusterScope) infrav1.DeletionPolicy {
+	if machineScope.AzureMachine.Spec.DeletionPolicy != "" {
+		return machineScope.AzureMachine.Spec.DeletionPolicy
+	}
+	return clusterScope.DeletionPolicy()
+}
+
 // GetClusterIdentityFromRef returns the AzureClusterIdentity referenced by the AzureCluster.
 func GetClusterIdentityFromRef(ctx context.Context, c client.Client, azureClusterNamespace string, ref *corev1.ObjectReference) (*infrav1.AzureClusterIdentity, error) {
 	identity := &infrav1.AzureClusterIdentity{}
@@ -577,3 +663,323 @@ func GetClusterIdentityFromRef(ctx context.Context, c client.Client, azureCluste
 	}
 	return nil, nil
 }
+
+// WithAzureTimeouts returns a context carrying the Azure service reconcile and call timeouts of a cluster.
+func WithAzureTimeouts(ctx context.Context, clusterScope *scope.ClusterScope) context.Context {
+	ctx = reconciler.WithAzureServiceReconcileTimeout(ctx, clusterScope.ServiceReconcileTimeout())
+	return reconciler.WithAzureCallTimeout(ctx, clusterScope.AzureCallTimeout())
+}
+
+// TraceScope describes the cluster whose Azure resources a reconciliation manages.
+type TraceScope interface {
+	ClusterName() string
+	SubscriptionID() string
+	ResourceGroup() string
+}
+
+// WithTraceAttributes returns a context whose spans carry the name of the cluster of a scope, and the subscription
+// and resource group of its Azure resources. They are also added to the span of the context, which was started before
+// the cluster was known.
+func WithTraceAttributes(ctx context.Context, traceScope TraceScope) context.Context {
+	attributes := tele.ClusterAttributes(traceScope.ClusterName(), traceScope.SubscriptionID(), traceScope.ResourceGroup())
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+	return tele.WithAttributes(ctx, attributes...)
+}
+
+// WithDryRun returns a context in which the changes to the Azure resources of a cluster and its machines are logged
+// to logger instead of being made, and the dry run recording them, if the cluster is in dry run or read-only mode.
+func WithDryRun(ctx context.Context, clusterScope *scope.ClusterScope, logger logr.Logger) (context.Context, *azure.DryRun) {
+	var dryRun *azure.DryRun
+	switch {
+	case clusterScope.ReadOnly():
+		dryRun = azure.NewReadOnlyDryRun(logger)
+	case clusterScope.DryRun():
+		dryRun = azure.NewDryRun(logger)
+	default:
+		return ctx, nil
+	}
+	return azure.WithDryRun(ctx, dryRun), dryRun
+}
+
+// WithManagerDryRun returns a context in which the changes to Azure resources are logged to logger instead of being
+// made, and the dry run recording them, if the controller runs in dry run or read-only mode. It is used for objects
+// which don't belong to an AzureCluster, like those of managed clusters, and so can't be annotated for either mode.
+func WithManagerDryRun(ctx context.Context, logger logr.Logger) (context.Context, *azure.DryRun) {
+	var dryRun *azure.DryRun
+	switch {
+	case azure.GetARMClientOptions().ReadOnly:
+		dryRun = azure.NewReadOnlyDryRun(logger)
+	case azure.GetARMClientOptions().DryRun:
+		dryRun = azure.NewDryRun(logger)
+	default:
+		return ctx, nil
+	}
+	return azure.WithDryRun(ctx, dryRun), dryRun
+}
+
+// RecordStuckLongRunningOperation records a warning event on an object whose long running operation has been in
+// flight for longer than the warning age, so operations which may never complete don't go unnoticed until they are
+// dropped.
+func RecordStuckLongRunningOperation(recorder record.EventRecorder, obj runtime.Object, future *infrav1.Future) {
+	age, stuck := scope.LongRunningOperationStuck(future)
+	if !stuck {
+		return
+	}
+	recorder.Eventf(obj, corev1.EventTypeWarning, infrav1.LongRunningOperationStuckReason,
+		"Long running %s operation on %s has been in flight for %s, and is dropped after %s",
+		future.Type, future.Name, age.Round(time.Second), reconciler.LongRunningOperationMaxAge())
+}
+
+type skippedServicesKey struct{}
+
+// WithSkippedServices returns a context in which the services whose reconciliation is paused by annotations of a
+// cluster are skipped, logging them to logger.
+func WithSkippedServices(ctx context.Context, clusterScope *scope.ClusterScope, logger logr.Logger) context.Context {
+	skipped := clusterScope.SkippedServices()
+	if len(skipped) == 0 {
+		return ctx
+	}
+	names := make([]string, 0, len(skipped))
+	for name := range skipped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	logger.Info("Skipping services paused by annotations", "services", names)
+	return context.WithValue(ctx, skippedServicesKey{}, skipped)
+}
+
+// serviceSkipped returns true if the reconciliation of a service is paused in a context.
+func serviceSkipped(ctx context.Context, name string) bool {
+	skipped, _ := ctx.Value(skippedServicesKey{}).(map[string]bool)
+	return skipped[name]
+}
+
+// skippedServiceRequeueAfter is how long the deletion of an object waits for a paused service to be resumed.
+const skippedServiceRequeueAfter = time.Minute
+
+// wrapService wraps a service so its reconciliation can be paused by annotation, is measured, and times out, under
+// the given service name, e.g. "virtualmachines".
+func wrapService(name string, metricsScope ServiceMetricsScope, svc azure.Reconciler) azure.Reconciler {
+	return withSkipAnnotation(name, WithServiceMetrics(name, metricsScope, withServiceTimeout(svc)))
+}
+
+// wrapClusterService wraps a service of a cluster as wrapService does, and additionally updates its service status.
+// Services with a spec in specs are only reconciled when their spec changes or the spec resync period passes.
+func wrapClusterService(name string, clusterScope *scope.ClusterScope, specs map[string]func() interface{}, svc azure.Reconciler) azure.Reconciler {
+	svc = withServiceTimeout(svc)
+	if spec, ok := specs[name]; ok {
+		svc = withSpecHash(name, clusterScope, spec, svc)
+	}
+	return withSkipAnnotation(name, WithServiceMetrics(name, clusterScope, withServiceStatus(name, svc)))
+}
+
+// skippableReconciler is a service whose reconciliation can be paused by annotating the cluster with
+// infrav1.SkipServiceAnnotationPrefix followed by the name of the service.
+type skippableReconciler struct {
+	azure.Reconciler
+	name string
+}
+
+// withSkipAnnotation wraps a service so its reconciliation can be paused by the annotation of the given service name,
+// e.g. "loadbalancers".
+func withSkipAnnotation(name string, svc azure.Reconciler) azure.Reconciler {
+	return &skippableReconciler{Reconciler: svc, name: name}
+}
+
+// Reconcile reconciles the resources of the service, unless the service is paused.
+func (r *skippableReconciler) Reconcile(ctx context.Context) error {
+	if serviceSkipped(ctx, r.name) {
+		return nil
+	}
+	return r.Reconciler.Reconcile(tele.WithAttributes(ctx, tele.ServiceKey.String(r.name)))
+}
+
+// Delete deletes the resources of the service. While the service is paused, it returns a transient error instead, so
+// the deletion of the object waits for the service to be resumed rather than leaving its resources behind.
+func (r *skippableReconciler) Delete(ctx context.Context) error {
+	if serviceSkipped(ctx, r.name) {
+		return azure.WithTransientError(errors.Errorf("deletion of %s is paused by annotation %s%s", r.name, infrav1.SkipServiceAnnotationPrefix, r.name), skippedServiceRequeueAfter)
+	}
+	return r.Reconciler.Delete(tele.WithAttributes(ctx, tele.ServiceKey.String(r.name)))
+}
+
+// statusReconciler is a service whose requests to Azure Resource Manager update the status of the service in the
+// service statuses of the context.
+type statusReconciler struct {
+	azure.Reconciler
+	name string
+}
+
+// withServiceStatus wraps a service so the responses to its requests update its service status, e.g. "loadbalancers".
+func withServiceStatus(name string, svc azure.Reconciler) azure.Reconciler {
+	return &statusReconciler{Reconciler: svc, name: name}
+}
+
+// Reconcile reconciles the resources of the service, clearing its last error once it succeeds.
+func (r *statusReconciler) Reconcile(ctx context.Context) error {
+	if err := r.Reconciler.Reconcile(azure.WithServiceName(ctx, r.name)); err != nil {
+		return err
+	}
+	azure.ServiceReconciled(ctx, r.name)
+	return nil
+}
+
+// Delete deletes the resources of the service.
+func (r *statusReconciler) Delete(ctx context.Context) error {
+	return r.Reconciler.Delete(azure.WithServiceName(ctx, r.name))
+}
+
+// specHashes stores the hashes of the specs of services when their Azure resources were last reconciled.
+type specHashes interface {
+	SpecResyncPeriod() time.Duration
+	ServiceSpecHash(service string) (string, time.Time)
+	SetServiceSpecHash(service, hash string)
+	AdditionalTags() infrav1.Tags
+}
+
+// specHashReconciler is a service which is reconciled only when the hash of its spec changed since its Azure
+// resources were last reconciled, or once the spec resync period passed, which spares reading the Azure resources of
+// services in sync.
+type specHashReconciler struct {
+	azure.Reconciler
+	name   string
+	hashes specHashes
+	spec   func() interface{}
+}
+
+// withSpecHash wraps a service so it is only reconciled when the spec returned by spec changes or the spec resync
+// period passes.
+func withSpecHash(name string, hashes specHashes, spec func() interface{}, svc azure.Reconciler) azure.Reconciler {
+	return &specHashReconciler{Reconciler: svc, name: name, hashes: hashes, spec: spec}
+}
+
+// Reconcile reconciles the resources of the service, unless their spec didn't change since they were last reconciled
+// within the spec resync period.
+func (r *specHashReconciler) Reconcile(ctx context.Context) error {
+	period := r.hashes.SpecResyncPeriod()
+	if period == 0 {
+		return r.Reconciler.Reconcile(ctx)
+	}
+	hash, reconciledAt := r.hashes.ServiceSpecHash(r.name)
+	if hash != "" && hash == r.hash() && time.Since(reconciledAt) < period {
+		return nil
+	}
+	if err := r.Reconciler.Reconcile(ctx); err != nil {
+		return err
+	}
+	// The spec is hashed again, as services complete it, e.g. with the IDs of the resources they created.
+	r.hashes.SetServiceSpecHash(r.name, r.hash())
+	return nil
+}
+
+// hash returns the hash of the spec of the service and of the tags its resources are created with, or an empty hash
+// if the spec can't be hashed.
+func (r *specHashReconciler) hash() string {
+	data, err := json.Marshal([]interface{}{r.spec(), r.hashes.AdditionalTags()})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
+// maxReportedDryRunChanges is the number of changes of a dry run listed in its condition and event.
+const maxReportedDryRunChanges = 10
+
+// ReportDryRun reports the changes a dry run would have made to the Azure resources of an object in its DryRun
+// condition and an event, or the changes refused in read-only mode in its ReadOnly condition and a warning event. The
+// conditions are removed if the object isn't in the respective mode.
+func ReportDryRun(recorder record.EventRecorder, obj conditions.Setter, dryRun *azure.DryRun) {
+	if dryRun == nil {
+		conditions.Delete(obj, infrav1.DryRunCondition)
+		conditions.Delete(obj, infrav1.ReadOnlyCondition)
+		return
+	}
+	condition, otherCondition := infrav1.DryRunCondition, infrav1.ReadOnlyCondition
+	if dryRun.ReadOnly() {
+		condition, otherCondition = infrav1.ReadOnlyCondition, infrav1.DryRunCondition
+	}
+	conditions.Delete(obj, otherCondition)
+	changes := dryRun.Changes()
+	if len(changes) == 0 {
+		conditions.MarkTrue(obj, condition)
+		return
+	}
+
+	summary := dryRunSummary(changes)
+	if dryRun.ReadOnly() {
+		conditions.MarkFalse(obj, infrav1.ReadOnlyCondition, infrav1.MutationsRefusedReason, clusterv1.ConditionSeverityWarning, "read-only mode refused to %s", summary)
+	} else {
+		conditions.MarkFalse(obj, infrav1.DryRunCondition, infrav1.DryRunChangesPendingReason, clusterv1.ConditionSeverityInfo, "dry run would %s", summary)
+	}
+	RecordDryRun(recorder, obj, dryRun)
+}
+
+// RecordDryRun reports the changes a dry run would have made to the Azure resources of an object, or the changes
+// refused in read-only mode, in an event. Unlike ReportDryRun, it suits objects without conditions.
+func RecordDryRun(recorder record.EventRecorder, obj runtime.Object, dryRun *azure.DryRun) {
+	if dryRun == nil {
+		return
+	}
+	changes := dryRun.Changes()
+	if len(changes) == 0 {
+		return
+	}
+	if dryRun.ReadOnly() {
+		recorder.Eventf(obj, corev1.EventTypeWarning, infrav1.MutationsRefusedReason, "Read-only mode refused to %s", dryRunSummary(changes))
+		return
+	}
+	recorder.Eventf(obj, corev1.EventTypeNormal, infrav1.DryRunChangesPendingReason, "Dry run would %s", dryRunSummary(changes))
+}
+
+// dryRunSummary lists the first changes of a dry run, and how many more there are.
+func dryRunSummary(changes []string) string {
+	if len(changes) > maxReportedDryRunChanges {
+		return fmt.Sprintf("%s, and %d more", strings.Join(changes[:maxReportedDryRunChanges], ", "), len(changes)-maxReportedDryRunChanges)
+	}
+	return strings.Join(changes, ", ")
+}
+
+// timeoutReconciler limits how long a service may take to reconcile or delete its resources to the Azure service
+// reconcile timeout of the context, and makes the objects of services throttled by Azure requeue once the throttling
+// ends.
+type timeoutReconciler struct {
+	azure.Reconciler
+}
+
+// withServiceTimeout wraps a service so its reconciliation and deletion time out.
+func withServiceTimeout(svc azure.Reconciler) azure.Reconciler {
+	return &timeoutReconciler{Reconciler: svc}
+}
+
+// Reconcile reconciles the resources of the service, for at most the Azure service reconcile timeout.
+func (r *timeoutReconciler) Reconcile(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.AzureServiceReconcileTimeout(ctx))
+	defer cancel()
+	return withRequeue(r.Reconciler.Reconcile(ctx))
+}
+
+// Delete deletes the resources of the service, for at most the Azure service reconcile timeout.
+func (r *timeoutReconciler) Delete(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, reconciler.AzureServiceReconcileTimeout(ctx))
+	defer cancel()
+	return withRequeue(r.Reconciler.Delete(ctx))
+}
+
+// conflictRequeueAfter is how long a service whose requests conflicted with the state of a resource waits before
+// being reconciled again.
+const conflictRequeueAfter = 15 * time.Second
+
+// withRequeue turns errors of requests throttled by Azure into transient errors, requeued after the Retry-After of
+// the throttling, and errors of requests conflicting with the state of a resource, like another operation in
+// progress, into transient errors requeued shortly, since the next reconciliation may not run into the conflict.
+func withRequeue(err error) error {
+	if retryAfter, ok := azure.ThrottledRetryAfter(err); ok {
+		return azure.WithTransientError(err, retryAfter)
+	}
+	var reconcileError azure.ReconcileError
+	if class, ok := azure.ClassifyError(err); ok && class == azure.ConflictErrorClass && !errors.As(err, &reconcileError) {
+		return azure.WithTransientError(err, conflictRequeueAfter)
+	}
+	return err
+}
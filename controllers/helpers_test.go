@@ -18,9 +18,13 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
@@ -35,14 +39,21 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/mocks"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/internal/test/mock_log"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 func TestAzureClusterToAzureMachinesMapper(t *testing.T) {
@@ -82,6 +93,51 @@ func TestAzureClusterToAzureMachinesMapper(t *testing.T) {
 	g.Expect(requests).To(HaveLen(2))
 }
 
+func TestClusterIdentitySecretToAzureClustersMapper(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(g)
+	newAzureCluster := func(namespace, name string, ref *corev1.ObjectReference) *infrav1.AzureCluster {
+		return &infrav1.AzureCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       infrav1.AzureClusterSpec{IdentityRef: ref},
+		}
+	}
+	initObjects := []runtime.Object{
+		&infrav1.AzureClusterIdentity{
+			ObjectMeta: metav1.ObjectMeta{Name: "identity", Namespace: "identities"},
+			Spec:       infrav1.AzureClusterIdentitySpec{ClientSecret: corev1.SecretReference{Name: "client-secret"}},
+		},
+		&infrav1.AzureClusterIdentity{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-identity", Namespace: "identities"},
+			Spec:       infrav1.AzureClusterIdentitySpec{ClientSecret: corev1.SecretReference{Name: "other-secret", Namespace: "identities"}},
+		},
+		newAzureCluster("default", "cluster-1", &corev1.ObjectReference{Name: "identity", Namespace: "identities"}),
+		newAzureCluster("identities", "cluster-2", &corev1.ObjectReference{Name: "identity"}),
+		newAzureCluster("default", "cluster-3", &corev1.ObjectReference{Name: "other-identity", Namespace: "identities"}),
+		newAzureCluster("default", "cluster-4", nil),
+		&infrav1.AzureCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-5", Namespace: "default"},
+			Spec: infrav1.AzureClusterSpec{
+				IdentityRef: &corev1.ObjectReference{Name: "other-identity", Namespace: "identities"},
+				ServiceIdentityRefs: []infrav1.ServiceIdentityRef{
+					{Service: infrav1.PrivateDNSService, IdentityRef: &corev1.ObjectReference{Name: "identity", Namespace: "identities"}},
+				},
+			},
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(initObjects...).Build()
+
+	mapper := ClusterIdentitySecretToAzureClustersMapper(context.Background(), client, ctrl.Log)
+	requests := mapper(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "client-secret", Namespace: "identities"}})
+	g.Expect(requests).To(ConsistOf(
+		ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "cluster-1"}},
+		ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "identities", Name: "cluster-2"}},
+		ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "cluster-5"}},
+	))
+
+	g.Expect(mapper(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "client-secret", Namespace: "default"}})).To(BeEmpty())
+}
+
 func TestGetCloudProviderConfig(t *testing.T) {
 	g := NewWithT(t)
 	scheme := runtime.NewScheme()
@@ -94,6 +150,10 @@ func TestGetCloudProviderConfig(t *testing.T) {
 	azureCluster.Default()
 	azureClusterCustomVnet := newAzureClusterWithCustomVnet("foo", "bar")
 	azureClusterCustomVnet.Default()
+	azureClusterCloudProviderIdentity := newAzureCluster("foo", "bar")
+	azureClusterCloudProviderIdentity.Spec.CloudProviderIdentity = &infrav1.CloudProviderIdentity{}
+	azureClusterCloudProviderIdentity.Default()
+	cloudProviderIdentityID := "/subscriptions/baz/resourceGroups/bar/providers/Microsoft.ManagedIdentity/userAssignedIdentities/foo-cloud-provider"
 
 	cases := map[string]struct {
 		cluster                    *clusterv1.Cluster
@@ -125,6 +185,21 @@ func TestGetCloudProviderConfig(t *testing.T) {
 			expectedControlPlaneConfig: userAssignedControlPlaneCloudConfig,
 			expectedWorkerNodeConfig:   userAssignedWorkerNodeCloudConfig,
 		},
+		"cloud provider identity": {
+			cluster:                    cluster,
+			azureCluster:               azureClusterCloudProviderIdentity,
+			identityType:               infrav1.VMIdentityNone,
+			expectedControlPlaneConfig: strings.Replace(userAssignedControlPlaneCloudConfig, "foobar", cloudProviderIdentityID, 1),
+			expectedWorkerNodeConfig:   strings.Replace(userAssignedWorkerNodeCloudConfig, "foobar", cloudProviderIdentityID, 1),
+		},
+		"user-assigned-identity with cloud provider identity": {
+			cluster:                    cluster,
+			azureCluster:               azureClusterCloudProviderIdentity,
+			identityType:               infrav1.VMIdentityUserAssigned,
+			identityID:                 "foobar",
+			expectedControlPlaneConfig: userAssignedControlPlaneCloudConfig,
+			expectedWorkerNodeConfig:   userAssignedWorkerNodeCloudConfig,
+		},
 		"serviceprincipal with custom vnet": {
 			cluster:                    cluster,
 			azureCluster:               azureClusterCustomVnet,
@@ -614,3 +689,320 @@ const (
     "cloudProviderBackoffJitter": 1.2000000000000002
 }`
 )
+
+func TestWithServiceTimeout(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	clusterScope := &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			infrav1.ServiceReconcileTimeoutAnnotation: "45m",
+			infrav1.AzureCallTimeoutAnnotation:        "20m",
+		}},
+	}}
+	ctx := WithAzureTimeouts(context.Background(), clusterScope)
+	g.Expect(reconciler.AzureCallTimeout(ctx)).To(Equal(20 * time.Minute))
+
+	expectDeadline := func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		g.Expect(ok).To(BeTrue())
+		g.Expect(time.Until(deadline)).To(BeNumerically("~", 45*time.Minute, time.Minute))
+		return nil
+	}
+	svcMock := mocks.NewMockReconciler(mockCtrl)
+	svcMock.EXPECT().Reconcile(gomock.Any()).DoAndReturn(expectDeadline)
+	svcMock.EXPECT().Delete(gomock.Any()).DoAndReturn(expectDeadline)
+
+	svc := withServiceTimeout(svcMock)
+	g.Expect(svc.Reconcile(ctx)).To(Succeed())
+	g.Expect(svc.Delete(ctx)).To(Succeed())
+}
+
+func TestWithServiceTimeoutThrottled(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	throttled := autorest.DetailedError{
+		StatusCode: http.StatusTooManyRequests,
+		Response:   &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"20"}}},
+	}
+	svcMock := mocks.NewMockReconciler(mockCtrl)
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(throttled)
+	svcMock.EXPECT().Delete(gomock.Any()).Return(errors.New("some error happened"))
+
+	svc := withServiceTimeout(svcMock)
+	err := svc.Reconcile(context.Background())
+	var reconcileError azure.ReconcileError
+	g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+	g.Expect(reconcileError.IsTransient()).To(BeTrue())
+	g.Expect(reconcileError.RequeueAfter()).To(Equal(20 * time.Second))
+
+	err = svc.Delete(context.Background())
+	g.Expect(errors.As(err, &reconcileError)).To(BeFalse())
+}
+
+func TestWithServiceTimeoutConflict(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	conflict := autorest.DetailedError{StatusCode: http.StatusConflict}
+	invalid := autorest.DetailedError{StatusCode: http.StatusBadRequest}
+	svcMock := mocks.NewMockReconciler(mockCtrl)
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(invalid)
+	svcMock.EXPECT().Delete(gomock.Any()).Return(fmt.Errorf("failed to delete subnet: %w", conflict))
+
+	svc := withServiceTimeout(svcMock)
+	var reconcileError azure.ReconcileError
+	g.Expect(errors.As(svc.Reconcile(context.Background()), &reconcileError)).To(BeFalse())
+
+	err := svc.Delete(context.Background())
+	g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+	g.Expect(reconcileError.IsTransient()).To(BeTrue())
+	g.Expect(reconcileError.RequeueAfter()).To(Equal(conflictRequeueAfter))
+	g.Expect(err.Error()).To(ContainSubstring("failed to delete subnet"))
+}
+
+func TestWithSkipAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	clusterScope := &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			infrav1.SkipServiceAnnotationPrefix + "loadbalancers": "true",
+			infrav1.SkipServiceAnnotationPrefix + "subnets":       "false",
+		}},
+	}}
+	ctx := WithSkippedServices(context.Background(), clusterScope, klogr.New())
+
+	// Only the services which aren't paused are called.
+	subnetsMock := mocks.NewMockReconciler(mockCtrl)
+	subnetsMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	subnetsMock.EXPECT().Delete(gomock.Any()).Return(nil)
+	loadBalancersMock := mocks.NewMockReconciler(mockCtrl)
+
+	subnetsSvc := withSkipAnnotation("subnets", subnetsMock)
+	g.Expect(subnetsSvc.Reconcile(ctx)).To(Succeed())
+	g.Expect(subnetsSvc.Delete(ctx)).To(Succeed())
+
+	loadBalancersSvc := withSkipAnnotation("loadbalancers", loadBalancersMock)
+	g.Expect(loadBalancersSvc.Reconcile(ctx)).To(Succeed())
+	err := loadBalancersSvc.Delete(ctx)
+	var reconcileError azure.ReconcileError
+	g.Expect(errors.As(err, &reconcileError)).To(BeTrue())
+	g.Expect(reconcileError.IsTransient()).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring("deletion of loadbalancers is paused"))
+
+	loadBalancersMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	g.Expect(loadBalancersSvc.Reconcile(context.Background())).To(Succeed())
+}
+
+func TestWithServiceStatus(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	statuses := azure.NewServiceStatuses([]infrav1.ServiceStatus{
+		{Service: "subnets", LastError: "NetcfgInvalidSubnet: Subnet is invalid."},
+	})
+	ctx := azure.WithServiceStatuses(context.Background(), statuses)
+
+	// The last error of a service is only cleared once it reconciles successfully.
+	subnetsMock := mocks.NewMockReconciler(mockCtrl)
+	subnetsMock.EXPECT().Reconcile(gomock.Any()).Return(errors.New("failed"))
+	subnetsMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	subnetsSvc := withServiceStatus("subnets", subnetsMock)
+
+	g.Expect(subnetsSvc.Reconcile(ctx)).NotTo(Succeed())
+	g.Expect(statuses.List(nil)).To(Equal([]infrav1.ServiceStatus{
+		{Service: "subnets", LastError: "NetcfgInvalidSubnet: Subnet is invalid."},
+	}))
+	g.Expect(subnetsSvc.Reconcile(ctx)).To(Succeed())
+	g.Expect(statuses.List(nil)).To(Equal([]infrav1.ServiceStatus{
+		{Service: "subnets"},
+	}))
+}
+
+func TestWithSpecHash(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	clusterScope := &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	spec := "a"
+	svcMock := mocks.NewMockReconciler(mockCtrl)
+	svc := withSpecHash("subnets", clusterScope, func() interface{} { return spec }, svcMock)
+
+	// By default, the service is reconciled every time.
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(nil).Times(2)
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+	g.Expect(clusterScope.AzureCluster.Status.ServiceSpecHashes).To(BeEmpty())
+
+	reconciler.SetSpecResyncPeriod(time.Hour)
+	defer reconciler.SetSpecResyncPeriod(reconciler.DefaultSpecResyncPeriod)
+
+	// The service is reconciled the first time, but not again while its spec doesn't change.
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+	g.Expect(clusterScope.AzureCluster.Status.ServiceSpecHashes).To(HaveLen(1))
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+
+	// A changed spec is reconciled until its reconciliation succeeds.
+	spec = "b"
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(errors.New("some error happened"))
+	g.Expect(svc.Reconcile(context.Background())).NotTo(Succeed())
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+
+	// The service is reconciled again once the resync period passed.
+	clusterScope.AzureCluster.Status.ServiceSpecHashes[0].ReconciledAt = metav1.NewTime(time.Now().Add(-time.Hour))
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+
+	// Drift detection compares the resources to their specs on every reconciliation.
+	clusterScope.AzureCluster.Spec.DriftDetection = &infrav1.DriftDetection{Interval: metav1.Duration{Duration: time.Minute}}
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+
+	svcMock.EXPECT().Delete(gomock.Any()).Return(nil)
+	g.Expect(svc.Delete(context.Background())).To(Succeed())
+}
+
+func TestReportDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	azureCluster := &infrav1.AzureCluster{}
+	recorder := record.NewFakeRecorder(10)
+
+	dryRun := azure.NewDryRun(klogr.New())
+	ReportDryRun(recorder, azureCluster, dryRun)
+	g.Expect(conditions.IsTrue(azureCluster, infrav1.DryRunCondition)).To(BeTrue())
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	clusterScope := &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{infrav1.DryRunAnnotation: "true"},
+	}}}
+	ctx, dryRun := WithDryRun(context.Background(), clusterScope, klogr.New())
+	g.Expect(dryRun).NotTo(BeNil())
+	client := autorest.NewClientWithUserAgent("")
+	azure.SetAutoRestClientDefaults(&client, autorest.NullAuthorizer{})
+	for i := 0; i < 12; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("https://management.azure.com/subscriptions/123/resourceGroups/my-rg-%d", i), nil)
+		resp, err := client.Do(req)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	}
+	ReportDryRun(recorder, azureCluster, dryRun)
+	g.Expect(conditions.IsFalse(azureCluster, infrav1.DryRunCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(azureCluster, infrav1.DryRunCondition)).To(Equal(infrav1.DryRunChangesPendingReason))
+	g.Expect(conditions.GetMessage(azureCluster, infrav1.DryRunCondition)).To(HavePrefix("dry run would delete resourceGroups/my-rg-0, delete resourceGroups/my-rg-1,"))
+	g.Expect(conditions.GetMessage(azureCluster, infrav1.DryRunCondition)).To(HaveSuffix("delete resourceGroups/my-rg-9, and 2 more"))
+	g.Expect(recorder.Events).To(HaveLen(1))
+
+	ReportDryRun(recorder, azureCluster, nil)
+	g.Expect(conditions.Has(azureCluster, infrav1.DryRunCondition)).To(BeFalse())
+
+	_, dryRun = WithDryRun(context.Background(), &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{}}, klogr.New())
+	g.Expect(dryRun).To(BeNil())
+}
+
+func TestReportReadOnly(t *testing.T) {
+	g := NewWithT(t)
+
+	azureCluster := &infrav1.AzureCluster{}
+	recorder := record.NewFakeRecorder(10)
+	conditions.MarkTrue(azureCluster, infrav1.DryRunCondition)
+
+	// Read-only mode takes precedence over dry runs.
+	clusterScope := &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{infrav1.DryRunAnnotation: "true", infrav1.ReadOnlyAnnotation: "true"},
+	}}}
+	ctx, dryRun := WithDryRun(context.Background(), clusterScope, klogr.New())
+	g.Expect(dryRun.ReadOnly()).To(BeTrue())
+	ReportDryRun(recorder, azureCluster, dryRun)
+	g.Expect(conditions.IsTrue(azureCluster, infrav1.ReadOnlyCondition)).To(BeTrue())
+	g.Expect(conditions.Has(azureCluster, infrav1.DryRunCondition)).To(BeFalse())
+
+	client := autorest.NewClientWithUserAgent("")
+	azure.SetAutoRestClientDefaults(&client, autorest.NullAuthorizer{})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg", nil)
+	_, err := client.Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+	ReportDryRun(recorder, azureCluster, dryRun)
+	g.Expect(conditions.IsFalse(azureCluster, infrav1.ReadOnlyCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(azureCluster, infrav1.ReadOnlyCondition)).To(Equal(infrav1.MutationsRefusedReason))
+	g.Expect(*conditions.GetSeverity(azureCluster, infrav1.ReadOnlyCondition)).To(Equal(clusterv1.ConditionSeverityWarning))
+	g.Expect(conditions.GetMessage(azureCluster, infrav1.ReadOnlyCondition)).To(Equal("read-only mode refused to delete resourceGroups/my-rg"))
+	g.Expect(<-recorder.Events).To(Equal("Warning MutationsRefused Read-only mode refused to delete resourceGroups/my-rg"))
+
+	ReportDryRun(recorder, azureCluster, nil)
+	g.Expect(conditions.Has(azureCluster, infrav1.ReadOnlyCondition)).To(BeFalse())
+}
+
+func TestWithManagerDryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	_, dryRun := WithManagerDryRun(context.Background(), klogr.New())
+	g.Expect(dryRun).To(BeNil())
+
+	defer azure.SetARMClientOptions(azure.ARMClientOptions{})
+	azure.SetARMClientOptions(azure.ARMClientOptions{DryRun: true})
+	ctx, dryRun := WithManagerDryRun(context.Background(), klogr.New())
+	g.Expect(dryRun).NotTo(BeNil())
+	g.Expect(azure.DryRunFrom(ctx)).To(BeIdenticalTo(dryRun))
+
+	client := autorest.NewClientWithUserAgent("")
+	azure.SetAutoRestClientDefaults(&client, autorest.NullAuthorizer{})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster", nil)
+	_, err := client.Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Objects without conditions only get the event.
+	recorder := record.NewFakeRecorder(10)
+	RecordDryRun(recorder, &infrav1exp.AzureManagedControlPlane{}, dryRun)
+	g.Expect(<-recorder.Events).To(Equal("Normal DryRunChangesPending Dry run would delete Microsoft.ContainerService/managedClusters/my-cluster"))
+
+	// Read-only mode takes precedence over dry runs.
+	azure.SetARMClientOptions(azure.ARMClientOptions{DryRun: true, ReadOnly: true})
+	ctx, dryRun = WithManagerDryRun(context.Background(), klogr.New())
+	g.Expect(dryRun.ReadOnly()).To(BeTrue())
+	req, _ = http.NewRequestWithContext(ctx, http.MethodDelete, "https://management.azure.com/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.ContainerService/managedClusters/my-cluster", nil)
+	_, err = client.Do(req)
+	g.Expect(err).NotTo(HaveOccurred())
+	RecordDryRun(recorder, &infrav1exp.AzureManagedControlPlane{}, dryRun)
+	g.Expect(<-recorder.Events).To(Equal("Warning MutationsRefused Read-only mode refused to delete Microsoft.ContainerService/managedClusters/my-cluster"))
+}
+
+func TestMachineDeletionPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	clusterScope := &scope.ClusterScope{AzureCluster: &infrav1.AzureCluster{}}
+	machineScope := &scope.MachineScope{AzureMachine: &infrav1.AzureMachine{}}
+	g.Expect(machineDeletionPolicy(machineScope, clusterScope)).To(Equal(infrav1.DeletionPolicyDelete))
+
+	// Machines follow the deletion policy of their cluster, unless they set their own.
+	clusterScope.AzureCluster.Spec.DeletionPolicy = infrav1.DeletionPolicyRetain
+	g.Expect(machineDeletionPolicy(machineScope, clusterScope)).To(Equal(infrav1.DeletionPolicyRetain))
+	machineScope.AzureMachine.Spec.DeletionPolicy = infrav1.DeletionPolicyDelete
+	g.Expect(machineDeletionPolicy(machineScope, clusterScope)).To(Equal(infrav1.DeletionPolicyDelete))
+}
+
+func TestShouldDeleteIndividualResourcesWithRetainedResourceGroup(t *testing.T) {
+	g := NewWithT(t)
+
+	now := metav1.Now()
+	clusterScope := &scope.ClusterScope{
+		Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}},
+		AzureCluster: &infrav1.AzureCluster{
+			Spec: infrav1.AzureClusterSpec{DeletionPolicy: infrav1.DeletionPolicyRetainResourceGroup},
+		},
+	}
+	// The resource group isn't deleted, so the resources of machines must be deleted one by one.
+	g.Expect(ShouldDeleteIndividualResources(context.Background(), clusterScope)).To(BeTrue())
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/images"
+)
+
+// ImageChecker checks that the images of machines exist, with the credentials of the AzureCluster of their cluster.
+type ImageChecker struct {
+	Client client.Client
+}
+
+var _ infrav1.ImageChecker = (*ImageChecker)(nil)
+
+// ImageExists returns whether the image of a machine of a cluster exists, or true if the cluster or its AzureCluster
+// don't exist.
+func (c *ImageChecker) ImageExists(ctx context.Context, namespace, clusterName string, image *infrav1.Image) (bool, error) {
+	clusterScope, err := admissionClusterScope(ctx, c.Client, namespace, clusterName)
+	if err != nil || clusterScope == nil {
+		return true, err
+	}
+	return images.NewChecker(clusterScope).ImageExists(ctx, clusterScope.Location(), image)
+}
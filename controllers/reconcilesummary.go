@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
+)
+
+// ReconcileSummaryReason is the reason of the events summarizing the reconciliation of the services of a cluster.
+const ReconcileSummaryReason = "ReconcileSummary"
+
+// serviceSummary is the outcome of the last reconciliation of each service of a service graph.
+type serviceSummary struct {
+	lock sync.Mutex
+	// services are the names of the services in the order of the graph.
+	services []string
+	results  map[string]*serviceResult
+	// dependsOn are the services each service depends on.
+	dependsOn map[string][]string
+}
+
+type serviceSummaryKey struct{}
+
+// withServiceSummary returns a context in which the reconciliations of service graphs record the outcome of each
+// service in the returned summary.
+func withServiceSummary(ctx context.Context) (context.Context, *serviceSummary) {
+	summary := &serviceSummary{}
+	return context.WithValue(ctx, serviceSummaryKey{}, summary), summary
+}
+
+// serviceSummaryFrom returns the service summary of a context, if any.
+func serviceSummaryFrom(ctx context.Context) *serviceSummary {
+	summary, _ := ctx.Value(serviceSummaryKey{}).(*serviceSummary)
+	return summary
+}
+
+// record records the outcome of the reconciliation of the services of a graph.
+func (s *serviceSummary) record(graph []serviceNode, results map[string]*serviceResult) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.results == nil {
+		s.results = make(map[string]*serviceResult, len(graph))
+		s.dependsOn = make(map[string][]string, len(graph))
+	}
+	for _, node := range graph {
+		if _, ok := s.results[node.name]; !ok {
+			s.services = append(s.services, node.name)
+		}
+		s.results[node.name] = results[node.name]
+		s.dependsOn[node.name] = node.dependsOn
+	}
+}
+
+// String returns a digest of the summary, e.g. "reconciled 11 of 13 services, 2 pending: load balancer (throttled),
+// bastion (waiting for public IP)".
+func (s *serviceSummary) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var pending, failed []string
+	for _, service := range s.services {
+		result := s.results[service]
+		switch {
+		case result.skipped:
+			pending = append(pending, fmt.Sprintf("%s (waiting for %s)", service, s.blockingDependency(service)))
+		case result.err == nil:
+		case isTransient(result.err):
+			pending = append(pending, fmt.Sprintf("%s (%s)", service, pendingState(result.err)))
+		default:
+			reason, _ := azure.ErrorReason(result.err, "error", clusterv1.ConditionSeverityError)
+			failed = append(failed, fmt.Sprintf("%s (%s)", service, reason))
+		}
+	}
+	reconciled := len(s.services) - len(pending) - len(failed)
+	noun := "services"
+	if len(s.services) == 1 {
+		noun = "service"
+	}
+	if reconciled == len(s.services) {
+		return fmt.Sprintf("reconciled %d %s", reconciled, noun)
+	}
+	summary := fmt.Sprintf("reconciled %d of %d %s", reconciled, len(s.services), noun)
+	if len(pending) > 0 {
+		summary += fmt.Sprintf(", %d pending: %s", len(pending), strings.Join(pending, ", "))
+	}
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(", %d failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return summary
+}
+
+// succeeded returns whether a service of the summary was reconciled successfully.
+func (s *serviceSummary) succeeded(service string) bool {
+	if s == nil {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result, ok := s.results[service]
+	return ok && result.err == nil && !result.skipped
+}
+
+// failed returns whether a service of the summary failed with an error which isn't transient.
+func (s *serviceSummary) failed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, result := range s.results {
+		if result.err != nil && !isTransient(result.err) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockingDependency returns the first dependency of a skipped service which failed or was skipped itself.
+func (s *serviceSummary) blockingDependency(service string) string {
+	for _, dep := range s.dependsOn[service] {
+		if result := s.results[dep]; result != nil && (result.err != nil || result.skipped) {
+			return dep
+		}
+	}
+	return "dependencies"
+}
+
+// isTransient returns whether an error only requeues the reconciliation.
+func isTransient(err error) bool {
+	var reconcileError azure.ReconcileError
+	return errors.As(err, &reconcileError) && reconcileError.IsTransient()
+}
+
+// pendingState describes why a service whose reconciliation failed with a transient error is pending.
+func pendingState(err error) string {
+	var notDone *azure.OperationNotDoneError
+	if errors.As(err, &notDone) && notDone.Future != nil {
+		switch notDone.Future.Type {
+		case http.MethodPut:
+			return "creating"
+		case http.MethodPatch:
+			return "updating"
+		case http.MethodDelete:
+			return "deleting"
+		default:
+			return "in progress"
+		}
+	}
+	if _, ok := azure.ThrottledRetryAfter(err); ok {
+		return "throttled"
+	}
+	return "retrying"
+}
+
+// summaryEvents rate limits the events summarizing the reconciliations of objects, so operators get a digest of
+// their services in `kubectl describe` without an event on every reconciliation.
+type summaryEvents struct {
+	lock sync.Mutex
+	// emitted is when the last summary event of each object was emitted.
+	emitted map[types.NamespacedName]time.Time
+	now     func() time.Time
+}
+
+func newSummaryEvents() *summaryEvents {
+	return &summaryEvents{
+		emitted: make(map[types.NamespacedName]time.Time),
+		now:     time.Now,
+	}
+}
+
+// emit emits an event with the summary of the reconciliation of an object, unless the summary is empty or an event
+// was emitted for the object within the summary event interval.
+func (e *summaryEvents) emit(recorder record.EventRecorder, obj client.Object, summary *serviceSummary) {
+	interval := reconciler.SummaryEventInterval()
+	if interval == 0 || summary == nil || len(summary.services) == 0 {
+		return
+	}
+	e.lock.Lock()
+	key := client.ObjectKeyFromObject(obj)
+	if emitted, ok := e.emitted[key]; ok && e.now().Sub(emitted) < interval {
+		e.lock.Unlock()
+		return
+	}
+	e.emitted[key] = e.now()
+	e.lock.Unlock()
+
+	eventType := corev1.EventTypeNormal
+	if summary.failed() {
+		eventType = corev1.EventTypeWarning
+	}
+	recorder.Event(obj, eventType, ReconcileSummaryReason, summary.String())
+}
+
+// forget forgets an object which was deleted.
+func (e *summaryEvents) forget(obj client.Object) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	delete(e.emitted, client.ObjectKeyFromObject(obj))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/mocks"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+func TestServiceSummary(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	first := mocks.NewMockReconciler(mockCtrl)
+	throttled := mocks.NewMockReconciler(mockCtrl)
+	creating := mocks.NewMockReconciler(mockCtrl)
+	dependent := mocks.NewMockReconciler(mockCtrl)
+
+	first.EXPECT().Reconcile(gomockinternal.AContext())
+	throttled.EXPECT().Reconcile(gomockinternal.AContext()).Return(azure.WithTransientError(autorest.DetailedError{StatusCode: http.StatusTooManyRequests}, time.Minute))
+	creating.EXPECT().Reconcile(gomockinternal.AContext()).Return(azure.WithTransientError(azure.NewOperationNotDoneError(&infrav1.Future{Type: http.MethodPut}), 15*time.Second))
+
+	ctx, summary := withServiceSummary(context.TODO())
+	g.Expect(reconcileServiceGraph(ctx, []serviceNode{
+		{name: "first", service: first},
+		{name: "nat gateway", service: throttled, dependsOn: []string{"first"}},
+		{name: "private link", service: creating, dependsOn: []string{"first"}},
+		{name: "dependent", service: dependent, dependsOn: []string{"first", "nat gateway"}},
+	})).NotTo(Succeed())
+	g.Expect(summary.String()).To(Equal("reconciled 1 of 4 services, 3 pending: nat gateway (throttled), private link (creating), dependent (waiting for nat gateway)"))
+	g.Expect(summary.failed()).To(BeFalse())
+
+	summary.record([]serviceNode{{name: "first"}}, map[string]*serviceResult{"first": {err: errors.New("some error happened")}})
+	g.Expect(summary.String()).To(HaveSuffix(", 1 failed: first (error)"))
+	g.Expect(summary.failed()).To(BeTrue())
+}
+
+func TestSummaryEvents(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	events := newSummaryEvents()
+	events.now = func() time.Time { return now }
+	recorder := record.NewFakeRecorder(10)
+	azureCluster := &infrav1.AzureCluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-cluster"}}
+	summary := &serviceSummary{}
+	summary.record([]serviceNode{{name: "resource group"}}, map[string]*serviceResult{"resource group": {}})
+
+	// Summaries are emitted at most once per interval.
+	events.emit(recorder, azureCluster, summary)
+	events.emit(recorder, azureCluster, summary)
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(Equal("Normal ReconcileSummary reconciled 1 service"))
+	now = now.Add(time.Hour)
+	events.emit(recorder, azureCluster, summary)
+	g.Expect(recorder.Events).To(HaveLen(1))
+
+	// Empty summaries, e.g. of reconciliations failing before services are reconciled, aren't emitted.
+	events.forget(azureCluster)
+	events.emit(recorder, azureCluster, &serviceSummary{})
+	g.Expect(recorder.Events).To(HaveLen(1))
+}
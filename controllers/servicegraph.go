@@ -0,0 +1,166 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+// serviceNode is a service of a service graph, reconciled once all the services it depends on are reconciled.
+type serviceNode struct {
+	// name describes the resources of the service, e.g. "virtual network", and identifies the service in the graph.
+	name    string
+	service azure.Reconciler
+	// dependsOn are the names of the services which must be reconciled first. They must come before the service in
+	// the graph.
+	dependsOn []string
+}
+
+// serviceResult is the outcome of reconciling or deleting a service of a service graph.
+type serviceResult struct {
+	done chan struct{}
+	err  error
+	// skipped is true if the service wasn't reconciled or deleted because a service it depends on failed.
+	skipped bool
+}
+
+// maxServiceDeletePasses is the number of times the deletion of a service graph is attempted in a single
+// reconciliation, as long as each attempt deletes more services.
+const maxServiceDeletePasses = 3
+
+// serviceDeleteRetryInterval is how long the deletion of a service graph waits before retrying the services which
+// failed to delete, so Azure notices the resources deleted in the meantime.
+var serviceDeleteRetryInterval = 5 * time.Second
+
+// reconcileServiceGraph reconciles the services of a graph concurrently, each one as soon as the services it depends
+// on are reconciled. Services depending on a service which failed to reconcile are skipped, while the services not
+// depending on it are still reconciled. The outcome of each service is recorded in the service summary of the
+// context, if any. The error of the first failed service in the order of the graph is returned.
+func reconcileServiceGraph(ctx context.Context, graph []serviceNode) error {
+	if err := validateServiceGraph(graph); err != nil {
+		return err
+	}
+	results := runServiceGraph(ctx, graph, nil, azure.Reconciler.Reconcile)
+	serviceSummaryFrom(ctx).record(graph, results)
+	return firstServiceError(graph, results, "reconcile")
+}
+
+// deleteServiceGraph deletes the services of a graph concurrently, each one as soon as the services it depends on are
+// deleted, i.e. dependsOn lists the services whose resources reference the resources of the service. Services
+// depending on a service which failed to delete are skipped, while the services not depending on it are still
+// deleted. As Azure may still reject deleting a resource referenced by another one, e.g. through a dependency missing
+// from the graph, the services which failed or were skipped are retried as long as each attempt deletes more services,
+// unless the error is transient, e.g. a long running operation in progress. The error of the first failed service in
+// the order of the graph is returned.
+func deleteServiceGraph(ctx context.Context, graph []serviceNode) error {
+	if err := validateServiceGraph(graph); err != nil {
+		return err
+	}
+
+	deleted := make(map[string]bool, len(graph))
+	var results map[string]*serviceResult
+	for pass := 1; ; pass++ {
+		results = runServiceGraph(ctx, graph, deleted, azure.Reconciler.Delete)
+
+		progress, transient := false, false
+		for _, node := range graph {
+			result := results[node.name]
+			if result.err == nil && !result.skipped && !deleted[node.name] {
+				deleted[node.name] = true
+				progress = true
+			}
+			var reconcileError azure.ReconcileError
+			if errors.As(result.err, &reconcileError) && reconcileError.IsTransient() {
+				transient = true
+			}
+		}
+		if len(deleted) == len(graph) || !progress || transient || pass == maxServiceDeletePasses {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "failed to retry deleting services")
+		case <-time.After(serviceDeleteRetryInterval):
+		}
+	}
+	return firstServiceError(graph, results, "delete")
+}
+
+// validateServiceGraph checks the services of a graph come after the services they depend on.
+func validateServiceGraph(graph []serviceNode) error {
+	seen := make(map[string]bool, len(graph))
+	for _, node := range graph {
+		for _, dep := range node.dependsOn {
+			if !seen[dep] {
+				return errors.Errorf("service %q depends on %q, which isn't reconciled before it", node.name, dep)
+			}
+		}
+		seen[node.name] = true
+	}
+	return nil
+}
+
+// runServiceGraph runs op on the services of a graph concurrently, each one as soon as op succeeded for the services
+// it depends on. The services which are done, e.g. in a previous attempt, are not run again.
+func runServiceGraph(ctx context.Context, graph []serviceNode, done map[string]bool, op func(azure.Reconciler, context.Context) error) map[string]*serviceResult {
+	results := make(map[string]*serviceResult, len(graph))
+	for _, node := range graph {
+		results[node.name] = &serviceResult{done: make(chan struct{})}
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range graph {
+		node := node
+		result := results[node.name]
+		if done[node.name] {
+			close(result.done)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(result.done)
+			for _, dep := range node.dependsOn {
+				<-results[dep].done
+				if results[dep].err != nil || results[dep].skipped {
+					result.skipped = true
+					return
+				}
+			}
+			result.err = op(node.service, ctx)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// firstServiceError returns the error of the first failed service in the order of the graph.
+func firstServiceError(graph []serviceNode, results map[string]*serviceResult, verb string) error {
+	for _, node := range graph {
+		if err := results[node.name].err; err != nil {
+			return errors.Wrapf(err, "failed to %s %s", verb, node.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure/mocks"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+func TestReconcileServiceGraph(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	first := mocks.NewMockReconciler(mockCtrl)
+	left := mocks.NewMockReconciler(mockCtrl)
+	right := mocks.NewMockReconciler(mockCtrl)
+	last := mocks.NewMockReconciler(mockCtrl)
+
+	// left and right only return once both of them are reconciling, so the graph only completes if they run
+	// concurrently.
+	started := make(chan struct{}, 2)
+	concurrently := func(ctx context.Context) error {
+		started <- struct{}{}
+		for len(started) < 2 {
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}
+	firstCall := first.EXPECT().Reconcile(gomockinternal.AContext())
+	leftCall := left.EXPECT().Reconcile(gomockinternal.AContext()).DoAndReturn(concurrently).After(firstCall)
+	rightCall := right.EXPECT().Reconcile(gomockinternal.AContext()).DoAndReturn(concurrently).After(firstCall)
+	last.EXPECT().Reconcile(gomockinternal.AContext()).After(leftCall).After(rightCall)
+
+	g.Expect(reconcileServiceGraph(context.TODO(), []serviceNode{
+		{name: "first", service: first},
+		{name: "left", service: left, dependsOn: []string{"first"}},
+		{name: "right", service: right, dependsOn: []string{"first"}},
+		{name: "last", service: last, dependsOn: []string{"left", "right"}},
+	})).To(Succeed())
+}
+
+func TestReconcileServiceGraphFailure(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	first := mocks.NewMockReconciler(mockCtrl)
+	failing := mocks.NewMockReconciler(mockCtrl)
+	independent := mocks.NewMockReconciler(mockCtrl)
+	dependent := mocks.NewMockReconciler(mockCtrl)
+	transitive := mocks.NewMockReconciler(mockCtrl)
+
+	first.EXPECT().Reconcile(gomockinternal.AContext())
+	failing.EXPECT().Reconcile(gomockinternal.AContext()).Return(errors.New("some error happened"))
+	independent.EXPECT().Reconcile(gomockinternal.AContext())
+
+	g.Expect(reconcileServiceGraph(context.TODO(), []serviceNode{
+		{name: "first", service: first},
+		{name: "failing", service: failing, dependsOn: []string{"first"}},
+		{name: "independent", service: independent, dependsOn: []string{"first"}},
+		{name: "dependent", service: dependent, dependsOn: []string{"failing", "independent"}},
+		{name: "transitive", service: transitive, dependsOn: []string{"dependent"}},
+	})).To(MatchError("failed to reconcile failing: some error happened"))
+}
+
+func TestReconcileServiceGraphUnknownDependency(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	g.Expect(reconcileServiceGraph(context.TODO(), []serviceNode{
+		{name: "first", service: mocks.NewMockReconciler(mockCtrl), dependsOn: []string{"second"}},
+		{name: "second", service: mocks.NewMockReconciler(mockCtrl)},
+	})).To(MatchError(`service "first" depends on "second", which isn't reconciled before it`))
+}
+
+func TestDeleteServiceGraphRetry(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	retryInterval := serviceDeleteRetryInterval
+	serviceDeleteRetryInterval = 0
+	defer func() { serviceDeleteRetryInterval = retryInterval }()
+
+	referenced := mocks.NewMockReconciler(mockCtrl)
+	referencing := mocks.NewMockReconciler(mockCtrl)
+	dependent := mocks.NewMockReconciler(mockCtrl)
+
+	// Azure rejects the first deletion of referenced, as referencing is only deleted in the same attempt. The services
+	// depending on referenced are deleted once it is.
+	failedCall := referenced.EXPECT().Delete(gomockinternal.AContext()).Return(errors.New("resource in use"))
+	referencingCall := referencing.EXPECT().Delete(gomockinternal.AContext())
+	retryCall := referenced.EXPECT().Delete(gomockinternal.AContext()).After(failedCall).After(referencingCall)
+	dependent.EXPECT().Delete(gomockinternal.AContext()).After(retryCall)
+
+	g.Expect(deleteServiceGraph(context.TODO(), []serviceNode{
+		{name: "referenced", service: referenced},
+		{name: "referencing", service: referencing},
+		{name: "dependent", service: dependent, dependsOn: []string{"referenced"}},
+	})).To(Succeed())
+}
+
+func TestDeleteServiceGraphNoProgress(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	failing := mocks.NewMockReconciler(mockCtrl)
+	dependent := mocks.NewMockReconciler(mockCtrl)
+
+	// The deletion isn't retried, as the first attempt deleted nothing.
+	failing.EXPECT().Delete(gomockinternal.AContext()).Return(errors.New("some error happened"))
+
+	g.Expect(deleteServiceGraph(context.TODO(), []serviceNode{
+		{name: "failing", service: failing},
+		{name: "dependent", service: dependent, dependsOn: []string{"failing"}},
+	})).To(MatchError("failed to delete failing: some error happened"))
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+var (
+	serviceReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capz_service_reconcile_duration_seconds",
+		Help:    "Duration of the reconciliations and deletions of the Azure resources of a service of a cluster, by namespace, cluster, service and operation.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"namespace", "cluster", "service", "operation"})
+	serviceReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "capz_service_reconciles_total",
+		Help: "Number of reconciliations and deletions of the Azure resources of a service of a cluster, by namespace, cluster, service, operation and result.",
+	}, []string{"namespace", "cluster", "service", "operation", "result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(serviceReconcileDuration, serviceReconciles)
+}
+
+// The operations of services.
+const (
+	// ReconcileOperation is the reconciliation of the resources of a service.
+	ReconcileOperation = "reconcile"
+	// DeleteOperation is the deletion of the resources of a service.
+	DeleteOperation = "delete"
+)
+
+// The results of the operations of services.
+const (
+	serviceSucceeded = "success"
+	// serviceTransientError is the result of operations which are requeued, e.g. to wait for a long running
+	// operation or the end of throttling.
+	serviceTransientError = "transient_error"
+	serviceFailed         = "error"
+)
+
+var serviceResults = []string{serviceSucceeded, serviceTransientError, serviceFailed}
+
+// ServiceMetricsScope describes the cluster whose services are measured.
+type ServiceMetricsScope interface {
+	ClusterName() string
+	Namespace() string
+}
+
+// clusterServices are the services and operations measured for each cluster, so their metrics can be deleted along
+// with the cluster.
+var clusterServices = struct {
+	sync.Mutex
+	operations map[[2]string]map[[2]string]bool
+}{operations: map[[2]string]map[[2]string]bool{}}
+
+// ObserveServiceOperation records the duration and result of an operation of a service of a cluster, which started
+// at start and returned err.
+func ObserveServiceOperation(metricsScope ServiceMetricsScope, service, operation string, start time.Time, err error) {
+	namespace, cluster := metricsScope.Namespace(), metricsScope.ClusterName()
+	clusterServices.Lock()
+	key := [2]string{namespace, cluster}
+	if clusterServices.operations[key] == nil {
+		clusterServices.operations[key] = map[[2]string]bool{}
+	}
+	clusterServices.operations[key][[2]string{service, operation}] = true
+	clusterServices.Unlock()
+
+	serviceReconcileDuration.WithLabelValues(namespace, cluster, service, operation).Observe(time.Since(start).Seconds())
+	serviceReconciles.WithLabelValues(namespace, cluster, service, operation, serviceOperationResult(err)).Inc()
+}
+
+// DeleteServiceMetrics deletes the metrics of the services of a cluster which was deleted.
+func DeleteServiceMetrics(namespace, cluster string) {
+	clusterServices.Lock()
+	defer clusterServices.Unlock()
+	key := [2]string{namespace, cluster}
+	for serviceOperation := range clusterServices.operations[key] {
+		service, operation := serviceOperation[0], serviceOperation[1]
+		serviceReconcileDuration.DeleteLabelValues(namespace, cluster, service, operation)
+		for _, result := range serviceResults {
+			serviceReconciles.DeleteLabelValues(namespace, cluster, service, operation, result)
+		}
+	}
+	delete(clusterServices.operations, key)
+}
+
+// serviceOperationResult returns the result label of an operation which returned err.
+func serviceOperationResult(err error) string {
+	if err == nil {
+		return serviceSucceeded
+	}
+	var reconcileError azure.ReconcileError
+	if errors.As(err, &reconcileError) && reconcileError.IsTransient() {
+		return serviceTransientError
+	}
+	return serviceFailed
+}
+
+// metricsReconciler is a service whose reconciliations and deletions are measured.
+type metricsReconciler struct {
+	azure.Reconciler
+	name  string
+	scope ServiceMetricsScope
+}
+
+// WithServiceMetrics wraps a service so the duration and result of its reconciliations and deletions are exported as
+// metrics, labeled by the cluster of the scope and the given service name, e.g. "loadbalancers".
+func WithServiceMetrics(name string, metricsScope ServiceMetricsScope, svc azure.Reconciler) azure.Reconciler {
+	return &metricsReconciler{Reconciler: svc, name: name, scope: metricsScope}
+}
+
+// Reconcile reconciles the resources of the service, recording how long it took and its result.
+func (r *metricsReconciler) Reconcile(ctx context.Context) error {
+	start := time.Now()
+	err := r.Reconciler.Reconcile(ctx)
+	ObserveServiceOperation(r.scope, r.name, ReconcileOperation, start, err)
+	return err
+}
+
+// Delete deletes the resources of the service, recording how long it took and its result.
+func (r *metricsReconciler) Delete(ctx context.Context) error {
+	start := time.Now()
+	err := r.Reconciler.Delete(ctx)
+	ObserveServiceOperation(r.scope, r.name, DeleteOperation, start, err)
+	return err
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/mocks"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+)
+
+func TestWithServiceMetrics(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	clusterScope := &scope.ClusterScope{Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "metrics-test", Name: "my-cluster"}}}
+	svcMock := mocks.NewMockReconciler(mockCtrl)
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(nil)
+	svcMock.EXPECT().Reconcile(gomock.Any()).Return(azure.WithTransientError(errors.New("operation in progress"), 15*time.Second))
+	svcMock.EXPECT().Delete(gomock.Any()).Return(errors.New("some error happened"))
+
+	svc := WithServiceMetrics("loadbalancers", clusterScope, svcMock)
+	g.Expect(svc.Reconcile(context.Background())).To(Succeed())
+	g.Expect(svc.Reconcile(context.Background())).NotTo(Succeed())
+	g.Expect(svc.Delete(context.Background())).NotTo(Succeed())
+
+	g.Expect(testutil.ToFloat64(serviceReconciles.WithLabelValues("metrics-test", "my-cluster", "loadbalancers", ReconcileOperation, serviceSucceeded))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(serviceReconciles.WithLabelValues("metrics-test", "my-cluster", "loadbalancers", ReconcileOperation, serviceTransientError))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(serviceReconciles.WithLabelValues("metrics-test", "my-cluster", "loadbalancers", DeleteOperation, serviceFailed))).To(Equal(1.0))
+	g.Expect(testutil.CollectAndCount(serviceReconcileDuration, "capz_service_reconcile_duration_seconds")).To(BeNumerically(">=", 2))
+
+	// The metrics of a cluster are deleted along with it.
+	DeleteServiceMetrics("metrics-test", "my-cluster")
+	g.Expect(serviceReconcileDuration.DeleteLabelValues("metrics-test", "my-cluster", "loadbalancers", ReconcileOperation)).To(BeFalse())
+	g.Expect(serviceReconciles.DeleteLabelValues("metrics-test", "my-cluster", "loadbalancers", DeleteOperation, serviceFailed)).To(BeFalse())
+}
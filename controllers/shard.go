@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+// Shard is the subset of the clusters of a management cluster a manager reconciles, so that several managers can
+// share them.
+type Shard struct {
+	// Subscriptions are the subscriptions of the clusters in the shard. Clusters of all subscriptions are in the
+	// shard if empty.
+	Subscriptions []string
+	// Selector selects the Clusters in the shard by their labels. All clusters are in the shard if nil.
+	Selector labels.Selector
+}
+
+// shard is the shard of the clusters reconciled by all controllers.
+var shard Shard
+
+// SetShard sets the shard of the clusters reconciled by all controllers.
+func SetShard(s Shard) {
+	shard = s
+}
+
+// IsSharded returns whether the clusters are split across several managers.
+func (s Shard) IsSharded() bool {
+	return len(s.Subscriptions) > 0 || (s.Selector != nil && !s.Selector.Empty())
+}
+
+// ID returns an ID of the shard, which is the same for managers reconciling the same clusters.
+func (s Shard) ID() string {
+	subscriptions := make([]string, len(s.Subscriptions))
+	for i, subscription := range s.Subscriptions {
+		subscriptions[i] = strings.ToLower(subscription)
+	}
+	sort.Strings(subscriptions)
+	selector := ""
+	if s.Selector != nil {
+		selector = s.Selector.String()
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.Join(subscriptions, ",") + "/" + selector))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// Contains returns whether a cluster in a subscription is in the shard. Clusters without a subscription are in the
+// subscription of the environment of the manager.
+func (s Shard) Contains(cluster *clusterv1.Cluster, subscriptionID string) bool {
+	if s.Selector != nil && !s.Selector.Matches(labels.Set(cluster.Labels)) {
+		return false
+	}
+	if len(s.Subscriptions) == 0 {
+		return true
+	}
+	if subscriptionID == "" {
+		subscriptionID = os.Getenv(auth.SubscriptionID)
+	}
+	for _, subscription := range s.Subscriptions {
+		if strings.EqualFold(subscription, subscriptionID) {
+			return true
+		}
+	}
+	return false
+}
+
+// InShard returns whether a cluster in a subscription is reconciled by the controllers of this manager.
+func InShard(cluster *clusterv1.Cluster, subscriptionID string) bool {
+	return shard.Contains(cluster, subscriptionID)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestShardContains(t *testing.T) {
+	os.Setenv(auth.SubscriptionID, "default-sub")
+	defer os.Unsetenv(auth.SubscriptionID)
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"shard": "a"}}}
+
+	cases := map[string]struct {
+		shard          Shard
+		subscriptionID string
+		expected       bool
+	}{
+		"not sharded": {
+			subscriptionID: "sub-1",
+			expected:       true,
+		},
+		"subscription in shard": {
+			shard:          Shard{Subscriptions: []string{"SUB-1", "sub-2"}},
+			subscriptionID: "sub-1",
+			expected:       true,
+		},
+		"subscription not in shard": {
+			shard:          Shard{Subscriptions: []string{"sub-2"}},
+			subscriptionID: "sub-1",
+			expected:       false,
+		},
+		"default subscription in shard": {
+			shard:    Shard{Subscriptions: []string{"default-sub"}},
+			expected: true,
+		},
+		"labels selected": {
+			shard:          Shard{Selector: labels.SelectorFromSet(labels.Set{"shard": "a"})},
+			subscriptionID: "sub-1",
+			expected:       true,
+		},
+		"labels not selected": {
+			shard:          Shard{Selector: labels.SelectorFromSet(labels.Set{"shard": "b"}), Subscriptions: []string{"sub-1"}},
+			subscriptionID: "sub-1",
+			expected:       false,
+		},
+	}
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(tc.shard.Contains(cluster, tc.subscriptionID)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestShardID(t *testing.T) {
+	g := NewWithT(t)
+
+	selector := labels.SelectorFromSet(labels.Set{"shard": "a"})
+	g.Expect(Shard{}.IsSharded()).To(BeFalse())
+	g.Expect(Shard{Selector: labels.Everything()}.IsSharded()).To(BeFalse())
+	g.Expect(Shard{Selector: selector}.IsSharded()).To(BeTrue())
+
+	// Managers reconciling the same clusters have the same ID, whatever the order of their subscriptions.
+	g.Expect(Shard{Subscriptions: []string{"sub-1", "SUB-2"}}.ID()).To(Equal(Shard{Subscriptions: []string{"sub-2", "sub-1"}}.ID()))
+	g.Expect(Shard{Subscriptions: []string{"sub-1"}}.ID()).NotTo(Equal(Shard{Subscriptions: []string{"sub-2"}}.ID()))
+	g.Expect(Shard{Selector: selector}.ID()).NotTo(Equal(Shard{}.ID()))
+}
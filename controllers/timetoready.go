@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+var (
+	timeToReadyBuckets = []float64{30, 60, 120, 180, 300, 450, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200}
+	timeToReady        = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capz_time_to_ready_seconds",
+		Help:    "Time from the creation of AzureClusters and AzureMachines to them being ready for the first time, by kind.",
+		Buckets: timeToReadyBuckets,
+	}, []string{"kind"})
+	timeToPhase = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "capz_time_to_phase_seconds",
+		Help:    "Time from the creation of AzureClusters and AzureMachines to the completion of each phase of their provisioning, by kind and phase.",
+		Buckets: timeToReadyBuckets,
+	}, []string{"kind", "phase"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(timeToReady, timeToPhase)
+}
+
+// clusterPhases are the phases of the provisioning of an AzureCluster, by the service of its service graph completing
+// them.
+var clusterPhases = map[string]string{
+	"resource group":  "resource_group",
+	"virtual network": "virtual_network",
+	"load balancer":   "load_balancer",
+}
+
+// machinePhases are the phases of the provisioning of an AzureMachine, by the condition turning true once they
+// complete.
+var machinePhases = map[clusterv1.ConditionType]string{
+	infrav1.VMRunningCondition:          "vm",
+	infrav1.BootstrapSucceededCondition: "bootstrap",
+}
+
+// readinessTracker measures the time objects take to become ready, and to complete each phase of their provisioning.
+// Each phase of an object is only measured once, as is the time it takes to become ready, so that objects becoming
+// ready again, e.g. after a transient failure, aren't measured again. As this is only known in memory, objects which
+// were ready before the controller restarted and aren't ready when it reconciles them again are measured again.
+type readinessTracker struct {
+	lock sync.Mutex
+	// observed are the phases measured for each object, and "ready" once it became ready.
+	observed map[types.UID]map[string]bool
+	now      func() time.Time
+}
+
+// readyPhase marks the objects measured as ready.
+const readyPhase = "ready"
+
+func newReadinessTracker() *readinessTracker {
+	return &readinessTracker{
+		observed: make(map[types.UID]map[string]bool),
+		now:      time.Now,
+	}
+}
+
+// observeCluster measures the phases an AzureCluster completed and whether it became ready in a reconciliation,
+// from the AzureCluster before and after it and the outcome of its services.
+func (t *readinessTracker) observeCluster(before, after *infrav1.AzureCluster, summary *serviceSummary) {
+	if before.Status.Ready || !after.DeletionTimestamp.IsZero() {
+		return
+	}
+	for service, phase := range clusterPhases {
+		if summary.succeeded(service) {
+			t.observe(after, "AzureCluster", phase)
+		}
+	}
+	if after.Status.Ready {
+		t.observe(after, "AzureCluster", readyPhase)
+	}
+}
+
+// observeMachine measures the phases an AzureMachine completed and whether it became ready in a reconciliation, from
+// the AzureMachine before and after it.
+func (t *readinessTracker) observeMachine(before, after *infrav1.AzureMachine) {
+	if before.Status.Ready || !after.DeletionTimestamp.IsZero() {
+		return
+	}
+	for condition, phase := range machinePhases {
+		if !conditions.IsTrue(before, condition) && conditions.IsTrue(after, condition) {
+			t.observe(after, "AzureMachine", phase)
+		}
+	}
+	if after.Status.Ready {
+		t.observe(after, "AzureMachine", readyPhase)
+	}
+}
+
+// observe measures the time from the creation of an object to the completion of a phase, unless it was measured
+// already.
+func (t *readinessTracker) observe(obj client.Object, kind, phase string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	observed := t.observed[obj.GetUID()]
+	if observed == nil {
+		observed = make(map[string]bool)
+		t.observed[obj.GetUID()] = observed
+	}
+	if observed[phase] || observed[readyPhase] {
+		return
+	}
+	observed[phase] = true
+
+	elapsed := t.now().Sub(obj.GetCreationTimestamp().Time).Seconds()
+	if phase == readyPhase {
+		timeToReady.WithLabelValues(kind).Observe(elapsed)
+		// Only the readiness of the object needs to be remembered from now on.
+		t.observed[obj.GetUID()] = map[string]bool{readyPhase: true}
+		return
+	}
+	timeToPhase.WithLabelValues(kind, phase).Observe(elapsed)
+}
+
+// forget forgets an object which is being deleted.
+func (t *readinessTracker) forget(obj client.Object) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.observed, obj.GetUID())
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+)
+
+func TestReadinessTrackerMachine(t *testing.T) {
+	g := NewWithT(t)
+	timeToReady.Reset()
+	timeToPhase.Reset()
+	defer timeToReady.Reset()
+	defer timeToPhase.Reset()
+
+	created := time.Now()
+	tracker := newReadinessTracker()
+	tracker.now = func() time.Time { return created.Add(5 * time.Minute) }
+	before := &infrav1.AzureMachine{ObjectMeta: metav1.ObjectMeta{Name: "my-machine", UID: "1234", CreationTimestamp: metav1.NewTime(created)}}
+
+	// The VM is running, but bootstrapping isn't done yet.
+	after := before.DeepCopy()
+	conditions.MarkTrue(after, infrav1.VMRunningCondition)
+	tracker.observeMachine(before, after)
+	g.Expect(testutil.CollectAndCount(timeToPhase)).To(Equal(1))
+	g.Expect(testutil.CollectAndCount(timeToReady)).To(BeZero())
+
+	// Bootstrapping is done and the machine is ready.
+	before, after = after, after.DeepCopy()
+	conditions.MarkTrue(after, infrav1.BootstrapSucceededCondition)
+	after.Status.Ready = true
+	tracker.observeMachine(before, after)
+	g.Expect(testutil.CollectAndCount(timeToPhase)).To(Equal(2))
+	g.Expect(testutil.CollectAndCount(timeToReady)).To(Equal(1))
+
+	// A machine becoming ready again, e.g. after a transient failure, isn't measured again.
+	before, after = after.DeepCopy(), after.DeepCopy()
+	before.Status.Ready = false
+	conditions.MarkFalse(before, infrav1.VMRunningCondition, infrav1.VMUpdatingReason, "", "")
+	tracker.observeMachine(before, after)
+	g.Expect(observations(timeToReady.WithLabelValues("AzureMachine"))).To(Equal(uint64(1)))
+	g.Expect(observations(timeToPhase.WithLabelValues("AzureMachine", "vm"))).To(Equal(uint64(1)))
+}
+
+func TestReadinessTrackerCluster(t *testing.T) {
+	g := NewWithT(t)
+	timeToReady.Reset()
+	timeToPhase.Reset()
+	defer timeToReady.Reset()
+	defer timeToPhase.Reset()
+
+	created := time.Now()
+	tracker := newReadinessTracker()
+	tracker.now = func() time.Time { return created.Add(10 * time.Minute) }
+	before := &infrav1.AzureCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", UID: "5678", CreationTimestamp: metav1.NewTime(created)}}
+	summary := &serviceSummary{}
+	summary.record([]serviceNode{{name: "resource group"}, {name: "virtual network"}, {name: "load balancer"}}, map[string]*serviceResult{
+		"resource group":  {},
+		"virtual network": {},
+		"load balancer":   {skipped: true},
+	})
+
+	tracker.observeCluster(before, before.DeepCopy(), summary)
+	// Phases are only measured the first time they complete.
+	tracker.observeCluster(before, before.DeepCopy(), summary)
+	g.Expect(observations(timeToPhase.WithLabelValues("AzureCluster", "resource_group"))).To(Equal(uint64(1)))
+	g.Expect(observations(timeToPhase.WithLabelValues("AzureCluster", "virtual_network"))).To(Equal(uint64(1)))
+	g.Expect(observations(timeToPhase.WithLabelValues("AzureCluster", "load_balancer"))).To(BeZero())
+
+	after := before.DeepCopy()
+	after.Status.Ready = true
+	tracker.observeCluster(before, after, &serviceSummary{})
+	g.Expect(observations(timeToReady.WithLabelValues("AzureCluster"))).To(Equal(uint64(1)))
+}
+
+// observations returns the number of observations of a histogram.
+func observations(observer prometheus.Observer) uint64 {
+	metric := &dto.Metric{}
+	_ = observer.(prometheus.Metric).Write(metric)
+	return metric.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+)
+
+// VMSizeCapabilitiesGetter gets the capabilities of VM sizes from the resource SKUs of the location of a cluster,
+// with the credentials of its AzureCluster. The resource SKUs are cached with those the controllers use.
+type VMSizeCapabilitiesGetter struct {
+	Client client.Client
+}
+
+var _ infrav1.VMSizeCapabilitiesGetter = (*VMSizeCapabilitiesGetter)(nil)
+
+// GetVMSizeCapabilities returns the capabilities of a VM size in the location of the AzureCluster of a cluster, or nil
+// if the cluster or its AzureCluster don't exist.
+func (g *VMSizeCapabilitiesGetter) GetVMSizeCapabilities(ctx context.Context, namespace, clusterName, vmSize string) (*infrav1.VMSizeCapabilities, error) {
+	clusterScope, err := admissionClusterScope(ctx, g.Client, namespace, clusterName)
+	if err != nil || clusterScope == nil {
+		return nil, err
+	}
+	skuCache, err := resourceskus.GetCache(clusterScope, clusterScope.Location())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get resource SKUs cache")
+	}
+	return vmSizeCapabilities(ctx, skuCache, vmSize, clusterScope.Location())
+}
+
+// vmSizeCapabilities returns the capabilities of a VM size from the resource SKUs of a location.
+func vmSizeCapabilities(ctx context.Context, skuCache *resourceskus.Cache, vmSize, location string) (*infrav1.VMSizeCapabilities, error) {
+	capabilities := &infrav1.VMSizeCapabilities{Location: location}
+
+	var vmSKU *resourceskus.SKU
+	if err := skuCache.Map(ctx, func(sku resourceskus.SKU) {
+		if sku.Name != nil && strings.EqualFold(*sku.Name, vmSize) && sku.ResourceType != nil && strings.EqualFold(*sku.ResourceType, string(resourceskus.VirtualMachines)) {
+			vmSKU = &sku
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if vmSKU == nil || isRestrictedInLocation(*vmSKU) {
+		return capabilities, nil
+	}
+
+	zones, err := skuCache.GetZonesWithVMSize(ctx, vmSize, location)
+	if err != nil {
+		return nil, err
+	}
+	capabilities.Available = true
+	capabilities.Zones = zones
+	capabilities.AcceleratedNetworking = vmSKU.HasCapability(resourceskus.AcceleratedNetworking)
+	capabilities.EncryptionAtHost = vmSKU.HasCapability(resourceskus.EncryptionAtHost)
+	capabilities.EphemeralOSDisk = vmSKU.HasCapability(resourceskus.EphemeralOSDisk)
+	capabilities.PremiumIO = vmSKU.HasCapability(resourceskus.PremiumIO)
+	if generations, ok := vmSKU.GetCapability(resourceskus.HyperVGenerations); ok && generations != "" {
+		capabilities.HyperVGenerations = strings.Split(generations, ",")
+	}
+	return capabilities, nil
+}
+
+// isRestrictedInLocation returns whether a resource SKU can't be deployed in its location for the subscription.
+func isRestrictedInLocation(sku resourceskus.SKU) bool {
+	if sku.Restrictions == nil {
+		return false
+	}
+	for _, restriction := range *sku.Restrictions {
+		if restriction.Type == compute.Location {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/resourceskus"
+)
+
+func TestVMSizeCapabilities(t *testing.T) {
+	g := NewWithT(t)
+
+	capability := func(name, value string) compute.ResourceSkuCapabilities {
+		return compute.ResourceSkuCapabilities{Name: to.StringPtr(name), Value: to.StringPtr(value)}
+	}
+	vmSKU := func(name string, restrictions []compute.ResourceSkuRestrictions, capabilities ...compute.ResourceSkuCapabilities) compute.ResourceSku {
+		return compute.ResourceSku{
+			Name:         to.StringPtr(name),
+			ResourceType: to.StringPtr(string(resourceskus.VirtualMachines)),
+			LocationInfo: &[]compute.ResourceSkuLocationInfo{
+				{Location: to.StringPtr("westeurope"), Zones: &[]string{"1", "2", "3"}},
+			},
+			Restrictions: &restrictions,
+			Capabilities: &capabilities,
+		}
+	}
+	skuCache := resourceskus.NewStaticCache([]compute.ResourceSku{
+		vmSKU("Standard_D2s_v3", []compute.ResourceSkuRestrictions{
+			{Type: compute.Zone, RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Zones: &[]string{"3"}}},
+		},
+			capability(resourceskus.AcceleratedNetworking, "True"),
+			capability(resourceskus.EncryptionAtHost, "True"),
+			capability(resourceskus.PremiumIO, "True"),
+			capability(resourceskus.EphemeralOSDisk, "False"),
+			capability(resourceskus.HyperVGenerations, "V1,V2"),
+		),
+		vmSKU("Standard_B2s", []compute.ResourceSkuRestrictions{
+			{Type: compute.Location, ReasonCode: compute.NotAvailableForSubscription, RestrictionInfo: &compute.ResourceSkuRestrictionInfo{Locations: &[]string{"westeurope"}}},
+		}),
+	}, "westeurope")
+
+	capabilities, err := vmSizeCapabilities(context.TODO(), skuCache, "Standard_D2s_v3", "westeurope")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(capabilities).To(Equal(&infrav1.VMSizeCapabilities{
+		Location:              "westeurope",
+		Available:             true,
+		Zones:                 []string{"1", "2"},
+		AcceleratedNetworking: true,
+		EncryptionAtHost:      true,
+		PremiumIO:             true,
+		HyperVGenerations:     []string{"V1", "V2"},
+	}))
+
+	capabilities, err = vmSizeCapabilities(context.TODO(), skuCache, "Standard_B2s", "westeurope")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(capabilities).To(Equal(&infrav1.VMSizeCapabilities{Location: "westeurope"}))
+
+	capabilities, err = vmSizeCapabilities(context.TODO(), skuCache, "Standard_Unknown", "westeurope")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(capabilities.Available).To(BeFalse())
+}
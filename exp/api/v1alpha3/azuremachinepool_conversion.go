@@ -38,6 +38,10 @@ func (src *AzureMachinePool) ConvertTo(dstRaw conversion.Hub) error { // nolint
 		return err
 	}
 
+	dst.Spec.Template.SSHPublicKeySecret = restored.Spec.Template.SSHPublicKeySecret
+	dst.Spec.Template.AdminPasswordSecret = restored.Spec.Template.AdminPasswordSecret
+	dst.Spec.Template.AdditionalCustomDataSecret = restored.Spec.Template.AdditionalCustomDataSecret
+
 	// Handle special case for conversion of ManagedDisk to pointer.
 	if restored.Spec.Template.OSDisk.ManagedDisk == nil && dst.Spec.Template.OSDisk.ManagedDisk != nil {
 		if *dst.Spec.Template.OSDisk.ManagedDisk == (infrav1alpha4.ManagedDiskParameters{}) {
@@ -60,6 +64,11 @@ func (src *AzureMachinePool) ConvertTo(dstRaw conversion.Hub) error { // nolint
 		dst.Status.Image = restored.Status.Image
 	}
 
+	if restored.Status.LongRunningOperationState != nil && dst.Status.LongRunningOperationState != nil {
+		dst.Status.LongRunningOperationState.StartTime = restored.Status.LongRunningOperationState.StartTime
+		dst.Status.LongRunningOperationState.ParametersHash = restored.Status.LongRunningOperationState.ParametersHash
+	}
+
 	if len(dst.Annotations) == 0 {
 		dst.Annotations = nil
 	}
@@ -87,6 +96,10 @@ func Convert_v1alpha4_AzureMachinePoolSpec_To_v1alpha3_AzureMachinePoolSpec(in *
 	return autoConvert_v1alpha4_AzureMachinePoolSpec_To_v1alpha3_AzureMachinePoolSpec(in, out, s)
 }
 
+func Convert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate(in *expv1alpha4.AzureMachinePoolMachineTemplate, out *AzureMachinePoolMachineTemplate, s convert.Scope) error {
+	return autoConvert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate(in, out, s)
+}
+
 func Convert_v1alpha4_AzureMachinePoolStatus_To_v1alpha3_AzureMachinePoolStatus(in *expv1alpha4.AzureMachinePoolStatus, out *AzureMachinePoolStatus, s convert.Scope) error {
 	return autoConvert_v1alpha4_AzureMachinePoolStatus_To_v1alpha3_AzureMachinePoolStatus(in, out, s)
 }
@@ -38,6 +38,13 @@ func (src *AzureManagedControlPlane) ConvertTo(dstRaw conversion.Hub) error { //
 	}
 
 	dst.Spec.IdentityRef = restored.Spec.IdentityRef
+	dst.Spec.IngressProfile = restored.Spec.IngressProfile
+	dst.Spec.WindowsProfile = restored.Spec.WindowsProfile
+	dst.Spec.MetricsProfile = restored.Spec.MetricsProfile
+	dst.Spec.SKU = restored.Spec.SKU
+	dst.Spec.UpgradePolicy = restored.Spec.UpgradePolicy
+	dst.Spec.Backup = restored.Spec.Backup
+	dst.Status.Upgrade = restored.Status.Upgrade
 
 	return nil
 }
@@ -62,3 +69,8 @@ func (dst *AzureManagedControlPlane) ConvertFrom(srcRaw conversion.Hub) error {
 func Convert_v1alpha4_AzureManagedControlPlaneSpec_To_v1alpha3_AzureManagedControlPlaneSpec(in *expv1alpha4.AzureManagedControlPlaneSpec, out *AzureManagedControlPlaneSpec, s apiconversion.Scope) error {
 	return autoConvert_v1alpha4_AzureManagedControlPlaneSpec_To_v1alpha3_AzureManagedControlPlaneSpec(in, out, s)
 }
+
+// Convert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus is an autogenerated conversion function.
+func Convert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(in *expv1alpha4.AzureManagedControlPlaneStatus, out *AzureManagedControlPlaneStatus, s apiconversion.Scope) error {
+	return autoConvert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(in, out, s)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha3
 
 import (
+	apiconversion "k8s.io/apimachinery/pkg/conversion"
 	expv1alpha4 "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
 	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
@@ -36,6 +37,12 @@ func (src *AzureManagedMachinePool) ConvertTo(dstRaw conversion.Hub) error { //
 		return err
 	}
 
+	dst.Spec.OSType = restored.Spec.OSType
+	dst.Spec.AvailabilityZones = restored.Spec.AvailabilityZones
+	dst.Spec.ScaleDownMode = restored.Spec.ScaleDownMode
+	dst.Spec.UpgradeSettings = restored.Spec.UpgradeSettings
+	dst.Status.DeallocatedReplicas = restored.Status.DeallocatedReplicas
+
 	return nil
 }
 
@@ -54,3 +61,13 @@ func (dst *AzureManagedMachinePool) ConvertFrom(srcRaw conversion.Hub) error { /
 
 	return nil
 }
+
+// Convert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec is an autogenerated conversion function.
+func Convert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(in *expv1alpha4.AzureManagedMachinePoolSpec, out *AzureManagedMachinePoolSpec, s apiconversion.Scope) error {
+	return autoConvert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(in, out, s)
+}
+
+// Convert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus is an autogenerated conversion function.
+func Convert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(in *expv1alpha4.AzureManagedMachinePoolStatus, out *AzureManagedMachinePoolStatus, s apiconversion.Scope) error {
+	return autoConvert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(in, out, s)
+}
@@ -44,6 +44,16 @@ func Convert_v1alpha4_Image_To_v1alpha3_Image(in *v1alpha4.Image, out *v1alpha3.
 	return v1alpha3.Convert_v1alpha4_Image_To_v1alpha3_Image(in, out, s)
 }
 
+// Convert_v1alpha3_Future_To_v1alpha4_Future is a conversion function.
+func Convert_v1alpha3_Future_To_v1alpha4_Future(in *v1alpha3.Future, out *v1alpha4.Future, s conversion.Scope) error {
+	return v1alpha3.Convert_v1alpha3_Future_To_v1alpha4_Future(in, out, s)
+}
+
+// Convert_v1alpha4_Future_To_v1alpha3_Future is a conversion function.
+func Convert_v1alpha4_Future_To_v1alpha3_Future(in *v1alpha4.Future, out *v1alpha3.Future, s conversion.Scope) error {
+	return v1alpha3.Convert_v1alpha4_Future_To_v1alpha3_Future(in, out, s)
+}
+
 // Convert_v1alpha3_APIEndpoint_To_v1alpha4_APIEndpoint is an autogenerated conversion function.
 func Convert_v1alpha3_APIEndpoint_To_v1alpha4_APIEndpoint(in *clusterapiapiv1alpha3.APIEndpoint, out *clusterapiapiv1alpha4.APIEndpoint, s conversion.Scope) error {
 	return clusterapiapiv1alpha3.Convert_v1alpha3_APIEndpoint_To_v1alpha4_APIEndpoint(in, out, s)
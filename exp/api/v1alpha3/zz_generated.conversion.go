@@ -75,11 +75,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha4.AzureMachinePoolMachineTemplate)(nil), (*AzureMachinePoolMachineTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate(a.(*v1alpha4.AzureMachinePoolMachineTemplate), b.(*AzureMachinePoolMachineTemplate), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*AzureMachinePoolSpec)(nil), (*v1alpha4.AzureMachinePoolSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_AzureMachinePoolSpec_To_v1alpha4_AzureMachinePoolSpec(a.(*AzureMachinePoolSpec), b.(*v1alpha4.AzureMachinePoolSpec), scope)
 	}); err != nil {
@@ -160,11 +155,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha4.AzureManagedControlPlaneStatus)(nil), (*AzureManagedControlPlaneStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(a.(*v1alpha4.AzureManagedControlPlaneStatus), b.(*AzureManagedControlPlaneStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*AzureManagedMachinePool)(nil), (*v1alpha4.AzureManagedMachinePool)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_AzureManagedMachinePool_To_v1alpha4_AzureManagedMachinePool(a.(*AzureManagedMachinePool), b.(*v1alpha4.AzureManagedMachinePool), scope)
 	}); err != nil {
@@ -190,21 +180,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha4.AzureManagedMachinePoolSpec)(nil), (*AzureManagedMachinePoolSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(a.(*v1alpha4.AzureManagedMachinePoolSpec), b.(*AzureManagedMachinePoolSpec), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*AzureManagedMachinePoolStatus)(nil), (*v1alpha4.AzureManagedMachinePoolStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_AzureManagedMachinePoolStatus_To_v1alpha4_AzureManagedMachinePoolStatus(a.(*AzureManagedMachinePoolStatus), b.(*v1alpha4.AzureManagedMachinePoolStatus), scope)
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha4.AzureManagedMachinePoolStatus)(nil), (*AzureManagedMachinePoolStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(a.(*v1alpha4.AzureManagedMachinePoolStatus), b.(*AzureManagedMachinePoolStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*ManagedControlPlaneSubnet)(nil), (*v1alpha4.ManagedControlPlaneSubnet)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_ManagedControlPlaneSubnet_To_v1alpha4_ManagedControlPlaneSubnet(a.(*ManagedControlPlaneSubnet), b.(*v1alpha4.ManagedControlPlaneSubnet), scope)
 	}); err != nil {
@@ -230,6 +210,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*clusterapiproviderazureapiv1alpha3.Future)(nil), (*clusterapiproviderazureapiv1alpha4.Future)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha3_Future_To_v1alpha4_Future(a.(*clusterapiproviderazureapiv1alpha3.Future), b.(*clusterapiproviderazureapiv1alpha4.Future), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*clusterapiproviderazureapiv1alpha3.Image)(nil), (*clusterapiproviderazureapiv1alpha4.Image)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha3_Image_To_v1alpha4_Image(a.(*clusterapiproviderazureapiv1alpha3.Image), b.(*clusterapiproviderazureapiv1alpha4.Image), scope)
 	}); err != nil {
@@ -245,6 +230,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha4.AzureMachinePoolMachineTemplate)(nil), (*AzureMachinePoolMachineTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate(a.(*v1alpha4.AzureMachinePoolMachineTemplate), b.(*AzureMachinePoolMachineTemplate), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*v1alpha4.AzureMachinePoolSpec)(nil), (*AzureMachinePoolSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_AzureMachinePoolSpec_To_v1alpha3_AzureMachinePoolSpec(a.(*v1alpha4.AzureMachinePoolSpec), b.(*AzureMachinePoolSpec), scope)
 	}); err != nil {
@@ -260,6 +250,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha4.AzureManagedControlPlaneStatus)(nil), (*AzureManagedControlPlaneStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(a.(*v1alpha4.AzureManagedControlPlaneStatus), b.(*AzureManagedControlPlaneStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha4.AzureManagedMachinePoolSpec)(nil), (*AzureManagedMachinePoolSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(a.(*v1alpha4.AzureManagedMachinePoolSpec), b.(*AzureManagedMachinePoolSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha4.AzureManagedMachinePoolStatus)(nil), (*AzureManagedMachinePoolStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(a.(*v1alpha4.AzureManagedMachinePoolStatus), b.(*AzureManagedMachinePoolStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*clusterapiproviderazureapiv1alpha4.Future)(nil), (*clusterapiproviderazureapiv1alpha3.Future)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha4_Future_To_v1alpha3_Future(a.(*clusterapiproviderazureapiv1alpha4.Future), b.(*clusterapiproviderazureapiv1alpha3.Future), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*clusterapiproviderazureapiv1alpha4.Image)(nil), (*clusterapiproviderazureapiv1alpha3.Image)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha4_Image_To_v1alpha3_Image(a.(*clusterapiproviderazureapiv1alpha4.Image), b.(*clusterapiproviderazureapiv1alpha3.Image), scope)
 	}); err != nil {
@@ -421,6 +431,9 @@ func autoConvert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachi
 	}
 	out.DataDisks = *(*[]clusterapiproviderazureapiv1alpha3.DataDisk)(unsafe.Pointer(&in.DataDisks))
 	out.SSHPublicKey = in.SSHPublicKey
+	// WARNING: in.SSHPublicKeySecret requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdminPasswordSecret requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdditionalCustomDataSecret requires manual conversion: does not exist in peer-type
 	out.AcceleratedNetworking = (*bool)(unsafe.Pointer(in.AcceleratedNetworking))
 	out.TerminateNotificationTimeout = (*int)(unsafe.Pointer(in.TerminateNotificationTimeout))
 	out.SecurityProfile = (*clusterapiproviderazureapiv1alpha3.SecurityProfile)(unsafe.Pointer(in.SecurityProfile))
@@ -428,11 +441,6 @@ func autoConvert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachi
 	return nil
 }
 
-// Convert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate is an autogenerated conversion function.
-func Convert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate(in *v1alpha4.AzureMachinePoolMachineTemplate, out *AzureMachinePoolMachineTemplate, s conversion.Scope) error {
-	return autoConvert_v1alpha4_AzureMachinePoolMachineTemplate_To_v1alpha3_AzureMachinePoolMachineTemplate(in, out, s)
-}
-
 func autoConvert_v1alpha3_AzureMachinePoolSpec_To_v1alpha4_AzureMachinePoolSpec(in *AzureMachinePoolSpec, out *v1alpha4.AzureMachinePoolSpec, s conversion.Scope) error {
 	out.Location = in.Location
 	if err := Convert_v1alpha3_AzureMachinePoolMachineTemplate_To_v1alpha4_AzureMachinePoolMachineTemplate(&in.Template, &out.Template, s); err != nil {
@@ -476,7 +484,15 @@ func autoConvert_v1alpha3_AzureMachinePoolStatus_To_v1alpha4_AzureMachinePoolSta
 	out.FailureReason = (*errors.MachineStatusError)(unsafe.Pointer(in.FailureReason))
 	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
 	out.Conditions = *(*apiv1alpha4.Conditions)(unsafe.Pointer(&in.Conditions))
-	out.LongRunningOperationState = (*clusterapiproviderazureapiv1alpha4.Future)(unsafe.Pointer(in.LongRunningOperationState))
+	if in.LongRunningOperationState != nil {
+		in, out := &in.LongRunningOperationState, &out.LongRunningOperationState
+		*out = new(clusterapiproviderazureapiv1alpha4.Future)
+		if err := Convert_v1alpha3_Future_To_v1alpha4_Future(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.LongRunningOperationState = nil
+	}
 	return nil
 }
 
@@ -495,7 +511,15 @@ func autoConvert_v1alpha4_AzureMachinePoolStatus_To_v1alpha3_AzureMachinePoolSta
 	out.FailureReason = (*errors.MachineStatusError)(unsafe.Pointer(in.FailureReason))
 	out.FailureMessage = (*string)(unsafe.Pointer(in.FailureMessage))
 	out.Conditions = *(*apiv1alpha3.Conditions)(unsafe.Pointer(&in.Conditions))
-	out.LongRunningOperationState = (*clusterapiproviderazureapiv1alpha3.Future)(unsafe.Pointer(in.LongRunningOperationState))
+	if in.LongRunningOperationState != nil {
+		in, out := &in.LongRunningOperationState, &out.LongRunningOperationState
+		*out = new(clusterapiproviderazureapiv1alpha3.Future)
+		if err := Convert_v1alpha4_Future_To_v1alpha3_Future(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.LongRunningOperationState = nil
+	}
 	return nil
 }
 
@@ -738,6 +762,12 @@ func autoConvert_v1alpha4_AzureManagedControlPlaneSpec_To_v1alpha3_AzureManagedC
 	out.DNSServiceIP = (*string)(unsafe.Pointer(in.DNSServiceIP))
 	out.LoadBalancerSKU = (*string)(unsafe.Pointer(in.LoadBalancerSKU))
 	// WARNING: in.IdentityRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.IngressProfile requires manual conversion: does not exist in peer-type
+	// WARNING: in.WindowsProfile requires manual conversion: does not exist in peer-type
+	// WARNING: in.MetricsProfile requires manual conversion: does not exist in peer-type
+	// WARNING: in.SKU requires manual conversion: does not exist in peer-type
+	// WARNING: in.UpgradePolicy requires manual conversion: does not exist in peer-type
+	// WARNING: in.Backup requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -755,14 +785,10 @@ func Convert_v1alpha3_AzureManagedControlPlaneStatus_To_v1alpha4_AzureManagedCon
 func autoConvert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(in *v1alpha4.AzureManagedControlPlaneStatus, out *AzureManagedControlPlaneStatus, s conversion.Scope) error {
 	out.Ready = in.Ready
 	out.Initialized = in.Initialized
+	// WARNING: in.Upgrade requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus is an autogenerated conversion function.
-func Convert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(in *v1alpha4.AzureManagedControlPlaneStatus, out *AzureManagedControlPlaneStatus, s conversion.Scope) error {
-	return autoConvert_v1alpha4_AzureManagedControlPlaneStatus_To_v1alpha3_AzureManagedControlPlaneStatus(in, out, s)
-}
-
 func autoConvert_v1alpha3_AzureManagedMachinePool_To_v1alpha4_AzureManagedMachinePool(in *AzureManagedMachinePool, out *v1alpha4.AzureManagedMachinePool, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha3_AzureManagedMachinePoolSpec_To_v1alpha4_AzureManagedMachinePoolSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -797,7 +823,17 @@ func Convert_v1alpha4_AzureManagedMachinePool_To_v1alpha3_AzureManagedMachinePoo
 
 func autoConvert_v1alpha3_AzureManagedMachinePoolList_To_v1alpha4_AzureManagedMachinePoolList(in *AzureManagedMachinePoolList, out *v1alpha4.AzureManagedMachinePoolList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]v1alpha4.AzureManagedMachinePool)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1alpha4.AzureManagedMachinePool, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha3_AzureManagedMachinePool_To_v1alpha4_AzureManagedMachinePool(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -808,7 +844,17 @@ func Convert_v1alpha3_AzureManagedMachinePoolList_To_v1alpha4_AzureManagedMachin
 
 func autoConvert_v1alpha4_AzureManagedMachinePoolList_To_v1alpha3_AzureManagedMachinePoolList(in *v1alpha4.AzureManagedMachinePoolList, out *AzureManagedMachinePoolList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]AzureManagedMachinePool)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AzureManagedMachinePool, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha4_AzureManagedMachinePool_To_v1alpha3_AzureManagedMachinePool(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -832,15 +878,14 @@ func Convert_v1alpha3_AzureManagedMachinePoolSpec_To_v1alpha4_AzureManagedMachin
 func autoConvert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(in *v1alpha4.AzureManagedMachinePoolSpec, out *AzureManagedMachinePoolSpec, s conversion.Scope) error {
 	out.SKU = in.SKU
 	out.OSDiskSizeGB = (*int32)(unsafe.Pointer(in.OSDiskSizeGB))
+	// WARNING: in.OSType requires manual conversion: does not exist in peer-type
+	// WARNING: in.AvailabilityZones requires manual conversion: does not exist in peer-type
+	// WARNING: in.ScaleDownMode requires manual conversion: does not exist in peer-type
+	// WARNING: in.UpgradeSettings requires manual conversion: does not exist in peer-type
 	out.ProviderIDList = *(*[]string)(unsafe.Pointer(&in.ProviderIDList))
 	return nil
 }
 
-// Convert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec is an autogenerated conversion function.
-func Convert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(in *v1alpha4.AzureManagedMachinePoolSpec, out *AzureManagedMachinePoolSpec, s conversion.Scope) error {
-	return autoConvert_v1alpha4_AzureManagedMachinePoolSpec_To_v1alpha3_AzureManagedMachinePoolSpec(in, out, s)
-}
-
 func autoConvert_v1alpha3_AzureManagedMachinePoolStatus_To_v1alpha4_AzureManagedMachinePoolStatus(in *AzureManagedMachinePoolStatus, out *v1alpha4.AzureManagedMachinePoolStatus, s conversion.Scope) error {
 	out.Ready = in.Ready
 	out.Replicas = in.Replicas
@@ -857,16 +902,12 @@ func Convert_v1alpha3_AzureManagedMachinePoolStatus_To_v1alpha4_AzureManagedMach
 func autoConvert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(in *v1alpha4.AzureManagedMachinePoolStatus, out *AzureManagedMachinePoolStatus, s conversion.Scope) error {
 	out.Ready = in.Ready
 	out.Replicas = in.Replicas
+	// WARNING: in.DeallocatedReplicas requires manual conversion: does not exist in peer-type
 	out.ErrorReason = (*errors.MachineStatusError)(unsafe.Pointer(in.ErrorReason))
 	out.ErrorMessage = (*string)(unsafe.Pointer(in.ErrorMessage))
 	return nil
 }
 
-// Convert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus is an autogenerated conversion function.
-func Convert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(in *v1alpha4.AzureManagedMachinePoolStatus, out *AzureManagedMachinePoolStatus, s conversion.Scope) error {
-	return autoConvert_v1alpha4_AzureManagedMachinePoolStatus_To_v1alpha3_AzureManagedMachinePoolStatus(in, out, s)
-}
-
 func autoConvert_v1alpha3_ManagedControlPlaneSubnet_To_v1alpha4_ManagedControlPlaneSubnet(in *ManagedControlPlaneSubnet, out *v1alpha4.ManagedControlPlaneSubnet, s conversion.Scope) error {
 	out.Name = in.Name
 	out.CIDRBlock = in.CIDRBlock
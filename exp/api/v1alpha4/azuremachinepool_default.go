@@ -29,7 +29,7 @@ import (
 // SetDefaultSSHPublicKey sets the default SSHPublicKey for an AzureMachinePool.
 func (amp *AzureMachinePool) SetDefaultSSHPublicKey() error {
 	sshKeyData := amp.Spec.Template.SSHPublicKey
-	if sshKeyData == "" {
+	if sshKeyData == "" && amp.Spec.Template.SSHPublicKeySecret == nil {
 		_, publicRsaKey, err := utilSSH.GenerateSSHKey()
 		if err != nil {
 			return err
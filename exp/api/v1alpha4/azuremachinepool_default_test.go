@@ -43,6 +43,12 @@ func TestAzureMachinePool_SetDefaultSSHPublicKey(t *testing.T) {
 	err = publicKeyNotExistTest.amp.SetDefaultSSHPublicKey()
 	g.Expect(err).To(BeNil())
 	g.Expect(publicKeyNotExistTest.amp.Spec.Template.SSHPublicKey).NotTo(BeEmpty())
+
+	publicKeySecretTest := test{amp: createMachinePoolWithSSHPublicKey("")}
+	publicKeySecretTest.amp.Spec.Template.SSHPublicKeySecret = &infrav1.KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/ssh-key"}
+	err = publicKeySecretTest.amp.SetDefaultSSHPublicKey()
+	g.Expect(err).To(BeNil())
+	g.Expect(publicKeySecretTest.amp.Spec.Template.SSHPublicKey).To(BeEmpty())
 }
 
 func TestAzureMachinePool_SetIdentityDefaults(t *testing.T) {
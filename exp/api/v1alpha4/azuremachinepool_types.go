@@ -66,6 +66,22 @@ type (
 		// SSHPublicKey is the SSH public key string base64 encoded to add to a Virtual Machine
 		SSHPublicKey string `json:"sshPublicKey"`
 
+		// SSHPublicKeySecret references a secret in Azure Key Vault holding the SSH public key to add to a Virtual Machine,
+		// in the OpenSSH authorized_keys format. It is read when the scale set model is updated and is mutually exclusive with SSHPublicKey.
+		// +optional
+		SSHPublicKeySecret *infrav1.KeyVaultSecretReference `json:"sshPublicKeySecret,omitempty"`
+
+		// AdminPasswordSecret references a secret in Azure Key Vault holding the password of the administrator of Windows
+		// Virtual Machines. It is read when the scale set model is updated. A random password is used if not set.
+		// +optional
+		AdminPasswordSecret *infrav1.KeyVaultSecretReference `json:"adminPasswordSecret,omitempty"`
+
+		// AdditionalCustomDataSecret references a Secret in the namespace of the AzureMachinePool holding additional
+		// cloud-init user data, e.g. a cloud-config snippet or a shell script, which is run after the bootstrap data of
+		// the Virtual Machines. It is read when the scale set model is updated, and is only supported for Linux machines.
+		// +optional
+		AdditionalCustomDataSecret *infrav1.SecretKeyReference `json:"additionalCustomDataSecret,omitempty"`
+
 		// AcceleratedNetworking enables or disables Azure accelerated networking. If omitted, it will be set based on
 		// whether the requested VMSize supports accelerated networking.
 		// If AcceleratedNetworking is set to true with a VMSize that does not support it, Azure will return an error.
@@ -74,6 +90,8 @@ type (
 
 		// TerminateNotificationTimeout enables or disables VMSS scheduled events termination notification with specified timeout
 		// allowed values are between 5 and 15 (mins)
+		// +kubebuilder:validation:Minimum=5
+		// +kubebuilder:validation:Maximum=15
 		// +optional
 		TerminateNotificationTimeout *int `json:"terminateNotificationTimeout,omitempty"`
 
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	webhookutil "sigs.k8s.io/cluster-api-provider-azure/util/webhook"
 )
 
 // log is for logging in this package.
@@ -37,6 +38,9 @@ var azuremachinepoollog = logf.Log.WithName("azuremachinepool-resource")
 
 // SetupWebhookWithManager sets up and registers the webhook with the manager.
 func (amp *AzureMachinePool) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if err := webhookutil.RegisterValidatingWebhookWithWarnings(mgr, amp); err != nil {
+		return err
+	}
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(amp).
 		Complete()
@@ -59,6 +63,7 @@ func (amp *AzureMachinePool) Default() {
 // +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha4-azuremachinepool,mutating=false,failurePolicy=fail,groups=infrastructure.cluster.x-k8s.io,resources=azuremachinepools,versions=v1alpha4,name=validation.azuremachinepool.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
 
 var _ webhook.Validator = &AzureMachinePool{}
+var _ webhookutil.Warner = &AzureMachinePool{}
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (amp *AzureMachinePool) ValidateCreate() error {
@@ -78,15 +83,28 @@ func (amp *AzureMachinePool) ValidateDelete() error {
 	return nil
 }
 
+// WarningsOnCreate implements webhookutil.Warner so warnings are returned when the AzureMachinePool is created.
+func (amp *AzureMachinePool) WarningsOnCreate() []string {
+	return infrav1.UserAssignedIdentitiesWarnings(amp.Spec.Identity, amp.Spec.UserAssignedIdentities, field.NewPath("spec", "userAssignedIdentities"))
+}
+
+// WarningsOnUpdate implements webhookutil.Warner so warnings are returned when the AzureMachinePool is updated.
+func (amp *AzureMachinePool) WarningsOnUpdate(_ runtime.Object) []string {
+	return amp.WarningsOnCreate()
+}
+
 // Validate the Azure Machine Pool and return an aggregate error.
 func (amp *AzureMachinePool) Validate(old runtime.Object) error {
 	validators := []func() error{
 		amp.ValidateImage,
 		amp.ValidateTerminateNotificationTimeout,
 		amp.ValidateSSHKey,
+		amp.ValidateAdminPasswordSecret,
+		amp.ValidateAdditionalCustomDataSecret,
 		amp.ValidateUserAssignedIdentity,
 		amp.ValidateStrategy(),
 		amp.ValidateSystemAssignedIdentity(old),
+		amp.ValidateTagPolicy(old),
 	}
 
 	var errs []error
@@ -131,6 +149,13 @@ func (amp *AzureMachinePool) ValidateTerminateNotificationTimeout() error {
 
 // ValidateSSHKey validates an SSHKey.
 func (amp *AzureMachinePool) ValidateSSHKey() error {
+	if amp.Spec.Template.SSHPublicKeySecret != nil {
+		if errs := infrav1.ValidateSSHPublicKeySecret(amp.Spec.Template.SSHPublicKey, amp.Spec.Template.SSHPublicKeySecret, field.NewPath("sshPublicKeySecret")); len(errs) > 0 {
+			return kerrors.NewAggregate(errs.ToAggregate().Errors())
+		}
+		return nil
+	}
+
 	if amp.Spec.Template.SSHPublicKey != "" {
 		sshKey := amp.Spec.Template.SSHPublicKey
 		if errs := infrav1.ValidateSSHKey(sshKey, field.NewPath("sshKey")); len(errs) > 0 {
@@ -143,6 +168,24 @@ func (amp *AzureMachinePool) ValidateSSHKey() error {
 	return nil
 }
 
+// ValidateAdminPasswordSecret validates the admin password Key Vault secret reference.
+func (amp *AzureMachinePool) ValidateAdminPasswordSecret() error {
+	if errs := infrav1.ValidateAdminPasswordSecret(amp.Spec.Template.OSDisk.OSType, amp.Spec.Template.AdminPasswordSecret, field.NewPath("adminPasswordSecret")); len(errs) > 0 {
+		return kerrors.NewAggregate(errs.ToAggregate().Errors())
+	}
+
+	return nil
+}
+
+// ValidateAdditionalCustomDataSecret validates the additional custom data secret reference.
+func (amp *AzureMachinePool) ValidateAdditionalCustomDataSecret() error {
+	if errs := infrav1.ValidateAdditionalCustomDataSecret(amp.Spec.Template.OSDisk.OSType, amp.Spec.Template.AdditionalCustomDataSecret, field.NewPath("additionalCustomDataSecret")); len(errs) > 0 {
+		return kerrors.NewAggregate(errs.ToAggregate().Errors())
+	}
+
+	return nil
+}
+
 // ValidateUserAssignedIdentity validates the user-assigned identities list.
 func (amp *AzureMachinePool) ValidateUserAssignedIdentity() error {
 	fldPath := field.NewPath("UserAssignedIdentities")
@@ -191,3 +234,26 @@ func (amp *AzureMachinePool) ValidateSystemAssignedIdentity(old runtime.Object)
 		return nil
 	}
 }
+
+// ValidateTagPolicy validates the additional tags of an AzureMachinePool against the tag policy, unless an update
+// leaves them unchanged.
+func (amp *AzureMachinePool) ValidateTagPolicy(old runtime.Object) func() error {
+	return func() error {
+		if old != nil {
+			oldMachinePool, ok := old.(*AzureMachinePool)
+			if !ok {
+				return fmt.Errorf("unexpected type for old azure machine pool object. Expected: %q, Got: %q",
+					"AzureMachinePool", reflect.TypeOf(old))
+			}
+			if reflect.DeepEqual(amp.Spec.AdditionalTags, oldMachinePool.Spec.AdditionalTags) {
+				return nil
+			}
+		}
+
+		if errs := infrav1.ValidateTagPolicy(amp.Spec.AdditionalTags, false, field.NewPath("additionalTags")); len(errs) > 0 {
+			return kerrors.NewAggregate(errs.ToAggregate().Errors())
+		}
+
+		return nil
+	}
+}
@@ -89,6 +89,34 @@ func TestAzureMachinePool_ValidateCreate(t *testing.T) {
 			amp:     createMachinePoolWithSSHPublicKey("invalid ssh key"),
 			wantErr: true,
 		},
+		{
+			name: "azuremachinepool with SSHPublicKeySecret and SSHPublicKey",
+			amp: func() *AzureMachinePool {
+				amp := createMachinePoolWithSSHPublicKey(validSSHPublicKey)
+				amp.Spec.Template.SSHPublicKeySecret = &infrav1.KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/ssh-key"}
+				return amp
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with AdminPasswordSecret on Linux",
+			amp: func() *AzureMachinePool {
+				amp := createMachinePoolWithSSHPublicKey(validSSHPublicKey)
+				amp.Spec.Template.AdminPasswordSecret = &infrav1.KeyVaultSecretReference{SecretURL: "https://my-vault.vault.azure.net/secrets/admin-password"}
+				return amp
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "azuremachinepool with AdditionalCustomDataSecret on Windows",
+			amp: func() *AzureMachinePool {
+				amp := createMachinePoolWithSSHPublicKey(validSSHPublicKey)
+				amp.Spec.Template.OSDisk.OSType = "Windows"
+				amp.Spec.Template.AdditionalCustomDataSecret = &infrav1.SecretKeyReference{Name: "custom-data"}
+				return amp
+			}(),
+			wantErr: true,
+		},
 		{
 			name:    "azuremachinepool with wrong terminate notification",
 			amp:     createMachinePoolWithSharedImage("SUB123", "RG123", "NAME123", "GALLERY1", "1.0.0", to.IntPtr(35)),
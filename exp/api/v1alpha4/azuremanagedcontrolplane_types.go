@@ -86,6 +86,170 @@ type AzureManagedControlPlaneSpec struct {
 	// IdentityRef is a reference to a AzureClusterIdentity to be used when reconciling this cluster
 	// +optional
 	IdentityRef *corev1.ObjectReference `json:"identityRef,omitempty"`
+
+	// IngressProfile configures the ingress addons of the AKS cluster.
+	// +optional
+	IngressProfile *IngressProfile `json:"ingressProfile,omitempty"`
+
+	// WindowsProfile configures the Windows nodes of the AKS cluster. Requires the azure network plugin.
+	// It must be set when the cluster is created for Windows node pools to be added to it, and can't be
+	// added or removed afterwards.
+	// +optional
+	WindowsProfile *ManagedControlPlaneWindowsProfile `json:"windowsProfile,omitempty"`
+
+	// MetricsProfile configures the metrics addons of the AKS cluster.
+	// +optional
+	MetricsProfile *MetricsProfile `json:"metricsProfile,omitempty"`
+
+	// SKU is the SKU of the AKS cluster. AKS creates clusters of the Free tier when it is not set.
+	// +optional
+	SKU *AKSSku `json:"sku,omitempty"`
+
+	// UpgradePolicy configures how Kubernetes version upgrades are rolled out over the control plane
+	// and the node pools of the AKS cluster.
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
+	// Backup configures Azure Backup for the AKS cluster. When set, a Backup Vault, the backup extension
+	// and a backup policy and instance are provisioned for the cluster.
+	// +optional
+	Backup *ManagedControlPlaneBackup `json:"backup,omitempty"`
+}
+
+const (
+	// IndependentUpgradeMode upgrades the control plane and each node pool as soon as their version changes.
+	IndependentUpgradeMode = "Independent"
+
+	// OrchestratedUpgradeMode upgrades the control plane first and the node pools one after another.
+	OrchestratedUpgradeMode = "Orchestrated"
+
+	// ResumeUpgradeAnnotation resumes a paused orchestrated upgrade when set on an AzureManagedControlPlane.
+	// The annotation is removed once the upgrade is resumed.
+	ResumeUpgradeAnnotation = "azuremanagedcontrolplane.infrastructure.cluster.x-k8s.io/resume-upgrade"
+)
+
+// UpgradePolicy describes how Kubernetes version upgrades of an AKS cluster are rolled out.
+type UpgradePolicy struct {
+	// Mode is the upgrade mode. With Independent, the control plane and each node pool are upgraded as
+	// soon as their version changes. With Orchestrated, the control plane is upgraded first, followed by
+	// the node pools one at a time. An orchestrated upgrade pauses when a step fails or times out.
+	// +kubebuilder:validation:Enum=Independent;Orchestrated
+	// +kubebuilder:default=Independent
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// NodePoolOrder lists the names of AzureManagedMachinePools in the order they are upgraded in
+	// Orchestrated mode. Node pools that are not listed are upgraded afterwards, in alphabetical order.
+	// +optional
+	NodePoolOrder []string `json:"nodePoolOrder,omitempty"`
+
+	// SoakDuration is the time to wait after the control plane or a node pool was upgraded before the
+	// next node pool is upgraded in Orchestrated mode.
+	// +optional
+	SoakDuration *metav1.Duration `json:"soakDuration,omitempty"`
+
+	// NodePoolTimeout is the time a node pool may take to upgrade in Orchestrated mode, until AKS reports
+	// it upgraded and all of its nodes are ready. The upgrade is paused when a node pool takes longer.
+	// Defaults to 2 hours.
+	// +optional
+	NodePoolTimeout *metav1.Duration `json:"nodePoolTimeout,omitempty"`
+}
+
+// ManagedControlPlaneBackup describes how an AKS cluster is backed up with Azure Backup.
+type ManagedControlPlaneBackup struct {
+	// VaultName is the name of the Backup Vault, which is created in the resource group of the cluster.
+	VaultName string `json:"vaultName"`
+
+	// StorageAccountID is the resource ID of the storage account the backup extension stores backups in.
+	StorageAccountID string `json:"storageAccountID"`
+
+	// BlobContainer is the name of the blob container in the storage account the backups are stored in.
+	BlobContainer string `json:"blobContainer"`
+
+	// BackupIntervalHours is the number of hours between two backups.
+	// +kubebuilder:validation:Enum=4;6;8;12;24
+	// +kubebuilder:default=24
+	// +optional
+	BackupIntervalHours int32 `json:"backupIntervalHours,omitempty"`
+
+	// RetentionDays is the number of days backups are kept for.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=360
+	// +kubebuilder:default=7
+	// +optional
+	RetentionDays int32 `json:"retentionDays,omitempty"`
+
+	// IncludedNamespaces are the namespaces that are backed up. All namespaces are backed up when empty.
+	// +optional
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+}
+
+// AKSSku describes the SKU of an AKS cluster.
+type AKSSku struct {
+	// Tier is the tier of the AKS cluster. The Standard and Premium tiers come with an uptime SLA and
+	// are required by some addons, such as cost analysis.
+	// +kubebuilder:validation:Enum=Free;Standard;Premium
+	Tier string `json:"tier"`
+}
+
+// MetricsProfile describes the metrics addons of an AKS cluster.
+type MetricsProfile struct {
+	// CostAnalysis configures the cost analysis addon, which adds per-namespace and per-asset cost
+	// details of the cluster to Azure Cost Management. Requires the Standard or Premium SKU tier.
+	// +optional
+	CostAnalysis *CostAnalysis `json:"costAnalysis,omitempty"`
+}
+
+// CostAnalysis describes the cost analysis addon of an AKS cluster.
+type CostAnalysis struct {
+	// Enabled toggles the cost analysis addon.
+	Enabled bool `json:"enabled"`
+}
+
+// ManagedControlPlaneWindowsProfile describes the settings applied to the Windows nodes of an AKS cluster.
+type ManagedControlPlaneWindowsProfile struct {
+	// LicenseType is the license type of the Windows VMs. Windows_Server enables Azure Hybrid User Benefits.
+	// +kubebuilder:validation:Enum=None;Windows_Server
+	// +optional
+	LicenseType *string `json:"licenseType,omitempty"`
+
+	// GMSA configures group managed service accounts for the Windows nodes.
+	// +optional
+	GMSA *GMSAProfile `json:"gmsa,omitempty"`
+}
+
+// GMSAProfile describes the group managed service account settings of the Windows nodes.
+type GMSAProfile struct {
+	// Enabled toggles GMSA on the Windows nodes.
+	Enabled bool `json:"enabled"`
+
+	// DNSServer is the IP address of the DNS server of the Active Directory domain. Must be set
+	// together with RootDomainName; when both are empty, the DNS server of the virtual network is used.
+	// +optional
+	DNSServer string `json:"dnsServer,omitempty"`
+
+	// RootDomainName is the root domain name of the Active Directory domain. Must be set together with DNSServer.
+	// +optional
+	RootDomainName string `json:"rootDomainName,omitempty"`
+}
+
+// IngressProfile describes the ingress addons of an AKS cluster.
+type IngressProfile struct {
+	// WebAppRouting configures the web app routing addon, which deploys a managed NGINX ingress controller.
+	// The addon is disabled when this is removed.
+	// +optional
+	WebAppRouting *WebAppRouting `json:"webAppRouting,omitempty"`
+}
+
+// WebAppRouting describes the web app routing addon of an AKS cluster.
+type WebAppRouting struct {
+	// Enabled toggles the web app routing addon.
+	Enabled bool `json:"enabled"`
+
+	// DNSZoneResourceIDs are the resource IDs of the public or private Azure DNS zones the addon
+	// manages records in. Only valid when the addon is enabled.
+	// +optional
+	DNSZoneResourceIDs []string `json:"dnsZoneResourceIDs,omitempty"`
 }
 
 // ManagedControlPlaneVirtualNetwork describes a virtual network required to provision AKS clusters.
@@ -112,6 +276,56 @@ type AzureManagedControlPlaneStatus struct {
 	// In the AzureManagedControlPlane implementation, these are identical.
 	// +optional
 	Initialized bool `json:"initialized,omitempty"`
+
+	// Upgrade reports the progress of the last orchestrated upgrade.
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+}
+
+// UpgradePhase is the phase of an orchestrated upgrade.
+type UpgradePhase string
+
+const (
+	// UpgradePhaseControlPlane means the control plane is being upgraded.
+	UpgradePhaseControlPlane = UpgradePhase("ControlPlane")
+
+	// UpgradePhaseNodePools means the node pools are being upgraded one after another.
+	UpgradePhaseNodePools = UpgradePhase("NodePools")
+
+	// UpgradePhasePaused means the upgrade failed and waits to be resumed.
+	UpgradePhasePaused = UpgradePhase("Paused")
+
+	// UpgradePhaseCompleted means the control plane and all node pools were upgraded.
+	UpgradePhaseCompleted = UpgradePhase("Completed")
+)
+
+// UpgradeStatus describes the progress of an orchestrated upgrade.
+type UpgradeStatus struct {
+	// Version is the Kubernetes version being rolled out.
+	Version string `json:"version"`
+
+	// Phase is the phase of the upgrade.
+	Phase UpgradePhase `json:"phase"`
+
+	// NodePool is the name of the AzureManagedMachinePool being upgraded.
+	// +optional
+	NodePool string `json:"nodePool,omitempty"`
+
+	// NodePoolStartTime is the time the upgrade of NodePool started or was resumed.
+	// +optional
+	NodePoolStartTime *metav1.Time `json:"nodePoolStartTime,omitempty"`
+
+	// UpgradedNodePools are the names of the AzureManagedMachinePools already running Version.
+	// +optional
+	UpgradedNodePools []string `json:"upgradedNodePools,omitempty"`
+
+	// LastTransitionTime is the time the control plane or the last node pool finished upgrading.
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// FailureMessage explains why the upgrade was paused.
+	// +optional
+	FailureMessage *string `json:"failureMessage,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -18,10 +18,13 @@ package v1alpha4
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"reflect"
 	"regexp"
 	"strings"
 
+	"github.com/Azure/go-autorest/autorest/azure"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -89,6 +92,10 @@ var _ webhook.Validator = &AzureManagedControlPlane{}
 func (r *AzureManagedControlPlane) ValidateCreate() error {
 	azuremanagedcontrolplanelog.Info("validate create", "name", r.Name)
 
+	if errs := infrav1.ValidateTagPolicy(r.Spec.AdditionalTags, true, field.NewPath("Spec", "AdditionalTags")); len(errs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("AzureManagedControlPlane").GroupKind(), r.Name, errs)
+	}
+
 	return r.Validate()
 }
 
@@ -227,6 +234,30 @@ func (r *AzureManagedControlPlane) ValidateUpdate(oldRaw runtime.Object) error {
 		}
 	}
 
+	// Windows agent pools can only be added to clusters created with a Windows profile, and AKS does not allow
+	// to remove the profile.
+	if (old.Spec.WindowsProfile == nil) != (r.Spec.WindowsProfile == nil) {
+		allErrs = append(allErrs,
+			field.Invalid(
+				field.NewPath("Spec", "WindowsProfile"),
+				r.Spec.WindowsProfile,
+				"field can't be added or removed after creation"))
+	}
+
+	if old.Spec.Backup != nil && r.Spec.Backup != nil {
+		if r.Spec.Backup.VaultName != old.Spec.Backup.VaultName {
+			allErrs = append(allErrs,
+				field.Invalid(
+					field.NewPath("Spec", "Backup", "VaultName"),
+					r.Spec.Backup.VaultName,
+					"field is immutable"))
+		}
+	}
+
+	if !reflect.DeepEqual(r.Spec.AdditionalTags, old.Spec.AdditionalTags) {
+		allErrs = append(allErrs, infrav1.ValidateTagPolicy(r.Spec.AdditionalTags, true, field.NewPath("Spec", "AdditionalTags"))...)
+	}
+
 	if len(allErrs) == 0 {
 		return r.Validate()
 	}
@@ -247,6 +278,11 @@ func (r *AzureManagedControlPlane) Validate() error {
 		r.validateVersion,
 		r.validateDNSServiceIP,
 		r.validateSSHKey,
+		r.validateIngressProfile,
+		r.validateWindowsProfile,
+		r.validateMetricsProfile,
+		r.validateUpgradePolicy,
+		r.validateBackup,
 	}
 
 	var errs []error
@@ -291,3 +327,118 @@ func (r *AzureManagedControlPlane) validateSSHKey() error {
 
 	return nil
 }
+
+// validateIngressProfile validates the ingress profile.
+func (r *AzureManagedControlPlane) validateIngressProfile() error {
+	if r.Spec.IngressProfile == nil || r.Spec.IngressProfile.WebAppRouting == nil {
+		return nil
+	}
+
+	webAppRouting := r.Spec.IngressProfile.WebAppRouting
+	if !webAppRouting.Enabled && len(webAppRouting.DNSZoneResourceIDs) > 0 {
+		return errors.New("IngressProfile.WebAppRouting.DNSZoneResourceIDs can only be set when web app routing is enabled")
+	}
+
+	for _, id := range webAppRouting.DNSZoneResourceIDs {
+		resource, err := azure.ParseResourceID(id)
+		if err != nil {
+			return fmt.Errorf("IngressProfile.WebAppRouting.DNSZoneResourceIDs contains an invalid resource ID %q", id)
+		}
+		if !strings.EqualFold(resource.Provider, "Microsoft.Network") ||
+			(!strings.EqualFold(resource.ResourceType, "dnszones") && !strings.EqualFold(resource.ResourceType, "privatednszones")) {
+			return fmt.Errorf("IngressProfile.WebAppRouting.DNSZoneResourceIDs contains %q which is not an Azure DNS zone", id)
+		}
+	}
+
+	return nil
+}
+
+// validateWindowsProfile validates the Windows profile.
+func (r *AzureManagedControlPlane) validateWindowsProfile() error {
+	if r.Spec.WindowsProfile == nil {
+		return nil
+	}
+
+	if r.Spec.NetworkPlugin != nil && *r.Spec.NetworkPlugin != "azure" {
+		return errors.New("WindowsProfile requires the azure network plugin")
+	}
+
+	gmsa := r.Spec.WindowsProfile.GMSA
+	if gmsa == nil {
+		return nil
+	}
+	if !gmsa.Enabled && (gmsa.DNSServer != "" || gmsa.RootDomainName != "") {
+		return errors.New("WindowsProfile.GMSA.DNSServer and RootDomainName can only be set when GMSA is enabled")
+	}
+	if (gmsa.DNSServer == "") != (gmsa.RootDomainName == "") {
+		return errors.New("WindowsProfile.GMSA.DNSServer and RootDomainName must either both be set or both be empty")
+	}
+	if gmsa.DNSServer != "" && net.ParseIP(gmsa.DNSServer) == nil {
+		return errors.New("WindowsProfile.GMSA.DNSServer must be a valid IP")
+	}
+
+	return nil
+}
+
+// validateMetricsProfile validates the metrics profile.
+func (r *AzureManagedControlPlane) validateMetricsProfile() error {
+	if r.Spec.MetricsProfile == nil || r.Spec.MetricsProfile.CostAnalysis == nil || !r.Spec.MetricsProfile.CostAnalysis.Enabled {
+		return nil
+	}
+
+	if r.Spec.SKU == nil || (r.Spec.SKU.Tier != "Standard" && r.Spec.SKU.Tier != "Premium") {
+		return errors.New("MetricsProfile.CostAnalysis requires the Standard or Premium SKU tier")
+	}
+
+	return nil
+}
+
+// validateUpgradePolicy validates the upgrade policy.
+func (r *AzureManagedControlPlane) validateUpgradePolicy() error {
+	if r.Spec.UpgradePolicy == nil {
+		return nil
+	}
+
+	if r.Spec.UpgradePolicy.SoakDuration != nil && r.Spec.UpgradePolicy.SoakDuration.Duration < 0 {
+		return errors.New("UpgradePolicy.SoakDuration must not be negative")
+	}
+
+	if r.Spec.UpgradePolicy.NodePoolTimeout != nil && r.Spec.UpgradePolicy.NodePoolTimeout.Duration <= 0 {
+		return errors.New("UpgradePolicy.NodePoolTimeout must be positive")
+	}
+
+	pools := map[string]bool{}
+	for _, pool := range r.Spec.UpgradePolicy.NodePoolOrder {
+		if pools[pool] {
+			return fmt.Errorf("UpgradePolicy.NodePoolOrder lists node pool %s more than once", pool)
+		}
+		pools[pool] = true
+	}
+
+	return nil
+}
+
+// validateBackup validates the backup settings.
+func (r *AzureManagedControlPlane) validateBackup() error {
+	if r.Spec.Backup == nil {
+		return nil
+	}
+
+	if r.Spec.Backup.VaultName == "" {
+		return errors.New("Backup.VaultName must be set")
+	}
+
+	if r.Spec.Backup.BlobContainer == "" {
+		return errors.New("Backup.BlobContainer must be set")
+	}
+
+	resource, err := azure.ParseResourceID(r.Spec.Backup.StorageAccountID)
+	if err != nil {
+		return fmt.Errorf("Backup.StorageAccountID %q is not a valid resource ID", r.Spec.Backup.StorageAccountID)
+	}
+	if !strings.EqualFold(resource.Provider, "Microsoft.Storage") || !strings.EqualFold(resource.ResourceType, "storageAccounts") {
+		return fmt.Errorf("Backup.StorageAccountID %q is not a storage account", r.Spec.Backup.StorageAccountID)
+	}
+
+	return nil
+}
@@ -18,6 +18,7 @@ package v1alpha4
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest/to"
 	. "github.com/onsi/gomega"
@@ -140,6 +141,260 @@ func TestValidatingWebhook(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "Valid web app routing with DNS zones",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					IngressProfile: &IngressProfile{
+						WebAppRouting: &WebAppRouting{
+							Enabled: true,
+							DNSZoneResourceIDs: []string{
+								"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/dnszones/example.com",
+								"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/privateDnsZones/example.internal",
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "DNS zones with web app routing disabled",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					IngressProfile: &IngressProfile{
+						WebAppRouting: &WebAppRouting{
+							DNSZoneResourceIDs: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/dnszones/example.com"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid Windows profile with GMSA",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version:       "v1.17.8",
+					NetworkPlugin: pointer.StringPtr("azure"),
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{
+						LicenseType: pointer.StringPtr("Windows_Server"),
+						GMSA: &GMSAProfile{
+							Enabled:        true,
+							DNSServer:      "10.0.0.4",
+							RootDomainName: "contoso.com",
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Windows profile with kubenet network plugin",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version:        "v1.17.8",
+					NetworkPlugin:  pointer.StringPtr("kubenet"),
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "GMSA with only a DNS server",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{
+						GMSA: &GMSAProfile{
+							Enabled:   true,
+							DNSServer: "10.0.0.4",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "GMSA with an invalid DNS server",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{
+						GMSA: &GMSAProfile{
+							Enabled:        true,
+							DNSServer:      "dns.contoso.com",
+							RootDomainName: "contoso.com",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Cost analysis with the Standard SKU tier",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					SKU:     &AKSSku{Tier: "Standard"},
+					MetricsProfile: &MetricsProfile{
+						CostAnalysis: &CostAnalysis{Enabled: true},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Cost analysis without a SKU tier",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					MetricsProfile: &MetricsProfile{
+						CostAnalysis: &CostAnalysis{Enabled: true},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Cost analysis with the Free SKU tier",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					SKU:     &AKSSku{Tier: "Free"},
+					MetricsProfile: &MetricsProfile{
+						CostAnalysis: &CostAnalysis{Enabled: true},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid DNS zone resource ID",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					IngressProfile: &IngressProfile{
+						WebAppRouting: &WebAppRouting{
+							Enabled:            true,
+							DNSZoneResourceIDs: []string{"example.com"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "DNS zone resource ID of another resource type",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					IngressProfile: &IngressProfile{
+						WebAppRouting: &WebAppRouting{
+							Enabled:            true,
+							DNSZoneResourceIDs: []string{"/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet"},
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid upgrade policy",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					UpgradePolicy: &UpgradePolicy{
+						Mode:          OrchestratedUpgradeMode,
+						NodePoolOrder: []string{"pool0", "pool1"},
+						SoakDuration:  &metav1.Duration{Duration: 10 * time.Minute},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Upgrade policy with a node pool listed twice",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					UpgradePolicy: &UpgradePolicy{
+						Mode:          OrchestratedUpgradeMode,
+						NodePoolOrder: []string{"pool0", "pool1", "pool0"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Upgrade policy with a negative soak duration",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					UpgradePolicy: &UpgradePolicy{
+						Mode:         OrchestratedUpgradeMode,
+						SoakDuration: &metav1.Duration{Duration: -time.Minute},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Upgrade policy with a zero node pool timeout",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					UpgradePolicy: &UpgradePolicy{
+						Mode:            OrchestratedUpgradeMode,
+						NodePoolTimeout: &metav1.Duration{},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid backup",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					Backup: &ManagedControlPlaneBackup{
+						VaultName:        "my-vault",
+						StorageAccountID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Storage/storageAccounts/backups",
+						BlobContainer:    "velero",
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Backup with a storage account ID of another resource type",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					Backup: &ManagedControlPlaneBackup{
+						VaultName:        "my-vault",
+						StorageAccountID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/my-vnet",
+						BlobContainer:    "velero",
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Backup without a blob container",
+			amcp: AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					Version: "v1.17.8",
+					Backup: &ManagedControlPlaneBackup{
+						VaultName:        "my-vault",
+						StorageAccountID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Storage/storageAccounts/backups",
+					},
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -496,6 +751,92 @@ func TestAzureManagedControlPlane_ValidateUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "AzureManagedControlPlane Backup.VaultName is immutable",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: to.StringPtr("192.168.0.0"),
+					Version:      "v1.18.0",
+					Backup: &ManagedControlPlaneBackup{
+						VaultName:        "vault-1",
+						StorageAccountID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Storage/storageAccounts/backups",
+						BlobContainer:    "velero",
+					},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP: to.StringPtr("192.168.0.0"),
+					Version:      "v1.18.0",
+					Backup: &ManagedControlPlaneBackup{
+						VaultName:        "vault-2",
+						StorageAccountID: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Storage/storageAccounts/backups",
+						BlobContainer:    "velero",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "AzureManagedControlPlane WindowsProfile can't be added",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP:  to.StringPtr("192.168.0.0"),
+					Version:       "v1.18.0",
+					NetworkPlugin: to.StringPtr("azure"),
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP:   to.StringPtr("192.168.0.0"),
+					Version:        "v1.18.0",
+					NetworkPlugin:  to.StringPtr("azure"),
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "AzureManagedControlPlane WindowsProfile can't be removed",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP:   to.StringPtr("192.168.0.0"),
+					Version:        "v1.18.0",
+					NetworkPlugin:  to.StringPtr("azure"),
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP:  to.StringPtr("192.168.0.0"),
+					Version:       "v1.18.0",
+					NetworkPlugin: to.StringPtr("azure"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "AzureManagedControlPlane WindowsProfile LicenseType can be changed",
+			oldAMCP: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP:   to.StringPtr("192.168.0.0"),
+					Version:        "v1.18.0",
+					NetworkPlugin:  to.StringPtr("azure"),
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{},
+				},
+			},
+			amcp: &AzureManagedControlPlane{
+				Spec: AzureManagedControlPlaneSpec{
+					DNSServiceIP:  to.StringPtr("192.168.0.0"),
+					Version:       "v1.18.0",
+					NetworkPlugin: to.StringPtr("azure"),
+					WindowsProfile: &ManagedControlPlaneWindowsProfile{
+						LicenseType: to.StringPtr("Windows_Server"),
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -21,6 +21,14 @@ import (
 	capierrors "sigs.k8s.io/cluster-api/errors"
 )
 
+const (
+	// LinuxOSType is the operating system type of Linux agent pools.
+	LinuxOSType = "Linux"
+
+	// WindowsOSType is the operating system type of Windows agent pools.
+	WindowsOSType = "Windows"
+)
+
 // AzureManagedMachinePoolSpec defines the desired state of AzureManagedMachinePool.
 type AzureManagedMachinePoolSpec struct {
 	// SKU is the size of the VMs in the node pool.
@@ -30,11 +38,71 @@ type AzureManagedMachinePoolSpec struct {
 	// If you specify 0, it will apply the default osDisk size according to the vmSize specified.
 	OSDiskSizeGB *int32 `json:"osDiskSizeGB,omitempty"`
 
+	// OSType is the operating system of the VMs in the node pool. Windows node pools require the
+	// AKS cluster to use the azure network plugin. Defaults to Linux.
+	// +kubebuilder:validation:Enum=Linux;Windows
+	// +optional
+	OSType *string `json:"osType,omitempty"`
+
+	// AvailabilityZones are the availability zones of the nodes in the node pool. AKS does not allow to
+	// change the zones of an existing node pool, so changing them replaces the node pool.
+	// +optional
+	AvailabilityZones []string `json:"availabilityZones,omitempty"`
+
+	// ScaleDownMode is the behavior when the node pool is scaled down. Delete removes the nodes, while
+	// Deallocate stops and deallocates them, so that they can be started again for faster scale up.
+	// Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Deallocate
+	// +optional
+	ScaleDownMode *string `json:"scaleDownMode,omitempty"`
+
+	// UpgradeSettings configures how the nodes of the node pool are replaced during an upgrade.
+	// Changes are applied to the existing node pool.
+	// +optional
+	UpgradeSettings *ManagedMachinePoolUpgradeSettings `json:"upgradeSettings,omitempty"`
+
 	// ProviderIDList is the unique identifier as specified by the cloud provider.
 	// +optional
 	ProviderIDList []string `json:"providerIDList,omitempty"`
 }
 
+const (
+	// CordonUndrainableNodeBehavior cordons nodes that can not be drained during an upgrade, labels them
+	// and replaces them by surge nodes.
+	CordonUndrainableNodeBehavior = "Cordon"
+
+	// ScheduleUndrainableNodeBehavior keeps nodes that can not be drained during an upgrade schedulable
+	// and fails the upgrade.
+	ScheduleUndrainableNodeBehavior = "Schedule"
+)
+
+// ManagedMachinePoolUpgradeSettings describes how the nodes of an AKS node pool are upgraded.
+type ManagedMachinePoolUpgradeSettings struct {
+	// MaxSurge is the number of extra nodes created during an upgrade, either as a number (e.g. "5") or
+	// as a percentage of the node pool size (e.g. "50%"). AKS defaults to "10%".
+	// +kubebuilder:validation:Pattern=`^[0-9]+%?$`
+	// +optional
+	MaxSurge *string `json:"maxSurge,omitempty"`
+
+	// DrainTimeout is the time to wait for the eviction of the pods of a node, including the time to wait
+	// for pod disruption budgets. It must be a whole number of minutes between 1m and 24h. AKS defaults
+	// to 30m.
+	// +optional
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+
+	// NodeSoakDuration is the time to wait after draining a node before it is reimaged and the next node
+	// is upgraded. It must be a whole number of minutes of at most 30m. AKS defaults to 0.
+	// +optional
+	NodeSoakDuration *metav1.Duration `json:"nodeSoakDuration,omitempty"`
+
+	// UndrainableNodeBehavior is the behavior for nodes that can not be drained during an upgrade. Cordon
+	// cordons and labels them and replaces them with surge nodes, while Schedule keeps them schedulable
+	// and fails the upgrade. AKS defaults to Schedule.
+	// +kubebuilder:validation:Enum=Cordon;Schedule
+	// +optional
+	UndrainableNodeBehavior *string `json:"undrainableNodeBehavior,omitempty"`
+}
+
 // AzureManagedMachinePoolStatus defines the observed state of AzureManagedMachinePool.
 type AzureManagedMachinePoolStatus struct {
 	// Ready is true when the provider resource is ready.
@@ -45,6 +113,11 @@ type AzureManagedMachinePoolStatus struct {
 	// +optional
 	Replicas int32 `json:"replicas"`
 
+	// DeallocatedReplicas is the most recently observed number of deallocated replicas, which are
+	// kept by a node pool with the Deallocate scale down mode.
+	// +optional
+	DeallocatedReplicas int32 `json:"deallocatedReplicas,omitempty"`
+
 	// Any transient errors that occur during the reconciliation of Machines
 	// can be added as events to the Machine object and/or logged in the
 	// controller's output.
@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// maxWindowsAgentPoolNameLength is the maximum length AKS allows for the name of a Windows agent pool.
+const maxWindowsAgentPoolNameLength = 6
+
+// log is for logging in this package.
+var azuremanagedmachinepoollog = logf.Log.WithName("azuremanagedmachinepool-resource")
+
+// azureManagedMachinePoolWebhookClient is used to look up the AzureManagedControlPlane of the cluster an
+// AzureManagedMachinePool belongs to. It is set up together with the webhook.
+var azureManagedMachinePoolWebhookClient client.Client
+
+// SetupWebhookWithManager sets up and registers the webhook with the manager.
+func (r *AzureManagedMachinePool) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	azureManagedMachinePoolWebhookClient = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-infrastructure-cluster-x-k8s-io-v1alpha4-azuremanagedmachinepool,mutating=true,failurePolicy=fail,groups=infrastructure.cluster.x-k8s.io,resources=azuremanagedmachinepools,verbs=create;update,versions=v1alpha4,name=default.azuremanagedmachinepools.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &AzureManagedMachinePool{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (r *AzureManagedMachinePool) Default() {
+	azuremanagedmachinepoollog.Info("default", "name", r.Name)
+
+	if r.Spec.OSType == nil {
+		osType := LinuxOSType
+		r.Spec.OSType = &osType
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-infrastructure-cluster-x-k8s-io-v1alpha4-azuremanagedmachinepool,mutating=false,failurePolicy=fail,groups=infrastructure.cluster.x-k8s.io,resources=azuremanagedmachinepools,versions=v1alpha4,name=validation.azuremanagedmachinepools.infrastructure.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &AzureManagedMachinePool{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *AzureManagedMachinePool) ValidateCreate() error {
+	azuremanagedmachinepoollog.Info("validate create", "name", r.Name)
+
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *AzureManagedMachinePool) ValidateUpdate(oldRaw runtime.Object) error {
+	azuremanagedmachinepoollog.Info("validate update", "name", r.Name)
+	old := oldRaw.(*AzureManagedMachinePool)
+
+	var allErrs field.ErrorList
+	if old.Spec.OSType != nil && (r.Spec.OSType == nil || *r.Spec.OSType != *old.Spec.OSType) {
+		allErrs = append(allErrs,
+			field.Invalid(
+				field.NewPath("Spec", "OSType"),
+				r.Spec.OSType,
+				"field is immutable"))
+	}
+
+	if len(allErrs) != 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("AzureManagedMachinePool").GroupKind(), r.Name, allErrs)
+	}
+
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *AzureManagedMachinePool) ValidateDelete() error {
+	azuremanagedmachinepoollog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validate validates an AzureManagedMachinePool.
+func (r *AzureManagedMachinePool) validate() error {
+	var allErrs field.ErrorList
+
+	zones := map[string]bool{}
+	for i, zone := range r.Spec.AvailabilityZones {
+		if zones[zone] {
+			allErrs = append(allErrs, field.Duplicate(field.NewPath("Spec", "AvailabilityZones").Index(i), zone))
+		}
+		zones[zone] = true
+	}
+
+	allErrs = append(allErrs, r.validateUpgradeSettings()...)
+
+	if r.Spec.OSType != nil && *r.Spec.OSType == WindowsOSType {
+		if len(r.Name) > maxWindowsAgentPoolNameLength {
+			allErrs = append(allErrs,
+				field.Invalid(
+					field.NewPath("Name"),
+					r.Name,
+					fmt.Sprintf("Windows agent pool names must not be longer than %d characters", maxWindowsAgentPoolNameLength)))
+		}
+
+		if err := r.validateWindowsNetworkPlugin(context.TODO()); err != nil {
+			allErrs = append(allErrs,
+				field.Invalid(
+					field.NewPath("Spec", "OSType"),
+					*r.Spec.OSType,
+					err.Error()))
+		}
+	}
+
+	if len(allErrs) != 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("AzureManagedMachinePool").GroupKind(), r.Name, allErrs)
+	}
+
+	return nil
+}
+
+// validateUpgradeSettings ensures the drain timeout and node soak duration are whole minutes within the
+// ranges AKS accepts.
+func (r *AzureManagedMachinePool) validateUpgradeSettings() field.ErrorList {
+	var allErrs field.ErrorList
+
+	settings := r.Spec.UpgradeSettings
+	if settings == nil {
+		return allErrs
+	}
+
+	if d := settings.DrainTimeout; d != nil {
+		if d.Duration%time.Minute != 0 || d.Duration < time.Minute || d.Duration > 24*time.Hour {
+			allErrs = append(allErrs,
+				field.Invalid(
+					field.NewPath("Spec", "UpgradeSettings", "DrainTimeout"),
+					d.Duration.String(),
+					"must be a whole number of minutes between 1m and 24h"))
+		}
+	}
+
+	if d := settings.NodeSoakDuration; d != nil {
+		if d.Duration%time.Minute != 0 || d.Duration < 0 || d.Duration > 30*time.Minute {
+			allErrs = append(allErrs,
+				field.Invalid(
+					field.NewPath("Spec", "UpgradeSettings", "NodeSoakDuration"),
+					d.Duration.String(),
+					"must be a whole number of minutes between 0 and 30m"))
+		}
+	}
+
+	if settings.MaxSurge != nil && (*settings.MaxSurge == "0" || *settings.MaxSurge == "0%") {
+		allErrs = append(allErrs,
+			field.Invalid(
+				field.NewPath("Spec", "UpgradeSettings", "MaxSurge"),
+				*settings.MaxSurge,
+				"must be greater than zero"))
+	}
+
+	return allErrs
+}
+
+// validateWindowsNetworkPlugin ensures the control plane of the owning cluster uses a network plugin
+// supporting Windows agent pools. The check is skipped if the owning cluster can not be determined yet.
+func (r *AzureManagedMachinePool) validateWindowsNetworkPlugin(ctx context.Context) error {
+	clusterName, ok := r.Labels[clusterv1.ClusterLabelName]
+	if !ok || azureManagedMachinePoolWebhookClient == nil {
+		return nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	key := client.ObjectKey{Namespace: r.Namespace, Name: clusterName}
+	if err := azureManagedMachinePoolWebhookClient.Get(ctx, key, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	ref := cluster.Spec.ControlPlaneRef
+	if ref == nil || ref.Kind != "AzureManagedControlPlane" {
+		return nil
+	}
+
+	controlPlane := &AzureManagedControlPlane{}
+	key = client.ObjectKey{Namespace: cluster.Namespace, Name: ref.Name}
+	if err := azureManagedMachinePoolWebhookClient.Get(ctx, key, controlPlane); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if controlPlane.Spec.NetworkPlugin != nil && *controlPlane.Spec.NetworkPlugin != "azure" {
+		return fmt.Errorf("Windows agent pools require the azure network plugin, but cluster %s uses %s", clusterName, *controlPlane.Spec.NetworkPlugin)
+	}
+
+	return nil
+}
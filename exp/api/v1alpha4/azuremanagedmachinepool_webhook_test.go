@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAzureManagedMachinePool_Default(t *testing.T) {
+	g := NewWithT(t)
+
+	ammp := &AzureManagedMachinePool{}
+	ammp.Default()
+	g.Expect(ammp.Spec.OSType).To(Equal(pointer.StringPtr(LinuxOSType)))
+
+	ammp.Spec.OSType = pointer.StringPtr(WindowsOSType)
+	ammp.Default()
+	g.Expect(ammp.Spec.OSType).To(Equal(pointer.StringPtr(WindowsOSType)))
+}
+
+func TestAzureManagedMachinePool_ValidateCreate(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clusterv1.AddToScheme(scheme)
+	_ = AddToScheme(scheme)
+
+	cluster := func(name, controlPlane string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: clusterv1.ClusterSpec{
+				ControlPlaneRef: &corev1.ObjectReference{Kind: "AzureManagedControlPlane", Name: controlPlane},
+			},
+		}
+	}
+	controlPlane := func(name, networkPlugin string) *AzureManagedControlPlane {
+		return &AzureManagedControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       AzureManagedControlPlaneSpec{NetworkPlugin: pointer.StringPtr(networkPlugin)},
+		}
+	}
+	azureManagedMachinePoolWebhookClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		cluster("azure-cluster", "azure-cp"),
+		controlPlane("azure-cp", "azure"),
+		cluster("kubenet-cluster", "kubenet-cp"),
+		controlPlane("kubenet-cp", "kubenet"),
+	).Build()
+	defer func() { azureManagedMachinePoolWebhookClient = nil }()
+
+	tests := []struct {
+		name    string
+		ammp    *AzureManagedMachinePool
+		wantErr bool
+	}{
+		{
+			name:    "linux pool",
+			ammp:    createAzureManagedMachinePool("agentpool0", "kubenet-cluster", LinuxOSType),
+			wantErr: false,
+		},
+		{
+			name:    "windows pool with azure network plugin",
+			ammp:    createAzureManagedMachinePool("win0", "azure-cluster", WindowsOSType),
+			wantErr: false,
+		},
+		{
+			name:    "windows pool with kubenet network plugin",
+			ammp:    createAzureManagedMachinePool("win0", "kubenet-cluster", WindowsOSType),
+			wantErr: true,
+		},
+		{
+			name:    "windows pool without cluster",
+			ammp:    createAzureManagedMachinePool("win0", "", WindowsOSType),
+			wantErr: false,
+		},
+		{
+			name: "duplicate availability zones",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.AvailabilityZones = []string{"1", "2", "1"}
+				return ammp
+			}(),
+			wantErr: true,
+		},
+		{
+			name:    "windows pool with a name that is too long",
+			ammp:    createAzureManagedMachinePool("windows0", "azure-cluster", WindowsOSType),
+			wantErr: true,
+		},
+		{
+			name: "valid upgrade settings",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.UpgradeSettings = &ManagedMachinePoolUpgradeSettings{
+					MaxSurge:                pointer.StringPtr("33%"),
+					DrainTimeout:            &metav1.Duration{Duration: 45 * time.Minute},
+					NodeSoakDuration:        &metav1.Duration{Duration: 5 * time.Minute},
+					UndrainableNodeBehavior: pointer.StringPtr(CordonUndrainableNodeBehavior),
+				}
+				return ammp
+			}(),
+			wantErr: false,
+		},
+		{
+			name: "drain timeout of less than a minute",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.UpgradeSettings = &ManagedMachinePoolUpgradeSettings{DrainTimeout: &metav1.Duration{Duration: 30 * time.Second}}
+				return ammp
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "drain timeout longer than a day",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.UpgradeSettings = &ManagedMachinePoolUpgradeSettings{DrainTimeout: &metav1.Duration{Duration: 25 * time.Hour}}
+				return ammp
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "node soak duration of fractional minutes",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.UpgradeSettings = &ManagedMachinePoolUpgradeSettings{NodeSoakDuration: &metav1.Duration{Duration: 90 * time.Second}}
+				return ammp
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "node soak duration longer than 30 minutes",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.UpgradeSettings = &ManagedMachinePoolUpgradeSettings{NodeSoakDuration: &metav1.Duration{Duration: time.Hour}}
+				return ammp
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "zero max surge",
+			ammp: func() *AzureManagedMachinePool {
+				ammp := createAzureManagedMachinePool("agentpool0", "", LinuxOSType)
+				ammp.Spec.UpgradeSettings = &ManagedMachinePoolUpgradeSettings{MaxSurge: pointer.StringPtr("0%")}
+				return ammp
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := tc.ammp.ValidateCreate()
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestAzureManagedMachinePool_ValidateUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	old := createAzureManagedMachinePool("win0", "", WindowsOSType)
+	g.Expect(createAzureManagedMachinePool("win0", "", WindowsOSType).ValidateUpdate(old)).To(Succeed())
+	g.Expect(createAzureManagedMachinePool("win0", "", LinuxOSType).ValidateUpdate(old)).NotTo(Succeed())
+
+	old = createAzureManagedMachinePool("pool0", "", LinuxOSType)
+	old.Spec.AvailabilityZones = []string{"1", "2"}
+	reordered := old.DeepCopy()
+	reordered.Spec.AvailabilityZones = []string{"2", "1"}
+	g.Expect(reordered.ValidateUpdate(old)).To(Succeed())
+	changed := old.DeepCopy()
+	changed.Spec.AvailabilityZones = []string{"1", "2", "3"}
+	g.Expect(changed.ValidateUpdate(old)).To(Succeed())
+}
+
+func createAzureManagedMachinePool(name, clusterName, osType string) *AzureManagedMachinePool {
+	ammp := &AzureManagedMachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: AzureManagedMachinePoolSpec{
+			SKU:    "Standard_D2s_v3",
+			OSType: pointer.StringPtr(osType),
+		},
+	}
+	if clusterName != "" {
+		ammp.Labels = map[string]string{clusterv1.ClusterLabelName: clusterName}
+	}
+	return ammp
+}
@@ -22,6 +22,7 @@ package v1alpha4
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	apiv1alpha4 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
@@ -29,6 +30,21 @@ import (
 	"sigs.k8s.io/cluster-api/errors"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AKSSku) DeepCopyInto(out *AKSSku) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AKSSku.
+func (in *AKSSku) DeepCopy() *AKSSku {
+	if in == nil {
+		return nil
+	}
+	out := new(AKSSku)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureMachinePool) DeepCopyInto(out *AzureMachinePool) {
 	*out = *in
@@ -235,7 +251,7 @@ func (in *AzureMachinePoolMachineStatus) DeepCopyInto(out *AzureMachinePoolMachi
 	if in.LongRunningOperationState != nil {
 		in, out := &in.LongRunningOperationState, &out.LongRunningOperationState
 		*out = new(apiv1alpha4.Future)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -265,6 +281,21 @@ func (in *AzureMachinePoolMachineTemplate) DeepCopyInto(out *AzureMachinePoolMac
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SSHPublicKeySecret != nil {
+		in, out := &in.SSHPublicKeySecret, &out.SSHPublicKeySecret
+		*out = new(apiv1alpha4.KeyVaultSecretReference)
+		**out = **in
+	}
+	if in.AdminPasswordSecret != nil {
+		in, out := &in.AdminPasswordSecret, &out.AdminPasswordSecret
+		*out = new(apiv1alpha4.KeyVaultSecretReference)
+		**out = **in
+	}
+	if in.AdditionalCustomDataSecret != nil {
+		in, out := &in.AdditionalCustomDataSecret, &out.AdditionalCustomDataSecret
+		*out = new(apiv1alpha4.SecretKeyReference)
+		**out = **in
+	}
 	if in.AcceleratedNetworking != nil {
 		in, out := &in.AcceleratedNetworking, &out.AcceleratedNetworking
 		*out = new(bool)
@@ -375,7 +406,7 @@ func (in *AzureMachinePoolStatus) DeepCopyInto(out *AzureMachinePoolStatus) {
 	if in.LongRunningOperationState != nil {
 		in, out := &in.LongRunningOperationState, &out.LongRunningOperationState
 		*out = new(apiv1alpha4.Future)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -485,7 +516,7 @@ func (in *AzureManagedControlPlane) DeepCopyInto(out *AzureManagedControlPlane)
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureManagedControlPlane.
@@ -576,6 +607,36 @@ func (in *AzureManagedControlPlaneSpec) DeepCopyInto(out *AzureManagedControlPla
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.IngressProfile != nil {
+		in, out := &in.IngressProfile, &out.IngressProfile
+		*out = new(IngressProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WindowsProfile != nil {
+		in, out := &in.WindowsProfile, &out.WindowsProfile
+		*out = new(ManagedControlPlaneWindowsProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricsProfile != nil {
+		in, out := &in.MetricsProfile, &out.MetricsProfile
+		*out = new(MetricsProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SKU != nil {
+		in, out := &in.SKU, &out.SKU
+		*out = new(AKSSku)
+		**out = **in
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(UpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(ManagedControlPlaneBackup)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureManagedControlPlaneSpec.
@@ -591,6 +652,11 @@ func (in *AzureManagedControlPlaneSpec) DeepCopy() *AzureManagedControlPlaneSpec
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureManagedControlPlaneStatus) DeepCopyInto(out *AzureManagedControlPlaneStatus) {
 	*out = *in
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureManagedControlPlaneStatus.
@@ -670,6 +736,26 @@ func (in *AzureManagedMachinePoolSpec) DeepCopyInto(out *AzureManagedMachinePool
 		*out = new(int32)
 		**out = **in
 	}
+	if in.OSType != nil {
+		in, out := &in.OSType, &out.OSType
+		*out = new(string)
+		**out = **in
+	}
+	if in.AvailabilityZones != nil {
+		in, out := &in.AvailabilityZones, &out.AvailabilityZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScaleDownMode != nil {
+		in, out := &in.ScaleDownMode, &out.ScaleDownMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.UpgradeSettings != nil {
+		in, out := &in.UpgradeSettings, &out.UpgradeSettings
+		*out = new(ManagedMachinePoolUpgradeSettings)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ProviderIDList != nil {
 		in, out := &in.ProviderIDList, &out.ProviderIDList
 		*out = make([]string, len(*in))
@@ -712,6 +798,56 @@ func (in *AzureManagedMachinePoolStatus) DeepCopy() *AzureManagedMachinePoolStat
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CostAnalysis) DeepCopyInto(out *CostAnalysis) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CostAnalysis.
+func (in *CostAnalysis) DeepCopy() *CostAnalysis {
+	if in == nil {
+		return nil
+	}
+	out := new(CostAnalysis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GMSAProfile) DeepCopyInto(out *GMSAProfile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GMSAProfile.
+func (in *GMSAProfile) DeepCopy() *GMSAProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(GMSAProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressProfile) DeepCopyInto(out *IngressProfile) {
+	*out = *in
+	if in.WebAppRouting != nil {
+		in, out := &in.WebAppRouting, &out.WebAppRouting
+		*out = new(WebAppRouting)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressProfile.
+func (in *IngressProfile) DeepCopy() *IngressProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineRollingUpdateDeployment) DeepCopyInto(out *MachineRollingUpdateDeployment) {
 	*out = *in
@@ -737,6 +873,26 @@ func (in *MachineRollingUpdateDeployment) DeepCopy() *MachineRollingUpdateDeploy
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedControlPlaneBackup) DeepCopyInto(out *ManagedControlPlaneBackup) {
+	*out = *in
+	if in.IncludedNamespaces != nil {
+		in, out := &in.IncludedNamespaces, &out.IncludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedControlPlaneBackup.
+func (in *ManagedControlPlaneBackup) DeepCopy() *ManagedControlPlaneBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedControlPlaneBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagedControlPlaneSubnet) DeepCopyInto(out *ManagedControlPlaneSubnet) {
 	*out = *in
@@ -767,3 +923,166 @@ func (in *ManagedControlPlaneVirtualNetwork) DeepCopy() *ManagedControlPlaneVirt
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedControlPlaneWindowsProfile) DeepCopyInto(out *ManagedControlPlaneWindowsProfile) {
+	*out = *in
+	if in.LicenseType != nil {
+		in, out := &in.LicenseType, &out.LicenseType
+		*out = new(string)
+		**out = **in
+	}
+	if in.GMSA != nil {
+		in, out := &in.GMSA, &out.GMSA
+		*out = new(GMSAProfile)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedControlPlaneWindowsProfile.
+func (in *ManagedControlPlaneWindowsProfile) DeepCopy() *ManagedControlPlaneWindowsProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedControlPlaneWindowsProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedMachinePoolUpgradeSettings) DeepCopyInto(out *ManagedMachinePoolUpgradeSettings) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(string)
+		**out = **in
+	}
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NodeSoakDuration != nil {
+		in, out := &in.NodeSoakDuration, &out.NodeSoakDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.UndrainableNodeBehavior != nil {
+		in, out := &in.UndrainableNodeBehavior, &out.UndrainableNodeBehavior
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedMachinePoolUpgradeSettings.
+func (in *ManagedMachinePoolUpgradeSettings) DeepCopy() *ManagedMachinePoolUpgradeSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedMachinePoolUpgradeSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsProfile) DeepCopyInto(out *MetricsProfile) {
+	*out = *in
+	if in.CostAnalysis != nil {
+		in, out := &in.CostAnalysis, &out.CostAnalysis
+		*out = new(CostAnalysis)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsProfile.
+func (in *MetricsProfile) DeepCopy() *MetricsProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+	if in.NodePoolOrder != nil {
+		in, out := &in.NodePoolOrder, &out.NodePoolOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SoakDuration != nil {
+		in, out := &in.SoakDuration, &out.SoakDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NodePoolTimeout != nil {
+		in, out := &in.NodePoolTimeout, &out.NodePoolTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+	if in.NodePoolStartTime != nil {
+		in, out := &in.NodePoolStartTime, &out.NodePoolStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.UpgradedNodePools != nil {
+		in, out := &in.UpgradedNodePools, &out.UpgradedNodePools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FailureMessage != nil {
+		in, out := &in.FailureMessage, &out.FailureMessage
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebAppRouting) DeepCopyInto(out *WebAppRouting) {
+	*out = *in
+	if in.DNSZoneResourceIDs != nil {
+		in, out := &in.DNSZoneResourceIDs, &out.DNSZoneResourceIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebAppRouting.
+func (in *WebAppRouting) DeepCopy() *WebAppRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(WebAppRouting)
+	in.DeepCopyInto(out)
+	return out
+}
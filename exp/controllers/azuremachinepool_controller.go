@@ -22,8 +22,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,6 +31,7 @@ import (
 	capiv1exp "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -163,12 +162,8 @@ func (ampr *AzureMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	logger := ampr.Log.WithValues("namespace", req.Namespace, "azureMachinePool", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureMachinePoolReconciler.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureMachinePool"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureMachinePool", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureMachinePoolReconciler.Reconcile")
 	defer span.End()
 
 	azMachinePool := &infrav1exp.AzureMachinePool{}
@@ -219,6 +214,11 @@ func (ampr *AzureMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	logger = logger.WithValues("AzureCluster", azureCluster.Name)
 
+	if !infracontroller.InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		logger.V(2).Info("Cluster is not in the shard of this manager. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
 	// Create the cluster scope
 	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
 		Client:       ampr.Client,
@@ -229,6 +229,7 @@ func (ampr *AzureMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	ctx = infracontroller.WithTraceAttributes(ctx, clusterScope)
 
 	// Create the machine pool scope
 	machinePoolScope, err := scope.NewMachinePoolScope(scope.MachinePoolScopeParams{
@@ -249,6 +250,31 @@ func (ampr *AzureMachinePoolReconciler) Reconcile(ctx context.Context, req ctrl.
 		}
 	}()
 
+	// A dry run leaves the AzureMachinePool as it was, apart from reporting the changes it would have made, or refused
+	// to make in read-only mode.
+	ctx, dryRun := infracontroller.WithDryRun(ctx, clusterScope, logger)
+	original := azMachinePool.DeepCopy()
+	defer func() {
+		if dryRun != nil {
+			azMachinePool.Annotations = original.Annotations
+			azMachinePool.Finalizers = original.Finalizers
+			azMachinePool.Spec = original.Spec
+			status := azMachinePool.Status
+			azMachinePool.Status = original.Status
+			// In read-only mode, the scale set is reported as it was read.
+			if dryRun.ReadOnly() {
+				azMachinePool.Status.Replicas = status.Replicas
+				azMachinePool.Status.Instances = status.Instances
+				azMachinePool.Status.ProvisioningState = status.ProvisioningState
+			}
+		}
+		infracontroller.ReportDryRun(ampr.Recorder, azMachinePool, dryRun)
+	}()
+	// The changes made to Azure resources, and the errors returned by Azure, are recorded as events of the
+	// AzureMachinePool.
+	ctx = azure.WithResourceEvents(ctx, azure.NewResourceEvents(ampr.Recorder, azMachinePool))
+	infracontroller.RecordStuckLongRunningOperation(ampr.Recorder, azMachinePool, azMachinePool.Status.LongRunningOperationState)
+
 	// Handle deleted machine pools
 	if !azMachinePool.ObjectMeta.DeletionTimestamp.IsZero() {
 		return ampr.reconcileDelete(ctx, machinePoolScope, clusterScope)
@@ -293,11 +319,14 @@ func (ampr *AzureMachinePoolReconciler) reconcileNormal(ctx context.Context, mac
 	}
 
 	if err := ams.Reconcile(ctx); err != nil {
+		err = azure.WithRequestIDs(err)
 		// Handle transient and terminal errors
 		var reconcileError azure.ReconcileError
 		if errors.As(err, &reconcileError) {
 			if reconcileError.IsTerminal() {
 				machinePoolScope.Error(err, "failed to reconcile AzureMachinePool", "name", machinePoolScope.Name())
+				reason, severity := azure.ErrorReason(err, infrav1.ScaleSetProvisionFailedReason, clusterv1.ConditionSeverityError)
+				conditions.MarkFalse(machinePoolScope.AzureMachinePool, infrav1.ScaleSetRunningCondition, reason, severity, err.Error())
 				return reconcile.Result{}, nil
 			}
 
@@ -309,6 +338,8 @@ func (ampr *AzureMachinePoolReconciler) reconcileNormal(ctx context.Context, mac
 			return reconcile.Result{}, errors.Wrap(err, "failed to reconcile AzureMachinePool")
 		}
 
+		reason, severity := azure.ErrorReason(err, infrav1.ScaleSetProvisionFailedReason, clusterv1.ConditionSeverityError)
+		conditions.MarkFalse(machinePoolScope.AzureMachinePool, infrav1.ScaleSetRunningCondition, reason, severity, err.Error())
 		return reconcile.Result{}, err
 	}
 
@@ -342,7 +373,10 @@ func (ampr *AzureMachinePoolReconciler) reconcileDelete(ctx context.Context, mac
 
 	machinePoolScope.V(2).Info("handling deleted AzureMachinePool")
 
-	if infracontroller.ShouldDeleteIndividualResources(ctx, clusterScope) {
+	// AzureMachinePools follow the deletion policy of their cluster.
+	if clusterScope.DeletionPolicy() == infrav1.DeletionPolicyRetain {
+		machinePoolScope.Info("Retaining the scale set of the AzureMachinePool as requested by the deletion policy of the cluster")
+	} else if infracontroller.ShouldDeleteIndividualResources(ctx, clusterScope) {
 		amps, err := ampr.createAzureMachinePoolService(machinePoolScope)
 		if err != nil {
 			return reconcile.Result{}, errors.Wrap(err, "failed creating a new AzureMachinePoolService")
@@ -350,6 +384,7 @@ func (ampr *AzureMachinePoolReconciler) reconcileDelete(ctx context.Context, mac
 
 		machinePoolScope.V(4).Info("deleting AzureMachinePool resource individually")
 		if err := amps.Delete(ctx); err != nil {
+			err = azure.WithRequestIDs(err)
 			return reconcile.Result{}, errors.Wrapf(err, "error deleting AzureMachinePool %s/%s", clusterScope.Namespace(), machinePoolScope.Name())
 		}
 	}
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/roleassignments"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/scalesets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/vmssextensions"
+	infracontroller "sigs.k8s.io/cluster-api-provider-azure/controllers"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
 
@@ -48,10 +49,10 @@ func newAzureMachinePoolService(machinePoolScope *scope.MachinePoolScope) (*azur
 	}
 
 	return &azureMachinePoolService{
-		virtualMachinesScaleSetSvc: scalesets.NewService(machinePoolScope, cache),
+		virtualMachinesScaleSetSvc: infracontroller.WithServiceMetrics("scalesets", machinePoolScope, scalesets.NewService(machinePoolScope, cache)),
 		skuCache:                   cache,
-		roleAssignmentsSvc:         roleassignments.New(machinePoolScope),
-		vmssExtensionSvc:           vmssextensions.New(machinePoolScope),
+		roleAssignmentsSvc:         infracontroller.WithServiceMetrics("roleassignments", machinePoolScope, roleassignments.New(machinePoolScope)),
+		vmssExtensionSvc:           infracontroller.WithServiceMetrics("vmssextensions", machinePoolScope, vmssextensions.New(machinePoolScope)),
 	}, nil
 }
 
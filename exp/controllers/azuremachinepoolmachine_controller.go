@@ -23,8 +23,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -67,7 +65,7 @@ type (
 
 	azureMachinePoolMachineReconciler struct {
 		Scope              *scope.MachinePoolMachineScope
-		scalesetVMsService *scalesetvms.Service
+		scalesetVMsService azure.Reconciler
 	}
 )
 
@@ -131,12 +129,8 @@ func (ampmr *AzureMachinePoolMachineController) Reconcile(ctx context.Context, r
 	defer cancel()
 	logger := ampmr.Log.WithValues("namespace", req.Namespace, "azureMachinePoolMachine", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureMachinePoolMachineController.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureMachinePoolMachine"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureMachinePoolMachine", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureMachinePoolMachineController.Reconcile")
 	defer span.End()
 
 	machine := &infrav1exp.AzureMachinePoolMachine{}
@@ -200,6 +194,11 @@ func (ampmr *AzureMachinePoolMachineController) Reconcile(ctx context.Context, r
 
 	logger = logger.WithValues("AzureCluster", azureCluster.Name)
 
+	if !infracontroller.InShard(cluster, azureCluster.Spec.SubscriptionID) {
+		logger.V(2).Info("Cluster is not in the shard of this manager. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
 	// Create the cluster scope
 	clusterScope, err := scope.NewClusterScope(ctx, scope.ClusterScopeParams{
 		Client:       ampmr.Client,
@@ -210,6 +209,7 @@ func (ampmr *AzureMachinePoolMachineController) Reconcile(ctx context.Context, r
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	ctx = infracontroller.WithTraceAttributes(ctx, clusterScope)
 
 	// Create the machine pool scope
 	machineScope, err := scope.NewMachinePoolMachineScope(scope.MachinePoolMachineScopeParams{
@@ -231,6 +231,30 @@ func (ampmr *AzureMachinePoolMachineController) Reconcile(ctx context.Context, r
 		}
 	}()
 
+	// A dry run leaves the AzureMachinePoolMachine as it was, apart from reporting the changes it would have made, or
+	// refused to make in read-only mode.
+	ctx, dryRun := infracontroller.WithDryRun(ctx, clusterScope, logger)
+	original := machine.DeepCopy()
+	defer func() {
+		if dryRun != nil {
+			machine.Annotations = original.Annotations
+			machine.Finalizers = original.Finalizers
+			machine.Spec = original.Spec
+			status := machine.Status
+			machine.Status = original.Status
+			// In read-only mode, the scale set VM and its node are reported as they were read.
+			if dryRun.ReadOnly() {
+				machine.Status.NodeRef = status.NodeRef
+				machine.Status.Version = status.Version
+				machine.Status.ProvisioningState = status.ProvisioningState
+				machine.Status.InstanceName = status.InstanceName
+				machine.Status.LatestModelApplied = status.LatestModelApplied
+			}
+		}
+		infracontroller.ReportDryRun(ampmr.Recorder, machine, dryRun)
+	}()
+	infracontroller.RecordStuckLongRunningOperation(ampmr.Recorder, machine, machine.Status.LongRunningOperationState)
+
 	// Handle deleted machine pools machine
 	if !machine.ObjectMeta.DeletionTimestamp.IsZero() {
 		return ampmr.reconcileDelete(ctx, machineScope)
@@ -258,6 +282,7 @@ func (ampmr *AzureMachinePoolMachineController) reconcileNormal(ctx context.Cont
 
 	ampms := ampmr.reconcilerFactory(machineScope)
 	if err := ampms.Reconcile(ctx); err != nil {
+		err = azure.WithRequestIDs(err)
 		// Handle transient and terminal errors
 		var reconcileError azure.ReconcileError
 		if errors.As(err, &reconcileError) {
@@ -321,6 +346,7 @@ func (ampmr *AzureMachinePoolMachineController) reconcileDelete(ctx context.Cont
 
 	ampms := ampmr.reconcilerFactory(machineScope)
 	if err := ampms.Delete(ctx); err != nil {
+		err = azure.WithRequestIDs(err)
 		// Handle transient and terminal errors
 		var reconcileError azure.ReconcileError
 		if errors.As(err, &reconcileError) {
@@ -346,7 +372,7 @@ func (ampmr *AzureMachinePoolMachineController) reconcileDelete(ctx context.Cont
 func newAzureMachinePoolMachineReconciler(scope *scope.MachinePoolMachineScope) azure.Reconciler {
 	return &azureMachinePoolMachineReconciler{
 		Scope:              scope,
-		scalesetVMsService: scalesetvms.NewService(scope),
+		scalesetVMsService: infracontroller.WithServiceMetrics("scalesetvms", scope, scalesetvms.NewService(scope)),
 	}
 }
 
@@ -24,8 +24,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -42,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	infracontroller "sigs.k8s.io/cluster-api-provider-azure/controllers"
 	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
@@ -105,6 +104,7 @@ func (r *AzureManagedControlPlaneReconciler) SetupWithManager(ctx context.Contex
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremanagedcontrolplanes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremanagedcontrolplanes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=azuremanagedmachinepools,verbs=get;list;watch
 
 // Reconcile idempotently gets, creates, and updates a managed control plane.
 func (r *AzureManagedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reterr error) {
@@ -112,12 +112,8 @@ func (r *AzureManagedControlPlaneReconciler) Reconcile(ctx context.Context, req
 	defer cancel()
 	log := r.Log.WithValues("namespace", req.Namespace, "azureManagedControlPlane", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureManagedControlPlaneReconciler.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureManagedControlPlane"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureManagedControlPlane", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureManagedControlPlaneReconciler.Reconcile")
 	defer span.End()
 
 	// Fetch the AzureManagedControlPlane instance
@@ -148,6 +144,11 @@ func (r *AzureManagedControlPlaneReconciler) Reconcile(ctx context.Context, req
 		return ctrl.Result{}, nil
 	}
 
+	if !infracontroller.InShard(cluster, azureControlPlane.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
 	// Handle deleted clusters
 	// needs to happen before trying to fetch the default pool to avoid circular deletion dependencies
 	if !azureControlPlane.DeletionTimestamp.IsZero() {
@@ -162,11 +163,14 @@ func (r *AzureManagedControlPlaneReconciler) Reconcile(ctx context.Context, req
 		if err != nil {
 			return reconcile.Result{}, errors.Errorf("failed to create scope: %+v", err)
 		}
+		ctx = infracontroller.WithTraceAttributes(ctx, mcpScope)
 		defer func() {
 			if err := mcpScope.PatchObject(ctx); err != nil && reterr == nil {
 				reterr = err
 			}
 		}()
+		ctx, reportDryRun := r.withDryRun(ctx, azureControlPlane, log)
+		defer reportDryRun()
 		return r.reconcileDelete(ctx, mcpScope)
 	}
 
@@ -228,6 +232,7 @@ func (r *AzureManagedControlPlaneReconciler) Reconcile(ctx context.Context, req
 	if err != nil {
 		return reconcile.Result{}, errors.Errorf("failed to create scope: %+v", err)
 	}
+	ctx = infracontroller.WithTraceAttributes(ctx, mcpScope)
 
 	// Always patch when exiting so we can persist changes to finalizers and status
 	defer func() {
@@ -235,11 +240,35 @@ func (r *AzureManagedControlPlaneReconciler) Reconcile(ctx context.Context, req
 			reterr = err
 		}
 	}()
+	ctx, reportDryRun := r.withDryRun(ctx, azureControlPlane, log)
+	defer reportDryRun()
 
 	// Handle non-deleted clusters
 	return r.reconcileNormal(ctx, mcpScope)
 }
 
+// withDryRun returns a context in which the changes to Azure resources are only logged if the controller runs in dry
+// run mode, or refused in read-only mode, and a function reporting them. The function also leaves the
+// AzureManagedControlPlane as it was, apart from the upgrade observed in read-only mode, so it must be deferred after
+// the AzureManagedControlPlane is set to be patched.
+func (r *AzureManagedControlPlaneReconciler) withDryRun(ctx context.Context, azureControlPlane *infrav1exp.AzureManagedControlPlane, log logr.Logger) (context.Context, func()) {
+	ctx, dryRun := infracontroller.WithManagerDryRun(ctx, log)
+	original := azureControlPlane.DeepCopy()
+	return ctx, func() {
+		if dryRun != nil {
+			azureControlPlane.Annotations = original.Annotations
+			azureControlPlane.Finalizers = original.Finalizers
+			azureControlPlane.Spec = original.Spec
+			upgrade := azureControlPlane.Status.Upgrade
+			azureControlPlane.Status = original.Status
+			if dryRun.ReadOnly() {
+				azureControlPlane.Status.Upgrade = upgrade
+			}
+		}
+		infracontroller.RecordDryRun(r.Recorder, azureControlPlane, dryRun)
+	}
+}
+
 func (r *AzureManagedControlPlaneReconciler) reconcileNormal(ctx context.Context, scope *scope.ManagedControlPlaneScope) (reconcile.Result, error) {
 	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureManagedControlPlaneReconciler.reconcileNormal")
 	defer span.End()
@@ -253,7 +282,9 @@ func (r *AzureManagedControlPlaneReconciler) reconcileNormal(ctx context.Context
 		return reconcile.Result{}, err
 	}
 
-	if err := newAzureManagedControlPlaneReconciler(scope).Reconcile(ctx, scope); err != nil {
+	reconciler := newAzureManagedControlPlaneReconciler(scope)
+	if err := reconciler.Reconcile(ctx, scope); err != nil {
+		err = azure.WithRequestIDs(err)
 		return reconcile.Result{}, errors.Wrapf(err, "error creating AzureManagedControlPlane %s/%s", scope.ControlPlane.Namespace, scope.ControlPlane.Name)
 	}
 
@@ -261,7 +292,12 @@ func (r *AzureManagedControlPlaneReconciler) reconcileNormal(ctx context.Context
 	scope.ControlPlane.Status.Ready = true
 	scope.ControlPlane.Status.Initialized = true
 
-	return reconcile.Result{}, nil
+	requeueAfter, err := reconciler.ReconcileUpgrade(ctx, scope)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "error upgrading AzureManagedControlPlane %s/%s", scope.ControlPlane.Namespace, scope.ControlPlane.Name)
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *AzureManagedControlPlaneReconciler) reconcileDelete(ctx context.Context, scope *scope.ManagedControlPlaneScope) (reconcile.Result, error) {
@@ -271,11 +307,13 @@ func (r *AzureManagedControlPlaneReconciler) reconcileDelete(ctx context.Context
 	scope.Logger.Info("Reconciling AzureManagedControlPlane delete")
 
 	if err := newAzureManagedControlPlaneReconciler(scope).Delete(ctx, scope); err != nil {
+		err = azure.WithRequestIDs(err)
 		return reconcile.Result{}, errors.Wrapf(err, "error deleting AzureManagedControlPlane %s/%s", scope.ControlPlane.Namespace, scope.ControlPlane.Name)
 	}
 
 	// Cluster is deleted so remove the finalizer.
 	controllerutil.RemoveFinalizer(scope.ControlPlane, infrav1.ClusterFinalizer)
+	infracontroller.DeleteServiceMetrics(scope.Namespace(), scope.ClusterName())
 
 	return reconcile.Result{}, nil
 }
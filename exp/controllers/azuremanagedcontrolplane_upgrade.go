@@ -0,0 +1,356 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2020-02-01/containerservice"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters"
+	infracontroller "sigs.k8s.io/cluster-api-provider-azure/controllers"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
+)
+
+const (
+	// upgradeRequeueAfter is the interval in which the progress of an orchestrated upgrade is checked.
+	upgradeRequeueAfter = 30 * time.Second
+
+	// defaultNodePoolTimeout is the time a node pool may take to upgrade if the upgrade policy does not
+	// set a timeout.
+	defaultNodePoolTimeout = 2 * time.Hour
+)
+
+// ReconcileUpgrade drives an orchestrated upgrade of the control plane and its node pools. It returns
+// the duration after which the upgrade should be checked again, or zero if no upgrade is in progress.
+func (r *azureManagedControlPlaneReconciler) ReconcileUpgrade(ctx context.Context, scope *scope.ManagedControlPlaneScope) (time.Duration, error) {
+	ctx, span := tele.Tracer().Start(ctx, "controllers.azureManagedControlPlaneReconciler.ReconcileUpgrade")
+	defer span.End()
+
+	controlPlane := scope.ControlPlane
+	if !isOrchestratedUpgrade(controlPlane) {
+		return 0, nil
+	}
+
+	version := strings.TrimPrefix(controlPlane.Spec.Version, "v")
+	status := controlPlane.Status.Upgrade
+	if status == nil || status.Version != version {
+		scope.Info("Starting orchestrated upgrade", "version", version)
+		status = &infrav1exp.UpgradeStatus{
+			Version: version,
+			Phase:   infrav1exp.UpgradePhaseControlPlane,
+		}
+		controlPlane.Status.Upgrade = status
+	}
+
+	if status.Phase == infrav1exp.UpgradePhasePaused {
+		if _, ok := controlPlane.Annotations[infrav1exp.ResumeUpgradeAnnotation]; !ok {
+			return 0, nil
+		}
+		scope.Info("Resuming orchestrated upgrade", "version", version)
+		delete(controlPlane.Annotations, infrav1exp.ResumeUpgradeAnnotation)
+		status.FailureMessage = nil
+		if status.NodePool != "" {
+			now := metav1.Now()
+			status.NodePoolStartTime = &now
+		}
+		// The control plane finished upgrading once the last transition time is set.
+		status.Phase = infrav1exp.UpgradePhaseControlPlane
+		if status.LastTransitionTime != nil {
+			status.Phase = infrav1exp.UpgradePhaseNodePools
+		}
+	}
+
+	if status.Phase == infrav1exp.UpgradePhaseControlPlane {
+		result, err := r.managedClustersSvc.Get(ctx, &managedclusters.Spec{
+			Name:              controlPlane.Name,
+			ResourceGroupName: controlPlane.Spec.ResourceGroupName,
+		})
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get managed cluster")
+		}
+		managedCluster, ok := result.(containerservice.ManagedCluster)
+		if !ok || managedCluster.ManagedClusterProperties == nil {
+			return 0, errors.New("expected containerservice ManagedCluster object")
+		}
+
+		switch provisioningState(managedCluster.ProvisioningState) {
+		case "Failed", "Canceled":
+			pauseUpgrade(scope, fmt.Sprintf("upgrade of control plane %s to %s failed with provisioning state %s", controlPlane.Name, version, *managedCluster.ProvisioningState))
+			return 0, nil
+		case "Succeeded":
+			if managedCluster.KubernetesVersion == nil || *managedCluster.KubernetesVersion != version {
+				return upgradeRequeueAfter, nil
+			}
+		default:
+			return upgradeRequeueAfter, nil
+		}
+
+		scope.Info("Control plane upgraded", "version", version)
+		now := metav1.Now()
+		status.Phase = infrav1exp.UpgradePhaseNodePools
+		status.LastTransitionTime = &now
+	}
+
+	if status.Phase == infrav1exp.UpgradePhaseNodePools {
+		return r.reconcileNodePoolUpgrade(ctx, scope)
+	}
+
+	return 0, nil
+}
+
+// reconcileNodePoolUpgrade upgrades the node pools of the control plane one after another.
+func (r *azureManagedControlPlaneReconciler) reconcileNodePoolUpgrade(ctx context.Context, scope *scope.ManagedControlPlaneScope) (time.Duration, error) {
+	ctx, span := tele.Tracer().Start(ctx, "controllers.azureManagedControlPlaneReconciler.reconcileNodePoolUpgrade")
+	defer span.End()
+
+	controlPlane := scope.ControlPlane
+	status := controlPlane.Status.Upgrade
+
+	if status.NodePool != "" {
+		if status.NodePoolStartTime == nil {
+			now := metav1.Now()
+			status.NodePoolStartTime = &now
+		}
+
+		pool, _, err := r.agentPoolsClient.Get(ctx, controlPlane.Spec.ResourceGroupName, controlPlane.Name, status.NodePool, false)
+		if azure.ResourceNotFound(err) {
+			scope.Info("Node pool was removed during the upgrade", "nodePool", status.NodePool)
+			status.NodePool = ""
+			status.NodePoolStartTime = nil
+		} else if err != nil {
+			return 0, errors.Wrapf(err, "failed to get agent pool %s", status.NodePool)
+		}
+
+		if status.NodePool != "" {
+			if pool.ManagedClusterAgentPoolProfileProperties != nil {
+				if state := provisioningState(pool.ProvisioningState); state == "Failed" || state == "Canceled" {
+					pauseUpgrade(scope, fmt.Sprintf("upgrade of node pool %s to %s failed with provisioning state %s", status.NodePool, status.Version, state))
+					return 0, nil
+				}
+			}
+
+			ready := false
+			if isAgentPoolUpgraded(pool, status.Version) {
+				if ready, err = r.isNodePoolReady(ctx, scope, status.NodePool); err != nil {
+					return 0, err
+				}
+			}
+			if !ready {
+				// Pools which are stuck upgrading, or whose nodes don't become ready after the upgrade, pause
+				// the upgrade as well.
+				timeout := defaultNodePoolTimeout
+				if policy := controlPlane.Spec.UpgradePolicy; policy.NodePoolTimeout != nil {
+					timeout = policy.NodePoolTimeout.Duration
+				}
+				if time.Since(status.NodePoolStartTime.Time) > timeout {
+					pauseUpgrade(scope, fmt.Sprintf("upgrade of node pool %s to %s did not complete within %s", status.NodePool, status.Version, timeout))
+					return 0, nil
+				}
+				return upgradeRequeueAfter, nil
+			}
+
+			scope.Info("Node pool upgraded", "nodePool", status.NodePool, "version", status.Version)
+			now := metav1.Now()
+			status.UpgradedNodePools = append(status.UpgradedNodePools, status.NodePool)
+			status.NodePool = ""
+			status.NodePoolStartTime = nil
+			status.LastTransitionTime = &now
+		}
+	}
+
+	pools, err := r.nodePoolsToUpgrade(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, name := range pools {
+		if containsString(status.UpgradedNodePools, name) {
+			continue
+		}
+
+		// Node pools which do not exist yet or already run the version need no upgrade and no soak time.
+		pool, _, err := r.agentPoolsClient.Get(ctx, controlPlane.Spec.ResourceGroupName, controlPlane.Name, name, false)
+		if err != nil && !azure.ResourceNotFound(err) {
+			return 0, errors.Wrapf(err, "failed to get agent pool %s", name)
+		}
+		if azure.ResourceNotFound(err) || isAgentPoolUpgraded(pool, status.Version) {
+			status.UpgradedNodePools = append(status.UpgradedNodePools, name)
+			continue
+		}
+
+		if policy := controlPlane.Spec.UpgradePolicy; policy.SoakDuration != nil && status.LastTransitionTime != nil {
+			if remaining := time.Until(status.LastTransitionTime.Add(policy.SoakDuration.Duration)); remaining > 0 {
+				scope.V(2).Info("Soaking before upgrading the next node pool", "nodePool", name, "remaining", remaining)
+				return remaining, nil
+			}
+		}
+
+		scope.Info("Upgrading node pool", "nodePool", name, "version", status.Version)
+		now := metav1.Now()
+		status.NodePool = name
+		status.NodePoolStartTime = &now
+		return upgradeRequeueAfter, nil
+	}
+
+	scope.Info("Orchestrated upgrade completed", "version", status.Version)
+	status.Phase = infrav1exp.UpgradePhaseCompleted
+	return 0, nil
+}
+
+// nodePoolsToUpgrade returns the names of the AzureManagedMachinePools of the cluster whose MachinePool
+// asks for the version of the upgrade, in the order they are upgraded in.
+func (r *azureManagedControlPlaneReconciler) nodePoolsToUpgrade(ctx context.Context, scope *scope.ManagedControlPlaneScope) ([]string, error) {
+	pools := &infrav1exp.AzureManagedMachinePoolList{}
+	if err := r.kubeclient.List(ctx, pools, client.InNamespace(scope.ControlPlane.Namespace), client.MatchingLabels{clusterv1.ClusterLabelName: scope.Cluster.Name}); err != nil {
+		return nil, errors.Wrap(err, "failed to list AzureManagedMachinePools")
+	}
+
+	var names []string
+	for _, pool := range pools.Items {
+		machinePool, err := infracontroller.GetOwnerMachinePool(ctx, r.kubeclient, pool.ObjectMeta)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get owner MachinePool of AzureManagedMachinePool %s", pool.Name)
+		}
+		if machinePool == nil || machinePool.Spec.Template.Spec.Version == nil {
+			continue
+		}
+		if strings.TrimPrefix(*machinePool.Spec.Template.Spec.Version, "v") != scope.ControlPlane.Status.Upgrade.Version {
+			continue
+		}
+		names = append(names, pool.Name)
+	}
+
+	order := map[string]int{}
+	for i, name := range scope.ControlPlane.Spec.UpgradePolicy.NodePoolOrder {
+		order[name] = i + 1
+	}
+	sort.Slice(names, func(i, j int) bool {
+		oi, oj := order[names[i]], order[names[j]]
+		switch {
+		case oi != 0 && oj != 0:
+			return oi < oj
+		case oi != 0 || oj != 0:
+			return oi != 0
+		default:
+			return names[i] < names[j]
+		}
+	})
+	return names, nil
+}
+
+// isNodePoolReady returns true if all the nodes of the MachinePool owning an AzureManagedMachinePool are ready.
+func (r *azureManagedControlPlaneReconciler) isNodePoolReady(ctx context.Context, scope *scope.ManagedControlPlaneScope, name string) (bool, error) {
+	pool := &infrav1exp.AzureManagedMachinePool{}
+	if err := r.kubeclient.Get(ctx, client.ObjectKey{Namespace: scope.ControlPlane.Namespace, Name: name}, pool); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "failed to get AzureManagedMachinePool %s", name)
+	}
+
+	machinePool, err := infracontroller.GetOwnerMachinePool(ctx, r.kubeclient, pool.ObjectMeta)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get owner MachinePool of AzureManagedMachinePool %s", name)
+	}
+	if machinePool == nil {
+		return true, nil
+	}
+
+	replicas := int32(1)
+	if machinePool.Spec.Replicas != nil {
+		replicas = *machinePool.Spec.Replicas
+	}
+	return machinePool.Status.ReadyReplicas >= replicas, nil
+}
+
+// controlPlaneVersion returns the Kubernetes version of the control plane, which new agent pools are
+// created with when agentPoolVersion holds their version back.
+func controlPlaneVersion(controlPlane *infrav1exp.AzureManagedControlPlane) *string {
+	version := strings.TrimPrefix(controlPlane.Spec.Version, "v")
+	return &version
+}
+
+// agentPoolVersion returns the Kubernetes version the agent pool of an AzureManagedMachinePool may be
+// reconciled to. During an orchestrated upgrade, node pools keep their version until it is their turn,
+// which is signalled by returning nil. Agent pools which don't exist yet are then created with the
+// version of the control plane.
+func agentPoolVersion(controlPlane *infrav1exp.AzureManagedControlPlane, poolName string, version *string) *string {
+	if version == nil || !isOrchestratedUpgrade(controlPlane) {
+		return version
+	}
+
+	status := controlPlane.Status.Upgrade
+	if status == nil || status.Version != *version {
+		return nil
+	}
+	if status.Phase == infrav1exp.UpgradePhaseCompleted || containsString(status.UpgradedNodePools, poolName) {
+		return version
+	}
+	if status.Phase == infrav1exp.UpgradePhaseNodePools && status.NodePool == poolName {
+		return version
+	}
+	return nil
+}
+
+// isOrchestratedUpgrade returns true if the control plane asks for orchestrated upgrades.
+func isOrchestratedUpgrade(controlPlane *infrav1exp.AzureManagedControlPlane) bool {
+	return controlPlane.Spec.UpgradePolicy != nil && controlPlane.Spec.UpgradePolicy.Mode == infrav1exp.OrchestratedUpgradeMode
+}
+
+// isAgentPoolUpgraded returns true if the agent pool successfully runs the given version.
+func isAgentPoolUpgraded(pool containerservice.AgentPool, version string) bool {
+	if pool.ManagedClusterAgentPoolProfileProperties == nil || pool.OrchestratorVersion == nil {
+		return false
+	}
+	return provisioningState(pool.ProvisioningState) == "Succeeded" && *pool.OrchestratorVersion == version
+}
+
+// pauseUpgrade pauses the orchestrated upgrade of the control plane until it is resumed.
+func pauseUpgrade(scope *scope.ManagedControlPlaneScope, message string) {
+	scope.Info("Pausing orchestrated upgrade", "reason", message)
+	scope.ControlPlane.Status.Upgrade.Phase = infrav1exp.UpgradePhasePaused
+	scope.ControlPlane.Status.Upgrade.FailureMessage = &message
+}
+
+func provisioningState(state *string) string {
+	if state == nil {
+		return ""
+	}
+	return *state
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,375 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2020-02-01/containerservice"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/klogr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterv1exp "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/agentpools/mock_agentpools"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters/mock_managedclusters"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
+	gomockinternal "sigs.k8s.io/cluster-api-provider-azure/internal/test/matchers/gomock"
+)
+
+func TestAgentPoolVersion(t *testing.T) {
+	orchestrated := func(status *infrav1exp.UpgradeStatus) *infrav1exp.AzureManagedControlPlane {
+		return &infrav1exp.AzureManagedControlPlane{
+			Spec: infrav1exp.AzureManagedControlPlaneSpec{
+				UpgradePolicy: &infrav1exp.UpgradePolicy{Mode: infrav1exp.OrchestratedUpgradeMode},
+			},
+			Status: infrav1exp.AzureManagedControlPlaneStatus{Upgrade: status},
+		}
+	}
+
+	cases := []struct {
+		Name         string
+		ControlPlane *infrav1exp.AzureManagedControlPlane
+		Expected     *string
+	}{
+		{
+			Name:         "IndependentUpgrade",
+			ControlPlane: &infrav1exp.AzureManagedControlPlane{},
+			Expected:     to.StringPtr("1.21.2"),
+		},
+		{
+			Name:         "OrchestratedUpgradeNotStarted",
+			ControlPlane: orchestrated(nil),
+			Expected:     nil,
+		},
+		{
+			Name:         "OrchestratedUpgradeOfOtherVersion",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.20.7", Phase: infrav1exp.UpgradePhaseCompleted}),
+			Expected:     nil,
+		},
+		{
+			Name:         "ControlPlaneUpgrading",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseControlPlane}),
+			Expected:     nil,
+		},
+		{
+			Name:         "OtherPoolUpgrading",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1"}),
+			Expected:     nil,
+		},
+		{
+			Name:         "PoolUpgrading",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool0"}),
+			Expected:     to.StringPtr("1.21.2"),
+		},
+		{
+			Name:         "PoolUpgradePaused",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhasePaused, NodePool: "pool0"}),
+			Expected:     nil,
+		},
+		{
+			Name:         "PoolUpgraded",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhasePaused, UpgradedNodePools: []string{"pool0"}}),
+			Expected:     to.StringPtr("1.21.2"),
+		},
+		{
+			Name:         "UpgradeCompleted",
+			ControlPlane: orchestrated(&infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseCompleted}),
+			Expected:     to.StringPtr("1.21.2"),
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+			g.Expect(agentPoolVersion(c.ControlPlane, "pool0", to.StringPtr("1.21.2"))).To(gomega.Equal(c.Expected))
+		})
+	}
+}
+
+func TestReconcileUpgrade(t *testing.T) {
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	veryLongAgo := metav1.NewTime(time.Now().Add(-3 * time.Hour))
+
+	cases := []struct {
+		Name            string
+		Status          *infrav1exp.UpgradeStatus
+		Annotations     map[string]string
+		SoakDuration    time.Duration
+		NodePoolTimeout *metav1.Duration
+		UnreadyPools    []string
+		Expect         func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder)
+		ExpectedStatus *infrav1exp.UpgradeStatus
+		ExpectRequeue  bool
+	}{
+		{
+			Name: "StartsWithControlPlane",
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				mc.Get(gomockinternal.AContext(), "my-rg", "my-cluster").Return(managedCluster("Upgrading", "1.20.7"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseControlPlane},
+			ExpectRequeue:  true,
+		},
+		{
+			Name:   "PausesOnControlPlaneFailure",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseControlPlane},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				mc.Get(gomockinternal.AContext(), "my-rg", "my-cluster").Return(managedCluster("Failed", "1.20.7"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{
+				Version:        "1.21.2",
+				Phase:          infrav1exp.UpgradePhasePaused,
+				FailureMessage: to.StringPtr("upgrade of control plane my-cluster to 1.21.2 failed with provisioning state Failed"),
+			},
+		},
+		{
+			Name:   "UpgradesNodePoolsInOrder",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseControlPlane},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				mc.Get(gomockinternal.AContext(), "my-rg", "my-cluster").Return(managedCluster("Succeeded", "1.21.2"), nil, nil)
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Succeeded", "1.20.7"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1"},
+			ExpectRequeue:  true,
+		},
+		{
+			Name:   "PausesOnNodePoolFailure",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1", LastTransitionTime: &longAgo},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Failed", "1.21.2"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{
+				Version:            "1.21.2",
+				Phase:              infrav1exp.UpgradePhasePaused,
+				NodePool:           "pool1",
+				LastTransitionTime: &longAgo,
+				FailureMessage:     to.StringPtr("upgrade of node pool pool1 to 1.21.2 failed with provisioning state Failed"),
+			},
+		},
+		{
+			Name:   "PausesOnCanceledNodePool",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1", NodePoolStartTime: &longAgo, LastTransitionTime: &longAgo},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Canceled", "1.20.7"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{
+				Version:            "1.21.2",
+				Phase:              infrav1exp.UpgradePhasePaused,
+				NodePool:           "pool1",
+				NodePoolStartTime:  &longAgo,
+				LastTransitionTime: &longAgo,
+				FailureMessage:     to.StringPtr("upgrade of node pool pool1 to 1.21.2 failed with provisioning state Canceled"),
+			},
+		},
+		{
+			Name:   "PausesOnStuckNodePool",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1", NodePoolStartTime: &veryLongAgo, LastTransitionTime: &veryLongAgo},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Upgrading", "1.21.2"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{
+				Version:            "1.21.2",
+				Phase:              infrav1exp.UpgradePhasePaused,
+				NodePool:           "pool1",
+				NodePoolStartTime:  &veryLongAgo,
+				LastTransitionTime: &veryLongAgo,
+				FailureMessage:     to.StringPtr("upgrade of node pool pool1 to 1.21.2 did not complete within 2h0m0s"),
+			},
+		},
+		{
+			Name:         "WaitsForNodesOfUpgradedNodePool",
+			Status:       &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1", NodePoolStartTime: &longAgo, LastTransitionTime: &longAgo},
+			UnreadyPools: []string{"pool1"},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Succeeded", "1.21.2"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1", NodePoolStartTime: &longAgo, LastTransitionTime: &longAgo},
+			ExpectRequeue:  true,
+		},
+		{
+			Name:            "PausesOnDegradedNodePool",
+			Status:          &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool1", NodePoolStartTime: &longAgo, LastTransitionTime: &longAgo},
+			NodePoolTimeout: &metav1.Duration{Duration: 30 * time.Minute},
+			UnreadyPools:    []string{"pool1"},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Succeeded", "1.21.2"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{
+				Version:            "1.21.2",
+				Phase:              infrav1exp.UpgradePhasePaused,
+				NodePool:           "pool1",
+				NodePoolStartTime:  &longAgo,
+				LastTransitionTime: &longAgo,
+				FailureMessage:     to.StringPtr("upgrade of node pool pool1 to 1.21.2 did not complete within 30m0s"),
+			},
+		},
+		{
+			Name:   "StaysPaused",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhasePaused, NodePool: "pool1", NodePoolStartTime: &veryLongAgo, LastTransitionTime: &longAgo},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{
+				Version:            "1.21.2",
+				Phase:              infrav1exp.UpgradePhasePaused,
+				NodePool:           "pool1",
+				NodePoolStartTime:  &veryLongAgo,
+				LastTransitionTime: &longAgo,
+			},
+		},
+		{
+			Name:         "ResumesAndSoaksBeforeNextNodePool",
+			Status:       &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhasePaused, NodePool: "pool1", LastTransitionTime: &longAgo},
+			Annotations:  map[string]string{infrav1exp.ResumeUpgradeAnnotation: ""},
+			SoakDuration: 2 * time.Hour,
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool1", false).Return(agentPool("Succeeded", "1.21.2"), nil, nil)
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(agentPool("Succeeded", "1.20.7"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, UpgradedNodePools: []string{"pool1"}},
+			ExpectRequeue:  true,
+		},
+		{
+			Name:   "Completes",
+			Status: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseNodePools, NodePool: "pool0", UpgradedNodePools: []string{"pool1"}, LastTransitionTime: &longAgo},
+			Expect: func(mc *mock_managedclusters.MockClientMockRecorder, ap *mock_agentpools.MockClientMockRecorder) {
+				ap.Get(gomockinternal.AContext(), "my-rg", "my-cluster", "pool0", false).Return(agentPool("Succeeded", "1.21.2"), nil, nil)
+			},
+			ExpectedStatus: &infrav1exp.UpgradeStatus{Version: "1.21.2", Phase: infrav1exp.UpgradePhaseCompleted, UpgradedNodePools: []string{"pool1", "pool0"}},
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			managedClustersMock := mock_managedclusters.NewMockClient(mockCtrl)
+			agentPoolsMock := mock_agentpools.NewMockClient(mockCtrl)
+			if c.Expect != nil {
+				c.Expect(managedClustersMock.EXPECT(), agentPoolsMock.EXPECT())
+			}
+
+			controlPlane := &infrav1exp.AzureManagedControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "default", Annotations: c.Annotations},
+				Spec: infrav1exp.AzureManagedControlPlaneSpec{
+					Version:           "v1.21.2",
+					ResourceGroupName: "my-rg",
+					UpgradePolicy: &infrav1exp.UpgradePolicy{
+						Mode:          infrav1exp.OrchestratedUpgradeMode,
+						NodePoolOrder: []string{"pool1"},
+						SoakDuration:    &metav1.Duration{Duration: c.SoakDuration},
+						NodePoolTimeout: c.NodePoolTimeout,
+					},
+				},
+				Status: infrav1exp.AzureManagedControlPlaneStatus{Upgrade: c.Status},
+			}
+
+			r := &azureManagedControlPlaneReconciler{
+				kubeclient:         upgradeTestClient(c.UnreadyPools, "pool0", "pool1"),
+				managedClustersSvc: &managedclusters.Service{Client: managedClustersMock},
+				agentPoolsClient:   agentPoolsMock,
+			}
+			s := &scope.ManagedControlPlaneScope{
+				Logger:       klogr.New(),
+				Cluster:      &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "default"}},
+				ControlPlane: controlPlane,
+			}
+
+			requeueAfter, err := r.ReconcileUpgrade(context.TODO(), s)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(requeueAfter > 0).To(gomega.Equal(c.ExpectRequeue))
+			if c.ExpectedStatus != nil && c.ExpectedStatus.LastTransitionTime == nil && controlPlane.Status.Upgrade.LastTransitionTime != nil {
+				c.ExpectedStatus.LastTransitionTime = controlPlane.Status.Upgrade.LastTransitionTime
+			}
+			if c.ExpectedStatus != nil && c.ExpectedStatus.NodePool != "" && c.ExpectedStatus.NodePoolStartTime == nil {
+				c.ExpectedStatus.NodePoolStartTime = controlPlane.Status.Upgrade.NodePoolStartTime
+				g.Expect(c.ExpectedStatus.NodePoolStartTime).NotTo(gomega.BeNil())
+			}
+			g.Expect(controlPlane.Status.Upgrade).To(gomega.Equal(c.ExpectedStatus))
+			g.Expect(controlPlane.Annotations).NotTo(gomega.HaveKey(infrav1exp.ResumeUpgradeAnnotation))
+		})
+	}
+}
+
+// upgradeTestClient returns a client for the MachinePools and AzureManagedMachinePools of the given node
+// pools. The nodes of all pools are ready, except the ones of unready pools.
+func upgradeTestClient(unready []string, pools ...string) client.Client {
+	scheme := runtime.NewScheme()
+	_ = clusterv1exp.AddToScheme(scheme)
+	_ = infrav1exp.AddToScheme(scheme)
+
+	var objects []client.Object
+	for _, name := range pools {
+		readyReplicas := int32(3)
+		if containsString(unready, name) {
+			readyReplicas = 2
+		}
+		objects = append(objects,
+			&clusterv1exp.MachinePool{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: clusterv1exp.MachinePoolSpec{
+					Replicas: to.Int32Ptr(3),
+					Template: clusterv1.MachineTemplateSpec{
+						Spec: clusterv1.MachineSpec{Version: to.StringPtr("v1.21.2")},
+					},
+				},
+				Status: clusterv1exp.MachinePoolStatus{ReadyReplicas: readyReplicas},
+			},
+			&infrav1exp.AzureManagedMachinePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{clusterv1.ClusterLabelName: "my-cluster"},
+					OwnerReferences: []metav1.OwnerReference{
+						{APIVersion: clusterv1exp.GroupVersion.String(), Kind: "MachinePool", Name: name},
+					},
+				},
+			},
+		)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func managedCluster(provisioningState, version string) containerservice.ManagedCluster {
+	return containerservice.ManagedCluster{
+		ManagedClusterProperties: &containerservice.ManagedClusterProperties{
+			ProvisioningState: to.StringPtr(provisioningState),
+			KubernetesVersion: to.StringPtr(version),
+		},
+	}
+}
+
+func agentPool(provisioningState, version string) containerservice.AgentPool {
+	return containerservice.AgentPool{
+		ManagedClusterAgentPoolProfileProperties: &containerservice.ManagedClusterAgentPoolProfileProperties{
+			ProvisioningState:   to.StringPtr(provisioningState),
+			OrchestratorVersion: to.StringPtr(version),
+		},
+	}
+}
@@ -22,8 +22,6 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -40,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	infracontroller "sigs.k8s.io/cluster-api-provider-azure/controllers"
 	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
@@ -126,12 +125,8 @@ func (r *AzureManagedMachinePoolReconciler) Reconcile(ctx context.Context, req c
 	defer cancel()
 	log := r.Log.WithValues("namespace", req.Namespace, "azureManagedMachinePool", req.Name)
 
-	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureManagedMachinePoolReconciler.Reconcile",
-		trace.WithAttributes(
-			attribute.String("namespace", req.Namespace),
-			attribute.String("name", req.Name),
-			attribute.String("kind", "AzureManagedMachinePool"),
-		))
+	ctx = tele.WithAttributes(ctx, tele.ObjectAttributes("AzureManagedMachinePool", req.Namespace, req.Name)...)
+	ctx, span := tele.Tracer().Start(ctx, "controllers.AzureManagedMachinePoolReconciler.Reconcile")
 	defer span.End()
 
 	// Fetch the AzureManagedMachinePool instance
@@ -182,6 +177,11 @@ func (r *AzureManagedMachinePoolReconciler) Reconcile(ctx context.Context, req c
 		return reconcile.Result{}, err
 	}
 
+	if !infracontroller.InShard(ownerCluster, controlPlane.Spec.SubscriptionID) {
+		log.V(2).Info("Cluster is not in the shard of this manager. Won't reconcile")
+		return reconcile.Result{}, nil
+	}
+
 	// For non-system node pools, we wait for the control plane to be
 	// initialized, otherwise Azure API will return an error for node pool
 	// CreateOrUpdate request.
@@ -203,6 +203,7 @@ func (r *AzureManagedMachinePoolReconciler) Reconcile(ctx context.Context, req c
 	if err != nil {
 		return reconcile.Result{}, errors.Errorf("failed to create scope: %+v", err)
 	}
+	ctx = infracontroller.WithTraceAttributes(ctx, mcpScope)
 
 	// Always patch when exiting so we can persist changes to finalizers and status
 	defer func() {
@@ -211,6 +212,26 @@ func (r *AzureManagedMachinePoolReconciler) Reconcile(ctx context.Context, req c
 		}
 	}()
 
+	// A dry run leaves the AzureManagedMachinePool as it was, apart from reporting the changes it would have made, or
+	// refused to make in read-only mode.
+	ctx, dryRun := infracontroller.WithManagerDryRun(ctx, log)
+	original := infraPool.DeepCopy()
+	defer func() {
+		if dryRun != nil {
+			infraPool.Annotations = original.Annotations
+			infraPool.Finalizers = original.Finalizers
+			infraPool.Spec = original.Spec
+			status := infraPool.Status
+			infraPool.Status = original.Status
+			// In read-only mode, the nodes of the agent pool are reported as they were read.
+			if dryRun.ReadOnly() {
+				infraPool.Status.Replicas = status.Replicas
+				infraPool.Status.DeallocatedReplicas = status.DeallocatedReplicas
+			}
+		}
+		infracontroller.RecordDryRun(r.Recorder, infraPool, dryRun)
+	}()
+
 	// Handle deleted clusters
 	if !infraPool.DeletionTimestamp.IsZero() {
 		return r.reconcileDelete(ctx, mcpScope)
@@ -234,6 +255,7 @@ func (r *AzureManagedMachinePoolReconciler) reconcileNormal(ctx context.Context,
 	}
 
 	if err := r.createAzureManagedMachinePoolService(scope).Reconcile(ctx, scope); err != nil {
+		err = azure.WithRequestIDs(err)
 		if IsAgentPoolVMSSNotFoundError(err) {
 			// if the underlying VMSS is not yet created, requeue for 30s in the future
 			return reconcile.Result{
@@ -261,15 +283,13 @@ func (r *AzureManagedMachinePoolReconciler) reconcileDelete(ctx context.Context,
 		controllerutil.RemoveFinalizer(scope.InfraMachinePool, infrav1.ClusterFinalizer)
 	} else {
 		if err := r.createAzureManagedMachinePoolService(scope).Delete(ctx, scope); err != nil {
+			err = azure.WithRequestIDs(err)
 			return reconcile.Result{}, errors.Wrapf(err, "error deleting AzureManagedMachinePool %s/%s", scope.InfraMachinePool.Namespace, scope.InfraMachinePool.Name)
 		}
 		// Machine pool successfully deleted, remove the finalizer.
 		controllerutil.RemoveFinalizer(scope.InfraMachinePool, infrav1.ClusterFinalizer)
 	}
 
-	if err := scope.PatchObject(ctx); err != nil {
-		return reconcile.Result{}, err
-	}
-
+	// The removal of the finalizer is patched when the reconciliation ends, unless it is a dry run.
 	return reconcile.Result{}, nil
 }
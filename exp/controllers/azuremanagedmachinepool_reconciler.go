@@ -20,13 +20,17 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/agentpools"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/scalesets"
+	infracontroller "sigs.k8s.io/cluster-api-provider-azure/controllers"
+	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -79,9 +83,13 @@ func (a *AgentPoolVMSSNotFoundError) Is(target error) bool {
 
 // newAzureManagedMachinePoolService populates all the services based on input scope.
 func newAzureManagedMachinePoolService(scope *scope.ManagedControlPlaneScope) *azureManagedMachinePoolService {
+	drainer := agentpools.NewNodeDrainer(scope.Client, client.ObjectKey{
+		Namespace: scope.Cluster.Namespace,
+		Name:      scope.Cluster.Name,
+	})
 	return &azureManagedMachinePoolService{
 		kubeclient:    scope.Client,
-		agentPoolsSvc: agentpools.NewService(scope),
+		agentPoolsSvc: agentpools.NewService(scope, drainer),
 		scaleSetsSvc:  scalesets.NewClient(scope),
 	}
 }
@@ -105,12 +113,13 @@ func (s *azureManagedMachinePoolService) Reconcile(ctx context.Context, scope *s
 	}
 
 	agentPoolSpec := &agentpools.Spec{
-		Name:          scope.InfraMachinePool.Name,
-		ResourceGroup: scope.ControlPlane.Spec.ResourceGroupName,
-		Cluster:       scope.ControlPlane.Name,
-		SKU:           scope.InfraMachinePool.Spec.SKU,
-		Replicas:      replicas,
-		Version:       normalizedVersion,
+		Name:           scope.InfraMachinePool.Name,
+		ResourceGroup:  scope.ControlPlane.Spec.ResourceGroupName,
+		Cluster:        scope.ControlPlane.Name,
+		SKU:            scope.InfraMachinePool.Spec.SKU,
+		Replicas:       replicas,
+		Version:        agentPoolVersion(scope.ControlPlane, scope.InfraMachinePool.Name, normalizedVersion),
+		DefaultVersion: controlPlaneVersion(scope.ControlPlane),
 		VnetSubnetID: azure.SubnetID(
 			scope.ControlPlane.Spec.SubscriptionID,
 			scope.ControlPlane.Spec.ResourceGroupName,
@@ -123,7 +132,41 @@ func (s *azureManagedMachinePoolService) Reconcile(ctx context.Context, scope *s
 		agentPoolSpec.OSDiskSizeGB = *scope.InfraMachinePool.Spec.OSDiskSizeGB
 	}
 
-	if err := s.agentPoolsSvc.Reconcile(ctx, agentPoolSpec); err != nil {
+	if scope.InfraMachinePool.Spec.OSType != nil {
+		agentPoolSpec.OSType = *scope.InfraMachinePool.Spec.OSType
+	}
+
+	if len(scope.InfraMachinePool.Spec.AvailabilityZones) > 0 {
+		agentPoolSpec.AvailabilityZones = scope.InfraMachinePool.Spec.AvailabilityZones
+	}
+
+	if scope.InfraMachinePool.Spec.ScaleDownMode != nil {
+		agentPoolSpec.ScaleDownMode = *scope.InfraMachinePool.Spec.ScaleDownMode
+	}
+
+	if settings := scope.InfraMachinePool.Spec.UpgradeSettings; settings != nil {
+		if settings.MaxSurge != nil {
+			agentPoolSpec.MaxSurge = *settings.MaxSurge
+		}
+		if settings.DrainTimeout != nil {
+			agentPoolSpec.DrainTimeoutInMinutes = to.Int32Ptr(int32(settings.DrainTimeout.Minutes()))
+		}
+		if settings.NodeSoakDuration != nil {
+			agentPoolSpec.NodeSoakDurationInMinutes = to.Int32Ptr(int32(settings.NodeSoakDuration.Minutes()))
+		}
+		if settings.UndrainableNodeBehavior != nil {
+			agentPoolSpec.UndrainableNodeBehavior = *settings.UndrainableNodeBehavior
+		}
+	}
+
+	if agentPoolSpec.OSType == infrav1exp.WindowsOSType && scope.ControlPlane.Spec.NetworkPlugin != nil && *scope.ControlPlane.Spec.NetworkPlugin != "azure" {
+		return errors.Errorf("failed to reconcile machine pool %s: Windows agent pools require the azure network plugin", scope.InfraMachinePool.Name)
+	}
+
+	start := time.Now()
+	err := s.agentPoolsSvc.Reconcile(ctx, agentPoolSpec)
+	infracontroller.ObserveServiceOperation(scope, "agentpools", infracontroller.ReconcileOperation, start, err)
+	if err != nil {
 		return errors.Wrapf(err, "failed to reconcile machine pool %s", scope.InfraMachinePool.Name)
 	}
 
@@ -150,13 +193,21 @@ func (s *azureManagedMachinePoolService) Reconcile(ctx context.Context, scope *s
 		return errors.Wrapf(err, "failed to reconcile machine pool %s", scope.InfraMachinePool.Name)
 	}
 
-	var providerIDs = make([]string, len(instances))
+	// Deallocated instances are kept by pools with the Deallocate scale down mode, they do not
+	// count as replicas until they are started again.
+	var providerIDs = make([]string, 0, len(instances))
+	var deallocated int32
 	for i := 0; i < len(instances); i++ {
-		providerIDs[i] = azure.ProviderIDPrefix + *instances[i].ID
+		if isDeallocated(instances[i]) {
+			deallocated++
+			continue
+		}
+		providerIDs = append(providerIDs, azure.ProviderIDPrefix+*instances[i].ID)
 	}
 
 	scope.InfraMachinePool.Spec.ProviderIDList = providerIDs
 	scope.InfraMachinePool.Status.Replicas = int32(len(providerIDs))
+	scope.InfraMachinePool.Status.DeallocatedReplicas = deallocated
 	scope.InfraMachinePool.Status.Ready = true
 
 	scope.Logger.Info("reconciled machine pool successfully")
@@ -173,14 +224,33 @@ func (s *azureManagedMachinePoolService) Delete(ctx context.Context, scope *scop
 		ResourceGroup: scope.ControlPlane.Spec.ResourceGroupName,
 		Cluster:       scope.ControlPlane.Name,
 	}
+	if scope.InfraMachinePool.Spec.OSType != nil {
+		agentPoolSpec.OSType = *scope.InfraMachinePool.Spec.OSType
+	}
 
-	if err := s.agentPoolsSvc.Delete(ctx, agentPoolSpec); err != nil {
+	start := time.Now()
+	err := s.agentPoolsSvc.Delete(ctx, agentPoolSpec)
+	infracontroller.ObserveServiceOperation(scope, "agentpools", infracontroller.DeleteOperation, start, err)
+	if err != nil {
 		return errors.Wrapf(err, "failed to delete machine pool %s", scope.InfraMachinePool.Name)
 	}
 
 	return nil
 }
 
+// isDeallocated returns true if the instance view of a scale set VM reports it as deallocated.
+func isDeallocated(instance compute.VirtualMachineScaleSetVM) bool {
+	if instance.VirtualMachineScaleSetVMProperties == nil || instance.InstanceView == nil || instance.InstanceView.Statuses == nil {
+		return false
+	}
+	for _, status := range *instance.InstanceView.Statuses {
+		if status.Code != nil && strings.EqualFold(*status.Code, "PowerState/deallocated") {
+			return true
+		}
+	}
+	return false
+}
+
 // IsAgentPoolVMSSNotFoundError returns true if the error is an AgentPoolVMSSNotFoundError.
 func IsAgentPoolVMSSNotFoundError(err error) bool {
 	return errors.Is(err, notFoundErr)
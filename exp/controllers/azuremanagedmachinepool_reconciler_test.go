@@ -19,6 +19,8 @@ package controllers
 import (
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-30/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/onsi/gomega"
 	"github.com/pkg/errors"
 )
@@ -60,3 +62,48 @@ func TestIsAgentPoolVMSSNotFoundError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsDeallocated(t *testing.T) {
+	instance := func(codes ...string) compute.VirtualMachineScaleSetVM {
+		statuses := make([]compute.InstanceViewStatus, len(codes))
+		for i := range codes {
+			statuses[i] = compute.InstanceViewStatus{Code: to.StringPtr(codes[i])}
+		}
+		return compute.VirtualMachineScaleSetVM{
+			VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+				InstanceView: &compute.VirtualMachineScaleSetVMInstanceView{Statuses: &statuses},
+			},
+		}
+	}
+
+	cases := []struct {
+		Name     string
+		Instance compute.VirtualMachineScaleSetVM
+		Expected bool
+	}{
+		{
+			Name:     "Deallocated",
+			Instance: instance("ProvisioningState/succeeded", "PowerState/deallocated"),
+			Expected: true,
+		},
+		{
+			Name:     "Running",
+			Instance: instance("ProvisioningState/succeeded", "PowerState/running"),
+			Expected: false,
+		},
+		{
+			Name:     "WithoutInstanceView",
+			Instance: compute.VirtualMachineScaleSetVM{},
+			Expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+			g.Expect(isDeallocated(c.Instance)).To(gomega.Equal(c.Expected))
+		})
+	}
+}
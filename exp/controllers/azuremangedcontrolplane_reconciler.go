@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2020-02-01/containerservice"
 	"github.com/pkg/errors"
@@ -34,10 +35,13 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-azure/azure"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/agentpools"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/aksbackup"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/groups"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/managedclusters"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/subnets"
 	"sigs.k8s.io/cluster-api-provider-azure/azure/services/virtualnetworks"
+	infracontroller "sigs.k8s.io/cluster-api-provider-azure/controllers"
 	infrav1exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 )
@@ -46,9 +50,11 @@ import (
 type azureManagedControlPlaneReconciler struct {
 	kubeclient         client.Client
 	managedClustersSvc *managedclusters.Service
+	agentPoolsClient   agentpools.Client
 	groupsSvc          azure.Reconciler
 	vnetSvc            azure.Reconciler
 	subnetsSvc         azure.Reconciler
+	aksBackupSvc       azure.Reconciler
 }
 
 // newAzureManagedControlPlaneReconciler populates all the services based on input scope.
@@ -56,9 +62,11 @@ func newAzureManagedControlPlaneReconciler(scope *scope.ManagedControlPlaneScope
 	return &azureManagedControlPlaneReconciler{
 		kubeclient:         scope.Client,
 		managedClustersSvc: managedclusters.NewService(scope),
-		groupsSvc:          groups.New(scope),
-		vnetSvc:            virtualnetworks.New(scope),
-		subnetsSvc:         subnets.New(scope),
+		agentPoolsClient:   agentpools.NewClient(scope),
+		groupsSvc:          infracontroller.WithServiceMetrics("groups", scope, groups.New(scope)),
+		vnetSvc:            infracontroller.WithServiceMetrics("virtualnetworks", scope, virtualnetworks.New(scope)),
+		subnetsSvc:         infracontroller.WithServiceMetrics("subnets", scope, subnets.New(scope)),
+		aksBackupSvc:       infracontroller.WithServiceMetrics("aksbackup", scope, aksbackup.New(scope)),
 	}
 }
 
@@ -98,6 +106,31 @@ func (r *azureManagedControlPlaneReconciler) Reconcile(ctx context.Context, scop
 	if scope.ControlPlane.Spec.LoadBalancerSKU != nil {
 		managedClusterSpec.LoadBalancerSKU = *scope.ControlPlane.Spec.LoadBalancerSKU
 	}
+	if windowsProfile := scope.ControlPlane.Spec.WindowsProfile; windowsProfile != nil {
+		managedClusterSpec.WindowsProfile = &managedclusters.WindowsProfile{}
+		if windowsProfile.LicenseType != nil {
+			managedClusterSpec.WindowsProfile.LicenseType = *windowsProfile.LicenseType
+		}
+		if windowsProfile.GMSA != nil {
+			managedClusterSpec.WindowsProfile.GMSA = &managedclusters.GMSAProfile{
+				Enabled:        windowsProfile.GMSA.Enabled,
+				DNSServer:      windowsProfile.GMSA.DNSServer,
+				RootDomainName: windowsProfile.GMSA.RootDomainName,
+			}
+		}
+	}
+	if ingressProfile := scope.ControlPlane.Spec.IngressProfile; ingressProfile != nil && ingressProfile.WebAppRouting != nil {
+		managedClusterSpec.WebAppRouting = &managedclusters.WebAppRouting{
+			Enabled:            ingressProfile.WebAppRouting.Enabled,
+			DNSZoneResourceIDs: ingressProfile.WebAppRouting.DNSZoneResourceIDs,
+		}
+	}
+	if metricsProfile := scope.ControlPlane.Spec.MetricsProfile; metricsProfile != nil && metricsProfile.CostAnalysis != nil {
+		managedClusterSpec.CostAnalysis = &metricsProfile.CostAnalysis.Enabled
+	}
+	if sku := scope.ControlPlane.Spec.SKU; sku != nil {
+		managedClusterSpec.SKUTier = sku.Tier
+	}
 
 	scope.V(2).Info("Reconciling managed cluster resource group")
 	if err := r.groupsSvc.Reconcile(ctx); err != nil {
@@ -119,14 +152,24 @@ func (r *azureManagedControlPlaneReconciler) Reconcile(ctx context.Context, scop
 		return errors.Wrap(err, "failed to reconcile managed cluster")
 	}
 
-	scope.V(2).Info("Reconciling endpoint")
-	if err := r.reconcileEndpoint(ctx, scope, managedClusterSpec); err != nil {
-		return errors.Wrap(err, "failed to reconcile control plane endpoint")
+	// The managed cluster of a dry run may not exist, and its credentials are not fetched.
+	if azure.DryRunFrom(ctx) != nil {
+		scope.V(2).Info("Skipping endpoint and kubeconfig in dry run")
+	} else {
+		scope.V(2).Info("Reconciling endpoint")
+		if err := r.reconcileEndpoint(ctx, scope, managedClusterSpec); err != nil {
+			return errors.Wrap(err, "failed to reconcile control plane endpoint")
+		}
+
+		scope.V(2).Info("Reconciling kubeconfig")
+		if err := r.reconcileKubeconfig(ctx, scope, managedClusterSpec); err != nil {
+			return errors.Wrap(err, "failed to reconcile kubeconfig secret")
+		}
 	}
 
-	scope.V(2).Info("Reconciling kubeconfig")
-	if err := r.reconcileKubeconfig(ctx, scope, managedClusterSpec); err != nil {
-		return errors.Wrap(err, "failed to reconcile kubeconfig secret")
+	scope.V(2).Info("Reconciling backup")
+	if err := r.aksBackupSvc.Reconcile(ctx); err != nil {
+		return errors.Wrap(err, "failed to reconcile backup")
 	}
 
 	return nil
@@ -142,8 +185,16 @@ func (r *azureManagedControlPlaneReconciler) Delete(ctx context.Context, scope *
 		ResourceGroupName: scope.ControlPlane.Spec.ResourceGroupName,
 	}
 
+	scope.V(2).Info("Deleting backup")
+	if err := r.aksBackupSvc.Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete backup")
+	}
+
 	scope.V(2).Info("Deleting managed cluster")
-	if err := r.managedClustersSvc.Delete(ctx, managedClusterSpec); err != nil {
+	start := time.Now()
+	err := r.managedClustersSvc.Delete(ctx, managedClusterSpec)
+	infracontroller.ObserveServiceOperation(scope, "managedclusters", infracontroller.DeleteOperation, start, err)
+	if err != nil {
 		return errors.Wrapf(err, "failed to delete managed cluster %s", scope.ControlPlane.Name)
 	}
 
@@ -213,6 +264,10 @@ func (r *azureManagedControlPlaneReconciler) reconcileManagedCluster(ctx context
 	// We do this here because AKS will only let us mutate agent pools via managed
 	// clusters API at create time, not update.
 	if azure.ResourceNotFound(err) {
+		if osType := scope.InfraMachinePool.Spec.OSType; osType != nil && *osType == infrav1exp.WindowsOSType {
+			return errors.Errorf("default pool %s must be a Linux pool, AKS requires a Linux system pool", scope.InfraMachinePool.Name)
+		}
+
 		defaultPoolSpec := managedclusters.PoolSpec{
 			Name:         scope.InfraMachinePool.Name,
 			SKU:          scope.InfraMachinePool.Spec.SKU,
@@ -227,13 +282,19 @@ func (r *azureManagedControlPlaneReconciler) reconcileManagedCluster(ctx context
 		if scope.MachinePool.Spec.Replicas != nil {
 			defaultPoolSpec.Replicas = *scope.MachinePool.Spec.Replicas
 		}
+		if len(scope.InfraMachinePool.Spec.AvailabilityZones) > 0 {
+			defaultPoolSpec.AvailabilityZones = scope.InfraMachinePool.Spec.AvailabilityZones
+		}
 
 		// Add to cluster spec
 		managedClusterSpec.AgentPools = []managedclusters.PoolSpec{defaultPoolSpec}
 	}
 
 	// Send to Azure for create/update.
-	if err := r.managedClustersSvc.Reconcile(ctx, managedClusterSpec); err != nil {
+	start := time.Now()
+	err = r.managedClustersSvc.Reconcile(ctx, managedClusterSpec)
+	infracontroller.ObserveServiceOperation(scope, "managedclusters", infracontroller.ReconcileOperation, start, err)
+	if err != nil {
 		return errors.Wrapf(err, "failed to reconcile managed cluster %s", scope.ControlPlane.Name)
 	}
 	return nil
@@ -0,0 +1,308 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakearm implements a fake Azure Resource Manager, which the clients of the Azure services can be pointed at
+// to test them against canned responses, without a subscription nor mocks of their interfaces.
+package fakearm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+)
+
+const (
+	// SubscriptionID is the subscription of the authorizer of the fake Azure Resource Manager.
+	SubscriptionID = "00000000-0000-0000-0000-000000000000"
+
+	// operationsPath is the path the long running operations are polled at.
+	operationsPath = "/fakearm/operations/"
+)
+
+// Request is a request received by the fake Azure Resource Manager.
+type Request struct {
+	Method string
+	// Path is the path of the request, without its query, e.g. the API version.
+	Path string
+	Body []byte
+}
+
+// Expectation is a request the fake Azure Resource Manager expects, and the response it serves to it.
+type Expectation struct {
+	method string
+	path   string
+	times  int
+
+	status int
+	body   []byte
+	header http.Header
+	// states are the states the long running operation started by the request goes through, if any.
+	states []string
+
+	received int
+}
+
+// Respond makes the expected request answered with status and body, marshaled as JSON unless it is nil.
+func (e *Expectation) Respond(status int, body interface{}) *Expectation {
+	e.status = status
+	e.body = nil
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			panic(errors.Wrap(err, "failed to marshal response body"))
+		}
+		e.body = b
+	}
+	return e
+}
+
+// RespondError makes the expected request fail with status and an Azure Resource Manager error.
+func (e *Expectation) RespondError(status int, code, message string) *Expectation {
+	return e.Respond(status, armError(code, message))
+}
+
+// WithHeader adds a header to the response to the expected request.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.header.Add(key, value)
+	return e
+}
+
+// Poll makes the expected request start a long running operation, going through states at each poll, the last one
+// being repeated. The response to the request is 201 Created for a PUT, and 202 Accepted otherwise. The final GET
+// of a PUT whose operation succeeded is answered with the body of the response.
+func (e *Expectation) Poll(states ...string) *Expectation {
+	e.states = states
+	if e.method == http.MethodPut {
+		e.status = http.StatusCreated
+	} else {
+		e.status = http.StatusAccepted
+	}
+	return e
+}
+
+// Times makes the request expected n times, instead of once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) matches(r *http.Request) bool {
+	return e.received < e.times && e.method == r.Method && strings.EqualFold(e.path, r.URL.Path)
+}
+
+func (e *Expectation) String() string {
+	return fmt.Sprintf("%s %s", e.method, e.path)
+}
+
+// operation is a long running operation started by an expected request.
+type operation struct {
+	expectation *Expectation
+	polls       int
+}
+
+// Server is a fake Azure Resource Manager. It answers the requests it expects in the order they are expected, and
+// fails the other ones with 501 Not Implemented, which the clients don't retry.
+type Server struct {
+	// URL is the base URI of the fake Azure Resource Manager.
+	URL string
+
+	server       *httptest.Server
+	mu           sync.Mutex
+	expectations []*Expectation
+	requests     []Request
+	unexpected   []Request
+	operations   []*operation
+}
+
+// NewServer starts a fake Azure Resource Manager, which must be closed once done.
+func NewServer() *Server {
+	s := &Server{}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the fake Azure Resource Manager down.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Authorizer returns an authorizer for the clients of the Azure services to send their requests to the fake Azure
+// Resource Manager, without credentials.
+func (s *Server) Authorizer() azure.Authorizer {
+	return authorizer{baseURI: s.URL}
+}
+
+// Expect makes the fake Azure Resource Manager expect a request, answered with 200 OK and no body unless told
+// otherwise.
+func (s *Server) Expect(method, path string) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &Expectation{method: method, path: path, times: 1, status: http.StatusOK, header: http.Header{}}
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// Requests returns the requests received so far, excluding the polls of long running operations.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// Verify returns an error if a request wasn't expected, or an expected request wasn't received.
+func (s *Server) Verify() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var problems []string
+	for _, r := range s.unexpected {
+		problems = append(problems, fmt.Sprintf("unexpected request %s %s", r.Method, r.Path))
+	}
+	for _, e := range s.expectations {
+		if e.received < e.times {
+			problems = append(problems, fmt.Sprintf("expected request %s received %d times instead of %d", e, e.received, e.times))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, operationsPath) {
+		s.serveOperation(w, strings.TrimPrefix(r.URL.Path, operationsPath))
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	request := Request{Method: r.Method, Path: r.URL.Path, Body: body}
+	s.requests = append(s.requests, request)
+
+	var e *Expectation
+	for _, candidate := range s.expectations {
+		if candidate.matches(r) {
+			e = candidate
+			break
+		}
+	}
+	if e == nil {
+		s.unexpected = append(s.unexpected, request)
+		writeJSON(w, http.StatusNotImplemented, nil, armError("UnexpectedRequest", fmt.Sprintf("%s %s wasn't expected", r.Method, r.URL.Path)))
+		return
+	}
+	e.received++
+
+	header := e.header.Clone()
+	if len(e.states) > 0 {
+		s.operations = append(s.operations, &operation{expectation: e})
+		header.Set("Azure-AsyncOperation", fmt.Sprintf("%s%s%d", s.URL, operationsPath, len(s.operations)-1))
+		// The clients poll right away rather than waiting for their default polling delay.
+		header.Set("Retry-After", "0")
+	}
+	writeRaw(w, e.status, header, e.body)
+}
+
+// serveOperation answers a poll of a long running operation with its next state.
+func (s *Server) serveOperation(w http.ResponseWriter, id string) {
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 0 || i >= len(s.operations) {
+		writeJSON(w, http.StatusNotFound, nil, armError("OperationNotFound", fmt.Sprintf("operation %s doesn't exist", id)))
+		return
+	}
+
+	op := s.operations[i]
+	e := op.expectation
+	state := e.states[len(e.states)-1]
+	if op.polls < len(e.states) {
+		state = e.states[op.polls]
+	}
+	op.polls++
+
+	status := map[string]interface{}{"status": state}
+	if state == "Failed" {
+		status["error"] = map[string]string{"code": "OperationFailed", "message": "the operation failed"}
+	}
+	if state == "Succeeded" && e.method == http.MethodPut && op.polls == len(e.states) {
+		s.expectations = append(s.expectations, &Expectation{
+			method: http.MethodGet,
+			path:   e.path,
+			times:  1,
+			status: http.StatusOK,
+			body:   e.body,
+			header: http.Header{},
+		})
+	}
+	writeJSON(w, http.StatusOK, http.Header{"Retry-After": []string{"0"}}, status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, header http.Header, body interface{}) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeRaw(w, status, header, b)
+}
+
+func writeRaw(w http.ResponseWriter, status int, header http.Header, body []byte) {
+	for k, values := range header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	if body != nil {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func armError(code, message string) map[string]interface{} {
+	return map[string]interface{}{"error": map[string]string{"code": code, "message": message}}
+}
+
+// authorizer is an azure.Authorizer for the fake Azure Resource Manager.
+type authorizer struct {
+	baseURI string
+}
+
+var _ azure.Authorizer = authorizer{}
+
+func (a authorizer) SubscriptionID() string          { return SubscriptionID }
+func (a authorizer) ClientID() string                { return "" }
+func (a authorizer) ClientSecret() string            { return "" }
+func (a authorizer) CloudEnvironment() string        { return "AzurePublicCloud" }
+func (a authorizer) TenantID() string                { return "" }
+func (a authorizer) BaseURI() string                 { return a.baseURI }
+func (a authorizer) Authorizer() autorest.Authorizer { return autorest.NullAuthorizer{} }
+func (a authorizer) HashKey() string                 { return a.baseURI }
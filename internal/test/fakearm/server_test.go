@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakearm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/pointer"
+
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/publicips"
+)
+
+const publicIPPath = "/subscriptions/" + SubscriptionID + "/resourceGroups/my-rg/providers/Microsoft.Network/publicIPAddresses/my-ip"
+
+func TestServer(t *testing.T) {
+	g := NewWithT(t)
+
+	server := NewServer()
+	defer server.Close()
+	client := publicips.NewClient(server.Authorizer())
+
+	ip := network.PublicIPAddress{
+		Name:     pointer.StringPtr("my-ip"),
+		Location: pointer.StringPtr("westeurope"),
+		PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+			PublicIPAllocationMethod: network.Static,
+		},
+	}
+	server.Expect(http.MethodGet, publicIPPath).RespondError(http.StatusNotFound, "ResourceNotFound", "my-ip doesn't exist")
+	server.Expect(http.MethodPut, publicIPPath).Respond(http.StatusCreated, ip).Poll("InProgress", "InProgress", "Succeeded")
+	server.Expect(http.MethodDelete, publicIPPath).Poll("Succeeded")
+
+	_, err := client.Get(context.TODO(), "my-rg", "my-ip")
+	g.Expect(azure.ResourceNotFound(err)).To(BeTrue())
+	g.Expect(client.CreateOrUpdate(context.TODO(), "my-rg", "my-ip", ip)).To(Succeed())
+	g.Expect(client.Delete(context.TODO(), "my-rg", "my-ip")).To(Succeed())
+	g.Expect(server.Verify()).To(Succeed())
+
+	requests := server.Requests()
+	g.Expect(requests).To(HaveLen(4))
+	g.Expect(requests[1].Method).To(Equal(http.MethodPut))
+	var sent network.PublicIPAddress
+	g.Expect(json.Unmarshal(requests[1].Body, &sent)).To(Succeed())
+	g.Expect(sent.PublicIPAllocationMethod).To(Equal(network.Static))
+	// The final GET of the creation is answered with the created public IP.
+	g.Expect(requests[2].Method).To(Equal(http.MethodGet))
+	g.Expect(requests[3].Method).To(Equal(http.MethodDelete))
+}
+
+func TestServerFailedOperation(t *testing.T) {
+	g := NewWithT(t)
+
+	server := NewServer()
+	defer server.Close()
+	client := publicips.NewClient(server.Authorizer())
+
+	server.Expect(http.MethodDelete, publicIPPath).Poll("InProgress", "Failed")
+
+	g.Expect(client.Delete(context.TODO(), "my-rg", "my-ip")).To(MatchError(ContainSubstring("OperationFailed")))
+	g.Expect(server.Verify()).To(Succeed())
+}
+
+func TestServerUnexpectedRequest(t *testing.T) {
+	g := NewWithT(t)
+
+	server := NewServer()
+	defer server.Close()
+	client := publicips.NewClient(server.Authorizer())
+
+	server.Expect(http.MethodGet, publicIPPath).Times(2)
+
+	_, err := client.Get(context.TODO(), "my-rg", "my-ip")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.Delete(context.TODO(), "my-rg", "my-ip")).To(MatchError(ContainSubstring("UnexpectedRequest")))
+	g.Expect(server.Verify()).To(MatchError(And(
+		ContainSubstring("unexpected request DELETE "+publicIPPath),
+		ContainSubstring("expected request GET "+publicIPPath+" received 1 times instead of 2"),
+	)))
+}
@@ -20,9 +20,12 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	_ "net/http/pprof" //nolint
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	// +kubebuilder:scaffold:imports
@@ -31,15 +34,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/pflag"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
 	otelProm "go.opentelemetry.io/otel/exporters/metric/prometheus"
-	"go.opentelemetry.io/otel/exporters/trace/jaeger"
-	"go.opentelemetry.io/otel/sdk/resource"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
-	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/propagation"
+	"gopkg.in/natefinch/lumberjack.v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	cgrecord "k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
@@ -59,12 +61,16 @@ import (
 
 	infrav1alpha3 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha3"
 	infrav1alpha4 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/scope"
+	"sigs.k8s.io/cluster-api-provider-azure/azure/services/retailprices"
 	"sigs.k8s.io/cluster-api-provider-azure/controllers"
 	infrav1alpha3exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha3"
 	infrav1alpha4exp "sigs.k8s.io/cluster-api-provider-azure/exp/api/v1alpha4"
 	infrav1controllersexp "sigs.k8s.io/cluster-api-provider-azure/exp/controllers"
 	"sigs.k8s.io/cluster-api-provider-azure/feature"
 	"sigs.k8s.io/cluster-api-provider-azure/pkg/ot"
+	"sigs.k8s.io/cluster-api-provider-azure/util/capabilities"
 	"sigs.k8s.io/cluster-api-provider-azure/util/reconciler"
 	"sigs.k8s.io/cluster-api-provider-azure/util/tele"
 	"sigs.k8s.io/cluster-api-provider-azure/version"
@@ -118,7 +124,56 @@ var (
 	healthAddr                         string
 	webhookPort                        int
 	reconcileTimeout                   time.Duration
+	azureServiceReconcileTimeout       time.Duration
+	azureCallTimeout                   time.Duration
+	longRunningOperationMaxAge         time.Duration
+	longRunningOperationWarningAge     time.Duration
+	reconcileErrorBackoff              time.Duration
+	reconcileErrorMaxBackoff           time.Duration
+	degradedAfterFailures              int
+	specResyncPeriod                   time.Duration
+	reconcileSummaryInterval           time.Duration
+	orphanedResourcePurgeInterval      time.Duration
+	orphanedResourceMinAge             time.Duration
+	resourceHealthInterval             time.Duration
+	costEstimationInterval             time.Duration
+	costEstimationCurrency             string
+	quotaCheckInterval                 time.Duration
+	validateVMSizeCapabilities         bool
+	validateImages                     bool
 	enableTracing                      bool
+	otlpEndpoint                       string
+	otlpInsecure                       bool
+	traceSamplingRatio                 float64
+	traceNoisySpans                    []string
+	traceNoisySpanSamplingRatio        float64
+	traceResourceAttributes            map[string]string
+	enableDeveloperCredentials         bool
+	workloadIdentityServiceAccounts    []string
+	workloadIdentityAudiences          []string
+	workloadIdentityTokenFiles         []string
+	customEnvironmentsConfigMap        string
+	tagPolicyConfigMap                 string
+	azureProxyURL                      string
+	azureResourceManagerEndpoint       string
+	azureReadQPS                       float32
+	azureReadBurst                     int
+	azureWriteQPS                      float32
+	azureWriteBurst                    int
+	azureMaxRetries                    int
+	azureRetryBudget                   int
+	azurePollingInterval               time.Duration
+	azurePollBudget                    time.Duration
+	dryRun                             bool
+	logAzureRequestBodies              bool
+	azureAuditLogPath                  string
+	azureAuditLogMaxAge                int
+	azureAuditLogMaxBackups            int
+	azureAuditLogMaxSize               int
+	readOnly                           bool
+	azureHealthCheckInterval           time.Duration
+	subscriptionFilter                 []string
+	clusterSelector                    string
 )
 
 // InitFlags initializes all command-line flags.
@@ -232,6 +287,108 @@ func InitFlags(fs *pflag.FlagSet) {
 		"The maximum duration a reconcile loop can run (e.g. 90m)",
 	)
 
+	fs.DurationVar(&azureServiceReconcileTimeout,
+		"azure-service-reconcile-timeout",
+		reconciler.DefaultAzureServiceReconcileTimeout,
+		"The maximum duration the reconciliation of a single Azure service can run (e.g. 30m). Can be overridden per cluster with the azurecluster.infrastructure.cluster.x-k8s.io/service-reconcile-timeout annotation.",
+	)
+
+	fs.DurationVar(&azureCallTimeout,
+		"azure-call-timeout",
+		reconciler.DefaultAzureCallTimeout,
+		"The maximum duration a single call to Azure, including waiting for a long running operation, can run (e.g. 15m). Can be overridden per cluster with the azurecluster.infrastructure.cluster.x-k8s.io/azure-call-timeout annotation.",
+	)
+
+	fs.DurationVar(&longRunningOperationMaxAge,
+		"long-running-operation-max-age",
+		reconciler.DefaultLongRunningOperationMaxAge,
+		"The age after which a long running operation of Azure stored on an object is dropped instead of being waited for, and the resource is reconciled from its current state (e.g. 6h).",
+	)
+
+	fs.DurationVar(&longRunningOperationWarningAge,
+		"long-running-operation-warning-age",
+		reconciler.DefaultLongRunningOperationWarningAge,
+		"The age after which a long running operation of Azure stored on an object is reported as stuck, with a warning event on the object and the capz_long_running_operations_stuck metric (e.g. 1h).",
+	)
+
+	fs.DurationVar(&reconcileErrorBackoff,
+		"reconcile-error-backoff",
+		reconciler.DefaultErrorBackoff,
+		"The delay before an AzureCluster or AzureMachine is reconciled again after its reconciliation failed, doubled for every further failure in a row (e.g. 10s).",
+	)
+
+	fs.DurationVar(&reconcileErrorMaxBackoff,
+		"reconcile-error-max-backoff",
+		reconciler.DefaultMaxErrorBackoff,
+		"The maximum delay before an AzureCluster or AzureMachine whose reconciliation keeps failing is reconciled again (e.g. 30m).",
+	)
+
+	fs.IntVar(&degradedAfterFailures,
+		"degraded-after-failures",
+		reconciler.DefaultDegradedAfterFailures,
+		"The number of failed reconciliations in a row after which an AzureCluster or AzureMachine is marked with the Degraded condition.",
+	)
+
+	fs.DurationVar(&specResyncPeriod,
+		"spec-resync-period",
+		reconciler.DefaultSpecResyncPeriod,
+		"The period after which the Azure resources of a service of an AzureCluster are reconciled again although their spec didn't change, sparing the requests reading them in the meantime (e.g. 1h). 0, the default, reconciles them every time. Ignored for clusters with drift detection.",
+	)
+
+	fs.DurationVar(&reconcileSummaryInterval,
+		"reconcile-summary-interval",
+		reconciler.DefaultSummaryEventInterval,
+		"The minimum interval between the events summarizing which services of an AzureCluster were reconciled and which are pending (e.g. 10m). 0 disables these events.",
+	)
+
+	fs.DurationVar(&orphanedResourcePurgeInterval,
+		"orphaned-resource-purge-interval",
+		0,
+		"How often the Azure resources owned by a cluster which neither the cluster nor its machines refer to anymore are deleted (e.g. 1h). The purge is disabled by default.",
+	)
+
+	fs.DurationVar(&orphanedResourceMinAge,
+		"orphaned-resource-min-age",
+		time.Hour,
+		"The age under which Azure resources owned by a cluster are never purged as orphaned (e.g. 1h).",
+	)
+
+	fs.DurationVar(&resourceHealthInterval,
+		"resource-health-interval",
+		0,
+		"How often the Azure resources of a cluster are checked against Azure Resource Health, so the AzureCluster or AzureMachine owning an unavailable resource is reconciled right away (e.g. 5m). The check is disabled by default.",
+	)
+
+	fs.DurationVar(&costEstimationInterval,
+		"cost-estimation-interval",
+		0,
+		"How often the hourly cost of the virtual machines, disks, public IP addresses and load balancers of a cluster is estimated from the Azure Retail Prices API and exposed as the capz_cluster_estimated_hourly_cost metric (e.g. 10m). The estimation is disabled by default.",
+	)
+
+	fs.StringVar(&costEstimationCurrency,
+		"cost-estimation-currency",
+		retailprices.DefaultCurrency,
+		"The currency the cost of clusters is estimated in, e.g. EUR.",
+	)
+
+	fs.DurationVar(&quotaCheckInterval,
+		"quota-check-interval",
+		0,
+		"How often the compute and network quotas of the subscription of a cluster are exposed as metrics and checked against what its machines and machine pools need to scale up, setting the QuotaAvailable condition of the AzureCluster (e.g. 10m). The check is disabled by default.",
+	)
+
+	fs.BoolVar(&validateVMSizeCapabilities,
+		"validate-vm-size-capabilities",
+		true,
+		"Reject AzureMachines whose VM size isn't available in the location of their cluster, or doesn't support their options (e.g. accelerated networking, encryption at host, premium storage or their zone), using the resource SKUs of the location.",
+	)
+
+	fs.BoolVar(&validateImages,
+		"validate-images",
+		false,
+		"Reject AzureMachines whose marketplace image version isn't offered in the location of their cluster, or whose shared gallery image version doesn't exist. The check is disabled by default.",
+	)
+
 	fs.BoolVar(
 		&enableTracing,
 		"enable-tracing",
@@ -239,6 +396,230 @@ func InitFlags(fs *pflag.FlagSet) {
 		"Enable Jaeger tracing to an agent running as a sidecar to the controller.",
 	)
 
+	fs.StringVar(
+		&otlpEndpoint,
+		"otlp-endpoint",
+		"",
+		"Export traces to an OpenTelemetry collector, e.g. Tempo or Jaeger, at this host:port over OTLP/gRPC instead of a Jaeger agent sidecar. Enables tracing.",
+	)
+
+	fs.BoolVar(
+		&otlpInsecure,
+		"otlp-insecure",
+		false,
+		"Connect to the OTLP endpoint without TLS.",
+	)
+
+	fs.Float64Var(
+		&traceSamplingRatio,
+		"trace-sampling-ratio",
+		1,
+		"Ratio of the traces started by the controller which are sampled, from 0 to 1. Spans which failed are exported even if their trace isn't sampled.",
+	)
+
+	fs.StringSliceVar(
+		&traceNoisySpans,
+		"trace-noisy-spans",
+		tele.DefaultNoisySpans,
+		"Names of high-frequency spans, or prefixes of their names ending with *, which are sampled at --trace-noisy-span-sampling-ratio within sampled traces. The spans of the polls of long running operations by default.",
+	)
+
+	fs.Float64Var(
+		&traceNoisySpanSamplingRatio,
+		"trace-noisy-span-sampling-ratio",
+		1,
+		"Ratio of the noisy spans of sampled traces which are sampled, from 0 to 1. Noisy spans which failed are always exported.",
+	)
+
+	fs.StringToStringVar(
+		&traceResourceAttributes,
+		"trace-resource-attributes",
+		nil,
+		"Attributes added to the resource of all traces, e.g. management_cluster=my-mc. They may override service.name, capz by default.",
+	)
+
+	fs.BoolVar(
+		&enableDeveloperCredentials,
+		"enable-developer-credentials",
+		false,
+		"Fall back to the credentials of the Azure CLI or Azure Developer CLI when no credentials are set in the environment. Meant for local development only.",
+	)
+
+	fs.StringSliceVar(
+		&workloadIdentityServiceAccounts,
+		"workload-identity-service-accounts",
+		nil,
+		"Service accounts in the namespace of the controller, besides its own, which workload identities may mint tokens for with serviceAccountName.",
+	)
+
+	fs.StringSliceVar(
+		&workloadIdentityAudiences,
+		"workload-identity-audiences",
+		[]string{"api://AzureADTokenExchange"},
+		"Audiences workload identities may mint tokens for.",
+	)
+
+	fs.StringSliceVar(
+		&workloadIdentityTokenFiles,
+		"workload-identity-token-files",
+		nil,
+		"Paths of the projected service account tokens workload identities may read with tokenFilePath. If unspecified, workload identities can't read tokens from files.",
+	)
+
+	fs.StringVar(
+		&customEnvironmentsConfigMap,
+		"azure-environments-configmap",
+		"",
+		"ConfigMap with the environments of Azure Stack Hub or other clouds with custom endpoints, in the form namespace/name. AzureClusters select them by name in spec.azureEnvironment.",
+	)
+
+	fs.StringVar(
+		&tagPolicyConfigMap,
+		"tag-policy-configmap",
+		"",
+		"ConfigMap with the tags the additionalTags of all objects must set, in the form namespace/name. Its keys are the required tags, and its values the regular expressions their values must match. Non-compliant specs are rejected by the webhooks.",
+	)
+
+	fs.StringVar(
+		&azureProxyURL,
+		"azure-proxy-url",
+		"",
+		"URL of an HTTP proxy all requests to Azure Resource Manager and Azure AD are sent through. Requests to the Kubernetes API server don't use it.",
+	)
+
+	fs.StringVar(
+		&azureResourceManagerEndpoint,
+		"azure-resource-manager-endpoint",
+		"",
+		"Endpoint of Azure Resource Manager used for all clusters instead of the one of their environment, e.g. a private endpoint of Azure Resource Manager.",
+	)
+
+	fs.Float32Var(
+		&azureReadQPS,
+		"azure-read-qps",
+		0,
+		"Maximum rate of reads from Azure Resource Manager per subscription, shared by all clusters in the subscription. 0 disables the limit.",
+	)
+
+	fs.IntVar(
+		&azureReadBurst,
+		"azure-read-burst",
+		100,
+		"Maximum burst of reads from Azure Resource Manager per subscription, if azure-read-qps is set.",
+	)
+
+	fs.Float32Var(
+		&azureWriteQPS,
+		"azure-write-qps",
+		0,
+		"Maximum rate of writes and deletes to Azure Resource Manager per subscription, shared by all clusters in the subscription. 0 disables the limit.",
+	)
+
+	fs.IntVar(
+		&azureWriteBurst,
+		"azure-write-burst",
+		20,
+		"Maximum burst of writes and deletes to Azure Resource Manager per subscription, if azure-write-qps is set.",
+	)
+
+	fs.IntVar(
+		&azureMaxRetries,
+		"azure-max-retries",
+		azure.DefaultMaxRetries,
+		"Number of times a request to Azure Resource Manager failing with a transient error, like an internal server error, is retried with jittered backoff.",
+	)
+
+	fs.IntVar(
+		&azureRetryBudget,
+		"azure-retry-budget",
+		azure.DefaultRetryBudget,
+		"Number of retries the requests to Azure Resource Manager made while reconciling an object may make in total, after which the reconciliation fails and is requeued.",
+	)
+
+	fs.DurationVar(
+		&azurePollingInterval,
+		"azure-polling-interval",
+		0,
+		"How often long running operations of Azure Resource Manager are polled for their completion, instead of the interval Azure asks for (e.g. 5s). 0 polls at the interval Azure asks for.",
+	)
+
+	fs.DurationVar(
+		&azurePollBudget,
+		"azure-poll-budget",
+		0,
+		"The maximum duration a single long running operation of Azure Resource Manager is waited for, after which the Azure service is requeued instead of holding a worker of the controller (e.g. 2m). 0 waits until the Azure call timeout.",
+	)
+
+	fs.BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Only log the changes the controller would make to Azure resources, with their diffs, without making them. Can be enabled per cluster with the azurecluster.infrastructure.cluster.x-k8s.io/dry-run annotation.",
+	)
+
+	fs.BoolVar(
+		&logAzureRequestBodies,
+		"log-azure-request-bodies",
+		false,
+		"Log the bodies of the requests changing Azure resources and of their responses at verbosity 6 (-v=6), with secrets like custom data, passwords and SAS tokens redacted. Meant for debugging requests rejected by Azure.",
+	)
+
+	fs.StringVar(
+		&azureAuditLogPath,
+		"azure-audit-log-path",
+		"",
+		"Path of the file the controller writes an audit record of each change it requests to an Azure resource to, as JSON lines: the resource ID, operation, initiating object, result, correlation ID and timestamp. \"-\" writes the records to standard output. If unspecified, changes aren't audited.",
+	)
+
+	fs.IntVar(
+		&azureAuditLogMaxAge,
+		"azure-audit-log-maxage",
+		0,
+		"The maximum number of days to retain old audit log files, based on the timestamp encoded in their filename. 0 retains them regardless of their age.",
+	)
+
+	fs.IntVar(
+		&azureAuditLogMaxBackups,
+		"azure-audit-log-maxbackup",
+		0,
+		"The maximum number of old audit log files to retain. 0 retains all of them, up to azure-audit-log-maxage.",
+	)
+
+	fs.IntVar(
+		&azureAuditLogMaxSize,
+		"azure-audit-log-maxsize",
+		100,
+		"The maximum size in megabytes of the audit log file before it gets rotated.",
+	)
+
+	fs.BoolVar(
+		&readOnly,
+		"read-only",
+		false,
+		"Refuse all changes to Azure resources, while still reporting their observed state and drift, e.g. for identities with only the Reader role. Can be enabled per cluster with the azurecluster.infrastructure.cluster.x-k8s.io/read-only annotation.",
+	)
+
+	fs.DurationVar(
+		&azureHealthCheckInterval,
+		"azure-health-check-interval",
+		time.Minute,
+		"How often the azure health check of the health endpoint checks that the controller can obtain tokens from Azure AD and reach Azure Resource Manager (e.g. 1m). 0 disables the check.",
+	)
+
+	fs.StringSliceVar(
+		&subscriptionFilter,
+		"subscription-filter",
+		nil,
+		"Subscriptions of the clusters the controller reconciles, so that several controllers can share the clusters of the management cluster. If unspecified, the controller reconciles the clusters of all subscriptions.",
+	)
+
+	fs.StringVar(
+		&clusterSelector,
+		"cluster-selector",
+		"",
+		"Label selector of the Clusters the controller reconciles, so that several controllers can share the clusters of the management cluster (e.g. shard=a). If unspecified, the controller reconciles all clusters.",
+	)
+
 	feature.MutableGates.AddFlag(fs)
 }
 
@@ -260,6 +641,56 @@ func main() {
 
 	ctrl.SetLogger(klogr.New())
 
+	armClientOptions := azure.ARMClientOptions{
+		ResourceManagerEndpoint: azureResourceManagerEndpoint,
+		ReadRateLimit:           azure.RateLimit{QPS: azureReadQPS, Burst: azureReadBurst},
+		WriteRateLimit:          azure.RateLimit{QPS: azureWriteQPS, Burst: azureWriteBurst},
+		MaxRetries:              azureMaxRetries,
+		RetryBudget:             azureRetryBudget,
+		PollingInterval:         azurePollingInterval,
+		PollBudget:              azurePollBudget,
+		DryRun:                  dryRun,
+		ReadOnly:                readOnly,
+		LogRequestBodies:        logAzureRequestBodies,
+		AuditLog:                newAuditLog(),
+	}
+	if (azureReadQPS > 0 && azureReadBurst < 1) || (azureWriteQPS > 0 && azureWriteBurst < 1) {
+		setupLog.Error(fmt.Errorf("expected a burst of at least 1"), "invalid azure-read-burst or azure-write-burst")
+		os.Exit(1)
+	}
+	if azureProxyURL != "" {
+		proxyURL, err := url.Parse(azureProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			setupLog.Error(fmt.Errorf("expected an absolute URL"), "invalid azure-proxy-url", "value", azureProxyURL)
+			os.Exit(1)
+		}
+		armClientOptions.ProxyURL = proxyURL
+	}
+	azure.SetARMClientOptions(armClientOptions)
+	reconciler.SetAzureTimeouts(azureServiceReconcileTimeout, azureCallTimeout)
+	reconciler.SetLongRunningOperationMaxAge(longRunningOperationMaxAge)
+	reconciler.SetLongRunningOperationWarningAge(longRunningOperationWarningAge)
+	reconciler.SetErrorBackoff(reconcileErrorBackoff, reconcileErrorMaxBackoff, degradedAfterFailures)
+	reconciler.SetSpecResyncPeriod(specResyncPeriod)
+	reconciler.SetSummaryEventInterval(reconcileSummaryInterval)
+
+	shard := controllers.Shard{Subscriptions: subscriptionFilter}
+	if clusterSelector != "" {
+		selector, err := labels.Parse(clusterSelector)
+		if err != nil {
+			setupLog.Error(err, "invalid cluster-selector", "value", clusterSelector)
+			os.Exit(1)
+		}
+		shard.Selector = selector
+	}
+	controllers.SetShard(shard)
+	// Managers reconciling different shards run side by side, so each shard elects a leader of its own.
+	leaderElectionID := "controller-leader-election-capz"
+	if shard.IsSharded() {
+		leaderElectionID += "-" + shard.ID()
+		setupLog.Info("Reconciling a shard of the clusters", "subscriptions", subscriptionFilter, "selector", clusterSelector, "leader-election-id", leaderElectionID)
+	}
+
 	// Machine and cluster operations can create enough events to trigger the event recorder spam filter
 	// Setting the burst size higher ensures all events will be recorded and submitted to the API
 	broadcaster := cgrecord.NewBroadcasterWithCorrelatorOptions(cgrecord.CorrelatorOptions{
@@ -272,7 +703,7 @@ func main() {
 		Scheme:                  scheme,
 		MetricsBindAddress:      metricsAddr,
 		LeaderElection:          enableLeaderElection,
-		LeaderElectionID:        "controller-leader-election-capz",
+		LeaderElectionID:        leaderElectionID,
 		LeaderElectionNamespace: leaderElectionNamespace,
 		LeaseDuration:           &leaderElectionLeaseDuration,
 		RenewDeadline:           &leaderElectionRenewDeadline,
@@ -293,11 +724,72 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Workload identities mint service account tokens of the controller, which needs a clientset.
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to create kubernetes clientset")
+		os.Exit(1)
+	}
+	scope.SetServiceAccountsClient(clientset.CoreV1())
+	scope.SetWorkloadIdentityAllowlist(scope.WorkloadIdentityAllowlist{
+		ServiceAccounts: workloadIdentityServiceAccounts,
+		Audiences:       workloadIdentityAudiences,
+		TokenFilePaths:  workloadIdentityTokenFiles,
+	})
+
+	if enableDeveloperCredentials {
+		setupLog.Info("Falling back to credentials of developer tools, do not use in production")
+		scope.EnableDeveloperCredentials()
+	}
+
 	// Initialize event recorder.
 	record.InitFromRecorder(mgr.GetEventRecorderFor("azure-controller"))
 
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
+
+	if customEnvironmentsConfigMap != "" {
+		parts := strings.SplitN(customEnvironmentsConfigMap, "/", 2)
+		if len(parts) != 2 {
+			setupLog.Error(fmt.Errorf("expected namespace/name"), "invalid azure-environments-configmap", "value", customEnvironmentsConfigMap)
+			os.Exit(1)
+		}
+		if err := scope.LoadCustomEnvironments(ctx, clientset.CoreV1(), parts[0], parts[1]); err != nil {
+			setupLog.Error(err, "unable to load custom Azure environments")
+			os.Exit(1)
+		}
+	}
+
+	if tagPolicyConfigMap != "" {
+		parts := strings.SplitN(tagPolicyConfigMap, "/", 2)
+		if len(parts) != 2 {
+			setupLog.Error(fmt.Errorf("expected namespace/name"), "invalid tag-policy-configmap", "value", tagPolicyConfigMap)
+			os.Exit(1)
+		}
+		configMap, err := clientset.CoreV1().ConfigMaps(parts[0]).Get(ctx, parts[1], metav1.GetOptions{})
+		if err != nil {
+			setupLog.Error(err, "unable to get tag policy", "configmap", tagPolicyConfigMap)
+			os.Exit(1)
+		}
+		policy, err := infrav1alpha4.ParseTagPolicy(configMap.Data)
+		if err != nil {
+			setupLog.Error(err, "invalid tag policy", "configmap", tagPolicyConfigMap)
+			os.Exit(1)
+		}
+		infrav1alpha4.SetTagPolicy(policy)
+	}
+
+	// Tooling generating manifests discovers the optional features of the controller next to its metrics.
+	capabilities.SetFeature(capabilities.CustomEnvironments, customEnvironmentsConfigMap != "")
+	capabilities.SetFeature(capabilities.TagPolicy, tagPolicyConfigMap != "")
+	capabilities.SetFeature(capabilities.ImageValidation, validateImages)
+	capabilities.SetFeature(capabilities.VMSizeValidation, validateVMSizeCapabilities)
+	capabilities.SetFeature(capabilities.Tracing, enableTracing || otlpEndpoint != "")
+	if err := mgr.AddMetricsExtraHandler(capabilities.Path, capabilities.Handler(scheme, infrav1alpha4.GroupVersion.Group)); err != nil {
+		setupLog.Error(err, "unable to serve capabilities")
+		os.Exit(1)
+	}
+
 	registerControllers(ctx, mgr)
 	// +kubebuilder:scaffold:builder
 
@@ -316,6 +808,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The liveness probe excludes the azure check, as restarting the controller doesn't help when Azure can't be
+	// reached. It is meant for monitoring, at /healthz/azure.
+	if azureHealthCheckInterval > 0 {
+		if err := mgr.AddHealthzCheck("azure", scope.NewAzureHealthChecker(azureHealthCheckInterval).Check); err != nil {
+			setupLog.Error(err, "unable to create azure health check")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting manager", "version", version.Get().String())
 	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
@@ -324,14 +825,22 @@ func main() {
 }
 
 func registerTracing(ctx context.Context) error {
-	if !enableTracing {
+	if !enableTracing && otlpEndpoint == "" {
 		return nil
 	}
-	tp, err := jaegerTracerProvider("http://localhost:5778/api/traces")
+	tp, err := tele.NewTracerProvider(ctx, tele.ExporterOptions{
+		OTLPEndpoint:           otlpEndpoint,
+		OTLPInsecure:           otlpInsecure,
+		SamplingRatio:          traceSamplingRatio,
+		NoisySpans:             traceNoisySpans,
+		NoisySpanSamplingRatio: traceNoisySpanSamplingRatio,
+		ResourceAttributes:     traceResourceAttributes,
+	})
 	if err != nil {
 		return err
 	}
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
 	tracing.Register(ot.NewOpenTelemetryAutorestTracer(tele.Tracer()))
 	go func() {
 		<-ctx.Done()
@@ -397,6 +906,68 @@ func registerControllers(ctx context.Context, mgr manager.Manager) {
 		os.Exit(1)
 	}
 
+	if orphanedResourcePurgeInterval > 0 {
+		if err := (&controllers.AzureOrphanedResourcesReconciler{
+			Client:           mgr.GetClient(),
+			Log:              ctrl.Log.WithName("controllers").WithName("AzureOrphanedResources"),
+			Recorder:         mgr.GetEventRecorderFor("azureorphanedresources-reconciler"),
+			ReconcileTimeout: reconcileTimeout,
+			WatchFilterValue: watchFilterValue,
+			Interval:         orphanedResourcePurgeInterval,
+			MinAge:           orphanedResourceMinAge,
+		}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: azureClusterConcurrency}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AzureOrphanedResources")
+			os.Exit(1)
+		}
+	}
+
+	if resourceHealthInterval > 0 {
+		if err := (&controllers.AzureResourceHealthReconciler{
+			Client:           mgr.GetClient(),
+			Log:              ctrl.Log.WithName("controllers").WithName("AzureResourceHealth"),
+			Recorder:         mgr.GetEventRecorderFor("azureresourcehealth-reconciler"),
+			ReconcileTimeout: reconcileTimeout,
+			WatchFilterValue: watchFilterValue,
+			Interval:         resourceHealthInterval,
+		}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: azureClusterConcurrency}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AzureResourceHealth")
+			os.Exit(1)
+		}
+	}
+
+	if costEstimationInterval > 0 {
+		estimator, err := retailprices.NewEstimator(retailprices.NewClient(retailprices.DefaultBaseURI), costEstimationCurrency)
+		if err != nil {
+			setupLog.Error(err, "unable to create cost estimator")
+			os.Exit(1)
+		}
+		if err := (&controllers.AzureCostReconciler{
+			Client:           mgr.GetClient(),
+			Log:              ctrl.Log.WithName("controllers").WithName("AzureCost"),
+			ReconcileTimeout: reconcileTimeout,
+			WatchFilterValue: watchFilterValue,
+			Interval:         costEstimationInterval,
+			Estimator:        estimator,
+		}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: azureClusterConcurrency}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AzureCost")
+			os.Exit(1)
+		}
+	}
+
+	if quotaCheckInterval > 0 {
+		if err := (&controllers.AzureQuotaReconciler{
+			Client:           mgr.GetClient(),
+			Log:              ctrl.Log.WithName("controllers").WithName("AzureQuota"),
+			Recorder:         mgr.GetEventRecorderFor("azurequota-reconciler"),
+			ReconcileTimeout: reconcileTimeout,
+			WatchFilterValue: watchFilterValue,
+			Interval:         quotaCheckInterval,
+		}).SetupWithManager(ctx, mgr, controller.Options{MaxConcurrentReconciles: azureClusterConcurrency}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AzureQuota")
+			os.Exit(1)
+		}
+	}
+
 	// just use CAPI MachinePool feature flag rather than create a new one
 	setupLog.V(1).Info(fmt.Sprintf("%+v\n", feature.Gates))
 	if feature.Gates.Enabled(capifeature.MachinePool) {
@@ -482,6 +1053,13 @@ func registerControllers(ctx context.Context, mgr manager.Manager) {
 		os.Exit(1)
 	}
 
+	if validateVMSizeCapabilities {
+		infrav1alpha4.SetVMSizeCapabilitiesGetter(&controllers.VMSizeCapabilitiesGetter{Client: mgr.GetClient()})
+	}
+	if validateImages {
+		infrav1alpha4.SetImageChecker(&controllers.ImageChecker{Client: mgr.GetClient()})
+	}
+
 	if err := (&infrav1alpha4.AzureMachine{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "AzureMachine")
 		os.Exit(1)
@@ -509,6 +1087,11 @@ func registerControllers(ctx context.Context, mgr manager.Manager) {
 			setupLog.Error(err, "unable to create webhook", "webhook", "AzureManagedControlPlane")
 			os.Exit(1)
 		}
+
+		if err := (&infrav1alpha4exp.AzureManagedMachinePool{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "AzureManagedMachinePool")
+			os.Exit(1)
+		}
 	}
 
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
@@ -537,20 +1120,19 @@ func initPrometheusMetrics() error {
 	return err
 }
 
-// jaegerTracerProvider creates a jaeger tracing provider.
-func jaegerTracerProvider(url string) (*tracesdk.TracerProvider, error) {
-	exp, err := jaeger.NewRawExporter(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
-	if err != nil {
-		return nil, err
-	}
-	tp := tracesdk.NewTracerProvider(
-		// Always be sure to batch in production.
-		tracesdk.WithBatcher(exp),
-		// Record information about this application in an Resource.
-		tracesdk.WithResource(resource.NewWithAttributes(
-			semconv.ServiceNameKey.String("capz"),
-			attribute.String("exporter", "jaeger"),
-		)),
-	)
-	return tp, nil
+// newAuditLog returns the writer of the audit log of the changes to Azure resources, rotated and retained as
+// configured, or nil if it is disabled.
+func newAuditLog() io.Writer {
+	switch azureAuditLogPath {
+	case "":
+		return nil
+	case "-":
+		return os.Stdout
+	}
+	return &lumberjack.Logger{
+		Filename:   azureAuditLogPath,
+		MaxAge:     azureAuditLogMaxAge,
+		MaxBackups: azureAuditLogMaxBackups,
+		MaxSize:    azureAuditLogMaxSize,
+	}
 }
@@ -0,0 +1,375 @@
+// +build e2e
+
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BYONetwork is a network created outside of the provider for a workload cluster, named after the defaults of the
+// AzureCluster so that the default flavor uses it as is.
+type BYONetwork struct {
+	ResourceGroup      string
+	VNetName           string
+	SubnetNames        []string
+	SecurityGroupNames []string
+	RouteTableName     string
+
+	// state is the configuration of the network right after creating it.
+	state byoNetworkState
+}
+
+// byoNetworkState is the configuration of a BYONetwork which the provider must leave untouched.
+type byoNetworkState struct {
+	VNetAddressPrefixes []string
+	VNetTags            map[string]string
+	Subnets             map[string]byoSubnetState
+	SecurityGroups      map[string]byoSecurityGroupState
+	RouteTableRoutes    []string
+	RouteTableTags      map[string]string
+}
+
+type byoSubnetState struct {
+	AddressPrefix   string
+	SecurityGroupID string
+	RouteTableID    string
+}
+
+type byoSecurityGroupState struct {
+	Rules []string
+	Tags  map[string]string
+}
+
+// AzureBYONetworkSpecInput is the input for AzureBYONetworkSpec.
+type AzureBYONetworkSpecInput struct {
+	BootstrapClusterProxy framework.ClusterProxy
+	Namespace             *corev1.Namespace
+	ClusterName           string
+	Network               BYONetwork
+	ArtifactFolder        string
+	DeleteClusterTimeouts []interface{}
+	SkipCleanup           bool
+}
+
+// AzureBYONetworkSpec implements a test that verifies the provider only associates a workload cluster with a network
+// created outside of it, possibly in another resource group, and neither mutates nor deletes any of its resources,
+// including when the cluster is deleted. The cluster is deleted by the spec.
+func AzureBYONetworkSpec(ctx context.Context, inputGetter func() AzureBYONetworkSpecInput) {
+	var (
+		specName = "azure-byo-network"
+		input    AzureBYONetworkSpecInput
+	)
+
+	input = inputGetter()
+	Expect(input.BootstrapClusterProxy).NotTo(BeNil(), "Invalid argument. input.BootstrapClusterProxy can't be nil when calling %s spec", specName)
+	Expect(input.Namespace).NotTo(BeNil(), "Invalid argument. input.Namespace can't be nil when calling %s spec", specName)
+	Expect(input.ClusterName).NotTo(BeEmpty(), "Invalid argument. input.ClusterName can't be empty when calling %s spec", specName)
+	Expect(input.Network.VNetName).NotTo(BeEmpty(), "Invalid argument. input.Network can't be empty when calling %s spec", specName)
+
+	mgmtClient := input.BootstrapClusterProxy.GetClient()
+	cluster := &clusterv1.Cluster{}
+	Expect(mgmtClient.Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: input.ClusterName}, cluster)).To(Succeed())
+	azureCluster := &v1alpha4.AzureCluster{}
+	Expect(mgmtClient.Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: cluster.Spec.InfrastructureRef.Name}, azureCluster)).To(Succeed())
+
+	By("verifying the AzureCluster uses the existing virtual network")
+	Expect(azureCluster.Spec.NetworkSpec.Vnet.Name).To(Equal(input.Network.VNetName))
+	Expect(azureCluster.Spec.NetworkSpec.Vnet.ResourceGroup).To(Equal(input.Network.ResourceGroup))
+	Expect(azureCluster.Spec.NetworkSpec.Vnet.IsManaged(input.ClusterName)).To(BeFalse())
+
+	settings, err := auth.GetSettingsFromEnvironment()
+	Expect(err).NotTo(HaveOccurred())
+	authorizer, err := settings.GetAuthorizer()
+	Expect(err).NotTo(HaveOccurred())
+
+	By("verifying the existing network was left untouched while creating the cluster")
+	Expect(byoNetworkSnapshot(ctx, settings.GetSubscriptionID(), authorizer, input.Network)).To(Equal(input.Network.state))
+
+	By("verifying the network interfaces of the cluster joined the subnets of the existing network")
+	vnetClient := network.NewVirtualNetworksClient(settings.GetSubscriptionID())
+	vnetClient.Authorizer = authorizer
+	vnet, err := vnetClient.Get(ctx, input.Network.ResourceGroup, input.Network.VNetName, "")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(vnet.Subnets).NotTo(BeNil())
+	clusterResourceGroup := strings.ToLower(fmt.Sprintf("/resourceGroups/%s/", azureCluster.Spec.ResourceGroup))
+	for _, subnet := range *vnet.Subnets {
+		var joined bool
+		if subnet.IPConfigurations != nil {
+			for _, ipConfig := range *subnet.IPConfigurations {
+				joined = joined || strings.Contains(strings.ToLower(pointer.StringDeref(ipConfig.ID, "")), clusterResourceGroup)
+			}
+		}
+		Expect(joined).To(BeTrue(), "no network interface of resource group %s joined subnet %s", azureCluster.Spec.ResourceGroup, pointer.StringDeref(subnet.Name, ""))
+	}
+
+	Byf("dumping logs from the %q workload cluster before deleting it", input.ClusterName)
+	input.BootstrapClusterProxy.CollectWorkloadClusterLogs(ctx, input.Namespace.Name, input.ClusterName, filepath.Join(input.ArtifactFolder, "clusters", input.ClusterName))
+
+	Byf("deleting the %q workload cluster", input.ClusterName)
+	framework.DeleteClusterAndWait(ctx, framework.DeleteClusterAndWaitInput{
+		Client:  mgmtClient,
+		Cluster: cluster,
+	}, input.DeleteClusterTimeouts...)
+
+	By("verifying the existing network was left untouched while deleting the cluster")
+	Expect(byoNetworkSnapshot(ctx, settings.GetSubscriptionID(), authorizer, input.Network)).To(Equal(input.Network.state))
+
+	if input.SkipCleanup {
+		return
+	}
+
+	Byf("deleting resource group %s of the existing network", input.Network.ResourceGroup)
+	groupsClient := resources.NewGroupsClient(settings.GetSubscriptionID())
+	groupsClient.Authorizer = authorizer
+	groupFuture, err := groupsClient.Delete(ctx, input.Network.ResourceGroup)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(groupFuture.WaitForCompletionRef(ctx, groupsClient.Client)).To(Succeed())
+}
+
+// SetupBYONetwork creates a resource group, network security groups, a route table and a VNet with subnets associated
+// with them, using the names the AzureCluster of the default flavor defaults to. The resource group can be the one of
+// the cluster, or another one.
+func SetupBYONetwork(ctx context.Context, clusterName, resourceGroup string) BYONetwork {
+	By("creating Azure clients with the workload cluster's subscription")
+	settings, err := auth.GetSettingsFromEnvironment()
+	Expect(err).NotTo(HaveOccurred())
+	subscriptionID := settings.GetSubscriptionID()
+	authorizer, err := settings.GetAuthorizer()
+	Expect(err).NotTo(HaveOccurred())
+	groupsClient := resources.NewGroupsClient(subscriptionID)
+	groupsClient.Authorizer = authorizer
+	securityGroupsClient := network.NewSecurityGroupsClient(subscriptionID)
+	securityGroupsClient.Authorizer = authorizer
+	routeTablesClient := network.NewRouteTablesClient(subscriptionID)
+	routeTablesClient.Authorizer = authorizer
+	vnetClient := network.NewVirtualNetworksClient(subscriptionID)
+	vnetClient.Authorizer = authorizer
+
+	location := os.Getenv(AzureLocation)
+	byo := BYONetwork{
+		ResourceGroup: resourceGroup,
+		VNetName:      os.Getenv(AzureVNetName),
+		SubnetNames: []string{
+			fmt.Sprintf("%s-controlplane-subnet", clusterName),
+			fmt.Sprintf("%s-node-subnet", clusterName),
+		},
+		SecurityGroupNames: []string{
+			fmt.Sprintf("%s-controlplane-nsg", clusterName),
+			fmt.Sprintf("%s-node-nsg", clusterName),
+		},
+		RouteTableName: fmt.Sprintf("%s-node-routetable", clusterName),
+	}
+	tags := map[string]*string{
+		"jobName":           pointer.StringPtr(os.Getenv(JobName)),
+		"creationTimestamp": pointer.StringPtr(os.Getenv(Timestamp)),
+	}
+
+	Byf("creating resource group %s", byo.ResourceGroup)
+	_, err = groupsClient.CreateOrUpdate(ctx, byo.ResourceGroup, resources.Group{
+		Location: pointer.StringPtr(location),
+		Tags:     tags,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	controlPlaneRules := []network.SecurityRule{
+		{
+			Name: pointer.StringPtr("allow_ssh"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Description:              pointer.StringPtr("Allow SSH"),
+				Priority:                 pointer.Int32Ptr(2200),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				Access:                   network.SecurityRuleAccessAllow,
+				Direction:                network.SecurityRuleDirectionInbound,
+				SourceAddressPrefix:      pointer.StringPtr("*"),
+				SourcePortRange:          pointer.StringPtr("*"),
+				DestinationAddressPrefix: pointer.StringPtr("*"),
+				DestinationPortRange:     pointer.StringPtr("22"),
+			},
+		},
+		{
+			Name: pointer.StringPtr("allow_apiserver"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Description:              pointer.StringPtr("Allow API Server"),
+				Priority:                 pointer.Int32Ptr(2201),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				Access:                   network.SecurityRuleAccessAllow,
+				Direction:                network.SecurityRuleDirectionInbound,
+				SourceAddressPrefix:      pointer.StringPtr("*"),
+				SourcePortRange:          pointer.StringPtr("*"),
+				DestinationAddressPrefix: pointer.StringPtr("*"),
+				DestinationPortRange:     pointer.StringPtr("6443"),
+			},
+		},
+	}
+	securityGroupRules := map[string][]network.SecurityRule{
+		byo.SecurityGroupNames[0]: controlPlaneRules,
+		byo.SecurityGroupNames[1]: {},
+	}
+	securityGroups := map[string]network.SecurityGroup{}
+	for _, name := range byo.SecurityGroupNames {
+		rules := securityGroupRules[name]
+		Byf("creating network security group %s", name)
+		future, err := securityGroupsClient.CreateOrUpdate(ctx, byo.ResourceGroup, name, network.SecurityGroup{
+			Location: pointer.StringPtr(location),
+			Tags:     tags,
+			SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+				SecurityRules: &rules,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(future.WaitForCompletionRef(ctx, securityGroupsClient.Client)).To(Succeed())
+		securityGroups[name], err = future.Result(securityGroupsClient)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	Byf("creating route table %s", byo.RouteTableName)
+	routeTableFuture, err := routeTablesClient.CreateOrUpdate(ctx, byo.ResourceGroup, byo.RouteTableName, network.RouteTable{
+		Location:                   pointer.StringPtr(location),
+		Tags:                       tags,
+		RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(routeTableFuture.WaitForCompletionRef(ctx, routeTablesClient.Client)).To(Succeed())
+	routeTable, err := routeTableFuture.Result(routeTablesClient)
+	Expect(err).NotTo(HaveOccurred())
+
+	Byf("creating virtual network %s", byo.VNetName)
+	subnets := []network.Subnet{
+		{
+			Name: pointer.StringPtr(byo.SubnetNames[0]),
+			SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+				AddressPrefix:        pointer.StringPtr(v1alpha4.DefaultControlPlaneSubnetCIDR),
+				NetworkSecurityGroup: &network.SecurityGroup{ID: securityGroups[byo.SecurityGroupNames[0]].ID},
+			},
+		},
+		{
+			Name: pointer.StringPtr(byo.SubnetNames[1]),
+			SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+				AddressPrefix:        pointer.StringPtr(v1alpha4.DefaultNodeSubnetCIDR),
+				NetworkSecurityGroup: &network.SecurityGroup{ID: securityGroups[byo.SecurityGroupNames[1]].ID},
+				RouteTable:           &network.RouteTable{ID: routeTable.ID},
+			},
+		},
+	}
+	vnetFuture, err := vnetClient.CreateOrUpdate(ctx, byo.ResourceGroup, byo.VNetName, network.VirtualNetwork{
+		Location: pointer.StringPtr(location),
+		Tags:     tags,
+		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+			AddressSpace: &network.AddressSpace{
+				AddressPrefixes: &[]string{v1alpha4.DefaultVnetCIDR},
+			},
+			Subnets: &subnets,
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(vnetFuture.WaitForCompletionRef(ctx, vnetClient.Client)).To(Succeed())
+
+	byo.state = byoNetworkSnapshot(ctx, subscriptionID, authorizer, byo)
+	return byo
+}
+
+// byoNetworkSnapshot gets the configuration of the resources of a BYONetwork. Etags aren't used, as the NICs of the
+// cluster joining the subnets may change them without the provider updating the network.
+func byoNetworkSnapshot(ctx context.Context, subscriptionID string, authorizer autorest.Authorizer, byo BYONetwork) byoNetworkState {
+	vnetClient := network.NewVirtualNetworksClient(subscriptionID)
+	vnetClient.Authorizer = authorizer
+	securityGroupsClient := network.NewSecurityGroupsClient(subscriptionID)
+	securityGroupsClient.Authorizer = authorizer
+	routeTablesClient := network.NewRouteTablesClient(subscriptionID)
+	routeTablesClient.Authorizer = authorizer
+
+	state := byoNetworkState{
+		Subnets:        map[string]byoSubnetState{},
+		SecurityGroups: map[string]byoSecurityGroupState{},
+	}
+
+	vnet, err := vnetClient.Get(ctx, byo.ResourceGroup, byo.VNetName, "")
+	Expect(err).NotTo(HaveOccurred())
+	state.VNetTags = toStringMap(vnet.Tags)
+	if vnet.AddressSpace != nil && vnet.AddressSpace.AddressPrefixes != nil {
+		state.VNetAddressPrefixes = *vnet.AddressSpace.AddressPrefixes
+	}
+	if vnet.Subnets != nil {
+		for _, subnet := range *vnet.Subnets {
+			var s byoSubnetState
+			if subnet.AddressPrefix != nil {
+				s.AddressPrefix = *subnet.AddressPrefix
+			}
+			if subnet.NetworkSecurityGroup != nil && subnet.NetworkSecurityGroup.ID != nil {
+				s.SecurityGroupID = *subnet.NetworkSecurityGroup.ID
+			}
+			if subnet.RouteTable != nil && subnet.RouteTable.ID != nil {
+				s.RouteTableID = *subnet.RouteTable.ID
+			}
+			state.Subnets[pointer.StringDeref(subnet.Name, "")] = s
+		}
+	}
+
+	for _, name := range byo.SecurityGroupNames {
+		securityGroup, err := securityGroupsClient.Get(ctx, byo.ResourceGroup, name, "")
+		Expect(err).NotTo(HaveOccurred())
+		s := byoSecurityGroupState{Rules: []string{}, Tags: toStringMap(securityGroup.Tags)}
+		if securityGroup.SecurityRules != nil {
+			for _, rule := range *securityGroup.SecurityRules {
+				s.Rules = append(s.Rules, pointer.StringDeref(rule.Name, ""))
+			}
+		}
+		sort.Strings(s.Rules)
+		state.SecurityGroups[name] = s
+	}
+
+	routeTable, err := routeTablesClient.Get(ctx, byo.ResourceGroup, byo.RouteTableName, "")
+	Expect(err).NotTo(HaveOccurred())
+	state.RouteTableTags = toStringMap(routeTable.Tags)
+	state.RouteTableRoutes = []string{}
+	if routeTable.Routes != nil {
+		for _, route := range *routeTable.Routes {
+			state.RouteTableRoutes = append(state.RouteTableRoutes, pointer.StringDeref(route.Name, ""))
+		}
+	}
+	sort.Strings(state.RouteTableRoutes)
+
+	return state
+}
+
+func toStringMap(tags map[string]*string) map[string]string {
+	m := make(map[string]string, len(tags))
+	for k, v := range tags {
+		m[k] = pointer.StringDeref(v, "")
+	}
+	return m
+}
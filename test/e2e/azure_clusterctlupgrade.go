@@ -0,0 +1,342 @@
+// +build e2e
+
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-05-01/resources"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	clusterv1old "sigs.k8s.io/cluster-api/api/v1alpha3"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	capi_e2e "sigs.k8s.io/cluster-api/test/e2e"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const initWithBinaryVariableName = "INIT_WITH_BINARY"
+
+// AzureClusterctlUpgradeSpecInput is the input for AzureClusterctlUpgradeSpec.
+type AzureClusterctlUpgradeSpecInput struct {
+	E2EConfig             *clusterctl.E2EConfig
+	ClusterctlConfigPath  string
+	BootstrapClusterProxy framework.ClusterProxy
+	ArtifactFolder        string
+	SkipCleanup           bool
+}
+
+// AzureClusterctlUpgradeSpec implements a test that creates a workload cluster with the previous provider release,
+// upgrades the providers and CRDs with clusterctl, and verifies that no Azure resource of the workload cluster is
+// recreated or deleted, that no Machine is replaced and that the cluster stays Ready.
+func AzureClusterctlUpgradeSpec(ctx context.Context, inputGetter func() AzureClusterctlUpgradeSpecInput) {
+	var (
+		specName = "azure-clusterctl-upgrade"
+		input    AzureClusterctlUpgradeSpecInput
+
+		managementClusterNamespace     *corev1.Namespace
+		managementClusterCancelWatches context.CancelFunc
+		managementClusterResources     *clusterctl.ApplyClusterTemplateAndWaitResult
+		managementClusterProxy         framework.ClusterProxy
+
+		testNamespace     *corev1.Namespace
+		testCancelWatches context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		Expect(ctx).NotTo(BeNil(), "ctx is required for %s spec", specName)
+		input = inputGetter()
+		Expect(input.E2EConfig).NotTo(BeNil(), "Invalid argument. input.E2EConfig can't be nil when calling %s spec", specName)
+		Expect(input.ClusterctlConfigPath).To(BeAnExistingFile(), "Invalid argument. input.ClusterctlConfigPath must be an existing file when calling %s spec", specName)
+		Expect(input.BootstrapClusterProxy).NotTo(BeNil(), "Invalid argument. input.BootstrapClusterProxy can't be nil when calling %s spec", specName)
+		Expect(input.E2EConfig.Variables).To(HaveKey(initWithBinaryVariableName), "Invalid argument. %s variable must be defined when calling %s spec", initWithBinaryVariableName, specName)
+		Expect(input.E2EConfig.Variables).To(HaveKey(capi_e2e.KubernetesVersion))
+		Expect(os.MkdirAll(input.ArtifactFolder, 0755)).To(Succeed(), "Invalid argument. input.ArtifactFolder can't be created for %s spec", specName)
+
+		var err error
+		managementClusterNamespace, managementClusterCancelWatches, err = setupSpecNamespace(ctx, specName, input.BootstrapClusterProxy, input.ArtifactFolder)
+		Expect(err).NotTo(HaveOccurred())
+		managementClusterResources = new(clusterctl.ApplyClusterTemplateAndWaitResult)
+	})
+
+	It("Should upgrade the providers without changing the Azure resources of a workload cluster", func() {
+		By("Creating a workload cluster to be used as a management cluster with the previous provider release")
+		// the bootstrap cluster is shared by the other specs and already runs the current providers
+		managementClusterName := fmt.Sprintf("capz-e2e-%s", util.RandomString(6))
+		clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
+			ClusterProxy: input.BootstrapClusterProxy,
+			ConfigCluster: clusterctl.ConfigClusterInput{
+				LogFolder:                filepath.Join(input.ArtifactFolder, "clusters", input.BootstrapClusterProxy.GetName()),
+				ClusterctlConfigPath:     input.ClusterctlConfigPath,
+				KubeconfigPath:           input.BootstrapClusterProxy.GetKubeconfigPath(),
+				InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+				Flavor:                   clusterctl.DefaultFlavor,
+				Namespace:                managementClusterNamespace.Name,
+				ClusterName:              managementClusterName,
+				KubernetesVersion:        input.E2EConfig.GetVariable(capi_e2e.KubernetesVersion),
+				ControlPlaneMachineCount: pointer.Int64Ptr(1),
+				WorkerMachineCount:       pointer.Int64Ptr(1),
+			},
+			WaitForClusterIntervals:      input.E2EConfig.GetIntervals(specName, "wait-cluster"),
+			WaitForControlPlaneIntervals: input.E2EConfig.GetIntervals(specName, "wait-control-plane"),
+			WaitForMachineDeployments:    input.E2EConfig.GetIntervals(specName, "wait-worker-nodes"),
+		}, managementClusterResources)
+		cluster := managementClusterResources.Cluster
+		managementClusterProxy = input.BootstrapClusterProxy.GetWorkloadCluster(ctx, cluster.Namespace, cluster.Name)
+
+		By("Downloading the clusterctl binary of the previous release")
+		clusterctlBinaryPath := downloadClusterctl(input.E2EConfig.GetVariable(initWithBinaryVariableName))
+		defer os.Remove(clusterctlBinaryPath)
+
+		By("Initializing the management cluster with the previous provider release")
+		clusterctl.InitManagementClusterAndWatchControllerLogs(ctx, clusterctl.InitManagementClusterAndWatchControllerLogsInput{
+			ClusterctlBinaryPath:    clusterctlBinaryPath,
+			ClusterProxy:            managementClusterProxy,
+			ClusterctlConfigPath:    input.ClusterctlConfigPath,
+			CoreProvider:            input.E2EConfig.GetProvidersWithOldestVersion(config.ClusterAPIProviderName)[0],
+			BootstrapProviders:      input.E2EConfig.GetProvidersWithOldestVersion(config.KubeadmBootstrapProviderName),
+			ControlPlaneProviders:   input.E2EConfig.GetProvidersWithOldestVersion(config.KubeadmControlPlaneProviderName),
+			InfrastructureProviders: input.E2EConfig.GetProvidersWithOldestVersion(input.E2EConfig.InfrastructureProviders()...),
+			LogFolder:               filepath.Join(input.ArtifactFolder, "clusters", cluster.Name),
+		}, input.E2EConfig.GetIntervals(specName, "wait-controllers")...)
+
+		testNamespace, testCancelWatches = framework.CreateNamespaceAndWatchEvents(ctx, framework.CreateNamespaceAndWatchEventsInput{
+			Creator:   managementClusterProxy.GetClient(),
+			ClientSet: managementClusterProxy.GetClientSet(),
+			Name:      specName,
+			LogFolder: filepath.Join(input.ArtifactFolder, "clusters", cluster.Name),
+		})
+
+		By("Creating a workload cluster with the previous provider release")
+		// the current API helpers can't be used before the upgrade, so the template of the previous release is
+		// generated with its clusterctl binary and applied as is.
+		workloadClusterName := fmt.Sprintf("capz-e2e-%s", util.RandomString(6))
+		controlPlaneMachineCount := pointer.Int64Ptr(1)
+		workerMachineCount := pointer.Int64Ptr(1)
+		// the workload cluster gets a resource group of its own, so that its Azure resources can be listed apart
+		// from the ones of the management cluster.
+		managementClusterResourceGroup := os.Getenv(AzureResourceGroup)
+		Expect(os.Setenv(AzureResourceGroup, workloadClusterName)).To(Succeed())
+		workloadClusterTemplate := clusterctl.ConfigClusterWithBinary(ctx, clusterctlBinaryPath, clusterctl.ConfigClusterInput{
+			KubeconfigPath:           managementClusterProxy.GetKubeconfigPath(),
+			ClusterctlConfigPath:     input.ClusterctlConfigPath,
+			Flavor:                   clusterctl.DefaultFlavor,
+			Namespace:                testNamespace.Name,
+			ClusterName:              workloadClusterName,
+			KubernetesVersion:        input.E2EConfig.GetVariable(capi_e2e.KubernetesVersion),
+			ControlPlaneMachineCount: controlPlaneMachineCount,
+			WorkerMachineCount:       workerMachineCount,
+			InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+			LogFolder:                filepath.Join(input.ArtifactFolder, "clusters", managementClusterProxy.GetName()),
+		})
+		Expect(os.Setenv(AzureResourceGroup, managementClusterResourceGroup)).To(Succeed())
+		Expect(workloadClusterTemplate).NotTo(BeNil(), "Failed to get the cluster template")
+		Expect(managementClusterProxy.Apply(ctx, workloadClusterTemplate)).To(Succeed())
+
+		mgmtClient := managementClusterProxy.GetClient()
+		clusterKey := client.ObjectKey{Namespace: testNamespace.Name, Name: workloadClusterName}
+
+		By("Waiting for the workload cluster to be Ready with the previous provider release")
+		Eventually(func() ([]string, error) {
+			return getProvisionedMachineNames(ctx, mgmtClient, testNamespace.Name, workloadClusterName)
+		}, input.E2EConfig.GetIntervals(specName, "wait-worker-nodes")...).Should(HaveLen(int(*controlPlaneMachineCount + *workerMachineCount)))
+		Eventually(func() bool {
+			oldCluster := &clusterv1old.Cluster{}
+			if err := mgmtClient.Get(ctx, clusterKey, oldCluster); err != nil {
+				return false
+			}
+			for _, c := range oldCluster.Status.Conditions {
+				if c.Type == clusterv1old.ReadyCondition {
+					return c.Status == corev1.ConditionTrue
+				}
+			}
+			return false
+		}, input.E2EConfig.GetIntervals(specName, "wait-cluster")...).Should(BeTrue())
+
+		By("Recording the Machines and Azure resources of the workload cluster")
+		machineNames, err := getProvisionedMachineNames(ctx, mgmtClient, testNamespace.Name, workloadClusterName)
+		Expect(err).NotTo(HaveOccurred())
+		settings, err := auth.GetSettingsFromEnvironment()
+		Expect(err).NotTo(HaveOccurred())
+		resourcesClient := resources.NewClient(settings.GetSubscriptionID())
+		resourcesClient.Authorizer, err = settings.GetAuthorizer()
+		Expect(err).NotTo(HaveOccurred())
+		resourceCreationTimes := getResourceCreationTimes(ctx, resourcesClient, workloadClusterName)
+		Expect(resourceCreationTimes).NotTo(BeEmpty())
+
+		By("Upgrading the providers and CRDs to the current release")
+		clusterctl.UpgradeManagementClusterAndWait(ctx, clusterctl.UpgradeManagementClusterAndWaitInput{
+			ClusterctlConfigPath: input.ClusterctlConfigPath,
+			ClusterProxy:         managementClusterProxy,
+			Contract:             clusterv1.GroupVersion.Version,
+			LogFolder:            filepath.Join(input.ArtifactFolder, "clusters", cluster.Name),
+		}, input.E2EConfig.GetIntervals(specName, "wait-controllers")...)
+
+		By("Verifying that the workload cluster stays Ready after the upgrade")
+		Consistently(func() error {
+			return getClusterNotReadyError(ctx, mgmtClient, testNamespace.Name, workloadClusterName)
+		}, input.E2EConfig.GetIntervals(specName, "wait-stable")...).Should(Succeed())
+
+		By("Verifying that no Machine of the workload cluster was replaced")
+		Expect(getProvisionedMachineNames(ctx, mgmtClient, testNamespace.Name, workloadClusterName)).To(Equal(machineNames))
+
+		By("Verifying that no Azure resource of the workload cluster was recreated or deleted")
+		Expect(getResourceCreationTimes(ctx, resourcesClient, workloadClusterName)).To(Equal(resourceCreationTimes))
+	})
+
+	AfterEach(func() {
+		if testNamespace != nil {
+			framework.DumpAllResources(ctx, framework.DumpAllResourcesInput{
+				Lister:    managementClusterProxy.GetClient(),
+				Namespace: testNamespace.Name,
+				LogPath:   filepath.Join(input.ArtifactFolder, "clusters", managementClusterResources.Cluster.Name, "resources"),
+			})
+
+			if !input.SkipCleanup {
+				Byf("Deleting the workload clusters in namespace %s", testNamespace.Name)
+				framework.DeleteAllClustersAndWait(ctx, framework.DeleteAllClustersAndWaitInput{
+					Client:    managementClusterProxy.GetClient(),
+					Namespace: testNamespace.Name,
+				}, input.E2EConfig.GetIntervals(specName, "wait-delete-cluster")...)
+
+				framework.DeleteNamespace(ctx, framework.DeleteNamespaceInput{
+					Deleter: managementClusterProxy.GetClient(),
+					Name:    testNamespace.Name,
+				})
+			}
+			testCancelWatches()
+		}
+
+		dumpSpecResourcesAndCleanup(ctx, specName, input.BootstrapClusterProxy, input.ArtifactFolder, managementClusterNamespace, managementClusterCancelWatches, managementClusterResources.Cluster, input.E2EConfig.GetIntervals, input.SkipCleanup)
+	})
+}
+
+// downloadClusterctl downloads the clusterctl binary for the current platform from the given URL, where {OS} and
+// {ARCH} stand for the platform, and returns the path of the executable.
+func downloadClusterctl(url string) string {
+	url = strings.ReplaceAll(url, "{OS}", runtime.GOOS)
+	url = strings.ReplaceAll(url, "{ARCH}", runtime.GOARCH)
+	Byf("downloading clusterctl from %s", url)
+
+	tmpFile, err := ioutil.TempFile("", "clusterctl")
+	Expect(err).NotTo(HaveOccurred())
+	defer tmpFile.Close()
+
+	resp, err := http.Get(url) //nolint:gosec
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	_, err = io.Copy(tmpFile, resp.Body)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(os.Chmod(tmpFile.Name(), 0744)).To(Succeed())
+	return tmpFile.Name()
+}
+
+// getProvisionedMachineNames returns the sorted names of the Machines of a cluster that have a Node.
+// It lists the Machines with the v1alpha3 API, which is served both before and after the upgrade.
+func getProvisionedMachineNames(ctx context.Context, c client.Client, namespace, clusterName string) ([]string, error) {
+	machineList := &clusterv1old.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, machine := range machineList.Items {
+		if machine.Status.NodeRef != nil {
+			names = append(names, machine.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// getClusterNotReadyError returns an error naming the first of the Cluster, its AzureCluster, Machines and
+// AzureMachines that isn't Ready.
+func getClusterNotReadyError(ctx context.Context, c client.Client, namespace, clusterName string) error {
+	cluster := &clusterv1.Cluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: clusterName}, cluster); err != nil {
+		return err
+	}
+	if !conditions.IsTrue(cluster, clusterv1.ReadyCondition) {
+		return errors.Errorf("Cluster %s is not Ready", cluster.Name)
+	}
+
+	azureCluster := &infrav1.AzureCluster{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: cluster.Spec.InfrastructureRef.Name}, azureCluster); err != nil {
+		return err
+	}
+	if !conditions.IsTrue(azureCluster, clusterv1.ReadyCondition) {
+		return errors.Errorf("AzureCluster %s is not Ready", azureCluster.Name)
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := c.List(ctx, machineList, client.InNamespace(namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		return err
+	}
+	for i := range machineList.Items {
+		if !conditions.IsTrue(&machineList.Items[i], clusterv1.ReadyCondition) {
+			return errors.Errorf("Machine %s is not Ready", machineList.Items[i].Name)
+		}
+	}
+
+	azureMachineList := &infrav1.AzureMachineList{}
+	if err := c.List(ctx, azureMachineList, client.InNamespace(namespace), client.MatchingLabels{clusterv1.ClusterLabelName: clusterName}); err != nil {
+		return err
+	}
+	for i := range azureMachineList.Items {
+		if !conditions.IsTrue(&azureMachineList.Items[i], clusterv1.ReadyCondition) {
+			return errors.Errorf("AzureMachine %s is not Ready", azureMachineList.Items[i].Name)
+		}
+	}
+	return nil
+}
+
+// getResourceCreationTimes returns the creation times of the Azure resources in a resource group, by lower case
+// resource ID. A resource that is recreated keeps its ID, but gets a new creation time.
+func getResourceCreationTimes(ctx context.Context, resourcesClient resources.Client, resourceGroup string) map[string]string {
+	creationTimes := map[string]string{}
+	iter, err := resourcesClient.ListByResourceGroupComplete(ctx, resourceGroup, "", "createdTime", nil)
+	Expect(err).NotTo(HaveOccurred())
+	for iter.NotDone() {
+		resource := iter.Value()
+		Expect(resource.CreatedTime).NotTo(BeNil(), "resource %s has no creation time", to.String(resource.ID))
+		creationTimes[strings.ToLower(to.String(resource.ID))] = resource.CreatedTime.String()
+		Expect(iter.NextWithContext(ctx)).To(Succeed())
+	}
+	return creationTimes
+}
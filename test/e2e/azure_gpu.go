@@ -39,8 +39,8 @@ type AzureGPUSpecInput struct {
 	SkipCleanup           bool
 }
 
-// AzureGPUSpec implements a test that verifies a GPU-enabled application runs on an
-// "nvidia-gpu"-flavored CAPZ cluster.
+// AzureGPUSpec implements a test that verifies a GPU-enabled application and nvidia-smi run on an
+// "nvidia-gpu" or "machine-pool-nvidia-gpu"-flavored CAPZ cluster.
 func AzureGPUSpec(ctx context.Context, inputGetter func() AzureGPUSpecInput) {
 	var (
 		specName    = "azure-gpu"
@@ -97,4 +97,42 @@ func AzureGPUSpec(ctx context.Context, inputGetter func() AzureGPUSpecInput) {
 		Clientset: clientset,
 	}
 	WaitForJobComplete(ctx, gpuJobInput, e2eConfig.GetIntervals(specName, "wait-job")...)
+
+	By("running an nvidia-smi job")
+	smiJobName := "nvidia-smi"
+	smiJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      smiJobName,
+			Namespace: corev1.NamespaceDefault,
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						{
+							Name: smiJobName,
+							// The driver utilities, including nvidia-smi, are mounted by the NVIDIA container toolkit.
+							Image:   "nvcr.io/nvidia/cuda:11.1.1-base-ubuntu18.04",
+							Command: []string{"nvidia-smi"},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{
+									"nvidia.com/gpu": resource.MustParse("1"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	Log("starting to create nvidia-smi job")
+	_, err = jobsClient.Create(ctx, smiJob, metav1.CreateOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	smiJobInput := WaitForJobCompleteInput{
+		Getter:    jobsClientAdapter{client: jobsClient},
+		Job:       smiJob,
+		Clientset: clientset,
+	}
+	WaitForJobComplete(ctx, smiJobInput, e2eConfig.GetIntervals(specName, "wait-job")...)
 }
@@ -22,6 +22,7 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -119,7 +120,7 @@ func (k AzureLogCollector) CollectMachinePoolLog(ctx context.Context, management
 func collectLogsFromNode(ctx context.Context, managementClusterClient client.Client, cluster *clusterv1.Cluster, hostname string, isWindows bool, outputPath string) error {
 	Logf("INFO: Collecting logs for node %s in cluster %s in namespace %s\n", hostname, cluster.Name, cluster.Namespace)
 
-	controlPlaneEndpoint := cluster.Spec.ControlPlaneEndpoint.Host
+	sshEndpoint := getSSHEndpoint(cluster)
 
 	execToPathFn := func(outputFileName, command string, args ...string) func() error {
 		return func() error {
@@ -129,7 +130,7 @@ func collectLogsFromNode(ctx context.Context, managementClusterClient client.Cli
 			}
 			defer f.Close()
 			return retryWithExponentialBackOff(func() error {
-				return execOnHost(controlPlaneEndpoint, hostname, sshPort, f, command, args...)
+				return execOnHost(sshEndpoint, hostname, sshPort, f, command, args...)
 			})
 		}
 	}
@@ -355,7 +356,7 @@ func collectVMBootLog(ctx context.Context, am *v1alpha4.AzureMachine, outputPath
 		return errors.Wrap(err, "failed to get boot diagnostics data")
 	}
 
-	return writeBootLog(bootDiagnostics, outputPath)
+	return writeBootDiagnostics(bootDiagnostics, outputPath)
 }
 
 // collectVMSSBootLog collects boot logs of the scale set by using azure boot diagnostics.
@@ -387,22 +388,135 @@ func collectVMSSBootLog(ctx context.Context, providerID string, outputPath strin
 		return errors.Wrap(err, "failed to get boot diagnostics data")
 	}
 
-	return writeBootLog(bootDiagnostics, outputPath)
+	return writeBootDiagnostics(bootDiagnostics, outputPath)
+}
+
+// collectBootDiagnostics collects boot logs and screenshots of every vm and scale set instance of a resource group by
+// using azure boot diagnostics, including the ones which never became a node, into a directory per vm or instance.
+func collectBootDiagnostics(ctx context.Context, resourceGroup string, outputPath string) error {
+	var errs []error
+
+	settings, err := auth.GetSettingsFromEnvironment()
+	if err != nil {
+		return errors.Wrap(err, "failed to get settings from environment")
+	}
+	authorizer, err := settings.GetAuthorizer()
+	if err != nil {
+		return errors.Wrap(err, "failed to get authorizer")
+	}
+
+	vmClient := compute.NewVirtualMachinesClient(settings.GetSubscriptionID())
+	vmClient.Authorizer = authorizer
+	vms, err := vmClient.ListComplete(ctx, resourceGroup)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list vms in resource group %s", resourceGroup)
+	}
+	for ; vms.NotDone(); err = vms.NextWithContext(ctx) {
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to list vms in resource group %s", resourceGroup))
+			break
+		}
+		name := *vms.Value().Name
+		Logf("INFO: Collecting boot diagnostics for vm %s\n", name)
+		bootDiagnostics, err := vmClient.RetrieveBootDiagnosticsData(ctx, resourceGroup, name, nil)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to get boot diagnostics data of vm %s", name))
+			continue
+		}
+		if err := writeBootDiagnostics(bootDiagnostics, filepath.Join(outputPath, name)); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to write boot diagnostics of vm %s", name))
+		}
+	}
+
+	vmssClient := compute.NewVirtualMachineScaleSetsClient(settings.GetSubscriptionID())
+	vmssClient.Authorizer = authorizer
+	vmssVMClient := compute.NewVirtualMachineScaleSetVMsClient(settings.GetSubscriptionID())
+	vmssVMClient.Authorizer = authorizer
+	scaleSets, err := vmssClient.ListComplete(ctx, resourceGroup)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list scale sets in resource group %s", resourceGroup)
+	}
+	for ; scaleSets.NotDone(); err = scaleSets.NextWithContext(ctx) {
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to list scale sets in resource group %s", resourceGroup))
+			break
+		}
+		scaleSetName := *scaleSets.Value().Name
+		instances, err := vmssVMClient.ListComplete(ctx, resourceGroup, scaleSetName, "", "", "")
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to list instances of scale set %s", scaleSetName))
+			continue
+		}
+		for ; instances.NotDone(); err = instances.NextWithContext(ctx) {
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to list instances of scale set %s", scaleSetName))
+				break
+			}
+			instance := instances.Value()
+			Logf("INFO: Collecting boot diagnostics for VMSS instance %s of scale set %s\n", *instance.InstanceID, scaleSetName)
+			bootDiagnostics, err := vmssVMClient.RetrieveBootDiagnosticsData(ctx, resourceGroup, scaleSetName, *instance.InstanceID, nil)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to get boot diagnostics data of VMSS instance %s", *instance.Name))
+				continue
+			}
+			if err := writeBootDiagnostics(bootDiagnostics, filepath.Join(outputPath, *instance.Name)); err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to write boot diagnostics of VMSS instance %s", *instance.Name))
+			}
+		}
+	}
+
+	return kinderrors.NewAggregate(errs)
+}
+
+// writeBootDiagnostics writes the serial log and, when there is one, the screenshot of boot diagnostics data to
+// outputPath.
+func writeBootDiagnostics(bootDiagnostics compute.RetrieveBootDiagnosticsDataResult, outputPath string) error {
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return errors.Wrap(err, "failed to create output directory")
+	}
+
+	if err := writeBootLog(bootDiagnostics, outputPath); err != nil {
+		return err
+	}
+
+	if bootDiagnostics.ConsoleScreenshotBlobURI == nil {
+		return nil
+	}
+	if err := downloadToFile(*bootDiagnostics.ConsoleScreenshotBlobURI, filepath.Join(outputPath, "boot-screenshot.bmp")); err != nil {
+		return errors.Wrap(err, "failed to get screenshot from console screenshot uri")
+	}
+
+	return nil
 }
 
 func writeBootLog(bootDiagnostics compute.RetrieveBootDiagnosticsDataResult, outputPath string) error {
-	var err error
-	resp, err := http.Get(*bootDiagnostics.SerialConsoleLogBlobURI)
-	if err != nil || resp.StatusCode != 200 {
+	if bootDiagnostics.SerialConsoleLogBlobURI == nil {
+		return errors.New("boot diagnostics data has no serial console uri")
+	}
+	if err := downloadToFile(*bootDiagnostics.SerialConsoleLogBlobURI, filepath.Join(outputPath, "boot.log")); err != nil {
 		return errors.Wrap(err, "failed to get logs from serial console uri")
 	}
 
+	return nil
+}
+
+// downloadToFile writes the content served at uri to a file.
+func downloadToFile(uri string, path string) error {
+	resp, err := http.Get(uri)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+
 	content, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return errors.Wrap(err, "failed to read response body")
 	}
 
-	if err := ioutil.WriteFile(filepath.Join(outputPath, "boot.log"), content, 0644); err != nil {
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
 		return errors.Wrap(err, "failed to write response to file")
 	}
 
@@ -161,6 +161,22 @@ func AzurePrivateClusterSpec(ctx context.Context, inputGetter func() AzurePrivat
 
 		Expect(err).To(BeNil())
 	}
+
+	// The nodes of the private cluster are reached through the control plane of the public cluster, which is in the
+	// same virtual network.
+	By("Using the public cluster's control plane endpoint as SSH jump host of the private cluster")
+	publicCluster := &clusterv1.Cluster{}
+	Expect(input.BootstrapClusterProxy.GetClient().Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: input.ClusterName}, publicCluster)).To(Succeed())
+	setSSHJumpHost(cluster.Namespace, cluster.Name, publicCluster.Spec.ControlPlaneEndpoint.Host)
+
+	By("Validating time synchronization of the private cluster")
+	AzureTimeSyncSpec(ctx, func() AzureTimeSyncSpecInput {
+		return AzureTimeSyncSpecInput{
+			BootstrapClusterProxy: publicClusterProxy,
+			Namespace:             input.Namespace,
+			ClusterName:           clusterName,
+		}
+	})
 }
 
 // SetupExistingVNet creates a resource group and a VNet to be used by a workload cluster.
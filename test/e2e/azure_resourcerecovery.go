@@ -0,0 +1,147 @@
+// +build e2e
+
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AzureResourceRecoverySpecInput is the input for AzureResourceRecoverySpec.
+type AzureResourceRecoverySpecInput struct {
+	BootstrapClusterProxy framework.ClusterProxy
+	Namespace             *corev1.Namespace
+	ClusterName           string
+}
+
+// AzureResourceRecoverySpec implements a test that deletes Azure resources of a cluster with a managed VNet
+// out-of-band, and verifies that drift repair recreates them, and restores the association of the route table
+// with its subnet, within a bounded time.
+func AzureResourceRecoverySpec(ctx context.Context, inputGetter func() AzureResourceRecoverySpecInput) {
+	var (
+		specName        = "azure-resource-recovery"
+		input           AzureResourceRecoverySpecInput
+		recoveryTimeout = 10 * time.Minute
+		pollInterval    = 15 * time.Second
+		// allow_ssh is deleted rather than allow_apiserver, so the API server stays reachable while it is missing.
+		securityRuleName = "allow_ssh"
+	)
+
+	input = inputGetter()
+	Expect(input.BootstrapClusterProxy).NotTo(BeNil(), "Invalid argument. input.BootstrapClusterProxy can't be nil when calling %s spec", specName)
+	Expect(input.Namespace).NotTo(BeNil(), "Invalid argument. input.Namespace can't be nil when calling %s spec", specName)
+	Expect(input.ClusterName).NotTo(BeEmpty(), "Invalid argument. input.ClusterName can't be empty when calling %s spec", specName)
+
+	mgmtClient := input.BootstrapClusterProxy.GetClient()
+	cluster := &clusterv1.Cluster{}
+	Expect(mgmtClient.Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: input.ClusterName}, cluster)).To(Succeed())
+	azureCluster := &v1alpha4.AzureCluster{}
+	Expect(mgmtClient.Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: cluster.Spec.InfrastructureRef.Name}, azureCluster)).To(Succeed())
+
+	controlPlaneSubnet, err := azureCluster.Spec.NetworkSpec.GetControlPlaneSubnet()
+	Expect(err).NotTo(HaveOccurred())
+	nodeSubnet, err := azureCluster.Spec.NetworkSpec.GetNodeSubnet()
+	Expect(err).NotTo(HaveOccurred())
+	resourceGroup := azureCluster.Spec.ResourceGroup
+	vnet := azureCluster.Spec.NetworkSpec.Vnet
+
+	By("enabling drift repair on the AzureCluster")
+	driftDetection := azureCluster.Spec.DriftDetection
+	patch := client.MergeFrom(azureCluster.DeepCopy())
+	azureCluster.Spec.DriftDetection = &v1alpha4.DriftDetection{
+		Interval: metav1.Duration{Duration: time.Minute},
+		Mode:     v1alpha4.DriftDetectionModeRepair,
+	}
+	Expect(mgmtClient.Patch(ctx, azureCluster, patch)).To(Succeed())
+	defer func() {
+		By("restoring the drift detection of the AzureCluster")
+		Expect(mgmtClient.Get(ctx, client.ObjectKeyFromObject(azureCluster), azureCluster)).To(Succeed())
+		patch := client.MergeFrom(azureCluster.DeepCopy())
+		azureCluster.Spec.DriftDetection = driftDetection
+		Expect(mgmtClient.Patch(ctx, azureCluster, patch)).To(Succeed())
+	}()
+
+	By("creating Azure clients with the workload cluster's subscription")
+	settings, err := auth.GetSettingsFromEnvironment()
+	Expect(err).NotTo(HaveOccurred())
+	subscriptionID := settings.GetSubscriptionID()
+	authorizer, err := settings.GetAuthorizer()
+	Expect(err).NotTo(HaveOccurred())
+	securityRulesClient := network.NewSecurityRulesClient(subscriptionID)
+	securityRulesClient.Authorizer = authorizer
+	routeTablesClient := network.NewRouteTablesClient(subscriptionID)
+	routeTablesClient.Authorizer = authorizer
+	subnetsClient := network.NewSubnetsClient(subscriptionID)
+	subnetsClient.Authorizer = authorizer
+
+	Byf("deleting security rule %s of network security group %s out-of-band", securityRuleName, controlPlaneSubnet.SecurityGroup.Name)
+	ruleFuture, err := securityRulesClient.Delete(ctx, resourceGroup, controlPlaneSubnet.SecurityGroup.Name, securityRuleName)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(ruleFuture.WaitForCompletionRef(ctx, securityRulesClient.Client)).To(Succeed())
+
+	Byf("waiting for security rule %s to be recreated", securityRuleName)
+	Eventually(func() error {
+		_, err := securityRulesClient.Get(ctx, resourceGroup, controlPlaneSubnet.SecurityGroup.Name, securityRuleName)
+		return err
+	}, recoveryTimeout, pollInterval).Should(Succeed())
+
+	// Azure doesn't delete route tables which are associated with subnets, so the route table is detached first.
+	Byf("detaching route table %s from subnet %s", nodeSubnet.RouteTable.Name, nodeSubnet.Name)
+	subnet, err := subnetsClient.Get(ctx, vnet.ResourceGroup, vnet.Name, nodeSubnet.Name, "")
+	Expect(err).NotTo(HaveOccurred())
+	routeTable := subnet.RouteTable
+	Expect(routeTable).NotTo(BeNil())
+	subnet.RouteTable = nil
+	subnetFuture, err := subnetsClient.CreateOrUpdate(ctx, vnet.ResourceGroup, vnet.Name, nodeSubnet.Name, subnet)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(subnetFuture.WaitForCompletionRef(ctx, subnetsClient.Client)).To(Succeed())
+
+	Byf("deleting route table %s out-of-band", nodeSubnet.RouteTable.Name)
+	routeTableFuture, err := routeTablesClient.Delete(ctx, resourceGroup, nodeSubnet.RouteTable.Name)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(routeTableFuture.WaitForCompletionRef(ctx, routeTablesClient.Client)).To(Succeed())
+
+	Byf("waiting for route table %s to be recreated", nodeSubnet.RouteTable.Name)
+	Eventually(func() error {
+		_, err := routeTablesClient.Get(ctx, resourceGroup, nodeSubnet.RouteTable.Name, "")
+		return err
+	}, recoveryTimeout, pollInterval).Should(Succeed())
+
+	Byf("waiting for route table %s to be reattached to subnet %s", nodeSubnet.RouteTable.Name, nodeSubnet.Name)
+	Eventually(func() (string, error) {
+		subnet, err := subnetsClient.Get(ctx, vnet.ResourceGroup, vnet.Name, nodeSubnet.Name, "")
+		if err != nil || subnet.RouteTable == nil {
+			return "", err
+		}
+		return strings.ToLower(to.String(subnet.RouteTable.ID)), nil
+	}, recoveryTimeout, pollInterval).Should(Equal(strings.ToLower(to.String(routeTable.ID))))
+}
@@ -0,0 +1,156 @@
+// +build e2e
+
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2019-10-01/resources"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/azure"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AzureTagsEnforcementSpecInput is the input for AzureTagsEnforcementSpec.
+type AzureTagsEnforcementSpecInput struct {
+	BootstrapClusterProxy framework.ClusterProxy
+	Namespace             *corev1.Namespace
+	ClusterName           string
+}
+
+// AzureTagsEnforcementSpec implements a test that removes and changes the tags the controller sets on the API server
+// public IP of a cluster out-of-band, and verifies that tag enforcement restores them while keeping the tags added
+// by users.
+func AzureTagsEnforcementSpec(ctx context.Context, inputGetter func() AzureTagsEnforcementSpecInput) {
+	var (
+		specName       = "azure-tags-enforcement"
+		input          AzureTagsEnforcementSpecInput
+		restoreTimeout = 10 * time.Minute
+		pollInterval   = 15 * time.Second
+		userTagKey     = "capz-e2e-user-tag"
+		userTagValue   = "kept"
+	)
+
+	input = inputGetter()
+	Expect(input.BootstrapClusterProxy).NotTo(BeNil(), "Invalid argument. input.BootstrapClusterProxy can't be nil when calling %s spec", specName)
+	Expect(input.Namespace).NotTo(BeNil(), "Invalid argument. input.Namespace can't be nil when calling %s spec", specName)
+	Expect(input.ClusterName).NotTo(BeEmpty(), "Invalid argument. input.ClusterName can't be empty when calling %s spec", specName)
+
+	mgmtClient := input.BootstrapClusterProxy.GetClient()
+	cluster := &clusterv1.Cluster{}
+	Expect(mgmtClient.Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: input.ClusterName}, cluster)).To(Succeed())
+	azureCluster := &v1alpha4.AzureCluster{}
+	Expect(mgmtClient.Get(ctx, client.ObjectKey{Namespace: input.Namespace.Name, Name: cluster.Spec.InfrastructureRef.Name}, azureCluster)).To(Succeed())
+	Expect(azureCluster.Spec.NetworkSpec.APIServerLB.FrontendIPs).NotTo(BeEmpty())
+	publicIP := azureCluster.Spec.NetworkSpec.APIServerLB.FrontendIPs[0].PublicIP
+	Expect(publicIP).NotTo(BeNil(), "%s spec requires a cluster with a public API server", specName)
+
+	// Tags are only enforced when the AzureCluster is reconciled, which drift detection makes periodic.
+	By("enabling tag enforcement and periodic reconciliation on the AzureCluster")
+	enforceTags, driftDetection := azureCluster.Spec.EnforceTags, azureCluster.Spec.DriftDetection
+	patch := client.MergeFrom(azureCluster.DeepCopy())
+	azureCluster.Spec.EnforceTags = true
+	azureCluster.Spec.DriftDetection = &v1alpha4.DriftDetection{
+		Interval: metav1.Duration{Duration: time.Minute},
+		Mode:     v1alpha4.DriftDetectionModeReport,
+	}
+	Expect(mgmtClient.Patch(ctx, azureCluster, patch)).To(Succeed())
+	defer func() {
+		By("restoring the tag enforcement and drift detection of the AzureCluster")
+		Expect(mgmtClient.Get(ctx, client.ObjectKeyFromObject(azureCluster), azureCluster)).To(Succeed())
+		patch := client.MergeFrom(azureCluster.DeepCopy())
+		azureCluster.Spec.EnforceTags = enforceTags
+		azureCluster.Spec.DriftDetection = driftDetection
+		Expect(mgmtClient.Patch(ctx, azureCluster, patch)).To(Succeed())
+	}()
+
+	By("creating an Azure tags client with the workload cluster's subscription")
+	settings, err := auth.GetSettingsFromEnvironment()
+	Expect(err).NotTo(HaveOccurred())
+	tagsClient := resources.NewTagsClient(settings.GetSubscriptionID())
+	tagsClient.Authorizer, err = settings.GetAuthorizer()
+	Expect(err).NotTo(HaveOccurred())
+	scope := azure.PublicIPID(settings.GetSubscriptionID(), azureCluster.Spec.ResourceGroup, publicIP.Name)
+
+	ownedKey := v1alpha4.ClusterTagKey(input.ClusterName)
+	existing, err := tagsClient.GetAtScope(ctx, scope)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(existing.Properties).NotTo(BeNil())
+	Expect(toStringMap(existing.Properties.Tags)).To(HaveKeyWithValue(ownedKey, string(v1alpha4.ResourceLifecycleOwned)))
+
+	Byf("removing tag %s, changing tag Name and adding tag %s on public IP %s out-of-band", ownedKey, userTagKey, publicIP.Name)
+	_, err = tagsClient.UpdateAtScope(ctx, scope, resources.TagsPatchResource{
+		Operation:  resources.TagsPatchOperationDelete,
+		Properties: &resources.Tags{Tags: map[string]*string{ownedKey: pointer.StringPtr(string(v1alpha4.ResourceLifecycleOwned))}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	_, err = tagsClient.UpdateAtScope(ctx, scope, resources.TagsPatchResource{
+		Operation: resources.TagsPatchOperationMerge,
+		Properties: &resources.Tags{Tags: map[string]*string{
+			"Name":     pointer.StringPtr("changed-out-of-band"),
+			userTagKey: pointer.StringPtr(userTagValue),
+		}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Byf("waiting for the tags of public IP %s to be restored", publicIP.Name)
+	Eventually(func() (map[string]string, error) {
+		result, err := tagsClient.GetAtScope(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		if result.Properties == nil {
+			return nil, fmt.Errorf("public IP %s has no tags", publicIP.Name)
+		}
+		return toStringMap(result.Properties.Tags), nil
+	}, restoreTimeout, pollInterval).Should(And(
+		HaveKeyWithValue(ownedKey, string(v1alpha4.ResourceLifecycleOwned)),
+		HaveKeyWithValue("Name", publicIP.Name),
+		HaveKeyWithValue(userTagKey, userTagValue),
+	))
+
+	By("verifying the restored tags are reported on the AzureCluster")
+	Eventually(func() (string, error) {
+		if err := mgmtClient.Get(ctx, client.ObjectKeyFromObject(azureCluster), azureCluster); err != nil {
+			return "", err
+		}
+		if !conditions.Has(azureCluster, v1alpha4.TagsEnforcedCondition) {
+			return "", fmt.Errorf("AzureCluster %s has no %s condition", azureCluster.Name, v1alpha4.TagsEnforcedCondition)
+		}
+		return conditions.GetMessage(azureCluster, v1alpha4.TagsEnforcedCondition), nil
+	}, restoreTimeout, pollInterval).Should(ContainSubstring("public IP " + publicIP.Name))
+
+	Byf("removing tag %s from public IP %s", userTagKey, publicIP.Name)
+	_, err = tagsClient.UpdateAtScope(ctx, scope, resources.TagsPatchResource{
+		Operation:  resources.TagsPatchOperationDelete,
+		Properties: &resources.Tags{Tags: map[string]*string{userTagKey: pointer.StringPtr(userTagValue)}},
+	})
+	Expect(err).NotTo(HaveOccurred())
+}
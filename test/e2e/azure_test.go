@@ -149,6 +149,96 @@ var _ = Describe("Workload cluster creation", func() {
 		fmt.Fprintf(GinkgoWriter, "INFO: skipping test requires pushing container images to external repository")
 	}
 
+	It("With a bring-your-own network", func() {
+		var byoNetwork BYONetwork
+		Context("Creating the network outside of the provider", func() {
+			byoNetwork = SetupBYONetwork(ctx, clusterName, os.Getenv(AzureResourceGroup))
+		})
+
+		clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
+			ClusterProxy: bootstrapClusterProxy,
+			ConfigCluster: clusterctl.ConfigClusterInput{
+				LogFolder:                filepath.Join(artifactFolder, "clusters", bootstrapClusterProxy.GetName()),
+				ClusterctlConfigPath:     clusterctlConfigPath,
+				KubeconfigPath:           bootstrapClusterProxy.GetKubeconfigPath(),
+				InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+				Flavor:                   clusterctl.DefaultFlavor,
+				Namespace:                namespace.Name,
+				ClusterName:              clusterName,
+				KubernetesVersion:        e2eConfig.GetVariable(capi_e2e.KubernetesVersion),
+				ControlPlaneMachineCount: pointer.Int64Ptr(1),
+				WorkerMachineCount:       pointer.Int64Ptr(1),
+			},
+			WaitForClusterIntervals:      e2eConfig.GetIntervals(specName, "wait-cluster"),
+			WaitForControlPlaneIntervals: e2eConfig.GetIntervals(specName, "wait-control-plane"),
+			WaitForMachineDeployments:    e2eConfig.GetIntervals(specName, "wait-worker-nodes"),
+		}, result)
+
+		Context("Validating the network is left untouched until the cluster is deleted", func() {
+			AzureBYONetworkSpec(ctx, func() AzureBYONetworkSpecInput {
+				return AzureBYONetworkSpecInput{
+					BootstrapClusterProxy: bootstrapClusterProxy,
+					Namespace:             namespace,
+					ClusterName:           clusterName,
+					Network:               byoNetwork,
+					ArtifactFolder:        artifactFolder,
+					DeleteClusterTimeouts: e2eConfig.GetIntervals(specName, "wait-delete-cluster"),
+					SkipCleanup:           skipCleanup,
+				}
+			})
+			// The spec collected the logs of the cluster before deleting it.
+			result.Cluster = nil
+		})
+	})
+
+	It("With the virtual network in another resource group", func() {
+		vnetResourceGroup := fmt.Sprintf("%s-vnet", clusterName)
+		Expect(os.Setenv(AzureVNetResourceGroup, vnetResourceGroup)).NotTo(HaveOccurred())
+		defer func() {
+			Expect(os.Unsetenv(AzureVNetResourceGroup)).NotTo(HaveOccurred())
+		}()
+
+		var byoNetwork BYONetwork
+		Context("Creating the network in its own resource group", func() {
+			byoNetwork = SetupBYONetwork(ctx, clusterName, vnetResourceGroup)
+		})
+
+		clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
+			ClusterProxy: bootstrapClusterProxy,
+			ConfigCluster: clusterctl.ConfigClusterInput{
+				LogFolder:                filepath.Join(artifactFolder, "clusters", bootstrapClusterProxy.GetName()),
+				ClusterctlConfigPath:     clusterctlConfigPath,
+				KubeconfigPath:           bootstrapClusterProxy.GetKubeconfigPath(),
+				InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+				Flavor:                   "vnet-resource-group",
+				Namespace:                namespace.Name,
+				ClusterName:              clusterName,
+				KubernetesVersion:        e2eConfig.GetVariable(capi_e2e.KubernetesVersion),
+				ControlPlaneMachineCount: pointer.Int64Ptr(1),
+				WorkerMachineCount:       pointer.Int64Ptr(1),
+			},
+			WaitForClusterIntervals:      e2eConfig.GetIntervals(specName, "wait-cluster"),
+			WaitForControlPlaneIntervals: e2eConfig.GetIntervals(specName, "wait-control-plane"),
+			WaitForMachineDeployments:    e2eConfig.GetIntervals(specName, "wait-worker-nodes"),
+		}, result)
+
+		Context("Validating the network is left untouched until the cluster is deleted", func() {
+			AzureBYONetworkSpec(ctx, func() AzureBYONetworkSpecInput {
+				return AzureBYONetworkSpecInput{
+					BootstrapClusterProxy: bootstrapClusterProxy,
+					Namespace:             namespace,
+					ClusterName:           clusterName,
+					Network:               byoNetwork,
+					ArtifactFolder:        artifactFolder,
+					DeleteClusterTimeouts: e2eConfig.GetIntervals(specName, "wait-delete-cluster"),
+					SkipCleanup:           skipCleanup,
+				}
+			})
+			// The spec collected the logs of the cluster before deleting it.
+			result.Cluster = nil
+		})
+	})
+
 	It("With 3 control-plane nodes and 2 worker nodes", func() {
 		clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
 			ClusterProxy: bootstrapClusterProxy,
@@ -219,6 +309,26 @@ var _ = Describe("Workload cluster creation", func() {
 				}
 			})
 		})
+
+		Context("Recovering Azure resources deleted out-of-band", func() {
+			AzureResourceRecoverySpec(ctx, func() AzureResourceRecoverySpecInput {
+				return AzureResourceRecoverySpecInput{
+					BootstrapClusterProxy: bootstrapClusterProxy,
+					Namespace:             namespace,
+					ClusterName:           clusterName,
+				}
+			})
+		})
+
+		Context("Restoring tags changed out-of-band", func() {
+			AzureTagsEnforcementSpec(ctx, func() AzureTagsEnforcementSpecInput {
+				return AzureTagsEnforcementSpecInput{
+					BootstrapClusterProxy: bootstrapClusterProxy,
+					Namespace:             namespace,
+					ClusterName:           clusterName,
+				}
+			})
+		})
 	})
 
 	Context("Creating a ipv6 control-plane cluster", func() {
@@ -361,6 +471,40 @@ var _ = Describe("Workload cluster creation", func() {
 				})
 			})
 		})
+
+		It("with a single control plane node and 1 machine pool node", func() {
+			clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
+				ClusterProxy: bootstrapClusterProxy,
+				ConfigCluster: clusterctl.ConfigClusterInput{
+					LogFolder:                filepath.Join(artifactFolder, "clusters", bootstrapClusterProxy.GetName()),
+					ClusterctlConfigPath:     clusterctlConfigPath,
+					KubeconfigPath:           bootstrapClusterProxy.GetKubeconfigPath(),
+					InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+					Flavor:                   "machine-pool-nvidia-gpu",
+					Namespace:                namespace.Name,
+					ClusterName:              clusterName,
+					KubernetesVersion:        e2eConfig.GetVariable(capi_e2e.KubernetesVersion),
+					ControlPlaneMachineCount: pointer.Int64Ptr(1),
+					WorkerMachineCount:       pointer.Int64Ptr(1),
+				},
+				WaitForClusterIntervals:      e2eConfig.GetIntervals(specName, "wait-cluster"),
+				WaitForControlPlaneIntervals: e2eConfig.GetIntervals(specName, "wait-control-plane"),
+				WaitForMachinePools:          e2eConfig.GetIntervals(specName, "wait-machine-pool-nodes"),
+				// Like the nvidia-gpu flavor, the machine-pool-nvidia-gpu flavor needs server side apply for its large config map.
+				Args: []string{"--server-side"},
+			}, result)
+
+			Context("Running a GPU-based calculation", func() {
+				AzureGPUSpec(ctx, func() AzureGPUSpecInput {
+					return AzureGPUSpecInput{
+						BootstrapClusterProxy: bootstrapClusterProxy,
+						Namespace:             namespace,
+						ClusterName:           clusterName,
+						SkipCleanup:           skipCleanup,
+					}
+				})
+			})
+		})
 	})
 
 	// ci-e2e.sh and Prow CI skip this test by default.
@@ -560,6 +704,16 @@ var _ = Describe("Workload cluster creation", func() {
 					}
 				})
 			})
+
+			Context("Validating time synchronization", func() {
+				AzureTimeSyncSpec(ctx, func() AzureTimeSyncSpecInput {
+					return AzureTimeSyncSpecInput{
+						BootstrapClusterProxy: bootstrapClusterProxy,
+						Namespace:             namespace,
+						ClusterName:           clusterName,
+					}
+				})
+			})
 		})
 	})
 
@@ -606,6 +760,16 @@ var _ = Describe("Workload cluster creation", func() {
 					}
 				})
 			})
+
+			Context("Validating time synchronization", func() {
+				AzureTimeSyncSpec(ctx, func() AzureTimeSyncSpecInput {
+					return AzureTimeSyncSpecInput{
+						BootstrapClusterProxy: bootstrapClusterProxy,
+						Namespace:             namespace,
+						ClusterName:           clusterName,
+					}
+				})
+			})
 		})
 	})
 })
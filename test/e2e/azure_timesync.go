@@ -39,7 +39,7 @@ type AzureTimeSyncSpecInput struct {
 }
 
 // AzureTimeSyncSpec implements a test that verifies time synchronization is healthy for
-// the nodes in a cluster.
+// the nodes in a cluster: chronyd on Linux nodes, and the Windows Time service on Windows nodes.
 func AzureTimeSyncSpec(ctx context.Context, inputGetter func() AzureTimeSyncSpecInput) {
 	var (
 		specName = "azure-timesync"
@@ -78,6 +78,21 @@ func AzureTimeSyncSpec(ctx context.Context, inputGetter func() AzureTimeSyncSpec
 				}
 			}
 
+			if s.Windows {
+				testFuncs = append(testFuncs,
+					execToStringFn(
+						"Running",
+						"Get-Service", "w32time | Select-Object -ExpandProperty Status",
+					),
+					// A leap indicator of 3 means the clock isn't synchronized.
+					execToStringFn(
+						"Leap Indicator: 0(no warning)",
+						"w32tm", "/query /status",
+					),
+				)
+				continue
+			}
+
 			testFuncs = append(testFuncs,
 				execToStringFn(
 					"✓ chronyd is active",
@@ -107,6 +107,18 @@ var _ = Describe("Running the Cluster API E2E tests", func() {
 				}
 			})
 		})
+
+		Context("Running the clusterctl upgrade spec from the previous release", func() {
+			AzureClusterctlUpgradeSpec(context.TODO(), func() AzureClusterctlUpgradeSpecInput {
+				return AzureClusterctlUpgradeSpecInput{
+					E2EConfig:             e2eConfig,
+					ClusterctlConfigPath:  clusterctlConfigPath,
+					BootstrapClusterProxy: bootstrapClusterProxy,
+					ArtifactFolder:        artifactFolder,
+					SkipCleanup:           skipCleanup,
+				}
+			})
+		})
 	}
 
 	Context("Should successfully remediate unhealthy machines with MachineHealthCheck", func() {
@@ -47,6 +47,7 @@ const (
 	AzureLocation               = "AZURE_LOCATION"
 	AzureResourceGroup          = "AZURE_RESOURCE_GROUP"
 	AzureVNetName               = "AZURE_VNET_NAME"
+	AzureVNetResourceGroup      = "AZURE_VNET_RESOURCE_GROUP"
 	AzureInternalLBIP           = "AZURE_INTERNAL_LB_IP"
 	AzureCPSubnetCidr           = "AZURE_CP_SUBNET_CIDR"
 	AzureNodeSubnetCidr         = "AZURE_NODE_SUBNET_CIDR"
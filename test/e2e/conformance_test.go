@@ -104,6 +104,12 @@ var _ = Describe("Conformance Tests", func() {
 			}
 		}
 
+		// only the default flavor has variants with other CNIs
+		if conformanceCNI != "" {
+			Expect(flavor).To(Equal(clusterctl.DefaultFlavor), "kubetest.cni is only supported for the default flavor")
+			flavor = conformanceCNI
+		}
+
 		workerMachineCount, err := strconv.ParseInt(e2eConfig.GetVariable("CONFORMANCE_WORKER_MACHINE_COUNT"), 10, 64)
 		Expect(err).NotTo(HaveOccurred())
 		controlPlaneMachineCount, err := strconv.ParseInt(e2eConfig.GetVariable("CONFORMANCE_CONTROL_PLANE_MACHINE_COUNT"), 10, 64)
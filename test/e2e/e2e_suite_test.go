@@ -55,8 +55,9 @@ import (
 )
 
 const (
-	kubesystem  = "kube-system"
-	activitylog = "azure-activity-logs"
+	kubesystem      = "kube-system"
+	activitylog     = "azure-activity-logs"
+	bootdiagnostics = "azure-boot-diagnostics"
 )
 
 // Test suite flags
@@ -101,6 +102,9 @@ var (
 
 	// usePRArtifacts specifies whether or not to use the build from a PR of the Kubernetes repository
 	usePRArtifacts bool
+
+	// conformanceCNI is the CNI the conformance test cluster is created with, e.g. cilium. Defaults to the CNI of the default flavor.
+	conformanceCNI string
 )
 
 type (
@@ -135,6 +139,14 @@ func (acp *AzureClusterProxy) CollectWorkloadClusterLogs(ctx context.Context, na
 	start = time.Now()
 	acp.collectActivityLogs(ctx, aboveMachinesPath)
 	Byf("Fetching activity logs took %s", time.Since(start).String())
+
+	// Boot diagnostics are mostly useful to debug nodes which failed to bootstrap, and are lost with the resource group.
+	if CurrentGinkgoTestDescription().Failed {
+		Byf("Dumping workload cluster %s/%s boot diagnostics", namespace, name)
+		start = time.Now()
+		acp.collectBootDiagnostics(ctx, aboveMachinesPath)
+		Byf("Fetching boot diagnostics took %s", time.Since(start).String())
+	}
 }
 
 func (acp *AzureClusterProxy) collectPodLogs(ctx context.Context, namespace string, name string, aboveMachinesPath string) {
@@ -185,6 +197,17 @@ func (acp *AzureClusterProxy) collectPodLogs(ctx context.Context, namespace stri
 	}
 }
 
+func (acp *AzureClusterProxy) collectBootDiagnostics(ctx context.Context, aboveMachinesPath string) {
+	timeoutctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	groupName := os.Getenv(AzureResourceGroup)
+	if err := collectBootDiagnostics(timeoutctx, groupName, path.Join(aboveMachinesPath, bootdiagnostics)); err != nil {
+		// Failing to fetch boot diagnostics should not cause the test to fail
+		Byf("Error fetching boot diagnostics for resource group %s: %v", groupName, err)
+	}
+}
+
 func (acp *AzureClusterProxy) collectActivityLogs(ctx context.Context, aboveMachinesPath string) {
 	timeoutctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -247,6 +270,7 @@ func init() {
 	flag.BoolVar(&useExistingCluster, "e2e.use-existing-cluster", false, "if true, the test uses the current cluster instead of creating a new one (default discovery rules apply)")
 	flag.StringVar(&kubetestConfigFilePath, "kubetest.config-file", "", "path to the kubetest configuration file")
 	flag.StringVar(&kubetestRepoListPath, "kubetest.repo-list-file", "", "path to the kubetest repo-list file")
+	flag.StringVar(&conformanceCNI, "kubetest.cni", "", "CNI of the conformance test cluster, e.g. cilium. Uses the CNI of the default flavor if empty")
 }
 
 func TestE2E(t *testing.T) {
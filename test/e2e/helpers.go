@@ -30,6 +30,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
@@ -303,20 +304,46 @@ func logCheckpoint(specTimes map[string]time.Time) {
 	}
 }
 
+var (
+	sshJumpHostsMu sync.Mutex
+	// sshJumpHosts are the SSH endpoints the nodes of clusters without a reachable control plane endpoint are
+	// reached through, by cluster.
+	sshJumpHosts = map[client.ObjectKey]string{}
+)
+
+// setSSHJumpHost makes the nodes of a cluster whose control plane endpoint isn't reachable, e.g. a private cluster,
+// reached through an SSH server in the same virtual network, like a control plane endpoint of a public cluster.
+func setSSHJumpHost(namespace, clusterName, endpoint string) {
+	sshJumpHostsMu.Lock()
+	defer sshJumpHostsMu.Unlock()
+	sshJumpHosts[client.ObjectKey{Namespace: namespace, Name: clusterName}] = endpoint
+}
+
+// getSSHEndpoint returns the endpoint of the SSH server the nodes of a cluster are reached through: its jump host if
+// it has one, and its control plane endpoint otherwise.
+func getSSHEndpoint(cluster *clusterv1.Cluster) string {
+	sshJumpHostsMu.Lock()
+	defer sshJumpHostsMu.Unlock()
+	if endpoint, ok := sshJumpHosts[client.ObjectKeyFromObject(cluster)]; ok {
+		return endpoint
+	}
+	return cluster.Spec.ControlPlaneEndpoint.Host
+}
+
 // nodeSSHInfo provides information to establish an SSH connection to a VM or VMSS instance.
 type nodeSSHInfo struct {
-	Endpoint string // Endpoint is the control plane hostname or IP address for initial connection.
+	Endpoint string // Endpoint is the control plane or jump host hostname or IP address for initial connection.
 	Hostname string // Hostname is the name or IP address of the destination VM or VMSS instance.
 	Port     string // Port is the TCP port used for the SSH connection.
+	Windows  bool   // Windows is whether the node runs Windows, whose SSH sessions run PowerShell.
 }
 
 // getClusterSSHInfo returns the information needed to establish a SSH connection through a
-// control plane endpoint to each node in the cluster.
+// control plane endpoint, or the jump host of a private cluster, to each node in the cluster.
 func getClusterSSHInfo(ctx context.Context, mgmtClusterProxy framework.ClusterProxy, namespace, clusterName string) ([]nodeSSHInfo, error) {
 	var (
-		sshInfo               []nodeSSHInfo
-		mgmtClusterClient     = mgmtClusterProxy.GetClient()
-		workloadClusterClient = mgmtClusterProxy.GetWorkloadCluster(ctx, namespace, clusterName).GetClient()
+		sshInfo           []nodeSSHInfo
+		mgmtClusterClient = mgmtClusterProxy.GetClient()
 	)
 	// Collect the info for each VM / Machine.
 	machines, err := getMachinesInCluster(ctx, mgmtClusterClient, namespace, clusterName)
@@ -329,10 +356,16 @@ func getClusterSSHInfo(ctx context.Context, mgmtClusterProxy framework.ClusterPr
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get cluster from metadata")
 		}
+		am, err := getAzureMachine(ctx, mgmtClusterClient, m)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get azure machine")
+		}
+		isWindows := isAzureMachineWindows(am)
 		sshInfo = append(sshInfo, nodeSSHInfo{
-			Endpoint: cluster.Spec.ControlPlaneEndpoint.Host,
-			Hostname: m.Spec.InfrastructureRef.Name,
+			Endpoint: getSSHEndpoint(cluster),
+			Hostname: getHostname(m, isWindows),
 			Port:     sshPort,
+			Windows:  isWindows,
 		})
 	}
 
@@ -341,7 +374,13 @@ func getClusterSSHInfo(ctx context.Context, mgmtClusterProxy framework.ClusterPr
 	if err != nil {
 		return sshInfo, errors.Wrap(err, "failed to find machine pools in cluster")
 	}
+	if len(machinePools.Items) == 0 {
+		return sshInfo, nil
+	}
 
+	// The API server of the workload cluster is only needed for machine pools, as it can't be reached for private
+	// clusters.
+	workloadClusterClient := mgmtClusterProxy.GetWorkloadCluster(ctx, namespace, clusterName).GetClient()
 	for i := range machinePools.Items {
 		p := &machinePools.Items[i]
 		cluster, err := util.GetClusterFromMetadata(ctx, mgmtClusterClient, p.ObjectMeta)
@@ -349,6 +388,16 @@ func getClusterSSHInfo(ctx context.Context, mgmtClusterProxy framework.ClusterPr
 			return sshInfo, errors.Wrap(err, "failed to get cluster from metadata")
 		}
 
+		// Machine pools can be AzureManagedMachinePools for AKS clusters, which are reached as Linux nodes.
+		var isWindows bool
+		amp, err := getAzureMachinePool(ctx, mgmtClusterClient, p)
+		switch {
+		case err == nil:
+			isWindows = isAzureMachinePoolWindows(amp)
+		case !apierrors.IsNotFound(err):
+			return sshInfo, errors.Wrap(err, "failed to get azure machine pool")
+		}
+
 		nodes, err := getReadyNodes(ctx, workloadClusterClient, p.Status.NodeRefs)
 		if err != nil {
 			return sshInfo, errors.Wrap(err, "failed to get ready nodes")
@@ -362,9 +411,10 @@ func getClusterSSHInfo(ctx context.Context, mgmtClusterProxy framework.ClusterPr
 
 		for _, node := range nodes {
 			sshInfo = append(sshInfo, nodeSSHInfo{
-				Endpoint: cluster.Spec.ControlPlaneEndpoint.Host,
+				Endpoint: getSSHEndpoint(cluster),
 				Hostname: node.Name,
 				Port:     sshPort,
+				Windows:  isWindows,
 			})
 		}
 	}
@@ -433,24 +483,24 @@ func getMachinePoolsInCluster(ctx context.Context, c framework.Lister, namespace
 }
 
 // execOnHost runs the specified command directly on a node's host, using an SSH connection
-// proxied through a control plane host.
-func execOnHost(controlPlaneEndpoint, hostname, port string, f io.StringWriter, command string,
+// proxied through a control plane host, or the jump host of a private cluster, see getSSHEndpoint.
+func execOnHost(sshEndpoint, hostname, port string, f io.StringWriter, command string,
 	args ...string) error {
 	config, err := newSSHConfig()
 	if err != nil {
 		return err
 	}
 
-	// Init a client connection to a control plane node via the public load balancer
-	lbClient, err := ssh.Dial("tcp", fmt.Sprintf("%s:%s", controlPlaneEndpoint, port), config)
+	// Init a client connection to a control plane node via the public load balancer, or to the jump host
+	lbClient, err := ssh.Dial("tcp", fmt.Sprintf("%s:%s", sshEndpoint, port), config)
 	if err != nil {
-		return errors.Wrapf(err, "dialing public load balancer at %s", controlPlaneEndpoint)
+		return errors.Wrapf(err, "dialing SSH endpoint at %s", sshEndpoint)
 	}
 
-	// Init a connection from the control plane to the target node
+	// Init a connection from the control plane or jump host to the target node
 	c, err := lbClient.Dial("tcp", fmt.Sprintf("%s:%s", hostname, port))
 	if err != nil {
-		return errors.Wrapf(err, "dialing from control plane to target node at %s", hostname)
+		return errors.Wrapf(err, "dialing from %s to target node at %s", sshEndpoint, hostname)
 	}
 
 	// Establish an authenticated SSH conn over the client -> control plane -> target transport
@@ -0,0 +1,338 @@
+// +build e2e
+
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	capi_e2e "sigs.k8s.io/cluster-api/test/e2e"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+	"sigs.k8s.io/cluster-api/util"
+)
+
+const (
+	// ScaleClusterCount is the number of workload clusters the scale spec creates concurrently.
+	ScaleClusterCount = "SCALE_CLUSTER_COUNT"
+
+	// controllerNamespace is the namespace the CAPZ controller is deployed to.
+	controllerNamespace = "capz-system"
+	// controllerMetricsPort is the port the CAPZ controller serves its metrics on, on the loopback interface of its
+	// pod only, as kube-rbac-proxy serves them outside of it.
+	controllerMetricsPort = 8080
+)
+
+var _ = Describe("Scale Tests", func() {
+	var (
+		ctx           = context.TODO()
+		specName      = "scale-tests"
+		namespace     *corev1.Namespace
+		cancelWatches context.CancelFunc
+		results       []*clusterctl.ApplyClusterTemplateAndWaitResult
+	)
+
+	BeforeEach(func() {
+		Expect(ctx).NotTo(BeNil(), "ctx is required for %s spec", specName)
+		Expect(e2eConfig).ToNot(BeNil(), "Invalid argument. e2eConfig can't be nil when calling %s spec", specName)
+		Expect(clusterctlConfigPath).To(BeAnExistingFile(), "Invalid argument. clusterctlConfigPath must be an existing file when calling %s spec", specName)
+		Expect(bootstrapClusterProxy).ToNot(BeNil(), "Invalid argument. bootstrapClusterProxy can't be nil when calling %s spec", specName)
+		Expect(os.MkdirAll(artifactFolder, 0755)).To(Succeed(), "Invalid argument. artifactFolder can't be created for %s spec", specName)
+
+		Expect(e2eConfig.Variables).To(HaveKey(capi_e2e.KubernetesVersion))
+		Expect(e2eConfig.Variables).To(HaveKey(ScaleClusterCount))
+
+		// Setup a Namespace where to host objects for this spec and create a watcher for the namespace events.
+		var err error
+		namespace, cancelWatches, err = setupSpecNamespace(ctx, fmt.Sprintf("capz-scale-%s", util.RandomString(6)), bootstrapClusterProxy, artifactFolder)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Each cluster gets its own resource group and VNet, named after it by the template defaults.
+		Expect(os.Unsetenv(AzureResourceGroup)).NotTo(HaveOccurred())
+		Expect(os.Unsetenv(AzureVNetName)).NotTo(HaveOccurred())
+		results = nil
+	})
+
+	Measure(specName, func(b Benchmarker) {
+		clusterCount, err := strconv.Atoi(e2eConfig.GetVariable(ScaleClusterCount))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clusterCount).To(BeNumerically(">", 0))
+
+		By("scraping the metrics of the controller before creating the clusters")
+		before, err := scrapeControllerMetrics(ctx, bootstrapClusterProxy)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The resident memory of the controller is sampled while the clusters are created, to report its peak.
+		peakMemory := before.residentMemoryBytes
+		sampling, stopSampling := context.WithCancel(ctx)
+		sampled := make(chan struct{})
+		go func() {
+			defer close(sampled)
+			ticker := time.NewTicker(30 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-sampling.Done():
+					return
+				case <-ticker.C:
+					if m, err := scrapeControllerMetrics(sampling, bootstrapClusterProxy); err == nil && m.residentMemoryBytes > peakMemory {
+						peakMemory = m.residentMemoryBytes
+					}
+				}
+			}
+		}()
+
+		Byf("creating %d clusters concurrently", clusterCount)
+		results = make([]*clusterctl.ApplyClusterTemplateAndWaitResult, clusterCount)
+		timesToReady := make([]time.Duration, clusterCount)
+		var wg sync.WaitGroup
+		for i := range results {
+			results[i] = new(clusterctl.ApplyClusterTemplateAndWaitResult)
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				clusterName := fmt.Sprintf("capz-scale-%d-%s", i, util.RandomString(6))
+				start := time.Now()
+				clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
+					ClusterProxy: bootstrapClusterProxy,
+					ConfigCluster: clusterctl.ConfigClusterInput{
+						LogFolder:                filepath.Join(artifactFolder, "clusters", bootstrapClusterProxy.GetName()),
+						ClusterctlConfigPath:     clusterctlConfigPath,
+						KubeconfigPath:           bootstrapClusterProxy.GetKubeconfigPath(),
+						InfrastructureProvider:   clusterctl.DefaultInfrastructureProvider,
+						Flavor:                   clusterctl.DefaultFlavor,
+						Namespace:                namespace.Name,
+						ClusterName:              clusterName,
+						KubernetesVersion:        e2eConfig.GetVariable(capi_e2e.KubernetesVersion),
+						ControlPlaneMachineCount: pointer.Int64Ptr(1),
+						WorkerMachineCount:       pointer.Int64Ptr(1),
+					},
+					WaitForClusterIntervals:      e2eConfig.GetIntervals(specName, "wait-cluster"),
+					WaitForControlPlaneIntervals: e2eConfig.GetIntervals(specName, "wait-control-plane"),
+					WaitForMachineDeployments:    e2eConfig.GetIntervals(specName, "wait-worker-nodes"),
+				}, results[i])
+				timesToReady[i] = time.Since(start)
+				Logf("cluster %s was ready after %s", clusterName, timesToReady[i].Round(time.Second))
+			}(i)
+		}
+		wg.Wait()
+		stopSampling()
+		<-sampled
+
+		By("scraping the metrics of the controller after creating the clusters")
+		after, err := scrapeControllerMetrics(ctx, bootstrapClusterProxy)
+		Expect(err).NotTo(HaveOccurred())
+		if after.residentMemoryBytes > peakMemory {
+			peakMemory = after.residentMemoryBytes
+		}
+
+		properties := []scaleProperty{
+			{Name: "cluster_count", Value: strconv.Itoa(clusterCount)},
+			{Name: "azure_requests", Value: formatFloat(after.requests - before.requests)},
+			{Name: "azure_throttled_responses", Value: formatFloat(after.throttledResponses - before.throttledResponses)},
+			{Name: "controller_cpu_seconds", Value: formatFloat(after.cpuSeconds - before.cpuSeconds)},
+			{Name: "controller_peak_resident_memory_bytes", Value: formatFloat(peakMemory)},
+		}
+		var slowest time.Duration
+		for i, timeToReady := range timesToReady {
+			b.RecordValue("cluster time to ready", timeToReady.Seconds())
+			properties = append(properties, scaleProperty{Name: fmt.Sprintf("cluster_%d_time_to_ready_seconds", i), Value: formatFloat(timeToReady.Seconds())})
+			if timeToReady > slowest {
+				slowest = timeToReady
+			}
+		}
+		properties = append(properties, scaleProperty{Name: "slowest_time_to_ready_seconds", Value: formatFloat(slowest.Seconds())})
+		b.RecordValue("Azure throttled responses", after.throttledResponses-before.throttledResponses)
+		b.RecordValue("controller CPU seconds", after.cpuSeconds-before.cpuSeconds)
+		b.RecordValue("controller peak resident memory bytes", peakMemory)
+
+		By("writing the results to the artifacts folder")
+		Expect(writeScaleResults(filepath.Join(artifactFolder, fmt.Sprintf("junit.%s.xml", specName)), specName, properties)).To(Succeed())
+	}, 1)
+
+	AfterEach(func() {
+		// dumpSpecResourcesAndCleanup dumps the logs of a single cluster before deleting all the clusters of the
+		// namespace, so the logs of the others are dumped first.
+		var cluster *clusterv1.Cluster
+		for _, result := range results {
+			switch {
+			case result.Cluster == nil:
+			case cluster == nil:
+				cluster = result.Cluster
+			default:
+				bootstrapClusterProxy.CollectWorkloadClusterLogs(ctx, result.Cluster.Namespace, result.Cluster.Name, filepath.Join(artifactFolder, "clusters", result.Cluster.Name))
+			}
+		}
+		dumpSpecResourcesAndCleanup(ctx, specName, bootstrapClusterProxy, artifactFolder, namespace, cancelWatches, cluster, e2eConfig.GetIntervals, skipCleanup)
+	})
+})
+
+// controllerMetrics are the metrics of the CAPZ controller the scale spec reports. Counters are totals since the
+// controller started.
+type controllerMetrics struct {
+	requests            float64
+	throttledResponses  float64
+	cpuSeconds          float64
+	residentMemoryBytes float64
+}
+
+// scrapeControllerMetrics scrapes the metrics of the CAPZ controller through a port forward to its pod.
+func scrapeControllerMetrics(ctx context.Context, clusterProxy framework.ClusterProxy) (controllerMetrics, error) {
+	clientset := clusterProxy.GetClientSet()
+	pods, err := clientset.CoreV1().Pods(controllerNamespace).List(ctx, metav1.ListOptions{LabelSelector: "control-plane=capz-controller-manager"})
+	if err != nil {
+		return controllerMetrics{}, errors.Wrap(err, "failed to list controller pods")
+	}
+	if len(pods.Items) == 0 {
+		return controllerMetrics{}, errors.Errorf("found no controller pod in namespace %s", controllerNamespace)
+	}
+	pod := pods.Items[0]
+
+	transport, upgrader, err := spdy.RoundTripperFor(clusterProxy.GetRESTConfig())
+	if err != nil {
+		return controllerMetrics{}, errors.Wrap(err, "failed to create port forward transport")
+	}
+	url := clientset.CoreV1().RESTClient().Post().Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+	stop, ready := make(chan struct{}), make(chan struct{})
+	defer close(stop)
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", controllerMetricsPort)}, stop, ready, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return controllerMetrics{}, errors.Wrap(err, "failed to create port forward")
+	}
+	forwarded := make(chan error, 1)
+	go func() {
+		forwarded <- forwarder.ForwardPorts()
+	}()
+	select {
+	case <-ready:
+	case err := <-forwarded:
+		return controllerMetrics{}, errors.Wrapf(err, "failed to forward port %d of pod %s", controllerMetricsPort, pod.Name)
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		return controllerMetrics{}, errors.Wrap(err, "failed to get forwarded ports")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/metrics", ports[0].Local), nil)
+	if err != nil {
+		return controllerMetrics{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return controllerMetrics{}, errors.Wrap(err, "failed to scrape controller metrics")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return controllerMetrics{}, errors.Errorf("failed to scrape controller metrics: %s", resp.Status)
+	}
+	return parseControllerMetrics(resp.Body)
+}
+
+// parseControllerMetrics parses metrics in the Prometheus text format, summing the samples of each metric over
+// their labels.
+func parseControllerMetrics(r io.Reader) (controllerMetrics, error) {
+	sums := map[string]float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		name := fields[0]
+		if i := strings.Index(line, "{"); i >= 0 {
+			name = line[:i]
+			fields = strings.Fields(line[strings.LastIndex(line, "}")+1:])
+		} else {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		sums[name] += value
+	}
+	if err := scanner.Err(); err != nil {
+		return controllerMetrics{}, errors.Wrap(err, "failed to read controller metrics")
+	}
+	return controllerMetrics{
+		requests:            sums["capz_azure_requests_total"],
+		throttledResponses:  sums["capz_azure_throttled_responses_total"],
+		cpuSeconds:          sums["process_cpu_seconds_total"],
+		residentMemoryBytes: sums["process_resident_memory_bytes"],
+	}, nil
+}
+
+// scaleProperty is a property of the JUnit test suite the results of the scale spec are written as.
+type scaleProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// writeScaleResults writes the results of the scale spec as the properties of a JUnit test suite, which CI keeps
+// as an artifact to track regressions across runs.
+func writeScaleResults(path, name string, properties []scaleProperty) error {
+	suite := struct {
+		XMLName    xml.Name        `xml:"testsuite"`
+		Name       string          `xml:"name,attr"`
+		Properties []scaleProperty `xml:"properties>property"`
+	}{Name: name, Properties: properties}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(f)
+	encoder.Indent("", "  ")
+	return encoder.Encode(suite)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package capabilities describes the optional features of a build of the provider, so that tooling generating
+// manifests, e.g. for GitOps, can adapt them to the provider running in a management cluster.
+package capabilities
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/component-base/featuregate"
+
+	"sigs.k8s.io/cluster-api-provider-azure/feature"
+	"sigs.k8s.io/cluster-api-provider-azure/version"
+)
+
+// Path is the path the capabilities are served at by the metrics server.
+const Path = "/capabilities"
+
+// Feature is an optional feature of the provider.
+type Feature string
+
+const (
+	// AzureBastion is the Azure Bastion host of AzureClusters.
+	AzureBastion Feature = "AzureBastion"
+	// CustomEnvironments are the clouds with custom endpoints AzureClusters select in spec.azureEnvironment.
+	CustomEnvironments Feature = "CustomEnvironments"
+	// DeletionPolicy is the deletion policy of AzureClusters and AzureMachines.
+	DeletionPolicy Feature = "DeletionPolicy"
+	// DisabledServices are the Azure services AzureClusters opt out of.
+	DisabledServices Feature = "DisabledServices"
+	// DriftDetection is the periodic comparison of the Azure resources of AzureClusters to their specs.
+	DriftDetection Feature = "DriftDetection"
+	// EncryptionAtHost is the host encryption of the VMs of AzureMachines and AzureMachinePools.
+	EncryptionAtHost Feature = "EncryptionAtHost"
+	// FailureDomainOverrides are the excluded and renamed failure domains of AzureClusters.
+	FailureDomainOverrides Feature = "FailureDomainOverrides"
+	// ImageValidation is the check that the images of AzureMachines exist at admission.
+	ImageValidation Feature = "ImageValidation"
+	// PrivateClusters are AzureClusters with an internal API server load balancer.
+	PrivateClusters Feature = "PrivateClusters"
+	// ServiceEndpoints are the service endpoints of the subnets of AzureClusters.
+	ServiceEndpoints Feature = "ServiceEndpoints"
+	// SpotVMs are the Spot VMs of AzureMachines and AzureMachinePools.
+	SpotVMs Feature = "SpotVMs"
+	// TagPolicy is the tag policy enforced on additionalTags at admission.
+	TagPolicy Feature = "TagPolicy"
+	// Tracing is the export of traces to a Jaeger agent.
+	Tracing Feature = "Tracing"
+	// VMSizeValidation is the validation of the VM sizes of AzureMachines at admission.
+	VMSizeValidation Feature = "VMSizeValidation"
+	// WorkloadIdentity is the authentication of AzureClusterIdentities with service account tokens.
+	WorkloadIdentity Feature = "WorkloadIdentity"
+)
+
+var (
+	featuresMu sync.RWMutex
+	// features are the optional features of this build, and whether they are enabled. Features that are always
+	// enabled are listed here, those depending on the configuration of the controller are set at startup.
+	features = map[Feature]bool{
+		AzureBastion:           true,
+		DeletionPolicy:         true,
+		DisabledServices:       true,
+		DriftDetection:         true,
+		EncryptionAtHost:       true,
+		FailureDomainOverrides: true,
+		PrivateClusters:        true,
+		ServiceEndpoints:       true,
+		SpotVMs:                true,
+		WorkloadIdentity:       true,
+		CustomEnvironments:     false,
+		ImageValidation:        false,
+		TagPolicy:              false,
+		Tracing:                false,
+		VMSizeValidation:       false,
+	}
+)
+
+// SetFeature records whether an optional feature is enabled by the configuration of the controller.
+func SetFeature(f Feature, enabled bool) {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	features[f] = enabled
+}
+
+// Capabilities describes the optional features of the provider.
+type Capabilities struct {
+	// Version is the version of the provider.
+	Version string `json:"version"`
+	// APIVersions are the versions of the API groups of the provider, the preferred version first.
+	APIVersions map[string][]string `json:"apiVersions"`
+	// FeatureGates are the feature gates of the provider and of Cluster API, and whether they are enabled.
+	FeatureGates map[string]bool `json:"featureGates"`
+	// Features are the optional features of the provider, and whether they are enabled.
+	Features map[Feature]bool `json:"features"`
+}
+
+// Get returns the capabilities of the provider, with the versions of the given API groups of its scheme.
+func Get(scheme *runtime.Scheme, groups ...string) Capabilities {
+	c := Capabilities{
+		Version:      version.Get().String(),
+		APIVersions:  map[string][]string{},
+		FeatureGates: map[string]bool{},
+		Features:     map[Feature]bool{},
+	}
+	for _, group := range groups {
+		for _, gv := range scheme.PrioritizedVersionsForGroup(group) {
+			c.APIVersions[group] = append(c.APIVersions[group], gv.Version)
+		}
+	}
+	// Known features are described as "Name=true|false (PRERELEASE - default=...)". AllAlpha and AllBeta only toggle
+	// the other gates.
+	for _, known := range feature.MutableGates.KnownFeatures() {
+		gate := featuregate.Feature(strings.SplitN(known, "=", 2)[0])
+		if gate == "AllAlpha" || gate == "AllBeta" {
+			continue
+		}
+		c.FeatureGates[string(gate)] = feature.Gates.Enabled(gate)
+	}
+
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	for f, enabled := range features {
+		c.Features[f] = enabled
+	}
+	return c
+}
+
+// Handler returns a handler serving the capabilities of the provider as JSON.
+func Handler(scheme *runtime.Scheme, groups ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(Get(scheme, groups...)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capabilities
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	infrav1alpha3 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha3"
+	infrav1alpha4 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-azure/feature"
+)
+
+func TestHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(infrav1alpha3.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1alpha4.AddToScheme(scheme)).To(Succeed())
+	g.Expect(scheme.SetVersionPriority(infrav1alpha4.GroupVersion, infrav1alpha3.GroupVersion)).To(Succeed())
+
+	SetFeature(TagPolicy, true)
+	defer SetFeature(TagPolicy, false)
+
+	handler := Handler(scheme, infrav1alpha4.GroupVersion.Group)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+	var c Capabilities
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &c)).To(Succeed())
+	g.Expect(c.APIVersions).To(Equal(map[string][]string{infrav1alpha4.GroupVersion.Group: {"v1alpha4", "v1alpha3"}}))
+	g.Expect(c.FeatureGates).To(HaveKeyWithValue(string(feature.AKS), false))
+	g.Expect(c.FeatureGates).NotTo(HaveKey("AllAlpha"))
+	g.Expect(c.Features).To(HaveKeyWithValue(TagPolicy, true))
+	g.Expect(c.Features).To(HaveKeyWithValue(VMSizeValidation, false))
+	g.Expect(c.Features).To(HaveKeyWithValue(AzureBastion, true))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, Path, nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"time"
 )
 
@@ -25,8 +26,198 @@ const (
 	DefaultLoopTimeout = 90 * time.Minute
 	// DefaultMappingTimeout is the default timeout for a controller request mapping func.
 	DefaultMappingTimeout = 60 * time.Second
+	// DefaultAzureServiceReconcileTimeout is the default timeout for reconciling or deleting the resources of an Azure service.
+	DefaultAzureServiceReconcileTimeout = 30 * time.Minute
+	// DefaultAzureCallTimeout is the default timeout for a long running operation of Azure Resource Manager to complete.
+	DefaultAzureCallTimeout = 15 * time.Minute
+	// DefaultLongRunningOperationMaxAge is the default age after which a long running operation of Azure Resource
+	// Manager stored on an object is dropped, rather than being waited for any longer.
+	DefaultLongRunningOperationMaxAge = 6 * time.Hour
+	// DefaultLongRunningOperationWarningAge is the default age after which a long running operation of Azure Resource
+	// Manager stored on an object is reported as stuck.
+	DefaultLongRunningOperationWarningAge = time.Hour
+	// DefaultErrorBackoff is the default delay before an object is reconciled again after its reconciliation failed,
+	// doubled for every further consecutive failure.
+	DefaultErrorBackoff = 10 * time.Second
+	// DefaultMaxErrorBackoff is the default maximum delay before an object whose reconciliation keeps failing is
+	// reconciled again.
+	DefaultMaxErrorBackoff = 30 * time.Minute
+	// DefaultDegradedAfterFailures is the default number of consecutive failed reconciliations after which an object
+	// is marked as degraded.
+	DefaultDegradedAfterFailures = 5
+	// DefaultSpecResyncPeriod is the default period after which the Azure resources of a service are reconciled again
+	// even though their spec didn't change. Zero reconciles them every time, so skipping services in sync is opt-in.
+	DefaultSpecResyncPeriod time.Duration = 0
+	// DefaultSummaryEventInterval is the default minimum interval between the events summarizing the reconciliation of
+	// the services of a cluster.
+	DefaultSummaryEventInterval = 10 * time.Minute
 )
 
+var (
+	azureServiceReconcileTimeout = DefaultAzureServiceReconcileTimeout
+	azureCallTimeout             = DefaultAzureCallTimeout
+	longRunningOperationMaxAge   = DefaultLongRunningOperationMaxAge
+	longRunningOperationWarnAge  = DefaultLongRunningOperationWarningAge
+	errorBackoff                 = DefaultErrorBackoff
+	maxErrorBackoff              = DefaultMaxErrorBackoff
+	degradedAfterFailures        = DefaultDegradedAfterFailures
+	specResyncPeriod             = DefaultSpecResyncPeriod
+	summaryEventInterval         = DefaultSummaryEventInterval
+)
+
+type (
+	azureServiceReconcileTimeoutKey struct{}
+	azureCallTimeoutKey             struct{}
+)
+
+// SetAzureTimeouts replaces the defaults of the Azure service reconcile and call timeouts, e.g. from flags.
+// Zero-valued timeouts keep their defaults.
+func SetAzureTimeouts(serviceReconcileTimeout, callTimeout time.Duration) {
+	azureServiceReconcileTimeout = DefaultAzureServiceReconcileTimeout
+	if serviceReconcileTimeout > 0 {
+		azureServiceReconcileTimeout = serviceReconcileTimeout
+	}
+	azureCallTimeout = DefaultAzureCallTimeout
+	if callTimeout > 0 {
+		azureCallTimeout = callTimeout
+	}
+}
+
+// SetLongRunningOperationMaxAge replaces the default of the age after which long running operations are dropped, e.g.
+// from flags. A zero-valued age keeps the default.
+func SetLongRunningOperationMaxAge(maxAge time.Duration) {
+	longRunningOperationMaxAge = DefaultLongRunningOperationMaxAge
+	if maxAge > 0 {
+		longRunningOperationMaxAge = maxAge
+	}
+}
+
+// LongRunningOperationMaxAge returns the age after which long running operations are dropped.
+func LongRunningOperationMaxAge() time.Duration {
+	return longRunningOperationMaxAge
+}
+
+// SetLongRunningOperationWarningAge replaces the default of the age after which long running operations are reported
+// as stuck, e.g. from flags. A zero-valued age keeps the default.
+func SetLongRunningOperationWarningAge(warningAge time.Duration) {
+	longRunningOperationWarnAge = DefaultLongRunningOperationWarningAge
+	if warningAge > 0 {
+		longRunningOperationWarnAge = warningAge
+	}
+}
+
+// LongRunningOperationWarningAge returns the age after which long running operations are reported as stuck.
+func LongRunningOperationWarningAge() time.Duration {
+	return longRunningOperationWarnAge
+}
+
+// SetErrorBackoff replaces the defaults of the backoff of objects whose reconciliation fails, e.g. from flags.
+// Zero-valued settings keep their defaults.
+func SetErrorBackoff(backoff, maxBackoff time.Duration, degradedAfter int) {
+	errorBackoff = DefaultErrorBackoff
+	if backoff > 0 {
+		errorBackoff = backoff
+	}
+	maxErrorBackoff = DefaultMaxErrorBackoff
+	if maxBackoff > 0 {
+		maxErrorBackoff = maxBackoff
+	}
+	degradedAfterFailures = DefaultDegradedAfterFailures
+	if degradedAfter > 0 {
+		degradedAfterFailures = degradedAfter
+	}
+}
+
+// ErrorBackoff returns the delay before an object is reconciled again after the given number of consecutive failed
+// reconciliations.
+func ErrorBackoff(failures int) time.Duration {
+	backoff := errorBackoff
+	for i := 1; i < failures && backoff < maxErrorBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxErrorBackoff {
+		return maxErrorBackoff
+	}
+	return backoff
+}
+
+// DegradedAfterFailures returns the number of consecutive failed reconciliations after which an object is marked as
+// degraded.
+func DegradedAfterFailures() int {
+	return degradedAfterFailures
+}
+
+// SetSpecResyncPeriod replaces the default of the period after which the Azure resources of a service whose spec
+// didn't change are reconciled again, e.g. from flags. A zero-valued period reconciles them every time.
+func SetSpecResyncPeriod(period time.Duration) {
+	specResyncPeriod = period
+}
+
+// SpecResyncPeriod returns the period after which the Azure resources of a service whose spec didn't change are
+// reconciled again, or zero if they are reconciled every time.
+func SpecResyncPeriod() time.Duration {
+	return specResyncPeriod
+}
+
+// SetSummaryEventInterval replaces the default of the minimum interval between the events summarizing the
+// reconciliation of the services of a cluster, e.g. from flags. A zero-valued interval disables these events.
+func SetSummaryEventInterval(interval time.Duration) {
+	summaryEventInterval = interval
+}
+
+// SummaryEventInterval returns the minimum interval between the events summarizing the reconciliation of the services
+// of a cluster, or zero if these events are disabled.
+func SummaryEventInterval() time.Duration {
+	return summaryEventInterval
+}
+
+// DefaultedAzureServiceReconcileTimeout will default the timeout if it is zero-valued.
+func DefaultedAzureServiceReconcileTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return azureServiceReconcileTimeout
+	}
+
+	return timeout
+}
+
+// DefaultedAzureCallTimeout will default the timeout if it is zero-valued.
+func DefaultedAzureCallTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return azureCallTimeout
+	}
+
+	return timeout
+}
+
+// WithAzureServiceReconcileTimeout returns a context in which the reconciliation or deletion of the resources of an
+// Azure service times out after the given duration, or after the default if it is zero-valued.
+func WithAzureServiceReconcileTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, azureServiceReconcileTimeoutKey{}, DefaultedAzureServiceReconcileTimeout(timeout))
+}
+
+// AzureServiceReconcileTimeout returns the timeout for reconciling or deleting the resources of an Azure service in a
+// context.
+func AzureServiceReconcileTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(azureServiceReconcileTimeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return azureServiceReconcileTimeout
+}
+
+// WithAzureCallTimeout returns a context in which long running operations of Azure Resource Manager time out
+// after the given duration, or after the default if it is zero-valued.
+func WithAzureCallTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, azureCallTimeoutKey{}, DefaultedAzureCallTimeout(timeout))
+}
+
+// AzureCallTimeout returns the timeout for long running operations of Azure Resource Manager in a context.
+func AzureCallTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(azureCallTimeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return azureCallTimeout
+}
+
 // DefaultedLoopTimeout will default the timeout if it is zero-valued.
 func DefaultedLoopTimeout(timeout time.Duration) time.Duration {
 	if timeout <= 0 {
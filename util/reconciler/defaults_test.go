@@ -17,6 +17,7 @@ limitations under the License.
 package reconciler_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -57,3 +58,74 @@ func TestDefaultedTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestAzureTimeouts(t *testing.T) {
+	g := gomega.NewWithT(t)
+	defer reconciler.SetAzureTimeouts(0, 0)
+
+	g.Expect(reconciler.DefaultedAzureServiceReconcileTimeout(0)).To(gomega.Equal(reconciler.DefaultAzureServiceReconcileTimeout))
+	g.Expect(reconciler.AzureCallTimeout(context.Background())).To(gomega.Equal(reconciler.DefaultAzureCallTimeout))
+
+	reconciler.SetAzureTimeouts(time.Hour, 20*time.Minute)
+	g.Expect(reconciler.DefaultedAzureServiceReconcileTimeout(0)).To(gomega.Equal(time.Hour))
+	g.Expect(reconciler.DefaultedAzureServiceReconcileTimeout(45 * time.Minute)).To(gomega.Equal(45 * time.Minute))
+	g.Expect(reconciler.AzureCallTimeout(context.Background())).To(gomega.Equal(20 * time.Minute))
+
+	ctx := reconciler.WithAzureCallTimeout(context.Background(), 40*time.Minute)
+	g.Expect(reconciler.AzureCallTimeout(ctx)).To(gomega.Equal(40 * time.Minute))
+	ctx = reconciler.WithAzureCallTimeout(context.Background(), 0)
+	g.Expect(reconciler.AzureCallTimeout(ctx)).To(gomega.Equal(20 * time.Minute))
+
+	ctx = reconciler.WithAzureServiceReconcileTimeout(context.Background(), 2*time.Hour)
+	g.Expect(reconciler.AzureServiceReconcileTimeout(ctx)).To(gomega.Equal(2 * time.Hour))
+	g.Expect(reconciler.AzureServiceReconcileTimeout(context.Background())).To(gomega.Equal(time.Hour))
+}
+
+func TestLongRunningOperationMaxAge(t *testing.T) {
+	g := gomega.NewWithT(t)
+	defer reconciler.SetLongRunningOperationMaxAge(0)
+
+	g.Expect(reconciler.LongRunningOperationMaxAge()).To(gomega.Equal(reconciler.DefaultLongRunningOperationMaxAge))
+	reconciler.SetLongRunningOperationMaxAge(2 * time.Hour)
+	g.Expect(reconciler.LongRunningOperationMaxAge()).To(gomega.Equal(2 * time.Hour))
+	reconciler.SetLongRunningOperationMaxAge(0)
+	g.Expect(reconciler.LongRunningOperationMaxAge()).To(gomega.Equal(reconciler.DefaultLongRunningOperationMaxAge))
+}
+
+func TestSpecResyncPeriod(t *testing.T) {
+	g := gomega.NewWithT(t)
+	defer reconciler.SetSpecResyncPeriod(reconciler.DefaultSpecResyncPeriod)
+
+	g.Expect(reconciler.SpecResyncPeriod()).To(gomega.Equal(reconciler.DefaultSpecResyncPeriod))
+	reconciler.SetSpecResyncPeriod(10 * time.Minute)
+	g.Expect(reconciler.SpecResyncPeriod()).To(gomega.Equal(10 * time.Minute))
+	reconciler.SetSpecResyncPeriod(0)
+	g.Expect(reconciler.SpecResyncPeriod()).To(gomega.BeZero())
+}
+
+func TestSummaryEventInterval(t *testing.T) {
+	g := gomega.NewWithT(t)
+	defer reconciler.SetSummaryEventInterval(reconciler.DefaultSummaryEventInterval)
+
+	g.Expect(reconciler.SummaryEventInterval()).To(gomega.Equal(reconciler.DefaultSummaryEventInterval))
+	reconciler.SetSummaryEventInterval(time.Hour)
+	g.Expect(reconciler.SummaryEventInterval()).To(gomega.Equal(time.Hour))
+	reconciler.SetSummaryEventInterval(0)
+	g.Expect(reconciler.SummaryEventInterval()).To(gomega.BeZero())
+}
+
+func TestErrorBackoff(t *testing.T) {
+	g := gomega.NewWithT(t)
+	defer reconciler.SetErrorBackoff(0, 0, 0)
+
+	g.Expect(reconciler.ErrorBackoff(1)).To(gomega.Equal(reconciler.DefaultErrorBackoff))
+	g.Expect(reconciler.DegradedAfterFailures()).To(gomega.Equal(reconciler.DefaultDegradedAfterFailures))
+
+	reconciler.SetErrorBackoff(time.Minute, 5*time.Minute, 3)
+	g.Expect(reconciler.ErrorBackoff(1)).To(gomega.Equal(time.Minute))
+	g.Expect(reconciler.ErrorBackoff(2)).To(gomega.Equal(2 * time.Minute))
+	g.Expect(reconciler.ErrorBackoff(3)).To(gomega.Equal(4 * time.Minute))
+	g.Expect(reconciler.ErrorBackoff(4)).To(gomega.Equal(5 * time.Minute))
+	g.Expect(reconciler.ErrorBackoff(100)).To(gomega.Equal(5 * time.Minute))
+	g.Expect(reconciler.DegradedAfterFailures()).To(gomega.Equal(3))
+}
@@ -23,6 +23,10 @@ const (
 	NamespaceEnvVarName = "POD_NAMESPACE"
 	// DefaultNamespace is the default value from manifest.
 	DefaultNamespace = "capz-system"
+	// ServiceAccountEnvVarName is the env var coming from DownwardAPI in the manager manifest.
+	ServiceAccountEnvVarName = "POD_SERVICE_ACCOUNT"
+	// DefaultServiceAccount is the default value from manifest.
+	DefaultServiceAccount = "capz-manager"
 )
 
 // GetManagerNamespace returns the namespace where the controller is running.
@@ -33,3 +37,12 @@ func GetManagerNamespace() string {
 	}
 	return managerNamespace
 }
+
+// GetManagerServiceAccount returns the name of the service account the controller is running as.
+func GetManagerServiceAccount() string {
+	serviceAccount := os.Getenv(ServiceAccountEnvVarName)
+	if serviceAccount == "" {
+		serviceAccount = DefaultServiceAccount
+	}
+	return serviceAccount
+}
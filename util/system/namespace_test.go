@@ -51,3 +51,32 @@ func TestGetNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestGetServiceAccount(t *testing.T) {
+	cases := []struct {
+		Name              string
+		PodServiceAccount string
+		Expected          string
+	}{
+		{
+			Name:              "env var set to custom service account",
+			PodServiceAccount: "capz",
+			Expected:          "capz",
+		},
+		{
+			Name:              "env var empty",
+			PodServiceAccount: "",
+			Expected:          "capz-manager",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+			os.Setenv(ServiceAccountEnvVarName, c.PodServiceAccount)
+			defer os.Unsetenv(ServiceAccountEnvVarName)
+			g.Expect(GetManagerServiceAccount()).To(gomega.Equal(c.Expected))
+		})
+	}
+}
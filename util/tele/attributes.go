@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tele
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// The attributes linking spans to the objects reconciled and the Azure resources they manage. All spans started by
+// Tracer carry the attributes of their context, so they can be correlated across controllers, services and requests to
+// Azure Resource Manager.
+const (
+	// KindKey is the kind of the object reconciled, e.g. AzureMachine.
+	KindKey = attribute.Key("kind")
+	// NamespaceKey is the namespace of the object reconciled, which is the namespace of its cluster.
+	NamespaceKey = attribute.Key("namespace")
+	// NameKey is the name of the object reconciled.
+	NameKey = attribute.Key("name")
+	// ClusterKey is the name of the cluster of the object reconciled.
+	ClusterKey = attribute.Key("cluster")
+	// ServiceKey is the name of the Azure service reconciled, e.g. loadbalancers.
+	ServiceKey = attribute.Key("azure.service")
+	// SubscriptionKey is the ID of the subscription of the cluster.
+	SubscriptionKey = attribute.Key("azure.subscription_id")
+	// ResourceGroupKey is the resource group of the cluster.
+	ResourceGroupKey = attribute.Key("azure.resource_group")
+	// ResourceIDKey is the ARM resource ID of the resource a request to Azure Resource Manager is for.
+	ResourceIDKey = attribute.Key("azure.resource_id")
+)
+
+type attributesKey struct{}
+
+// WithAttributes returns a context whose spans carry the given attributes, along with those of the parent context.
+// An attribute overrides the attribute of the parent context with the same key.
+func WithAttributes(ctx context.Context, attributes ...attribute.KeyValue) context.Context {
+	if len(attributes) == 0 {
+		return ctx
+	}
+	parent := AttributesFrom(ctx)
+	merged := make([]attribute.KeyValue, 0, len(parent)+len(attributes))
+	for _, kv := range parent {
+		if !hasKey(attributes, kv.Key) {
+			merged = append(merged, kv)
+		}
+	}
+	for i, kv := range attributes {
+		// The last of the attributes with the same key wins, as it does on spans.
+		if !hasKey(attributes[i+1:], kv.Key) {
+			merged = append(merged, kv)
+		}
+	}
+	return context.WithValue(ctx, attributesKey{}, merged)
+}
+
+// AttributesFrom returns the attributes the spans of a context carry.
+func AttributesFrom(ctx context.Context) []attribute.KeyValue {
+	attributes, _ := ctx.Value(attributesKey{}).([]attribute.KeyValue)
+	return attributes
+}
+
+// ObjectAttributes returns the attributes of an object reconciled.
+func ObjectAttributes(kind, namespace, name string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		KindKey.String(kind),
+		NamespaceKey.String(namespace),
+		NameKey.String(name),
+	}
+}
+
+// ClusterAttributes returns the attributes of the cluster of the object reconciled, and of the subscription and
+// resource group its Azure resources are in. Empty values are left out.
+func ClusterAttributes(cluster, subscriptionID, resourceGroup string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+	for _, kv := range []attribute.KeyValue{
+		ClusterKey.String(cluster),
+		SubscriptionKey.String(subscriptionID),
+		ResourceGroupKey.String(resourceGroup),
+	} {
+		if kv.Value.AsString() != "" {
+			attributes = append(attributes, kv)
+		}
+	}
+	return attributes
+}
+
+func hasKey(attributes []attribute.KeyValue, key attribute.Key) bool {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
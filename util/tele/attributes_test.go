@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tele
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithAttributes(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := WithAttributes(context.Background(), ObjectAttributes("AzureMachine", "default", "my-machine")...)
+	ctx = WithAttributes(ctx, ClusterAttributes("my-cluster", "my-subscription", "")...)
+	ctx = WithAttributes(ctx, ServiceKey.String("publicips"))
+	// The service of a nested context overrides the one of its parent.
+	nested := WithAttributes(ctx, ServiceKey.String("loadbalancers"))
+
+	g.Expect(AttributesFrom(context.Background())).To(BeEmpty())
+	g.Expect(AttributesFrom(ctx)).To(Equal([]attribute.KeyValue{
+		KindKey.String("AzureMachine"),
+		NamespaceKey.String("default"),
+		NameKey.String("my-machine"),
+		ClusterKey.String("my-cluster"),
+		SubscriptionKey.String("my-subscription"),
+		ServiceKey.String("publicips"),
+	}))
+	g.Expect(AttributesFrom(nested)).To(ContainElement(ServiceKey.String("loadbalancers")))
+	g.Expect(AttributesFrom(nested)).NotTo(ContainElement(ServiceKey.String("publicips")))
+}
+
+func TestTracerAddsContextAttributes(t *testing.T) {
+	g := NewWithT(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exporter))
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	ctx := WithAttributes(context.Background(), ObjectAttributes("AzureCluster", "default", "my-cluster")...)
+	_, span := Tracer().Start(ctx, "test", trace.WithAttributes(NameKey.String("overridden")))
+	span.End()
+
+	spans := exporter.GetSpans()
+	g.Expect(spans).To(HaveLen(1))
+	attributes := attribute.NewSet(spans[0].Attributes...)
+	value, _ := attributes.Value(KindKey)
+	g.Expect(value.AsString()).To(Equal("AzureCluster"))
+	// The attributes of the options of a span take precedence over those of its context.
+	value, _ = attributes.Value(NameKey)
+	g.Expect(value.AsString()).To(Equal("overridden"))
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tele
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/exporters/trace/jaeger"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// DefaultJaegerEndpoint is the collector endpoint of a Jaeger agent running as a sidecar to the controller.
+const DefaultJaegerEndpoint = "http://localhost:5778/api/traces"
+
+// ExporterOptions configures where the traces of the controller are exported to.
+type ExporterOptions struct {
+	// OTLPEndpoint is the host:port of an OpenTelemetry collector, e.g. Tempo or Jaeger, receiving traces over
+	// OTLP/gRPC. If empty, traces are exported to the Jaeger agent at DefaultJaegerEndpoint.
+	OTLPEndpoint string
+
+	// OTLPInsecure disables TLS for the connection to the OTLP endpoint.
+	OTLPInsecure bool
+
+	// SamplingRatio is the ratio of the traces started by the controller which are sampled, from 0 to 1. Spans
+	// which failed are exported even if their trace isn't sampled.
+	SamplingRatio float64
+
+	// NoisySpans are the names of high-frequency spans of little value, e.g. DefaultNoisySpans, which are sampled
+	// again within sampled traces. Names ending with "*" are prefixes.
+	NoisySpans []string
+
+	// NoisySpanSamplingRatio is the ratio of the noisy spans of sampled traces which are sampled, from 0 to 1. Noisy
+	// spans which failed are always exported.
+	NoisySpanSamplingRatio float64
+
+	// ResourceAttributes are added to the resource of all traces, e.g. the name of the management cluster. They
+	// may override the service name, "capz" by default.
+	ResourceAttributes map[string]string
+}
+
+// Validate validates the exporter options.
+func (o ExporterOptions) Validate() error {
+	if o.SamplingRatio < 0 || o.SamplingRatio > 1 {
+		return errors.Errorf("sampling ratio %v must be between 0 and 1", o.SamplingRatio)
+	}
+	if o.NoisySpanSamplingRatio < 0 || o.NoisySpanSamplingRatio > 1 {
+		return errors.Errorf("noisy span sampling ratio %v must be between 0 and 1", o.NoisySpanSamplingRatio)
+	}
+	return nil
+}
+
+// NewTracerProvider returns a tracer provider exporting traces as configured by the options.
+func NewTracerProvider(ctx context.Context, options ExporterOptions) (*tracesdk.TracerProvider, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	exporterName := "jaeger"
+	var exporter tracesdk.SpanExporter
+	if options.OTLPEndpoint != "" {
+		exporterName = "otlp"
+		driverOptions := []otlpgrpc.Option{otlpgrpc.WithEndpoint(options.OTLPEndpoint)}
+		if options.OTLPInsecure {
+			driverOptions = append(driverOptions, otlpgrpc.WithInsecure())
+		}
+		exp, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(driverOptions...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create OTLP exporter for %s", options.OTLPEndpoint)
+		}
+		exporter = exp
+	} else {
+		exp, err := jaeger.NewRawExporter(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(DefaultJaegerEndpoint)))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create Jaeger exporter")
+		}
+		exporter = exp
+	}
+
+	return tracesdk.NewTracerProvider(
+		// Always be sure to batch in production.
+		tracesdk.WithSpanProcessor(newSamplingProcessor(tracesdk.NewBatchSpanProcessor(exporter), options.SamplingRatio, options.NoisySpanSamplingRatio, options.NoisySpans)),
+		// All spans are recorded so the ones which failed can be exported whether their trace is sampled or not. The
+		// sampling processor decides which spans are exported once they ended.
+		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
+		// Record information about this application in an Resource.
+		tracesdk.WithResource(newResource(exporterName, options.ResourceAttributes)),
+	), nil
+}
+
+// newResource returns the resource of the traces of the controller. The given attributes take precedence over the
+// default ones.
+func newResource(exporterName string, attributes map[string]string) *resource.Resource {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	custom := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		custom = append(custom, attribute.String(key, attributes[key]))
+	}
+	return resource.Merge(
+		resource.NewWithAttributes(
+			semconv.ServiceNameKey.String("capz"),
+			attribute.String("exporter", exporterName),
+		),
+		resource.NewWithAttributes(custom...),
+	)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tele
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := NewTracerProvider(context.Background(), ExporterOptions{SamplingRatio: 1.5})
+	g.Expect(err).To(MatchError(ContainSubstring("must be between 0 and 1")))
+	_, err = NewTracerProvider(context.Background(), ExporterOptions{SamplingRatio: 1, NoisySpanSamplingRatio: -0.5})
+	g.Expect(err).To(MatchError(ContainSubstring("noisy span sampling ratio")))
+
+	tp, err := NewTracerProvider(context.Background(), ExporterOptions{OTLPEndpoint: "localhost:4317", OTLPInsecure: true, SamplingRatio: 0.5})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tp.Shutdown(context.Background())).To(Succeed())
+}
+
+func TestNewResource(t *testing.T) {
+	g := NewWithT(t)
+
+	attrs := newResource("otlp", map[string]string{"management_cluster": "my-mc", "service.name": "capz-mc"}).Set()
+	value, ok := attrs.Value(semconv.ServiceNameKey)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(value.AsString()).To(Equal("capz-mc"))
+	value, ok = attrs.Value(attribute.Key("management_cluster"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(value.AsString()).To(Equal("my-mc"))
+	value, ok = attrs.Value(attribute.Key("exporter"))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(value.AsString()).To(Equal("otlp"))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tele
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// DefaultNoisySpans are the spans started for each poll of a long running operation of Azure Resource Manager, which
+// make up most of the spans of the controller while resources are created or deleted.
+var DefaultNoisySpans = []string{
+	"github.com/Azure/go-autorest/autorest/azure/async.DoneWithContext",
+	PollSpanName,
+}
+
+// PollSpanName is the name of the spans of the requests polling the status of long running operations of Azure
+// Resource Manager.
+const PollSpanName = "azure.Poll"
+
+// samplingProcessor decides which ended spans are passed on to be exported. All spans are recorded, so a span which
+// failed is exported even if its trace isn't sampled; the others are exported if their trace is sampled and, for
+// noisy spans, if the span itself is sampled as well.
+type samplingProcessor struct {
+	tracesdk.SpanProcessor
+	traceBound uint64
+	noisyBound uint64
+	noisy      []string
+}
+
+// newSamplingProcessor returns a processor passing the spans to export on to next. The ratio of traces sampled is
+// samplingRatio, and the ratio of the spans whose name is in noisy which are sampled in those is noisyRatio. Names
+// ending with "*" are prefixes.
+func newSamplingProcessor(next tracesdk.SpanProcessor, samplingRatio, noisyRatio float64, noisy []string) *samplingProcessor {
+	return &samplingProcessor{
+		SpanProcessor: next,
+		traceBound:    ratioBound(samplingRatio),
+		noisyBound:    ratioBound(noisyRatio),
+		noisy:         noisy,
+	}
+}
+
+// OnStart does nothing, as spans are only passed on once they ended and whether they failed is known.
+func (p *samplingProcessor) OnStart(context.Context, tracesdk.ReadWriteSpan) {}
+
+// OnEnd passes the span on to be exported if it failed or is sampled.
+func (p *samplingProcessor) OnEnd(s tracesdk.ReadOnlySpan) {
+	if s.Status().Code == codes.Error {
+		p.SpanProcessor.OnEnd(s)
+		return
+	}
+	traceID := s.SpanContext().TraceID()
+	if !sampled(traceID[8:], p.traceBound) {
+		return
+	}
+	if p.isNoisy(s.Name()) {
+		spanID := s.SpanContext().SpanID()
+		if !sampled(spanID[:], p.noisyBound) {
+			return
+		}
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// isNoisy returns whether spans with the given name are noisy.
+func (p *samplingProcessor) isNoisy(name string) bool {
+	for _, noisy := range p.noisy {
+		if prefix := strings.TrimSuffix(noisy, "*"); prefix != noisy {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == noisy {
+			return true
+		}
+	}
+	return false
+}
+
+// ratioBound returns the bound the random part of an ID must stay under for the given ratio of IDs to be sampled.
+func ratioBound(ratio float64) uint64 {
+	switch {
+	case ratio >= 1:
+		return 1 << 63
+	case ratio <= 0:
+		return 0
+	default:
+		return uint64(ratio * (1 << 63))
+	}
+}
+
+// sampled returns whether an ID, of which at least the 8 given bytes are random, is sampled, the same way
+// TraceIDRatioBased samples traces.
+func sampled(id []byte, bound uint64) bool {
+	return binary.BigEndian.Uint64(id)>>1 < bound
+}
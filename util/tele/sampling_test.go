@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tele
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSamplingProcessor(t *testing.T) {
+	g := NewWithT(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSpanProcessor(newSamplingProcessor(tracesdk.NewSimpleSpanProcessor(exporter), 1, 0, []string{PollSpanName, "github.com/Azure/go-autorest/autorest/azure/async.*"})),
+		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
+	)
+	tracer := tp.Tracer("test")
+
+	ctx, parent := tracer.Start(context.Background(), "controllers.AzureMachineReconciler.Reconcile")
+	for _, name := range []string{PollSpanName, "github.com/Azure/go-autorest/autorest/azure/async.DoneWithContext", "azure.Request"} {
+		_, span := tracer.Start(ctx, name)
+		span.End()
+	}
+	_, failed := tracer.Start(ctx, PollSpanName)
+	failed.RecordError(errors.New("some error happened"))
+	failed.SetStatus(codes.Error, "some error happened")
+	failed.End()
+	parent.End()
+
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+	}
+	// Noisy spans are dropped unless they failed.
+	g.Expect(names).To(Equal([]string{"azure.Request", PollSpanName, "controllers.AzureMachineReconciler.Reconcile"}))
+}
+
+func TestSamplingProcessorKeepsErrorsOfUnsampledTraces(t *testing.T) {
+	g := NewWithT(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSpanProcessor(newSamplingProcessor(tracesdk.NewSimpleSpanProcessor(exporter), 0, 1, nil)),
+		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.AlwaysSample())),
+	)
+	tracer := tp.Tracer("test")
+
+	ctx, parent := tracer.Start(context.Background(), "controllers.AzureClusterReconciler.Reconcile")
+	_, span := tracer.Start(ctx, "azure.Request")
+	span.End()
+	_, failed := tracer.Start(ctx, "azure.Request")
+	failed.SetStatus(codes.Error, "some error happened")
+	failed.End()
+	parent.End()
+
+	spans := exporter.GetSpans()
+	g.Expect(spans).To(HaveLen(1))
+	g.Expect(spans[0].SpanContext.SpanID()).To(Equal(failed.SpanContext().SpanID()))
+}
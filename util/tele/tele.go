@@ -17,11 +17,27 @@ limitations under the License.
 package tele
 
 import (
+	"context"
+
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Tracer returns the default opentelemetry tracer.
+// Tracer returns the default opentelemetry tracer. The spans it starts carry the attributes of their context, see
+// WithAttributes.
 func Tracer() trace.Tracer {
-	return otel.Tracer("capz")
+	return attributesTracer{Tracer: otel.Tracer("capz")}
+}
+
+// attributesTracer is a tracer adding the attributes of the context of spans to them.
+type attributesTracer struct {
+	trace.Tracer
+}
+
+// Start starts a span carrying the attributes of its context, before those of the options.
+func (t attributesTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanOption) (context.Context, trace.Span) {
+	if attributes := AttributesFrom(ctx); len(attributes) > 0 {
+		opts = append([]trace.SpanOption{trace.WithAttributes(attributes...)}, opts...)
+	}
+	return t.Tracer.Start(ctx, spanName, opts...)
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Warner is implemented by API types whose validating webhook returns admission warnings in addition to validation
+// errors, e.g. for fields that are ignored in the configuration of the object. Warnings don't reject the request.
+type Warner interface {
+	admission.Validator
+
+	// WarningsOnCreate returns the warnings for the object when it is created.
+	WarningsOnCreate() []string
+
+	// WarningsOnUpdate returns the warnings for the object when it is updated from old.
+	WarningsOnUpdate(old runtime.Object) []string
+}
+
+// RegisterValidatingWebhookWithWarnings registers the validating webhook of a type at the path the webhook builder
+// generates for it, so that the builder doesn't register its own. It must be called before the builder completes.
+func RegisterValidatingWebhookWithWarnings(mgr ctrl.Manager, warner Warner) error {
+	gvk, err := apiutil.GVKForObject(warner, mgr.GetScheme())
+	if err != nil {
+		return errors.Wrap(err, "failed to get the group version kind of the validated type")
+	}
+	mgr.GetWebhookServer().Register(validatePath(gvk), &admission.Webhook{Handler: NewWarningHandler(warner)})
+	return nil
+}
+
+// validatePath returns the path of the validating webhook of a kind, as generated by the webhook builder.
+func validatePath(gvk schema.GroupVersionKind) string {
+	return "/validate-" + strings.ReplaceAll(gvk.Group, ".", "-") + "-" + gvk.Version + "-" + strings.ToLower(gvk.Kind)
+}
+
+// warningHandler validates objects like the validating handler of controller-runtime, and adds the warnings of the
+// objects to the responses of allowed requests.
+type warningHandler struct {
+	warner    Warner
+	validator admission.Handler
+	decoder   *admission.Decoder
+}
+
+var _ admission.DecoderInjector = &warningHandler{}
+
+// NewWarningHandler returns an admission handler validating objects of the type of warner and returning their
+// warnings.
+func NewWarningHandler(warner Warner) admission.Handler {
+	return &warningHandler{
+		warner:    warner,
+		validator: admission.ValidatingWebhookFor(warner).Handler,
+	}
+}
+
+// InjectDecoder injects the decoder into the handler and the validating handler it wraps.
+func (h *warningHandler) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	_, err := admission.InjectDecoderInto(d, h.validator)
+	return err
+}
+
+// Handle validates the object of the request and adds its warnings to the response if it is allowed.
+func (h *warningHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := h.validator.Handle(ctx, req)
+	if !resp.Allowed {
+		return resp
+	}
+
+	obj := h.warner.DeepCopyObject().(Warner)
+	switch req.Operation {
+	case admissionv1.Create:
+		if err := h.decoder.DecodeRaw(req.Object, obj); err != nil {
+			return resp
+		}
+		return resp.WithWarnings(obj.WarningsOnCreate()...)
+	case admissionv1.Update:
+		old := h.warner.DeepCopyObject()
+		if err := h.decoder.DecodeRaw(req.Object, obj); err != nil {
+			return resp
+		}
+		if err := h.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return resp
+		}
+		return resp.WithWarnings(obj.WarningsOnUpdate(old)...)
+	default:
+		return resp
+	}
+}
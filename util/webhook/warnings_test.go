@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var fakeGroupVersion = schema.GroupVersion{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha4"}
+
+type fakeObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Invalid bool   `json:"invalid,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+func (f *fakeObject) DeepCopyObject() runtime.Object {
+	out := *f
+	return &out
+}
+
+func (f *fakeObject) ValidateCreate() error {
+	if f.Invalid {
+		return errors.New("invalid object")
+	}
+	return nil
+}
+
+func (f *fakeObject) ValidateUpdate(_ runtime.Object) error {
+	return f.ValidateCreate()
+}
+
+func (f *fakeObject) ValidateDelete() error {
+	return nil
+}
+
+func (f *fakeObject) WarningsOnCreate() []string {
+	if f.Warning == "" {
+		return nil
+	}
+	return []string{f.Warning}
+}
+
+func (f *fakeObject) WarningsOnUpdate(old runtime.Object) []string {
+	if f.Warning == old.(*fakeObject).Warning {
+		return nil
+	}
+	return f.WarningsOnCreate()
+}
+
+func TestWarningHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(fakeGroupVersion.WithKind("FakeObject"), &fakeObject{})
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := func(obj *fakeObject) runtime.RawExtension {
+		obj.APIVersion = fakeGroupVersion.String()
+		obj.Kind = "FakeObject"
+		data, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtime.RawExtension{Raw: data}
+	}
+
+	tests := []struct {
+		name         string
+		operation    admissionv1.Operation
+		object       *fakeObject
+		oldObject    *fakeObject
+		wantAllowed  bool
+		wantWarnings []string
+	}{
+		{
+			name:        "create without warnings",
+			operation:   admissionv1.Create,
+			object:      &fakeObject{},
+			wantAllowed: true,
+		},
+		{
+			name:         "create with warnings",
+			operation:    admissionv1.Create,
+			object:       &fakeObject{Warning: "spec.field: ignored"},
+			wantAllowed:  true,
+			wantWarnings: []string{"spec.field: ignored"},
+		},
+		{
+			name:      "denied create without warnings",
+			operation: admissionv1.Create,
+			object:    &fakeObject{Invalid: true, Warning: "spec.field: ignored"},
+		},
+		{
+			name:         "update with warnings",
+			operation:    admissionv1.Update,
+			object:       &fakeObject{Warning: "spec.field: ignored"},
+			oldObject:    &fakeObject{},
+			wantAllowed:  true,
+			wantWarnings: []string{"spec.field: ignored"},
+		},
+		{
+			name:        "update without changes",
+			operation:   admissionv1.Update,
+			object:      &fakeObject{Warning: "spec.field: ignored"},
+			oldObject:   &fakeObject{Warning: "spec.field: ignored"},
+			wantAllowed: true,
+		},
+		{
+			name:        "delete",
+			operation:   admissionv1.Delete,
+			oldObject:   &fakeObject{Warning: "spec.field: ignored"},
+			wantAllowed: true,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			handler := NewWarningHandler(&fakeObject{})
+			_, err := admission.InjectDecoderInto(decoder, handler)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: tc.operation}}
+			if tc.object != nil {
+				req.Object = raw(tc.object)
+			}
+			if tc.oldObject != nil {
+				req.OldObject = raw(tc.oldObject)
+			}
+
+			resp := handler.Handle(context.Background(), req)
+			g.Expect(resp.Allowed).To(Equal(tc.wantAllowed))
+			g.Expect(resp.Warnings).To(Equal(tc.wantWarnings))
+		})
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validatePath(fakeGroupVersion.WithKind("AzureCluster"))).To(Equal("/validate-infrastructure-cluster-x-k8s-io-v1alpha4-azurecluster"))
+}